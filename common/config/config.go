@@ -114,6 +114,31 @@ var HECO120_HEIGHT = map[uint32]uint64{
 	NETWORK_ID_TEST_NET: constants.HECO120_HEIGHT_TESTNET,
 }
 
+var BTC_HEADER_CHECK_HEIGHT = map[uint32]uint32{
+	NETWORK_ID_MAIN_NET: constants.BTC_HEADER_CHECK_HEIGHT_MAINNET,
+	NETWORK_ID_TEST_NET: constants.BTC_HEADER_CHECK_HEIGHT_TESTNET,
+}
+
+var CROSS_CHAIN_REQUEST_INDEX_HEIGHT = map[uint32]uint32{
+	NETWORK_ID_MAIN_NET: constants.CROSS_CHAIN_REQUEST_INDEX_HEIGHT_MAINNET,
+	NETWORK_ID_TEST_NET: constants.CROSS_CHAIN_REQUEST_INDEX_HEIGHT_TESTNET,
+}
+
+var RELAYER_REWARD_HEIGHT = map[uint32]uint32{
+	NETWORK_ID_MAIN_NET: constants.RELAYER_REWARD_HEIGHT_MAINNET,
+	NETWORK_ID_TEST_NET: constants.RELAYER_REWARD_HEIGHT_TESTNET,
+}
+
+var VOTE_CONFIG_HEIGHT = map[uint32]uint32{
+	NETWORK_ID_MAIN_NET: constants.VOTE_CONFIG_HEIGHT_MAINNET,
+	NETWORK_ID_TEST_NET: constants.VOTE_CONFIG_HEIGHT_TESTNET,
+}
+
+var REORG_RECORD_HEIGHT = map[uint32]uint32{
+	NETWORK_ID_MAIN_NET: constants.REORG_RECORD_HEIGHT_MAINNET,
+	NETWORK_ID_TEST_NET: constants.REORG_RECORD_HEIGHT_TESTNET,
+}
+
 var POLYGON_SNAP_CHAINID = map[uint32]uint32{
 	NETWORK_ID_MAIN_NET: constants.POLYGON_SNAP_CHAINID_MAINNET,
 }
@@ -155,6 +180,26 @@ func GetExtraInfoHeight(id uint32) uint32 {
 	return EXTRA_INFO_HEIGHT[id]
 }
 
+func GetBtcHeaderCheckHeight(id uint32) uint32 {
+	return BTC_HEADER_CHECK_HEIGHT[id]
+}
+
+func GetCrossChainRequestIndexHeight(id uint32) uint32 {
+	return CROSS_CHAIN_REQUEST_INDEX_HEIGHT[id]
+}
+
+func GetRelayerRewardHeight(id uint32) uint32 {
+	return RELAYER_REWARD_HEIGHT[id]
+}
+
+func GetVoteConfigHeight(id uint32) uint32 {
+	return VOTE_CONFIG_HEIGHT[id]
+}
+
+func GetReorgRecordHeight(id uint32) uint32 {
+	return REORG_RECORD_HEIGHT[id]
+}
+
 func GetNetworkName(id uint32) string {
 	name, ok := NETWORK_NAME[id]
 	if ok {
@@ -52,4 +52,24 @@ const ETH1559_HEIGHT_TESTNET = 10499401
 const HECO120_HEIGHT_MAINNET = 8577000
 const HECO120_HEIGHT_TESTNET = 8290000
 
+// btc header check height, not scheduled on main net and test net yet
+const BTC_HEADER_CHECK_HEIGHT_MAINNET = 1<<32 - 1
+const BTC_HEADER_CHECK_HEIGHT_TESTNET = 1<<32 - 1
+
+// cross chain request index height, not scheduled on main net and test net yet
+const CROSS_CHAIN_REQUEST_INDEX_HEIGHT_MAINNET = 1<<32 - 1
+const CROSS_CHAIN_REQUEST_INDEX_HEIGHT_TESTNET = 1<<32 - 1
+
+// relayer reward height, not scheduled on main net and test net yet
+const RELAYER_REWARD_HEIGHT_MAINNET = 1<<32 - 1
+const RELAYER_REWARD_HEIGHT_TESTNET = 1<<32 - 1
+
+// vote configuration height, not scheduled on main net and test net yet
+const VOTE_CONFIG_HEIGHT_MAINNET = 1<<32 - 1
+const VOTE_CONFIG_HEIGHT_TESTNET = 1<<32 - 1
+
+// reorg record height, not scheduled on main net and test net yet
+const REORG_RECORD_HEIGHT_MAINNET = 1<<32 - 1
+const REORG_RECORD_HEIGHT_TESTNET = 1<<32 - 1
+
 const POLYGON_SNAP_CHAINID_MAINNET = 16
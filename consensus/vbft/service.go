@@ -2160,7 +2160,7 @@ func (self *Server) createGovernaceTransaction(blkNum uint32) *types.Transaction
 	return tx
 }
 
-//checkNeedUpdateChainConfig use blockcount
+//checkNeedUpdateChainConfig use blockcount, or the epoch rotation period of node_manager
 func (self *Server) checkNeedUpdateChainConfig(blockNum uint32) bool {
 	prevBlk, _ := self.blockPool.getSealedBlock(blockNum - 1)
 	if prevBlk == nil {
@@ -2171,7 +2171,17 @@ func (self *Server) checkNeedUpdateChainConfig(blockNum uint32) bool {
 	if (blockNum - lastConfigBlkNum) >= self.config.MaxBlockChangeView {
 		return true
 	}
-	return false
+	return self.checkEpochRotation(blockNum)
+}
+
+//checkEpochRotation query leveldb check if a new governance view is scheduled
+func (self *Server) checkEpochRotation(blkNum uint32) bool {
+	due, err := isRotationDue(self.blockPool.getExecWriteSet(blkNum-1), blkNum)
+	if err != nil {
+		log.Errorf("checkEpochRotation err:%s", err)
+		return false
+	}
+	return due
 }
 
 //checkUpdateChainConfig query leveldb check is force update
@@ -187,6 +187,25 @@ func isUpdate(memdb *overlaydb.MemDB, view uint32) (bool, error) {
 	return false, nil
 }
 
+//isRotationDue check if the epoch rotation of node_manager is due at blkNum
+func isRotationDue(memdb *overlaydb.MemDB, blkNum uint32) (bool, error) {
+	data, err := GetStorageValue(memdb, ledger.DefLedger, nutils.NodeManagerContractAddress, []byte(node_manager.VBFT_CONFIG))
+	if err != nil {
+		return false, err
+	}
+	cfg := new(node_manager.Configuration)
+	err = cfg.Deserialization(common.NewZeroCopySource(data))
+	if err != nil {
+		return false, err
+	}
+	goveranceview, err := GetGovernanceView(memdb)
+	if err != nil {
+		return false, err
+	}
+	next := cfg.NextRotationHeight(goveranceview)
+	return next != 0 && blkNum >= next, nil
+}
+
 func getRawStorageItemFromMemDb(memdb *overlaydb.MemDB, addr common.Address, key []byte) (value []byte, unkown bool) {
 	rawKey := make([]byte, 0, 1+common.ADDR_LEN+len(key))
 	rawKey = append(rawKey, byte(scommon.ST_STORAGE))
@@ -29,13 +29,18 @@ import (
 	"github.com/ontio/ontology-eventbus/actor"
 	"github.com/polynetwork/poly/common"
 	"github.com/polynetwork/poly/common/log"
+	"github.com/polynetwork/poly/core/payload"
 	scommon "github.com/polynetwork/poly/core/store/common"
 	"github.com/polynetwork/poly/core/types"
 	polyErrors "github.com/polynetwork/poly/errors"
+	ccm "github.com/polynetwork/poly/native/service/cross_chain_manager"
+	ccmcom "github.com/polynetwork/poly/native/service/cross_chain_manager/common"
 	"github.com/polynetwork/poly/native/service/governance/node_manager"
 	"github.com/polynetwork/poly/native/service/governance/relayer_manager"
+	hscommon "github.com/polynetwork/poly/native/service/header_sync/common"
 	"github.com/polynetwork/poly/native/service/utils"
 	nutils "github.com/polynetwork/poly/native/service/utils"
+	"github.com/polynetwork/poly/native/states"
 	tcomn "github.com/polynetwork/poly/txnpool/common"
 )
 
@@ -71,9 +76,15 @@ func AppendTxToPool(txn *types.Transaction) (polyErrors.ErrCode, string) {
 		}
 		// Check if address is registreed relayer
 		if value != nil {
-			// Here means address is registered relayer
-			flag = false
-			break
+			// Here means address is registered relayer, a scoped relayer is only permitted the calls of its scope
+			allowed, err := isRelayerAllowed(address, txn)
+			if err != nil {
+				return polyErrors.ErrUnknown, err.Error()
+			}
+			if allowed {
+				flag = false
+				break
+			}
 		}
 		// Check if permittedAddrMap is empty
 		if len(permittedAddrMap) == 0 {
@@ -111,6 +122,72 @@ func AppendTxToPool(txn *types.Transaction) (polyErrors.ErrCode, string) {
 	return polyErrors.ErrUnknown, ""
 }
 
+// isRelayerAllowed tells if the registered relayer address is permitted to send txn
+func isRelayerAllowed(address common.Address, txn *types.Transaction) (bool, error) {
+	key := append([]byte(relayer_manager.RELAYER_SCOPE), address[:]...)
+	value, err := GetStorageItem(utils.RelayerManagerContractAddress, key)
+	if err != nil {
+		if err == scommon.ErrNotFound {
+			return true, nil
+		}
+		return false, err
+	}
+	scope := new(relayer_manager.RelayerScope)
+	if err := scope.Deserialization(common.NewZeroCopySource(value)); err != nil {
+		return false, fmt.Errorf("isRelayerAllowed, deserialize relayer scope error: %v", err)
+	}
+	method, chainIDs, err := relayerCall(txn)
+	if err != nil {
+		return false, err
+	}
+	return scope.Allow(method, chainIDs...), nil
+}
+
+// relayerCall returns the method called by txn and the side chains it is called for, the side
+// chains are only known for the header sync and cross chain manager calls
+func relayerCall(txn *types.Transaction) (string, []uint64, error) {
+	code, ok := txn.Payload.(*payload.InvokeCode)
+	if !ok {
+		return "", nil, nil
+	}
+	invoke := new(states.ContractInvokeParam)
+	if err := invoke.Deserialization(common.NewZeroCopySource(code.Code)); err != nil {
+		return "", nil, fmt.Errorf("relayerCall, deserialize invoke param error: %v", err)
+	}
+	source := common.NewZeroCopySource(invoke.Args)
+	var err error
+	var chainIDs []uint64
+	switch {
+	case invoke.Address == utils.HeaderSyncContractAddress && invoke.Method == hscommon.SYNC_BLOCK_HEADER:
+		params := new(hscommon.SyncBlockHeaderParam)
+		err = params.Deserialization(source)
+		chainIDs = []uint64{params.ChainID}
+	case invoke.Address == utils.HeaderSyncContractAddress && invoke.Method == hscommon.SYNC_CROSS_CHAIN_MSG:
+		params := new(hscommon.SyncCrossChainMsgParam)
+		err = params.Deserialization(source)
+		chainIDs = []uint64{params.ChainID}
+	case invoke.Address == utils.CrossChainManagerContractAddress &&
+		(invoke.Method == ccm.IMPORT_OUTER_TRANSFER_NAME || invoke.Method == ccm.ACK_EXECUTION):
+		params := new(ccmcom.EntranceParam)
+		err = params.Deserialization(source)
+		chainIDs = []uint64{params.SourceChainID}
+	case invoke.Address == utils.CrossChainManagerContractAddress && invoke.Method == ccm.BATCH_IMPORT_OUTER_TRANSFER_NAME:
+		params := new(ccmcom.BatchEntranceParam)
+		err = params.Deserialization(source)
+		for _, param := range params.Params {
+			chainIDs = append(chainIDs, param.SourceChainID)
+		}
+	case invoke.Address == utils.CrossChainManagerContractAddress && invoke.Method == ccm.MULTI_SIGN:
+		params := new(ccmcom.MultiSignParam)
+		err = params.Deserialization(source)
+		chainIDs = []uint64{params.ChainID}
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("relayerCall, deserialize %s param error: %v", invoke.Method, err)
+	}
+	return invoke.Method, chainIDs, nil
+}
+
 //GetTxsFromPool from txpool actor
 func GetTxsFromPool(byCount bool) map[common.Uint256]*types.Transaction {
 	future := txnPoolPid.RequestFuture(&tcomn.GetTxnPoolReq{ByCount: byCount}, REQ_TIMEOUT*time.Second)
@@ -20,18 +20,40 @@
 package common
 
 import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ontio/ontology-crypto/keypair"
 	"github.com/polynetwork/poly/common"
 	"github.com/polynetwork/poly/common/log"
+	scom "github.com/polynetwork/poly/core/store/common"
 	"github.com/polynetwork/poly/core/types"
 	ontErrors "github.com/polynetwork/poly/errors"
 	bactor "github.com/polynetwork/poly/http/base/actor"
 	"github.com/polynetwork/poly/native/event"
+	ccom "github.com/polynetwork/poly/native/service/cross_chain_manager/common"
+	"github.com/polynetwork/poly/native/service/cross_chain_manager/consensus_vote"
+	"github.com/polynetwork/poly/native/service/governance/node_manager"
+	hsbtc "github.com/polynetwork/poly/native/service/header_sync/btc"
+	hscom "github.com/polynetwork/poly/native/service/header_sync/common"
+	"github.com/polynetwork/poly/native/service/router"
+	"github.com/polynetwork/poly/native/service/utils"
 	cstate "github.com/polynetwork/poly/native/states"
 )
 
 const MAX_SEARCH_HEIGHT uint32 = 100
 
+// bounds of a listcrosschainrequests page
+const (
+	MAX_REQUEST_LIMIT uint64 = 100
+	MAX_REQUEST_SCAN  uint64 = 1000
+)
+
 type BalanceOfRsp struct {
 	Ont string `json:"ont"`
 	Ong string `json:"ong"`
@@ -151,6 +173,95 @@ type TXNEntryInfo struct {
 	State []TXNAttrInfo // the result from each validator
 }
 
+type CrossChainRequestInfo struct {
+	FromChainID    uint64
+	ToChainID      uint64
+	SourceTxHash   string
+	CrossChainID   string
+	PolyTxHash     string
+	Status         string
+	ReceivedHeight uint32
+	UpdateHeight   uint32
+	TimeoutHeight  uint32
+	SourceHeight   uint32
+	Orphaned       bool
+}
+
+type BtcChainWorkInfo struct {
+	Hash      string
+	Height    uint32
+	ChainWork string
+	MainChain bool
+}
+
+type EvmReorgInfo struct {
+	OldTip     string
+	OldHeight  uint64
+	NewTip     string
+	NewHeight  uint64
+	ForkHeight uint64
+	Depth      uint64
+	PolyHeight uint32
+}
+
+type EvmForkInfo struct {
+	ChainID       uint64
+	Hash          string
+	Height        uint64
+	DifficultySum string
+	MainChain     bool
+	TipHeight     uint64
+	TipHash       string
+	ReorgCount    uint64
+	LastReorg     *EvmReorgInfo
+}
+
+type VoteProgressInfo struct {
+	ID           string
+	ChainID      uint64
+	Decided      bool
+	Expired      bool
+	Voters       []string
+	VotedWeight  uint64
+	TotalWeight  uint64
+	QuorumWeight uint64
+	StartHeight  uint32
+	ExpireHeight uint32
+}
+
+type EpochRotationInfo struct {
+	View               uint32
+	ViewHeight         uint32
+	Period             uint32
+	NextRotationHeight uint32 // 0 if the epoch rotation is off
+}
+
+type NodeEvidenceInfo struct {
+	ID         string
+	Kind       string
+	PeerPubkey string
+	Address    string
+	Height     uint32
+	FirstHash  string
+	SecondHash string
+	Reporter   string
+	PolyHeight uint32
+	Blacked    bool
+}
+
+type CrossChainRequestList struct {
+	Requests []*CrossChainRequestInfo
+	Total    uint64
+	Next     uint64 // index to continue from, equal to Total at the end of the list
+}
+
+type RouterInfo struct {
+	Name             string
+	ID               uint64
+	Methods          []string
+	ActivationHeight uint32
+}
+
 func GetExecuteNotify(obj *event.ExecuteNotify) (map[string]bool, ExecuteNotify) {
 	evts := []NotifyEventInfo{}
 	var contractAddrs = make(map[string]bool)
@@ -266,6 +377,333 @@ func GetBlockTransactions(block *types.Block) interface{} {
 	return b
 }
 
+func GetRouterInfos() []RouterInfo {
+	routers := router.GetRouters()
+	infos := make([]RouterInfo, 0, len(routers))
+	for _, r := range routers {
+		infos = append(infos, RouterInfo{
+			Name:             r.Name,
+			ID:               r.ID,
+			Methods:          r.Methods,
+			ActivationHeight: r.ActivationHeight,
+		})
+	}
+	return infos
+}
+
+// GetCrossChainRequest returns nil if no request was recorded for polyTxHash.
+func GetCrossChainRequest(polyTxHash common.Uint256) (*CrossChainRequestInfo, error) {
+	value, err := bactor.GetStorageItem(utils.CrossChainManagerContractAddress, ccom.RequestRecordKey(polyTxHash))
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	request := new(ccom.CrossChainRequest)
+	if err := request.Deserialization(common.NewZeroCopySource(value)); err != nil {
+		return nil, err
+	}
+	return &CrossChainRequestInfo{
+		FromChainID:    request.FromChainID,
+		ToChainID:      request.ToChainID,
+		SourceTxHash:   hex.EncodeToString(request.SourceTxHash),
+		CrossChainID:   hex.EncodeToString(request.CrossChainID),
+		PolyTxHash:     request.PolyTxHash.ToHexString(),
+		Status:         ccom.RequestStatusNames[request.Status],
+		ReceivedHeight: request.ReceivedHeight,
+		UpdateHeight:   request.UpdateHeight,
+		TimeoutHeight:  request.TimeoutHeight,
+		SourceHeight:   request.SourceHeight,
+		Orphaned:       request.Orphaned,
+	}, nil
+}
+
+// GetCrossChainRequestBySource returns nil if no request was recorded for the source chain tx.
+func GetCrossChainRequestBySource(fromChainID uint64, sourceTxHash []byte) (*CrossChainRequestInfo, error) {
+	value, err := bactor.GetStorageItem(utils.CrossChainManagerContractAddress, ccom.RequestSourceKey(fromChainID, sourceTxHash))
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	polyTxHash, err := common.Uint256ParseFromBytes(value)
+	if err != nil {
+		return nil, err
+	}
+	return GetCrossChainRequest(polyTxHash)
+}
+
+// ListCrossChainRequests pages through the requests to toChainID in the order they were received,
+// status filters the requests by status name if it is not empty.
+func ListCrossChainRequests(toChainID, start, limit uint64, status string) (*CrossChainRequestList, error) {
+	if limit == 0 || limit > MAX_REQUEST_LIMIT {
+		limit = MAX_REQUEST_LIMIT
+	}
+	list := &CrossChainRequestList{Requests: make([]*CrossChainRequestInfo, 0)}
+	value, err := bactor.GetStorageItem(utils.CrossChainManagerContractAddress, ccom.RequestTargetCountKey(toChainID))
+	if err != nil && err != scom.ErrNotFound {
+		return nil, err
+	}
+	if err == nil {
+		list.Total = utils.GetBytesUint64(value)
+	}
+
+	index := start
+	for ; index < list.Total && index-start < MAX_REQUEST_SCAN && uint64(len(list.Requests)) < limit; index++ {
+		value, err := bactor.GetStorageItem(utils.CrossChainManagerContractAddress, ccom.RequestTargetKey(toChainID, index))
+		if err != nil {
+			return nil, err
+		}
+		polyTxHash, err := common.Uint256ParseFromBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		info, err := GetCrossChainRequest(polyTxHash)
+		if err != nil {
+			return nil, err
+		}
+		if info == nil {
+			return nil, fmt.Errorf("request %s of index %d is not recorded", polyTxHash.ToHexString(), index)
+		}
+		if status == "" || info.Status == status {
+			list.Requests = append(list.Requests, info)
+		}
+	}
+	if index > list.Total {
+		index = list.Total
+	}
+	list.Next = index
+	return list, nil
+}
+
+// GetBtcChainWork returns the work accumulated since the genesis header of the synced btc header with hash,
+// or of the best header if hash is nil. It returns nil if the header is not synced.
+func GetBtcChainWork(chainID uint64, hash *chainhash.Hash) (*BtcChainWorkInfo, error) {
+	key := hsbtc.BestHeaderKey(chainID)
+	if hash != nil {
+		key = hsbtc.BlockHeaderKey(chainID, *hash)
+	}
+	value, err := bactor.GetStorageItem(utils.HeaderSyncContractAddress, key)
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	header := new(hsbtc.StoredHeader)
+	if err := header.Deserialization(common.NewZeroCopySource(value)); err != nil {
+		return nil, err
+	}
+	blockHash := header.Header.BlockHash()
+	value, err = bactor.GetStorageItem(utils.HeaderSyncContractAddress, hsbtc.BlockHashKey(chainID, header.Height))
+	if err != nil && err != scom.ErrNotFound {
+		return nil, err
+	}
+	return &BtcChainWorkInfo{
+		Hash:      blockHash.String(),
+		Height:    header.Height,
+		ChainWork: fmt.Sprintf("%064x", header.TotalWork()),
+		MainChain: bytes.Equal(value, blockHash[:]),
+	}, nil
+}
+
+// evmHeaderWithDifficultySum decodes the number and the difficulty sum of the headers stored by the
+// evm routers, polygon keeps its header in headerWithOptionalSnap
+type evmHeaderWithDifficultySum struct {
+	Header *struct {
+		Number *hexutil.Big `json:"number"`
+	} `json:"header"`
+	HeaderWithOptionalSnap *struct {
+		Header struct {
+			Number *hexutil.Big `json:"number"`
+		}
+	} `json:"headerWithOptionalSnap"`
+	DifficultySum *big.Int `json:"difficultySum"`
+}
+
+// GetEvmForkInfo returns the fork choice of an evm side chain for the synced header with hash, or for the
+// current header of its main chain if hash is nil, with its last reorg. It returns nil if the header is not synced.
+func GetEvmForkInfo(chainID uint64, hash []byte) (*EvmForkInfo, error) {
+	info := &EvmForkInfo{ChainID: chainID}
+	value, err := bactor.GetStorageItem(utils.HeaderSyncContractAddress,
+		append([]byte(hscom.CURRENT_HEADER_HEIGHT), utils.GetUint64Bytes(chainID)...))
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	info.TipHeight = utils.GetBytesUint64(value)
+	tipHash, err := bactor.GetStorageItem(utils.HeaderSyncContractAddress, append(append([]byte(hscom.MAIN_CHAIN),
+		utils.GetUint64Bytes(chainID)...), utils.GetUint64Bytes(info.TipHeight)...))
+	if err != nil {
+		return nil, err
+	}
+	info.TipHash = hex.EncodeToString(tipHash)
+	if hash == nil {
+		hash = tipHash
+	}
+
+	value, err = bactor.GetStorageItem(utils.HeaderSyncContractAddress, append(append([]byte(hscom.HEADER_INDEX),
+		utils.GetUint64Bytes(chainID)...), hash...))
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	header := new(evmHeaderWithDifficultySum)
+	if err := json.Unmarshal(value, header); err != nil {
+		return nil, err
+	}
+	var number *hexutil.Big
+	if header.Header != nil {
+		number = header.Header.Number
+	} else if header.HeaderWithOptionalSnap != nil {
+		number = header.HeaderWithOptionalSnap.Header.Number
+	}
+	if number == nil || header.DifficultySum == nil {
+		return nil, fmt.Errorf("header %x of chain %d is not an evm header", hash, chainID)
+	}
+	info.Hash = hex.EncodeToString(hash)
+	info.Height = number.ToInt().Uint64()
+	info.DifficultySum = header.DifficultySum.String()
+	value, err = bactor.GetStorageItem(utils.HeaderSyncContractAddress, append(append([]byte(hscom.MAIN_CHAIN),
+		utils.GetUint64Bytes(chainID)...), utils.GetUint64Bytes(info.Height)...))
+	if err != nil && err != scom.ErrNotFound {
+		return nil, err
+	}
+	info.MainChain = bytes.Equal(value, hash)
+
+	value, err = bactor.GetStorageItem(utils.HeaderSyncContractAddress, hscom.ReorgCountKey(chainID))
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return info, nil
+		}
+		return nil, err
+	}
+	info.ReorgCount = utils.GetBytesUint64(value)
+	value, err = bactor.GetStorageItem(utils.HeaderSyncContractAddress, hscom.ReorgInfoKey(chainID))
+	if err != nil {
+		return nil, err
+	}
+	reorg := new(hscom.ReorgInfo)
+	if err := reorg.Deserialization(common.NewZeroCopySource(value)); err != nil {
+		return nil, err
+	}
+	info.LastReorg = &EvmReorgInfo{
+		OldTip:     hex.EncodeToString(reorg.OldTip),
+		OldHeight:  reorg.OldHeight,
+		NewTip:     hex.EncodeToString(reorg.NewTip),
+		NewHeight:  reorg.NewHeight,
+		ForkHeight: reorg.ForkHeight,
+		Depth:      reorg.Depth(),
+		PolyHeight: reorg.PolyHeight,
+	}
+	return info, nil
+}
+
+// GetVoteProgress returns the votes for the vote router message id as of their last vote, it
+// returns nil if the message has no vote.
+func GetVoteProgress(id []byte) (*VoteProgressInfo, error) {
+	value, err := bactor.GetStorageItem(utils.CrossChainManagerContractAddress, consensus_vote.VoteInfoKey(id))
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	info := new(consensus_vote.VoteInfo)
+	if err := info.Deserialization(common.NewZeroCopySource(value)); err != nil {
+		return nil, err
+	}
+	return &VoteProgressInfo{
+		ID:      hex.EncodeToString(id),
+		ChainID: info.ChainID,
+		Decided: info.Status,
+		// a vote in the next block would start the votes over
+		Expired:      info.ExpireHeight != 0 && bactor.GetCurrentBlockHeight() >= info.ExpireHeight,
+		Voters:       info.GetVoters(),
+		VotedWeight:  info.VotedWeight,
+		TotalWeight:  info.TotalWeight,
+		QuorumWeight: info.QuorumWeight,
+		StartHeight:  info.StartHeight,
+		ExpireHeight: info.ExpireHeight,
+	}, nil
+}
+
+// GetEpochRotationInfo returns the current governance view and the height the next one is scheduled at
+func GetEpochRotationInfo() (*EpochRotationInfo, error) {
+	value, err := bactor.GetStorageItem(utils.NodeManagerContractAddress, []byte(node_manager.GOVERNANCE_VIEW))
+	if err != nil {
+		return nil, err
+	}
+	view := new(node_manager.GovernanceView)
+	if err := view.Deserialization(common.NewZeroCopySource(value)); err != nil {
+		return nil, err
+	}
+	value, err = bactor.GetStorageItem(utils.NodeManagerContractAddress, []byte(node_manager.VBFT_CONFIG))
+	if err != nil {
+		return nil, err
+	}
+	config := new(node_manager.Configuration)
+	if err := config.Deserialization(common.NewZeroCopySource(value)); err != nil {
+		return nil, err
+	}
+	return &EpochRotationInfo{
+		View:               view.View,
+		ViewHeight:         view.Height,
+		Period:             config.EpochRotationPeriod,
+		NextRotationHeight: config.NextRotationHeight(view),
+	}, nil
+}
+
+// GetNodeEvidence returns the misbehaviour evidence submitted against a consensus peer in submission order
+func GetNodeEvidence(peerPubkey string) ([]*NodeEvidenceInfo, error) {
+	pubkey, err := hex.DecodeString(peerPubkey)
+	if err != nil {
+		return nil, err
+	}
+	value, err := bactor.GetStorageItem(utils.NodeManagerContractAddress, append([]byte(node_manager.EVIDENCE_INDEX), pubkey...))
+	if err != nil {
+		if err == scom.ErrNotFound {
+			return []*NodeEvidenceInfo{}, nil
+		}
+		return nil, err
+	}
+	infos := make([]*NodeEvidenceInfo, 0, len(value)/common.UINT256_SIZE)
+	source := common.NewZeroCopySource(value)
+	for source.Len() != 0 {
+		id, eof := source.NextHash()
+		if eof {
+			return nil, fmt.Errorf("invalid evidence index of peer %s", peerPubkey)
+		}
+		raw, err := bactor.GetStorageItem(utils.NodeManagerContractAddress, append([]byte(node_manager.EVIDENCE), id[:]...))
+		if err != nil {
+			return nil, err
+		}
+		evidence := new(node_manager.Evidence)
+		if err := evidence.Deserialization(common.NewZeroCopySource(raw)); err != nil {
+			return nil, err
+		}
+		infos = append(infos, &NodeEvidenceInfo{
+			ID:         id.ToHexString(),
+			Kind:       evidence.Kind.String(),
+			PeerPubkey: evidence.PeerPubkey,
+			Address:    evidence.Address.ToBase58(),
+			Height:     evidence.Height,
+			FirstHash:  evidence.FirstHash.ToHexString(),
+			SecondHash: evidence.SecondHash.ToHexString(),
+			Reporter:   evidence.Reporter.ToBase58(),
+			PolyHeight: evidence.PolyHeight,
+			Blacked:    evidence.Blacked,
+		})
+	}
+	return infos, nil
+}
+
 func GetAddress(str string) (common.Address, error) {
 	var address common.Address
 	var err error
@@ -20,6 +20,7 @@ package rest
 
 import (
 	"encoding/hex"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/polynetwork/poly/common"
 	"github.com/polynetwork/poly/common/config"
 	"github.com/polynetwork/poly/common/log"
@@ -437,3 +438,158 @@ func GetMemPoolTxState(cmd map[string]interface{}) map[string]interface{} {
 	resp["Result"] = bcomn.TXNEntryInfo{attrs}
 	return resp
 }
+
+// get registered side chain routers
+func GetRouters(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	resp["Result"] = bcomn.GetRouterInfos()
+	return resp
+}
+
+// get the lifecycle record of a cross chain request by poly tx hash
+func GetCrossChainRequest(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	str, ok := cmd["Hash"].(string)
+	if !ok {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	hash, err := common.Uint256FromHexString(str)
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	info, err := bcomn.GetCrossChainRequest(hash)
+	if err != nil {
+		return ResponsePack(berr.INTERNAL_ERROR)
+	}
+	resp["Result"] = info
+	return resp
+}
+
+// get the lifecycle record of a cross chain request by source chain id and source tx hash
+func GetCrossChainRequestBySource(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	chainID, err := strconv.ParseUint(cmd["ChainID"].(string), 10, 64)
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	txHash, err := common.HexToBytes(cmd["Hash"].(string))
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	info, err := bcomn.GetCrossChainRequestBySource(chainID, txHash)
+	if err != nil {
+		return ResponsePack(berr.INTERNAL_ERROR)
+	}
+	resp["Result"] = info
+	return resp
+}
+
+// get the chain work of a synced btc header, or of the best header if the hash is empty
+func GetBtcChainWork(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	chainID, err := strconv.ParseUint(cmd["ChainID"].(string), 10, 64)
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	var hash *chainhash.Hash
+	if str := cmd["Hash"].(string); str != "" {
+		if hash, err = chainhash.NewHashFromStr(str); err != nil {
+			return ResponsePack(berr.INVALID_PARAMS)
+		}
+	}
+	info, err := bcomn.GetBtcChainWork(chainID, hash)
+	if err != nil {
+		return ResponsePack(berr.INTERNAL_ERROR)
+	}
+	resp["Result"] = info
+	return resp
+}
+
+// get the votes for a vote router message by its id
+func GetVoteProgress(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	id, err := common.HexToBytes(cmd["ID"].(string))
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	info, err := bcomn.GetVoteProgress(id)
+	if err != nil {
+		return ResponsePack(berr.INTERNAL_ERROR)
+	}
+	resp["Result"] = info
+	return resp
+}
+
+// get the fork choice and the last reorg of an evm side chain for a synced header, or for its current
+// header if the hash is empty
+func GetEvmForkInfo(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	chainID, err := strconv.ParseUint(cmd["ChainID"].(string), 10, 64)
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	var hash []byte
+	if str := cmd["Hash"].(string); str != "" {
+		if hash, err = common.HexToBytes(str); err != nil {
+			return ResponsePack(berr.INVALID_PARAMS)
+		}
+	}
+	info, err := bcomn.GetEvmForkInfo(chainID, hash)
+	if err != nil {
+		return ResponsePack(berr.INTERNAL_ERROR)
+	}
+	resp["Result"] = info
+	return resp
+}
+
+// get the current governance view and the height the next one starts at automatically
+func GetNextRotationHeight(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	info, err := bcomn.GetEpochRotationInfo()
+	if err != nil {
+		return ResponsePack(berr.INTERNAL_ERROR)
+	}
+	resp["Result"] = info
+	return resp
+}
+
+// get the misbehaviour evidence submitted against a consensus peer
+func GetNodeEvidence(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	str := cmd["PeerPubkey"].(string)
+	if _, err := common.HexToBytes(str); err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	infos, err := bcomn.GetNodeEvidence(str)
+	if err != nil {
+		return ResponsePack(berr.INTERNAL_ERROR)
+	}
+	resp["Result"] = infos
+	return resp
+}
+
+// list the cross chain requests to a chain
+func ListCrossChainRequests(cmd map[string]interface{}) map[string]interface{} {
+	resp := ResponsePack(berr.SUCCESS)
+	chainID, err := strconv.ParseUint(cmd["ChainID"].(string), 10, 64)
+	if err != nil {
+		return ResponsePack(berr.INVALID_PARAMS)
+	}
+	var start, limit uint64
+	if str := cmd["Start"].(string); str != "" {
+		if start, err = strconv.ParseUint(str, 10, 64); err != nil {
+			return ResponsePack(berr.INVALID_PARAMS)
+		}
+	}
+	if str := cmd["Limit"].(string); str != "" {
+		if limit, err = strconv.ParseUint(str, 10, 64); err != nil {
+			return ResponsePack(berr.INVALID_PARAMS)
+		}
+	}
+	list, err := bcomn.ListCrossChainRequests(chainID, start, limit, cmd["Status"].(string))
+	if err != nil {
+		return ResponsePack(berr.INTERNAL_ERROR)
+	}
+	resp["Result"] = list
+	return resp
+}
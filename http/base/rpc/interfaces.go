@@ -22,6 +22,7 @@ import (
 	"encoding/hex"
 	"fmt"
 
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/polynetwork/poly/common"
 	"github.com/polynetwork/poly/common/config"
 	"github.com/polynetwork/poly/common/log"
@@ -516,3 +517,191 @@ func GetStateMerkleRoot(params []interface{}) map[string]interface{} {
 	}
 
 }
+
+// get registered side chain routers
+//   {"jsonrpc": "2.0", "method": "getrouters", "params": [], "id": 0}
+func GetRouters(params []interface{}) map[string]interface{} {
+	return responseSuccess(bcomn.GetRouterInfos())
+}
+
+// get the lifecycle record of a cross chain request by poly tx hash, or by source chain id and source tx hash
+//   {"jsonrpc": "2.0", "method": "getcrosschainrequest", "params": ["poly tx hash"], "id": 0}
+//   {"jsonrpc": "2.0", "method": "getcrosschainrequest", "params": [2, "source tx hash"], "id": 0}
+func GetCrossChainRequest(params []interface{}) map[string]interface{} {
+	var info *bcomn.CrossChainRequestInfo
+	var err error
+	switch len(params) {
+	case 1:
+		str, ok := params[0].(string)
+		if !ok {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		hash, e := common.Uint256FromHexString(str)
+		if e != nil {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		info, err = bcomn.GetCrossChainRequest(hash)
+	case 2:
+		chainID, ok := params[0].(float64)
+		if !ok {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		str, ok := params[1].(string)
+		if !ok {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		txHash, e := hex.DecodeString(str)
+		if e != nil {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		info, err = bcomn.GetCrossChainRequestBySource(uint64(chainID), txHash)
+	default:
+		return responsePack(berr.INVALID_PARAMS, nil)
+	}
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, err.Error())
+	}
+	return responseSuccess(info)
+}
+
+// get the chain work of a synced btc header, or of the best header if the hash is omitted
+//   {"jsonrpc": "2.0", "method": "getbtcchainwork", "params": [1, "block hash"], "id": 0}
+func GetBtcChainWork(params []interface{}) map[string]interface{} {
+	if len(params) < 1 || len(params) > 2 {
+		return responsePack(berr.INVALID_PARAMS, nil)
+	}
+	chainID, ok := params[0].(float64)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	var hash *chainhash.Hash
+	if len(params) == 2 {
+		str, ok := params[1].(string)
+		if !ok {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		h, err := chainhash.NewHashFromStr(str)
+		if err != nil {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		hash = h
+	}
+	info, err := bcomn.GetBtcChainWork(uint64(chainID), hash)
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, err.Error())
+	}
+	return responseSuccess(info)
+}
+
+// get the votes for a vote router message by its id
+//   {"jsonrpc": "2.0", "method": "getvoteprogress", "params": ["message id"], "id": 0}
+func GetVoteProgress(params []interface{}) map[string]interface{} {
+	if len(params) != 1 {
+		return responsePack(berr.INVALID_PARAMS, nil)
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	id, err := hex.DecodeString(str)
+	if err != nil {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	info, err := bcomn.GetVoteProgress(id)
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, err.Error())
+	}
+	return responseSuccess(info)
+}
+
+// get the fork choice and the last reorg of an evm side chain for a synced header, or for its current
+// header if the hash is omitted
+//   {"jsonrpc": "2.0", "method": "getevmforkinfo", "params": [2, "block hash"], "id": 0}
+func GetEvmForkInfo(params []interface{}) map[string]interface{} {
+	if len(params) < 1 || len(params) > 2 {
+		return responsePack(berr.INVALID_PARAMS, nil)
+	}
+	chainID, ok := params[0].(float64)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	var hash []byte
+	if len(params) == 2 {
+		str, ok := params[1].(string)
+		if !ok {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		h, err := hex.DecodeString(str)
+		if err != nil {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+		hash = h
+	}
+	info, err := bcomn.GetEvmForkInfo(uint64(chainID), hash)
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, err.Error())
+	}
+	return responseSuccess(info)
+}
+
+// get the current governance view and the height the next one starts at automatically
+//   {"jsonrpc": "2.0", "method": "getnextrotationheight", "params": [], "id": 0}
+func GetNextRotationHeight(params []interface{}) map[string]interface{} {
+	info, err := bcomn.GetEpochRotationInfo()
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, err.Error())
+	}
+	return responseSuccess(info)
+}
+
+// get the misbehaviour evidence submitted against a consensus peer
+//   {"jsonrpc": "2.0", "method": "getnodeevidence", "params": ["peer pubkey"], "id": 0}
+func GetNodeEvidence(params []interface{}) map[string]interface{} {
+	if len(params) < 1 {
+		return responsePack(berr.INVALID_PARAMS, nil)
+	}
+	str, ok := params[0].(string)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	if _, err := hex.DecodeString(str); err != nil {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	infos, err := bcomn.GetNodeEvidence(str)
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, err.Error())
+	}
+	return responseSuccess(infos)
+}
+
+// list the cross chain requests to a chain, status is optional
+//   {"jsonrpc": "2.0", "method": "listcrosschainrequests", "params": [2, start, limit, "made-proof"], "id": 0}
+func ListCrossChainRequests(params []interface{}) map[string]interface{} {
+	if len(params) < 3 {
+		return responsePack(berr.INVALID_PARAMS, nil)
+	}
+	chainID, ok := params[0].(float64)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	start, ok := params[1].(float64)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	limit, ok := params[2].(float64)
+	if !ok {
+		return responsePack(berr.INVALID_PARAMS, "")
+	}
+	var status string
+	if len(params) > 3 {
+		status, ok = params[3].(string)
+		if !ok {
+			return responsePack(berr.INVALID_PARAMS, "")
+		}
+	}
+	list, err := bcomn.ListCrossChainRequests(uint64(chainID), uint64(start), uint64(limit), status)
+	if err != nil {
+		return responsePack(berr.INTERNAL_ERROR, err.Error())
+	}
+	return responseSuccess(list)
+}
@@ -59,6 +59,14 @@ func StartRPCServer() error {
 	rpc.HandleFunc("getheaderbyheight", rpc.GetHeaderByHeight)
 	rpc.HandleFunc("getblocktxsbyheight", rpc.GetBlockTxsByHeight)
 	rpc.HandleFunc("getstatemerkleroot", rpc.GetStateMerkleRoot)
+	rpc.HandleFunc("getrouters", rpc.GetRouters)
+	rpc.HandleFunc("getcrosschainrequest", rpc.GetCrossChainRequest)
+	rpc.HandleFunc("listcrosschainrequests", rpc.ListCrossChainRequests)
+	rpc.HandleFunc("getbtcchainwork", rpc.GetBtcChainWork)
+	rpc.HandleFunc("getvoteprogress", rpc.GetVoteProgress)
+	rpc.HandleFunc("getevmforkinfo", rpc.GetEvmForkInfo)
+	rpc.HandleFunc("getnodeevidence", rpc.GetNodeEvidence)
+	rpc.HandleFunc("getnextrotationheight", rpc.GetNextRotationHeight)
 
 	err := http.ListenAndServe(":"+strconv.Itoa(int(cfg.DefConfig.Rpc.HttpJsonPort)), nil)
 	if err != nil {
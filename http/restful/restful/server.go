@@ -74,6 +74,15 @@ const (
 	GET_MEMPOOL_TXSTATE   = "/api/v1/mempool/txstate/:hash"
 	GET_VERSION           = "/api/v1/version"
 	GET_NETWORKID         = "/api/v1/networkid"
+	GET_ROUTERS           = "/api/v1/routers"
+	GET_CROSS_CHAIN_REQ   = "/api/v1/crosschainrequest/:hash"
+	GET_CROSS_CHAIN_SRC   = "/api/v1/crosschainrequest/source/:chainid/:hash"
+	LIST_CROSS_CHAIN_REQS = "/api/v1/crosschainrequests/:chainid"
+	GET_BTC_CHAIN_WORK    = "/api/v1/btcchainwork/:chainid"
+	GET_VOTE_PROGRESS     = "/api/v1/voteprogress/:id"
+	GET_EVM_FORK_INFO     = "/api/v1/evmforkinfo/:chainid"
+	GET_NODE_EVIDENCE     = "/api/v1/nodeevidence/:pubkey"
+	GET_NEXT_ROTATION     = "/api/v1/nextrotationheight"
 
 	POST_RAW_TX = "/api/v1/transaction"
 )
@@ -148,6 +157,15 @@ func (this *restServer) registryMethod() {
 		GET_MEMPOOL_TXSTATE:   {name: "getmempooltxstate", handler: rest.GetMemPoolTxState},
 		GET_VERSION:           {name: "getversion", handler: rest.GetNodeVersion},
 		GET_NETWORKID:         {name: "getnetworkid", handler: rest.GetNetworkId},
+		GET_ROUTERS:           {name: "getrouters", handler: rest.GetRouters},
+		GET_CROSS_CHAIN_REQ:   {name: "getcrosschainrequest", handler: rest.GetCrossChainRequest},
+		GET_CROSS_CHAIN_SRC:   {name: "getcrosschainrequestbysource", handler: rest.GetCrossChainRequestBySource},
+		LIST_CROSS_CHAIN_REQS: {name: "listcrosschainrequests", handler: rest.ListCrossChainRequests},
+		GET_BTC_CHAIN_WORK:    {name: "getbtcchainwork", handler: rest.GetBtcChainWork},
+		GET_EVM_FORK_INFO:     {name: "getevmforkinfo", handler: rest.GetEvmForkInfo},
+		GET_NODE_EVIDENCE:     {name: "getnodeevidence", handler: rest.GetNodeEvidence},
+		GET_NEXT_ROTATION:     {name: "getnextrotationheight", handler: rest.GetNextRotationHeight},
+		GET_VOTE_PROGRESS:     {name: "getvoteprogress", handler: rest.GetVoteProgress},
 	}
 
 	postMethodMap := map[string]Action{
@@ -190,6 +208,20 @@ func (this *restServer) getPath(url string) string {
 		return GET_GRANTONG
 	} else if strings.Contains(url, strings.TrimRight(GET_MEMPOOL_TXSTATE, ":hash")) {
 		return GET_MEMPOOL_TXSTATE
+	} else if strings.Contains(url, strings.TrimRight(GET_CROSS_CHAIN_SRC, ":chainid/:hash")) {
+		return GET_CROSS_CHAIN_SRC
+	} else if strings.Contains(url, strings.TrimRight(GET_CROSS_CHAIN_REQ, ":hash")) {
+		return GET_CROSS_CHAIN_REQ
+	} else if strings.Contains(url, strings.TrimRight(LIST_CROSS_CHAIN_REQS, ":chainid")) {
+		return LIST_CROSS_CHAIN_REQS
+	} else if strings.Contains(url, strings.TrimRight(GET_BTC_CHAIN_WORK, ":chainid")) {
+		return GET_BTC_CHAIN_WORK
+	} else if strings.Contains(url, strings.TrimRight(GET_VOTE_PROGRESS, ":id")) {
+		return GET_VOTE_PROGRESS
+	} else if strings.Contains(url, strings.TrimRight(GET_EVM_FORK_INFO, ":chainid")) {
+		return GET_EVM_FORK_INFO
+	} else if strings.Contains(url, strings.TrimRight(GET_NODE_EVIDENCE, ":pubkey")) {
+		return GET_NODE_EVIDENCE
 	}
 	return url
 }
@@ -234,6 +266,21 @@ func (this *restServer) getParams(r *http.Request, url string, req map[string]in
 		req["Addr"] = getParam(r, "addr")
 	case GET_MEMPOOL_TXSTATE:
 		req["Hash"] = getParam(r, "hash")
+	case GET_CROSS_CHAIN_REQ:
+		req["Hash"] = getParam(r, "hash")
+	case GET_CROSS_CHAIN_SRC:
+		req["ChainID"], req["Hash"] = getParam(r, "chainid"), getParam(r, "hash")
+	case LIST_CROSS_CHAIN_REQS:
+		req["ChainID"] = getParam(r, "chainid")
+		req["Start"], req["Limit"], req["Status"] = r.FormValue("start"), r.FormValue("limit"), r.FormValue("status")
+	case GET_BTC_CHAIN_WORK:
+		req["ChainID"], req["Hash"] = getParam(r, "chainid"), r.FormValue("hash")
+	case GET_VOTE_PROGRESS:
+		req["ID"] = getParam(r, "id")
+	case GET_EVM_FORK_INFO:
+		req["ChainID"], req["Hash"] = getParam(r, "chainid"), r.FormValue("hash")
+	case GET_NODE_EVIDENCE:
+		req["PeerPubkey"] = getParam(r, "pubkey")
 	default:
 	}
 	return req
@@ -117,6 +117,24 @@ func (this *NativeService) Invoke() (interface{}, error) {
 	return result, nil
 }
 
+// SubCall runs handler with input in its own storage cache, the storage changes, notifications and
+// cross states of a failed call are dropped so that the caller can go on.
+func (this *NativeService) SubCall(handler Handler, input []byte) ([]byte, error) {
+	snapshot := this.cacheDB.Snapshot()
+	notifications, crossHashes, args := len(this.notifications), len(this.crossHashes), this.input
+	this.input = input
+	result, err := handler(this)
+	this.input = args
+	if err != nil {
+		this.cacheDB.RevertToSnapshot(snapshot)
+		this.notifications = this.notifications[:notifications]
+		this.crossHashes = this.crossHashes[:crossHashes]
+	} else {
+		this.cacheDB.CommitSnapshot(snapshot)
+	}
+	return result, err
+}
+
 func (this *NativeService) NativeCall(address common.Address, method string, args []byte) (interface{}, error) {
 	c := states.ContractInvokeParam{
 		Address: address,
@@ -210,6 +228,10 @@ func (this *NativeService) GetHeight() uint32 {
 	return this.height
 }
 
+func (this *NativeService) GetBlockTime() uint32 {
+	return this.time
+}
+
 func (this *NativeService) GetChainID() uint64 {
 	return this.chainID
 }
@@ -80,8 +80,15 @@ func verifyFromTx(native *native.NativeService, proof, extra []byte, fromChainID
 
 	cheight32 := uint32(cheight)
 
-	if cheight32 < height || cheight32-height < uint32(sideChain.BlocksToWait-1) {
-		return nil, fmt.Errorf("verifyFromTx, transaction is not confirmed, current height: %d, input height: %d", cheight, height)
+	// blocks finalized by fast finality votes need no confirmation depth
+	fheight, finalized, err := bsc.GetFinalizedHeight(native, fromChainID)
+	if err != nil {
+		return nil, fmt.Errorf("verifyFromTx, GetFinalizedHeight error:%s", err)
+	}
+	if !finalized || uint64(height) > fheight {
+		if cheight32 < height || cheight32-height < uint32(sideChain.BlocksToWait-1) {
+			return nil, fmt.Errorf("verifyFromTx, transaction is not confirmed, current height: %d, input height: %d", cheight, height)
+		}
 	}
 
 	headerWithSum, err := bsc.GetCanonicalHeader(native, fromChainID, uint64(height))
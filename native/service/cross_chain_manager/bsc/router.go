@@ -0,0 +1,36 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bsc
+
+import (
+	scom "github.com/polynetwork/poly/native/service/cross_chain_manager/common"
+	hs "github.com/polynetwork/poly/native/service/header_sync/bsc"
+	hscommon "github.com/polynetwork/poly/native/service/header_sync/common"
+	"github.com/polynetwork/poly/native/service/router"
+	"github.com/polynetwork/poly/native/service/utils"
+)
+
+func init() {
+	router.Register(&router.Router{
+		Name:              "bsc",
+		ID:                utils.BSC_ROUTER,
+		Methods:           []string{hscommon.SYNC_GENESIS_HEADER, hscommon.SYNC_BLOCK_HEADER, scom.IMPORT_OUTER_TRANSFER_NAME},
+		HeaderSyncHandler: hs.NewHandler(),
+		ChainHandler:      NewHandler(),
+	})
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/polynetwork/poly/native/event"
 	crosscommon "github.com/polynetwork/poly/native/service/cross_chain_manager/common"
 	"github.com/polynetwork/poly/native/service/utils"
+	"github.com/polynetwork/poly/native/service/utils/taproot"
 )
 
 type BTCHandler struct {
@@ -65,7 +66,17 @@ func (this *BTCHandler) MultiSign(service *native.NativeService) error {
 	if err != nil {
 		return fmt.Errorf("MultiSign, %v", err)
 	}
-	_, addrs, n, err := txscript.ExtractPkScriptAddrs(redeemScript, netParam)
+	isTaproot := taproot.IsMultisigLeaf(redeemScript)
+	var (
+		addrs []btcutil.Address
+		keys  [][]byte
+		n     int
+	)
+	if isTaproot {
+		keys, n, err = taproot.ParseMultisigLeaf(redeemScript)
+	} else {
+		_, addrs, n, err = txscript.ExtractPkScriptAddrs(redeemScript, netParam)
+	}
 	if err != nil {
 		return fmt.Errorf("MultiSign, failed to extract pkscript addrs: %v", err)
 	}
@@ -93,7 +104,11 @@ func (this *BTCHandler) MultiSign(service *native.NativeService) error {
 	if err != nil {
 		return fmt.Errorf("MultiSign, failed to get stxos: %v", err)
 	}
-	err = verifySigs(params.Signs, params.Address, addrs, redeemScript, mtx, pkScripts, amts)
+	if isTaproot {
+		err = verifyTaprootSigs(params.Signs, params.Address, keys, redeemScript, mtx, pkScripts, amts)
+	} else {
+		err = verifySigs(params.Signs, params.Address, addrs, redeemScript, mtx, pkScripts, amts)
+	}
 	if err != nil {
 		return fmt.Errorf("MultiSign, failed to verify: %v", err)
 	}
@@ -111,7 +126,11 @@ func (this *BTCHandler) MultiSign(service *native.NativeService) error {
 				States:          []interface{}{"btcTxMultiSign", params.TxHash, multiSignInfo.MultiSignInfo},
 			})
 	} else {
-		err = addSigToTx(multiSignInfo, addrs, redeemScript, mtx, pkScripts)
+		if isTaproot {
+			err = addTaprootSigToTx(multiSignInfo, keys, redeemScript, mtx)
+		} else {
+			err = addSigToTx(multiSignInfo, addrs, redeemScript, mtx, pkScripts)
+		}
 		if err != nil {
 			return fmt.Errorf("MultiSign, failed to add sig to tx: %v", err)
 		}
@@ -266,8 +285,14 @@ func makeBtcTx(service *native.NativeService, chainID uint64, amounts map[string
 		return fmt.Errorf("makeBtcTx, %v", err)
 	}
 	out := wire.NewTxOut(0, script)
-	_, addrs, m, _ := txscript.ExtractPkScriptAddrs(redeemScript, netParam)
-	choosed, sum, gasFee, err := chooseUtxos(service, chainID, amountSum, append(outs, out), rk, m, len(addrs))
+	var m, n int
+	if keys, required, err := taproot.ParseMultisigLeaf(redeemScript); err == nil {
+		m, n = required, len(keys)
+	} else {
+		_, addrs, required, _ := txscript.ExtractPkScriptAddrs(redeemScript, netParam)
+		m, n = required, len(addrs)
+	}
+	choosed, sum, gasFee, err := chooseUtxos(service, chainID, amountSum, append(outs, out), rk, m, n)
 	if err != nil {
 		return fmt.Errorf("makeBtcTx, chooseUtxos error: %v", err)
 	}
@@ -21,6 +21,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"encoding/hex"
+	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
@@ -43,7 +44,8 @@ import (
 )
 
 var (
-	acct *account.Account = account.NewAccount("")
+	acct     *account.Account = account.NewAccount("")
+	netParam                  = &chaincfg.TestNet3Params
 
 	rdm               = "552102dec9a415b6384ec0a9331d0cdf02020f0f1e5731c327b86e2b5a92455a289748210365b1066bcfa21987c3e207b92e309b95ca6bee5f1133cf04d6ed4ed265eafdbc21031104e387cd1a103c27fdc8a52d5c68dec25ddfb2f574fbdca405edfd8c5187de21031fdb4b44a9f20883aff505009ebc18702774c105cb04b1eecebcb294d404b1cb210387cda955196cc2b2fc0adbbbac1776f8de77b563c6d2a06a77d96457dc3d0d1f2102dd7767b6a7cc83693343ba721e0f5f4c7b4b8d85eeb7aec20d227625ec0f59d321034ad129efdab75061e8d4def08f5911495af2dae6d3e9a4b6e7aeb5186fa432fc57ae"
 	fromBtcTxid       = "2587a59e8069c563d32de9d4a2b946760d740b6963566dd7b32d8ec549f2d238"
@@ -88,6 +90,7 @@ var (
 			ChainId:      1,
 			BlocksToWait: 1,
 			Router:       0,
+			CCMCAddress:  utils.GetUint64Bytes(uint64(utils.TyTestnet3)),
 		}
 		sink := common.NewZeroCopySink(nil)
 		_ = side.Serialization(sink)
@@ -247,6 +250,7 @@ func TestBTCHandler_MultiSign(t *testing.T) {
 	_ = mtx.BtcDecode(bytes.NewBuffer(rawTx), wire.ProtocolVersion, wire.LatestEncoding)
 	ns := getNativeFunc(nil, nil)
 	_ = addUtxos(ns, 1, 0, mtx)
+	setSideChain(ns)
 	setBtcTxParam(ns.GetCacheDB(), utxoKey)
 	registerRC(ns.GetCacheDB())
 
@@ -337,7 +341,7 @@ func TestBTCHandler_MultiSign(t *testing.T) {
 	err = mtx.BtcDecode(bytes.NewBuffer(rawTx), wire.ProtocolVersion, wire.LatestEncoding)
 	assert.NoError(t, err)
 	txid = mtx.TxHash()
-	utxos, err = getUtxos(ns, 1, utxoKey)
+	utxos, err := getUtxos(ns, 1, utxoKey)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(utxos.Utxos))
 	assert.Equal(t, uint64(4000), utxos.Utxos[0].Value)
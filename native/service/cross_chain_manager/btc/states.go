@@ -25,6 +25,7 @@ import (
 	"github.com/btcsuite/btcd/wire"
 	"github.com/gcash/bchd/chaincfg/chainhash"
 	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/native/service/utils/taproot"
 	"sort"
 	"strconv"
 )
@@ -305,20 +306,29 @@ func (selector *CoinSelector) estimateTxSize(selection []*Utxo) int {
 	redeemSize := 1 + selector.m*(1+75) + 1 + 1 + selector.n*(1+33) + 1 + 1
 	p2shInputSize := 43 + redeemSize
 	witnessInputSize := 41 + redeemSize/blockchain.WitnessScaleFactor
+	// taproot inputs carry m schnorr sigs, n-m empty elements, the leaf and the control block
+	leafSize := selector.n*(1+32+1) + 3 + 1
+	taprootWitnessSize := 1 + selector.m*(1+64) + selector.n - selector.m + wire.VarIntSerializeSize(uint64(leafSize)) +
+		leafSize + 1 + taproot.CONTROL_SIZE
+	taprootInputSize := 41 + (taprootWitnessSize+blockchain.WitnessScaleFactor-1)/blockchain.WitnessScaleFactor
 	outsSize := 0
 	for _, txOut := range selector.txOuts {
 		outsSize += txOut.SerializeSize()
 	}
-	witNum := 0
+	witNum, taprootNum := 0, 0
 	for _, u := range selection {
+		if taproot.IsPayToTaproot(u.ScriptPubkey) {
+			taprootNum++
+			continue
+		}
 		switch txscript.GetScriptClass(u.ScriptPubkey) {
 		case txscript.WitnessV0ScriptHashTy:
 			witNum++
 		}
 	}
 	return 10 + 2 + wire.VarIntSerializeSize(uint64(len(selection))) +
-		wire.VarIntSerializeSize(uint64(len(selector.txOuts)+1)) + (len(selection)-witNum)*p2shInputSize +
-		witNum*witnessInputSize + outsSize
+		wire.VarIntSerializeSize(uint64(len(selector.txOuts)+1)) + (len(selection)-witNum-taprootNum)*p2shInputSize +
+		witNum*witnessInputSize + taprootNum*taprootInputSize + outsSize
 }
 
 type OutPoint struct {
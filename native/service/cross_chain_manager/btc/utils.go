@@ -39,6 +39,7 @@ import (
 	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
 	"github.com/polynetwork/poly/native/service/header_sync/btc"
 	"github.com/polynetwork/poly/native/service/utils"
+	"github.com/polynetwork/poly/native/service/utils/taproot"
 	"golang.org/x/crypto/ripemd160"
 )
 
@@ -123,7 +124,10 @@ func verifyFromBtcTx(native *native.NativeService, proof, tx []byte, fromChainID
 	if err != nil {
 		return nil, fmt.Errorf("verifyFromBtcTx, failed to resolve parameter: %v", err)
 	}
-	rk := GetUtxoKey(mtx.TxOut[0].PkScript)
+	rk, err := getUtxoKey(native, fromChainID, mtx.TxOut[0].PkScript)
+	if err != nil {
+		return nil, fmt.Errorf("verifyFromBtcTx, %v", err)
+	}
 	redeemKey, err := hex.DecodeString(rk)
 	if err != nil {
 		return nil, fmt.Errorf("verifyFromBtcTx, hex.DecodeString error: %v", err)
@@ -268,6 +272,13 @@ func getTxOuts(amounts map[string]int64, netParam *chaincfg.Params) ([]*wire.TxO
 }
 
 func getLockScript(redeem []byte, netParam *chaincfg.Params) ([]byte, error) {
+	if taproot.IsMultisigLeaf(redeem) {
+		outputKey, _, err := taproot.OutputKey(redeem)
+		if err != nil {
+			return nil, fmt.Errorf("getChangeTxOut, failed to get taproot output key: %v", err)
+		}
+		return taproot.PayToTaprootScript(outputKey), nil
+	}
 	hasher := sha256.New()
 	hasher.Write(redeem)
 	witAddr, err := btcutil.NewAddressWitnessScriptHash(hasher.Sum(nil), netParam)
@@ -296,8 +307,24 @@ func GetUtxoKey(scriptPk []byte) string {
 	}
 }
 
+// getUtxoKey is GetUtxoKey extended with taproot outputs, whose redeem key can only
+// be found from the output key registered along with the taproot leaf
+func getUtxoKey(native *native.NativeService, chainID uint64, scriptPk []byte) (string, error) {
+	if !taproot.IsPayToTaproot(scriptPk) {
+		return GetUtxoKey(scriptPk), nil
+	}
+	rk, err := side_chain_manager.GetTaprootRedeemKey(native, scriptPk[2:], chainID)
+	if err != nil {
+		return "", fmt.Errorf("getUtxoKey, %v", err)
+	}
+	return rk, nil
+}
+
 func addUtxos(native *native.NativeService, chainID uint64, height uint32, mtx *wire.MsgTx) error {
-	utxoKey := GetUtxoKey(mtx.TxOut[0].PkScript)
+	utxoKey, err := getUtxoKey(native, chainID, mtx.TxOut[0].PkScript)
+	if err != nil {
+		return fmt.Errorf("addUtxos, %v", err)
+	}
 
 	utxos, err := getUtxos(native, chainID, utxoKey)
 	if err != nil {
@@ -460,6 +487,7 @@ func verifySigs(sigs [][]byte, addr string, addrs []btcutil.Address, redeem []by
 		return fmt.Errorf("address %s not found in redeem script", addr)
 	}
 
+	var sh *txscript.TxSigHashes
 	for i, sig := range sigs {
 		if len(sig) < 1 {
 			return fmt.Errorf("length of no.%d sig is less than 1", i)
@@ -477,7 +505,9 @@ func verifySigs(sigs [][]byte, addr string, addrs []btcutil.Address, redeem []by
 				return fmt.Errorf("failed to calculate sig hash: %v", err)
 			}
 		case txscript.WitnessV0ScriptHashTy:
-			sh := txscript.NewTxSigHashes(tx)
+			if sh == nil {
+				sh = txscript.NewTxSigHashes(tx)
+			}
 			hash, err = txscript.CalcWitnessSigHash(redeem, sh, txscript.SigHashType(sig[len(sig)-1]), tx, i, int64(amts[i]))
 			if err != nil {
 				return fmt.Errorf("failed to calculate sig hash: %v", err)
@@ -493,6 +523,50 @@ func verifySigs(sigs [][]byte, addr string, addrs []btcutil.Address, redeem []by
 	return nil
 }
 
+// verifyTaprootSigs verifies the BIP340 signatures of the signer with hex x-only key addr
+// for spending all inputs through the taproot multisig leaf
+func verifyTaprootSigs(sigs [][]byte, addr string, keys [][]byte, leaf []byte, tx *wire.MsgTx,
+	pkScripts [][]byte, amts []uint64) error {
+	if len(sigs) != len(tx.TxIn) {
+		return fmt.Errorf("not enough sig, only %d sigs but %d required", len(sigs), len(tx.TxIn))
+	}
+	var signer []byte
+	for _, k := range keys {
+		if hex.EncodeToString(k) == addr {
+			signer = k
+		}
+	}
+	if signer == nil {
+		return fmt.Errorf("key %s not found in taproot leaf", addr)
+	}
+
+	leafHash := taproot.LeafHash(leaf)
+	for i, sig := range sigs {
+		if !taproot.IsPayToTaproot(pkScripts[i]) {
+			return fmt.Errorf("script of no.%d utxo is not taproot", i)
+		}
+		hashType := taproot.SIGHASH_DEFAULT
+		switch len(sig) {
+		case taproot.SIG_SIZE:
+		case taproot.SIG_SIZE + 1:
+			if hashType = sig[taproot.SIG_SIZE]; hashType == taproot.SIGHASH_DEFAULT {
+				return fmt.Errorf("no.%d sig with explicit default sighash type", i)
+			}
+		default:
+			return fmt.Errorf("wrong length of no.%d sig: %d", i, len(sig))
+		}
+		hash, err := taproot.SigHash(tx, i, hashType, amts, pkScripts, leafHash)
+		if err != nil {
+			return fmt.Errorf("failed to calculate sig hash: %v", err)
+		}
+		if !taproot.VerifySchnorr(signer, hash, sig[:taproot.SIG_SIZE]) {
+			return fmt.Errorf("verify no.%d sig and not pass", i+1)
+		}
+	}
+
+	return nil
+}
+
 func putBtcMultiSignInfo(native *native.NativeService, txid []byte, multiSignInfo *MultiSignInfo) error {
 	key := utils.ConcatKey(utils.CrossChainManagerContractAddress, []byte(MULTI_SIGN_INFO), txid)
 	sink := common.NewZeroCopySink(nil)
@@ -570,6 +644,29 @@ func addSigToTx(sigMap *MultiSignInfo, addrs []btcutil.Address, redeem []byte, t
 	return nil
 }
 
+// addTaprootSigToTx sets the witness spending each input through the taproot multisig leaf.
+// OP_CHECKSIGADD consumes signatures in key order from the top of the stack, so they are
+// pushed in reverse key order with empty elements for keys not signing.
+func addTaprootSigToTx(sigMap *MultiSignInfo, keys [][]byte, leaf []byte, tx *wire.MsgTx) error {
+	cb, err := taproot.ControlBlock(leaf)
+	if err != nil {
+		return fmt.Errorf("addTaprootSigToTx, failed to get control block: %v", err)
+	}
+	for i := 0; i < len(tx.TxIn); i++ {
+		data := make([][]byte, 0, len(keys)+2)
+		for j := len(keys) - 1; j >= 0; j-- {
+			signs, ok := sigMap.MultiSignInfo[hex.EncodeToString(keys[j])]
+			if !ok {
+				data = append(data, []byte{})
+				continue
+			}
+			data = append(data, signs[i])
+		}
+		tx.TxIn[i].Witness = wire.TxWitness(append(data, leaf, cb))
+	}
+	return nil
+}
+
 func putBtcFromInfo(native *native.NativeService, txid []byte, btcFromInfo *BtcFromInfo) error {
 	key := utils.ConcatKey(utils.CrossChainManagerContractAddress, []byte(BTC_FROM_TX_PREFIX), txid)
 	sink := common.NewZeroCopySink(nil)
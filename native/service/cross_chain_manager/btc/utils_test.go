@@ -19,10 +19,12 @@ package btc
 import (
 	"bytes"
 	"encoding/hex"
+	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
+	"github.com/polynetwork/poly/native/service/utils/taproot"
 	"sort"
 	"testing"
 )
@@ -268,3 +270,63 @@ func TestUtxos_Choose(t *testing.T) {
 		t.Fatal("err should not be nil")
 	}
 }
+
+func TestTaprootSigs(t *testing.T) {
+	privs := make([]*btcec.PrivateKey, 3)
+	keys := make([][]byte, len(privs))
+	for i := range privs {
+		privs[i], _ = btcec.NewPrivateKey(btcec.S256())
+		keys[i] = taproot.XOnlyPubKey(privs[i].PubKey())
+	}
+	leaf, err := taproot.MultisigLeaf(keys, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock, err := getLockScript(leaf, &chaincfg.TestNet3Params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !taproot.IsPayToTaproot(lock) {
+		t.Fatal("lock script of taproot leaf is not p2tr")
+	}
+
+	mtx := wire.NewMsgTx(wire.TxVersion)
+	mtx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 0}, nil, nil))
+	mtx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 1}, nil, nil))
+	mtx.AddTxOut(wire.NewTxOut(15e4, p2sh))
+	pkScripts := [][]byte{lock, lock}
+	amts := []uint64{1e5, 1e5}
+
+	leafHash := taproot.LeafHash(leaf)
+	sigMap := &MultiSignInfo{MultiSignInfo: make(map[string][][]byte)}
+	for _, j := range []int{0, 2} {
+		sigs := make([][]byte, len(mtx.TxIn))
+		for i := range mtx.TxIn {
+			hash, _ := taproot.SigHash(mtx, i, taproot.SIGHASH_DEFAULT, amts, pkScripts, leafHash)
+			sigs[i], _ = taproot.SignSchnorr(privs[j], hash)
+		}
+		addr := hex.EncodeToString(keys[j])
+		if err = verifyTaprootSigs(sigs, addr, keys, leaf, mtx, pkScripts, amts); err != nil {
+			t.Fatal(err)
+		}
+		if err = verifyTaprootSigs(sigs, addr, keys, leaf, mtx, pkScripts, []uint64{1e5, 2e5}); err == nil {
+			t.Fatal("sigs should not commit to other amounts")
+		}
+		if err = verifyTaprootSigs(sigs, hex.EncodeToString(keys[1]), keys, leaf, mtx, pkScripts, amts); err == nil {
+			t.Fatal("sigs should not pass for other key")
+		}
+		sigMap.MultiSignInfo[addr] = sigs
+	}
+
+	if err = addTaprootSigToTx(sigMap, keys, leaf, mtx); err != nil {
+		t.Fatal(err)
+	}
+	cb, _ := taproot.ControlBlock(leaf)
+	for i, in := range mtx.TxIn {
+		if len(in.Witness) != 5 || !bytes.Equal(in.Witness[0], sigMap.MultiSignInfo[hex.EncodeToString(keys[2])][i]) ||
+			len(in.Witness[1]) != 0 || !bytes.Equal(in.Witness[2], sigMap.MultiSignInfo[hex.EncodeToString(keys[0])][i]) ||
+			!bytes.Equal(in.Witness[3], leaf) || !bytes.Equal(in.Witness[4], cb) {
+			t.Fatalf("wrong witness of no.%d input", i)
+		}
+	}
+}
@@ -18,10 +18,25 @@ package common
 
 import (
 	"fmt"
+	"math/big"
+
 	"github.com/polynetwork/poly/common"
 	"github.com/polynetwork/poly/native"
 )
 
+const (
+	//function name
+	IMPORT_OUTER_TRANSFER_NAME       = "ImportOuterTransfer"
+	BATCH_IMPORT_OUTER_TRANSFER_NAME = "BatchImportOuterTransfer"
+	MULTI_SIGN                       = "MultiSign"
+
+	// max number of entrance params in a batch
+	MAX_BATCH_SIZE = 32
+
+	// method of the lock proxy contracts on the target chains
+	LOCK_PROXY_UNLOCK = "unlock"
+)
+
 var (
 	KEY_PREFIX_BTC = "btc"
 
@@ -29,13 +44,31 @@ var (
 	REQUEST             = "request"
 	DONE_TX             = "doneTx"
 
-	NOTIFY_MAKE_PROOF = "makeProof"
+	REQUEST_RECORD       = "requestRecord"
+	REQUEST_SOURCE       = "requestSource"
+	REQUEST_TARGET       = "requestTarget"
+	REQUEST_TARGET_COUNT = "requestTargetCount"
+	REQUEST_HEIGHT       = "requestHeight"
+
+	TIMEOUT = "timeout"
+	RECEIPT = "receipt"
+
+	NOTIFY_MAKE_PROOF    = "makeProof"
+	NOTIFY_REJECT_CALL   = "rejectCall"
+	NOTIFY_TIMEOUT_PROOF = "timeoutProof"
+	NOTIFY_ORPHANED      = "orphanedRequest"
 )
 
 type ChainHandler interface {
 	MakeDepositProposal(service *native.NativeService) (*MakeTxParam, error)
 }
 
+// AckHandler is implemented by the chain handlers which can verify that a request
+// made by poly was executed on their chain.
+type AckHandler interface {
+	VerifyExecution(service *native.NativeService) (*ExecutionReceipt, error)
+}
+
 type InitRedeemScriptParam struct {
 	RedeemScript string
 }
@@ -107,6 +140,38 @@ func (this *EntranceParam) Deserialization(source *common.ZeroCopySource) error
 	return nil
 }
 
+type BatchEntranceParam struct {
+	Params []*EntranceParam
+}
+
+func (this *BatchEntranceParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarUint(uint64(len(this.Params)))
+	for _, v := range this.Params {
+		v.Serialization(sink)
+	}
+}
+
+func (this *BatchEntranceParam) Deserialization(source *common.ZeroCopySource) error {
+	n, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("BatchEntranceParam deserialize params length error")
+	}
+	if n > MAX_BATCH_SIZE {
+		return fmt.Errorf("BatchEntranceParam deserialize, %d params is more than %d", n, MAX_BATCH_SIZE)
+	}
+	params := make([]*EntranceParam, 0, n)
+	for i := uint64(0); i < n; i++ {
+		param := new(EntranceParam)
+		if err := param.Deserialization(source); err != nil {
+			return fmt.Errorf("BatchEntranceParam deserialize param error: %v", err)
+		}
+		params = append(params, param)
+	}
+
+	this.Params = params
+	return nil
+}
+
 type MakeTxParam struct {
 	TxHash              []byte
 	CrossChainID        []byte
@@ -115,6 +180,9 @@ type MakeTxParam struct {
 	ToContractAddress   []byte
 	Method              string
 	Args                []byte
+	// poly height after which the request can be timed out, 0 for no timeout. It is set by the user on
+	// the source chain and is part of the merkle value, so the target chain can refuse a late delivery
+	TimeoutHeight uint32
 }
 
 func (this *MakeTxParam) Serialization(sink *common.ZeroCopySink) {
@@ -125,6 +193,9 @@ func (this *MakeTxParam) Serialization(sink *common.ZeroCopySink) {
 	sink.WriteVarBytes(this.ToContractAddress)
 	sink.WriteVarBytes([]byte(this.Method))
 	sink.WriteVarBytes(this.Args)
+	if this.TimeoutHeight != 0 {
+		sink.WriteUint32(this.TimeoutHeight)
+	}
 }
 
 func (this *MakeTxParam) Deserialization(source *common.ZeroCopySource) error {
@@ -156,6 +227,14 @@ func (this *MakeTxParam) Deserialization(source *common.ZeroCopySource) error {
 	if eof {
 		return fmt.Errorf("MakeTxParam deserialize args error")
 	}
+	// txs without a timeout end here
+	var timeoutHeight uint32
+	if source.Len() != 0 {
+		timeoutHeight, eof = source.NextUint32()
+		if eof {
+			return fmt.Errorf("MakeTxParam deserialize timeoutHeight error")
+		}
+	}
 
 	this.TxHash = txHash
 	this.CrossChainID = crossChainID
@@ -164,6 +243,7 @@ func (this *MakeTxParam) Deserialization(source *common.ZeroCopySource) error {
 	this.ToContractAddress = toContractAddress
 	this.Method = method
 	this.Args = args
+	this.TimeoutHeight = timeoutHeight
 	return nil
 }
 
@@ -257,3 +337,155 @@ func (this *ToMerkleValue) Deserialization(source *common.ZeroCopySource) error
 	this.MakeTxParam = makeTxParam
 	return nil
 }
+
+// TxArgs is the Args of a lock proxy unlock call, the amount is a little endian uint255.
+type TxArgs struct {
+	ToAssetHash []byte
+	ToAddress   []byte
+	Amount      *big.Int
+}
+
+func (this *TxArgs) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarBytes(this.ToAssetHash)
+	sink.WriteVarBytes(this.ToAddress)
+	amount := make([]byte, 32)
+	b := this.Amount.Bytes()
+	for i := range b {
+		amount[i] = b[len(b)-1-i]
+	}
+	sink.WriteBytes(amount)
+}
+
+func (this *TxArgs) Deserialization(source *common.ZeroCopySource) error {
+	toAssetHash, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("TxArgs deserialize toAssetHash error")
+	}
+	toAddress, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("TxArgs deserialize toAddress error")
+	}
+	raw, eof := source.NextBytes(32)
+	if eof {
+		return fmt.Errorf("TxArgs deserialize amount error")
+	}
+	amount := make([]byte, 32)
+	for i := range raw {
+		amount[i] = raw[31-i]
+	}
+
+	this.ToAssetHash = toAssetHash
+	this.ToAddress = toAddress
+	this.Amount = new(big.Int).SetBytes(amount)
+	return nil
+}
+
+// ExecutionReceipt is recorded by the cross chain manager contract of the target chain
+// once it has executed the request made by poly tx PolyTxHash, it starts with RECEIPT so the
+// stored hash of a MakeTxParam can't be proven as a receipt.
+type ExecutionReceipt struct {
+	PolyTxHash   []byte
+	FromChainID  uint64
+	CrossChainID []byte
+	Success      bool
+}
+
+func (this *ExecutionReceipt) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarBytes([]byte(RECEIPT))
+	sink.WriteVarBytes(this.PolyTxHash)
+	sink.WriteVarUint(this.FromChainID)
+	sink.WriteVarBytes(this.CrossChainID)
+	sink.WriteBool(this.Success)
+}
+
+func (this *ExecutionReceipt) Deserialization(source *common.ZeroCopySource) error {
+	tag, eof := source.NextString()
+	if eof || tag != RECEIPT {
+		return fmt.Errorf("ExecutionReceipt deserialize tag error")
+	}
+	polyTxHash, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("ExecutionReceipt deserialize polyTxHash error")
+	}
+	fromChainID, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("ExecutionReceipt deserialize fromChainID error")
+	}
+	crossChainID, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("ExecutionReceipt deserialize crossChainID error")
+	}
+	success, eof := source.NextBool()
+	if eof {
+		return fmt.Errorf("ExecutionReceipt deserialize success error")
+	}
+	if source.Len() != 0 {
+		return fmt.Errorf("ExecutionReceipt deserialize, %d trailing bytes", source.Len())
+	}
+
+	this.PolyTxHash = polyTxHash
+	this.FromChainID = fromChainID
+	this.CrossChainID = crossChainID
+	this.Success = success
+	return nil
+}
+
+// TimeoutMerkleValue is the cross state entry proving to the source chain that a request
+// timed out, it starts with TIMEOUT so it can't be read as a ToMerkleValue.
+type TimeoutMerkleValue struct {
+	PolyTxHash    []byte
+	FromChainID   uint64
+	ToChainID     uint64
+	SourceTxHash  []byte
+	CrossChainID  []byte
+	TimeoutHeight uint32
+}
+
+func (this *TimeoutMerkleValue) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarBytes([]byte(TIMEOUT))
+	sink.WriteVarBytes(this.PolyTxHash)
+	sink.WriteUint64(this.FromChainID)
+	sink.WriteUint64(this.ToChainID)
+	sink.WriteVarBytes(this.SourceTxHash)
+	sink.WriteVarBytes(this.CrossChainID)
+	sink.WriteUint32(this.TimeoutHeight)
+}
+
+func (this *TimeoutMerkleValue) Deserialization(source *common.ZeroCopySource) error {
+	tag, eof := source.NextString()
+	if eof || tag != TIMEOUT {
+		return fmt.Errorf("TimeoutMerkleValue deserialize tag error")
+	}
+	polyTxHash, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("TimeoutMerkleValue deserialize polyTxHash error")
+	}
+	fromChainID, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("TimeoutMerkleValue deserialize fromChainID error")
+	}
+	toChainID, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("TimeoutMerkleValue deserialize toChainID error")
+	}
+	sourceTxHash, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("TimeoutMerkleValue deserialize sourceTxHash error")
+	}
+	crossChainID, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("TimeoutMerkleValue deserialize crossChainID error")
+	}
+	timeoutHeight, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("TimeoutMerkleValue deserialize timeoutHeight error")
+	}
+
+	this.PolyTxHash = polyTxHash
+	this.FromChainID = fromChainID
+	this.ToChainID = toChainID
+	this.SourceTxHash = sourceTxHash
+	this.CrossChainID = crossChainID
+	this.TimeoutHeight = timeoutHeight
+	return nil
+}
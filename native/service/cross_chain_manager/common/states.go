@@ -0,0 +1,157 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+package common
+
+import (
+	"fmt"
+
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/native/service/utils"
+)
+
+// status of a cross chain request
+const (
+	REQUEST_RECEIVED uint8 = iota + 1
+	REQUEST_MADE_PROOF
+	// acknowledged by the target chain
+	REQUEST_DELIVERED
+	REQUEST_FAILED
+	// timed out before it was acknowledged
+	REQUEST_UNREACHABLE
+)
+
+var RequestStatusNames = map[uint8]string{
+	REQUEST_RECEIVED:    "received",
+	REQUEST_MADE_PROOF:  "made-proof",
+	REQUEST_DELIVERED:   "delivered",
+	REQUEST_FAILED:      "failed",
+	REQUEST_UNREACHABLE: "unreachable",
+}
+
+// CrossChainRequest is the lifecycle record of a request imported by poly, it is
+// indexed by PolyTxHash, by the source chain tx hash and by the target chain.
+// SourceHeight is the height of the source chain header the request was proven with, Orphaned
+// is set once that header left the main chain of the source chain in a reorg.
+type CrossChainRequest struct {
+	FromChainID    uint64
+	ToChainID      uint64
+	SourceTxHash   []byte
+	CrossChainID   []byte
+	PolyTxHash     common.Uint256
+	Status         uint8
+	ReceivedHeight uint32
+	UpdateHeight   uint32
+	TimeoutHeight  uint32
+	SourceHeight   uint32
+	Orphaned       bool
+}
+
+func (this *CrossChainRequest) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarUint(this.FromChainID)
+	sink.WriteVarUint(this.ToChainID)
+	sink.WriteVarBytes(this.SourceTxHash)
+	sink.WriteVarBytes(this.CrossChainID)
+	sink.WriteHash(this.PolyTxHash)
+	sink.WriteUint8(this.Status)
+	sink.WriteUint32(this.ReceivedHeight)
+	sink.WriteUint32(this.UpdateHeight)
+	sink.WriteUint32(this.TimeoutHeight)
+	sink.WriteUint32(this.SourceHeight)
+	sink.WriteBool(this.Orphaned)
+}
+
+func (this *CrossChainRequest) Deserialization(source *common.ZeroCopySource) error {
+	fromChainID, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("CrossChainRequest deserialize fromChainID error")
+	}
+	toChainID, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("CrossChainRequest deserialize toChainID error")
+	}
+	sourceTxHash, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("CrossChainRequest deserialize sourceTxHash error")
+	}
+	crossChainID, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("CrossChainRequest deserialize crossChainID error")
+	}
+	polyTxHash, eof := source.NextHash()
+	if eof {
+		return fmt.Errorf("CrossChainRequest deserialize polyTxHash error")
+	}
+	status, eof := source.NextUint8()
+	if eof {
+		return fmt.Errorf("CrossChainRequest deserialize status error")
+	}
+	receivedHeight, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("CrossChainRequest deserialize receivedHeight error")
+	}
+	updateHeight, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("CrossChainRequest deserialize updateHeight error")
+	}
+	timeoutHeight, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("CrossChainRequest deserialize timeoutHeight error")
+	}
+	sourceHeight, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("CrossChainRequest deserialize sourceHeight error")
+	}
+	orphaned, eof := source.NextBool()
+	if eof {
+		return fmt.Errorf("CrossChainRequest deserialize orphaned error")
+	}
+
+	this.FromChainID = fromChainID
+	this.ToChainID = toChainID
+	this.SourceTxHash = sourceTxHash
+	this.CrossChainID = crossChainID
+	this.PolyTxHash = polyTxHash
+	this.Status = status
+	this.ReceivedHeight = receivedHeight
+	this.UpdateHeight = updateHeight
+	this.TimeoutHeight = timeoutHeight
+	this.SourceHeight = sourceHeight
+	this.Orphaned = orphaned
+	return nil
+}
+
+// keys of the request index under the cross chain manager contract, without the contract address
+
+func RequestRecordKey(polyTxHash common.Uint256) []byte {
+	return append([]byte(REQUEST_RECORD), polyTxHash[:]...)
+}
+
+func RequestSourceKey(fromChainID uint64, sourceTxHash []byte) []byte {
+	return append(append([]byte(REQUEST_SOURCE), utils.GetUint64Bytes(fromChainID)...), sourceTxHash...)
+}
+
+func RequestTargetKey(toChainID uint64, index uint64) []byte {
+	return append(append([]byte(REQUEST_TARGET), utils.GetUint64Bytes(toChainID)...), utils.GetUint64Bytes(index)...)
+}
+
+func RequestTargetCountKey(toChainID uint64) []byte {
+	return append([]byte(REQUEST_TARGET_COUNT), utils.GetUint64Bytes(toChainID)...)
+}
+
+func RequestHeightKey(fromChainID uint64, sourceHeight uint64) []byte {
+	return append(append([]byte(REQUEST_HEIGHT), utils.GetUint64Bytes(fromChainID)...), utils.GetUint64Bytes(sourceHeight)...)
+}
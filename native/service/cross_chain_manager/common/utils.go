@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/polynetwork/poly/common"
 	"github.com/polynetwork/poly/common/config"
 	"github.com/polynetwork/poly/core/states"
 	"github.com/polynetwork/poly/native"
@@ -42,6 +43,41 @@ func NotifyMakeProof(native *native.NativeService, fromChainID, toChainID uint64
 		})
 }
 
+func NotifyTimeoutProof(native *native.NativeService, fromChainID, toChainID uint64, txHash string, key string) {
+	if !config.DefConfig.Common.EnableEventLog {
+		return
+	}
+	native.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.CrossChainManagerContractAddress,
+			States:          []interface{}{NOTIFY_TIMEOUT_PROOF, fromChainID, toChainID, txHash, native.GetHeight(), key},
+		})
+}
+
+func NotifyOrphanedRequest(native *native.NativeService, request *CrossChainRequest) {
+	if !config.DefConfig.Common.EnableEventLog {
+		return
+	}
+	native.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.CrossChainManagerContractAddress,
+			States: []interface{}{NOTIFY_ORPHANED, request.FromChainID, request.ToChainID, request.PolyTxHash.ToHexString(),
+				request.SourceHeight, native.GetHeight()},
+		})
+}
+
+func NotifyRejectCall(native *native.NativeService, fromChainID, toChainID uint64, txHash string, toContract string,
+	method string, reason string) {
+	if !config.DefConfig.Common.EnableEventLog {
+		return
+	}
+	native.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.CrossChainManagerContractAddress,
+			States:          []interface{}{NOTIFY_REJECT_CALL, fromChainID, toChainID, txHash, toContract, method, native.GetHeight(), reason},
+		})
+}
+
 func PutDoneTx(native *native.NativeService, crossChainID []byte, chainID uint64) error {
 	contract := utils.CrossChainManagerContractAddress
 	chainIDBytes := utils.GetUint64Bytes(chainID)
@@ -62,3 +98,145 @@ func CheckDoneTx(native *native.NativeService, crossChainID []byte, chainID uint
 	}
 	return nil
 }
+
+// isRequestIndexActive reports whether requests are recorded at the current height
+func isRequestIndexActive(native *native.NativeService) bool {
+	return native.GetHeight() >= config.GetCrossChainRequestIndexHeight(config.DefConfig.P2PNode.NetworkId)
+}
+
+// PutCrossChainRequest records a new request as received and adds it to the source, source height
+// and target indexes. Requests made below the index height are not recorded.
+func PutCrossChainRequest(native *native.NativeService, request *CrossChainRequest) error {
+	if !isRequestIndexActive(native) {
+		return nil
+	}
+	contract := utils.CrossChainManagerContractAddress
+	request.Status = REQUEST_RECEIVED
+	request.ReceivedHeight = native.GetHeight()
+	request.UpdateHeight = native.GetHeight()
+	putCrossChainRequest(native, request)
+	native.GetCacheDB().Put(utils.ConcatKey(contract, RequestSourceKey(request.FromChainID, request.SourceTxHash)),
+		states.GenRawStorageItem(request.PolyTxHash[:]))
+
+	if request.SourceHeight != 0 {
+		heightKey := utils.ConcatKey(contract, RequestHeightKey(request.FromChainID, uint64(request.SourceHeight)))
+		hashes, err := getRequestHashes(native, heightKey)
+		if err != nil {
+			return fmt.Errorf("PutCrossChainRequest, %v", err)
+		}
+		native.GetCacheDB().Put(heightKey, states.GenRawStorageItem(append(hashes, request.PolyTxHash[:]...)))
+	}
+
+	countKey := utils.ConcatKey(contract, RequestTargetCountKey(request.ToChainID))
+	value, err := native.GetCacheDB().Get(countKey)
+	if err != nil {
+		return fmt.Errorf("PutCrossChainRequest, native.GetCacheDB().Get error: %v", err)
+	}
+	var count uint64
+	if value != nil {
+		raw, err := states.GetValueFromRawStorageItem(value)
+		if err != nil {
+			return fmt.Errorf("PutCrossChainRequest, deserialize from raw storage item err:%v", err)
+		}
+		count = utils.GetBytesUint64(raw)
+	}
+	native.GetCacheDB().Put(utils.ConcatKey(contract, RequestTargetKey(request.ToChainID, count)),
+		states.GenRawStorageItem(request.PolyTxHash[:]))
+	native.GetCacheDB().Put(countKey, states.GenRawStorageItem(utils.GetUint64Bytes(count+1)))
+	return nil
+}
+
+func putCrossChainRequest(native *native.NativeService, request *CrossChainRequest) {
+	contract := utils.CrossChainManagerContractAddress
+	sink := common.NewZeroCopySink(nil)
+	request.Serialization(sink)
+	native.GetCacheDB().Put(utils.ConcatKey(contract, RequestRecordKey(request.PolyTxHash)), states.GenRawStorageItem(sink.Bytes()))
+}
+
+func GetCrossChainRequest(native *native.NativeService, polyTxHash common.Uint256) (*CrossChainRequest, error) {
+	contract := utils.CrossChainManagerContractAddress
+	value, err := native.GetCacheDB().Get(utils.ConcatKey(contract, RequestRecordKey(polyTxHash)))
+	if err != nil {
+		return nil, fmt.Errorf("GetCrossChainRequest, native.GetCacheDB().Get error: %v", err)
+	}
+	if value == nil {
+		return nil, nil
+	}
+	raw, err := states.GetValueFromRawStorageItem(value)
+	if err != nil {
+		return nil, fmt.Errorf("GetCrossChainRequest, deserialize from raw storage item err:%v", err)
+	}
+	request := new(CrossChainRequest)
+	if err := request.Deserialization(common.NewZeroCopySource(raw)); err != nil {
+		return nil, fmt.Errorf("GetCrossChainRequest, deserialize CrossChainRequest error: %v", err)
+	}
+	return request, nil
+}
+
+// UpdateCrossChainRequestStatus moves a recorded request to status, requests made below
+// the index height are not recorded and are left alone.
+func UpdateCrossChainRequestStatus(native *native.NativeService, polyTxHash common.Uint256, status uint8) error {
+	if !isRequestIndexActive(native) {
+		return nil
+	}
+	request, err := GetCrossChainRequest(native, polyTxHash)
+	if err != nil {
+		return fmt.Errorf("UpdateCrossChainRequestStatus, %v", err)
+	}
+	if request == nil {
+		return nil
+	}
+	request.Status = status
+	request.UpdateHeight = native.GetHeight()
+	putCrossChainRequest(native, request)
+	return nil
+}
+
+// getRequestHashes returns the concatenated poly tx hashes stored under key
+func getRequestHashes(native *native.NativeService, key []byte) ([]byte, error) {
+	value, err := native.GetCacheDB().Get(key)
+	if err != nil {
+		return nil, fmt.Errorf("getRequestHashes, native.GetCacheDB().Get error: %v", err)
+	}
+	if value == nil {
+		return nil, nil
+	}
+	raw, err := states.GetValueFromRawStorageItem(value)
+	if err != nil {
+		return nil, fmt.Errorf("getRequestHashes, deserialize from raw storage item err:%v", err)
+	}
+	if len(raw)%common.UINT256_SIZE != 0 {
+		return nil, fmt.Errorf("getRequestHashes, invalid length %d", len(raw))
+	}
+	return raw, nil
+}
+
+// FlagOrphanedRequests marks the requests proven with a header of chainID from fromHeight to toHeight
+// as orphaned, those headers were just replaced in a reorg of the source chain.
+func FlagOrphanedRequests(native *native.NativeService, chainID uint64, fromHeight, toHeight uint64) error {
+	contract := utils.CrossChainManagerContractAddress
+	for height := fromHeight; height <= toHeight; height++ {
+		hashes, err := getRequestHashes(native, utils.ConcatKey(contract, RequestHeightKey(chainID, height)))
+		if err != nil {
+			return fmt.Errorf("FlagOrphanedRequests, %v", err)
+		}
+		for i := 0; i < len(hashes); i += common.UINT256_SIZE {
+			polyTxHash, err := common.Uint256ParseFromBytes(hashes[i : i+common.UINT256_SIZE])
+			if err != nil {
+				return fmt.Errorf("FlagOrphanedRequests, parse poly tx hash error: %v", err)
+			}
+			request, err := GetCrossChainRequest(native, polyTxHash)
+			if err != nil {
+				return fmt.Errorf("FlagOrphanedRequests, %v", err)
+			}
+			if request == nil || request.Orphaned {
+				continue
+			}
+			request.Orphaned = true
+			request.UpdateHeight = native.GetHeight()
+			putCrossChainRequest(native, request)
+			NotifyOrphanedRequest(native, request)
+		}
+	}
+	return nil
+}
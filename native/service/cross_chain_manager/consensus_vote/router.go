@@ -0,0 +1,33 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package consensus_vote
+
+import (
+	scom "github.com/polynetwork/poly/native/service/cross_chain_manager/common"
+	"github.com/polynetwork/poly/native/service/router"
+	"github.com/polynetwork/poly/native/service/utils"
+)
+
+func init() {
+	router.Register(&router.Router{
+		Name:         "vote",
+		ID:           utils.VOTE_ROUTER,
+		Methods:      []string{scom.IMPORT_OUTER_TRANSFER_NAME},
+		ChainHandler: NewVoteHandler(),
+	})
+}
@@ -23,9 +23,36 @@ import (
 	"sort"
 )
 
+// ExtraInfo is the vote configuration of a side chain, kept as json in the ExtraInfo of the side chain.
+// A message is decided once its voters hold QuorumNumerator/QuorumDenominator of the total weight,
+// 2/3 by default. Without Voters the current poly consensus nodes vote with weight 1. A message not
+// decided within ExpiryBlocks poly blocks from its first vote expires and its votes are dropped,
+// a zero ExpiryBlocks never expires.
+type ExtraInfo struct {
+	QuorumNumerator   uint64
+	QuorumDenominator uint64
+	Voters            []*Voter
+	ExpiryBlocks      uint32
+}
+
+// Voter is a weighted voter, Address is in base58.
+type Voter struct {
+	Address string
+	Weight  uint64
+}
+
+// VoteInfo is the vote record of a message, the weights are the ones of its last vote. Messages
+// first voted for below the vote configuration height have no StartHeight and keep the encoding
+// without chain, heights and weights.
 type VoteInfo struct {
-	Status   bool
-	VoteInfo map[string]bool
+	Status       bool
+	VoteInfo     map[string]bool
+	ChainID      uint64
+	StartHeight  uint32
+	ExpireHeight uint32
+	VotedWeight  uint64
+	TotalWeight  uint64
+	QuorumWeight uint64
 }
 
 func (this *VoteInfo) Serialization(sink *common.ZeroCopySink) {
@@ -43,6 +70,15 @@ func (this *VoteInfo) Serialization(sink *common.ZeroCopySink) {
 		v := this.VoteInfo[k]
 		sink.WriteBool(v)
 	}
+	if this.StartHeight == 0 {
+		return
+	}
+	sink.WriteVarUint(this.ChainID)
+	sink.WriteUint32(this.StartHeight)
+	sink.WriteUint32(this.ExpireHeight)
+	sink.WriteUint64(this.VotedWeight)
+	sink.WriteUint64(this.TotalWeight)
+	sink.WriteUint64(this.QuorumWeight)
 }
 
 func (this *VoteInfo) Deserialization(source *common.ZeroCopySource) error {
@@ -68,5 +104,49 @@ func (this *VoteInfo) Deserialization(source *common.ZeroCopySource) error {
 	}
 	this.Status = status
 	this.VoteInfo = voteInfo
+	// votes stored before the vote configuration have no chain, heights or weights
+	if source.Len() == 0 {
+		return nil
+	}
+	chainID, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("VoteInfo deserialize chainID error")
+	}
+	startHeight, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("VoteInfo deserialize startHeight error")
+	}
+	expireHeight, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("VoteInfo deserialize expireHeight error")
+	}
+	votedWeight, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("VoteInfo deserialize votedWeight error")
+	}
+	totalWeight, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("VoteInfo deserialize totalWeight error")
+	}
+	quorumWeight, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("VoteInfo deserialize quorumWeight error")
+	}
+	this.ChainID = chainID
+	this.StartHeight = startHeight
+	this.ExpireHeight = expireHeight
+	this.VotedWeight = votedWeight
+	this.TotalWeight = totalWeight
+	this.QuorumWeight = quorumWeight
 	return nil
 }
+
+// GetVoters returns the base58 addresses which voted for a message in ascending order
+func (this *VoteInfo) GetVoters() []string {
+	list := make([]string, 0, len(this.VoteInfo))
+	for k := range this.VoteInfo {
+		list = append(list, k)
+	}
+	sort.Strings(list)
+	return list
+}
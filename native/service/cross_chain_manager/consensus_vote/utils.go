@@ -19,27 +19,117 @@ package consensus_vote
 
 import (
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"math/big"
+
 	"github.com/ontio/ontology-crypto/keypair"
 	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/common/config"
 	cstates "github.com/polynetwork/poly/core/states"
 	"github.com/polynetwork/poly/core/types"
 	"github.com/polynetwork/poly/native"
 	"github.com/polynetwork/poly/native/service/governance/node_manager"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
 	"github.com/polynetwork/poly/native/service/utils"
 )
 
 const (
-	VOTE_INFO = "voteInfo"
+	VOTE_INFO         = "voteInfo"
+	VOTE_EXPIRY       = "voteExpiry"
+	VOTE_EXPIRY_RANGE = "voteExpiryRange"
+
+	// at most this many expired messages are dropped by a vote
+	MAX_EXPIRED_VOTES = 10
 )
 
+// VoteInfoKey is the storage key of the vote record of message id, without the contract prefix
+func VoteInfoKey(id []byte) []byte {
+	return append([]byte(VOTE_INFO), id...)
+}
+
+// GetExtraInfo returns the vote configuration of chainID, the default one if chainID is not registered
+// or its ExtraInfo is not json
+func GetExtraInfo(native *native.NativeService, chainID uint64) (*ExtraInfo, error) {
+	sideChain, err := side_chain_manager.GetSideChain(native, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("GetExtraInfo, GetSideChain error: %v", err)
+	}
+	info := new(ExtraInfo)
+	if sideChain != nil && len(sideChain.ExtraInfo) != 0 {
+		// chains registered before the vote configuration may have an ExtraInfo which is not
+		// a vote configuration, they keep the default one
+		if err := json.Unmarshal(sideChain.ExtraInfo, info); err != nil {
+			info = new(ExtraInfo)
+		}
+	}
+	if info.QuorumNumerator == 0 && info.QuorumDenominator == 0 {
+		info.QuorumNumerator, info.QuorumDenominator = 2, 3
+	}
+	if info.QuorumNumerator == 0 || info.QuorumNumerator > info.QuorumDenominator {
+		return nil, fmt.Errorf("GetExtraInfo, invalid quorum %d/%d", info.QuorumNumerator, info.QuorumDenominator)
+	}
+	seen := make(map[string]bool, len(info.Voters))
+	var total uint64
+	for _, v := range info.Voters {
+		if _, err := common.AddressFromBase58(v.Address); err != nil {
+			return nil, fmt.Errorf("GetExtraInfo, invalid voter address %s: %v", v.Address, err)
+		}
+		if seen[v.Address] {
+			return nil, fmt.Errorf("GetExtraInfo, duplicated voter %s", v.Address)
+		}
+		seen[v.Address] = true
+		if v.Weight == 0 || total+v.Weight < total {
+			return nil, fmt.Errorf("GetExtraInfo, invalid weight of voter %s", v.Address)
+		}
+		total += v.Weight
+	}
+	return info, nil
+}
+
+// getVoters returns the weight of every voter by its base58 address, the current consensus
+// nodes with weight 1 if info has no voters
+func getVoters(native *native.NativeService, info *ExtraInfo) (map[string]uint64, error) {
+	voters := make(map[string]uint64)
+	if len(info.Voters) != 0 {
+		for _, v := range info.Voters {
+			voters[v.Address] = v.Weight
+		}
+		return voters, nil
+	}
+	//get view
+	view, err := node_manager.GetView(native)
+	if err != nil {
+		return nil, fmt.Errorf("getVoters, GetView error: %v", err)
+	}
+	//get consensus peer
+	peerPoolMap, err := node_manager.GetPeerPoolMap(native, view)
+	if err != nil {
+		return nil, fmt.Errorf("getVoters, GetPeerPoolMap error: %v", err)
+	}
+	for key, v := range peerPoolMap.PeerPoolMap {
+		if v.Status == node_manager.ConsensusStatus {
+			k, err := hex.DecodeString(key)
+			if err != nil {
+				return nil, fmt.Errorf("getVoters, hex.DecodeString public key error: %v", err)
+			}
+			publicKey, err := keypair.DeserializePublicKey(k)
+			if err != nil {
+				return nil, fmt.Errorf("getVoters, keypair.DeserializePublicKey error: %v", err)
+			}
+			addr := types.AddressFromPubKey(publicKey)
+			voters[addr.ToBase58()] = 1
+		}
+	}
+	return voters, nil
+}
+
 func getVoteInfo(native *native.NativeService, id []byte) (*VoteInfo, error) {
-	key := utils.ConcatKey(utils.CrossChainManagerContractAddress, []byte(VOTE_INFO), id)
+	key := utils.ConcatKey(utils.CrossChainManagerContractAddress, VoteInfoKey(id))
 	voteInfoStore, err := native.GetCacheDB().Get(key)
 	if err != nil {
 		return nil, fmt.Errorf("getVoteInfo, get getVoteInfoStore error: %v", err)
 	}
-
 	voteInfo := &VoteInfo{
 		VoteInfo: make(map[string]bool),
 	}
@@ -60,93 +150,190 @@ func putVoteInfo(native *native.NativeService, id []byte, voteInfo *VoteInfo) {
 	contract := utils.CrossChainManagerContractAddress
 	sink := common.NewZeroCopySink(nil)
 	voteInfo.Serialization(sink)
-	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(VOTE_INFO), id), cstates.GenRawStorageItem(sink.Bytes()))
+	native.GetCacheDB().Put(utils.ConcatKey(contract, VoteInfoKey(id)), cstates.GenRawStorageItem(sink.Bytes()))
 }
 
-func CheckVotes(native *native.NativeService, id []byte, address common.Address) (bool, error) {
-	voteInfo, err := getVoteInfo(native, id)
+// getExpiryRange returns the first and the next index of the expiry queue of chainID, messages
+// are queued in the order they are first voted for
+func getExpiryRange(native *native.NativeService, chainID uint64) (uint64, uint64, error) {
+	rangeStore, err := native.GetCacheDB().Get(utils.ConcatKey(utils.CrossChainManagerContractAddress,
+		[]byte(VOTE_EXPIRY_RANGE), utils.GetUint64Bytes(chainID)))
 	if err != nil {
-		return false, fmt.Errorf("CheckVotes, getVoteInfo error: %v", err)
+		return 0, 0, fmt.Errorf("getExpiryRange, get rangeStore error: %v", err)
+	}
+	if rangeStore == nil {
+		return 0, 0, nil
+	}
+	rangeBytes, err := cstates.GetValueFromRawStorageItem(rangeStore)
+	if err != nil {
+		return 0, 0, fmt.Errorf("getExpiryRange, deserialize from raw storage item err:%v", err)
 	}
+	source := common.NewZeroCopySource(rangeBytes)
+	head, eof := source.NextUint64()
+	if eof {
+		return 0, 0, fmt.Errorf("getExpiryRange, deserialize head error")
+	}
+	tail, eof := source.NextUint64()
+	if eof {
+		return 0, 0, fmt.Errorf("getExpiryRange, deserialize tail error")
+	}
+	return head, tail, nil
+}
 
-	//check voteInfo status
-	if voteInfo.Status {
-		return false, nil
+func putExpiryRange(native *native.NativeService, chainID uint64, head, tail uint64) {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint64(head)
+	sink.WriteUint64(tail)
+	native.GetCacheDB().Put(utils.ConcatKey(utils.CrossChainManagerContractAddress, []byte(VOTE_EXPIRY_RANGE),
+		utils.GetUint64Bytes(chainID)), cstates.GenRawStorageItem(sink.Bytes()))
+}
+
+func expiryKey(chainID uint64, index uint64) []byte {
+	return utils.ConcatKey(utils.CrossChainManagerContractAddress, []byte(VOTE_EXPIRY), utils.GetUint64Bytes(chainID),
+		utils.GetUint64Bytes(index))
+}
+
+func queueExpiry(native *native.NativeService, chainID uint64, id []byte, expireHeight uint32) error {
+	head, tail, err := getExpiryRange(native, chainID)
+	if err != nil {
+		return fmt.Errorf("queueExpiry, %v", err)
 	}
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteVarBytes(id)
+	sink.WriteUint32(expireHeight)
+	native.GetCacheDB().Put(expiryKey(chainID, tail), cstates.GenRawStorageItem(sink.Bytes()))
+	putExpiryRange(native, chainID, head, tail+1)
+	return nil
+}
 
-	//get view
-	view, err := node_manager.GetView(native)
+func getExpiry(native *native.NativeService, chainID uint64, index uint64) ([]byte, uint32, error) {
+	expiryStore, err := native.GetCacheDB().Get(expiryKey(chainID, index))
 	if err != nil {
-		return false, fmt.Errorf("CheckVotes, GetView error: %v", err)
+		return nil, 0, fmt.Errorf("getExpiry, get expiryStore error: %v", err)
 	}
-	//get consensus peer
-	peerPoolMap, err := node_manager.GetPeerPoolMap(native, view)
+	expiryBytes, err := cstates.GetValueFromRawStorageItem(expiryStore)
 	if err != nil {
-		return false, fmt.Errorf("CheckVotes, GetPeerPoolMap error: %v", err)
+		return nil, 0, fmt.Errorf("getExpiry, deserialize from raw storage item err:%v", err)
 	}
+	source := common.NewZeroCopySource(expiryBytes)
+	id, eof := source.NextVarBytes()
+	if eof {
+		return nil, 0, fmt.Errorf("getExpiry, deserialize id error")
+	}
+	expireHeight, eof := source.NextUint32()
+	if eof {
+		return nil, 0, fmt.Errorf("getExpiry, deserialize expire height error")
+	}
+	return id, expireHeight, nil
+}
 
-	//check if signer is consensus peer
-	consensus := false
-	for key, v := range peerPoolMap.PeerPoolMap {
-		if v.Status == node_manager.ConsensusStatus {
-			k, err := hex.DecodeString(key)
-			if err != nil {
-				return false, fmt.Errorf("CheckVotes, hex.DecodeString public key error: %v", err)
-			}
-			publicKey, err := keypair.DeserializePublicKey(k)
-			if err != nil {
-				return false, fmt.Errorf("CheckVotes, keypair.DeserializePublicKey error: %v", err)
-			}
-			addr := types.AddressFromPubKey(publicKey)
-
-			if addr == address {
-				consensus = true
-				break
-			}
+// dropExpiredVotes deletes the records of at most MAX_EXPIRED_VOTES expired messages at the
+// head of the expiry queue of chainID
+func dropExpiredVotes(native *native.NativeService, chainID uint64) error {
+	head, tail, err := getExpiryRange(native, chainID)
+	if err != nil {
+		return fmt.Errorf("dropExpiredVotes, %v", err)
+	}
+	start := head
+	for ; head < tail && head-start < MAX_EXPIRED_VOTES; head++ {
+		id, expireHeight, err := getExpiry(native, chainID, head)
+		if err != nil {
+			return fmt.Errorf("dropExpiredVotes, %v", err)
 		}
+		if native.GetHeight() <= expireHeight {
+			break
+		}
+		voteInfo, err := getVoteInfo(native, id)
+		if err != nil {
+			return fmt.Errorf("dropExpiredVotes, %v", err)
+		}
+		// a message voted for again after it expired is queued again, and its new record is
+		// left to the later entry
+		if voteInfo.ExpireHeight == expireHeight {
+			native.GetCacheDB().Delete(utils.ConcatKey(utils.CrossChainManagerContractAddress, VoteInfoKey(id)))
+		}
+		native.GetCacheDB().Delete(expiryKey(chainID, head))
 	}
-	if !consensus {
-		return false, fmt.Errorf("CheckVotes, signer is not consensus peer")
+	if head != start {
+		putExpiryRange(native, chainID, head, tail)
 	}
+	return nil
+}
 
-	//check signs num
-	num := 0
-	sum := 0
-	flag := false
-	for key, v := range peerPoolMap.PeerPoolMap {
-		if v.Status == node_manager.ConsensusStatus {
-			k, err := hex.DecodeString(key)
-			if err != nil {
-				return false, fmt.Errorf("CheckVotes, hex.DecodeString public key error: %v", err)
-			}
-			publicKey, err := keypair.DeserializePublicKey(k)
-			if err != nil {
-				return false, fmt.Errorf("CheckVotes, keypair.DeserializePublicKey error: %v", err)
-			}
-			addr := types.AddressFromPubKey(publicKey)
-			_, ok := voteInfo.VoteInfo[addr.ToBase58()]
-			if ok {
-				num = num + 1
-			}
-			sum = sum + 1
+// quorumWeight returns the least weight deciding a message out of total
+func quorumWeight(info *ExtraInfo, total uint64) uint64 {
+	weight := new(big.Int).Mul(new(big.Int).SetUint64(total), new(big.Int).SetUint64(info.QuorumNumerator))
+	weight.Add(weight, new(big.Int).SetUint64(info.QuorumDenominator-1))
+	weight.Div(weight, new(big.Int).SetUint64(info.QuorumDenominator))
+	return weight.Uint64()
+}
 
-			//check if voted
-			_, ok = voteInfo.VoteInfo[address.ToBase58()]
-			if !ok {
-				flag = true
+// CheckVotes records the vote of address for message id of chainID, and returns true if the vote
+// decides the message. The weights are counted with the voters at the time of the vote, so the
+// votes of former voters are not counted. Below the vote configuration height the current consensus
+// nodes vote with the default quorum and nothing expires.
+func CheckVotes(native *native.NativeService, chainID uint64, id []byte, address common.Address) (bool, error) {
+	configured := native.GetHeight() >= config.GetVoteConfigHeight(config.DefConfig.P2PNode.NetworkId)
+	info := &ExtraInfo{QuorumNumerator: 2, QuorumDenominator: 3}
+	if configured {
+		var err error
+		info, err = GetExtraInfo(native, chainID)
+		if err != nil {
+			return false, fmt.Errorf("CheckVotes, %v", err)
+		}
+		if err := dropExpiredVotes(native, chainID); err != nil {
+			return false, fmt.Errorf("CheckVotes, %v", err)
+		}
+	}
+	voteInfo, err := getVoteInfo(native, id)
+	if err != nil {
+		return false, fmt.Errorf("CheckVotes, getVoteInfo error: %v", err)
+	}
+	if voteInfo.ExpireHeight != 0 && native.GetHeight() > voteInfo.ExpireHeight {
+		voteInfo = &VoteInfo{VoteInfo: make(map[string]bool)}
+	}
+	//check voteInfo status
+	if voteInfo.Status {
+		return false, nil
+	}
+
+	voters, err := getVoters(native, info)
+	if err != nil {
+		return false, fmt.Errorf("CheckVotes, %v", err)
+	}
+	//check if signer is voter
+	if _, ok := voters[address.ToBase58()]; !ok {
+		if len(info.Voters) == 0 {
+			return false, fmt.Errorf("CheckVotes, signer is not consensus peer")
+		}
+		return false, fmt.Errorf("CheckVotes, signer is not voter")
+	}
+	if len(voteInfo.VoteInfo) == 0 && configured {
+		voteInfo.ChainID = chainID
+		voteInfo.StartHeight = native.GetHeight()
+		if info.ExpiryBlocks != 0 {
+			voteInfo.ExpireHeight = native.GetHeight() + info.ExpiryBlocks
+			if err := queueExpiry(native, chainID, id, voteInfo.ExpireHeight); err != nil {
+				return false, fmt.Errorf("CheckVotes, %v", err)
 			}
 		}
 	}
-	if flag {
-		voteInfo.VoteInfo[address.ToBase58()] = true
-		num = num + 1
-		putVoteInfo(native, id, voteInfo)
+	voted := voteInfo.VoteInfo[address.ToBase58()]
+	voteInfo.VoteInfo[address.ToBase58()] = true
+
+	//check votes weight
+	voteInfo.VotedWeight, voteInfo.TotalWeight = 0, 0
+	for addr, weight := range voters {
+		if voteInfo.VoteInfo[addr] {
+			voteInfo.VotedWeight += weight
+		}
+		voteInfo.TotalWeight += weight
 	}
-	if num >= (2*sum+2)/3 {
-		voteInfo.Status = true
+	voteInfo.QuorumWeight = quorumWeight(info, voteInfo.TotalWeight)
+	voteInfo.Status = voteInfo.VotedWeight >= voteInfo.QuorumWeight
+	// a repeated vote which decides nothing leaves the record as it is
+	if !voted || voteInfo.Status {
 		putVoteInfo(native, id, voteInfo)
-		return true, nil
-	} else {
-		return false, nil
 	}
+	return voteInfo.Status, nil
 }
@@ -61,7 +61,7 @@ func (this *VoteHandler) MakeDepositProposal(service *native.NativeService) (*sc
 	temp := sha256.Sum256(sink.Bytes())
 	id := temp[:]
 
-	ok, err := CheckVotes(service, id, address)
+	ok, err := CheckVotes(service, params.SourceChainID, id, address)
 	if err != nil {
 		return nil, fmt.Errorf("vote MakeDepositProposal, CheckVotes error: %v", err)
 	}
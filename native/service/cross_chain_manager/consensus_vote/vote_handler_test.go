@@ -19,9 +19,11 @@ package consensus_vote
 
 import (
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"github.com/ontio/ontology-crypto/keypair"
 	"github.com/polynetwork/poly/account"
+	"github.com/polynetwork/poly/common/config"
 	"github.com/polynetwork/poly/native/service/governance/node_manager"
 	"reflect"
 	"testing"
@@ -59,6 +61,11 @@ var (
 	acctList3 []*account.Account = []*account.Account{acct1, acct2, acct3, acct4, acct5}
 )
 
+func init() {
+	// the vote configuration is active from genesis on solo net
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+}
+
 func Init(db *storage.CacheDB) {
 	contractAddr, _ := hex.DecodeString("bA6F835ECAE18f5Fc5eBc074e5A0B94422a13126")
 	side := &side_chain_manager.SideChain{
@@ -541,3 +548,195 @@ func TestAutoTriggerConsensusVote(t *testing.T) {
 		assert.NilError(t, err, "test error")
 	}
 }
+
+func putVoteSideChain(db *storage.CacheDB, chainID uint64, info *ExtraInfo) {
+	extra, _ := json.Marshal(info)
+	side := &side_chain_manager.SideChain{
+		Name:      "vote",
+		ChainId:   chainID,
+		Router:    utils.VOTE_ROUTER,
+		ExtraInfo: extra,
+	}
+	sink := common.NewZeroCopySink(nil)
+	_ = side.Serialization(sink)
+	db.Put(utils.ConcatKey(utils.SideChainManagerContractAddress, []byte(side_chain_manager.SIDE_CHAIN), utils.GetUint64Bytes(chainID)), cstates.GenRawStorageItem(sink.Bytes()))
+}
+
+func vote(db *storage.CacheDB, height uint32, chainID uint64, id []byte, acct *account.Account) (bool, error) {
+	ns, _ := native.NewNativeService(db, &types.Transaction{}, 0, height, common.Uint256{0}, 0, nil, false)
+	return CheckVotes(ns, chainID, id, acct.Address)
+}
+
+func TestWeightedConsensusVote(t *testing.T) {
+	store, _ := leveldbstore.NewMemLevelDBStore()
+	db := storage.NewCacheDB(overlaydb.NewOverlayDB(store))
+	Init(db)
+	putVoteSideChain(db, 10, &ExtraInfo{
+		QuorumNumerator:   1,
+		QuorumDenominator: 2,
+		Voters: []*Voter{
+			{Address: acct1.Address.ToBase58(), Weight: 3},
+			{Address: acct2.Address.ToBase58(), Weight: 1},
+			{Address: acct3.Address.ToBase58(), Weight: 1},
+		},
+		ExpiryBlocks: 10,
+	})
+	id := []byte{0x01}
+	ns, _ := native.NewNativeService(db, &types.Transaction{}, 0, 0, common.Uint256{0}, 0, nil, false)
+
+	ok, err := vote(db, 1, 10, id, acct2)
+	assert.NilError(t, err)
+	assert.Equal(t, false, ok)
+	voteInfo, err := getVoteInfo(ns, id)
+	assert.NilError(t, err)
+	assert.Equal(t, uint64(1), voteInfo.VotedWeight)
+	assert.Equal(t, uint64(5), voteInfo.TotalWeight)
+	assert.Equal(t, uint64(3), voteInfo.QuorumWeight)
+	assert.Equal(t, uint32(11), voteInfo.ExpireHeight)
+
+	// a consensus node is not a voter of a chain with configured voters
+	_, err = vote(db, 2, 10, id, acct4)
+	assert.Error(t, err, "CheckVotes, signer is not voter")
+
+	// the votes of an expired message are dropped
+	ok, err = vote(db, 20, 10, id, acct3)
+	assert.NilError(t, err)
+	assert.Equal(t, false, ok)
+	voteInfo, err = getVoteInfo(ns, id)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{acct3.Address.ToBase58()}, voteInfo.GetVoters())
+	assert.Equal(t, uint32(20), voteInfo.StartHeight)
+
+	ok, err = vote(db, 21, 10, id, acct1)
+	assert.NilError(t, err)
+	assert.Equal(t, true, ok)
+	ok, err = vote(db, 22, 10, id, acct2)
+	assert.NilError(t, err)
+	assert.Equal(t, false, ok)
+
+	// the expired records are deleted by later votes of the chain
+	ok, err = vote(db, 40, 10, []byte{0x02}, acct2)
+	assert.NilError(t, err)
+	assert.Equal(t, false, ok)
+	voteInfo, err = getVoteInfo(ns, id)
+	assert.NilError(t, err)
+	assert.Equal(t, 0, len(voteInfo.VoteInfo))
+	head, tail, err := getExpiryRange(ns, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, uint64(2), head)
+	assert.Equal(t, uint64(3), tail)
+}
+
+func TestVoteExtraInfo(t *testing.T) {
+	store, _ := leveldbstore.NewMemLevelDBStore()
+	db := storage.NewCacheDB(overlaydb.NewOverlayDB(store))
+	ns, _ := native.NewNativeService(db, &types.Transaction{}, 0, 0, common.Uint256{0}, 0, nil, false)
+
+	info, err := GetExtraInfo(ns, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, uint64(2), info.QuorumNumerator)
+	assert.Equal(t, uint64(3), info.QuorumDenominator)
+	assert.Equal(t, uint64(5), quorumWeight(info, 7))
+	assert.Equal(t, uint64(3), quorumWeight(info, 4))
+
+	putVoteSideChain(db, 10, &ExtraInfo{QuorumNumerator: 3, QuorumDenominator: 2})
+	_, err = GetExtraInfo(ns, 10)
+	assert.Error(t, err, "GetExtraInfo, invalid quorum 3/2")
+
+	putVoteSideChain(db, 10, &ExtraInfo{Voters: []*Voter{
+		{Address: acct1.Address.ToBase58(), Weight: 1},
+		{Address: acct1.Address.ToBase58(), Weight: 1},
+	}})
+	_, err = GetExtraInfo(ns, 10)
+	assert.Error(t, err, "GetExtraInfo, duplicated voter "+acct1.Address.ToBase58())
+
+	// a chain registered with an ExtraInfo which is not json keeps the default configuration
+	side := &side_chain_manager.SideChain{Name: "vote", ChainId: 10, Router: utils.VOTE_ROUTER, ExtraInfo: []byte{0x01, 0x02}}
+	sink := common.NewZeroCopySink(nil)
+	_ = side.Serialization(sink)
+	db.Put(utils.ConcatKey(utils.SideChainManagerContractAddress, []byte(side_chain_manager.SIDE_CHAIN), utils.GetUint64Bytes(10)), cstates.GenRawStorageItem(sink.Bytes()))
+	info, err = GetExtraInfo(ns, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, uint64(2), info.QuorumNumerator)
+	assert.Equal(t, uint64(3), info.QuorumDenominator)
+	assert.Equal(t, 0, len(info.Voters))
+}
+
+func TestDropExpiredVotes(t *testing.T) {
+	store, _ := leveldbstore.NewMemLevelDBStore()
+	db := storage.NewCacheDB(overlaydb.NewOverlayDB(store))
+	putVoteSideChain(db, 10, &ExtraInfo{
+		Voters: []*Voter{
+			{Address: acct1.Address.ToBase58(), Weight: 1},
+			{Address: acct2.Address.ToBase58(), Weight: 1},
+		},
+		ExpiryBlocks: 10,
+	})
+	ns, _ := native.NewNativeService(db, &types.Transaction{}, 0, 0, common.Uint256{0}, 0, nil, false)
+
+	for i := 0; i <= MAX_EXPIRED_VOTES; i++ {
+		_, err := vote(db, 1, 10, []byte{byte(i)}, acct1)
+		assert.NilError(t, err)
+	}
+	late := []byte{0xff}
+	_, err := vote(db, 5, 10, late, acct1)
+	assert.NilError(t, err)
+
+	// the last message is voted for again while its expired entry is still queued
+	revoted := []byte{byte(MAX_EXPIRED_VOTES)}
+	_, err = vote(db, 20, 10, revoted, acct1)
+	assert.NilError(t, err)
+	head, tail, err := getExpiryRange(ns, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, uint64(MAX_EXPIRED_VOTES), head)
+	assert.Equal(t, uint64(MAX_EXPIRED_VOTES+3), tail)
+
+	// its stale entry doesn't hold back the messages expired after it, nor drop its new record
+	_, err = vote(db, 21, 10, []byte{0xfe}, acct1)
+	assert.NilError(t, err)
+	voteInfo, err := getVoteInfo(ns, late)
+	assert.NilError(t, err)
+	assert.Equal(t, 0, len(voteInfo.VoteInfo))
+	voteInfo, err = getVoteInfo(ns, revoted)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []string{acct1.Address.ToBase58()}, voteInfo.GetVoters())
+	assert.Equal(t, uint32(30), voteInfo.ExpireHeight)
+	head, tail, err = getExpiryRange(ns, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, uint64(MAX_EXPIRED_VOTES+2), head)
+	assert.Equal(t, uint64(MAX_EXPIRED_VOTES+4), tail)
+}
+
+func TestVoteConfigHeight(t *testing.T) {
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_MAIN_NET
+	defer func() { config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET }()
+
+	store, _ := leveldbstore.NewMemLevelDBStore()
+	db := storage.NewCacheDB(overlaydb.NewOverlayDB(store))
+	Init(db)
+	putVoteSideChain(db, 10, &ExtraInfo{
+		Voters:       []*Voter{{Address: acct1.Address.ToBase58(), Weight: 1}},
+		ExpiryBlocks: 10,
+	})
+	ns, _ := native.NewNativeService(db, &types.Transaction{}, 0, 0, common.Uint256{0}, 0, nil, false)
+
+	// below the vote configuration height the consensus nodes vote and nothing expires
+	id := []byte{0x01}
+	ok, err := vote(db, 1, 10, id, acct2)
+	assert.NilError(t, err)
+	assert.Equal(t, false, ok)
+	head, tail, err := getExpiryRange(ns, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, uint64(0), head)
+	assert.Equal(t, uint64(0), tail)
+
+	// and the record keeps the encoding without chain, heights and weights
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteBool(false)
+	sink.WriteUint64(1)
+	sink.WriteString(acct2.Address.ToBase58())
+	sink.WriteBool(true)
+	item, err := utils.GetStorageItem(ns, utils.ConcatKey(utils.CrossChainManagerContractAddress, VoteInfoKey(id)))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, sink.Bytes(), item.Value)
+}
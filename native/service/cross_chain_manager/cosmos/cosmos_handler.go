@@ -25,6 +25,7 @@ import (
 	"github.com/polynetwork/poly/native"
 	scom "github.com/polynetwork/poly/native/service/cross_chain_manager/common"
 	"github.com/polynetwork/poly/native/service/header_sync/cosmos"
+	"github.com/polynetwork/poly/native/service/header_sync/cosmos/cometbft"
 	"github.com/tendermint/tendermint/crypto"
 	"github.com/tendermint/tendermint/crypto/ed25519"
 	"github.com/tendermint/tendermint/crypto/merkle"
@@ -61,6 +62,13 @@ func (this *CosmosHandler) MakeDepositProposal(service *native.NativeService) (*
 	if err := params.Deserialization(common.NewZeroCopySource(service.GetInput())); err != nil {
 		return nil, fmt.Errorf("Cosmos MakeDepositProposal, contract params deserialize error: %s", err)
 	}
+	extraInfo, err := cosmos.GetExtraInfo(service, params.SourceChainID)
+	if err != nil {
+		return nil, fmt.Errorf("Cosmos MakeDepositProposal, %v", err)
+	}
+	if extraInfo != nil {
+		return makeLightDepositProposal(service, params, extraInfo)
+	}
 	info, err := cosmos.GetEpochSwitchInfo(service, params.SourceChainID)
 	if err != nil {
 		return nil, fmt.Errorf("Cosmos MakeDepositProposal, failed to get epoch switching height: %v", err)
@@ -130,3 +138,37 @@ func (this *CosmosHandler) MakeDepositProposal(service *native.NativeService) (*
 	}
 	return txParam, nil
 }
+
+// makeLightDepositProposal verifies the ICS23 proof of the transaction in
+// params.Extra against the app hash of the synced header at params.Height,
+// which commits the state of the block below it
+func makeLightDepositProposal(service *native.NativeService, params *scom.EntranceParam, extraInfo *cosmos.ExtraInfo) (*scom.MakeTxParam, error) {
+	state, err := cosmos.GetConsensusState(service, params.SourceChainID, uint64(params.Height))
+	if err != nil {
+		return nil, fmt.Errorf("Cosmos MakeDepositProposal, %v", err)
+	}
+	if state == nil {
+		return nil, fmt.Errorf("Cosmos MakeDepositProposal, header at height %d is not synced", params.Height)
+	}
+	proof, err := cometbft.DecodeMerkleProof(params.Proof)
+	if err != nil {
+		return nil, fmt.Errorf("Cosmos MakeDepositProposal, unmarshal proof err: %v", err)
+	}
+	if !bytes.HasPrefix(proof.Key(), extraInfo.KeyPrefix) {
+		return nil, fmt.Errorf("Cosmos MakeDepositProposal, key %x is not a cross chain transaction", proof.Key())
+	}
+	if err := proof.VerifyMembership(state.AppHash, extraInfo.StoreName, proof.Key(), params.Extra); err != nil {
+		return nil, fmt.Errorf("Cosmos MakeDepositProposal, proof error: %v", err)
+	}
+	txParam := new(scom.MakeTxParam)
+	if err := txParam.Deserialization(common.NewZeroCopySource(params.Extra)); err != nil {
+		return nil, fmt.Errorf("Cosmos MakeDepositProposal, deserialize merkleValue error:%s", err)
+	}
+	if err := scom.CheckDoneTx(service, txParam.CrossChainID, params.SourceChainID); err != nil {
+		return nil, fmt.Errorf("Cosmos MakeDepositProposal, check done transaction error:%s", err)
+	}
+	if err := scom.PutDoneTx(service, txParam.CrossChainID, params.SourceChainID); err != nil {
+		return nil, fmt.Errorf("Cosmos MakeDepositProposal, PutDoneTx error:%s", err)
+	}
+	return txParam, nil
+}
@@ -0,0 +1,179 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cosmos
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/polynetwork/poly/common"
+	vconfig "github.com/polynetwork/poly/consensus/vbft/config"
+	cstates "github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/core/store/leveldbstore"
+	"github.com/polynetwork/poly/core/store/overlaydb"
+	"github.com/polynetwork/poly/core/types"
+	"github.com/polynetwork/poly/native"
+	ccmcom "github.com/polynetwork/poly/native/service/cross_chain_manager/common"
+	"github.com/polynetwork/poly/native/service/governance/node_manager"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	scom "github.com/polynetwork/poly/native/service/header_sync/common"
+	synccom "github.com/polynetwork/poly/native/service/header_sync/cosmos"
+	"github.com/polynetwork/poly/native/service/header_sync/cosmos/cometbft"
+	"github.com/polynetwork/poly/native/service/utils"
+	"github.com/polynetwork/poly/native/storage"
+	gassert "gotest.tools/assert"
+)
+
+const lightChainID = 7
+
+func newLightNative(args []byte, db *storage.CacheDB) *native.NativeService {
+	tx := &types.Transaction{SignedAddr: []common.Address{acct.Address}}
+	if db == nil {
+		store, _ := leveldbstore.NewMemLevelDBStore()
+		db = storage.NewCacheDB(overlaydb.NewOverlayDB(store))
+		sink := common.NewZeroCopySink(nil)
+		view := &node_manager.GovernanceView{TxHash: common.UINT256_EMPTY}
+		view.Serialization(sink)
+		db.Put(utils.ConcatKey(utils.NodeManagerContractAddress, []byte(node_manager.GOVERNANCE_VIEW)), cstates.GenRawStorageItem(sink.Bytes()))
+
+		peerPoolMap := &node_manager.PeerPoolMap{
+			PeerPoolMap: map[string]*node_manager.PeerPoolItem{
+				vconfig.PubkeyID(acct.PublicKey): {
+					Address:    acct.Address,
+					Status:     node_manager.ConsensusStatus,
+					PeerPubkey: vconfig.PubkeyID(acct.PublicKey),
+				},
+			},
+		}
+		sink.Reset()
+		peerPoolMap.Serialization(sink)
+		db.Put(utils.ConcatKey(utils.NodeManagerContractAddress, []byte(node_manager.PEER_POOL), utils.GetUint32Bytes(0)),
+			cstates.GenRawStorageItem(sink.Bytes()))
+
+		extraInfo, _ := json.Marshal(&synccom.ExtraInfo{TrustingPeriod: 3600, StoreName: "ccm", KeyPrefix: []byte{1}})
+		service, _ := native.NewNativeService(db, tx, 0, 0, common.Uint256{0}, 0, nil, false)
+		_ = side_chain_manager.PutSideChain(service, &side_chain_manager.SideChain{
+			Name:      "cometbft",
+			ChainId:   lightChainID,
+			Router:    utils.COSMOS_ROUTER,
+			ExtraInfo: extraInfo,
+		})
+	}
+	service, _ := native.NewNativeService(db, tx, 1600000000, 0, common.Uint256{0}, 0, args, false)
+	return service
+}
+
+// leafProof proves key in a tree of a single leaf, the root is returned
+func leafProof(prefix, key, value []byte) (*cometbft.ExistenceProof, []byte) {
+	proof := &cometbft.ExistenceProof{
+		Key:   key,
+		Value: value,
+		Leaf:  cometbft.LeafOp{Hash: 1, PrehashValue: 1, Length: 1, Prefix: prefix},
+	}
+	var varint [binary.MaxVarintLen64]byte
+	valueHash := sha256.Sum256(value)
+	data := append([]byte{}, proof.Leaf.Prefix...)
+	data = append(data, varint[:binary.PutUvarint(varint[:], uint64(len(key)))]...)
+	data = append(append(data, key...), 32)
+	data = append(data, valueHash[:]...)
+	root := sha256.Sum256(data)
+	return proof, root[:]
+}
+
+// genesisHeader is a header at height 10 committing appHash signed by a single validator
+func genesisHeader(appHash []byte) []byte {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	pubKey := cometbft.PubKey{Type: cometbft.KeyTypeEd25519, Key: pub}
+	vals := &cometbft.ValidatorSet{Validators: []*cometbft.Validator{{Address: pubKey.Address(), PubKey: pubKey, VotingPower: 10}}}
+	header := &cometbft.Header{
+		Version:            cometbft.Consensus{Block: 11},
+		ChainID:            "light-chain",
+		Height:             10,
+		Time:               cometbft.Timestamp{Seconds: 1600000000},
+		ValidatorsHash:     vals.Hash(),
+		NextValidatorsHash: vals.Hash(),
+		ConsensusHash:      make([]byte, 32),
+		AppHash:            appHash,
+		ProposerAddress:    pubKey.Address(),
+	}
+	commit := &cometbft.Commit{Height: 10, BlockID: cometbft.BlockID{Hash: header.Hash(), PartSetHeader: cometbft.PartSetHeader{Total: 1, Hash: make([]byte, 32)}}}
+	commit.Signatures = []*cometbft.CommitSig{{BlockIDFlag: cometbft.BlockIDFlagCommit, ValidatorAddress: pubKey.Address(), Timestamp: header.Time}}
+	commit.Signatures[0].Signature = ed25519.Sign(priv, commit.VoteSignBytes(header.ChainID, 0))
+	light := &cometbft.LightHeader{SignedHeader: cometbft.SignedHeader{Header: header, Commit: commit}, ValidatorSet: *vals}
+	return light.Marshal()
+}
+
+func TestICS23ProofHandle(t *testing.T) {
+	txParam := &ccmcom.MakeTxParam{
+		TxHash:              []byte{1},
+		CrossChainID:        []byte{2},
+		FromContractAddress: []byte{3},
+		ToChainID:           2,
+		ToContractAddress:   []byte{4},
+		Method:              "unlock",
+		Args:                []byte{5},
+	}
+	sink := common.NewZeroCopySink(nil)
+	txParam.Serialization(sink)
+	value := sink.Bytes()
+
+	// an IAVL leaf at version 1 in the store and a simple merkle leaf in the multistore
+	iavlLeaf, multistoreLeaf := []byte{0, 2, 2}, []byte{0}
+	key := []byte{1, 2}
+	store, storeRoot := leafProof(iavlLeaf, key, value)
+	multistore, appHash := leafProof(multistoreLeaf, []byte("ccm"), storeRoot)
+	proof := (&cometbft.MerkleProof{Proofs: []*cometbft.ExistenceProof{store, multistore}}).Marshal()
+
+	genesisParam := &scom.SyncGenesisHeaderParam{ChainID: lightChainID, GenesisHeader: genesisHeader(appHash)}
+	sink = common.NewZeroCopySink(nil)
+	genesisParam.Serialization(sink)
+	service := newLightNative(sink.Bytes(), nil)
+	db := service.GetCacheDB()
+	gassert.NilError(t, synccom.NewCosmosHandler().SyncGenesisHeader(service))
+
+	makeDepositProposal := func(height uint32, proof, value []byte) (*ccmcom.MakeTxParam, error) {
+		param := &ccmcom.EntranceParam{
+			SourceChainID:  lightChainID,
+			Height:         height,
+			Proof:          proof,
+			RelayerAddress: acct.Address[:],
+			Extra:          value,
+		}
+		sink := common.NewZeroCopySink(nil)
+		param.Serialization(sink)
+		return NewCosmosHandler().MakeDepositProposal(newLightNative(sink.Bytes(), db))
+	}
+
+	_, err := makeDepositProposal(11, proof, value)
+	gassert.ErrorContains(t, err, "is not synced")
+	_, err = makeDepositProposal(10, proof, append(value, 0))
+	gassert.ErrorContains(t, err, "proof error")
+	other, otherRoot := leafProof(iavlLeaf, []byte{2}, value)
+	otherMultistore, _ := leafProof(multistoreLeaf, []byte("ccm"), otherRoot)
+	_, err = makeDepositProposal(10, (&cometbft.MerkleProof{Proofs: []*cometbft.ExistenceProof{other, otherMultistore}}).Marshal(), value)
+	gassert.ErrorContains(t, err, "is not a cross chain transaction")
+
+	result, err := makeDepositProposal(10, proof, value)
+	gassert.NilError(t, err)
+	gassert.DeepEqual(t, result, txParam)
+	_, err = makeDepositProposal(10, proof, value)
+	gassert.ErrorContains(t, err, "tx already done")
+}
@@ -17,87 +17,139 @@
 package cross_chain_manager
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
-	"github.com/polynetwork/poly/native/service/cross_chain_manager/zilliqa"
-	"github.com/polynetwork/poly/native/service/cross_chain_manager/consensus_vote"
 
 	"github.com/polynetwork/poly/common"
 	"github.com/polynetwork/poly/native"
-	"github.com/polynetwork/poly/native/service/cross_chain_manager/bsc"
+	"github.com/polynetwork/poly/native/event"
 	"github.com/polynetwork/poly/native/service/cross_chain_manager/btc"
 	scom "github.com/polynetwork/poly/native/service/cross_chain_manager/common"
-	"github.com/polynetwork/poly/native/service/cross_chain_manager/cosmos"
-	"github.com/polynetwork/poly/native/service/cross_chain_manager/eth"
-	"github.com/polynetwork/poly/native/service/cross_chain_manager/heco"
-	"github.com/polynetwork/poly/native/service/cross_chain_manager/msc"
-	"github.com/polynetwork/poly/native/service/cross_chain_manager/neo"
-	"github.com/polynetwork/poly/native/service/cross_chain_manager/neo3"
-	"github.com/polynetwork/poly/native/service/cross_chain_manager/okex"
-	"github.com/polynetwork/poly/native/service/cross_chain_manager/ont"
-	"github.com/polynetwork/poly/native/service/cross_chain_manager/polygon"
-	"github.com/polynetwork/poly/native/service/cross_chain_manager/quorum"
-	"github.com/polynetwork/poly/native/service/cross_chain_manager/zilliqalegacy"
 	"github.com/polynetwork/poly/native/service/governance/node_manager"
+	"github.com/polynetwork/poly/native/service/governance/relayer_manager"
+	"github.com/polynetwork/poly/native/service/governance/relayer_reward"
 	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	"github.com/polynetwork/poly/native/service/header_sync"
+	hscommon "github.com/polynetwork/poly/native/service/header_sync/common"
+	"github.com/polynetwork/poly/native/service/router"
 	"github.com/polynetwork/poly/native/service/utils"
 )
 
 const (
-	IMPORT_OUTER_TRANSFER_NAME = "ImportOuterTransfer"
-	MULTI_SIGN                 = "MultiSign"
-	BLACK_CHAIN                = "BlackChain"
-	WHITE_CHAIN                = "WhiteChain"
+	IMPORT_OUTER_TRANSFER_NAME       = scom.IMPORT_OUTER_TRANSFER_NAME
+	BATCH_IMPORT_OUTER_TRANSFER_NAME = scom.BATCH_IMPORT_OUTER_TRANSFER_NAME
+	MULTI_SIGN                       = scom.MULTI_SIGN
+	BLACK_CHAIN                      = "BlackChain"
+	WHITE_CHAIN                      = "WhiteChain"
+	PAUSE_ROUTE                      = "PauseRoute"
+	RESUME_ROUTE                     = "ResumeRoute"
+	GET_PAUSED_ROUTE                 = "GetPausedRoute"
+	GET_PAUSED_ROUTES                = "GetPausedRoutes"
+	SET_ROUTE_LIMIT                  = "SetRouteLimit"
+	REMOVE_ROUTE_LIMIT               = "RemoveRouteLimit"
+	GET_ROUTE_LIMIT                  = "GetRouteLimit"
+	GET_ROUTE_USAGE                  = "GetRouteUsage"
+	ACK_EXECUTION                    = "AckExecution"
+	TIMEOUT_REQUEST                  = "TimeoutRequest"
 
 	BLACKED_CHAIN = "BlackedChain"
+	PAUSED_ROUTE  = "PausedRoute"
+	ROUTE_LIMIT   = "RouteLimit"
+	ROUTE_USAGE   = "RouteUsage"
 )
 
 func RegisterCrossChainManagerContract(native *native.NativeService) {
 	native.Register(IMPORT_OUTER_TRANSFER_NAME, ImportExTransfer)
+	native.Register(BATCH_IMPORT_OUTER_TRANSFER_NAME, BatchImportExTransfer)
 	native.Register(MULTI_SIGN, MultiSign)
+	native.Register(ACK_EXECUTION, AckExecution)
+	native.Register(TIMEOUT_REQUEST, TimeoutRequest)
 
 	native.Register(BLACK_CHAIN, BlackChain)
 	native.Register(WHITE_CHAIN, WhiteChain)
+
+	native.Register(PAUSE_ROUTE, PauseRoute)
+	native.Register(RESUME_ROUTE, ResumeRoute)
+	native.Register(GET_PAUSED_ROUTE, GetPausedRoute)
+	native.Register(GET_PAUSED_ROUTES, GetPausedRoutes)
+
+	native.Register(SET_ROUTE_LIMIT, SetRouteLimit)
+	native.Register(REMOVE_ROUTE_LIMIT, RemoveRouteLimit)
+	native.Register(GET_ROUTE_LIMIT, GetRouteLimit)
+	native.Register(GET_ROUTE_USAGE, GetRouteUsage)
 }
 
-func GetChainHandler(router uint64) (scom.ChainHandler, error) {
-	switch router {
-	case utils.VOTE_ROUTER:
-		return consensus_vote.NewVoteHandler(), nil
-	case utils.BTC_ROUTER:
-		return btc.NewBTCHandler(), nil
-	case utils.ETH_ROUTER:
-		return eth.NewETHHandler(), nil
-	case utils.ONT_ROUTER:
-		return ont.NewONTHandler(), nil
-	case utils.NEO_ROUTER:
-		return neo.NewNEOHandler(), nil
-	case utils.NEO3_ROUTER:
-		return neo3.NewNeo3Handler(), nil
-	case utils.COSMOS_ROUTER:
-		return cosmos.NewCosmosHandler(), nil
-	case utils.QUORUM_ROUTER:
-		return quorum.NewQuorumHandler(), nil
-	case utils.BSC_ROUTER:
-		return bsc.NewHandler(), nil
-	case utils.HECO_ROUTER:
-		return heco.NewHecoHandler(), nil
-	case utils.ZILLIQA_LEGACY_ROUTER:
-		return zilliqalegacy.NewHandler(), nil
-	case utils.ZILLIQA_ROUTER:
-		return zilliqa.NewHandler(), nil
-	case utils.MSC_ROUTER:
-		return msc.NewHandler(), nil
-	case utils.OKEX_ROUTER:
-		return okex.NewHandler(), nil
-	case utils.POLYGON_BOR_ROUTER:
-		return polygon.NewHandler(), nil
-	default:
-		return nil, fmt.Errorf("not a supported router:%d", router)
+func ImportExTransfer(native *native.NativeService) ([]byte, error) {
+	params := new(scom.EntranceParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("ImportExTransfer, contract params deserialize error: %v", err)
+	}
+	if err := relayer_manager.CheckRelayerScope(native, IMPORT_OUTER_TRANSFER_NAME, params.SourceChainID); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("ImportExTransfer, %v", err)
 	}
+	return importExTransfer(native, native.GetTx().Hash())
 }
 
-func ImportExTransfer(native *native.NativeService) ([]byte, error) {
+// BatchImportExTransfer imports every entrance param in its own storage cache, so a failed param
+// does not revert the others. The first request is made under the poly tx hash and the others
+// under sha256(poly tx hash, index).
+func BatchImportExTransfer(native *native.NativeService) ([]byte, error) {
+	params := new(scom.BatchEntranceParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("BatchImportExTransfer, contract params deserialize error: %v", err)
+	}
+	if len(params.Params) == 0 {
+		return utils.BYTE_FALSE, fmt.Errorf("BatchImportExTransfer, no entrance params")
+	}
+	chainIDs := make([]uint64, 0, len(params.Params))
+	for _, param := range params.Params {
+		chainIDs = append(chainIDs, param.SourceChainID)
+	}
+	if err := relayer_manager.CheckRelayerScope(native, BATCH_IMPORT_OUTER_TRANSFER_NAME, chainIDs...); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("BatchImportExTransfer, %v", err)
+	}
+
+	txHash := native.GetTx().Hash()
+	results := &BatchImportResults{Results: make([]*BatchImportResult, 0, len(params.Params))}
+	for i, param := range params.Params {
+		requestHash := txHash
+		if i != 0 {
+			requestHash = sha256.Sum256(append(txHash.ToArray(), utils.GetUint32Bytes(uint32(i))...))
+		}
+		sink := common.NewZeroCopySink(nil)
+		param.Serialization(sink)
+		res, err := native.SubCall(importHandler(requestHash), sink.Bytes())
+
+		result := &BatchImportResult{Success: err == nil && bytes.Equal(res, utils.BYTE_TRUE)}
+		if err != nil {
+			result.Reason = err.Error()
+		} else if !result.Success {
+			result.Reason = "rejected"
+		}
+		results.Results = append(results.Results, result)
+		native.AddNotify(
+			&event.NotifyEventInfo{
+				ContractAddress: utils.CrossChainManagerContractAddress,
+				States: []interface{}{BATCH_IMPORT_OUTER_TRANSFER_NAME, i, param.SourceChainID,
+					requestHash.ToHexString(), result.Success, result.Reason},
+			})
+	}
+	sink := common.NewZeroCopySink(nil)
+	results.Serialization(sink)
+	return sink.Bytes(), nil
+}
+
+func importHandler(requestHash common.Uint256) native.Handler {
+	return func(native *native.NativeService) ([]byte, error) {
+		return importExTransfer(native, requestHash)
+	}
+}
+
+// importExTransfer makes the request under requestHash, which is the poly tx hash
+// unless the request is part of a batch.
+func importExTransfer(native *native.NativeService, requestHash common.Uint256) ([]byte, error) {
 	params := new(scom.EntranceParam)
 	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
 		return utils.BYTE_FALSE, fmt.Errorf("ImportExTransfer, contract params deserialize error: %v", err)
@@ -121,16 +173,18 @@ func ImportExTransfer(native *native.NativeService) ([]byte, error) {
 		return utils.BYTE_FALSE, fmt.Errorf("ImportExTransfer, side chain %d is not registered", chainID)
 	}
 
-	handler, err := GetChainHandler(sideChain.Router)
+	handler, err := router.GetChainHandler(native, sideChain.Router)
 	if err != nil {
 		return utils.BYTE_FALSE, err
 	}
-	//1. verify tx
+	//1. verify tx, the handler marks the source tx done in a snapshot dropped if the target call is rejected
+	snapshot := native.GetCacheDB().Snapshot()
 	txParam, err := handler.MakeDepositProposal(native)
 	if err != nil {
 		return utils.BYTE_FALSE, err
 	}
 	if txParam == nil && sideChain.Router == utils.VOTE_ROUTER {
+		native.GetCacheDB().CommitSnapshot(snapshot)
 		return utils.BYTE_TRUE, nil
 	}
 
@@ -143,6 +197,13 @@ func ImportExTransfer(native *native.NativeService) ([]byte, error) {
 	if blacked {
 		return utils.BYTE_FALSE, fmt.Errorf("ImportExTransfer, target chain is blacked")
 	}
+	paused, err := CheckIfRoutePaused(native, chainID, targetid)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("ImportExTransfer, CheckIfRoutePaused error: %v", err)
+	}
+	if paused {
+		return utils.BYTE_FALSE, fmt.Errorf("ImportExTransfer, route from chain %d to chain %d is paused", chainID, targetid)
+	}
 
 	//check if chainid exist
 	sideChain, err = side_chain_manager.GetSideChain(native, targetid)
@@ -152,23 +213,161 @@ func ImportExTransfer(native *native.NativeService) ([]byte, error) {
 	if sideChain == nil {
 		return utils.BYTE_FALSE, fmt.Errorf("ImportExTransfer, side chain %d is not registered", targetid)
 	}
+	// a rejected call is not marked done, so the source tx can be imported again once the call is
+	// allowed, and the tx succeeds so that the event is kept
+	if err := sideChain.CheckTargetCall(txParam.ToContractAddress, txParam.Method); err != nil {
+		native.GetCacheDB().RevertToSnapshot(snapshot)
+		scom.NotifyRejectCall(native, chainID, targetid, hex.EncodeToString(txParam.TxHash),
+			hex.EncodeToString(txParam.ToContractAddress), txParam.Method, err.Error())
+		return utils.BYTE_FALSE, nil
+	}
+	native.GetCacheDB().CommitSnapshot(snapshot)
+	// a request over the route limit is reverted, the relayer can submit it again in a later window
+	if err := checkRouteLimit(native, chainID, txParam); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("ImportExTransfer, %w", err)
+	}
+	request := &scom.CrossChainRequest{
+		FromChainID:   chainID,
+		ToChainID:     targetid,
+		SourceTxHash:  txParam.TxHash,
+		CrossChainID:  txParam.CrossChainID,
+		PolyTxHash:    requestHash,
+		TimeoutHeight: txParam.TimeoutHeight,
+		SourceHeight:  params.Height,
+	}
+	if err := scom.PutCrossChainRequest(native, request); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("ImportExTransfer, %v", err)
+	}
+	// the header the request was proven with is kept until the request is finished, a request
+	// which is never acknowledged nor timed out must not keep it forever, so its header is left
+	// prunable and falls out of the retention window like any other
+	if isRequestFinishable(native, sideChain.Router, request) {
+		if err := hscommon.PinHeader(native, chainID, uint64(params.Height)); err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("ImportExTransfer, %v", err)
+		}
+	}
+	if err := relayer_reward.RecordImport(native, chainID, params.RelayerAddress); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("ImportExTransfer, %v", err)
+	}
+	// a request imported after its timeout is never made, the source chain gets the timeout proof instead
+	if request.TimeoutHeight != 0 && native.GetHeight() > request.TimeoutHeight {
+		if err := makeTimeoutProof(native, request); err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("ImportExTransfer, %v", err)
+		}
+		return utils.BYTE_TRUE, nil
+	}
 	if sideChain.Router == utils.BTC_ROUTER {
 		err := btc.NewBTCHandler().MakeTransaction(native, txParam, chainID)
 		if err != nil {
 			return utils.BYTE_FALSE, err
 		}
+		if err := scom.UpdateCrossChainRequestStatus(native, requestHash, scom.REQUEST_MADE_PROOF); err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("ImportExTransfer, %v", err)
+		}
 		return utils.BYTE_TRUE, nil
 	}
 
 	//NOTE, you need to store the tx in this
-	err = MakeTransaction(native, txParam, chainID)
+	err = makeTransaction(native, txParam, chainID, requestHash)
 	if err != nil {
 		return utils.BYTE_FALSE, err
 	}
 	return utils.BYTE_TRUE, nil
 }
 
+// isRequestFinishable reports whether request can be finished, either by an acknowledgement
+// through targetRouter or by its timeout. Only the requests which can be finished pin the header
+// they were proven with.
+func isRequestFinishable(native *native.NativeService, targetRouter uint64, request *scom.CrossChainRequest) bool {
+	if request.TimeoutHeight != 0 {
+		return true
+	}
+	handler, err := router.GetChainHandler(native, targetRouter)
+	if err != nil {
+		return false
+	}
+	_, ok := handler.(scom.AckHandler)
+	return ok
+}
+
+// AckExecution verifies the receipt of a request executed on the target chain, the
+// entrance param is read with SourceChainID set to the target chain.
+func AckExecution(native *native.NativeService) ([]byte, error) {
+	params := new(scom.EntranceParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("AckExecution, contract params deserialize error: %v", err)
+	}
+
+	chainID := params.SourceChainID
+	if err := relayer_manager.CheckRelayerScope(native, ACK_EXECUTION, chainID); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("AckExecution, %v", err)
+	}
+	sideChain, err := side_chain_manager.GetSideChain(native, chainID)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("AckExecution, side_chain_manager.GetSideChain error: %v", err)
+	}
+	if sideChain == nil {
+		return utils.BYTE_FALSE, fmt.Errorf("AckExecution, side chain %d is not registered", chainID)
+	}
+	handler, err := router.GetChainHandler(native, sideChain.Router)
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	ackHandler, ok := handler.(scom.AckHandler)
+	if !ok {
+		return utils.BYTE_FALSE, fmt.Errorf("AckExecution, router %d does not support acknowledgements", sideChain.Router)
+	}
+	receipt, err := ackHandler.VerifyExecution(native)
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+
+	polyTxHash, err := common.Uint256ParseFromBytes(receipt.PolyTxHash)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("AckExecution, invalid poly tx hash: %v", err)
+	}
+	request, err := scom.GetCrossChainRequest(native, polyTxHash)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("AckExecution, %v", err)
+	}
+	if request == nil {
+		return utils.BYTE_FALSE, fmt.Errorf("AckExecution, request %s is not recorded", polyTxHash.ToHexString())
+	}
+	if request.ToChainID != chainID || request.FromChainID != receipt.FromChainID ||
+		!bytes.Equal(request.CrossChainID, receipt.CrossChainID) {
+		return utils.BYTE_FALSE, fmt.Errorf("AckExecution, receipt does not match request %s", polyTxHash.ToHexString())
+	}
+	if request.Status != scom.REQUEST_MADE_PROOF {
+		return utils.BYTE_FALSE, fmt.Errorf("AckExecution, request %s is %s", polyTxHash.ToHexString(),
+			scom.RequestStatusNames[request.Status])
+	}
+
+	status := scom.REQUEST_DELIVERED
+	if !receipt.Success {
+		status = scom.REQUEST_FAILED
+	}
+	if err := scom.UpdateCrossChainRequestStatus(native, polyTxHash, status); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("AckExecution, %v", err)
+	}
+	if err := header_sync.ReleaseHeader(native, request.FromChainID, uint64(request.SourceHeight)); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("AckExecution, %v", err)
+	}
+	native.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.CrossChainManagerContractAddress,
+			States:          []interface{}{ACK_EXECUTION, request.FromChainID, chainID, polyTxHash.ToHexString(), receipt.Success},
+		})
+	return utils.BYTE_TRUE, nil
+}
+
 func MultiSign(native *native.NativeService) ([]byte, error) {
+	params := new(scom.MultiSignParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("MultiSign, contract params deserialize error: %v", err)
+	}
+	if err := relayer_manager.CheckRelayerScope(native, MULTI_SIGN, params.ChainID); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("MultiSign, %v", err)
+	}
 	handler := btc.NewBTCHandler()
 
 	//1. multi sign
@@ -180,7 +379,10 @@ func MultiSign(native *native.NativeService) ([]byte, error) {
 }
 
 func MakeTransaction(service *native.NativeService, params *scom.MakeTxParam, fromChainID uint64) error {
-	txHash := service.GetTx().Hash()
+	return makeTransaction(service, params, fromChainID, service.GetTx().Hash())
+}
+
+func makeTransaction(service *native.NativeService, params *scom.MakeTxParam, fromChainID uint64, txHash common.Uint256) error {
 	merkleValue := &scom.ToMerkleValue{
 		TxHash:      txHash.ToArray(),
 		FromChainID: fromChainID,
@@ -194,12 +396,76 @@ func MakeTransaction(service *native.NativeService, params *scom.MakeTxParam, fr
 		return fmt.Errorf("MakeTransaction, putRequest error:%s", err)
 	}
 	service.PutMerkleVal(sink.Bytes())
+	if err := scom.UpdateCrossChainRequestStatus(service, txHash, scom.REQUEST_MADE_PROOF); err != nil {
+		return fmt.Errorf("MakeTransaction, %v", err)
+	}
 	chainIDBytes := utils.GetUint64Bytes(params.ToChainID)
 	key := hex.EncodeToString(utils.ConcatKey(utils.CrossChainManagerContractAddress, []byte(scom.REQUEST), chainIDBytes, merkleValue.TxHash))
 	scom.NotifyMakeProof(service, fromChainID, params.ToChainID, hex.EncodeToString(params.TxHash), key)
 	return nil
 }
 
+// TimeoutRequest marks a request which was not acknowledged before its timeout height as unreachable,
+// and puts the timeout proof for the source chain to unlock or refund the user. Target chains should not
+// execute a request once the poly header they verify it with is above its timeout height.
+func TimeoutRequest(native *native.NativeService) ([]byte, error) {
+	params := new(TimeoutRequestParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("TimeoutRequest, contract params deserialize error: %v", err)
+	}
+	// anyone may time out a request, only the methods of a scoped relayer are checked
+	if err := relayer_manager.CheckRelayerScope(native, TIMEOUT_REQUEST); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("TimeoutRequest, %v", err)
+	}
+	polyTxHash, err := common.Uint256ParseFromBytes(params.PolyTxHash)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("TimeoutRequest, invalid poly tx hash: %v", err)
+	}
+	request, err := scom.GetCrossChainRequest(native, polyTxHash)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("TimeoutRequest, %v", err)
+	}
+	if request == nil {
+		return utils.BYTE_FALSE, fmt.Errorf("TimeoutRequest, request %s is not recorded", polyTxHash.ToHexString())
+	}
+	if request.TimeoutHeight == 0 || native.GetHeight() <= request.TimeoutHeight {
+		return utils.BYTE_FALSE, fmt.Errorf("TimeoutRequest, request %s has not timed out", polyTxHash.ToHexString())
+	}
+	if request.Status != scom.REQUEST_MADE_PROOF {
+		return utils.BYTE_FALSE, fmt.Errorf("TimeoutRequest, request %s is %s", polyTxHash.ToHexString(),
+			scom.RequestStatusNames[request.Status])
+	}
+	if err := makeTimeoutProof(native, request); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("TimeoutRequest, %v", err)
+	}
+	return utils.BYTE_TRUE, nil
+}
+
+func makeTimeoutProof(native *native.NativeService, request *scom.CrossChainRequest) error {
+	value := &scom.TimeoutMerkleValue{
+		PolyTxHash:    request.PolyTxHash.ToArray(),
+		FromChainID:   request.FromChainID,
+		ToChainID:     request.ToChainID,
+		SourceTxHash:  request.SourceTxHash,
+		CrossChainID:  request.CrossChainID,
+		TimeoutHeight: request.TimeoutHeight,
+	}
+	sink := common.NewZeroCopySink(nil)
+	value.Serialization(sink)
+	key := utils.ConcatKey(utils.CrossChainManagerContractAddress, []byte(scom.TIMEOUT),
+		utils.GetUint64Bytes(request.FromChainID), value.PolyTxHash)
+	utils.PutBytes(native, key, sink.Bytes())
+	native.PutMerkleVal(sink.Bytes())
+	if err := scom.UpdateCrossChainRequestStatus(native, request.PolyTxHash, scom.REQUEST_UNREACHABLE); err != nil {
+		return fmt.Errorf("makeTimeoutProof, %v", err)
+	}
+	if err := header_sync.ReleaseHeader(native, request.FromChainID, uint64(request.SourceHeight)); err != nil {
+		return fmt.Errorf("makeTimeoutProof, %v", err)
+	}
+	scom.NotifyTimeoutProof(native, request.FromChainID, request.ToChainID, hex.EncodeToString(request.SourceTxHash), hex.EncodeToString(key))
+	return nil
+}
+
 func PutRequest(native *native.NativeService, txHash []byte, chainID uint64, request []byte) error {
 	contract := utils.CrossChainManagerContractAddress
 	chainIDBytes := utils.GetUint64Bytes(chainID)
@@ -248,3 +514,204 @@ func WhiteChain(native *native.NativeService) ([]byte, error) {
 	RemoveBlackChain(native, params.ChainID)
 	return utils.BYTE_TRUE, nil
 }
+
+func PauseRoute(native *native.NativeService) ([]byte, error) {
+	params := new(PauseRouteParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("PauseRoute, contract params deserialize error: %v", err)
+	}
+	if params.ExpireHeight != 0 && params.ExpireHeight <= native.GetHeight() {
+		return utils.BYTE_FALSE, fmt.Errorf("PauseRoute, expire height %d is not above current height %d", params.ExpireHeight, native.GetHeight())
+	}
+
+	// Get current epoch operator
+	operatorAddress, err := node_manager.GetCurConOperator(native)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("PauseRoute, get current consensus operator address error: %v", err)
+	}
+	//check witness
+	err = utils.ValidateOwner(native, operatorAddress)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("PauseRoute, checkWitness error: %v", err)
+	}
+
+	putPausedRoute(native, &PausedRoute{
+		SourceChainID: params.SourceChainID,
+		ToChainID:     params.ToChainID,
+		PauseHeight:   native.GetHeight(),
+		ExpireHeight:  params.ExpireHeight,
+	})
+	native.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.CrossChainManagerContractAddress,
+			States:          []interface{}{PAUSE_ROUTE, params.SourceChainID, params.ToChainID, params.ExpireHeight},
+		})
+	return utils.BYTE_TRUE, nil
+}
+
+func ResumeRoute(native *native.NativeService) ([]byte, error) {
+	params := new(RouteParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("ResumeRoute, contract params deserialize error: %v", err)
+	}
+
+	// Get current epoch operator
+	operatorAddress, err := node_manager.GetCurConOperator(native)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("ResumeRoute, get current consensus operator address error: %v", err)
+	}
+	//check witness
+	err = utils.ValidateOwner(native, operatorAddress)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("ResumeRoute, checkWitness error: %v", err)
+	}
+
+	route, err := getPausedRoute(native, params.SourceChainID, params.ToChainID)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("ResumeRoute, getPausedRoute error: %v", err)
+	}
+	if route == nil {
+		return utils.BYTE_FALSE, fmt.Errorf("ResumeRoute, route from chain %d to chain %d is not paused", params.SourceChainID, params.ToChainID)
+	}
+	removePausedRoute(native, params.SourceChainID, params.ToChainID)
+	native.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.CrossChainManagerContractAddress,
+			States:          []interface{}{RESUME_ROUTE, params.SourceChainID, params.ToChainID},
+		})
+	return utils.BYTE_TRUE, nil
+}
+
+func GetPausedRoute(native *native.NativeService) ([]byte, error) {
+	params := new(RouteParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetPausedRoute, contract params deserialize error: %v", err)
+	}
+	route, err := getPausedRoute(native, params.SourceChainID, params.ToChainID)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetPausedRoute, getPausedRoute error: %v", err)
+	}
+	if route == nil || !route.IsActive(native.GetHeight()) {
+		return utils.BYTE_FALSE, nil
+	}
+	sink := common.NewZeroCopySink(nil)
+	route.Serialization(sink)
+	return sink.Bytes(), nil
+}
+
+func GetPausedRoutes(native *native.NativeService) ([]byte, error) {
+	routes, err := getPausedRoutes(native)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetPausedRoutes, getPausedRoutes error: %v", err)
+	}
+	list := &PausedRouteList{Routes: make([]*PausedRoute, 0, len(routes))}
+	for _, route := range routes {
+		if route.IsActive(native.GetHeight()) {
+			list.Routes = append(list.Routes, route)
+		}
+	}
+	sink := common.NewZeroCopySink(nil)
+	list.Serialization(sink)
+	return sink.Bytes(), nil
+}
+
+func SetRouteLimit(native *native.NativeService) ([]byte, error) {
+	params := new(RouteLimit)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetRouteLimit, contract params deserialize error: %v", err)
+	}
+	if params.Window == 0 {
+		return utils.BYTE_FALSE, fmt.Errorf("SetRouteLimit, window should be greater than 0")
+	}
+	if params.MaxCount == 0 && len(params.AssetCaps) == 0 {
+		return utils.BYTE_FALSE, fmt.Errorf("SetRouteLimit, neither max count nor asset caps is set")
+	}
+	for _, assetCap := range params.AssetCaps {
+		if len(assetCap.ToAssetHash) == 0 || assetCap.MaxAmount.Sign() <= 0 {
+			return utils.BYTE_FALSE, fmt.Errorf("SetRouteLimit, invalid cap of asset %x", assetCap.ToAssetHash)
+		}
+	}
+
+	// Get current epoch operator
+	operatorAddress, err := node_manager.GetCurConOperator(native)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetRouteLimit, get current consensus operator address error: %v", err)
+	}
+	//check witness
+	err = utils.ValidateOwner(native, operatorAddress)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetRouteLimit, checkWitness error: %v", err)
+	}
+
+	putRouteLimit(native, params)
+	native.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.CrossChainManagerContractAddress,
+			States:          []interface{}{SET_ROUTE_LIMIT, params.SourceChainID, params.ToChainID, params.Window, params.MaxCount},
+		})
+	return utils.BYTE_TRUE, nil
+}
+
+func RemoveRouteLimit(native *native.NativeService) ([]byte, error) {
+	params := new(RouteParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("RemoveRouteLimit, contract params deserialize error: %v", err)
+	}
+
+	// Get current epoch operator
+	operatorAddress, err := node_manager.GetCurConOperator(native)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("RemoveRouteLimit, get current consensus operator address error: %v", err)
+	}
+	//check witness
+	err = utils.ValidateOwner(native, operatorAddress)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("RemoveRouteLimit, checkWitness error: %v", err)
+	}
+
+	limit, err := getRouteLimit(native, params.SourceChainID, params.ToChainID)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("RemoveRouteLimit, getRouteLimit error: %v", err)
+	}
+	if limit == nil {
+		return utils.BYTE_FALSE, fmt.Errorf("RemoveRouteLimit, route from chain %d to chain %d has no limit", params.SourceChainID, params.ToChainID)
+	}
+	removeRouteLimit(native, params.SourceChainID, params.ToChainID)
+	native.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.CrossChainManagerContractAddress,
+			States:          []interface{}{REMOVE_ROUTE_LIMIT, params.SourceChainID, params.ToChainID},
+		})
+	return utils.BYTE_TRUE, nil
+}
+
+func GetRouteLimit(native *native.NativeService) ([]byte, error) {
+	params := new(RouteParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetRouteLimit, contract params deserialize error: %v", err)
+	}
+	limit, err := getRouteLimit(native, params.SourceChainID, params.ToChainID)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetRouteLimit, getRouteLimit error: %v", err)
+	}
+	if limit == nil {
+		return utils.BYTE_FALSE, nil
+	}
+	sink := common.NewZeroCopySink(nil)
+	limit.Serialization(sink)
+	return sink.Bytes(), nil
+}
+
+func GetRouteUsage(native *native.NativeService) ([]byte, error) {
+	params := new(RouteParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetRouteUsage, contract params deserialize error: %v", err)
+	}
+	usage, err := getRouteUsage(native, params.SourceChainID, params.ToChainID)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetRouteUsage, getRouteUsage error: %v", err)
+	}
+	sink := common.NewZeroCopySink(nil)
+	usage.Serialization(sink)
+	return sink.Bytes(), nil
+}
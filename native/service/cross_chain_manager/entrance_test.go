@@ -0,0 +1,703 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cross_chain_manager
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"strconv"
+	"testing"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/polynetwork/poly/account"
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/common/config"
+	vconfig "github.com/polynetwork/poly/consensus/vbft/config"
+	cstates "github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/core/store/leveldbstore"
+	"github.com/polynetwork/poly/core/store/overlaydb"
+	"github.com/polynetwork/poly/core/types"
+	"github.com/polynetwork/poly/native"
+	scom "github.com/polynetwork/poly/native/service/cross_chain_manager/common"
+	"github.com/polynetwork/poly/native/service/governance/node_manager"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	hscommon "github.com/polynetwork/poly/native/service/header_sync/common"
+	"github.com/polynetwork/poly/native/service/router"
+	"github.com/polynetwork/poly/native/service/utils"
+	"github.com/polynetwork/poly/native/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	conAccts = func() []*account.Account {
+		accts := make([]*account.Account, 0)
+		for i := 0; i < 4; i++ {
+			accts = append(accts, account.NewAccount(strconv.FormatUint(uint64(i), 10)))
+		}
+		return accts
+	}()
+	operator = func() common.Address {
+		pks := make([]keypair.PublicKey, 0, len(conAccts))
+		for _, acct := range conAccts {
+			pks = append(pks, acct.PublicKey)
+		}
+		addr, _ := types.AddressFromBookkeepers(pks)
+		return addr
+	}()
+)
+
+func putPeerMapPoolAndView(db *storage.CacheDB) {
+	peerPoolMap := new(node_manager.PeerPoolMap)
+	peerPoolMap.PeerPoolMap = make(map[string]*node_manager.PeerPoolItem)
+	for i, conAcct := range conAccts {
+		pkStr := vconfig.PubkeyID(conAcct.PublicKey)
+		peerPoolMap.PeerPoolMap[pkStr] = &node_manager.PeerPoolItem{
+			Index:      uint32(i),
+			PeerPubkey: pkStr,
+			Address:    conAcct.Address,
+			Status:     node_manager.ConsensusStatus,
+		}
+	}
+	viewBytes := utils.GetUint32Bytes(0)
+	sink := common.NewZeroCopySink(nil)
+	peerPoolMap.Serialization(sink)
+	db.Put(utils.ConcatKey(utils.NodeManagerContractAddress, []byte(node_manager.PEER_POOL), viewBytes), cstates.GenRawStorageItem(sink.Bytes()))
+
+	govView := node_manager.GovernanceView{
+		View:   0,
+		Height: 10,
+		TxHash: common.UINT256_EMPTY,
+	}
+	sink = common.NewZeroCopySink(nil)
+	govView.Serialization(sink)
+	db.Put(utils.ConcatKey(utils.NodeManagerContractAddress, []byte(node_manager.GOVERNANCE_VIEW)), cstates.GenRawStorageItem(sink.Bytes()))
+}
+
+func NewNative(args []byte, tx *types.Transaction, db *storage.CacheDB, height uint32) *native.NativeService {
+	if db == nil {
+		store, _ := leveldbstore.NewMemLevelDBStore()
+		db = storage.NewCacheDB(overlaydb.NewOverlayDB(store))
+		putPeerMapPoolAndView(db)
+	}
+	ns, _ := native.NewNativeService(db, tx, 0, height, common.Uint256{0}, 0, args, false)
+	return ns
+}
+
+func TestPauseRoute(t *testing.T) {
+	param := &PauseRouteParam{SourceChainID: 2, ToChainID: 6, ExpireHeight: 100}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+
+	// only the consensus operator can pause a route
+	ns := NewNative(sink.Bytes(), &types.Transaction{SignedAddr: []common.Address{conAccts[0].Address}}, nil, 10)
+	_, err := PauseRoute(ns)
+	assert.NotNil(t, err)
+
+	ns = NewNative(sink.Bytes(), &types.Transaction{SignedAddr: []common.Address{operator}}, ns.GetCacheDB(), 10)
+	res, err := PauseRoute(ns)
+	assert.Nil(t, err)
+	assert.Equal(t, utils.BYTE_TRUE, res)
+
+	paused, err := CheckIfRoutePaused(ns, 2, 6)
+	assert.Nil(t, err)
+	assert.True(t, paused)
+	paused, err = CheckIfRoutePaused(ns, 2, 3)
+	assert.Nil(t, err)
+	assert.False(t, paused)
+	paused, err = CheckIfRoutePaused(ns, 6, 2)
+	assert.Nil(t, err)
+	assert.False(t, paused)
+
+	ns = NewNative(nil, &types.Transaction{}, ns.GetCacheDB(), 99)
+	res, err = GetPausedRoutes(ns)
+	assert.Nil(t, err)
+	list := new(PausedRouteList)
+	assert.Nil(t, list.Deserialization(common.NewZeroCopySource(res)))
+	assert.Equal(t, []*PausedRoute{{SourceChainID: 2, ToChainID: 6, PauseHeight: 10, ExpireHeight: 100}}, list.Routes)
+
+	// the pause expires at its expire height
+	ns = NewNative(nil, &types.Transaction{}, ns.GetCacheDB(), 100)
+	paused, err = CheckIfRoutePaused(ns, 2, 6)
+	assert.Nil(t, err)
+	assert.False(t, paused)
+}
+
+func TestResumeRoute(t *testing.T) {
+	pause := &PauseRouteParam{SourceChainID: 2, ToChainID: 6}
+	sink := common.NewZeroCopySink(nil)
+	pause.Serialization(sink)
+	tx := &types.Transaction{SignedAddr: []common.Address{operator}}
+	ns := NewNative(sink.Bytes(), tx, nil, 10)
+	_, err := PauseRoute(ns)
+	assert.Nil(t, err)
+
+	resume := &RouteParam{SourceChainID: 2, ToChainID: 6}
+	sink = common.NewZeroCopySink(nil)
+	resume.Serialization(sink)
+	ns = NewNative(sink.Bytes(), tx, ns.GetCacheDB(), 11)
+	res, err := GetPausedRoute(ns)
+	assert.Nil(t, err)
+	assert.NotEqual(t, utils.BYTE_FALSE, res)
+
+	res, err = ResumeRoute(ns)
+	assert.Nil(t, err)
+	assert.Equal(t, utils.BYTE_TRUE, res)
+	paused, err := CheckIfRoutePaused(ns, 2, 6)
+	assert.Nil(t, err)
+	assert.False(t, paused)
+
+	// resuming a route that is not paused fails
+	_, err = ResumeRoute(ns)
+	assert.NotNil(t, err)
+}
+
+func unlockTxParam(toAssetHash []byte, amount int64) *scom.MakeTxParam {
+	args := &scom.TxArgs{
+		ToAssetHash: toAssetHash,
+		ToAddress:   []byte{1, 2, 3},
+		Amount:      big.NewInt(amount),
+	}
+	sink := common.NewZeroCopySink(nil)
+	args.Serialization(sink)
+	return &scom.MakeTxParam{
+		ToChainID: 6,
+		Method:    scom.LOCK_PROXY_UNLOCK,
+		Args:      sink.Bytes(),
+	}
+}
+
+func TestTxArgs(t *testing.T) {
+	param := unlockTxParam([]byte{0xaa}, 1000000)
+	args := new(scom.TxArgs)
+	assert.Nil(t, args.Deserialization(common.NewZeroCopySource(param.Args)))
+	assert.Equal(t, []byte{0xaa}, args.ToAssetHash)
+	assert.Equal(t, []byte{1, 2, 3}, args.ToAddress)
+	assert.Equal(t, big.NewInt(1000000), args.Amount)
+}
+
+func TestSetRouteLimit(t *testing.T) {
+	limit := &RouteLimit{SourceChainID: 2, ToChainID: 6, Window: 10, MaxCount: 2}
+	sink := common.NewZeroCopySink(nil)
+	limit.Serialization(sink)
+
+	// only the consensus operator can set a limit
+	ns := NewNative(sink.Bytes(), &types.Transaction{SignedAddr: []common.Address{conAccts[0].Address}}, nil, 10)
+	_, err := SetRouteLimit(ns)
+	assert.NotNil(t, err)
+
+	tx := &types.Transaction{SignedAddr: []common.Address{operator}}
+	ns = NewNative(sink.Bytes(), tx, ns.GetCacheDB(), 10)
+	res, err := SetRouteLimit(ns)
+	assert.Nil(t, err)
+	assert.Equal(t, utils.BYTE_TRUE, res)
+
+	route := &RouteParam{SourceChainID: 2, ToChainID: 6}
+	sink = common.NewZeroCopySink(nil)
+	route.Serialization(sink)
+	ns = NewNative(sink.Bytes(), tx, ns.GetCacheDB(), 10)
+	res, err = GetRouteLimit(ns)
+	assert.Nil(t, err)
+	stored := new(RouteLimit)
+	assert.Nil(t, stored.Deserialization(common.NewZeroCopySource(res)))
+	assert.Equal(t, limit.MaxCount, stored.MaxCount)
+
+	res, err = RemoveRouteLimit(ns)
+	assert.Nil(t, err)
+	assert.Equal(t, utils.BYTE_TRUE, res)
+	res, err = GetRouteLimit(ns)
+	assert.Nil(t, err)
+	assert.Equal(t, utils.BYTE_FALSE, res)
+
+	// a limit without a window is rejected
+	limit.Window = 0
+	sink = common.NewZeroCopySink(nil)
+	limit.Serialization(sink)
+	ns = NewNative(sink.Bytes(), tx, ns.GetCacheDB(), 10)
+	_, err = SetRouteLimit(ns)
+	assert.NotNil(t, err)
+}
+
+func TestCheckRouteLimit(t *testing.T) {
+	asset := []byte{0xaa}
+	ns := NewNative(nil, &types.Transaction{}, nil, 10)
+	putRouteLimit(ns, &RouteLimit{
+		SourceChainID: 2,
+		ToChainID:     6,
+		Window:        10,
+		MaxCount:      3,
+		AssetCaps:     []*AssetCap{{ToAssetHash: asset, MaxAmount: big.NewInt(100)}},
+	})
+
+	assert.Nil(t, checkRouteLimit(ns, 2, unlockTxParam(asset, 60)))
+	// the amount cap is exceeded
+	err := checkRouteLimit(ns, 2, unlockTxParam(asset, 50))
+	assert.True(t, errors.Is(err, ErrRouteLimitExceeded))
+	// other assets and routes are not capped
+	assert.Nil(t, checkRouteLimit(ns, 2, unlockTxParam([]byte{0xbb}, 50)))
+	assert.Nil(t, checkRouteLimit(ns, 3, unlockTxParam(asset, 500)))
+	assert.Nil(t, checkRouteLimit(ns, 2, unlockTxParam(asset, 40)))
+	// the request count is exceeded
+	err = checkRouteLimit(ns, 2, unlockTxParam(asset, 0))
+	assert.True(t, errors.Is(err, ErrRouteLimitExceeded))
+
+	// usage is reset in the next window
+	ns = NewNative(nil, &types.Transaction{}, ns.GetCacheDB(), 20)
+	assert.Nil(t, checkRouteLimit(ns, 2, unlockTxParam(asset, 100)))
+	// an unlock with args which can't be counted against the asset caps is rejected
+	err = checkRouteLimit(ns, 2, &scom.MakeTxParam{ToChainID: 6, Method: scom.LOCK_PROXY_UNLOCK, Args: []byte{1}})
+	assert.NotNil(t, err)
+	assert.False(t, errors.Is(err, ErrRouteLimitExceeded))
+	usage, err := getRouteUsage(ns, 2, 6)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(20), usage.WindowStart)
+	assert.Equal(t, uint64(1), usage.Count)
+}
+
+func TestCrossChainRequestIndex(t *testing.T) {
+	ns := NewNative(nil, &types.Transaction{}, nil, 10)
+	for i := byte(1); i <= 2; i++ {
+		err := scom.PutCrossChainRequest(ns, &scom.CrossChainRequest{
+			FromChainID:  2,
+			ToChainID:    6,
+			SourceTxHash: []byte{i},
+			CrossChainID: []byte{i, i},
+			PolyTxHash:   common.Uint256{i},
+		})
+		assert.Nil(t, err)
+	}
+
+	contract := utils.CrossChainManagerContractAddress
+	first, second := common.Uint256{1}, common.Uint256{2}
+	count, err := utils.GetStorageUInt64(ns, utils.ConcatKey(contract, scom.RequestTargetCountKey(6)))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), count)
+	item, err := utils.GetStorageItem(ns, utils.ConcatKey(contract, scom.RequestTargetKey(6, 1)))
+	assert.Nil(t, err)
+	assert.Equal(t, second[:], item.Value)
+	item, err = utils.GetStorageItem(ns, utils.ConcatKey(contract, scom.RequestSourceKey(2, []byte{1})))
+	assert.Nil(t, err)
+	assert.Equal(t, first[:], item.Value)
+
+	ns = NewNative(nil, &types.Transaction{}, ns.GetCacheDB(), 11)
+	assert.Nil(t, scom.UpdateCrossChainRequestStatus(ns, common.Uint256{1}, scom.REQUEST_MADE_PROOF))
+	request, err := scom.GetCrossChainRequest(ns, common.Uint256{1})
+	assert.Nil(t, err)
+	assert.Equal(t, scom.REQUEST_MADE_PROOF, request.Status)
+	assert.Equal(t, uint32(10), request.ReceivedHeight)
+	assert.Equal(t, uint32(11), request.UpdateHeight)
+	assert.Equal(t, []byte{1, 1}, request.CrossChainID)
+
+	// requests which are not recorded are left alone
+	assert.Nil(t, scom.UpdateCrossChainRequestStatus(ns, common.Uint256{3}, scom.REQUEST_MADE_PROOF))
+	request, err = scom.GetCrossChainRequest(ns, common.Uint256{3})
+	assert.Nil(t, err)
+	assert.Nil(t, request)
+}
+
+func TestCrossChainRequestIndexHeight(t *testing.T) {
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_MAIN_NET
+	defer func() { config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET }()
+
+	ns := NewNative(nil, &types.Transaction{}, nil, 10)
+	err := scom.PutCrossChainRequest(ns, &scom.CrossChainRequest{
+		FromChainID:  2,
+		ToChainID:    6,
+		SourceTxHash: []byte{1},
+		PolyTxHash:   common.Uint256{1},
+	})
+	assert.Nil(t, err)
+	request, err := scom.GetCrossChainRequest(ns, common.Uint256{1})
+	assert.Nil(t, err)
+	assert.Nil(t, request)
+	item, err := utils.GetStorageItem(ns, utils.ConcatKey(utils.CrossChainManagerContractAddress, scom.RequestTargetCountKey(6)))
+	assert.Nil(t, err)
+	assert.Nil(t, item)
+}
+
+const testAckRouter = 1000
+
+// ackHandler accepts the tx param or receipt serialized in the entrance param extra without a proof
+type ackHandler struct{}
+
+func (this *ackHandler) MakeDepositProposal(service *native.NativeService) (*scom.MakeTxParam, error) {
+	params := new(scom.EntranceParam)
+	if err := params.Deserialization(common.NewZeroCopySource(service.GetInput())); err != nil {
+		return nil, err
+	}
+	txParam := new(scom.MakeTxParam)
+	if err := txParam.Deserialization(common.NewZeroCopySource(params.Extra)); err != nil {
+		return nil, err
+	}
+	return txParam, nil
+}
+
+func (this *ackHandler) VerifyExecution(service *native.NativeService) (*scom.ExecutionReceipt, error) {
+	params := new(scom.EntranceParam)
+	if err := params.Deserialization(common.NewZeroCopySource(service.GetInput())); err != nil {
+		return nil, err
+	}
+	receipt := new(scom.ExecutionReceipt)
+	if err := receipt.Deserialization(common.NewZeroCopySource(params.Extra)); err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+const testDepositRouter = 1001
+
+// depositHandler is ackHandler without acknowledgements, it marks the source tx done like the
+// handlers of the real routers
+type depositHandler struct{}
+
+func (this *depositHandler) MakeDepositProposal(service *native.NativeService) (*scom.MakeTxParam, error) {
+	txParam, err := new(ackHandler).MakeDepositProposal(service)
+	if err != nil {
+		return nil, err
+	}
+	params := new(scom.EntranceParam)
+	if err := params.Deserialization(common.NewZeroCopySource(service.GetInput())); err != nil {
+		return nil, err
+	}
+	if err := scom.CheckDoneTx(service, txParam.CrossChainID, params.SourceChainID); err != nil {
+		return nil, err
+	}
+	if err := scom.PutDoneTx(service, txParam.CrossChainID, params.SourceChainID); err != nil {
+		return nil, err
+	}
+	return txParam, nil
+}
+
+func init() {
+	// features scheduled by height are active from genesis on solo net
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	router.Register(&router.Router{Name: "test_ack", ID: testAckRouter, ChainHandler: &ackHandler{}})
+	router.Register(&router.Router{Name: "test_deposit", ID: testDepositRouter, ChainHandler: &depositHandler{}})
+}
+
+func ackInput(receipt *scom.ExecutionReceipt) []byte {
+	sink := common.NewZeroCopySink(nil)
+	receipt.Serialization(sink)
+	params := &scom.EntranceParam{SourceChainID: 6, Extra: sink.Bytes()}
+	sink = common.NewZeroCopySink(nil)
+	params.Serialization(sink)
+	return sink.Bytes()
+}
+
+func TestAckExecution(t *testing.T) {
+	ns := NewNative(nil, &types.Transaction{}, nil, 10)
+	assert.Nil(t, side_chain_manager.PutSideChain(ns, &side_chain_manager.SideChain{ChainId: 6, Router: testAckRouter}))
+	polyTxHash := common.Uint256{1}
+	assert.Nil(t, scom.PutCrossChainRequest(ns, &scom.CrossChainRequest{
+		FromChainID:  2,
+		ToChainID:    6,
+		SourceTxHash: []byte{1},
+		CrossChainID: []byte{1, 1},
+		PolyTxHash:   polyTxHash,
+	}))
+
+	receipt := &scom.ExecutionReceipt{PolyTxHash: polyTxHash[:], FromChainID: 2, CrossChainID: []byte{1, 1}, Success: true}
+	// the proof has not been made yet
+	ns = NewNative(ackInput(receipt), &types.Transaction{}, ns.GetCacheDB(), 11)
+	_, err := AckExecution(ns)
+	assert.NotNil(t, err)
+
+	assert.Nil(t, scom.UpdateCrossChainRequestStatus(ns, polyTxHash, scom.REQUEST_MADE_PROOF))
+	// the receipt must match the request
+	mismatched := &scom.ExecutionReceipt{PolyTxHash: polyTxHash[:], FromChainID: 3, CrossChainID: []byte{1, 1}, Success: true}
+	ns = NewNative(ackInput(mismatched), &types.Transaction{}, ns.GetCacheDB(), 11)
+	_, err = AckExecution(ns)
+	assert.NotNil(t, err)
+
+	ns = NewNative(ackInput(receipt), &types.Transaction{}, ns.GetCacheDB(), 11)
+	res, err := AckExecution(ns)
+	assert.Nil(t, err)
+	assert.Equal(t, utils.BYTE_TRUE, res)
+	request, err := scom.GetCrossChainRequest(ns, polyTxHash)
+	assert.Nil(t, err)
+	assert.Equal(t, scom.REQUEST_DELIVERED, request.Status)
+
+	// a request is acknowledged once
+	_, err = AckExecution(ns)
+	assert.NotNil(t, err)
+}
+
+func TestImportPinHeader(t *testing.T) {
+	ns := NewNative(nil, &types.Transaction{}, nil, 10)
+	for chainID, id := range map[uint64]uint64{2: testAckRouter, 6: testAckRouter, 7: testDepositRouter} {
+		assert.Nil(t, side_chain_manager.PutSideChain(ns, &side_chain_manager.SideChain{ChainId: chainID, Router: id}))
+	}
+	hscommon.PutHeaderRetention(ns, &hscommon.HeaderRetention{ChainID: 2, Retain: 10})
+
+	cases := []struct {
+		toChainID     uint64
+		timeoutHeight uint32
+		pinned        bool
+	}{
+		{6, 0, true},
+		// a request to a router without acknowledgements is only finished by its timeout
+		{7, 0, false},
+		{7, 100, true},
+	}
+	for i, c := range cases {
+		txParam := &scom.MakeTxParam{TxHash: []byte{byte(i)}, CrossChainID: []byte{byte(i)}, ToChainID: c.toChainID, Method: "unlock",
+			TimeoutHeight: c.timeoutHeight}
+		sink := common.NewZeroCopySink(nil)
+		txParam.Serialization(sink)
+		height := uint32(100 + i)
+		params := &scom.EntranceParam{SourceChainID: 2, Height: height, Extra: sink.Bytes()}
+		sink = common.NewZeroCopySink(nil)
+		params.Serialization(sink)
+		ns = NewNative(sink.Bytes(), &types.Transaction{}, ns.GetCacheDB(), 10)
+		res, err := importExTransfer(ns, common.Uint256{byte(i + 1)})
+		assert.Nil(t, err)
+		assert.Equal(t, utils.BYTE_TRUE, res)
+
+		pins, err := hscommon.GetHeaderPins(ns, 2, uint64(height))
+		assert.Nil(t, err)
+		assert.Equal(t, c.pinned, pins == 1, "request to chain %d with timeout %d", c.toChainID, c.timeoutHeight)
+	}
+}
+
+func TestImportRejectedCall(t *testing.T) {
+	ns := NewNative(nil, &types.Transaction{}, nil, 10)
+	assert.Nil(t, side_chain_manager.PutSideChain(ns, &side_chain_manager.SideChain{ChainId: 2, Router: testDepositRouter}))
+	assert.Nil(t, side_chain_manager.PutSideChain(ns, &side_chain_manager.SideChain{ChainId: 6, Router: testAckRouter,
+		DeniedCalls: []*side_chain_manager.TargetCall{{Contract: []byte{1}}}}))
+	txParam := &scom.MakeTxParam{TxHash: []byte{1}, CrossChainID: []byte{1}, ToChainID: 6, ToContractAddress: []byte{1},
+		Method: "unlock"}
+	sink := common.NewZeroCopySink(nil)
+	txParam.Serialization(sink)
+	params := &scom.EntranceParam{SourceChainID: 2, Height: 1, Extra: sink.Bytes()}
+	sink = common.NewZeroCopySink(nil)
+	params.Serialization(sink)
+
+	// the rejected call is notified and its source tx is not marked done
+	ns = NewNative(sink.Bytes(), &types.Transaction{}, ns.GetCacheDB(), 10)
+	res, err := importExTransfer(ns, common.Uint256{1})
+	assert.Nil(t, err)
+	assert.Equal(t, utils.BYTE_FALSE, res)
+	assert.Equal(t, 1, len(ns.GetNotify()))
+	assert.Equal(t, scom.NOTIFY_REJECT_CALL, ns.GetNotify()[0].States.([]interface{})[0])
+	assert.Nil(t, scom.CheckDoneTx(ns, txParam.CrossChainID, 2))
+
+	// so it is imported once the call is allowed
+	assert.Nil(t, side_chain_manager.PutSideChain(ns, &side_chain_manager.SideChain{ChainId: 6, Router: testAckRouter}))
+	ns = NewNative(sink.Bytes(), &types.Transaction{}, ns.GetCacheDB(), 10)
+	res, err = importExTransfer(ns, common.Uint256{1})
+	assert.Nil(t, err)
+	assert.Equal(t, utils.BYTE_TRUE, res)
+	assert.NotNil(t, scom.CheckDoneTx(ns, txParam.CrossChainID, 2))
+}
+
+func TestExecutionReceiptTag(t *testing.T) {
+	receipt := &scom.ExecutionReceipt{PolyTxHash: []byte{1}, FromChainID: 2, CrossChainID: []byte{1, 1}, Success: true}
+	sink := common.NewZeroCopySink(nil)
+	receipt.Serialization(sink)
+	decoded := new(scom.ExecutionReceipt)
+	assert.Nil(t, decoded.Deserialization(common.NewZeroCopySource(sink.Bytes())))
+	assert.Equal(t, receipt, decoded)
+
+	// the same fields without the tag, as a CCMC could store them for a cross chain tx, are no receipt
+	sink = common.NewZeroCopySink(nil)
+	sink.WriteVarBytes(receipt.PolyTxHash)
+	sink.WriteVarUint(receipt.FromChainID)
+	sink.WriteVarBytes(receipt.CrossChainID)
+	sink.WriteBool(receipt.Success)
+	assert.NotNil(t, decoded.Deserialization(common.NewZeroCopySource(sink.Bytes())))
+
+	param := &scom.MakeTxParam{TxHash: []byte{1}, CrossChainID: []byte{1, 1}, ToChainID: 2, Method: "unlock"}
+	sink = common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	assert.NotNil(t, decoded.Deserialization(common.NewZeroCopySource(sink.Bytes())))
+}
+
+func TestMakeTxParamTimeout(t *testing.T) {
+	param := &scom.MakeTxParam{
+		TxHash:              []byte{1},
+		CrossChainID:        []byte{2},
+		FromContractAddress: []byte{3},
+		ToChainID:           6,
+		ToContractAddress:   []byte{4},
+		Method:              "unlock",
+		Args:                []byte{5},
+	}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	legacy := sink.Bytes()
+
+	param.TimeoutHeight = 100
+	sink = common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	// the timeout height is appended to the legacy encoding
+	assert.Equal(t, legacy, sink.Bytes()[:len(legacy)])
+
+	decoded := new(scom.MakeTxParam)
+	assert.Nil(t, decoded.Deserialization(common.NewZeroCopySource(sink.Bytes())))
+	assert.Equal(t, param, decoded)
+	decoded = new(scom.MakeTxParam)
+	assert.Nil(t, decoded.Deserialization(common.NewZeroCopySource(legacy)))
+	assert.Equal(t, uint32(0), decoded.TimeoutHeight)
+
+	// the target chain verifies the timeout height with the merkle value
+	merkleValue := &scom.ToMerkleValue{TxHash: []byte{6}, FromChainID: 2, MakeTxParam: param}
+	sink = common.NewZeroCopySink(nil)
+	merkleValue.Serialization(sink)
+	decodedValue := new(scom.ToMerkleValue)
+	assert.Nil(t, decodedValue.Deserialization(common.NewZeroCopySource(sink.Bytes())))
+	assert.Equal(t, uint32(100), decodedValue.MakeTxParam.TimeoutHeight)
+}
+
+func TestImportTimeout(t *testing.T) {
+	ns := NewNative(nil, &types.Transaction{}, nil, 10)
+	for _, chainID := range []uint64{2, 6} {
+		assert.Nil(t, side_chain_manager.PutSideChain(ns, &side_chain_manager.SideChain{ChainId: chainID, Router: testAckRouter}))
+	}
+	for i, timeoutHeight := range []uint32{0, 30, 5} {
+		txParam := &scom.MakeTxParam{TxHash: []byte{byte(i)}, CrossChainID: []byte{byte(i)}, ToChainID: 6, Method: "unlock",
+			TimeoutHeight: timeoutHeight}
+		sink := common.NewZeroCopySink(nil)
+		txParam.Serialization(sink)
+		params := &scom.EntranceParam{SourceChainID: 2, Height: 1, Extra: sink.Bytes()}
+		sink = common.NewZeroCopySink(nil)
+		params.Serialization(sink)
+		polyTxHash := common.Uint256{byte(i + 1)}
+		ns = NewNative(sink.Bytes(), &types.Transaction{}, ns.GetCacheDB(), 10)
+		res, err := importExTransfer(ns, polyTxHash)
+		assert.Nil(t, err)
+		assert.Equal(t, utils.BYTE_TRUE, res)
+
+		// the timeout is the one of the proven tx
+		request, err := scom.GetCrossChainRequest(ns, polyTxHash)
+		assert.Nil(t, err)
+		assert.Equal(t, timeoutHeight, request.TimeoutHeight)
+		if timeoutHeight == 5 {
+			assert.Equal(t, scom.REQUEST_UNREACHABLE, request.Status)
+			continue
+		}
+		assert.Equal(t, scom.REQUEST_MADE_PROOF, request.Status)
+		item, err := utils.GetStorageItem(ns, utils.ConcatKey(utils.CrossChainManagerContractAddress, []byte(scom.REQUEST),
+			utils.GetUint64Bytes(6), polyTxHash[:]))
+		assert.Nil(t, err)
+		merkleValue := new(scom.ToMerkleValue)
+		assert.Nil(t, merkleValue.Deserialization(common.NewZeroCopySource(item.Value)))
+		assert.Equal(t, timeoutHeight, merkleValue.MakeTxParam.TimeoutHeight)
+	}
+}
+
+func TestTimeoutRequest(t *testing.T) {
+	ns := NewNative(nil, &types.Transaction{}, nil, 10)
+	polyTxHash := common.Uint256{1}
+	assert.Nil(t, scom.PutCrossChainRequest(ns, &scom.CrossChainRequest{
+		FromChainID:   2,
+		ToChainID:     6,
+		SourceTxHash:  []byte{1},
+		CrossChainID:  []byte{1, 1},
+		PolyTxHash:    polyTxHash,
+		TimeoutHeight: 20,
+	}))
+	assert.Nil(t, scom.UpdateCrossChainRequestStatus(ns, polyTxHash, scom.REQUEST_MADE_PROOF))
+
+	param := &TimeoutRequestParam{PolyTxHash: polyTxHash[:]}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	ns = NewNative(sink.Bytes(), &types.Transaction{}, ns.GetCacheDB(), 20)
+	_, err := TimeoutRequest(ns)
+	assert.NotNil(t, err)
+
+	ns = NewNative(sink.Bytes(), &types.Transaction{}, ns.GetCacheDB(), 21)
+	res, err := TimeoutRequest(ns)
+	assert.Nil(t, err)
+	assert.Equal(t, utils.BYTE_TRUE, res)
+	request, err := scom.GetCrossChainRequest(ns, polyTxHash)
+	assert.Nil(t, err)
+	assert.Equal(t, scom.REQUEST_UNREACHABLE, request.Status)
+
+	key := utils.ConcatKey(utils.CrossChainManagerContractAddress, []byte(scom.TIMEOUT), utils.GetUint64Bytes(2), polyTxHash[:])
+	item, err := utils.GetStorageItem(ns, key)
+	assert.Nil(t, err)
+	value := new(scom.TimeoutMerkleValue)
+	assert.Nil(t, value.Deserialization(common.NewZeroCopySource(item.Value)))
+	assert.Equal(t, uint64(6), value.ToChainID)
+	assert.Equal(t, []byte{1, 1}, value.CrossChainID)
+	// a request can't be read as a timeout proof
+	merkleValue := &scom.ToMerkleValue{TxHash: polyTxHash[:], FromChainID: 2, MakeTxParam: &scom.MakeTxParam{ToChainID: 6}}
+	sink = common.NewZeroCopySink(nil)
+	merkleValue.Serialization(sink)
+	assert.NotNil(t, new(scom.TimeoutMerkleValue).Deserialization(common.NewZeroCopySource(sink.Bytes())))
+
+	// a late delivery can't complete an unreachable request
+	receipt := &scom.ExecutionReceipt{PolyTxHash: polyTxHash[:], FromChainID: 2, CrossChainID: []byte{1, 1}, Success: true}
+	assert.Nil(t, side_chain_manager.PutSideChain(ns, &side_chain_manager.SideChain{ChainId: 6, Router: testAckRouter}))
+	ns = NewNative(ackInput(receipt), &types.Transaction{}, ns.GetCacheDB(), 22)
+	_, err = AckExecution(ns)
+	assert.NotNil(t, err)
+
+	sink = common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	_, err = TimeoutRequest(NewNative(sink.Bytes(), &types.Transaction{}, ns.GetCacheDB(), 22))
+	assert.NotNil(t, err)
+}
+
+func TestBatchImportExTransfer(t *testing.T) {
+	ns := NewNative(nil, &types.Transaction{}, nil, 10)
+	for _, chainID := range []uint64{2, 6} {
+		assert.Nil(t, side_chain_manager.PutSideChain(ns, &side_chain_manager.SideChain{ChainId: chainID, Router: testAckRouter}))
+	}
+
+	batch := new(scom.BatchEntranceParam)
+	for i := byte(0); i < 3; i++ {
+		txParam := &scom.MakeTxParam{TxHash: []byte{i}, CrossChainID: []byte{i}, ToChainID: 6, Method: "unlock"}
+		sink := common.NewZeroCopySink(nil)
+		txParam.Serialization(sink)
+		extra := sink.Bytes()
+		if i == 1 {
+			extra = extra[:3]
+		}
+		batch.Params = append(batch.Params, &scom.EntranceParam{SourceChainID: 2, Extra: extra})
+	}
+	sink := common.NewZeroCopySink(nil)
+	batch.Serialization(sink)
+	tx := &types.Transaction{}
+	ns = NewNative(sink.Bytes(), tx, ns.GetCacheDB(), 10)
+	res, err := BatchImportExTransfer(ns)
+	assert.Nil(t, err)
+
+	results := new(BatchImportResults)
+	assert.Nil(t, results.Deserialization(common.NewZeroCopySource(res)))
+	assert.Equal(t, 3, len(results.Results))
+	assert.True(t, results.Results[0].Success)
+	assert.False(t, results.Results[1].Success)
+	assert.NotEqual(t, "", results.Results[1].Reason)
+	assert.True(t, results.Results[2].Success)
+	// a makeProof event for every request made and a result event for every param
+	assert.Equal(t, 5, len(ns.GetNotify()))
+
+	// every request is made under its own hash
+	txHash := tx.Hash()
+	for i, hash := range []common.Uint256{txHash, sha256.Sum256(append(txHash.ToArray(), utils.GetUint32Bytes(2)...))} {
+		request, err := scom.GetCrossChainRequest(ns, hash)
+		assert.Nil(t, err)
+		assert.Equal(t, scom.REQUEST_MADE_PROOF, request.Status)
+		assert.Equal(t, []byte{byte(i * 2)}, request.SourceTxHash)
+	}
+	// the failed param left nothing behind
+	item, err := utils.GetStorageItem(ns, utils.ConcatKey(utils.CrossChainManagerContractAddress, scom.RequestSourceKey(2, []byte{1})))
+	assert.Nil(t, err)
+	assert.Nil(t, item)
+}
@@ -54,3 +54,21 @@ func (this *ETHHandler) MakeDepositProposal(service *native.NativeService) (*sco
 	}
 	return value, nil
 }
+
+func (this *ETHHandler) VerifyExecution(service *native.NativeService) (*scom.ExecutionReceipt, error) {
+	params := new(scom.EntranceParam)
+	if err := params.Deserialization(common.NewZeroCopySource(service.GetInput())); err != nil {
+		return nil, fmt.Errorf("eth VerifyExecution, contract params deserialize error: %s", err)
+	}
+
+	sideChain, err := side_chain_manager.GetSideChain(service, params.SourceChainID)
+	if err != nil {
+		return nil, fmt.Errorf("eth VerifyExecution, side_chain_manager.GetSideChain error: %v", err)
+	}
+
+	receipt, err := verifyEthReceipt(service, params.Proof, params.Extra, params.SourceChainID, params.Height, sideChain)
+	if err != nil {
+		return nil, fmt.Errorf("eth VerifyExecution, verifyEthReceipt error: %s", err)
+	}
+	return receipt, nil
+}
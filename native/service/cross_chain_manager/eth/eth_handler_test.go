@@ -197,7 +197,7 @@ func TestProofHandle(t *testing.T) {
 		param.Serialization(sink)
 
 		native = NewNative(sink.Bytes(), &types.Transaction{ChainID: 0}, native.GetCacheDB())
-		err := ethSyncHandler.SyncBlockHeader(native)
+		_, err := ethSyncHandler.SyncBlockHeader(native)
 		assert.Equal(t, SUCCESS, typeOfError(err))
 		height := getLatestHeight(native)
 		assert.Equal(t, uint64(7259465), height)
@@ -264,7 +264,7 @@ func TestProofHandle_HeaderNotConfirmed(t *testing.T) {
 		param.Serialization(sink)
 
 		native = NewNative(sink.Bytes(), &types.Transaction{}, native.GetCacheDB())
-		err := ethSyncHandler.SyncBlockHeader(native)
+		_, err := ethSyncHandler.SyncBlockHeader(native)
 		assert.Equal(t, SUCCESS, typeOfError(err))
 		height := getLatestHeight(native)
 		assert.Equal(t, uint64(7259465), height)
@@ -329,7 +329,7 @@ func TestProofHandle1(t *testing.T) {
 		param.Serialization(sink)
 
 		native = NewNative(sink.Bytes(), &types.Transaction{}, native.GetCacheDB())
-		err := ethSyncHandler.SyncBlockHeader(native)
+		_, err := ethSyncHandler.SyncBlockHeader(native)
 		assert.Equal(t, SUCCESS, typeOfError(err))
 		height := getLatestHeight(native)
 		assert.Equal(t, uint64(7272744), height)
@@ -393,7 +393,7 @@ func TestProofHandle2(t *testing.T) {
 		param.Serialization(sink)
 
 		native = NewNative(sink.Bytes(), &types.Transaction{}, native.GetCacheDB())
-		err := ethSyncHandler.SyncBlockHeader(native)
+		_, err := ethSyncHandler.SyncBlockHeader(native)
 		assert.Equal(t, SUCCESS, typeOfError(err))
 		height := getLatestHeight(native)
 		assert.Equal(t, uint64(7272837), height)
@@ -455,7 +455,7 @@ func TestProofHandle_HeaderNotExist(t *testing.T) {
 		param.Serialization(sink)
 
 		native = NewNative(sink.Bytes(), &types.Transaction{}, native.GetCacheDB())
-		err := ethSyncHandler.SyncBlockHeader(native)
+		_, err := ethSyncHandler.SyncBlockHeader(native)
 		assert.Equal(t, SUCCESS, typeOfError(err))
 		height := getLatestHeight(native)
 		assert.Equal(t, uint64(7259463), height)
@@ -521,7 +521,7 @@ func TestProofHandle_ProofFormatWrong(t *testing.T) {
 		param.Serialization(sink)
 
 		native = NewNative(sink.Bytes(), &types.Transaction{}, native.GetCacheDB())
-		err := ethSyncHandler.SyncBlockHeader(native)
+		_, err := ethSyncHandler.SyncBlockHeader(native)
 		assert.Equal(t, SUCCESS, typeOfError(err))
 		height := getLatestHeight(native)
 		assert.Equal(t, uint64(7259465), height)
@@ -586,7 +586,7 @@ func TestProofHandle_VerifyProofWrong(t *testing.T) {
 		param.Serialization(sink)
 
 		native = NewNative(sink.Bytes(), &types.Transaction{}, native.GetCacheDB())
-		err := ethSyncHandler.SyncBlockHeader(native)
+		_, err := ethSyncHandler.SyncBlockHeader(native)
 		assert.Equal(t, SUCCESS, typeOfError(err))
 		height := getLatestHeight(native)
 		assert.Equal(t, uint64(7259465), height)
@@ -653,7 +653,7 @@ func TestProofHandle_CommitTwice(t *testing.T) {
 		param.Serialization(sink)
 
 		native = NewNative(sink.Bytes(), &types.Transaction{}, native.GetCacheDB())
-		err := ethSyncHandler.SyncBlockHeader(native)
+		_, err := ethSyncHandler.SyncBlockHeader(native)
 		assert.Equal(t, SUCCESS, typeOfError(err))
 		height := getLatestHeight(native)
 		assert.Equal(t, uint64(7259465), height)
@@ -736,7 +736,7 @@ func TestProofHandle_ZEROPrefix(t *testing.T) {
 		param.Serialization(sink)
 
 		native = NewNative(sink.Bytes(), &types.Transaction{}, native.GetCacheDB())
-		err := ethSyncHandler.SyncBlockHeader(native)
+		_, err := ethSyncHandler.SyncBlockHeader(native)
 		assert.Equal(t, SUCCESS, typeOfError(err))
 		height := getLatestHeight(native)
 		assert.Equal(t, uint64(7967936), height)
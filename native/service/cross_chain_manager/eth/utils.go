@@ -39,50 +39,77 @@ import (
 )
 
 func verifyFromEthTx(native *native.NativeService, proof, extra []byte, fromChainID uint64, height uint32, sideChain *cmanager.SideChain) (*scom.MakeTxParam, error) {
+	if err := verifyEthStorage(native, proof, extra, fromChainID, height, sideChain); err != nil {
+		return nil, err
+	}
+
+	data := common.NewZeroCopySource(extra)
+	txParam := new(scom.MakeTxParam)
+	if err := txParam.Deserialization(data); err != nil {
+		return nil, fmt.Errorf("VerifyFromEthProof, deserialize merkleValue error:%s", err)
+	}
+	return txParam, nil
+}
+
+func verifyEthReceipt(native *native.NativeService, proof, extra []byte, fromChainID uint64, height uint32, sideChain *cmanager.SideChain) (*scom.ExecutionReceipt, error) {
+	if err := verifyEthStorage(native, proof, extra, fromChainID, height, sideChain); err != nil {
+		return nil, err
+	}
+
+	data := common.NewZeroCopySource(extra)
+	receipt := new(scom.ExecutionReceipt)
+	if err := receipt.Deserialization(data); err != nil {
+		return nil, fmt.Errorf("VerifyFromEthProof, deserialize receipt error:%s", err)
+	}
+	return receipt, nil
+}
+
+// verifyEthStorage checks that the keccak hash of extra is stored by the cross chain manager contract at height
+func verifyEthStorage(native *native.NativeService, proof, extra []byte, fromChainID uint64, height uint32, sideChain *cmanager.SideChain) error {
 	bestHeader, _, err := eth.GetCurrentHeader(native, fromChainID)
 	if err != nil {
-		return nil, fmt.Errorf("VerifyFromEthProof, get current header fail, error:%s", err)
+		return fmt.Errorf("VerifyFromEthProof, get current header fail, error:%s", err)
 	}
 	bestHeight := uint32(bestHeader.Number.Uint64())
 	if bestHeight < height || bestHeight-height < uint32(sideChain.BlocksToWait-1) {
-		return nil, fmt.Errorf("VerifyFromEthProof, transaction is not confirmed, current height: %d, input height: %d", bestHeight, height)
+		return fmt.Errorf("VerifyFromEthProof, transaction is not confirmed, current height: %d, input height: %d", bestHeight, height)
 	}
 
 	blockData, _, err := eth.GetHeaderByHeight(native, uint64(height), fromChainID)
 	if err != nil {
-		return nil, fmt.Errorf("VerifyFromEthProof, get header by height, height:%d, error:%s", height, err)
+		return fmt.Errorf("VerifyFromEthProof, get header by height, height:%d, error:%s", height, err)
 	}
 
+	return VerifyStorage(proof, extra, blockData.Root, sideChain.CCMCAddress)
+}
+
+// VerifyStorage checks the storage proof of the keccak hash of extra, kept by the
+// cross chain manager contract ccmc, against the state root of a verified header
+func VerifyStorage(proof, extra []byte, root ecom.Hash, ccmc []byte) error {
 	ethProof := new(ETHProof)
-	err = json.Unmarshal(proof, ethProof)
+	err := json.Unmarshal(proof, ethProof)
 	if err != nil {
-		return nil, fmt.Errorf("VerifyFromEthProof, unmarshal proof error:%s", err)
+		return fmt.Errorf("VerifyFromEthProof, unmarshal proof error:%s", err)
 	}
 
 	if len(ethProof.StorageProofs) != 1 {
-		return nil, fmt.Errorf("VerifyFromEthProof, incorrect proof format")
+		return fmt.Errorf("VerifyFromEthProof, incorrect proof format")
 	}
 
 	//todo 1. verify the proof with header
 	//determine where the k and v from
-	proofResult, err := VerifyMerkleProof(ethProof, blockData, sideChain.CCMCAddress)
+	proofResult, err := VerifyMerkleProofWithRoot(ethProof, root, ccmc)
 	if err != nil {
-		return nil, fmt.Errorf("VerifyFromEthProof, verifyMerkleProof error:%v", err)
+		return fmt.Errorf("VerifyFromEthProof, verifyMerkleProof error:%v", err)
 	}
 	if proofResult == nil {
-		return nil, fmt.Errorf("VerifyFromEthProof, verifyMerkleProof failed!")
+		return fmt.Errorf("VerifyFromEthProof, verifyMerkleProof failed!")
 	}
 
 	if !CheckProofResult(proofResult, extra) {
-		return nil, fmt.Errorf("VerifyFromEthProof, verify proof value hash failed, proof result:%x, extra:%x", proofResult, extra)
-	}
-
-	data := common.NewZeroCopySource(extra)
-	txParam := new(scom.MakeTxParam)
-	if err := txParam.Deserialization(data); err != nil {
-		return nil, fmt.Errorf("VerifyFromEthProof, deserialize merkleValue error:%s", err)
+		return fmt.Errorf("VerifyFromEthProof, verify proof value hash failed, proof result:%x, extra:%x", proofResult, extra)
 	}
-	return txParam, nil
+	return nil
 }
 
 // used by quorum
@@ -91,6 +118,27 @@ func VerifyMerkleProofLegacy(ethProof *ETHProof, blockData *types.Header, contra
 }
 
 func VerifyMerkleProof(ethProof *ETHProof, blockData *eth.Header, contractAddr []byte) ([]byte, error) {
+	return VerifyMerkleProofWithRoot(ethProof, blockData.Root, contractAddr)
+}
+
+// VerifyMerkleProofWithRoot verifies ethProof against a state root, used by the
+// routers that track state roots rather than full headers
+func VerifyMerkleProofWithRoot(ethProof *ETHProof, root ecom.Hash, contractAddr []byte) ([]byte, error) {
+	storageHash, err := VerifyAccountProof(ethProof, root, contractAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	//3.verify storage proof
+	if len(ethProof.StorageProofs) != 1 {
+		return nil, fmt.Errorf("verifyMerkleProof, invalid storage proof format")
+	}
+	return VerifyStorageProof(storageHash, &ethProof.StorageProofs[0])
+}
+
+// VerifyAccountProof verifies the account proof of contractAddr against a state root
+// and returns the storage root of the account
+func VerifyAccountProof(ethProof *ETHProof, root ecom.Hash, contractAddr []byte) (ecom.Hash, error) {
 	//1. prepare verify account
 	nodeList := new(light.NodeList)
 
@@ -102,26 +150,26 @@ func VerifyMerkleProof(ethProof *ETHProof, blockData *eth.Header, contractAddr [
 
 	addr := ecom.Hex2Bytes(scom.Replace0x(ethProof.Address))
 	if !bytes.Equal(addr, contractAddr) {
-		return nil, fmt.Errorf("verifyMerkleProof, contract address is error, proof address: %s, side chain address: %s", ethProof.Address, hex.EncodeToString(contractAddr))
+		return ecom.Hash{}, fmt.Errorf("verifyMerkleProof, contract address is error, proof address: %s, side chain address: %s", ethProof.Address, hex.EncodeToString(contractAddr))
 	}
 	acctKey := crypto.Keccak256(addr)
 
 	// 2. verify account proof
-	acctVal, err := trie.VerifyProof(blockData.Root, acctKey, ns)
+	acctVal, err := trie.VerifyProof(root, acctKey, ns)
 	if err != nil {
-		return nil, fmt.Errorf("verifyMerkleProof, verify account proof error:%s\n", err)
+		return ecom.Hash{}, fmt.Errorf("verifyMerkleProof, verify account proof error:%s\n", err)
 	}
 
 	nounce := new(big.Int)
 	_, ok := nounce.SetString(scom.Replace0x(ethProof.Nonce), 16)
 	if !ok {
-		return nil, fmt.Errorf("verifyMerkleProof, invalid format of nounce:%s\n", ethProof.Nonce)
+		return ecom.Hash{}, fmt.Errorf("verifyMerkleProof, invalid format of nounce:%s\n", ethProof.Nonce)
 	}
 
 	balance := new(big.Int)
 	_, ok = balance.SetString(scom.Replace0x(ethProof.Balance), 16)
 	if !ok {
-		return nil, fmt.Errorf("verifyMerkleProof, invalid format of balance:%s\n", ethProof.Balance)
+		return ecom.Hash{}, fmt.Errorf("verifyMerkleProof, invalid format of balance:%s\n", ethProof.Balance)
 	}
 
 	storageHash := ecom.HexToHash(scom.Replace0x(ethProof.StorageHash))
@@ -136,27 +184,26 @@ func VerifyMerkleProof(ethProof *ETHProof, blockData *eth.Header, contractAddr [
 
 	acctrlp, err := rlp.EncodeToBytes(acct)
 	if err != nil {
-		return nil, err
+		return ecom.Hash{}, err
 	}
 
 	if !bytes.Equal(acctrlp, acctVal) {
-		return nil, fmt.Errorf("verifyMerkleProof, verify account proof failed, wanted:%v, get:%v", acctrlp, acctVal)
-	}
-
-	//3.verify storage proof
-	nodeList = new(light.NodeList)
-	if len(ethProof.StorageProofs) != 1 {
-		return nil, fmt.Errorf("verifyMerkleProof, invalid storage proof format")
+		return ecom.Hash{}, fmt.Errorf("verifyMerkleProof, verify account proof failed, wanted:%v, get:%v", acctrlp, acctVal)
 	}
+	return storageHash, nil
+}
 
-	sp := ethProof.StorageProofs[0]
+// VerifyStorageProof verifies a single storage slot proof against the storage root of
+// an account and returns the rlp encoded slot value
+func VerifyStorageProof(storageHash ecom.Hash, sp *StorageProof) ([]byte, error) {
+	nodeList := new(light.NodeList)
 	storageKey := crypto.Keccak256(ecom.HexToHash(scom.Replace0x(sp.Key)).Bytes())
 
 	for _, prf := range sp.Proof {
 		nodeList.Put(nil, ecom.Hex2Bytes(scom.Replace0x(prf)))
 	}
 
-	ns = nodeList.NodeSet()
+	ns := nodeList.NodeSet()
 	val, err := trie.VerifyProof(storageHash, storageKey, ns)
 	if err != nil {
 		return nil, fmt.Errorf("verifyMerkleProof, verify storage proof error:%s\n", err)
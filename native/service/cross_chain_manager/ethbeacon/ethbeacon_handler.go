@@ -0,0 +1,103 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ethbeacon
+
+import (
+	"fmt"
+
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/native"
+	scom "github.com/polynetwork/poly/native/service/cross_chain_manager/common"
+	"github.com/polynetwork/poly/native/service/cross_chain_manager/eth"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	hs "github.com/polynetwork/poly/native/service/header_sync/ethbeacon"
+)
+
+// Handler verifies eth storage proofs against the state roots of the
+// finalized execution headers kept by the beacon light client
+type Handler struct {
+}
+
+// NewHandler ...
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// MakeDepositProposal ...
+func (h *Handler) MakeDepositProposal(service *native.NativeService) (*scom.MakeTxParam, error) {
+	params := new(scom.EntranceParam)
+	if err := params.Deserialization(common.NewZeroCopySource(service.GetInput())); err != nil {
+		return nil, fmt.Errorf("ethbeacon MakeDepositProposal, contract params deserialize error: %s", err)
+	}
+
+	sideChain, err := side_chain_manager.GetSideChain(service, params.SourceChainID)
+	if err != nil {
+		return nil, fmt.Errorf("ethbeacon MakeDepositProposal, side_chain_manager.GetSideChain error: %v", err)
+	}
+
+	if err := verifyStorage(service, params, sideChain); err != nil {
+		return nil, fmt.Errorf("ethbeacon MakeDepositProposal, %v", err)
+	}
+	value := new(scom.MakeTxParam)
+	if err := value.Deserialization(common.NewZeroCopySource(params.Extra)); err != nil {
+		return nil, fmt.Errorf("ethbeacon MakeDepositProposal, deserialize merkleValue error:%s", err)
+	}
+
+	if err := scom.CheckDoneTx(service, value.CrossChainID, params.SourceChainID); err != nil {
+		return nil, fmt.Errorf("ethbeacon MakeDepositProposal, check done transaction error:%s", err)
+	}
+	if err := scom.PutDoneTx(service, value.CrossChainID, params.SourceChainID); err != nil {
+		return nil, fmt.Errorf("ethbeacon MakeDepositProposal, PutDoneTx error:%s", err)
+	}
+	return value, nil
+}
+
+// VerifyExecution checks the storage proof of the execution receipt kept by the CCMC of the target chain
+func (h *Handler) VerifyExecution(service *native.NativeService) (*scom.ExecutionReceipt, error) {
+	params := new(scom.EntranceParam)
+	if err := params.Deserialization(common.NewZeroCopySource(service.GetInput())); err != nil {
+		return nil, fmt.Errorf("ethbeacon VerifyExecution, contract params deserialize error: %s", err)
+	}
+
+	sideChain, err := side_chain_manager.GetSideChain(service, params.SourceChainID)
+	if err != nil {
+		return nil, fmt.Errorf("ethbeacon VerifyExecution, side_chain_manager.GetSideChain error: %v", err)
+	}
+
+	if err := verifyStorage(service, params, sideChain); err != nil {
+		return nil, fmt.Errorf("ethbeacon VerifyExecution, %v", err)
+	}
+	receipt := new(scom.ExecutionReceipt)
+	if err := receipt.Deserialization(common.NewZeroCopySource(params.Extra)); err != nil {
+		return nil, fmt.Errorf("ethbeacon VerifyExecution, deserialize receipt error:%s", err)
+	}
+	return receipt, nil
+}
+
+// verifyStorage checks the proof against the finalized execution header at
+// params.Height, finality makes BlocksToWait unnecessary
+func verifyStorage(service *native.NativeService, params *scom.EntranceParam, sideChain *side_chain_manager.SideChain) error {
+	header, err := hs.GetExecutionHeader(service, params.SourceChainID, uint64(params.Height))
+	if err != nil {
+		return fmt.Errorf("verifyStorage, %v", err)
+	}
+	if err := eth.VerifyStorage(params.Proof, params.Extra, header.StateRoot, sideChain.CCMCAddress); err != nil {
+		return fmt.Errorf("verifyStorage, %v", err)
+	}
+	return nil
+}
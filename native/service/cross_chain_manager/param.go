@@ -39,3 +39,78 @@ func (this *BlackChainParam) Deserialization(source *common.ZeroCopySource) erro
 	this.ChainID = chainID
 	return nil
 }
+
+type PauseRouteParam struct {
+	SourceChainID uint64
+	ToChainID     uint64
+	ExpireHeight  uint32
+}
+
+func (this *PauseRouteParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarUint(this.SourceChainID)
+	sink.WriteVarUint(this.ToChainID)
+	sink.WriteUint32(this.ExpireHeight)
+}
+
+func (this *PauseRouteParam) Deserialization(source *common.ZeroCopySource) error {
+	sourceChainID, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("PauseRouteParam deserialize sourceChainID error")
+	}
+	toChainID, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("PauseRouteParam deserialize toChainID error")
+	}
+	expireHeight, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("PauseRouteParam deserialize expireHeight error")
+	}
+
+	this.SourceChainID = sourceChainID
+	this.ToChainID = toChainID
+	this.ExpireHeight = expireHeight
+	return nil
+}
+
+type RouteParam struct {
+	SourceChainID uint64
+	ToChainID     uint64
+}
+
+func (this *RouteParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarUint(this.SourceChainID)
+	sink.WriteVarUint(this.ToChainID)
+}
+
+func (this *RouteParam) Deserialization(source *common.ZeroCopySource) error {
+	sourceChainID, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("RouteParam deserialize sourceChainID error")
+	}
+	toChainID, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("RouteParam deserialize toChainID error")
+	}
+
+	this.SourceChainID = sourceChainID
+	this.ToChainID = toChainID
+	return nil
+}
+
+type TimeoutRequestParam struct {
+	PolyTxHash []byte
+}
+
+func (this *TimeoutRequestParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarBytes(this.PolyTxHash)
+}
+
+func (this *TimeoutRequestParam) Deserialization(source *common.ZeroCopySource) error {
+	polyTxHash, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("TimeoutRequestParam deserialize polyTxHash error")
+	}
+
+	this.PolyTxHash = polyTxHash
+	return nil
+}
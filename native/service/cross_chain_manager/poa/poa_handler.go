@@ -0,0 +1,116 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package poa
+
+import (
+	"fmt"
+
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/native"
+	scom "github.com/polynetwork/poly/native/service/cross_chain_manager/common"
+	"github.com/polynetwork/poly/native/service/cross_chain_manager/eth"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	hs "github.com/polynetwork/poly/native/service/header_sync/poa"
+)
+
+// Handler ...
+type Handler struct {
+}
+
+// NewHandler ...
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// MakeDepositProposal ...
+func (h *Handler) MakeDepositProposal(service *native.NativeService) (*scom.MakeTxParam, error) {
+	params := new(scom.EntranceParam)
+	if err := params.Deserialization(common.NewZeroCopySource(service.GetInput())); err != nil {
+		return nil, fmt.Errorf("poa MakeDepositProposal, contract params deserialize error: %s", err)
+	}
+
+	sideChain, err := side_chain_manager.GetSideChain(service, params.SourceChainID)
+	if err != nil {
+		return nil, fmt.Errorf("poa MakeDepositProposal, side_chain_manager.GetSideChain error: %v", err)
+	}
+
+	if err := verifyStorage(service, params, sideChain); err != nil {
+		return nil, fmt.Errorf("poa MakeDepositProposal, %v", err)
+	}
+	value := new(scom.MakeTxParam)
+	if err := value.Deserialization(common.NewZeroCopySource(params.Extra)); err != nil {
+		return nil, fmt.Errorf("poa MakeDepositProposal, deserialize merkleValue error:%s", err)
+	}
+
+	if err := scom.CheckDoneTx(service, value.CrossChainID, params.SourceChainID); err != nil {
+		return nil, fmt.Errorf("poa MakeDepositProposal, check done transaction error:%s", err)
+	}
+	if err := scom.PutDoneTx(service, value.CrossChainID, params.SourceChainID); err != nil {
+		return nil, fmt.Errorf("poa MakeDepositProposal, PutDoneTx error:%s", err)
+	}
+	return value, nil
+}
+
+// VerifyExecution checks the storage proof of the execution receipt kept by the CCMC of the target chain
+func (h *Handler) VerifyExecution(service *native.NativeService) (*scom.ExecutionReceipt, error) {
+	params := new(scom.EntranceParam)
+	if err := params.Deserialization(common.NewZeroCopySource(service.GetInput())); err != nil {
+		return nil, fmt.Errorf("poa VerifyExecution, contract params deserialize error: %s", err)
+	}
+
+	sideChain, err := side_chain_manager.GetSideChain(service, params.SourceChainID)
+	if err != nil {
+		return nil, fmt.Errorf("poa VerifyExecution, side_chain_manager.GetSideChain error: %v", err)
+	}
+
+	if err := verifyStorage(service, params, sideChain); err != nil {
+		return nil, fmt.Errorf("poa VerifyExecution, %v", err)
+	}
+	receipt := new(scom.ExecutionReceipt)
+	if err := receipt.Deserialization(common.NewZeroCopySource(params.Extra)); err != nil {
+		return nil, fmt.Errorf("poa VerifyExecution, deserialize receipt error:%s", err)
+	}
+	return receipt, nil
+}
+
+// verifyStorage checks the proof against the canonical header at params.Height
+// once it is BlocksToWait deep
+func verifyStorage(service *native.NativeService, params *scom.EntranceParam, sideChain *side_chain_manager.SideChain) error {
+	cheight, err := hs.GetCanonicalHeight(service, params.SourceChainID)
+	if err != nil {
+		return fmt.Errorf("verifyStorage, %v", err)
+	}
+	if cheight == nil {
+		return fmt.Errorf("verifyStorage, genesis is not initialized")
+	}
+	height := uint64(params.Height)
+	if *cheight < height || *cheight-height+1 < sideChain.BlocksToWait {
+		return fmt.Errorf("verifyStorage, transaction is not confirmed, current height: %d, input height: %d", *cheight, height)
+	}
+	header, err := hs.GetCanonicalHeader(service, params.SourceChainID, height)
+	if err != nil {
+		return fmt.Errorf("verifyStorage, GetCanonicalHeader height:%d, error:%v", height, err)
+	}
+	if header == nil {
+		return fmt.Errorf("verifyStorage, no canonical header at height %d", height)
+	}
+	if err := eth.VerifyStorage(params.Proof, params.Extra, header.Header.Root, sideChain.CCMCAddress); err != nil {
+		return fmt.Errorf("verifyStorage, %v", err)
+	}
+	return nil
+}
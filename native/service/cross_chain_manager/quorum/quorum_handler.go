@@ -74,7 +74,15 @@ func (this *QuorumHandler) MakeDepositProposal(ns *native.NativeService) (*commo
 	if err != nil {
 		return nil, fmt.Errorf("Quorum MakeDepositProposal, failed to get quorum validators: %v", err)
 	}
-	if _, err := quorum.VerifyQuorumHeader(vs, header, false); err != nil {
+	info, err := quorum.GetExtraInfo(ns, params.SourceChainID)
+	if err != nil {
+		return nil, fmt.Errorf("Quorum MakeDepositProposal, %v", err)
+	}
+	if info.Consensus == quorum.CONSENSUS_QBFT {
+		if _, err := quorum.VerifyQBFTHeader(vs, header); err != nil {
+			return nil, fmt.Errorf("Quorum MakeDepositProposal, failed to verify qbft header %s: %v", quorum.GetQBFTHeaderHash(header).String(), err)
+		}
+	} else if _, err := quorum.VerifyQuorumHeader(vs, header, false); err != nil {
 		return nil, fmt.Errorf("Quorum MakeDepositProposal, failed to verify quorum header %s: %v", header.Hash().String(), err)
 	}
 
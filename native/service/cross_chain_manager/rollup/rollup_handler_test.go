@@ -0,0 +1,175 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+package rollup
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/polynetwork/poly/common"
+	cstates "github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/core/store/leveldbstore"
+	"github.com/polynetwork/poly/core/store/overlaydb"
+	"github.com/polynetwork/poly/core/types"
+	"github.com/polynetwork/poly/native"
+	scom "github.com/polynetwork/poly/native/service/cross_chain_manager/common"
+	"github.com/polynetwork/poly/native/service/cross_chain_manager/eth"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	hscommon "github.com/polynetwork/poly/native/service/header_sync/common"
+	hseth "github.com/polynetwork/poly/native/service/header_sync/eth"
+	hs "github.com/polynetwork/poly/native/service/header_sync/rollup"
+	"github.com/polynetwork/poly/native/service/utils"
+	"github.com/polynetwork/poly/native/storage"
+	"gotest.tools/assert"
+)
+
+const (
+	l1ChainID = 2
+	l2ChainID = 300
+)
+
+var (
+	oracle = ecommon.HexToAddress("0x0000000000000000000000000000000000000a01")
+	ccmc   = ecommon.HexToAddress("0x0000000000000000000000000000000000000c01")
+)
+
+func newNative(args []byte, db *storage.CacheDB) *native.NativeService {
+	if db == nil {
+		store, _ := leveldbstore.NewMemLevelDBStore()
+		db = storage.NewCacheDB(overlaydb.NewOverlayDB(store))
+		service, _ := native.NewNativeService(db, new(types.Transaction), 0, 0, common.Uint256{0}, 0, nil, false)
+		_ = side_chain_manager.PutSideChain(service, &side_chain_manager.SideChain{Name: "eth", ChainId: l1ChainID, Router: utils.ETH_ROUTER})
+		extraInfo, _ := json.Marshal(&hs.ExtraInfo{
+			Kind:            hs.KIND_OP_STACK,
+			L1ChainID:       l1ChainID,
+			Oracle:          oracle,
+			ChallengeWindow: 100,
+		})
+		_ = side_chain_manager.PutSideChain(service, &side_chain_manager.SideChain{Name: "op", ChainId: l2ChainID,
+			Router: utils.ROLLUP_ROUTER, CCMCAddress: ccmc[:], ExtraInfo: extraInfo})
+	}
+	service, _ := native.NewNativeService(db, new(types.Transaction), 0, 0, common.Uint256{0}, 0, args, false)
+	return service
+}
+
+func makeProof(t *testing.T, words map[ecommon.Hash]ecommon.Hash, addr ecommon.Address) (ecommon.Hash, *eth.ETHProof) {
+	st, err := state.New(ecommon.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	assert.NilError(t, err)
+	st.SetNonce(addr, 1)
+	for k, v := range words {
+		st.SetState(addr, k, v)
+	}
+	root, err := st.Commit(false)
+	assert.NilError(t, err)
+
+	encode := func(nodes [][]byte) []string {
+		list := make([]string, len(nodes))
+		for i, node := range nodes {
+			list[i] = hexutil.Encode(node)
+		}
+		return list
+	}
+	accountProof, err := st.GetProof(addr)
+	assert.NilError(t, err)
+	proof := &eth.ETHProof{
+		Address:      addr.Hex(),
+		Balance:      hexutil.EncodeBig(st.GetBalance(addr)),
+		CodeHash:     st.GetCodeHash(addr).Hex(),
+		Nonce:        hexutil.EncodeUint64(st.GetNonce(addr)),
+		StorageHash:  st.StorageTrie(addr).Hash().Hex(),
+		AccountProof: encode(accountProof),
+	}
+	for key := range words {
+		storageProof, err := st.GetStorageProof(addr, key)
+		assert.NilError(t, err)
+		proof.StorageProofs = append(proof.StorageProofs, eth.StorageProof{Key: key.Hex(), Proof: encode(storageProof)})
+	}
+	return root, proof
+}
+
+func TestMakeDepositProposal(t *testing.T) {
+	txParam := &scom.MakeTxParam{
+		TxHash:              []byte{1},
+		CrossChainID:        []byte{2},
+		FromContractAddress: []byte{3},
+		ToChainID:           2,
+		ToContractAddress:   []byte{4},
+		Method:              "unlock",
+		Args:                []byte{5},
+	}
+	sink := common.NewZeroCopySink(nil)
+	txParam.Serialization(sink)
+	extra := sink.Bytes()
+	l2StateRoot, txProof := makeProof(t, map[ecommon.Hash]ecommon.Hash{ecommon.HexToHash("0x01"): crypto.Keccak256Hash(extra)}, ccmc)
+
+	// output 0 of l2Outputs at slot 0, proposed at 1000 for L2 block 1800
+	outputRoot := crypto.Keccak256Hash(make([]byte, 32), l2StateRoot[:], make([]byte, 64))
+	slot := new(big.Int).SetBytes(crypto.Keccak256(make([]byte, 32)))
+	word := new(big.Int).Or(new(big.Int).Lsh(big.NewInt(1800), 128), big.NewInt(1000))
+	l1StateRoot, outputProof := makeProof(t, map[ecommon.Hash]ecommon.Hash{
+		ecommon.BigToHash(slot):                          outputRoot,
+		ecommon.BigToHash(slot.Add(slot, big.NewInt(1))): ecommon.BigToHash(word),
+	}, oracle)
+
+	service := newNative(nil, nil)
+	db := service.GetCacheDB()
+	l1Header := &hseth.Header{Number: big.NewInt(10), Difficulty: big.NewInt(1), Time: 1101, Root: l1StateRoot}
+	raw, _ := json.Marshal(&hseth.HeaderWithDifficultySum{Header: *l1Header, DifficultySum: l1Header.Difficulty})
+	hash := l1Header.Hash()
+	db.Put(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(hscommon.HEADER_INDEX), utils.GetUint64Bytes(l1ChainID), hash[:]),
+		cstates.GenRawStorageItem(raw))
+	db.Put(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(hscommon.MAIN_CHAIN), utils.GetUint64Bytes(l1ChainID),
+		utils.GetUint64Bytes(10)), cstates.GenRawStorageItem(hash[:]))
+	db.Put(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(hscommon.CURRENT_HEADER_HEIGHT), utils.GetUint64Bytes(l1ChainID)),
+		cstates.GenRawStorageItem(utils.GetUint64Bytes(10)))
+
+	makeDepositProposal := func(height uint32, extra []byte) (*scom.MakeTxParam, error) {
+		raw, _ := json.Marshal(txProof)
+		param := &scom.EntranceParam{
+			SourceChainID: l2ChainID,
+			Height:        height,
+			Proof:         raw,
+			Extra:         extra,
+		}
+		sink := common.NewZeroCopySink(nil)
+		param.Serialization(sink)
+		return NewHandler().MakeDepositProposal(newNative(sink.Bytes(), db))
+	}
+	_, err := makeDepositProposal(1800, extra)
+	assert.ErrorContains(t, err, "no final output at height 1800")
+
+	raw, _ = json.Marshal(&hs.OutputProof{L1Height: 10, Proof: outputProof, StateRoot: l2StateRoot})
+	param := &hscommon.SyncBlockHeaderParam{ChainID: l2ChainID, Headers: [][]byte{raw}}
+	sink = common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	_, err = hs.NewHandler().SyncBlockHeader(newNative(sink.Bytes(), db))
+	assert.NilError(t, err)
+
+	_, err = makeDepositProposal(1800, append(extra, 0))
+	assert.ErrorContains(t, err, "verify proof value hash failed")
+	result, err := makeDepositProposal(1800, extra)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, result, txParam)
+	_, err = makeDepositProposal(1800, extra)
+	assert.ErrorContains(t, err, "tx already done")
+}
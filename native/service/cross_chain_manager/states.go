@@ -0,0 +1,277 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cross_chain_manager
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/polynetwork/poly/common"
+)
+
+type PausedRoute struct {
+	SourceChainID uint64
+	ToChainID     uint64
+	PauseHeight   uint32
+	ExpireHeight  uint32
+}
+
+// IsActive reports whether the route is still paused at height, a zero ExpireHeight never expires.
+func (this *PausedRoute) IsActive(height uint32) bool {
+	return this.ExpireHeight == 0 || height < this.ExpireHeight
+}
+
+func (this *PausedRoute) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarUint(this.SourceChainID)
+	sink.WriteVarUint(this.ToChainID)
+	sink.WriteUint32(this.PauseHeight)
+	sink.WriteUint32(this.ExpireHeight)
+}
+
+func (this *PausedRoute) Deserialization(source *common.ZeroCopySource) error {
+	sourceChainID, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("PausedRoute deserialize sourceChainID error")
+	}
+	toChainID, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("PausedRoute deserialize toChainID error")
+	}
+	pauseHeight, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("PausedRoute deserialize pauseHeight error")
+	}
+	expireHeight, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("PausedRoute deserialize expireHeight error")
+	}
+
+	this.SourceChainID = sourceChainID
+	this.ToChainID = toChainID
+	this.PauseHeight = pauseHeight
+	this.ExpireHeight = expireHeight
+	return nil
+}
+
+type PausedRouteList struct {
+	Routes []*PausedRoute
+}
+
+func (this *PausedRouteList) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarUint(uint64(len(this.Routes)))
+	for _, v := range this.Routes {
+		v.Serialization(sink)
+	}
+}
+
+func (this *PausedRouteList) Deserialization(source *common.ZeroCopySource) error {
+	n, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("PausedRouteList deserialize length error")
+	}
+	routes := make([]*PausedRoute, 0, n)
+	for i := uint64(0); i < n; i++ {
+		route := new(PausedRoute)
+		if err := route.Deserialization(source); err != nil {
+			return fmt.Errorf("PausedRouteList deserialize route error: %v", err)
+		}
+		routes = append(routes, route)
+	}
+	this.Routes = routes
+	return nil
+}
+
+// RouteLimit bounds the cross chain requests made on a route within every window of Window blocks.
+// A zero MaxCount does not limit the request count.
+type RouteLimit struct {
+	SourceChainID uint64
+	ToChainID     uint64
+	Window        uint32
+	MaxCount      uint64
+	AssetCaps     []*AssetCap
+}
+
+func (this *RouteLimit) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarUint(this.SourceChainID)
+	sink.WriteVarUint(this.ToChainID)
+	sink.WriteUint32(this.Window)
+	sink.WriteUint64(this.MaxCount)
+	sink.WriteVarUint(uint64(len(this.AssetCaps)))
+	for _, v := range this.AssetCaps {
+		v.Serialization(sink)
+	}
+}
+
+func (this *RouteLimit) Deserialization(source *common.ZeroCopySource) error {
+	sourceChainID, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("RouteLimit deserialize sourceChainID error")
+	}
+	toChainID, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("RouteLimit deserialize toChainID error")
+	}
+	window, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("RouteLimit deserialize window error")
+	}
+	maxCount, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("RouteLimit deserialize maxCount error")
+	}
+	n, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("RouteLimit deserialize assetCaps length error")
+	}
+	assetCaps := make([]*AssetCap, 0, n)
+	for i := uint64(0); i < n; i++ {
+		assetCap := new(AssetCap)
+		if err := assetCap.Deserialization(source); err != nil {
+			return fmt.Errorf("RouteLimit deserialize assetCap error: %v", err)
+		}
+		assetCaps = append(assetCaps, assetCap)
+	}
+
+	this.SourceChainID = sourceChainID
+	this.ToChainID = toChainID
+	this.Window = window
+	this.MaxCount = maxCount
+	this.AssetCaps = assetCaps
+	return nil
+}
+
+// AssetCap bounds the amount of ToAssetHash unlocked on the target chain within a window.
+type AssetCap struct {
+	ToAssetHash []byte
+	MaxAmount   *big.Int
+}
+
+func (this *AssetCap) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarBytes(this.ToAssetHash)
+	sink.WriteVarBytes(this.MaxAmount.Bytes())
+}
+
+func (this *AssetCap) Deserialization(source *common.ZeroCopySource) error {
+	toAssetHash, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("AssetCap deserialize toAssetHash error")
+	}
+	maxAmount, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("AssetCap deserialize maxAmount error")
+	}
+
+	this.ToAssetHash = toAssetHash
+	this.MaxAmount = new(big.Int).SetBytes(maxAmount)
+	return nil
+}
+
+// RouteUsage counts the requests and asset amounts of a route in the window starting at WindowStart.
+type RouteUsage struct {
+	WindowStart uint32
+	Count       uint64
+	Amounts     map[string]*big.Int
+}
+
+func (this *RouteUsage) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint32(this.WindowStart)
+	sink.WriteUint64(this.Count)
+	sink.WriteVarUint(uint64(len(this.Amounts)))
+	assets := make([]string, 0, len(this.Amounts))
+	for k := range this.Amounts {
+		assets = append(assets, k)
+	}
+	sort.Strings(assets)
+	for _, k := range assets {
+		sink.WriteString(k)
+		sink.WriteVarBytes(this.Amounts[k].Bytes())
+	}
+}
+
+func (this *RouteUsage) Deserialization(source *common.ZeroCopySource) error {
+	windowStart, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("RouteUsage deserialize windowStart error")
+	}
+	count, eof := source.NextUint64()
+	if eof {
+		return fmt.Errorf("RouteUsage deserialize count error")
+	}
+	n, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("RouteUsage deserialize amounts length error")
+	}
+	amounts := make(map[string]*big.Int)
+	for i := uint64(0); i < n; i++ {
+		k, eof := source.NextString()
+		if eof {
+			return fmt.Errorf("RouteUsage deserialize asset error")
+		}
+		v, eof := source.NextVarBytes()
+		if eof {
+			return fmt.Errorf("RouteUsage deserialize amount error")
+		}
+		amounts[k] = new(big.Int).SetBytes(v)
+	}
+
+	this.WindowStart = windowStart
+	this.Count = count
+	this.Amounts = amounts
+	return nil
+}
+
+// BatchImportResult is the result of an entrance param of a batch, Reason is empty on success.
+type BatchImportResult struct {
+	Success bool
+	Reason  string
+}
+
+type BatchImportResults struct {
+	Results []*BatchImportResult
+}
+
+func (this *BatchImportResults) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarUint(uint64(len(this.Results)))
+	for _, v := range this.Results {
+		sink.WriteBool(v.Success)
+		sink.WriteString(v.Reason)
+	}
+}
+
+func (this *BatchImportResults) Deserialization(source *common.ZeroCopySource) error {
+	n, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("BatchImportResults deserialize results length error")
+	}
+	results := make([]*BatchImportResult, 0)
+	for i := uint64(0); i < n; i++ {
+		success, eof := source.NextBool()
+		if eof {
+			return fmt.Errorf("BatchImportResults deserialize success error")
+		}
+		reason, eof := source.NextString()
+		if eof {
+			return fmt.Errorf("BatchImportResults deserialize reason error")
+		}
+		results = append(results, &BatchImportResult{Success: success, Reason: reason})
+	}
+
+	this.Results = results
+	return nil
+}
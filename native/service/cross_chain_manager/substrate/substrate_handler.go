@@ -0,0 +1,97 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package substrate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/native"
+	scom "github.com/polynetwork/poly/native/service/cross_chain_manager/common"
+	hs "github.com/polynetwork/poly/native/service/header_sync/substrate"
+)
+
+// StorageProof is the proof of a cross chain transaction returned by
+// state_getReadProof for Key
+type StorageProof struct {
+	Key   hexutil.Bytes   `json:"key"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+// Handler ...
+type Handler struct {
+}
+
+// NewHandler ...
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// MakeDepositProposal verifies that params.Extra is stored under a key of the
+// cross chain transactions in the finalized state at params.Height, the value
+// is the SCALE encoded bytes of the MakeTxParam
+func (h *Handler) MakeDepositProposal(service *native.NativeService) (*scom.MakeTxParam, error) {
+	params := new(scom.EntranceParam)
+	if err := params.Deserialization(common.NewZeroCopySource(service.GetInput())); err != nil {
+		return nil, fmt.Errorf("substrate MakeDepositProposal, contract params deserialize error: %s", err)
+	}
+	extraInfo, err := hs.GetExtraInfo(service, params.SourceChainID)
+	if err != nil {
+		return nil, fmt.Errorf("substrate MakeDepositProposal, %v", err)
+	}
+	proof := new(StorageProof)
+	if err := json.Unmarshal(params.Proof, proof); err != nil {
+		return nil, fmt.Errorf("substrate MakeDepositProposal, unmarshal proof error: %v", err)
+	}
+	if !bytes.HasPrefix(proof.Key, extraInfo.KeyPrefix) {
+		return nil, fmt.Errorf("substrate MakeDepositProposal, key %x is not a cross chain transaction", []byte(proof.Key))
+	}
+	root, err := hs.GetStateRoot(service, params.SourceChainID, params.Height)
+	if err != nil {
+		return nil, fmt.Errorf("substrate MakeDepositProposal, %v", err)
+	}
+	nodes := make([][]byte, len(proof.Proof))
+	for i, node := range proof.Proof {
+		nodes[i] = node
+	}
+	value, err := hs.VerifyStorageProof(root, proof.Key, nodes)
+	if err != nil {
+		return nil, fmt.Errorf("substrate MakeDepositProposal, %v", err)
+	}
+	if !bytes.Equal(value, params.Extra) {
+		return nil, fmt.Errorf("substrate MakeDepositProposal, proven value %x does not match", value)
+	}
+	raw, err := hs.DecodeScaleBytes(value)
+	if err != nil {
+		return nil, fmt.Errorf("substrate MakeDepositProposal, decode value error: %v", err)
+	}
+	txParam := new(scom.MakeTxParam)
+	if err := txParam.Deserialization(common.NewZeroCopySource(raw)); err != nil {
+		return nil, fmt.Errorf("substrate MakeDepositProposal, deserialize merkleValue error:%s", err)
+	}
+	if err := scom.CheckDoneTx(service, txParam.CrossChainID, params.SourceChainID); err != nil {
+		return nil, fmt.Errorf("substrate MakeDepositProposal, check done transaction error:%s", err)
+	}
+	if err := scom.PutDoneTx(service, txParam.CrossChainID, params.SourceChainID); err != nil {
+		return nil, fmt.Errorf("substrate MakeDepositProposal, PutDoneTx error:%s", err)
+	}
+	return txParam, nil
+}
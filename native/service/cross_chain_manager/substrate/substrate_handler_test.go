@@ -0,0 +1,155 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package substrate
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/polynetwork/poly/account"
+	"github.com/polynetwork/poly/common"
+	vconfig "github.com/polynetwork/poly/consensus/vbft/config"
+	"github.com/polynetwork/poly/core/genesis"
+	"github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/core/store/leveldbstore"
+	"github.com/polynetwork/poly/core/store/overlaydb"
+	"github.com/polynetwork/poly/core/types"
+	"github.com/polynetwork/poly/native"
+	scom "github.com/polynetwork/poly/native/service/cross_chain_manager/common"
+	"github.com/polynetwork/poly/native/service/governance/node_manager"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	hscommon "github.com/polynetwork/poly/native/service/header_sync/common"
+	hs "github.com/polynetwork/poly/native/service/header_sync/substrate"
+	"github.com/polynetwork/poly/native/service/utils"
+	"github.com/polynetwork/poly/native/storage"
+	"golang.org/x/crypto/blake2b"
+	"gotest.tools/assert"
+)
+
+const testChainID = 102
+
+var acct = account.NewAccount("")
+
+func init() {
+	genesis.GenesisBookkeepers = []keypair.PublicKey{acct.PublicKey}
+}
+
+func newNative(args []byte, db *storage.CacheDB) *native.NativeService {
+	tx := &types.Transaction{SignedAddr: []common.Address{acct.Address}}
+	if db == nil {
+		store, _ := leveldbstore.NewMemLevelDBStore()
+		db = storage.NewCacheDB(overlaydb.NewOverlayDB(store))
+		sink := common.NewZeroCopySink(nil)
+		view := &node_manager.GovernanceView{TxHash: common.UINT256_EMPTY}
+		view.Serialization(sink)
+		db.Put(utils.ConcatKey(utils.NodeManagerContractAddress, []byte(node_manager.GOVERNANCE_VIEW)), states.GenRawStorageItem(sink.Bytes()))
+
+		peerPoolMap := &node_manager.PeerPoolMap{
+			PeerPoolMap: map[string]*node_manager.PeerPoolItem{
+				vconfig.PubkeyID(acct.PublicKey): {
+					Address:    acct.Address,
+					Status:     node_manager.ConsensusStatus,
+					PeerPubkey: vconfig.PubkeyID(acct.PublicKey),
+				},
+			},
+		}
+		sink.Reset()
+		peerPoolMap.Serialization(sink)
+		db.Put(utils.ConcatKey(utils.NodeManagerContractAddress, []byte(node_manager.PEER_POOL), utils.GetUint32Bytes(0)),
+			states.GenRawStorageItem(sink.Bytes()))
+
+		extraInfo, _ := json.Marshal(&hs.ExtraInfo{KeyPrefix: []byte{0xcc}})
+		service, _ := native.NewNativeService(db, tx, 0, 0, common.Uint256{0}, 0, nil, false)
+		_ = side_chain_manager.PutSideChain(service, &side_chain_manager.SideChain{
+			Name:      "substrate",
+			ChainId:   testChainID,
+			Router:    utils.SUBSTRATE_ROUTER,
+			ExtraInfo: extraInfo,
+		})
+	}
+	service, _ := native.NewNativeService(db, tx, 0, 0, common.Uint256{0}, 0, args, false)
+	return service
+}
+
+// leafTrie is a trie holding only key, its root node is a leaf
+func leafTrie(key, value []byte) []byte {
+	node := []byte{0x40 | byte(2*len(key))}
+	node = append(append(node, key...), byte(len(value)<<2))
+	return append(node, value...)
+}
+
+func TestMakeDepositProposal(t *testing.T) {
+	txParam := &scom.MakeTxParam{
+		TxHash:              []byte{1},
+		CrossChainID:        []byte{2},
+		FromContractAddress: []byte{3},
+		ToChainID:           2,
+		ToContractAddress:   []byte{4},
+		Method:              "unlock",
+		Args:                []byte{5},
+	}
+	sink := common.NewZeroCopySink(nil)
+	txParam.Serialization(sink)
+	// a Vec<u8> of less than 64 bytes has a single byte length
+	value := append([]byte{byte(len(sink.Bytes()) << 2)}, sink.Bytes()...)
+	key := []byte{0xcc, 1}
+	node := leafTrie(key, value)
+
+	header := &hs.Header{Number: 100, StateRoot: blake2b.Sum256(node)}
+	raw, _ := json.Marshal(&hs.GenesisHeader{Header: header.Encode(), Authorities: []hs.Authority{{Key: make([]byte, 32), Weight: 1}}})
+	genesisParam := &hscommon.SyncGenesisHeaderParam{ChainID: testChainID, GenesisHeader: raw}
+	sink = common.NewZeroCopySink(nil)
+	genesisParam.Serialization(sink)
+	service := newNative(sink.Bytes(), nil)
+	assert.NilError(t, hs.NewHandler().SyncGenesisHeader(service))
+
+	makeDepositProposal := func(height uint32, key []byte, nodes [][]byte, value []byte) (*scom.MakeTxParam, error) {
+		proof := &StorageProof{Key: key}
+		for _, node := range nodes {
+			proof.Proof = append(proof.Proof, hexutil.Bytes(node))
+		}
+		raw, _ := json.Marshal(proof)
+		param := &scom.EntranceParam{
+			SourceChainID:  testChainID,
+			Height:         height,
+			Proof:          raw,
+			RelayerAddress: acct.Address[:],
+			Extra:          value,
+		}
+		sink := common.NewZeroCopySink(nil)
+		param.Serialization(sink)
+		return NewHandler().MakeDepositProposal(newNative(sink.Bytes(), service.GetCacheDB()))
+	}
+
+	_, err := makeDepositProposal(101, key, [][]byte{node}, value)
+	assert.ErrorContains(t, err, "no finalized block at height 101")
+	_, err = makeDepositProposal(100, []byte{0xcd, 1}, [][]byte{node}, value)
+	assert.ErrorContains(t, err, "is not a cross chain transaction")
+	_, err = makeDepositProposal(100, []byte{0xcc, 2}, [][]byte{node}, value)
+	assert.ErrorContains(t, err, "does not match")
+	_, err = makeDepositProposal(100, key, [][]byte{node}, append(value, 0))
+	assert.ErrorContains(t, err, "does not match")
+
+	result, err := makeDepositProposal(100, key, [][]byte{node}, value)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, result, txParam)
+	_, err = makeDepositProposal(100, key, [][]byte{node}, value)
+	assert.ErrorContains(t, err, "tx already done")
+}
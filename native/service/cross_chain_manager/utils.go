@@ -17,12 +17,23 @@
 package cross_chain_manager
 
 import (
+	"bytes"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"math/big"
+
+	"github.com/polynetwork/poly/common"
 	cstates "github.com/polynetwork/poly/core/states"
 	"github.com/polynetwork/poly/native"
+	scom "github.com/polynetwork/poly/native/service/cross_chain_manager/common"
 	"github.com/polynetwork/poly/native/service/utils"
 )
 
+// ErrRouteLimitExceeded is returned when a request would exceed the limit of its route,
+// it can be retried in a later window.
+var ErrRouteLimitExceeded = errors.New("route limit exceeded")
+
 func PutBlackChain(native *native.NativeService, chainID uint64) {
 	contract := utils.CrossChainManagerContractAddress
 	chainIDBytes := utils.GetUint64Bytes(chainID)
@@ -48,3 +59,196 @@ func RemoveBlackChain(native *native.NativeService, chainID uint64) {
 	chainIDBytes := utils.GetUint64Bytes(chainID)
 	native.GetCacheDB().Delete(utils.ConcatKey(contract, []byte(BLACKED_CHAIN), chainIDBytes))
 }
+
+func putPausedRoute(native *native.NativeService, route *PausedRoute) {
+	contract := utils.CrossChainManagerContractAddress
+	sink := common.NewZeroCopySink(nil)
+	route.Serialization(sink)
+	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(PAUSED_ROUTE), utils.GetUint64Bytes(route.SourceChainID),
+		utils.GetUint64Bytes(route.ToChainID)), cstates.GenRawStorageItem(sink.Bytes()))
+}
+
+func getPausedRoute(native *native.NativeService, sourceChainID, toChainID uint64) (*PausedRoute, error) {
+	contract := utils.CrossChainManagerContractAddress
+	routeStore, err := native.GetCacheDB().Get(utils.ConcatKey(contract, []byte(PAUSED_ROUTE),
+		utils.GetUint64Bytes(sourceChainID), utils.GetUint64Bytes(toChainID)))
+	if err != nil {
+		return nil, fmt.Errorf("getPausedRoute, get routeStore error: %v", err)
+	}
+	if routeStore == nil {
+		return nil, nil
+	}
+	routeBytes, err := cstates.GetValueFromRawStorageItem(routeStore)
+	if err != nil {
+		return nil, fmt.Errorf("getPausedRoute, deserialize from raw storage item err:%v", err)
+	}
+	route := new(PausedRoute)
+	if err := route.Deserialization(common.NewZeroCopySource(routeBytes)); err != nil {
+		return nil, fmt.Errorf("getPausedRoute, deserialize PausedRoute error: %v", err)
+	}
+	return route, nil
+}
+
+func getPausedRoutes(native *native.NativeService) ([]*PausedRoute, error) {
+	contract := utils.CrossChainManagerContractAddress
+	iter := native.GetCacheDB().NewIterator(utils.ConcatKey(contract, []byte(PAUSED_ROUTE)))
+	defer iter.Release()
+	routes := make([]*PausedRoute, 0)
+	for has := iter.First(); has; has = iter.Next() {
+		routeBytes, err := cstates.GetValueFromRawStorageItem(iter.Value())
+		if err != nil {
+			return nil, fmt.Errorf("getPausedRoutes, deserialize from raw storage item err:%v", err)
+		}
+		route := new(PausedRoute)
+		if err := route.Deserialization(common.NewZeroCopySource(routeBytes)); err != nil {
+			return nil, fmt.Errorf("getPausedRoutes, deserialize PausedRoute error: %v", err)
+		}
+		routes = append(routes, route)
+	}
+	if err := iter.Error(); err != nil {
+		return nil, fmt.Errorf("getPausedRoutes, iterator error: %v", err)
+	}
+	return routes, nil
+}
+
+func removePausedRoute(native *native.NativeService, sourceChainID, toChainID uint64) {
+	contract := utils.CrossChainManagerContractAddress
+	native.GetCacheDB().Delete(utils.ConcatKey(contract, []byte(PAUSED_ROUTE), utils.GetUint64Bytes(sourceChainID),
+		utils.GetUint64Bytes(toChainID)))
+}
+
+func CheckIfRoutePaused(native *native.NativeService, sourceChainID, toChainID uint64) (bool, error) {
+	route, err := getPausedRoute(native, sourceChainID, toChainID)
+	if err != nil {
+		return true, fmt.Errorf("CheckIfRoutePaused, getPausedRoute error: %v", err)
+	}
+	if route == nil {
+		return false, nil
+	}
+	return route.IsActive(native.GetHeight()), nil
+}
+
+func putRouteLimit(native *native.NativeService, limit *RouteLimit) {
+	contract := utils.CrossChainManagerContractAddress
+	sink := common.NewZeroCopySink(nil)
+	limit.Serialization(sink)
+	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(ROUTE_LIMIT), utils.GetUint64Bytes(limit.SourceChainID),
+		utils.GetUint64Bytes(limit.ToChainID)), cstates.GenRawStorageItem(sink.Bytes()))
+}
+
+func getRouteLimit(native *native.NativeService, sourceChainID, toChainID uint64) (*RouteLimit, error) {
+	contract := utils.CrossChainManagerContractAddress
+	limitStore, err := native.GetCacheDB().Get(utils.ConcatKey(contract, []byte(ROUTE_LIMIT),
+		utils.GetUint64Bytes(sourceChainID), utils.GetUint64Bytes(toChainID)))
+	if err != nil {
+		return nil, fmt.Errorf("getRouteLimit, get limitStore error: %v", err)
+	}
+	if limitStore == nil {
+		return nil, nil
+	}
+	limitBytes, err := cstates.GetValueFromRawStorageItem(limitStore)
+	if err != nil {
+		return nil, fmt.Errorf("getRouteLimit, deserialize from raw storage item err:%v", err)
+	}
+	limit := new(RouteLimit)
+	if err := limit.Deserialization(common.NewZeroCopySource(limitBytes)); err != nil {
+		return nil, fmt.Errorf("getRouteLimit, deserialize RouteLimit error: %v", err)
+	}
+	return limit, nil
+}
+
+func removeRouteLimit(native *native.NativeService, sourceChainID, toChainID uint64) {
+	contract := utils.CrossChainManagerContractAddress
+	native.GetCacheDB().Delete(utils.ConcatKey(contract, []byte(ROUTE_LIMIT), utils.GetUint64Bytes(sourceChainID),
+		utils.GetUint64Bytes(toChainID)))
+	native.GetCacheDB().Delete(utils.ConcatKey(contract, []byte(ROUTE_USAGE), utils.GetUint64Bytes(sourceChainID),
+		utils.GetUint64Bytes(toChainID)))
+}
+
+func putRouteUsage(native *native.NativeService, sourceChainID, toChainID uint64, usage *RouteUsage) {
+	contract := utils.CrossChainManagerContractAddress
+	sink := common.NewZeroCopySink(nil)
+	usage.Serialization(sink)
+	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(ROUTE_USAGE), utils.GetUint64Bytes(sourceChainID),
+		utils.GetUint64Bytes(toChainID)), cstates.GenRawStorageItem(sink.Bytes()))
+}
+
+func getRouteUsage(native *native.NativeService, sourceChainID, toChainID uint64) (*RouteUsage, error) {
+	contract := utils.CrossChainManagerContractAddress
+	usage := &RouteUsage{
+		Amounts: make(map[string]*big.Int),
+	}
+	usageStore, err := native.GetCacheDB().Get(utils.ConcatKey(contract, []byte(ROUTE_USAGE),
+		utils.GetUint64Bytes(sourceChainID), utils.GetUint64Bytes(toChainID)))
+	if err != nil {
+		return nil, fmt.Errorf("getRouteUsage, get usageStore error: %v", err)
+	}
+	if usageStore == nil {
+		return usage, nil
+	}
+	usageBytes, err := cstates.GetValueFromRawStorageItem(usageStore)
+	if err != nil {
+		return nil, fmt.Errorf("getRouteUsage, deserialize from raw storage item err:%v", err)
+	}
+	if err := usage.Deserialization(common.NewZeroCopySource(usageBytes)); err != nil {
+		return nil, fmt.Errorf("getRouteUsage, deserialize RouteUsage error: %v", err)
+	}
+	return usage, nil
+}
+
+// checkRouteLimit counts the request against the limit of its route and fails with
+// ErrRouteLimitExceeded once the count or an asset cap of the current window is exceeded. An
+// unlock on a route with asset caps is rejected if its args are not in the lock proxy format.
+func checkRouteLimit(native *native.NativeService, fromChainID uint64, params *scom.MakeTxParam) error {
+	limit, err := getRouteLimit(native, fromChainID, params.ToChainID)
+	if err != nil {
+		return fmt.Errorf("checkRouteLimit, getRouteLimit error: %v", err)
+	}
+	if limit == nil {
+		return nil
+	}
+	usage, err := getRouteUsage(native, fromChainID, params.ToChainID)
+	if err != nil {
+		return fmt.Errorf("checkRouteLimit, getRouteUsage error: %v", err)
+	}
+	height := native.GetHeight()
+	windowStart := height - height%limit.Window
+	if usage.WindowStart != windowStart {
+		usage = &RouteUsage{
+			WindowStart: windowStart,
+			Amounts:     make(map[string]*big.Int),
+		}
+	}
+
+	usage.Count++
+	if limit.MaxCount != 0 && usage.Count > limit.MaxCount {
+		return fmt.Errorf("%w: route from chain %d to chain %d allows %d requests every %d blocks",
+			ErrRouteLimitExceeded, fromChainID, params.ToChainID, limit.MaxCount, limit.Window)
+	}
+	if len(limit.AssetCaps) != 0 && params.Method == scom.LOCK_PROXY_UNLOCK {
+		args := new(scom.TxArgs)
+		// an unlock which can't be counted against the asset caps would get around them
+		if err := args.Deserialization(common.NewZeroCopySource(params.Args)); err != nil {
+			return fmt.Errorf("checkRouteLimit, route from chain %d to chain %d caps assets, deserialize unlock args error: %v",
+				fromChainID, params.ToChainID, err)
+		}
+		for _, assetCap := range limit.AssetCaps {
+			if !bytes.Equal(assetCap.ToAssetHash, args.ToAssetHash) {
+				continue
+			}
+			asset := hex.EncodeToString(assetCap.ToAssetHash)
+			amount, ok := usage.Amounts[asset]
+			if !ok {
+				amount = new(big.Int)
+			}
+			amount = new(big.Int).Add(amount, args.Amount)
+			if amount.Cmp(assetCap.MaxAmount) > 0 {
+				return fmt.Errorf("%w: route from chain %d to chain %d allows %s of asset %s every %d blocks",
+					ErrRouteLimitExceeded, fromChainID, params.ToChainID, assetCap.MaxAmount.String(), asset, limit.Window)
+			}
+			usage.Amounts[asset] = amount
+		}
+	}
+	putRouteUsage(native, fromChainID, params.ToChainID, usage)
+	return nil
+}
@@ -0,0 +1,402 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package node_manager
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/ontio/ontology-crypto/signature"
+	"github.com/polynetwork/poly/common"
+	vconfig "github.com/polynetwork/poly/consensus/vbft/config"
+	cstates "github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/core/types"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/event"
+	"github.com/polynetwork/poly/native/service/utils"
+)
+
+// vbft message types and wire formats, consensus/vbft imports this package so they are mirrored here
+const (
+	vbftProposalMsg uint8 = 0
+	vbftEndorseMsg  uint8 = 1
+)
+
+type vbftMsgPayload struct {
+	Type    uint8  `json:"type"`
+	Len     uint32 `json:"len"`
+	Payload []byte `json:"payload"`
+}
+
+type vbftEndorse struct {
+	Endorser          uint32         `json:"endorser"`
+	BlockNum          uint32         `json:"block_num"`
+	EndorsedBlockHash common.Uint256 `json:"endorsed_block_hash"`
+	EndorseForEmpty   bool           `json:"endorse_for_empty"`
+	EndorserSig       []byte         `json:"endorser_sig"`
+}
+
+// signedVote is what a proposal or an endorsement commits its signer to
+type signedVote struct {
+	kind      EvidenceKind
+	signer    uint32 // vbft peer index
+	height    uint32
+	hash      common.Uint256
+	prevHash  common.Uint256 // proposals only
+	payload   []byte         // proposals only, the vbft block info
+	emptyHash common.Uint256 // proposals only, the empty block proposed along with the block
+	emptySig  []byte
+	forEmpty  bool // endorsements only
+	sig       []byte
+}
+
+// decodeProposalBlock reads one block of a proposal
+func decodeProposalBlock(source *common.ZeroCopySource) (*types.Block, error) {
+	raw, eof := source.NextVarBytes()
+	if eof {
+		return nil, fmt.Errorf("deserialize block error")
+	}
+	block, err := types.BlockFromRawBytes(raw)
+	if err != nil {
+		return nil, fmt.Errorf("deserialize block error: %v", err)
+	}
+	if len(block.Header.SigData) == 0 {
+		return nil, fmt.Errorf("no sigdata in block")
+	}
+	return block, nil
+}
+
+// decodeVbftMsg reads a serialized vbft proposal or endorsement. A proposal carries a block and the
+// empty block to fall back to, both are one vote of the proposer. An endorsement only signs the block
+// hash, so the endorsed header has to come with it to bind the height.
+func decodeVbftMsg(msg []byte, header []byte) (*signedVote, error) {
+	m := new(vbftMsgPayload)
+	if err := json.Unmarshal(msg, m); err != nil {
+		return nil, fmt.Errorf("decodeVbftMsg, unmarshal consensus msg payload error: %v", err)
+	}
+	switch m.Type {
+	case vbftProposalMsg:
+		source := common.NewZeroCopySource(m.Payload)
+		block, err := decodeProposalBlock(source)
+		if err != nil {
+			return nil, fmt.Errorf("decodeVbftMsg, proposal %v", err)
+		}
+		info := new(vconfig.VbftBlockInfo)
+		if err := json.Unmarshal(block.Header.ConsensusPayload, info); err != nil {
+			return nil, fmt.Errorf("decodeVbftMsg, unmarshal vbft block info error: %v", err)
+		}
+		vote := &signedVote{
+			kind:     ProposalEvidence,
+			signer:   info.Proposer,
+			height:   block.Header.Height,
+			hash:     block.Hash(),
+			prevHash: block.Header.PrevBlockHash,
+			payload:  block.Header.ConsensusPayload,
+			sig:      block.Header.SigData[0],
+		}
+		if source.Len() == 0 {
+			return vote, nil
+		}
+		empty, err := decodeProposalBlock(source)
+		if err != nil {
+			return nil, fmt.Errorf("decodeVbftMsg, proposal empty %v", err)
+		}
+		if empty.Header.Height != block.Header.Height || empty.Header.PrevBlockHash != block.Header.PrevBlockHash ||
+			!bytes.Equal(empty.Header.ConsensusPayload, block.Header.ConsensusPayload) {
+			return nil, fmt.Errorf("decodeVbftMsg, empty block does not match the proposal block")
+		}
+		vote.emptyHash, vote.emptySig = empty.Hash(), empty.Header.SigData[0]
+		return vote, nil
+	case vbftEndorseMsg:
+		endorse := new(vbftEndorse)
+		if err := json.Unmarshal(m.Payload, endorse); err != nil {
+			return nil, fmt.Errorf("decodeVbftMsg, unmarshal endorse msg error: %v", err)
+		}
+		h, err := types.HeaderFromRawBytes(header)
+		if err != nil {
+			return nil, fmt.Errorf("decodeVbftMsg, deserialize endorsed header error: %v", err)
+		}
+		if hash := h.Hash(); hash != endorse.EndorsedBlockHash {
+			return nil, fmt.Errorf("decodeVbftMsg, endorsed header %s does not match endorsed block hash %s",
+				hash.ToHexString(), endorse.EndorsedBlockHash.ToHexString())
+		}
+		if h.Height != endorse.BlockNum {
+			return nil, fmt.Errorf("decodeVbftMsg, endorsed header height %d does not match block num %d",
+				h.Height, endorse.BlockNum)
+		}
+		return &signedVote{
+			kind:     EndorseEvidence,
+			signer:   endorse.Endorser,
+			height:   h.Height,
+			hash:     endorse.EndorsedBlockHash,
+			forEmpty: endorse.EndorseForEmpty,
+			sig:      endorse.EndorserSig,
+		}, nil
+	}
+	return nil, fmt.Errorf("decodeVbftMsg, unsupported msg type: %d", m.Type)
+}
+
+// checkConflict returns nil if an honest vbft peer would never have signed both votes
+func checkConflict(first, second *signedVote) error {
+	if first.kind != second.kind {
+		return fmt.Errorf("checkConflict, msg types are different")
+	}
+	if first.signer != second.signer {
+		return fmt.Errorf("checkConflict, msgs are signed by different peers: %d, %d", first.signer, second.signer)
+	}
+	if first.height != second.height {
+		return fmt.Errorf("checkConflict, msgs are for different heights: %d, %d", first.height, second.height)
+	}
+	if first.hash == second.hash {
+		return fmt.Errorf("checkConflict, msgs are for the same block")
+	}
+	switch first.kind {
+	case ProposalEvidence:
+		if first.prevHash != second.prevHash {
+			return fmt.Errorf("checkConflict, proposals extend different blocks")
+		}
+		// a block and its empty block only differ in txs and share the vbft block info
+		if bytes.Equal(first.payload, second.payload) {
+			return fmt.Errorf("checkConflict, proposals have the same consensus payload")
+		}
+	case EndorseEvidence:
+		// a peer endorses one proposal and one empty proposal at most for each height
+		if first.forEmpty != second.forEmpty {
+			return fmt.Errorf("checkConflict, endorsements are for a block and an empty block")
+		}
+	}
+	return nil
+}
+
+func verifyVote(peerPubkey string, vote *signedVote) error {
+	k, err := hex.DecodeString(peerPubkey)
+	if err != nil {
+		return fmt.Errorf("verifyVote, hex.DecodeString public key error: %v", err)
+	}
+	pub, err := keypair.DeserializePublicKey(k)
+	if err != nil {
+		return fmt.Errorf("verifyVote, keypair.DeserializePublicKey error: %v", err)
+	}
+	if err := verifySig(pub, vote.hash, vote.sig); err != nil {
+		return fmt.Errorf("verifyVote, %v", err)
+	}
+	if vote.emptySig != nil {
+		if err := verifySig(pub, vote.emptyHash, vote.emptySig); err != nil {
+			return fmt.Errorf("verifyVote, empty block %v", err)
+		}
+	}
+	return nil
+}
+
+func verifySig(pub keypair.PublicKey, hash common.Uint256, sigData []byte) error {
+	sig, err := signature.Deserialize(sigData)
+	if err != nil {
+		return fmt.Errorf("deserialize signature error: %v", err)
+	}
+	if !signature.Verify(pub, hash[:], sig) {
+		return fmt.Errorf("failed to verify signature of block %s", hash.ToHexString())
+	}
+	return nil
+}
+
+func getPeerByIndex(peerPoolMap *PeerPoolMap, index uint32) *PeerPoolItem {
+	for _, peerPoolItem := range peerPoolMap.PeerPoolMap {
+		if peerPoolItem.Index == index {
+			return peerPoolItem
+		}
+	}
+	return nil
+}
+
+func GetEvidence(native *native.NativeService, id common.Uint256) (*Evidence, error) {
+	contract := utils.NodeManagerContractAddress
+	evidenceBytes, err := native.GetCacheDB().Get(utils.ConcatKey(contract, []byte(EVIDENCE), id[:]))
+	if err != nil {
+		return nil, fmt.Errorf("GetEvidence, get evidence error: %v", err)
+	}
+	if evidenceBytes == nil {
+		return nil, nil
+	}
+	value, err := cstates.GetValueFromRawStorageItem(evidenceBytes)
+	if err != nil {
+		return nil, fmt.Errorf("GetEvidence, deserialize from raw storage item err:%v", err)
+	}
+	evidence := new(Evidence)
+	if err := evidence.Deserialization(common.NewZeroCopySource(value)); err != nil {
+		return nil, fmt.Errorf("GetEvidence, deserialize evidence error: %v", err)
+	}
+	return evidence, nil
+}
+
+// GetPeerEvidenceIDs returns the ids of the evidence against a peer in submission order
+func GetPeerEvidenceIDs(native *native.NativeService, peerPubkey string) ([]common.Uint256, error) {
+	contract := utils.NodeManagerContractAddress
+	peerPubkeyPrefix, err := hex.DecodeString(peerPubkey)
+	if err != nil {
+		return nil, fmt.Errorf("GetPeerEvidenceIDs, peerPubkey format error: %v", err)
+	}
+	indexBytes, err := native.GetCacheDB().Get(utils.ConcatKey(contract, []byte(EVIDENCE_INDEX), peerPubkeyPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("GetPeerEvidenceIDs, get evidence index error: %v", err)
+	}
+	if indexBytes == nil {
+		return nil, nil
+	}
+	value, err := cstates.GetValueFromRawStorageItem(indexBytes)
+	if err != nil {
+		return nil, fmt.Errorf("GetPeerEvidenceIDs, deserialize from raw storage item err:%v", err)
+	}
+	ids := make([]common.Uint256, 0, len(value)/common.UINT256_SIZE)
+	source := common.NewZeroCopySource(value)
+	for source.Len() != 0 {
+		id, eof := source.NextHash()
+		if eof {
+			return nil, fmt.Errorf("GetPeerEvidenceIDs, deserialize evidence id error")
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func putEvidence(native *native.NativeService, evidence *Evidence) error {
+	contract := utils.NodeManagerContractAddress
+	peerPubkeyPrefix, err := hex.DecodeString(evidence.PeerPubkey)
+	if err != nil {
+		return fmt.Errorf("putEvidence, peerPubkey format error: %v", err)
+	}
+	ids, err := GetPeerEvidenceIDs(native, evidence.PeerPubkey)
+	if err != nil {
+		return fmt.Errorf("putEvidence, GetPeerEvidenceIDs error: %v", err)
+	}
+	id := evidence.ID()
+	sink := common.NewZeroCopySink(nil)
+	evidence.Serialization(sink)
+	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(EVIDENCE), id[:]), cstates.GenRawStorageItem(sink.Bytes()))
+
+	index := common.NewZeroCopySink(nil)
+	for _, v := range append(ids, id) {
+		index.WriteHash(v)
+	}
+	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(EVIDENCE_INDEX), peerPubkeyPrefix), cstates.GenRawStorageItem(index.Bytes()))
+	return nil
+}
+
+// Verify two conflicting vbft messages of one peer and put the peer into black list
+// Anyone can submit evidence, a peer is not blacked when it would leave less than 4 peers
+func SubmitEvidence(native *native.NativeService) ([]byte, error) {
+	params := new(SubmitEvidenceParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("submitEvidence, contract params deserialize error: %v", err)
+	}
+
+	//check witness
+	err := utils.ValidateOwner(native, params.Address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("submitEvidence, checkWitness error: %v", err)
+	}
+
+	first, err := decodeVbftMsg(params.FirstMsg, params.FirstHeader)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("submitEvidence, decode first msg error: %v", err)
+	}
+	second, err := decodeVbftMsg(params.SecondMsg, params.SecondHeader)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("submitEvidence, decode second msg error: %v", err)
+	}
+	if err := checkConflict(first, second); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("submitEvidence, %v", err)
+	}
+
+	//get current view
+	view, err := GetView(native)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("submitEvidence, get view error: %v", err)
+	}
+	//get peerPoolMap
+	peerPoolMap, err := GetPeerPoolMap(native, view)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("submitEvidence, get peerPoolMap error: %v", err)
+	}
+	peerPoolItem := getPeerByIndex(peerPoolMap, first.signer)
+	if peerPoolItem == nil {
+		return utils.BYTE_FALSE, fmt.Errorf("submitEvidence, peer index: %d is not in peerPoolMap", first.signer)
+	}
+	if peerPoolItem.Status == BlackStatus {
+		return utils.BYTE_FALSE, fmt.Errorf("submitEvidence, peerPubkey: %s is already blacked", peerPoolItem.PeerPubkey)
+	}
+	if err := verifyVote(peerPoolItem.PeerPubkey, first); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("submitEvidence, verify first msg error: %v", err)
+	}
+	if err := verifyVote(peerPoolItem.PeerPubkey, second); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("submitEvidence, verify second msg error: %v", err)
+	}
+
+	evidence := &Evidence{
+		Kind:       first.kind,
+		PeerPubkey: peerPoolItem.PeerPubkey,
+		Address:    peerPoolItem.Address,
+		Height:     first.height,
+		FirstHash:  first.hash,
+		SecondHash: second.hash,
+		Reporter:   params.Address,
+		PolyHeight: native.GetHeight(),
+	}
+	id := evidence.ID()
+	old, err := GetEvidence(native, id)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("submitEvidence, GetEvidence error: %v", err)
+	}
+	if old != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("submitEvidence, evidence %s is already submitted", id.ToHexString())
+	}
+
+	//check peers num
+	num := 0
+	for _, v := range peerPoolMap.PeerPoolMap {
+		if v.Status == CandidateStatus || v.Status == ConsensusStatus {
+			num = num + 1
+		}
+	}
+	active := peerPoolItem.Status == CandidateStatus || peerPoolItem.Status == ConsensusStatus
+	if !active || num > MIN_PEER_NUM {
+		commit, err := blackPeers(native, view, peerPoolMap, []string{peerPoolItem.PeerPubkey})
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("submitEvidence, blackPeers error: %v", err)
+		}
+		//commitDpos
+		if commit {
+			err = executeCommitDpos(native)
+			if err != nil {
+				return utils.BYTE_FALSE, fmt.Errorf("submitEvidence, executeCommitDpos error: %v", err)
+			}
+		}
+		evidence.Blacked = true
+	}
+	if err := putEvidence(native, evidence); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("submitEvidence, putEvidence error: %v", err)
+	}
+	native.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.NodeManagerContractAddress,
+			States:          []interface{}{"submitEvidence", evidence.PeerPubkey, evidence.Height, id.ToHexString(), evidence.Blacked},
+		})
+	return utils.BYTE_TRUE, nil
+}
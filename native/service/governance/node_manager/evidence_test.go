@@ -0,0 +1,294 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package node_manager
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/polynetwork/poly/account"
+	"github.com/polynetwork/poly/common"
+	vconfig "github.com/polynetwork/poly/consensus/vbft/config"
+	"github.com/polynetwork/poly/core/signature"
+	"github.com/polynetwork/poly/core/store/leveldbstore"
+	"github.com/polynetwork/poly/core/store/overlaydb"
+	"github.com/polynetwork/poly/core/types"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/utils"
+	"github.com/polynetwork/poly/native/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+var peerAccts = func() []*account.Account {
+	accts := make([]*account.Account, 0)
+	for i := 0; i < 5; i++ {
+		accts = append(accts, account.NewAccount(strconv.FormatUint(uint64(i), 10)))
+	}
+	return accts
+}()
+
+func newEvidenceNative(args []byte, signer common.Address, db *storage.CacheDB) *native.NativeService {
+	if db == nil {
+		store, _ := leveldbstore.NewMemLevelDBStore()
+		db = storage.NewCacheDB(overlaydb.NewOverlayDB(store))
+	}
+	ns, _ := native.NewNativeService(db, &types.Transaction{SignedAddr: []common.Address{signer}}, 0, 10,
+		common.Uint256{0}, 0, args, false)
+	return ns
+}
+
+// putPeers puts the first n peer accounts with indexes from 1 as consensus peers of view 1
+func putPeers(ns *native.NativeService, n int) {
+	peerPoolMap := &PeerPoolMap{PeerPoolMap: make(map[string]*PeerPoolItem)}
+	for i, acct := range peerAccts[:n] {
+		pkStr := vconfig.PubkeyID(acct.PublicKey)
+		peerPoolMap.PeerPoolMap[pkStr] = &PeerPoolItem{
+			Index:      uint32(i + 1),
+			PeerPubkey: pkStr,
+			Address:    acct.Address,
+			Status:     ConsensusStatus,
+		}
+	}
+	putPeerPoolMap(ns, peerPoolMap, 1)
+	putGovernanceView(ns, &GovernanceView{View: 1, Height: 5, TxHash: common.UINT256_EMPTY})
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	raw, err := hex.DecodeString(s)
+	assert.Nil(t, err)
+	return raw
+}
+
+func vbftMsg(t *testing.T, msgType uint8, payload []byte) []byte {
+	raw, err := json.Marshal(&vbftMsgPayload{Type: msgType, Len: uint32(len(payload)), Payload: payload})
+	assert.Nil(t, err)
+	return raw
+}
+
+// proposalBlocks returns a signed proposal block and its empty block, vrf tells proposals apart
+func proposalBlocks(t *testing.T, acct *account.Account, index, height uint32, vrf byte) (*types.Block, *types.Block) {
+	info, err := json.Marshal(&vconfig.VbftBlockInfo{Proposer: index, VrfValue: []byte{vrf}})
+	assert.Nil(t, err)
+	blocks := make([]*types.Block, 0, 2)
+	for _, nonce := range []uint64{1, 2} {
+		block := &types.Block{Header: &types.Header{
+			PrevBlockHash:    common.Uint256{1},
+			Timestamp:        10,
+			Height:           height,
+			ConsensusData:    nonce,
+			ConsensusPayload: info,
+		}}
+		hash := block.Hash()
+		sig, err := signature.Sign(acct, hash[:])
+		assert.Nil(t, err)
+		block.Header.SigData = [][]byte{sig}
+		blocks = append(blocks, block)
+	}
+	return blocks[0], blocks[1]
+}
+
+func proposalMsg(t *testing.T, blocks ...*types.Block) []byte {
+	payload := common.NewZeroCopySink(nil)
+	for _, block := range blocks {
+		sink := common.NewZeroCopySink(nil)
+		assert.Nil(t, block.Serialization(sink))
+		payload.WriteVarBytes(sink.Bytes())
+	}
+	return vbftMsg(t, vbftProposalMsg, payload.Bytes())
+}
+
+func endorseMsg(t *testing.T, acct *account.Account, index uint32, header *types.Header, forEmpty bool) []byte {
+	hash := header.Hash()
+	sig, err := signature.Sign(acct, hash[:])
+	assert.Nil(t, err)
+	payload, err := json.Marshal(&vbftEndorse{
+		Endorser:          index,
+		BlockNum:          header.Height,
+		EndorsedBlockHash: hash,
+		EndorseForEmpty:   forEmpty,
+		EndorserSig:       sig,
+	})
+	assert.Nil(t, err)
+	return vbftMsg(t, vbftEndorseMsg, payload)
+}
+
+func submitEvidence(db *storage.CacheDB, param *SubmitEvidenceParam) (*native.NativeService, error) {
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	ns := newEvidenceNative(sink.Bytes(), param.Address, db)
+	_, err := SubmitEvidence(ns)
+	return ns, err
+}
+
+func TestSubmitEvidenceParam(t *testing.T) {
+	param := &SubmitEvidenceParam{
+		FirstMsg:    []byte{1, 2},
+		SecondMsg:   []byte{3},
+		FirstHeader: []byte{4},
+		Address:     common.Address{5},
+	}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	param1 := new(SubmitEvidenceParam)
+	assert.Nil(t, param1.Deserialization(common.NewZeroCopySource(sink.Bytes())))
+	assert.Equal(t, param.FirstMsg, param1.FirstMsg)
+	assert.Equal(t, param.SecondMsg, param1.SecondMsg)
+	assert.Equal(t, param.FirstHeader, param1.FirstHeader)
+	assert.Empty(t, param1.SecondHeader)
+	assert.Equal(t, param.Address, param1.Address)
+}
+
+func TestSubmitProposalEvidence(t *testing.T) {
+	ns := newEvidenceNative(nil, common.ADDRESS_EMPTY, nil)
+	putPeers(ns, 5)
+	db := ns.GetCacheDB()
+	offender, reporter := peerAccts[1], common.Address{9}
+
+	block, empty := proposalBlocks(t, offender, 2, 20, 1)
+	// a peer proposing the same block twice is fine
+	_, err := submitEvidence(db, &SubmitEvidenceParam{
+		FirstMsg:  proposalMsg(t, block, empty),
+		SecondMsg: proposalMsg(t, block),
+		Address:   reporter,
+	})
+	assert.NotNil(t, err)
+	// and so is the empty block proposed along with its block
+	for _, second := range [][]*types.Block{{empty}, {empty, block}} {
+		_, err = submitEvidence(db, &SubmitEvidenceParam{
+			FirstMsg:  proposalMsg(t, block, empty),
+			SecondMsg: proposalMsg(t, second...),
+			Address:   reporter,
+		})
+		assert.NotNil(t, err)
+	}
+	// the empty block must belong to the proposal
+	other, otherEmpty := proposalBlocks(t, offender, 2, 20, 2)
+	_, err = submitEvidence(db, &SubmitEvidenceParam{
+		FirstMsg:  proposalMsg(t, block, otherEmpty),
+		SecondMsg: proposalMsg(t, other),
+		Address:   reporter,
+	})
+	assert.NotNil(t, err)
+	// the proposer index must belong to the signer
+	wrong1, _ := proposalBlocks(t, offender, 3, 20, 1)
+	wrong2, _ := proposalBlocks(t, offender, 3, 20, 2)
+	_, err = submitEvidence(db, &SubmitEvidenceParam{
+		FirstMsg:  proposalMsg(t, wrong1),
+		SecondMsg: proposalMsg(t, wrong2),
+		Address:   reporter,
+	})
+	assert.NotNil(t, err)
+	// proposals of different heights do not conflict
+	next, _ := proposalBlocks(t, offender, 2, 21, 2)
+	_, err = submitEvidence(db, &SubmitEvidenceParam{
+		FirstMsg:  proposalMsg(t, block),
+		SecondMsg: proposalMsg(t, next),
+		Address:   reporter,
+	})
+	assert.NotNil(t, err)
+
+	ns, err = submitEvidence(db, &SubmitEvidenceParam{
+		FirstMsg:  proposalMsg(t, block, empty),
+		SecondMsg: proposalMsg(t, otherEmpty),
+		Address:   reporter,
+	})
+	assert.Nil(t, err)
+
+	pkStr := vconfig.PubkeyID(offender.PublicKey)
+	ids, err := GetPeerEvidenceIDs(ns, pkStr)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(ids))
+	evidence, err := GetEvidence(ns, ids[0])
+	assert.Nil(t, err)
+	assert.Equal(t, ProposalEvidence, evidence.Kind)
+	assert.Equal(t, pkStr, evidence.PeerPubkey)
+	assert.Equal(t, offender.Address, evidence.Address)
+	assert.Equal(t, uint32(20), evidence.Height)
+	assert.Equal(t, reporter, evidence.Reporter)
+	assert.True(t, evidence.Blacked)
+
+	// the offender is blacked and removed from the next view
+	black, err := ns.GetCacheDB().Get(utils.ConcatKey(utils.NodeManagerContractAddress, []byte(BLACK_LIST), mustDecodeHex(t, pkStr)))
+	assert.Nil(t, err)
+	assert.NotNil(t, black)
+	view, err := GetView(ns)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(2), view)
+	peerPoolMap, err := GetPeerPoolMap(ns, view)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(peerPoolMap.PeerPoolMap))
+	assert.Nil(t, peerPoolMap.PeerPoolMap[pkStr])
+}
+
+func TestSubmitEndorseEvidence(t *testing.T) {
+	ns := newEvidenceNative(nil, common.ADDRESS_EMPTY, nil)
+	putPeers(ns, 4)
+	db := ns.GetCacheDB()
+	offender, reporter := peerAccts[0], peerAccts[3].Address
+	header1 := &types.Header{Height: 30, Timestamp: 1}
+	header2 := &types.Header{Height: 30, Timestamp: 2}
+
+	// the endorsed headers bind the height
+	_, err := submitEvidence(db, &SubmitEvidenceParam{
+		FirstMsg:     endorseMsg(t, offender, 1, header1, false),
+		SecondMsg:    endorseMsg(t, offender, 1, header2, false),
+		FirstHeader:  header2.ToArray(),
+		SecondHeader: header2.ToArray(),
+		Address:      reporter,
+	})
+	assert.NotNil(t, err)
+	// one endorsement for a block and one for an empty block is allowed
+	_, err = submitEvidence(db, &SubmitEvidenceParam{
+		FirstMsg:     endorseMsg(t, offender, 1, header1, false),
+		SecondMsg:    endorseMsg(t, offender, 1, header2, true),
+		FirstHeader:  header1.ToArray(),
+		SecondHeader: header2.ToArray(),
+		Address:      reporter,
+	})
+	assert.NotNil(t, err)
+
+	// with 4 peers left the evidence is kept but the offender is not blacked
+	param := &SubmitEvidenceParam{
+		FirstMsg:     endorseMsg(t, offender, 1, header1, false),
+		SecondMsg:    endorseMsg(t, offender, 1, header2, false),
+		FirstHeader:  header1.ToArray(),
+		SecondHeader: header2.ToArray(),
+		Address:      reporter,
+	}
+	ns, err = submitEvidence(db, param)
+	assert.Nil(t, err)
+	pkStr := vconfig.PubkeyID(offender.PublicKey)
+	ids, err := GetPeerEvidenceIDs(ns, pkStr)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(ids))
+	evidence, err := GetEvidence(ns, ids[0])
+	assert.Nil(t, err)
+	assert.Equal(t, EndorseEvidence, evidence.Kind)
+	assert.False(t, evidence.Blacked)
+	peerPoolMap, err := GetPeerPoolMap(ns, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, ConsensusStatus, peerPoolMap.PeerPoolMap[pkStr].Status)
+
+	// the same evidence in the other order is rejected
+	param.FirstMsg, param.SecondMsg = param.SecondMsg, param.FirstMsg
+	param.FirstHeader, param.SecondHeader = param.SecondHeader, param.FirstHeader
+	_, err = submitEvidence(db, param)
+	assert.NotNil(t, err)
+}
@@ -18,7 +18,10 @@
 package node_manager
 
 import (
+	"encoding/hex"
 	"fmt"
+	"github.com/polynetwork/poly/common"
+	cstates "github.com/polynetwork/poly/core/states"
 	"github.com/polynetwork/poly/native"
 	"github.com/polynetwork/poly/native/service/utils"
 )
@@ -68,3 +71,36 @@ func executeCommitDpos(native *native.NativeService) error {
 	putGovernanceView(native, governanceView)
 	return nil
 }
+
+func blackPeers(native *native.NativeService, view uint32, peerPoolMap *PeerPoolMap, peerPubkeyList []string) (bool, error) {
+	commit := false
+	for _, peerPubkey := range peerPubkeyList {
+		peerPubkeyPrefix, err := hex.DecodeString(peerPubkey)
+		if err != nil {
+			return false, fmt.Errorf("blackPeers, peerPubkey format error: %v", err)
+		}
+		peerPoolItem, ok := peerPoolMap.PeerPoolMap[peerPubkey]
+		if !ok {
+			return false, fmt.Errorf("blackPeers, peerPubkey is not in peerPoolMap")
+		}
+
+		blackListItem := &BlackListItem{
+			PeerPubkey: peerPoolItem.PeerPubkey,
+			Address:    peerPoolItem.Address,
+		}
+		sink := common.NewZeroCopySink(nil)
+		blackListItem.Serialization(sink)
+		//put peer into black list
+		native.GetCacheDB().Put(utils.ConcatKey(utils.NodeManagerContractAddress, []byte(BLACK_LIST), peerPubkeyPrefix),
+			cstates.GenRawStorageItem(sink.Bytes()))
+
+		//change peerPool status
+		if peerPoolItem.Status == ConsensusStatus {
+			commit = true
+		}
+		peerPoolItem.Status = BlackStatus
+		peerPoolMap.PeerPoolMap[peerPubkey] = peerPoolItem
+	}
+	putPeerPoolMap(native, peerPoolMap, view)
+	return commit, nil
+}
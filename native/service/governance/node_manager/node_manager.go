@@ -45,6 +45,7 @@ const (
 	QUIT_NODE            = "quitNode"
 	UPDATE_CONFIG        = "updateConfig"
 	COMMIT_DPOS          = "commitDpos"
+	SUBMIT_EVIDENCE      = "submitEvidence"
 
 	//key prefix
 	GOVERNANCE_VIEW = "governanceView"
@@ -55,9 +56,12 @@ const (
 	PEER_INDEX      = "peerIndex"
 	BLACK_LIST      = "blackList"
 	CONSENSUS_SIGNS = "consensusSigns"
+	EVIDENCE        = "evidence"
+	EVIDENCE_INDEX  = "evidenceIndex"
 
 	//const
-	MIN_PEER_NUM = 4
+	MIN_PEER_NUM              = 4
+	MIN_EPOCH_ROTATION_PERIOD = 1000
 )
 
 //Register methods of node_manager contract
@@ -71,6 +75,7 @@ func RegisterNodeManagerContract(native *native.NativeService) {
 	native.Register(WHITE_NODE, WhiteNode)
 	native.Register(UPDATE_CONFIG, UpdateConfig)
 	native.Register(COMMIT_DPOS, CommitDpos)
+	native.Register(SUBMIT_EVIDENCE, SubmitEvidence)
 }
 
 //Init node_manager contract
@@ -363,7 +368,6 @@ func BlackNode(native *native.NativeService) ([]byte, error) {
 	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
 		return utils.BYTE_FALSE, fmt.Errorf("blackNode, contract params deserialize error: %v", err)
 	}
-	contract := utils.NodeManagerContractAddress
 
 	//check witness
 	err := utils.ValidateOwner(native, params.Address)
@@ -415,34 +419,10 @@ func BlackNode(native *native.NativeService) ([]byte, error) {
 		return utils.BYTE_TRUE, nil
 	}
 
-	commit := false
-	for _, peerPubkey := range params.PeerPubkeyList {
-		peerPubkeyPrefix, err := hex.DecodeString(peerPubkey)
-		if err != nil {
-			return utils.BYTE_FALSE, fmt.Errorf("blackNode, peerPubkey format error: %v", err)
-		}
-		peerPoolItem, ok := peerPoolMap.PeerPoolMap[peerPubkey]
-		if !ok {
-			return utils.BYTE_FALSE, fmt.Errorf("blackNode, peerPubkey is not in peerPoolMap")
-		}
-
-		blackListItem := &BlackListItem{
-			PeerPubkey: peerPoolItem.PeerPubkey,
-			Address:    peerPoolItem.Address,
-		}
-		sink := common.NewZeroCopySink(nil)
-		blackListItem.Serialization(sink)
-		//put peer into black list
-		native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(BLACK_LIST), peerPubkeyPrefix), cstates.GenRawStorageItem(sink.Bytes()))
-
-		//change peerPool status
-		if peerPoolItem.Status == ConsensusStatus {
-			commit = true
-		}
-		peerPoolItem.Status = BlackStatus
-		peerPoolMap.PeerPoolMap[peerPubkey] = peerPoolItem
+	commit, err := blackPeers(native, view, peerPoolMap, params.PeerPubkeyList)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("blackNode, blackPeers error: %v", err)
 	}
-	putPeerPoolMap(native, peerPoolMap, view)
 
 	//commitDpos
 	if commit {
@@ -589,7 +569,8 @@ func CommitDpos(native *native.NativeService) ([]byte, error) {
 	err = utils.ValidateOwner(native, operatorAddress)
 	if err != nil {
 		cycle := (native.GetHeight() - governanceView.Height) >= config.MaxBlockChangeView
-		if !cycle {
+		rotation := config.EpochRotationPeriod != 0 && native.GetHeight() >= config.NextRotationHeight(governanceView)
+		if !cycle && !rotation {
 			return utils.BYTE_FALSE, fmt.Errorf("commitDpos, authentication Failed")
 		}
 	}
@@ -620,9 +601,9 @@ func UpdateConfig(native *native.NativeService) ([]byte, error) {
 	if err != nil {
 		return utils.BYTE_FALSE, fmt.Errorf("updateConfig, get current consensus operator address error: %v", err)
 	}
-	//check witness
+	//check witness, or a proposal approved in proposal_manager
 	err = utils.ValidateOwner(native, operatorAddress)
-	if err != nil {
+	if err != nil && !native.CheckWitness(utils.ProposalManagerContractAddress) {
 		return utils.BYTE_FALSE, fmt.Errorf("updateConfig, checkWitness error: %v", err)
 	}
 
@@ -638,6 +619,9 @@ func UpdateConfig(native *native.NativeService) ([]byte, error) {
 	if params.Configuration.MaxBlockChangeView < 10000 {
 		return utils.BYTE_FALSE, fmt.Errorf("updateConfig. MaxBlockChangeView must >= 10000")
 	}
+	if params.Configuration.EpochRotationPeriod != 0 && params.Configuration.EpochRotationPeriod < MIN_EPOCH_ROTATION_PERIOD {
+		return utils.BYTE_FALSE, fmt.Errorf("updateConfig. EpochRotationPeriod must be 0 or >= %d", MIN_EPOCH_ROTATION_PERIOD)
+	}
 
 	putConfig(native, params.Configuration)
 	native.AddNotify(
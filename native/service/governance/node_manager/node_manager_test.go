@@ -0,0 +1,59 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package node_manager
+
+import (
+	"testing"
+
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/core/types"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func commitDpos(db *storage.CacheDB, height uint32) error {
+	ns, _ := native.NewNativeService(db, &types.Transaction{}, 0, height, common.Uint256{0}, 0, nil, false)
+	_, err := CommitDpos(ns)
+	return err
+}
+
+func TestCommitDposRotation(t *testing.T) {
+	ns := newEvidenceNative(nil, common.ADDRESS_EMPTY, nil)
+	putPeers(ns, 4)
+	putConfig(ns, &Configuration{
+		BlockMsgDelay:        10000,
+		HashMsgDelay:         10000,
+		PeerHandshakeTimeout: 10,
+		MaxBlockChangeView:   60000,
+		EpochRotationPeriod:  1000,
+	})
+	db := ns.GetCacheDB()
+
+	// without the operator signature the view only changes once the rotation is due
+	assert.NotNil(t, commitDpos(db, 1004))
+	assert.Nil(t, commitDpos(db, 1005))
+	view, err := GetGovernanceView(ns)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(2), view.View)
+	assert.Equal(t, uint32(1005), view.Height)
+
+	// the next rotation is scheduled from the new view
+	assert.NotNil(t, commitDpos(db, 2004))
+	assert.Nil(t, commitDpos(db, 2005))
+}
@@ -137,3 +137,52 @@ func (this *UpdateConfigParam) Deserialization(source *common.ZeroCopySource) er
 	this.Configuration = configuration
 	return nil
 }
+
+type SubmitEvidenceParam struct {
+	FirstMsg     []byte // vbft consensus message, serialized as the peers broadcast it
+	SecondMsg    []byte
+	FirstHeader  []byte // header of the block endorsed by FirstMsg, only for endorse evidence
+	SecondHeader []byte
+	Address      common.Address
+}
+
+func (this *SubmitEvidenceParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarBytes(this.FirstMsg)
+	sink.WriteVarBytes(this.SecondMsg)
+	sink.WriteVarBytes(this.FirstHeader)
+	sink.WriteVarBytes(this.SecondHeader)
+	sink.WriteVarBytes(this.Address[:])
+}
+
+func (this *SubmitEvidenceParam) Deserialization(source *common.ZeroCopySource) error {
+	firstMsg, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("source.NextVarBytes, deserialize firstMsg error")
+	}
+	secondMsg, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("source.NextVarBytes, deserialize secondMsg error")
+	}
+	firstHeader, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("source.NextVarBytes, deserialize firstHeader error")
+	}
+	secondHeader, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("source.NextVarBytes, deserialize secondHeader error")
+	}
+	address, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("source.NextVarBytes, deserialize address error")
+	}
+	addr, err := common.AddressParseFromBytes(address)
+	if err != nil {
+		return fmt.Errorf("common.AddressParseFromBytes, deserialize address error: %s", err)
+	}
+	this.FirstMsg = firstMsg
+	this.SecondMsg = secondMsg
+	this.FirstHeader = firstHeader
+	this.SecondHeader = secondHeader
+	this.Address = addr
+	return nil
+}
@@ -18,6 +18,8 @@
 package node_manager
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"sort"
@@ -228,6 +230,16 @@ type Configuration struct {
 	HashMsgDelay         uint32
 	PeerHandshakeTimeout uint32
 	MaxBlockChangeView   uint32
+	EpochRotationPeriod  uint32 //a new governance view starts every period blocks, 0 leaves it to commitDpos
+}
+
+// NextRotationHeight returns the height the next governance view starts at automatically, 0 if the
+// epoch rotation is off
+func (this *Configuration) NextRotationHeight(governanceView *GovernanceView) uint32 {
+	if this.EpochRotationPeriod == 0 {
+		return 0
+	}
+	return governanceView.Height + this.EpochRotationPeriod
 }
 
 func (this *Configuration) Serialization(sink *common.ZeroCopySink) {
@@ -235,6 +247,10 @@ func (this *Configuration) Serialization(sink *common.ZeroCopySink) {
 	sink.WriteUint32(this.HashMsgDelay)
 	sink.WriteUint32(this.PeerHandshakeTimeout)
 	sink.WriteUint32(this.MaxBlockChangeView)
+	//the period is only written once set, so configurations without it keep their encoding
+	if this.EpochRotationPeriod != 0 {
+		sink.WriteUint32(this.EpochRotationPeriod)
+	}
 }
 
 func (this *Configuration) Deserialization(source *common.ZeroCopySource) error {
@@ -254,10 +270,133 @@ func (this *Configuration) Deserialization(source *common.ZeroCopySource) error
 	if eof {
 		return fmt.Errorf("source.NextUint32, deserialize maxBlockChangeView error")
 	}
+	var epochRotationPeriod uint32
+	//configurations stored before epoch rotation have no period
+	if source.Len() != 0 {
+		epochRotationPeriod, eof = source.NextUint32()
+		if eof {
+			return fmt.Errorf("source.NextUint32, deserialize epochRotationPeriod error")
+		}
+	}
 
 	this.BlockMsgDelay = blockMsgDelay
 	this.HashMsgDelay = hashMsgDelay
 	this.PeerHandshakeTimeout = peerHandshakeTimeout
 	this.MaxBlockChangeView = maxBlockChangeView
+	this.EpochRotationPeriod = epochRotationPeriod
+	return nil
+}
+
+type EvidenceKind uint8
+
+const (
+	ProposalEvidence EvidenceKind = iota //two proposals for one height
+	EndorseEvidence                      //two endorsements for one height
+)
+
+func (this EvidenceKind) String() string {
+	switch this {
+	case ProposalEvidence:
+		return "proposal"
+	case EndorseEvidence:
+		return "endorse"
+	}
+	return fmt.Sprintf("unknown(%d)", uint8(this))
+}
+
+type Evidence struct {
+	Kind       EvidenceKind
+	PeerPubkey string         //offender
+	Address    common.Address //the owner of the offender
+	Height     uint32         //poly block height the conflicting messages were made for
+	FirstHash  common.Uint256 //block hash signed by the first message
+	SecondHash common.Uint256
+	Reporter   common.Address
+	PolyHeight uint32 //poly height the evidence was submitted at
+	Blacked    bool   //whether the offender was moved to the black list by this evidence
+}
+
+// ID identifies the misbehaviour independent of the order the messages are submitted in
+func (this *Evidence) ID() common.Uint256 {
+	first, second := this.FirstHash, this.SecondHash
+	if bytes.Compare(first[:], second[:]) > 0 {
+		first, second = second, first
+	}
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteUint8(uint8(this.Kind))
+	sink.WriteString(this.PeerPubkey)
+	sink.WriteUint32(this.Height)
+	sink.WriteHash(first)
+	sink.WriteHash(second)
+	return sha256.Sum256(sink.Bytes())
+}
+
+func (this *Evidence) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint8(uint8(this.Kind))
+	sink.WriteString(this.PeerPubkey)
+	sink.WriteVarBytes(this.Address[:])
+	sink.WriteUint32(this.Height)
+	sink.WriteHash(this.FirstHash)
+	sink.WriteHash(this.SecondHash)
+	sink.WriteVarBytes(this.Reporter[:])
+	sink.WriteUint32(this.PolyHeight)
+	sink.WriteBool(this.Blacked)
+}
+
+func (this *Evidence) Deserialization(source *common.ZeroCopySource) error {
+	kind, eof := source.NextUint8()
+	if eof {
+		return fmt.Errorf("source.NextUint8, deserialize kind error")
+	}
+	peerPubkey, eof := source.NextString()
+	if eof {
+		return fmt.Errorf("source.NextString, deserialize peerPubkey error")
+	}
+	address, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("source.NextVarBytes, deserialize address error")
+	}
+	addr, err := common.AddressParseFromBytes(address)
+	if err != nil {
+		return fmt.Errorf("common.AddressParseFromBytes, deserialize address error: %s", err)
+	}
+	height, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("source.NextUint32, deserialize height error")
+	}
+	firstHash, eof := source.NextHash()
+	if eof {
+		return fmt.Errorf("source.NextHash, deserialize firstHash error")
+	}
+	secondHash, eof := source.NextHash()
+	if eof {
+		return fmt.Errorf("source.NextHash, deserialize secondHash error")
+	}
+	reporter, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("source.NextVarBytes, deserialize reporter error")
+	}
+	reporterAddr, err := common.AddressParseFromBytes(reporter)
+	if err != nil {
+		return fmt.Errorf("common.AddressParseFromBytes, deserialize reporter error: %s", err)
+	}
+	polyHeight, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("source.NextUint32, deserialize polyHeight error")
+	}
+	blacked, eof := source.NextBool()
+	if eof {
+		return fmt.Errorf("source.NextBool, deserialize blacked error")
+	}
+
+	this.Kind = EvidenceKind(kind)
+	this.PeerPubkey = peerPubkey
+	this.Address = addr
+	this.Height = height
+	this.FirstHash = firstHash
+	this.SecondHash = secondHash
+	this.Reporter = reporterAddr
+	this.PolyHeight = polyHeight
+	this.Blacked = blacked
 	return nil
 }
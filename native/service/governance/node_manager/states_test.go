@@ -38,3 +38,32 @@ func Test_Deserialize_GovernanceView(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, *govView, *govView1)
 }
+
+func Test_Deserialize_Configuration(t *testing.T) {
+	config := &Configuration{
+		BlockMsgDelay:        10000,
+		HashMsgDelay:         10000,
+		PeerHandshakeTimeout: 10,
+		MaxBlockChangeView:   60000,
+		EpochRotationPeriod:  2000,
+	}
+	sink := common.NewZeroCopySink(nil)
+	config.Serialization(sink)
+	config1 := new(Configuration)
+	assert.Nil(t, config1.Deserialization(common.NewZeroCopySource(sink.Bytes())))
+	assert.Equal(t, *config, *config1)
+
+	// configurations stored before epoch rotation have the period off
+	config2 := new(Configuration)
+	assert.Nil(t, config2.Deserialization(common.NewZeroCopySource(sink.Bytes()[:16])))
+	assert.Equal(t, uint32(0), config2.EpochRotationPeriod)
+	assert.Equal(t, config.MaxBlockChangeView, config2.MaxBlockChangeView)
+	// and keep their encoding
+	sink = common.NewZeroCopySink(nil)
+	config2.Serialization(sink)
+	assert.Equal(t, uint64(16), sink.Size())
+
+	view := &GovernanceView{View: 3, Height: 500}
+	assert.Equal(t, uint32(2500), config.NextRotationHeight(view))
+	assert.Equal(t, uint32(0), config2.NextRotationHeight(view))
+}
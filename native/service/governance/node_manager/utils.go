@@ -269,6 +269,10 @@ func deleteConsensusSigns(native *native.NativeService, key common.Uint256) {
 }
 
 func CheckConsensusSigns(native *native.NativeService, method string, input []byte, address common.Address) (bool, error) {
+	//a proposal approved in proposal_manager has the consensus signs already
+	if address == utils.ProposalManagerContractAddress && native.CheckWitness(address) {
+		return true, nil
+	}
 	message := append([]byte(method), input...)
 	key := sha256.Sum256(message)
 	consensusSigns, err := getConsensusSigns(native, key)
@@ -0,0 +1,278 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proposal_manager
+
+import (
+	"fmt"
+
+	"github.com/polynetwork/poly/common"
+)
+
+type ProposalStatus uint8
+
+const (
+	PendingStatus ProposalStatus = iota
+	ApprovedStatus
+	ExecutedStatus
+	CancelledStatus
+	ExpiredStatus
+)
+
+func (this ProposalStatus) String() string {
+	switch this {
+	case PendingStatus:
+		return "pending"
+	case ApprovedStatus:
+		return "approved"
+	case ExecutedStatus:
+		return "executed"
+	case CancelledStatus:
+		return "cancelled"
+	case ExpiredStatus:
+		return "expired"
+	}
+	return fmt.Sprintf("unknown(%d)", uint8(this))
+}
+
+// ProposalConfig holds the heights a proposal goes through: it is voted on for VotingPeriod blocks, once
+// approved it waits TimelockDelay blocks and then can be executed for ExecutionPeriod blocks.
+type ProposalConfig struct {
+	VotingPeriod    uint32
+	TimelockDelay   uint32
+	ExecutionPeriod uint32
+}
+
+func (this *ProposalConfig) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint32(this.VotingPeriod)
+	sink.WriteUint32(this.TimelockDelay)
+	sink.WriteUint32(this.ExecutionPeriod)
+}
+
+func (this *ProposalConfig) Deserialization(source *common.ZeroCopySource) error {
+	votingPeriod, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("ProposalConfig deserialize votingPeriod error")
+	}
+	timelockDelay, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("ProposalConfig deserialize timelockDelay error")
+	}
+	executionPeriod, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("ProposalConfig deserialize executionPeriod error")
+	}
+	this.VotingPeriod = votingPeriod
+	this.TimelockDelay = timelockDelay
+	this.ExecutionPeriod = executionPeriod
+	return nil
+}
+
+// Proposal is a native call the consensus nodes vote on. ExpireHeight is the end of the voting
+// while the proposal is pending and the end of the execution once it is approved.
+type Proposal struct {
+	ID               uint64
+	Proposer         common.Address
+	Target           common.Address
+	Method           string
+	Args             []byte
+	Description      string
+	Status           ProposalStatus
+	Voters           []common.Address
+	CreateHeight     uint32
+	ExecutableHeight uint32
+	ExpireHeight     uint32
+}
+
+// StatusAt returns the status of the proposal at height, taking expiry into account
+func (this *Proposal) StatusAt(height uint32) ProposalStatus {
+	if (this.Status == PendingStatus || this.Status == ApprovedStatus) && height >= this.ExpireHeight {
+		return ExpiredStatus
+	}
+	return this.Status
+}
+
+func (this *Proposal) hasVoted(address common.Address) bool {
+	for _, v := range this.Voters {
+		if v == address {
+			return true
+		}
+	}
+	return false
+}
+
+func (this *Proposal) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarUint(this.ID)
+	sink.WriteVarBytes(this.Proposer[:])
+	sink.WriteVarBytes(this.Target[:])
+	sink.WriteString(this.Method)
+	sink.WriteVarBytes(this.Args)
+	sink.WriteString(this.Description)
+	sink.WriteUint8(uint8(this.Status))
+	sink.WriteVarUint(uint64(len(this.Voters)))
+	for _, v := range this.Voters {
+		sink.WriteVarBytes(v[:])
+	}
+	sink.WriteUint32(this.CreateHeight)
+	sink.WriteUint32(this.ExecutableHeight)
+	sink.WriteUint32(this.ExpireHeight)
+}
+
+func (this *Proposal) Deserialization(source *common.ZeroCopySource) error {
+	id, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("Proposal deserialize id error")
+	}
+	proposer, err := nextAddress(source)
+	if err != nil {
+		return fmt.Errorf("Proposal deserialize proposer error: %v", err)
+	}
+	target, err := nextAddress(source)
+	if err != nil {
+		return fmt.Errorf("Proposal deserialize target error: %v", err)
+	}
+	method, eof := source.NextString()
+	if eof {
+		return fmt.Errorf("Proposal deserialize method error")
+	}
+	args, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("Proposal deserialize args error")
+	}
+	description, eof := source.NextString()
+	if eof {
+		return fmt.Errorf("Proposal deserialize description error")
+	}
+	status, eof := source.NextUint8()
+	if eof {
+		return fmt.Errorf("Proposal deserialize status error")
+	}
+	n, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("Proposal deserialize voters length error")
+	}
+	voters := make([]common.Address, 0)
+	for i := uint64(0); i < n; i++ {
+		voter, err := nextAddress(source)
+		if err != nil {
+			return fmt.Errorf("Proposal deserialize voter error: %v", err)
+		}
+		voters = append(voters, voter)
+	}
+	createHeight, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("Proposal deserialize createHeight error")
+	}
+	executableHeight, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("Proposal deserialize executableHeight error")
+	}
+	expireHeight, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("Proposal deserialize expireHeight error")
+	}
+	this.ID = id
+	this.Proposer = proposer
+	this.Target = target
+	this.Method = method
+	this.Args = args
+	this.Description = description
+	this.Status = ProposalStatus(status)
+	this.Voters = voters
+	this.CreateHeight = createHeight
+	this.ExecutableHeight = executableHeight
+	this.ExpireHeight = expireHeight
+	return nil
+}
+
+type SubmitProposalParam struct {
+	Target      common.Address
+	Method      string
+	Args        []byte
+	Description string
+	Address     common.Address
+}
+
+func (this *SubmitProposalParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarBytes(this.Target[:])
+	sink.WriteString(this.Method)
+	sink.WriteVarBytes(this.Args)
+	sink.WriteString(this.Description)
+	sink.WriteVarBytes(this.Address[:])
+}
+
+func (this *SubmitProposalParam) Deserialization(source *common.ZeroCopySource) error {
+	target, err := nextAddress(source)
+	if err != nil {
+		return fmt.Errorf("SubmitProposalParam deserialize target error: %v", err)
+	}
+	method, eof := source.NextString()
+	if eof {
+		return fmt.Errorf("SubmitProposalParam deserialize method error")
+	}
+	args, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("SubmitProposalParam deserialize args error")
+	}
+	description, eof := source.NextString()
+	if eof {
+		return fmt.Errorf("SubmitProposalParam deserialize description error")
+	}
+	address, err := nextAddress(source)
+	if err != nil {
+		return fmt.Errorf("SubmitProposalParam deserialize address error: %v", err)
+	}
+	this.Target = target
+	this.Method = method
+	this.Args = args
+	this.Description = description
+	this.Address = address
+	return nil
+}
+
+// ProposalParam is the param of vote, execute and cancel
+type ProposalParam struct {
+	ID      uint64
+	Address common.Address
+}
+
+func (this *ProposalParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarUint(this.ID)
+	sink.WriteVarBytes(this.Address[:])
+}
+
+func (this *ProposalParam) Deserialization(source *common.ZeroCopySource) error {
+	id, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("ProposalParam deserialize id error")
+	}
+	address, err := nextAddress(source)
+	if err != nil {
+		return fmt.Errorf("ProposalParam deserialize address error: %v", err)
+	}
+	this.ID = id
+	this.Address = address
+	return nil
+}
+
+func nextAddress(source *common.ZeroCopySource) (common.Address, error) {
+	raw, eof := source.NextVarBytes()
+	if eof {
+		return common.ADDRESS_EMPTY, fmt.Errorf("source.NextVarBytes error")
+	}
+	return common.AddressParseFromBytes(raw)
+}
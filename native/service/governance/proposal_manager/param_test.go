@@ -0,0 +1,80 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proposal_manager
+
+import (
+	"testing"
+
+	"github.com/polynetwork/poly/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProposal(t *testing.T) {
+	proposal := &Proposal{
+		ID:               3,
+		Proposer:         common.Address{1},
+		Target:           common.Address{2},
+		Method:           "updateConfig",
+		Args:             []byte{1, 2, 3},
+		Description:      "raise the block msg delay",
+		Status:           ApprovedStatus,
+		Voters:           []common.Address{{1}, {3}},
+		CreateHeight:     10,
+		ExecutableHeight: 30,
+		ExpireHeight:     50,
+	}
+	sink := common.NewZeroCopySink(nil)
+	proposal.Serialization(sink)
+	proposal1 := new(Proposal)
+	assert.Nil(t, proposal1.Deserialization(common.NewZeroCopySource(sink.Bytes())))
+	assert.Equal(t, proposal, proposal1)
+
+	assert.Equal(t, ApprovedStatus, proposal.StatusAt(49))
+	assert.Equal(t, ExpiredStatus, proposal.StatusAt(50))
+	proposal.Status = ExecutedStatus
+	assert.Equal(t, ExecutedStatus, proposal.StatusAt(50))
+}
+
+func TestProposalParams(t *testing.T) {
+	submit := &SubmitProposalParam{
+		Target:      common.Address{2},
+		Method:      "approveCandidate",
+		Args:        []byte{4},
+		Description: "add a consensus node",
+		Address:     common.Address{1},
+	}
+	sink := common.NewZeroCopySink(nil)
+	submit.Serialization(sink)
+	submit1 := new(SubmitProposalParam)
+	assert.Nil(t, submit1.Deserialization(common.NewZeroCopySource(sink.Bytes())))
+	assert.Equal(t, submit, submit1)
+
+	param := &ProposalParam{ID: 7, Address: common.Address{1}}
+	sink = common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	param1 := new(ProposalParam)
+	assert.Nil(t, param1.Deserialization(common.NewZeroCopySource(sink.Bytes())))
+	assert.Equal(t, param, param1)
+
+	config := &ProposalConfig{VotingPeriod: 100, TimelockDelay: 0, ExecutionPeriod: 200}
+	sink = common.NewZeroCopySink(nil)
+	config.Serialization(sink)
+	config1 := new(ProposalConfig)
+	assert.Nil(t, config1.Deserialization(common.NewZeroCopySource(sink.Bytes())))
+	assert.Equal(t, config, config1)
+}
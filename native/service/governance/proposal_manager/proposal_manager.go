@@ -0,0 +1,302 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proposal_manager
+
+import (
+	"fmt"
+
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/event"
+	"github.com/polynetwork/poly/native/service/utils"
+)
+
+const (
+	//function name
+	SUBMIT_PROPOSAL        = "submitProposal"
+	VOTE_PROPOSAL          = "voteProposal"
+	EXECUTE_PROPOSAL       = "executeProposal"
+	CANCEL_PROPOSAL        = "cancelProposal"
+	GET_PROPOSAL           = "getProposal"
+	GET_PROPOSAL_CONFIG    = "getProposalConfig"
+	UPDATE_PROPOSAL_CONFIG = "updateProposalConfig"
+
+	//key prefix
+	PROPOSAL        = "proposal"
+	PROPOSAL_COUNT  = "proposalCount"
+	PROPOSAL_CONFIG = "proposalConfig"
+
+	//const
+	DEFAULT_VOTING_PERIOD    = 120000
+	DEFAULT_TIMELOCK_DELAY   = 20000
+	DEFAULT_EXECUTION_PERIOD = 120000
+)
+
+// Register methods of proposal_manager contract
+func RegisterProposalManagerContract(native *native.NativeService) {
+	native.Register(SUBMIT_PROPOSAL, SubmitProposal)
+	native.Register(VOTE_PROPOSAL, VoteProposal)
+	native.Register(EXECUTE_PROPOSAL, ExecuteProposal)
+	native.Register(CANCEL_PROPOSAL, CancelProposal)
+	native.Register(GET_PROPOSAL, GetProposal)
+	native.Register(GET_PROPOSAL_CONFIG, GetProposalConfig)
+	native.Register(UPDATE_PROPOSAL_CONFIG, UpdateProposalConfig)
+}
+
+// SubmitProposal lets a consensus node propose a native call, the proposer votes for it.
+func SubmitProposal(native *native.NativeService) ([]byte, error) {
+	params := new(SubmitProposalParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SubmitProposal, contract params deserialize error: %v", err)
+	}
+
+	//check witness
+	err := utils.ValidateOwner(native, params.Address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SubmitProposal, checkWitness error: %v", err)
+	}
+	consensus, err := getConsensusAddresses(native)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SubmitProposal, %v", err)
+	}
+	if !consensus[params.Address] {
+		return utils.BYTE_FALSE, fmt.Errorf("SubmitProposal, %s is not a consensus node", params.Address.ToBase58())
+	}
+	// proposals may only change the proposal rules of this contract
+	if params.Target == utils.ProposalManagerContractAddress &&
+		params.Method != UPDATE_PROPOSAL_CONFIG && params.Method != CANCEL_PROPOSAL {
+		return utils.BYTE_FALSE, fmt.Errorf("SubmitProposal, method %s of proposal manager can not be proposed", params.Method)
+	}
+
+	config, err := getProposalConfig(native)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SubmitProposal, %v", err)
+	}
+	id, err := getProposalCount(native)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SubmitProposal, %v", err)
+	}
+	proposal := &Proposal{
+		ID:           id,
+		Proposer:     params.Address,
+		Target:       params.Target,
+		Method:       params.Method,
+		Args:         params.Args,
+		Description:  params.Description,
+		Status:       PendingStatus,
+		Voters:       []common.Address{params.Address},
+		CreateHeight: native.GetHeight(),
+		ExpireHeight: native.GetHeight() + config.VotingPeriod,
+	}
+	if err := tally(native, proposal, config); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SubmitProposal, %v", err)
+	}
+	putProposal(native, proposal)
+	putProposalCount(native, id+1)
+	native.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.ProposalManagerContractAddress,
+			States:          []interface{}{SUBMIT_PROPOSAL, id, params.Address.ToBase58(), params.Target.ToHexString(), params.Method},
+		})
+	return utils.BYTE_TRUE, nil
+}
+
+// VoteProposal adds the vote of a consensus node to a pending proposal.
+func VoteProposal(native *native.NativeService) ([]byte, error) {
+	params := new(ProposalParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("VoteProposal, contract params deserialize error: %v", err)
+	}
+
+	//check witness
+	err := utils.ValidateOwner(native, params.Address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("VoteProposal, checkWitness error: %v", err)
+	}
+	proposal, err := getProposal(native, params.ID)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("VoteProposal, %v", err)
+	}
+	if proposal == nil {
+		return utils.BYTE_FALSE, fmt.Errorf("VoteProposal, proposal %d does not exist", params.ID)
+	}
+	if status := proposal.StatusAt(native.GetHeight()); status != PendingStatus {
+		return utils.BYTE_FALSE, fmt.Errorf("VoteProposal, proposal %d is %s", params.ID, status)
+	}
+	consensus, err := getConsensusAddresses(native)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("VoteProposal, %v", err)
+	}
+	if !consensus[params.Address] {
+		return utils.BYTE_FALSE, fmt.Errorf("VoteProposal, %s is not a consensus node", params.Address.ToBase58())
+	}
+	if proposal.hasVoted(params.Address) {
+		return utils.BYTE_FALSE, fmt.Errorf("VoteProposal, %s has voted proposal %d", params.Address.ToBase58(), params.ID)
+	}
+
+	config, err := getProposalConfig(native)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("VoteProposal, %v", err)
+	}
+	proposal.Voters = append(proposal.Voters, params.Address)
+	if err := tally(native, proposal, config); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("VoteProposal, %v", err)
+	}
+	putProposal(native, proposal)
+	native.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.ProposalManagerContractAddress,
+			States:          []interface{}{VOTE_PROPOSAL, params.ID, params.Address.ToBase58(), proposal.Status.String()},
+		})
+	return utils.BYTE_TRUE, nil
+}
+
+// ExecuteProposal runs the native call of an approved proposal once its timelock is over, the
+// target sees proposal manager as the caller. A failed call fails the tx and the proposal can be
+// executed again until it expires.
+func ExecuteProposal(native *native.NativeService) ([]byte, error) {
+	params := new(ProposalParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("ExecuteProposal, contract params deserialize error: %v", err)
+	}
+
+	//check witness
+	err := utils.ValidateOwner(native, params.Address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("ExecuteProposal, checkWitness error: %v", err)
+	}
+	proposal, err := getProposal(native, params.ID)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("ExecuteProposal, %v", err)
+	}
+	if proposal == nil {
+		return utils.BYTE_FALSE, fmt.Errorf("ExecuteProposal, proposal %d does not exist", params.ID)
+	}
+	if status := proposal.StatusAt(native.GetHeight()); status != ApprovedStatus {
+		return utils.BYTE_FALSE, fmt.Errorf("ExecuteProposal, proposal %d is %s", params.ID, status)
+	}
+	if native.GetHeight() < proposal.ExecutableHeight {
+		return utils.BYTE_FALSE, fmt.Errorf("ExecuteProposal, proposal %d is locked until height %d",
+			params.ID, proposal.ExecutableHeight)
+	}
+
+	proposal.Status = ExecutedStatus
+	putProposal(native, proposal)
+	if _, err := native.NativeCall(proposal.Target, proposal.Method, proposal.Args); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("ExecuteProposal, execute proposal %d error: %v", params.ID, err)
+	}
+	native.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.ProposalManagerContractAddress,
+			States:          []interface{}{EXECUTE_PROPOSAL, params.ID},
+		})
+	return utils.BYTE_TRUE, nil
+}
+
+// CancelProposal drops a proposal which is not executed yet, by its proposer or by another proposal.
+func CancelProposal(native *native.NativeService) ([]byte, error) {
+	params := new(ProposalParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("CancelProposal, contract params deserialize error: %v", err)
+	}
+
+	//check witness
+	err := utils.ValidateOwner(native, params.Address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("CancelProposal, checkWitness error: %v", err)
+	}
+	proposal, err := getProposal(native, params.ID)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("CancelProposal, %v", err)
+	}
+	if proposal == nil {
+		return utils.BYTE_FALSE, fmt.Errorf("CancelProposal, proposal %d does not exist", params.ID)
+	}
+	if params.Address != proposal.Proposer && params.Address != utils.ProposalManagerContractAddress {
+		return utils.BYTE_FALSE, fmt.Errorf("CancelProposal, %s is not the proposer of proposal %d",
+			params.Address.ToBase58(), params.ID)
+	}
+	status := proposal.StatusAt(native.GetHeight())
+	if status != PendingStatus && status != ApprovedStatus {
+		return utils.BYTE_FALSE, fmt.Errorf("CancelProposal, proposal %d is %s", params.ID, status)
+	}
+
+	proposal.Status = CancelledStatus
+	putProposal(native, proposal)
+	native.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.ProposalManagerContractAddress,
+			States:          []interface{}{CANCEL_PROPOSAL, params.ID, params.Address.ToBase58()},
+		})
+	return utils.BYTE_TRUE, nil
+}
+
+// GetProposal returns a proposal with its status at the current height.
+func GetProposal(native *native.NativeService) ([]byte, error) {
+	id, eof := common.NewZeroCopySource(native.GetInput()).NextVarUint()
+	if eof {
+		return utils.BYTE_FALSE, fmt.Errorf("GetProposal, contract params deserialize error")
+	}
+	proposal, err := getProposal(native, id)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetProposal, %v", err)
+	}
+	if proposal == nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetProposal, proposal %d does not exist", id)
+	}
+	proposal.Status = proposal.StatusAt(native.GetHeight())
+	sink := common.NewZeroCopySink(nil)
+	proposal.Serialization(sink)
+	return sink.Bytes(), nil
+}
+
+func GetProposalConfig(native *native.NativeService) ([]byte, error) {
+	config, err := getProposalConfig(native)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetProposalConfig, %v", err)
+	}
+	sink := common.NewZeroCopySink(nil)
+	config.Serialization(sink)
+	return sink.Bytes(), nil
+}
+
+// UpdateProposalConfig changes the voting period, timelock delay and execution period of the
+// proposals submitted afterwards, it can only be called by an approved proposal.
+func UpdateProposalConfig(native *native.NativeService) ([]byte, error) {
+	config := new(ProposalConfig)
+	if err := config.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("UpdateProposalConfig, contract params deserialize error: %v", err)
+	}
+
+	//check witness
+	err := utils.ValidateOwner(native, utils.ProposalManagerContractAddress)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("UpdateProposalConfig, checkWitness error: %v", err)
+	}
+	if config.VotingPeriod == 0 || config.ExecutionPeriod == 0 {
+		return utils.BYTE_FALSE, fmt.Errorf("UpdateProposalConfig, voting period and execution period must > 0")
+	}
+
+	putProposalConfig(native, config)
+	native.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.ProposalManagerContractAddress,
+			States:          []interface{}{UPDATE_PROPOSAL_CONFIG, config.VotingPeriod, config.TimelockDelay, config.ExecutionPeriod},
+		})
+	return utils.BYTE_TRUE, nil
+}
@@ -0,0 +1,201 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proposal_manager
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/polynetwork/poly/account"
+	"github.com/polynetwork/poly/common"
+	vconfig "github.com/polynetwork/poly/consensus/vbft/config"
+	cstates "github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/core/store/leveldbstore"
+	"github.com/polynetwork/poly/core/store/overlaydb"
+	"github.com/polynetwork/poly/core/types"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/governance/node_manager"
+	"github.com/polynetwork/poly/native/service/utils"
+	"github.com/polynetwork/poly/native/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	native.Contracts[utils.NodeManagerContractAddress] = node_manager.RegisterNodeManagerContract
+	native.Contracts[utils.ProposalManagerContractAddress] = RegisterProposalManagerContract
+}
+
+var conAccts = func() []*account.Account {
+	accts := make([]*account.Account, 0)
+	for i := 0; i < 4; i++ {
+		accts = append(accts, account.NewAccount(strconv.FormatUint(uint64(i), 10)))
+	}
+	return accts
+}()
+
+func putPeerMapPoolAndView(db *storage.CacheDB) {
+	peerPoolMap := new(node_manager.PeerPoolMap)
+	peerPoolMap.PeerPoolMap = make(map[string]*node_manager.PeerPoolItem)
+	for i, conAcct := range conAccts {
+		pkStr := vconfig.PubkeyID(conAcct.PublicKey)
+		peerPoolMap.PeerPoolMap[pkStr] = &node_manager.PeerPoolItem{
+			Index:      uint32(i),
+			PeerPubkey: pkStr,
+			Address:    conAcct.Address,
+			Status:     node_manager.ConsensusStatus,
+		}
+	}
+	sink := common.NewZeroCopySink(nil)
+	peerPoolMap.Serialization(sink)
+	db.Put(utils.ConcatKey(utils.NodeManagerContractAddress, []byte(node_manager.PEER_POOL), utils.GetUint32Bytes(0)),
+		cstates.GenRawStorageItem(sink.Bytes()))
+
+	govView := node_manager.GovernanceView{View: 0, Height: 10, TxHash: common.UINT256_EMPTY}
+	sink = common.NewZeroCopySink(nil)
+	govView.Serialization(sink)
+	db.Put(utils.ConcatKey(utils.NodeManagerContractAddress, []byte(node_manager.GOVERNANCE_VIEW)),
+		cstates.GenRawStorageItem(sink.Bytes()))
+}
+
+func newDB() *storage.CacheDB {
+	store, _ := leveldbstore.NewMemLevelDBStore()
+	db := storage.NewCacheDB(overlaydb.NewOverlayDB(store))
+	putPeerMapPoolAndView(db)
+	return db
+}
+
+// invoke calls a method of proposal manager at height the way a tx does
+func invoke(db *storage.CacheDB, height uint32, signer common.Address, method string, args []byte) ([]byte, error) {
+	ns, _ := native.NewNativeService(db, &types.Transaction{SignedAddr: []common.Address{signer}}, 0, height,
+		common.Uint256{0}, 0, nil, false)
+	res, err := ns.NativeCall(utils.ProposalManagerContractAddress, method, args)
+	if err != nil {
+		return nil, err
+	}
+	return res.([]byte), nil
+}
+
+func submit(db *storage.CacheDB, height uint32, proposer common.Address, target common.Address, method string,
+	args []byte) error {
+	param := &SubmitProposalParam{Target: target, Method: method, Args: args, Address: proposer}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	_, err := invoke(db, height, proposer, SUBMIT_PROPOSAL, sink.Bytes())
+	return err
+}
+
+func call(db *storage.CacheDB, height uint32, method string, id uint64, address common.Address) error {
+	param := &ProposalParam{ID: id, Address: address}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	_, err := invoke(db, height, address, method, sink.Bytes())
+	return err
+}
+
+func proposalAt(t *testing.T, db *storage.CacheDB, height uint32, id uint64) *Proposal {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteVarUint(id)
+	res, err := invoke(db, height, common.ADDRESS_EMPTY, GET_PROPOSAL, sink.Bytes())
+	assert.Nil(t, err)
+	proposal := new(Proposal)
+	assert.Nil(t, proposal.Deserialization(common.NewZeroCopySource(res)))
+	return proposal
+}
+
+func TestExecuteProposal(t *testing.T) {
+	db := newDB()
+	config := &node_manager.Configuration{
+		BlockMsgDelay:        10000,
+		HashMsgDelay:         10000,
+		PeerHandshakeTimeout: 10,
+		MaxBlockChangeView:   60000,
+	}
+	sink := common.NewZeroCopySink(nil)
+	(&node_manager.UpdateConfigParam{Configuration: config}).Serialization(sink)
+
+	// only consensus nodes propose
+	assert.NotNil(t, submit(db, 10, common.Address{1}, utils.NodeManagerContractAddress, node_manager.UPDATE_CONFIG, sink.Bytes()))
+	// and the voting methods of proposal manager can not be proposed
+	assert.NotNil(t, submit(db, 10, conAccts[0].Address, utils.ProposalManagerContractAddress, EXECUTE_PROPOSAL, nil))
+	assert.Nil(t, submit(db, 10, conAccts[0].Address, utils.NodeManagerContractAddress, node_manager.UPDATE_CONFIG, sink.Bytes()))
+
+	assert.Nil(t, call(db, 11, VOTE_PROPOSAL, 0, conAccts[1].Address))
+	assert.NotNil(t, call(db, 11, VOTE_PROPOSAL, 0, conAccts[1].Address))
+	assert.NotNil(t, call(db, 11, VOTE_PROPOSAL, 0, common.Address{1}))
+	assert.Equal(t, PendingStatus, proposalAt(t, db, 11, 0).Status)
+	assert.Nil(t, call(db, 12, VOTE_PROPOSAL, 0, conAccts[2].Address))
+	proposal := proposalAt(t, db, 12, 0)
+	assert.Equal(t, ApprovedStatus, proposal.Status)
+	assert.Equal(t, uint32(12+DEFAULT_TIMELOCK_DELAY), proposal.ExecutableHeight)
+	assert.Equal(t, uint32(12+DEFAULT_TIMELOCK_DELAY+DEFAULT_EXECUTION_PERIOD), proposal.ExpireHeight)
+	// no more votes once approved
+	assert.NotNil(t, call(db, 13, VOTE_PROPOSAL, 0, conAccts[3].Address))
+
+	// the timelock holds the execution
+	assert.NotNil(t, call(db, proposal.ExecutableHeight-1, EXECUTE_PROPOSAL, 0, common.Address{1}))
+	assert.Nil(t, call(db, proposal.ExecutableHeight, EXECUTE_PROPOSAL, 0, common.Address{1}))
+	assert.NotNil(t, call(db, proposal.ExecutableHeight, EXECUTE_PROPOSAL, 0, common.Address{1}))
+	assert.Equal(t, ExecutedStatus, proposalAt(t, db, proposal.ExecutableHeight, 0).Status)
+
+	ns, _ := native.NewNativeService(db, &types.Transaction{}, 0, 0, common.Uint256{0}, 0, nil, false)
+	stored, err := node_manager.GetConfig(ns)
+	assert.Nil(t, err)
+	assert.Equal(t, config, stored)
+}
+
+func TestCancelAndExpireProposal(t *testing.T) {
+	db := newDB()
+	assert.Nil(t, submit(db, 10, conAccts[0].Address, utils.NodeManagerContractAddress, node_manager.COMMIT_DPOS, nil))
+	assert.NotNil(t, call(db, 11, CANCEL_PROPOSAL, 0, conAccts[1].Address))
+	assert.Nil(t, call(db, 11, CANCEL_PROPOSAL, 0, conAccts[0].Address))
+	assert.NotNil(t, call(db, 12, VOTE_PROPOSAL, 0, conAccts[1].Address))
+	assert.Equal(t, CancelledStatus, proposalAt(t, db, 12, 0).Status)
+
+	assert.Nil(t, submit(db, 10, conAccts[0].Address, utils.NodeManagerContractAddress, node_manager.COMMIT_DPOS, nil))
+	assert.Equal(t, PendingStatus, proposalAt(t, db, 10+DEFAULT_VOTING_PERIOD-1, 1).Status)
+	assert.Equal(t, ExpiredStatus, proposalAt(t, db, 10+DEFAULT_VOTING_PERIOD, 1).Status)
+	assert.NotNil(t, call(db, 10+DEFAULT_VOTING_PERIOD, VOTE_PROPOSAL, 1, conAccts[1].Address))
+	assert.NotNil(t, call(db, 10+DEFAULT_VOTING_PERIOD, CANCEL_PROPOSAL, 1, conAccts[0].Address))
+}
+
+func TestUpdateProposalConfig(t *testing.T) {
+	db := newDB()
+	config := &ProposalConfig{VotingPeriod: 100, TimelockDelay: 5, ExecutionPeriod: 100}
+	sink := common.NewZeroCopySink(nil)
+	config.Serialization(sink)
+
+	// the config is only changed by proposals
+	_, err := invoke(db, 10, conAccts[0].Address, UPDATE_PROPOSAL_CONFIG, sink.Bytes())
+	assert.NotNil(t, err)
+
+	assert.Nil(t, submit(db, 10, conAccts[0].Address, utils.ProposalManagerContractAddress, UPDATE_PROPOSAL_CONFIG, sink.Bytes()))
+	for _, acct := range conAccts[1:3] {
+		assert.Nil(t, call(db, 10, VOTE_PROPOSAL, 0, acct.Address))
+	}
+	assert.Nil(t, call(db, 10+DEFAULT_TIMELOCK_DELAY, EXECUTE_PROPOSAL, 0, conAccts[0].Address))
+
+	res, err := invoke(db, 10+DEFAULT_TIMELOCK_DELAY, common.ADDRESS_EMPTY, GET_PROPOSAL_CONFIG, nil)
+	assert.Nil(t, err)
+	stored := new(ProposalConfig)
+	assert.Nil(t, stored.Deserialization(common.NewZeroCopySource(res)))
+	assert.Equal(t, config, stored)
+
+	// new proposals follow the new config
+	assert.Nil(t, submit(db, 20, conAccts[0].Address, utils.NodeManagerContractAddress, node_manager.COMMIT_DPOS, nil))
+	assert.Equal(t, uint32(120), proposalAt(t, db, 20, 1).ExpireHeight)
+}
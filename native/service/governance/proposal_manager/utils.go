@@ -0,0 +1,161 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package proposal_manager
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/polynetwork/poly/common"
+	cstates "github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/core/types"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/governance/node_manager"
+	"github.com/polynetwork/poly/native/service/utils"
+)
+
+// getConsensusAddresses returns the addresses of the consensus peers of the current view, the same
+// addresses node_manager.CheckConsensusSigns counts.
+func getConsensusAddresses(native *native.NativeService) (map[common.Address]bool, error) {
+	view, err := node_manager.GetView(native)
+	if err != nil {
+		return nil, fmt.Errorf("getConsensusAddresses, GetView error: %v", err)
+	}
+	peerPoolMap, err := node_manager.GetPeerPoolMap(native, view)
+	if err != nil {
+		return nil, fmt.Errorf("getConsensusAddresses, GetPeerPoolMap error: %v", err)
+	}
+	addresses := make(map[common.Address]bool)
+	for key, v := range peerPoolMap.PeerPoolMap {
+		if v.Status != node_manager.ConsensusStatus {
+			continue
+		}
+		k, err := hex.DecodeString(key)
+		if err != nil {
+			return nil, fmt.Errorf("getConsensusAddresses, hex.DecodeString public key error: %v", err)
+		}
+		publicKey, err := keypair.DeserializePublicKey(k)
+		if err != nil {
+			return nil, fmt.Errorf("getConsensusAddresses, keypair.DeserializePublicKey error: %v", err)
+		}
+		addresses[types.AddressFromPubKey(publicKey)] = true
+	}
+	return addresses, nil
+}
+
+// tally approves a pending proposal once the votes of the current consensus peers reach the
+// threshold of node_manager.CheckConsensusSigns, and starts its timelock.
+func tally(native *native.NativeService, proposal *Proposal, config *ProposalConfig) error {
+	consensus, err := getConsensusAddresses(native)
+	if err != nil {
+		return fmt.Errorf("tally, %v", err)
+	}
+	num := 0
+	for _, v := range proposal.Voters {
+		if consensus[v] {
+			num = num + 1
+		}
+	}
+	if num >= (2*len(consensus)+2)/3 {
+		proposal.Status = ApprovedStatus
+		proposal.ExecutableHeight = native.GetHeight() + config.TimelockDelay
+		proposal.ExpireHeight = proposal.ExecutableHeight + config.ExecutionPeriod
+	}
+	return nil
+}
+
+func getProposalConfig(native *native.NativeService) (*ProposalConfig, error) {
+	contract := utils.ProposalManagerContractAddress
+	store, err := native.GetCacheDB().Get(utils.ConcatKey(contract, []byte(PROPOSAL_CONFIG)))
+	if err != nil {
+		return nil, fmt.Errorf("getProposalConfig, get config error: %v", err)
+	}
+	config := &ProposalConfig{
+		VotingPeriod:    DEFAULT_VOTING_PERIOD,
+		TimelockDelay:   DEFAULT_TIMELOCK_DELAY,
+		ExecutionPeriod: DEFAULT_EXECUTION_PERIOD,
+	}
+	if store == nil {
+		return config, nil
+	}
+	value, err := cstates.GetValueFromRawStorageItem(store)
+	if err != nil {
+		return nil, fmt.Errorf("getProposalConfig, deserialize from raw storage item err:%v", err)
+	}
+	if err := config.Deserialization(common.NewZeroCopySource(value)); err != nil {
+		return nil, fmt.Errorf("getProposalConfig, deserialize config error: %v", err)
+	}
+	return config, nil
+}
+
+func putProposalConfig(native *native.NativeService, config *ProposalConfig) {
+	contract := utils.ProposalManagerContractAddress
+	sink := common.NewZeroCopySink(nil)
+	config.Serialization(sink)
+	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(PROPOSAL_CONFIG)), cstates.GenRawStorageItem(sink.Bytes()))
+}
+
+func getProposal(native *native.NativeService, id uint64) (*Proposal, error) {
+	contract := utils.ProposalManagerContractAddress
+	store, err := native.GetCacheDB().Get(utils.ConcatKey(contract, []byte(PROPOSAL), utils.GetUint64Bytes(id)))
+	if err != nil {
+		return nil, fmt.Errorf("getProposal, get proposal error: %v", err)
+	}
+	if store == nil {
+		return nil, nil
+	}
+	value, err := cstates.GetValueFromRawStorageItem(store)
+	if err != nil {
+		return nil, fmt.Errorf("getProposal, deserialize from raw storage item err:%v", err)
+	}
+	proposal := new(Proposal)
+	if err := proposal.Deserialization(common.NewZeroCopySource(value)); err != nil {
+		return nil, fmt.Errorf("getProposal, deserialize proposal error: %v", err)
+	}
+	return proposal, nil
+}
+
+func putProposal(native *native.NativeService, proposal *Proposal) {
+	contract := utils.ProposalManagerContractAddress
+	sink := common.NewZeroCopySink(nil)
+	proposal.Serialization(sink)
+	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(PROPOSAL), utils.GetUint64Bytes(proposal.ID)),
+		cstates.GenRawStorageItem(sink.Bytes()))
+}
+
+func getProposalCount(native *native.NativeService) (uint64, error) {
+	contract := utils.ProposalManagerContractAddress
+	store, err := native.GetCacheDB().Get(utils.ConcatKey(contract, []byte(PROPOSAL_COUNT)))
+	if err != nil {
+		return 0, fmt.Errorf("getProposalCount, get proposal count error: %v", err)
+	}
+	if store == nil {
+		return 0, nil
+	}
+	value, err := cstates.GetValueFromRawStorageItem(store)
+	if err != nil {
+		return 0, fmt.Errorf("getProposalCount, deserialize from raw storage item err:%v", err)
+	}
+	return utils.GetBytesUint64(value), nil
+}
+
+func putProposalCount(native *native.NativeService, count uint64) {
+	contract := utils.ProposalManagerContractAddress
+	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(PROPOSAL_COUNT)), cstates.GenRawStorageItem(utils.GetUint64Bytes(count)))
+}
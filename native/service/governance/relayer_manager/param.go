@@ -22,9 +22,13 @@ import (
 	"github.com/polynetwork/poly/common"
 )
 
+// RelayerListParam registers or removes relayers, the relayers registered with a ChainIDs or a Methods
+// list are scoped to them.
 type RelayerListParam struct {
 	AddressList []common.Address
 	Address     common.Address
+	ChainIDs    []uint64
+	Methods     []string
 }
 
 func (this *RelayerListParam) Serialization(sink *common.ZeroCopySink) {
@@ -33,6 +37,12 @@ func (this *RelayerListParam) Serialization(sink *common.ZeroCopySink) {
 		sink.WriteVarBytes(v[:])
 	}
 	sink.WriteVarBytes(this.Address[:])
+	//the scope is only written if any, so unscoped applies keep their encoding
+	if len(this.ChainIDs) == 0 && len(this.Methods) == 0 {
+		return
+	}
+	scope := &RelayerScope{ChainIDs: this.ChainIDs, Methods: this.Methods}
+	scope.Serialization(sink)
 }
 
 func (this *RelayerListParam) Deserialization(source *common.ZeroCopySource) error {
@@ -61,8 +71,17 @@ func (this *RelayerListParam) Deserialization(source *common.ZeroCopySource) err
 	if err != nil {
 		return fmt.Errorf("common.AddressParseFromBytes, deserialize address error: %s", err)
 	}
+	// applies stored before relayers were scoped have no scope
+	scope := new(RelayerScope)
+	if source.Len() != 0 {
+		if err := scope.Deserialization(source); err != nil {
+			return err
+		}
+	}
 	this.AddressList = addressList
 	this.Address = addr
+	this.ChainIDs = scope.ChainIDs
+	this.Methods = scope.Methods
 	return nil
 }
 
@@ -94,3 +113,83 @@ func (this *ApproveRelayerParam) Deserialization(source *common.ZeroCopySource)
 	this.Address = addr
 	return nil
 }
+
+// RelayerScope limits a relayer to calls for the side chains ChainIDs and to the contract methods
+// Methods, an empty list does not limit anything.
+type RelayerScope struct {
+	ChainIDs []uint64
+	Methods  []string
+}
+
+func (this *RelayerScope) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarUint(uint64(len(this.ChainIDs)))
+	for _, v := range this.ChainIDs {
+		sink.WriteVarUint(v)
+	}
+	sink.WriteVarUint(uint64(len(this.Methods)))
+	for _, v := range this.Methods {
+		sink.WriteString(v)
+	}
+}
+
+func (this *RelayerScope) Deserialization(source *common.ZeroCopySource) error {
+	n, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("source.NextVarUint, deserialize ChainIDs length error")
+	}
+	var chainIDs []uint64
+	for i := uint64(0); i < n; i++ {
+		chainID, eof := source.NextVarUint()
+		if eof {
+			return fmt.Errorf("source.NextVarUint, deserialize chainID error")
+		}
+		chainIDs = append(chainIDs, chainID)
+	}
+	n, eof = source.NextVarUint()
+	if eof {
+		return fmt.Errorf("source.NextVarUint, deserialize Methods length error")
+	}
+	var methods []string
+	for i := uint64(0); i < n; i++ {
+		method, eof := source.NextString()
+		if eof {
+			return fmt.Errorf("source.NextString, deserialize method error")
+		}
+		methods = append(methods, method)
+	}
+	this.ChainIDs = chainIDs
+	this.Methods = methods
+	return nil
+}
+
+// Allow tells if the scope allows a call of method for all the side chains chainIDs.
+func (this *RelayerScope) Allow(method string, chainIDs ...uint64) bool {
+	if len(this.Methods) != 0 {
+		allowed := false
+		for _, m := range this.Methods {
+			if m == method {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	if len(this.ChainIDs) == 0 {
+		return true
+	}
+	for _, chainID := range chainIDs {
+		allowed := false
+		for _, id := range this.ChainIDs {
+			if id == chainID {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	return true
+}
@@ -33,3 +33,48 @@ func TestRelayerListParam_Serialization(t *testing.T) {
 	err := p.Deserialization(source)
 	assert.Nil(t, err)
 }
+
+func TestRelayerListParamScope(t *testing.T) {
+	params := &RelayerListParam{
+		AddressList: []common.Address{{1, 2, 4, 6}},
+		Address:     common.Address{1},
+		ChainIDs:    []uint64{2, 7},
+		Methods:     []string{"syncBlockHeader"},
+	}
+	sink := common.NewZeroCopySink(nil)
+	params.Serialization(sink)
+
+	var p RelayerListParam
+	err := p.Deserialization(common.NewZeroCopySource(sink.Bytes()))
+	assert.Nil(t, err)
+	assert.Equal(t, params, &p)
+
+	// applies made before relayers were scoped
+	sink = common.NewZeroCopySink(nil)
+	sink.WriteVarUint(1)
+	sink.WriteVarBytes(params.AddressList[0][:])
+	sink.WriteVarBytes(params.Address[:])
+	p = RelayerListParam{}
+	err = p.Deserialization(common.NewZeroCopySource(sink.Bytes()))
+	assert.Nil(t, err)
+	assert.Nil(t, p.ChainIDs)
+	assert.Nil(t, p.Methods)
+
+	// and unscoped applies keep their encoding
+	unscoped := common.NewZeroCopySink(nil)
+	p.Serialization(unscoped)
+	assert.Equal(t, sink.Bytes(), unscoped.Bytes())
+}
+
+func TestRelayerScopeAllow(t *testing.T) {
+	scope := &RelayerScope{ChainIDs: []uint64{2, 7}}
+	assert.True(t, scope.Allow("syncBlockHeader", 2))
+	assert.True(t, scope.Allow("BatchImportOuterTransfer", 2, 7))
+	assert.False(t, scope.Allow("BatchImportOuterTransfer", 2, 3))
+	assert.True(t, scope.Allow("TimeoutRequest"))
+
+	scope.Methods = []string{"syncBlockHeader"}
+	assert.True(t, scope.Allow("syncBlockHeader", 7))
+	assert.False(t, scope.Allow("ImportOuterTransfer", 7))
+	assert.False(t, scope.Allow("syncBlockHeader", 3))
+}
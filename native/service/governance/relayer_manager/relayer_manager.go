@@ -36,6 +36,7 @@ const (
 
 	//key prefix
 	RELAYER        = "relayer"
+	RELAYER_SCOPE  = "relayerScope"
 	RELAYER_APPLY  = "relayerApply"
 	RELAYER_REMOVE = "relayerRemove"
 	APPLY_ID       = "applyID"
@@ -91,11 +92,16 @@ func ApproveRegisterRelayer(native *native.NativeService) ([]byte, error) {
 		return utils.BYTE_TRUE, nil
 	}
 
+	scope := &RelayerScope{ChainIDs: relayerListParam.ChainIDs, Methods: relayerListParam.Methods}
 	for _, address := range relayerListParam.AddressList {
 		err = putRelayer(native, address)
 		if err != nil {
 			return utils.BYTE_FALSE, fmt.Errorf("ApproveRegisterRelayer, putRelayer error: %v", err)
 		}
+		err = putRelayerScope(native, address, scope)
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("ApproveRegisterRelayer, putRelayerScope error: %v", err)
+		}
 	}
 	native.GetCacheDB().Delete(utils.ConcatKey(utils.RelayerManagerContractAddress, []byte(RELAYER_APPLY), utils.GetUint64Bytes(params.ID)))
 	native.AddNotify(
@@ -150,6 +156,10 @@ func ApproveRemoveRelayer(native *native.NativeService) ([]byte, error) {
 
 	for _, address := range relayerListParam.AddressList {
 		native.GetCacheDB().Delete(utils.ConcatKey(utils.RelayerManagerContractAddress, []byte(RELAYER), address[:]))
+		err = deleteRelayerScope(native, address)
+		if err != nil {
+			return utils.BYTE_FALSE, fmt.Errorf("ApproveRemoveRelayer, deleteRelayerScope error: %v", err)
+		}
 	}
 	native.AddNotify(
 		&event.NotifyEventInfo{
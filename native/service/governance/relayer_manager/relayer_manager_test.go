@@ -197,3 +197,28 @@ func TestRemoveRelayer(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckRelayerScope(t *testing.T) {
+	scoped, global, other := common.Address{1}, common.Address{2}, common.Address{3}
+	ns := NewNative(nil, &types.Transaction{}, nil)
+	db := ns.GetCacheDB()
+	assert.Nil(t, putRelayer(ns, scoped))
+	putRelayerScope(ns, scoped, &RelayerScope{ChainIDs: []uint64{2}})
+	assert.Nil(t, putRelayer(ns, global))
+	putRelayerScope(ns, global, &RelayerScope{})
+
+	scope, err := GetRelayerScope(ns, global)
+	assert.Nil(t, err)
+	assert.Nil(t, scope)
+
+	check := func(chainID uint64, signers ...common.Address) error {
+		ns := NewNative(nil, &types.Transaction{SignedAddr: signers}, db)
+		return CheckRelayerScope(ns, "syncBlockHeader", chainID)
+	}
+	assert.Nil(t, check(2, scoped))
+	assert.NotNil(t, check(3, scoped))
+	assert.NotNil(t, check(3, scoped, other))
+	assert.Nil(t, check(3, scoped, global))
+	assert.Nil(t, check(3, global))
+	assert.Nil(t, check(3, other))
+}
@@ -33,6 +33,94 @@ func putRelayer(native *native.NativeService, relayer common.Address) error {
 	return nil
 }
 
+// IsRelayer tells if address is a registered relayer.
+func IsRelayer(native *native.NativeService, address common.Address) (bool, error) {
+	contract := utils.RelayerManagerContractAddress
+	relayerStore, err := native.GetCacheDB().Get(utils.ConcatKey(contract, []byte(RELAYER), address[:]))
+	if err != nil {
+		return false, fmt.Errorf("IsRelayer, get relayerStore error: %v", err)
+	}
+	return relayerStore != nil, nil
+}
+
+// putRelayerScope scopes a relayer, a scope without chains and methods registers it for every call.
+func putRelayerScope(native *native.NativeService, relayer common.Address, scope *RelayerScope) error {
+	if len(scope.ChainIDs) == 0 && len(scope.Methods) == 0 {
+		return deleteRelayerScope(native, relayer)
+	}
+	contract := utils.RelayerManagerContractAddress
+	sink := common.NewZeroCopySink(nil)
+	scope.Serialization(sink)
+	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(RELAYER_SCOPE), relayer[:]), cstates.GenRawStorageItem(sink.Bytes()))
+	return nil
+}
+
+// deleteRelayerScope unscopes a relayer, nothing is written for a relayer which is not scoped.
+func deleteRelayerScope(native *native.NativeService, relayer common.Address) error {
+	key := utils.ConcatKey(utils.RelayerManagerContractAddress, []byte(RELAYER_SCOPE), relayer[:])
+	scopeStore, err := native.GetCacheDB().Get(key)
+	if err != nil {
+		return fmt.Errorf("deleteRelayerScope, get scopeStore error: %v", err)
+	}
+	if scopeStore != nil {
+		native.GetCacheDB().Delete(key)
+	}
+	return nil
+}
+
+// GetRelayerScope returns the scope of a relayer, nil if it is not scoped.
+func GetRelayerScope(native *native.NativeService, relayer common.Address) (*RelayerScope, error) {
+	contract := utils.RelayerManagerContractAddress
+	scopeStore, err := native.GetCacheDB().Get(utils.ConcatKey(contract, []byte(RELAYER_SCOPE), relayer[:]))
+	if err != nil {
+		return nil, fmt.Errorf("GetRelayerScope, get scopeStore error: %v", err)
+	}
+	if scopeStore == nil {
+		return nil, nil
+	}
+	scopeBytes, err := cstates.GetValueFromRawStorageItem(scopeStore)
+	if err != nil {
+		return nil, fmt.Errorf("GetRelayerScope, deserialize from raw storage item err:%v", err)
+	}
+	scope := new(RelayerScope)
+	if err := scope.Deserialization(common.NewZeroCopySource(scopeBytes)); err != nil {
+		return nil, fmt.Errorf("GetRelayerScope, deserialize RelayerScope error: %v", err)
+	}
+	return scope, nil
+}
+
+// CheckRelayerScope fails a call of method for the side chains chainIDs if the tx is signed by scoped
+// relayers none of which is allowed to make it, and by no unscoped relayer. Signers which are not
+// relayers do not count either way.
+func CheckRelayerScope(native *native.NativeService, method string, chainIDs ...uint64) error {
+	signers, err := native.GetTx().GetSignatureAddresses()
+	if err != nil {
+		return fmt.Errorf("CheckRelayerScope, get signers error: %v", err)
+	}
+	scoped := false
+	for _, signer := range signers {
+		isRelayer, err := IsRelayer(native, signer)
+		if err != nil {
+			return fmt.Errorf("CheckRelayerScope, %v", err)
+		}
+		if !isRelayer {
+			continue
+		}
+		scope, err := GetRelayerScope(native, signer)
+		if err != nil {
+			return fmt.Errorf("CheckRelayerScope, %v", err)
+		}
+		if scope == nil || scope.Allow(method, chainIDs...) {
+			return nil
+		}
+		scoped = true
+	}
+	if scoped {
+		return fmt.Errorf("CheckRelayerScope, relayer is not allowed to call %s for chains %v", method, chainIDs)
+	}
+	return nil
+}
+
 func putRelayerApply(native *native.NativeService, relayerListParam *RelayerListParam) error {
 	contract := utils.RelayerManagerContractAddress
 	applyID, err := getApplyID(native)
@@ -0,0 +1,216 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package relayer_reward
+
+import (
+	"fmt"
+
+	"github.com/polynetwork/poly/common"
+)
+
+// RelayerActivity counts the work a relayer did for a side chain, the accepted header and cross chain
+// msg syncs and the imported cross chain transfers.
+type RelayerActivity struct {
+	HeaderSyncs uint64
+	Imports     uint64
+}
+
+func (this *RelayerActivity) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarUint(this.HeaderSyncs)
+	sink.WriteVarUint(this.Imports)
+}
+
+func (this *RelayerActivity) Deserialization(source *common.ZeroCopySource) error {
+	headerSyncs, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("RelayerActivity deserialize headerSyncs error")
+	}
+	imports, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("RelayerActivity deserialize imports error")
+	}
+	this.HeaderSyncs = headerSyncs
+	this.Imports = imports
+	return nil
+}
+
+// RewardPeriod is a reward period, every unit of activity in the period weighs one. The current
+// period has no EndHeight, Fund is shared by the relayers in proportion to their weight once the
+// period is closed by a distribution.
+type RewardPeriod struct {
+	ID          uint64
+	StartHeight uint32
+	EndHeight   uint32
+	TotalWeight uint64
+	Fund        uint64
+}
+
+func (this *RewardPeriod) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarUint(this.ID)
+	sink.WriteUint32(this.StartHeight)
+	sink.WriteUint32(this.EndHeight)
+	sink.WriteVarUint(this.TotalWeight)
+	sink.WriteVarUint(this.Fund)
+}
+
+func (this *RewardPeriod) Deserialization(source *common.ZeroCopySource) error {
+	id, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("RewardPeriod deserialize id error")
+	}
+	startHeight, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("RewardPeriod deserialize startHeight error")
+	}
+	endHeight, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("RewardPeriod deserialize endHeight error")
+	}
+	totalWeight, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("RewardPeriod deserialize totalWeight error")
+	}
+	fund, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("RewardPeriod deserialize fund error")
+	}
+	this.ID = id
+	this.StartHeight = startHeight
+	this.EndHeight = endHeight
+	this.TotalWeight = totalWeight
+	this.Fund = fund
+	return nil
+}
+
+// RelayerReward is the weight of a relayer in a reward period and its share of the fund.
+type RelayerReward struct {
+	Weight uint64
+	Reward uint64
+}
+
+func (this *RelayerReward) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarUint(this.Weight)
+	sink.WriteVarUint(this.Reward)
+}
+
+func (this *RelayerReward) Deserialization(source *common.ZeroCopySource) error {
+	weight, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("RelayerReward deserialize weight error")
+	}
+	reward, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("RelayerReward deserialize reward error")
+	}
+	this.Weight = weight
+	this.Reward = reward
+	return nil
+}
+
+type GetRelayerActivityParam struct {
+	Relayer common.Address
+	ChainID uint64
+}
+
+func (this *GetRelayerActivityParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarBytes(this.Relayer[:])
+	sink.WriteVarUint(this.ChainID)
+}
+
+func (this *GetRelayerActivityParam) Deserialization(source *common.ZeroCopySource) error {
+	relayer, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("GetRelayerActivityParam deserialize relayer error")
+	}
+	addr, err := common.AddressParseFromBytes(relayer)
+	if err != nil {
+		return fmt.Errorf("GetRelayerActivityParam, common.AddressParseFromBytes error: %v", err)
+	}
+	chainID, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("GetRelayerActivityParam deserialize chainID error")
+	}
+	this.Relayer = addr
+	this.ChainID = chainID
+	return nil
+}
+
+type GetRelayerRewardParam struct {
+	Relayer  common.Address
+	PeriodID uint64
+}
+
+func (this *GetRelayerRewardParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarBytes(this.Relayer[:])
+	sink.WriteVarUint(this.PeriodID)
+}
+
+func (this *GetRelayerRewardParam) Deserialization(source *common.ZeroCopySource) error {
+	relayer, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("GetRelayerRewardParam deserialize relayer error")
+	}
+	addr, err := common.AddressParseFromBytes(relayer)
+	if err != nil {
+		return fmt.Errorf("GetRelayerRewardParam, common.AddressParseFromBytes error: %v", err)
+	}
+	periodID, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("GetRelayerRewardParam deserialize periodID error")
+	}
+	this.Relayer = addr
+	this.PeriodID = periodID
+	return nil
+}
+
+// DistributeRewardsParam closes the reward period PeriodID with Fund, once the consensus nodes
+// agreed on it.
+type DistributeRewardsParam struct {
+	PeriodID uint64
+	Fund     uint64
+	Address  common.Address
+}
+
+func (this *DistributeRewardsParam) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarUint(this.PeriodID)
+	sink.WriteVarUint(this.Fund)
+	sink.WriteVarBytes(this.Address[:])
+}
+
+func (this *DistributeRewardsParam) Deserialization(source *common.ZeroCopySource) error {
+	periodID, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("DistributeRewardsParam deserialize periodID error")
+	}
+	fund, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("DistributeRewardsParam deserialize fund error")
+	}
+	address, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("DistributeRewardsParam deserialize address error")
+	}
+	addr, err := common.AddressParseFromBytes(address)
+	if err != nil {
+		return fmt.Errorf("DistributeRewardsParam, common.AddressParseFromBytes error: %v", err)
+	}
+	this.PeriodID = periodID
+	this.Fund = fund
+	this.Address = addr
+	return nil
+}
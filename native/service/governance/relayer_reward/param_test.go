@@ -0,0 +1,58 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package relayer_reward
+
+import (
+	"testing"
+
+	"github.com/polynetwork/poly/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRewardPeriod(t *testing.T) {
+	p := RewardPeriod{
+		ID:          3,
+		StartHeight: 100,
+		EndHeight:   200,
+		TotalWeight: 7,
+		Fund:        1000,
+	}
+	sink := common.NewZeroCopySink(nil)
+	p.Serialization(sink)
+
+	var period RewardPeriod
+	err := period.Deserialization(common.NewZeroCopySource(sink.Bytes()))
+	assert.Nil(t, err)
+	assert.Equal(t, p, period)
+	assert.Equal(t, uint64(428), period.share(3))
+}
+
+func TestDistributeRewardsParam(t *testing.T) {
+	p := DistributeRewardsParam{
+		PeriodID: 3,
+		Fund:     1000,
+		Address:  common.Address{1, 2, 3},
+	}
+	sink := common.NewZeroCopySink(nil)
+	p.Serialization(sink)
+
+	var param DistributeRewardsParam
+	err := param.Deserialization(common.NewZeroCopySource(sink.Bytes()))
+	assert.Nil(t, err)
+	assert.Equal(t, p, param)
+}
@@ -0,0 +1,171 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package relayer_reward
+
+import (
+	"fmt"
+
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/event"
+	"github.com/polynetwork/poly/native/service/governance/node_manager"
+	"github.com/polynetwork/poly/native/service/utils"
+)
+
+const (
+	//function name
+	GET_RELAYER_ACTIVITY      = "getRelayerActivity"
+	GET_REWARD_PERIOD         = "getRewardPeriod"
+	GET_CURRENT_REWARD_PERIOD = "getCurrentRewardPeriod"
+	GET_RELAYER_REWARD        = "getRelayerReward"
+	DISTRIBUTE_REWARDS        = "distributeRewards"
+
+	//key prefix
+	RELAYER_ACTIVITY = "relayerActivity"
+	PERIOD_WEIGHT    = "periodWeight"
+	REWARD_PERIOD    = "rewardPeriod"
+	CURRENT_PERIOD   = "currentPeriod"
+)
+
+// Register methods of relayer_reward contract
+func RegisterRelayerRewardContract(native *native.NativeService) {
+	native.Register(GET_RELAYER_ACTIVITY, GetRelayerActivity)
+	native.Register(GET_REWARD_PERIOD, GetRewardPeriod)
+	native.Register(GET_CURRENT_REWARD_PERIOD, GetCurrentRewardPeriod)
+	native.Register(GET_RELAYER_REWARD, GetRelayerReward)
+	native.Register(DISTRIBUTE_REWARDS, DistributeRewards)
+}
+
+func GetRelayerActivity(native *native.NativeService) ([]byte, error) {
+	params := new(GetRelayerActivityParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetRelayerActivity, contract params deserialize error: %v", err)
+	}
+	activity, err := getRelayerActivity(native, params.Relayer, params.ChainID)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetRelayerActivity, %v", err)
+	}
+	sink := common.NewZeroCopySink(nil)
+	activity.Serialization(sink)
+	return sink.Bytes(), nil
+}
+
+func GetRewardPeriod(native *native.NativeService) ([]byte, error) {
+	id, eof := common.NewZeroCopySource(native.GetInput()).NextVarUint()
+	if eof {
+		return utils.BYTE_FALSE, fmt.Errorf("GetRewardPeriod, contract params deserialize error")
+	}
+	period, err := getRewardPeriod(native, id)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetRewardPeriod, %v", err)
+	}
+	if period == nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetRewardPeriod, period %d does not exist", id)
+	}
+	sink := common.NewZeroCopySink(nil)
+	period.Serialization(sink)
+	return sink.Bytes(), nil
+}
+
+func GetCurrentRewardPeriod(native *native.NativeService) ([]byte, error) {
+	period, err := getCurrentPeriod(native)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetCurrentRewardPeriod, %v", err)
+	}
+	sink := common.NewZeroCopySink(nil)
+	period.Serialization(sink)
+	return sink.Bytes(), nil
+}
+
+// GetRelayerReward returns the weight of a relayer in a period and its reward, which is 0 until
+// the period is closed.
+func GetRelayerReward(native *native.NativeService) ([]byte, error) {
+	params := new(GetRelayerRewardParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetRelayerReward, contract params deserialize error: %v", err)
+	}
+	period, err := getRewardPeriod(native, params.PeriodID)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetRelayerReward, %v", err)
+	}
+	if period == nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetRelayerReward, period %d does not exist", params.PeriodID)
+	}
+	current, err := getCurrentPeriod(native)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetRelayerReward, %v", err)
+	}
+	weight, err := getPeriodWeight(native, params.PeriodID, params.Relayer)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetRelayerReward, %v", err)
+	}
+	reward := &RelayerReward{Weight: weight}
+	if period.ID < current.ID {
+		reward.Reward = period.share(weight)
+	}
+	sink := common.NewZeroCopySink(nil)
+	reward.Serialization(sink)
+	return sink.Bytes(), nil
+}
+
+// DistributeRewards closes the current reward period with the fund the consensus nodes agreed on
+// and starts the next one.
+func DistributeRewards(native *native.NativeService) ([]byte, error) {
+	params := new(DistributeRewardsParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("DistributeRewards, contract params deserialize error: %v", err)
+	}
+
+	//check witness
+	err := utils.ValidateOwner(native, params.Address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("DistributeRewards, checkWitness error: %v", err)
+	}
+
+	period, err := getCurrentPeriod(native)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("DistributeRewards, %v", err)
+	}
+	if params.PeriodID != period.ID {
+		return utils.BYTE_FALSE, fmt.Errorf("DistributeRewards, period %d is not the current period %d", params.PeriodID, period.ID)
+	}
+
+	//check consensus signs
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteVarUint(params.PeriodID)
+	sink.WriteVarUint(params.Fund)
+	ok, err := node_manager.CheckConsensusSigns(native, DISTRIBUTE_REWARDS, sink.Bytes(), params.Address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("DistributeRewards, CheckConsensusSigns error: %v", err)
+	}
+	if !ok {
+		return utils.BYTE_TRUE, nil
+	}
+
+	period.EndHeight = native.GetHeight()
+	period.Fund = params.Fund
+	putRewardPeriod(native, period)
+	putRewardPeriod(native, &RewardPeriod{ID: period.ID + 1, StartHeight: native.GetHeight()})
+	putCurrentPeriodID(native, period.ID+1)
+	native.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.RelayerRewardContractAddress,
+			States:          []interface{}{DISTRIBUTE_REWARDS, period.ID, period.Fund, period.TotalWeight},
+		})
+	return utils.BYTE_TRUE, nil
+}
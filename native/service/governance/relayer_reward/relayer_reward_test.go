@@ -0,0 +1,199 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package relayer_reward
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/polynetwork/poly/account"
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/common/config"
+	vconfig "github.com/polynetwork/poly/consensus/vbft/config"
+	cstates "github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/core/store/leveldbstore"
+	"github.com/polynetwork/poly/core/store/overlaydb"
+	"github.com/polynetwork/poly/core/types"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/governance/node_manager"
+	"github.com/polynetwork/poly/native/service/governance/relayer_manager"
+	"github.com/polynetwork/poly/native/service/utils"
+	"github.com/polynetwork/poly/native/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+var conAccts = func() []*account.Account {
+	accts := make([]*account.Account, 0)
+	for i := 0; i < 4; i++ {
+		accts = append(accts, account.NewAccount(strconv.FormatUint(uint64(i), 10)))
+	}
+	return accts
+}()
+
+func init() {
+	// relayer work is counted from genesis on solo net
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+}
+
+func putPeerMapPoolAndView(db *storage.CacheDB) {
+	peerPoolMap := new(node_manager.PeerPoolMap)
+	peerPoolMap.PeerPoolMap = make(map[string]*node_manager.PeerPoolItem)
+	for i, conAcct := range conAccts {
+		pkStr := vconfig.PubkeyID(conAcct.PublicKey)
+		peerPoolMap.PeerPoolMap[pkStr] = &node_manager.PeerPoolItem{
+			Index:      uint32(i),
+			PeerPubkey: pkStr,
+			Address:    conAcct.Address,
+			Status:     node_manager.ConsensusStatus,
+		}
+	}
+	sink := common.NewZeroCopySink(nil)
+	peerPoolMap.Serialization(sink)
+	db.Put(utils.ConcatKey(utils.NodeManagerContractAddress, []byte(node_manager.PEER_POOL), utils.GetUint32Bytes(0)),
+		cstates.GenRawStorageItem(sink.Bytes()))
+
+	govView := node_manager.GovernanceView{View: 0, Height: 10, TxHash: common.UINT256_EMPTY}
+	sink = common.NewZeroCopySink(nil)
+	govView.Serialization(sink)
+	db.Put(utils.ConcatKey(utils.NodeManagerContractAddress, []byte(node_manager.GOVERNANCE_VIEW)),
+		cstates.GenRawStorageItem(sink.Bytes()))
+}
+
+func newNative(args []byte, signers []common.Address, db *storage.CacheDB) *native.NativeService {
+	if db == nil {
+		store, _ := leveldbstore.NewMemLevelDBStore()
+		db = storage.NewCacheDB(overlaydb.NewOverlayDB(store))
+	}
+	ns, _ := native.NewNativeService(db, &types.Transaction{SignedAddr: signers}, 0, 10, common.Uint256{0}, 0, args, false)
+	return ns
+}
+
+func putRelayer(db *storage.CacheDB, relayer common.Address) {
+	db.Put(utils.ConcatKey(utils.RelayerManagerContractAddress, []byte(relayer_manager.RELAYER), relayer[:]),
+		cstates.GenRawStorageItem(relayer[:]))
+}
+
+func getReward(t *testing.T, db *storage.CacheDB, relayer common.Address, periodID uint64) *RelayerReward {
+	param := &GetRelayerRewardParam{Relayer: relayer, PeriodID: periodID}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	res, err := GetRelayerReward(newNative(sink.Bytes(), nil, db))
+	assert.Nil(t, err)
+	reward := new(RelayerReward)
+	assert.Nil(t, reward.Deserialization(common.NewZeroCopySource(res)))
+	return reward
+}
+
+func TestRecordActivity(t *testing.T) {
+	relayerA, relayerB, other := common.Address{1}, common.Address{2}, common.Address{3}
+	db := newNative(nil, nil, nil).GetCacheDB()
+	putRelayer(db, relayerA)
+	putRelayer(db, relayerB)
+
+	// the relayer in the params is credited if it signed the tx
+	assert.Nil(t, RecordHeaderSync(newNative(nil, []common.Address{relayerA, relayerB}, db), 2, relayerB[:], 1))
+	// a sync storing no header is not counted
+	assert.Nil(t, RecordHeaderSync(newNative(nil, []common.Address{relayerA}, db), 2, relayerA[:], 0))
+	// else the first signing relayer
+	assert.Nil(t, RecordImport(newNative(nil, []common.Address{other, relayerA}, db), 2, relayerB[:]))
+	assert.Nil(t, RecordImport(newNative(nil, []common.Address{relayerA}, db), 3, []byte{1, 2}))
+	// and nobody if no relayer signed it
+	assert.Nil(t, RecordImport(newNative(nil, []common.Address{other}, db), 2, other[:]))
+
+	ns := newNative(nil, nil, db)
+	activity, err := getRelayerActivity(ns, relayerA, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, &RelayerActivity{HeaderSyncs: 0, Imports: 1}, activity)
+	activity, err = getRelayerActivity(ns, relayerA, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, &RelayerActivity{HeaderSyncs: 0, Imports: 1}, activity)
+	activity, err = getRelayerActivity(ns, relayerB, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, &RelayerActivity{HeaderSyncs: 1, Imports: 0}, activity)
+	activity, err = getRelayerActivity(ns, other, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, &RelayerActivity{}, activity)
+
+	period, err := getCurrentPeriod(ns)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), period.ID)
+	assert.Equal(t, uint64(3), period.TotalWeight)
+	assert.Equal(t, &RelayerReward{Weight: 2}, getReward(t, db, relayerA, 0))
+}
+
+func TestRecordActivityHeight(t *testing.T) {
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_MAIN_NET
+	defer func() { config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET }()
+
+	relayer := common.Address{1}
+	db := newNative(nil, nil, nil).GetCacheDB()
+	putRelayer(db, relayer)
+	assert.Nil(t, RecordHeaderSync(newNative(nil, []common.Address{relayer}, db), 2, relayer[:], 1))
+	assert.Nil(t, RecordImport(newNative(nil, []common.Address{relayer}, db), 2, relayer[:]))
+
+	// nothing is counted below the relayer reward height
+	ns := newNative(nil, nil, db)
+	activity, err := getRelayerActivity(ns, relayer, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, &RelayerActivity{}, activity)
+	value, err := db.Get(utils.ConcatKey(utils.RelayerRewardContractAddress, []byte(REWARD_PERIOD), utils.GetUint64Bytes(0)))
+	assert.Nil(t, err)
+	assert.Nil(t, value)
+}
+
+func TestDistributeRewards(t *testing.T) {
+	relayerA, relayerB := common.Address{1}, common.Address{2}
+	db := newNative(nil, nil, nil).GetCacheDB()
+	putPeerMapPoolAndView(db)
+	putRelayer(db, relayerA)
+	putRelayer(db, relayerB)
+	assert.Nil(t, RecordImport(newNative(nil, []common.Address{relayerA}, db), 2, nil))
+	assert.Nil(t, RecordImport(newNative(nil, []common.Address{relayerA}, db), 2, nil))
+	assert.Nil(t, RecordHeaderSync(newNative(nil, []common.Address{relayerB}, db), 2, nil, 1))
+
+	distribute := func(acct *account.Account, periodID uint64) error {
+		param := &DistributeRewardsParam{PeriodID: periodID, Fund: 1000, Address: acct.Address}
+		sink := common.NewZeroCopySink(nil)
+		param.Serialization(sink)
+		_, err := DistributeRewards(newNative(sink.Bytes(), []common.Address{acct.Address}, db))
+		return err
+	}
+	// only the current period can be distributed
+	assert.NotNil(t, distribute(conAccts[0], 1))
+	for i, acct := range conAccts {
+		assert.Nil(t, distribute(acct, 0))
+		period, err := getCurrentPeriod(newNative(nil, nil, db))
+		assert.Nil(t, err)
+		if i < 2 {
+			assert.Equal(t, uint64(0), period.ID)
+			assert.Equal(t, &RelayerReward{Weight: 2}, getReward(t, db, relayerA, 0))
+		} else {
+			break
+		}
+	}
+
+	period, err := getCurrentPeriod(newNative(nil, nil, db))
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), period.ID)
+	assert.Equal(t, &RelayerReward{Weight: 2, Reward: 666}, getReward(t, db, relayerA, 0))
+	assert.Equal(t, &RelayerReward{Weight: 1, Reward: 333}, getReward(t, db, relayerB, 0))
+
+	// activity goes to the next period
+	assert.Nil(t, RecordImport(newNative(nil, []common.Address{relayerB}, db), 2, nil))
+	assert.Equal(t, &RelayerReward{Weight: 1}, getReward(t, db, relayerB, 1))
+}
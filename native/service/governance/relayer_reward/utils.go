@@ -0,0 +1,237 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package relayer_reward
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/common/config"
+	cstates "github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/governance/relayer_manager"
+	"github.com/polynetwork/poly/native/service/utils"
+)
+
+// RecordHeaderSync counts the headers or cross chain msgs of chainID stored by the sync tx of the relayer,
+// a sync storing nothing new is not counted.
+func RecordHeaderSync(native *native.NativeService, chainID uint64, relayer []byte, stored uint64) error {
+	if stored == 0 {
+		return nil
+	}
+	if err := recordActivity(native, chainID, relayer, &RelayerActivity{HeaderSyncs: stored}); err != nil {
+		return fmt.Errorf("RecordHeaderSync, %v", err)
+	}
+	return nil
+}
+
+// RecordImport counts a cross chain transfer of chainID imported by the relayer of the tx.
+func RecordImport(native *native.NativeService, chainID uint64, relayer []byte) error {
+	if err := recordActivity(native, chainID, relayer, &RelayerActivity{Imports: 1}); err != nil {
+		return fmt.Errorf("RecordImport, %v", err)
+	}
+	return nil
+}
+
+// creditedRelayer returns the relayer credited with the work of the tx: the relayer address given in the
+// params if it signed the tx, else the first signer which is a registered relayer.
+func creditedRelayer(native *native.NativeService, relayer []byte) (common.Address, bool, error) {
+	signers, err := native.GetTx().GetSignatureAddresses()
+	if err != nil {
+		return common.ADDRESS_EMPTY, false, fmt.Errorf("creditedRelayer, get signers error: %v", err)
+	}
+	claimed, err := common.AddressParseFromBytes(relayer)
+	if err == nil {
+		for _, signer := range signers {
+			if signer != claimed {
+				continue
+			}
+			isRelayer, err := relayer_manager.IsRelayer(native, signer)
+			if err != nil {
+				return common.ADDRESS_EMPTY, false, fmt.Errorf("creditedRelayer, %v", err)
+			}
+			if isRelayer {
+				return signer, true, nil
+			}
+		}
+	}
+	for _, signer := range signers {
+		isRelayer, err := relayer_manager.IsRelayer(native, signer)
+		if err != nil {
+			return common.ADDRESS_EMPTY, false, fmt.Errorf("creditedRelayer, %v", err)
+		}
+		if isRelayer {
+			return signer, true, nil
+		}
+	}
+	return common.ADDRESS_EMPTY, false, nil
+}
+
+// recordActivity credits work to the relayer of the tx, work done below the relayer reward height is not counted
+func recordActivity(native *native.NativeService, chainID uint64, relayer []byte, work *RelayerActivity) error {
+	if native.GetHeight() < config.GetRelayerRewardHeight(config.DefConfig.P2PNode.NetworkId) {
+		return nil
+	}
+	address, ok, err := creditedRelayer(native, relayer)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+	activity, err := getRelayerActivity(native, address, chainID)
+	if err != nil {
+		return err
+	}
+	activity.HeaderSyncs += work.HeaderSyncs
+	activity.Imports += work.Imports
+	putRelayerActivity(native, address, chainID, activity)
+
+	period, err := getCurrentPeriod(native)
+	if err != nil {
+		return err
+	}
+	weight, err := getPeriodWeight(native, period.ID, address)
+	if err != nil {
+		return err
+	}
+	putPeriodWeight(native, period.ID, address, weight+work.HeaderSyncs+work.Imports)
+	period.TotalWeight += work.HeaderSyncs + work.Imports
+	putRewardPeriod(native, period)
+	return nil
+}
+
+func getRelayerActivity(native *native.NativeService, relayer common.Address, chainID uint64) (*RelayerActivity, error) {
+	contract := utils.RelayerRewardContractAddress
+	activityStore, err := native.GetCacheDB().Get(utils.ConcatKey(contract, []byte(RELAYER_ACTIVITY), relayer[:],
+		utils.GetUint64Bytes(chainID)))
+	if err != nil {
+		return nil, fmt.Errorf("getRelayerActivity, get activityStore error: %v", err)
+	}
+	activity := new(RelayerActivity)
+	if activityStore == nil {
+		return activity, nil
+	}
+	activityBytes, err := cstates.GetValueFromRawStorageItem(activityStore)
+	if err != nil {
+		return nil, fmt.Errorf("getRelayerActivity, deserialize from raw storage item err:%v", err)
+	}
+	if err := activity.Deserialization(common.NewZeroCopySource(activityBytes)); err != nil {
+		return nil, fmt.Errorf("getRelayerActivity, deserialize RelayerActivity error: %v", err)
+	}
+	return activity, nil
+}
+
+func putRelayerActivity(native *native.NativeService, relayer common.Address, chainID uint64, activity *RelayerActivity) {
+	contract := utils.RelayerRewardContractAddress
+	sink := common.NewZeroCopySink(nil)
+	activity.Serialization(sink)
+	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(RELAYER_ACTIVITY), relayer[:], utils.GetUint64Bytes(chainID)),
+		cstates.GenRawStorageItem(sink.Bytes()))
+}
+
+func getPeriodWeight(native *native.NativeService, periodID uint64, relayer common.Address) (uint64, error) {
+	contract := utils.RelayerRewardContractAddress
+	weightStore, err := native.GetCacheDB().Get(utils.ConcatKey(contract, []byte(PERIOD_WEIGHT), utils.GetUint64Bytes(periodID),
+		relayer[:]))
+	if err != nil {
+		return 0, fmt.Errorf("getPeriodWeight, get weightStore error: %v", err)
+	}
+	if weightStore == nil {
+		return 0, nil
+	}
+	weightBytes, err := cstates.GetValueFromRawStorageItem(weightStore)
+	if err != nil {
+		return 0, fmt.Errorf("getPeriodWeight, deserialize from raw storage item err:%v", err)
+	}
+	return utils.GetBytesUint64(weightBytes), nil
+}
+
+func putPeriodWeight(native *native.NativeService, periodID uint64, relayer common.Address, weight uint64) {
+	contract := utils.RelayerRewardContractAddress
+	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(PERIOD_WEIGHT), utils.GetUint64Bytes(periodID), relayer[:]),
+		cstates.GenRawStorageItem(utils.GetUint64Bytes(weight)))
+}
+
+func getRewardPeriod(native *native.NativeService, id uint64) (*RewardPeriod, error) {
+	contract := utils.RelayerRewardContractAddress
+	periodStore, err := native.GetCacheDB().Get(utils.ConcatKey(contract, []byte(REWARD_PERIOD), utils.GetUint64Bytes(id)))
+	if err != nil {
+		return nil, fmt.Errorf("getRewardPeriod, get periodStore error: %v", err)
+	}
+	if periodStore == nil {
+		return nil, nil
+	}
+	periodBytes, err := cstates.GetValueFromRawStorageItem(periodStore)
+	if err != nil {
+		return nil, fmt.Errorf("getRewardPeriod, deserialize from raw storage item err:%v", err)
+	}
+	period := new(RewardPeriod)
+	if err := period.Deserialization(common.NewZeroCopySource(periodBytes)); err != nil {
+		return nil, fmt.Errorf("getRewardPeriod, deserialize RewardPeriod error: %v", err)
+	}
+	return period, nil
+}
+
+func putRewardPeriod(native *native.NativeService, period *RewardPeriod) {
+	contract := utils.RelayerRewardContractAddress
+	sink := common.NewZeroCopySink(nil)
+	period.Serialization(sink)
+	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(REWARD_PERIOD), utils.GetUint64Bytes(period.ID)),
+		cstates.GenRawStorageItem(sink.Bytes()))
+}
+
+// getCurrentPeriod returns the open reward period, the first one starts with the first recorded activity.
+func getCurrentPeriod(native *native.NativeService) (*RewardPeriod, error) {
+	contract := utils.RelayerRewardContractAddress
+	idStore, err := native.GetCacheDB().Get(utils.ConcatKey(contract, []byte(CURRENT_PERIOD)))
+	if err != nil {
+		return nil, fmt.Errorf("getCurrentPeriod, get idStore error: %v", err)
+	}
+	var id uint64
+	if idStore != nil {
+		idBytes, err := cstates.GetValueFromRawStorageItem(idStore)
+		if err != nil {
+			return nil, fmt.Errorf("getCurrentPeriod, deserialize from raw storage item err:%v", err)
+		}
+		id = utils.GetBytesUint64(idBytes)
+	}
+	period, err := getRewardPeriod(native, id)
+	if err != nil {
+		return nil, fmt.Errorf("getCurrentPeriod, %v", err)
+	}
+	if period == nil {
+		period = &RewardPeriod{ID: id, StartHeight: native.GetHeight()}
+	}
+	return period, nil
+}
+
+func putCurrentPeriodID(native *native.NativeService, id uint64) {
+	contract := utils.RelayerRewardContractAddress
+	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(CURRENT_PERIOD)), cstates.GenRawStorageItem(utils.GetUint64Bytes(id)))
+}
+
+// share returns the part of the fund of the period earned by weight, rounded down.
+func (this *RewardPeriod) share(weight uint64) uint64 {
+	if this.TotalWeight == 0 {
+		return 0
+	}
+	reward := new(big.Int).Mul(new(big.Int).SetUint64(this.Fund), new(big.Int).SetUint64(weight))
+	return reward.Div(reward, new(big.Int).SetUint64(this.TotalWeight)).Uint64()
+}
@@ -122,6 +122,53 @@ func (this *ChainidParam) Deserialization(source *common.ZeroCopySource) error {
 	return nil
 }
 
+type CallFilterParam struct {
+	ChainID      uint64
+	AllowedCalls []*TargetCall
+	DeniedCalls  []*TargetCall
+	Address      common.Address
+}
+
+func (this *CallFilterParam) Serialization(sink *common.ZeroCopySink) {
+	this.serializeFilter(sink)
+	sink.WriteVarBytes(this.Address[:])
+}
+
+// serializeFilter writes the content consensus nodes have to agree on, without the signer address
+func (this *CallFilterParam) serializeFilter(sink *common.ZeroCopySink) {
+	sink.WriteVarUint(this.ChainID)
+	serializeTargetCalls(sink, this.AllowedCalls)
+	serializeTargetCalls(sink, this.DeniedCalls)
+}
+
+func (this *CallFilterParam) Deserialization(source *common.ZeroCopySource) error {
+	chainID, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("source.NextVarUint, deserialize chainID error")
+	}
+	allowedCalls, err := deserializeTargetCalls(source)
+	if err != nil {
+		return fmt.Errorf("deserialize allowedCalls error: %s", err)
+	}
+	deniedCalls, err := deserializeTargetCalls(source)
+	if err != nil {
+		return fmt.Errorf("deserialize deniedCalls error: %s", err)
+	}
+	address, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("source.NextVarBytes, deserialize address error")
+	}
+	addr, err := common.AddressParseFromBytes(address)
+	if err != nil {
+		return fmt.Errorf("common.AddressParseFromBytes, deserialize address error: %s", err)
+	}
+	this.ChainID = chainID
+	this.AllowedCalls = allowedCalls
+	this.DeniedCalls = deniedCalls
+	this.Address = addr
+	return nil
+}
+
 type RegisterRedeemParam struct {
 	RedeemChainID   uint64
 	ContractChainID uint64
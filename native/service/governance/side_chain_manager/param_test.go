@@ -55,3 +55,21 @@ func TestChainidParam(t *testing.T) {
 
 	assert.Equal(t, p, param)
 }
+
+func TestCallFilterParam(t *testing.T) {
+	p := CallFilterParam{
+		ChainID:      2,
+		AllowedCalls: []*TargetCall{{Contract: []byte{1, 2, 3}, Method: "unlock"}},
+		DeniedCalls:  []*TargetCall{{Contract: []byte{4, 5, 6}}},
+		Address:      common.Address{1, 2, 3},
+	}
+
+	sink := common.NewZeroCopySink(nil)
+	p.Serialization(sink)
+
+	var param CallFilterParam
+	err := param.Deserialization(common.NewZeroCopySource(sink.Bytes()))
+	assert.NoError(t, err)
+
+	assert.Equal(t, p, param)
+}
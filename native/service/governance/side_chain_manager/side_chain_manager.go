@@ -21,7 +21,6 @@ import (
 	"encoding/hex"
 	"fmt"
 
-	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcutil"
 	"github.com/polynetwork/poly/common"
 	"github.com/polynetwork/poly/native"
@@ -40,6 +39,7 @@ const (
 	APPROVE_QUIT_SIDE_CHAIN     = "approveQuitSideChain"
 	REGISTER_REDEEM             = "registerRedeem"
 	SET_BTC_TX_PARAM            = "setBtcTxParam"
+	SET_CALL_FILTER             = "setCallFilter"
 
 	//key prefix
 	SIDE_CHAIN_APPLY          = "sideChainApply"
@@ -50,6 +50,7 @@ const (
 	BIND_SIGN_INFO            = "bindSignInfo"
 	BTC_TX_PARAM              = "btcTxParam"
 	REDEEM_SCRIPT             = "redeemScript"
+	TAPROOT_OUTPUT_KEY        = "taprootOutputKey"
 )
 
 //Register methods of node_manager contract
@@ -63,6 +64,7 @@ func RegisterSideChainManagerContract(native *native.NativeService) {
 
 	native.Register(REGISTER_REDEEM, RegisterRedeem)
 	native.Register(SET_BTC_TX_PARAM, SetBtcTxParam)
+	native.Register(SET_CALL_FILTER, SetCallFilter)
 }
 
 func RegisterSideChain(native *native.NativeService) ([]byte, error) {
@@ -228,6 +230,15 @@ func ApproveUpdateSideChain(native *native.NativeService) ([]byte, error) {
 		return utils.BYTE_TRUE, nil
 	}
 
+	// call filters are managed by SetCallFilter, keep the ones of the registered side chain
+	registered, err := GetSideChain(native, params.Chainid)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("ApproveUpdateSideChain, getSideChain error: %v", err)
+	}
+	if registered != nil {
+		sideChain.AllowedCalls = registered.AllowedCalls
+		sideChain.DeniedCalls = registered.DeniedCalls
+	}
 	err = PutSideChain(native, sideChain)
 	if err != nil {
 		return utils.BYTE_FALSE, fmt.Errorf("ApproveUpdateSideChain, putSideChain error: %v", err)
@@ -320,12 +331,9 @@ func RegisterRedeem(native *native.NativeService) ([]byte, error) {
 	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
 		return utils.BYTE_FALSE, fmt.Errorf("RegisterRedeem, contract params deserialize error: %v", err)
 	}
-	ty, addrs, m, err := txscript.ExtractPkScriptAddrs(params.Redeem, netParam)
+	signers, m, err := getRedeemSigners(params.Redeem)
 	if err != nil {
-		return utils.BYTE_FALSE, fmt.Errorf("RegisterRedeem, failed to extract addrs: %v", err)
-	}
-	if ty != txscript.MultiSigTy {
-		return utils.BYTE_FALSE, fmt.Errorf("RegisterRedeem, wrong type of redeem: %s", ty.String())
+		return utils.BYTE_FALSE, fmt.Errorf("RegisterRedeem, %v", err)
 	}
 	rk := btcutil.Hash160(params.Redeem)
 	contract, err := GetContractBind(native, params.RedeemChainID, params.ContractChainID, rk)
@@ -336,7 +344,7 @@ func RegisterRedeem(native *native.NativeService) ([]byte, error) {
 		return utils.BYTE_FALSE, fmt.Errorf("RegisterRedeem, previous version is %d and your version should "+
 			"be %d not %d", contract.Ver, contract.Ver+1, params.CVersion)
 	}
-	verified, err := verifyRedeemRegister(params, addrs)
+	verified, err := verifyRedeemRegister(params, signers)
 	if err != nil {
 		return utils.BYTE_FALSE, fmt.Errorf("RegisterRedeem, failed to verify: %v", err)
 	}
@@ -382,12 +390,9 @@ func SetBtcTxParam(native *native.NativeService) ([]byte, error) {
 	if params.Detial.MinChange < 2000 {
 		return utils.BYTE_FALSE, fmt.Errorf("SetBtcTxParam, min-change can't less than 2000")
 	}
-	cls, addrs, m, err := txscript.ExtractPkScriptAddrs(params.Redeem, netParam)
+	signers, m, err := getRedeemSigners(params.Redeem)
 	if err != nil {
-		return utils.BYTE_FALSE, fmt.Errorf("SetBtcTxParam, extract addrs from redeem %v", err)
-	}
-	if cls != txscript.MultiSigTy {
-		return utils.BYTE_FALSE, fmt.Errorf("SetBtcTxParam, redeem script is not multisig script: %s", cls.String())
+		return utils.BYTE_FALSE, fmt.Errorf("SetBtcTxParam, redeem script is not multisig script: %v", err)
 	}
 	rk := btcutil.Hash160(params.Redeem)
 	prev, err := GetBtcTxParam(native, rk, params.RedeemChainId)
@@ -408,7 +413,7 @@ func SetBtcTxParam(native *native.NativeService) ([]byte, error) {
 	if len(info.BindSignInfo) >= m {
 		return utils.BYTE_FALSE, fmt.Errorf("SetBtcTxParam, the signatures are already enough")
 	}
-	verified, err := verifyBtcTxParam(params, addrs)
+	verified, err := verifyBtcTxParam(params, signers)
 	if err != nil {
 		return utils.BYTE_FALSE, fmt.Errorf("SetBtcTxParam, failed to verify: %v", err)
 	}
@@ -431,3 +436,48 @@ func SetBtcTxParam(native *native.NativeService) ([]byte, error) {
 	}
 	return utils.BYTE_TRUE, nil
 }
+
+func SetCallFilter(native *native.NativeService) ([]byte, error) {
+	params := new(CallFilterParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetCallFilter, contract params deserialize error: %v", err)
+	}
+
+	//check witness
+	err := utils.ValidateOwner(native, params.Address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetCallFilter, checkWitness error: %v", err)
+	}
+
+	sideChain, err := GetSideChain(native, params.ChainID)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetCallFilter, getSideChain error: %v", err)
+	}
+	if sideChain == nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetCallFilter, side chain is not registered")
+	}
+
+	//check consensus signs
+	sink := common.NewZeroCopySink(nil)
+	params.serializeFilter(sink)
+	ok, err := node_manager.CheckConsensusSigns(native, SET_CALL_FILTER, sink.Bytes(), params.Address)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetCallFilter, CheckConsensusSigns error: %v", err)
+	}
+	if !ok {
+		return utils.BYTE_TRUE, nil
+	}
+
+	sideChain.AllowedCalls = params.AllowedCalls
+	sideChain.DeniedCalls = params.DeniedCalls
+	err = PutSideChain(native, sideChain)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetCallFilter, putSideChain error: %v", err)
+	}
+	native.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.SideChainManagerContractAddress,
+			States:          []interface{}{"SetCallFilter", params.ChainID, len(params.AllowedCalls), len(params.DeniedCalls)},
+		})
+	return utils.BYTE_TRUE, nil
+}
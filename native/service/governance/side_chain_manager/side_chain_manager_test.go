@@ -19,6 +19,8 @@ package side_chain_manager
 
 import (
 	"encoding/hex"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcutil"
 	"github.com/ontio/ontology-crypto/keypair"
 	"github.com/polynetwork/poly/account"
 	"github.com/polynetwork/poly/common"
@@ -28,6 +30,7 @@ import (
 	"github.com/polynetwork/poly/core/types"
 	"github.com/polynetwork/poly/native"
 	"github.com/polynetwork/poly/native/service/utils"
+	"github.com/polynetwork/poly/native/service/utils/taproot"
 	"github.com/polynetwork/poly/native/storage"
 	"github.com/stretchr/testify/assert"
 	"strings"
@@ -242,3 +245,51 @@ func TestSetBtcTxParam(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, utils.BYTE_FALSE, ok)
 }
+
+func TestRegisterTaprootRedeem(t *testing.T) {
+	privs := make([]*btcec.PrivateKey, 3)
+	keys := make([][]byte, len(privs))
+	for i := range privs {
+		privs[i], _ = btcec.NewPrivateKey(btcec.S256())
+		keys[i] = taproot.XOnlyPubKey(privs[i].PubKey())
+	}
+	redeem, err := taproot.MultisigLeaf(keys, 2)
+	assert.NoError(t, err)
+
+	param := new(RegisterRedeemParam)
+	param.ContractAddress, _ = hex.DecodeString("9a20bEd97360d28AE93c21750e9492ea8f85989f")
+	param.ContractChainID = 2
+	param.RedeemChainID = 1
+	param.Redeem = redeem
+	param.CVersion = 0
+	hash := btcutil.Hash160(append(append(append(append(append([]byte{}, redeem...), utils.GetUint64Bytes(1)...),
+		param.ContractAddress...), utils.GetUint64Bytes(2)...), utils.GetUint64Bytes(0)...))
+	for _, priv := range privs[:2] {
+		sig, err := taproot.SignSchnorr(priv, hash)
+		assert.NoError(t, err)
+		param.Signs = append(param.Signs, sig)
+	}
+
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	ns := getNativeFunc(sink.Bytes())
+	ok, err := RegisterRedeem(ns)
+	assert.NoError(t, err)
+	assert.Equal(t, utils.BYTE_TRUE, ok)
+	rk := hex.EncodeToString(btcutil.Hash160(redeem))
+	assert.Equal(t, rk, ns.GetNotify()[0].States.([]interface{})[1].(string))
+
+	stored, err := GetBtcRedeemScriptBytes(ns, rk, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, redeem, stored)
+	outputKey, _, _ := taproot.OutputKey(redeem)
+	res, err := GetTaprootRedeemKey(ns, outputKey, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, rk, res)
+
+	param.Signs = [][]byte{param.Signs[0][:63]}
+	sink = common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	_, err = RegisterRedeem(getNativeFunc(sink.Bytes()))
+	assert.Error(t, err)
+}
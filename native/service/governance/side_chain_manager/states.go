@@ -18,6 +18,7 @@
 package side_chain_manager
 
 import (
+	"bytes"
 	"fmt"
 	"sort"
 
@@ -34,6 +35,80 @@ type SideChain struct {
 	BlocksToWait uint64
 	CCMCAddress  []byte
 	ExtraInfo    []byte
+	AllowedCalls []*TargetCall
+	DeniedCalls  []*TargetCall
+}
+
+// TargetCall is a (target contract, method) pair of a cross chain message,
+// an empty Method matches every method of the contract.
+type TargetCall struct {
+	Contract []byte
+	Method   string
+}
+
+func (this *TargetCall) Match(contract []byte, method string) bool {
+	return bytes.Equal(this.Contract, contract) && (this.Method == "" || this.Method == method)
+}
+
+func (this *TargetCall) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarBytes(this.Contract)
+	sink.WriteString(this.Method)
+}
+
+func (this *TargetCall) Deserialization(source *common.ZeroCopySource) error {
+	contract, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("TargetCall deserialize contract error")
+	}
+	method, eof := source.NextString()
+	if eof {
+		return fmt.Errorf("TargetCall deserialize method error")
+	}
+	this.Contract = contract
+	this.Method = method
+	return nil
+}
+
+func serializeTargetCalls(sink *common.ZeroCopySink, calls []*TargetCall) {
+	sink.WriteVarUint(uint64(len(calls)))
+	for _, v := range calls {
+		v.Serialization(sink)
+	}
+}
+
+func deserializeTargetCalls(source *common.ZeroCopySource) ([]*TargetCall, error) {
+	n, eof := source.NextVarUint()
+	if eof {
+		return nil, fmt.Errorf("deserialize target calls length error")
+	}
+	calls := make([]*TargetCall, 0, n)
+	for i := uint64(0); i < n; i++ {
+		call := new(TargetCall)
+		if err := call.Deserialization(source); err != nil {
+			return nil, err
+		}
+		calls = append(calls, call)
+	}
+	return calls, nil
+}
+
+// CheckTargetCall rejects a call to contract and method matching the deny list, or
+// missing from a non empty allow list of the side chain.
+func (this *SideChain) CheckTargetCall(contract []byte, method string) error {
+	for _, v := range this.DeniedCalls {
+		if v.Match(contract, method) {
+			return fmt.Errorf("call to %x method %s is denied on chain %d", contract, method, this.ChainId)
+		}
+	}
+	if len(this.AllowedCalls) == 0 {
+		return nil
+	}
+	for _, v := range this.AllowedCalls {
+		if v.Match(contract, method) {
+			return nil
+		}
+	}
+	return fmt.Errorf("call to %x method %s is not allowed on chain %d", contract, method, this.ChainId)
 }
 
 func (this *SideChain) Serialization(sink *common.ZeroCopySink) error {
@@ -46,6 +121,11 @@ func (this *SideChain) Serialization(sink *common.ZeroCopySink) error {
 	height := config.GetExtraInfoHeight(config.DefConfig.P2PNode.NetworkId)
 	if !config.EXTRA_INFO_HEIGHT_FORK_CHECK || ledger.DefLedger.GetCurrentBlockHeight() >= height {
 		sink.WriteVarBytes(this.ExtraInfo)
+		// call filters are only written once set, so records without them keep their encoding
+		if len(this.AllowedCalls) != 0 || len(this.DeniedCalls) != 0 {
+			serializeTargetCalls(sink, this.AllowedCalls)
+			serializeTargetCalls(sink, this.DeniedCalls)
+		}
 	}
 	return nil
 }
@@ -80,6 +160,17 @@ func (this *SideChain) Deserialization(source *common.ZeroCopySource) error {
 		return fmt.Errorf("source.NextVarBytes, deserialize CCMCAddress error")
 	}
 	ExtraInfo, _ := source.NextVarBytes()
+	var allowedCalls, deniedCalls []*TargetCall
+	if source.Len() != 0 {
+		allowedCalls, err = deserializeTargetCalls(source)
+		if err != nil {
+			return fmt.Errorf("deserialize allowedCalls error: %s", err)
+		}
+		deniedCalls, err = deserializeTargetCalls(source)
+		if err != nil {
+			return fmt.Errorf("deserialize deniedCalls error: %s", err)
+		}
+	}
 
 	this.Address = addr
 	this.ChainId = chainId
@@ -88,6 +179,8 @@ func (this *SideChain) Deserialization(source *common.ZeroCopySource) error {
 	this.BlocksToWait = blocksToWait
 	this.CCMCAddress = CCMCAddress
 	this.ExtraInfo = ExtraInfo
+	this.AllowedCalls = allowedCalls
+	this.DeniedCalls = deniedCalls
 	return nil
 }
 
@@ -38,3 +38,37 @@ func TestSideChain_Serialization(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, paramDeserialize, paramSerialize)
 }
+
+func TestSideChain_CallFilterSerialization(t *testing.T) {
+	paramSerialize := new(SideChain)
+	paramSerialize.Name = "own"
+	paramSerialize.ChainId = 8
+	paramSerialize.ExtraInfo = []byte{1}
+	paramSerialize.AllowedCalls = []*TargetCall{{Contract: []byte{1, 2}, Method: "unlock"}}
+	paramSerialize.DeniedCalls = []*TargetCall{}
+	sink := common.NewZeroCopySink(nil)
+	err := paramSerialize.Serialization(sink)
+	assert.Nil(t, err)
+
+	paramDeserialize := new(SideChain)
+	err = paramDeserialize.Deserialization(common.NewZeroCopySource(sink.Bytes()))
+	assert.Nil(t, err)
+	assert.Equal(t, paramSerialize.AllowedCalls, paramDeserialize.AllowedCalls)
+	assert.Equal(t, 0, len(paramDeserialize.DeniedCalls))
+}
+
+func TestSideChain_CheckTargetCall(t *testing.T) {
+	sideChain := &SideChain{ChainId: 2}
+	assert.Nil(t, sideChain.CheckTargetCall([]byte{1}, "unlock"))
+
+	sideChain.DeniedCalls = []*TargetCall{{Contract: []byte{1}, Method: "putCurEpochConPubKeyBytes"}, {Contract: []byte{3}}}
+	assert.Nil(t, sideChain.CheckTargetCall([]byte{1}, "unlock"))
+	assert.NotNil(t, sideChain.CheckTargetCall([]byte{1}, "putCurEpochConPubKeyBytes"))
+	assert.NotNil(t, sideChain.CheckTargetCall([]byte{3}, "unlock"))
+
+	sideChain.AllowedCalls = []*TargetCall{{Contract: []byte{1}, Method: "unlock"}, {Contract: []byte{2}}}
+	assert.Nil(t, sideChain.CheckTargetCall([]byte{1}, "unlock"))
+	assert.Nil(t, sideChain.CheckTargetCall([]byte{2}, "anything"))
+	assert.NotNil(t, sideChain.CheckTargetCall([]byte{1}, "lock"))
+	assert.NotNil(t, sideChain.CheckTargetCall([]byte{4}, "unlock"))
+}
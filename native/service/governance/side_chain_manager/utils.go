@@ -18,6 +18,7 @@
 package side_chain_manager
 
 import (
+	"encoding/hex"
 	"fmt"
 
 	"github.com/btcsuite/btcd/btcec"
@@ -28,6 +29,7 @@ import (
 	cstates "github.com/polynetwork/poly/core/states"
 	"github.com/polynetwork/poly/native"
 	"github.com/polynetwork/poly/native/service/utils"
+	"github.com/polynetwork/poly/native/service/utils/taproot"
 )
 
 var netParam = &chaincfg.TestNet3Params
@@ -279,7 +281,7 @@ func GetBtcTxParam(native *native.NativeService, redeemKey []byte, redeemChainId
 	return nil, nil
 }
 
-func verifyRedeemRegister(param *RegisterRedeemParam, addrs []btcutil.Address) (map[string][]byte, error) {
+func verifyRedeemRegister(param *RegisterRedeemParam, signers []*redeemSigner) (map[string][]byte, error) {
 	r := make([]byte, len(param.Redeem))
 	copy(r, param.Redeem)
 	cverBytes := utils.GetUint64Bytes(param.CVersion)
@@ -287,10 +289,10 @@ func verifyRedeemRegister(param *RegisterRedeemParam, addrs []btcutil.Address) (
 	toChainId := utils.GetUint64Bytes(param.ContractChainID)
 	hash := btcutil.Hash160(append(append(append(append(r, fromChainId...), param.ContractAddress...),
 		toChainId...), cverBytes...))
-	return verify(param.Signs, addrs, hash)
+	return verify(param.Signs, signers, hash)
 }
 
-func verifyBtcTxParam(param *BtcTxParam, addrs []btcutil.Address) (map[string][]byte, error) {
+func verifyBtcTxParam(param *BtcTxParam, signers []*redeemSigner) (map[string][]byte, error) {
 	r := make([]byte, len(param.Redeem))
 	copy(r, param.Redeem)
 	fromChainId := utils.GetUint64Bytes(param.RedeemChainId)
@@ -298,22 +300,77 @@ func verifyBtcTxParam(param *BtcTxParam, addrs []btcutil.Address) (map[string][]
 	mcBytes := utils.GetUint64Bytes(param.Detial.MinChange)
 	verBytes := utils.GetUint64Bytes(param.Detial.PVersion)
 	hash := btcutil.Hash160(append(append(append(append(r, fromChainId...), frBytes...), mcBytes...), verBytes...))
-	return verify(param.Sigs, addrs, hash)
+	return verify(param.Sigs, signers, hash)
 }
 
-func verify(sigs [][]byte, addrs []btcutil.Address, hash []byte) (map[string][]byte, error) {
+// redeemSigner is a key of a redeem script, id is the encoded address for legacy
+// multisig scripts and the hex x-only key for taproot multisig leaves
+type redeemSigner struct {
+	id     string
+	verify func(sig, hash []byte) (bool, error)
+}
+
+// getRedeemSigners returns the signers of a legacy multisig redeem script or of a
+// taproot multisig leaf and the number of signatures required. Signatures of taproot
+// signers are BIP340 schnorr signatures.
+func getRedeemSigners(redeem []byte) ([]*redeemSigner, int, error) {
+	if taproot.IsMultisigLeaf(redeem) {
+		keys, m, err := taproot.ParseMultisigLeaf(redeem)
+		if err != nil {
+			return nil, 0, err
+		}
+		signers := make([]*redeemSigner, len(keys))
+		for i, k := range keys {
+			key := k
+			signers[i] = &redeemSigner{
+				id: hex.EncodeToString(key),
+				verify: func(sig, hash []byte) (bool, error) {
+					if len(sig) != taproot.SIG_SIZE {
+						return false, fmt.Errorf("wrong length of schnorr sig: %d", len(sig))
+					}
+					return taproot.VerifySchnorr(key, hash, sig), nil
+				},
+			}
+		}
+		return signers, m, nil
+	}
+	ty, addrs, m, err := txscript.ExtractPkScriptAddrs(redeem, netParam)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to extract addrs: %v", err)
+	}
+	if ty != txscript.MultiSigTy {
+		return nil, 0, fmt.Errorf("wrong type of redeem: %s", ty.String())
+	}
+	signers := make([]*redeemSigner, len(addrs))
+	for i, addr := range addrs {
+		pub := addr.(*btcutil.AddressPubKey).PubKey()
+		signers[i] = &redeemSigner{
+			id: addr.EncodeAddress(),
+			verify: func(sig, hash []byte) (bool, error) {
+				pSig, err := btcec.ParseDERSignature(sig, btcec.S256())
+				if err != nil {
+					return false, err
+				}
+				return pSig.Verify(hash, pub), nil
+			},
+		}
+	}
+	return signers, m, nil
+}
+
+func verify(sigs [][]byte, signers []*redeemSigner, hash []byte) (map[string][]byte, error) {
 	res := make(map[string][]byte)
 	for i, sig := range sigs {
 		if len(sig) < 1 {
 			return nil, fmt.Errorf("length of no.%d sig is less than 1", i)
 		}
-		pSig, err := btcec.ParseDERSignature(sig, btcec.S256())
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse no.%d sig: %v", i, err)
-		}
-		for _, addr := range addrs {
-			if pSig.Verify(hash, addr.(*btcutil.AddressPubKey).PubKey()) {
-				res[addr.EncodeAddress()] = sig
+		for _, signer := range signers {
+			ok, err := signer.verify(sig, hash)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse no.%d sig: %v", i, err)
+			}
+			if ok {
+				res[signer.id] = sig
 			}
 		}
 	}
@@ -327,14 +384,39 @@ func putBtcRedeemScript(native *native.NativeService, redeemScriptKey string, re
 	chainIDBytes := utils.GetUint64Bytes(redeemChainId)
 	key := utils.ConcatKey(utils.SideChainManagerContractAddress, []byte(REDEEM_SCRIPT), chainIDBytes, []byte(redeemScriptKey))
 
-	cls := txscript.GetScriptClass(redeemScriptBytes)
-	if cls.String() != "multisig" {
+	if taproot.IsMultisigLeaf(redeemScriptBytes) {
+		outputKey, _, err := taproot.OutputKey(redeemScriptBytes)
+		if err != nil {
+			return fmt.Errorf("putBtcRedeemScript, failed to get taproot output key: %v", err)
+		}
+		native.GetCacheDB().Put(utils.ConcatKey(utils.SideChainManagerContractAddress, []byte(TAPROOT_OUTPUT_KEY),
+			chainIDBytes, outputKey), cstates.GenRawStorageItem([]byte(redeemScriptKey)))
+	} else if cls := txscript.GetScriptClass(redeemScriptBytes); cls.String() != "multisig" {
 		return fmt.Errorf("putBtcRedeemScript, wrong type of redeem: %s", cls)
 	}
 	native.GetCacheDB().Put(key, cstates.GenRawStorageItem(redeemScriptBytes))
 	return nil
 }
 
+// GetTaprootRedeemKey returns the redeem key of the taproot multisig leaf locked by
+// the output key, or an empty string if no such leaf is registered
+func GetTaprootRedeemKey(native *native.NativeService, outputKey []byte, redeemChainId uint64) (string, error) {
+	chainIDBytes := utils.GetUint64Bytes(redeemChainId)
+	store, err := native.GetCacheDB().Get(utils.ConcatKey(utils.SideChainManagerContractAddress, []byte(TAPROOT_OUTPUT_KEY),
+		chainIDBytes, outputKey))
+	if err != nil {
+		return "", fmt.Errorf("GetTaprootRedeemKey, get redeem key error: %v", err)
+	}
+	if store == nil {
+		return "", nil
+	}
+	rk, err := cstates.GetValueFromRawStorageItem(store)
+	if err != nil {
+		return "", fmt.Errorf("GetTaprootRedeemKey, deserialize from raw storage item err:%v", err)
+	}
+	return string(rk), nil
+}
+
 func GetBtcRedeemScriptBytes(native *native.NativeService, redeemScriptKey string, redeemChainId uint64) ([]byte, error) {
 	chainIDBytes := utils.GetUint64Bytes(redeemChainId)
 	key := utils.ConcatKey(utils.SideChainManagerContractAddress, []byte(REDEEM_SCRIPT), chainIDBytes, []byte(redeemScriptKey))
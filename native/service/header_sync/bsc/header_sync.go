@@ -25,6 +25,7 @@ import (
 	"time"
 
 	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/params"
@@ -36,6 +37,7 @@ import (
 	"github.com/polynetwork/poly/native/service/governance/node_manager"
 	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
 	scom "github.com/polynetwork/poly/native/service/header_sync/common"
+	"github.com/polynetwork/poly/native/service/header_sync/common/bls"
 	"github.com/polynetwork/poly/native/service/utils"
 	"golang.org/x/crypto/sha3"
 )
@@ -50,6 +52,9 @@ func NewHandler() *Handler {
 }
 
 // GenesisHeader ...
+//
+// From Luban on PrevValidators need VoteAddresses to verify the vote
+// attestations of the blocks signed by them.
 type GenesisHeader struct {
 	Header         types.Header
 	PrevValidators []HeightAndValidators
@@ -88,9 +93,25 @@ func (h *Handler) SyncGenesisHeader(native *native.NativeService) (err error) {
 		return fmt.Errorf("bsc Handler SyncGenesisHeader, deserialize GenesisHeader err: %v", err)
 	}
 
-	signersBytes := len(genesis.Header.Extra) - extraVanity - extraSeal
-	if signersBytes == 0 || signersBytes%ecommon.AddressLength != 0 {
-		return fmt.Errorf("invalid signer list, signersBytes:%d", signersBytes)
+	var extraInfo ExtraInfo
+	side, err := side_chain_manager.GetSideChain(native, params.ChainID)
+	if err != nil {
+		return fmt.Errorf("bsc Handler SyncGenesisHeader, GetSideChain error: %v", err)
+	}
+	if side != nil && len(side.ExtraInfo) > 0 {
+		err = json.Unmarshal(side.ExtraInfo, &extraInfo)
+		if err != nil {
+			return fmt.Errorf("bsc Handler SyncGenesisHeader, ExtraInfo Unmarshal error: %v", err)
+		}
+	}
+	ctx := &Context{ExtraInfo: extraInfo, ChainID: params.ChainID}
+
+	if len(genesis.Header.Extra) < extraVanity+extraSeal {
+		return fmt.Errorf("invalid signer list, extra length:%d", len(genesis.Header.Extra))
+	}
+	validators, voteAddrs, attestationBytes, err := parseExtra(&genesis.Header, ctx)
+	if err != nil || len(validators) == 0 {
+		return fmt.Errorf("invalid signer list, err:%v", err)
 	}
 
 	if len(genesis.PrevValidators) != 1 {
@@ -99,15 +120,21 @@ func (h *Handler) SyncGenesisHeader(native *native.NativeService) (err error) {
 	if genesis.Header.Number.Cmp(genesis.PrevValidators[0].Height) <= 0 {
 		return fmt.Errorf("invalid height orders")
 	}
-	validators, err := ParseValidators(genesis.Header.Extra[extraVanity : extraVanity+signersBytes])
-	if err != nil {
-		return
-	}
 	genesis.PrevValidators = append([]HeightAndValidators{
-		{Height: genesis.Header.Number, Validators: validators},
+		{Height: genesis.Header.Number, Validators: validators, VoteAddresses: voteAddrs},
 	}, genesis.PrevValidators...)
 
-	err = storeGenesis(native, params, &genesis)
+	// votes carried by the genesis are trusted as the genesis itself
+	headerWithSum := &HeaderWithDifficultySum{Header: &genesis.Header, DifficultySum: genesis.Header.Difficulty}
+	if len(attestationBytes) > 0 {
+		attestation, err := decodeVoteAttestation(attestationBytes)
+		if err != nil {
+			return fmt.Errorf("bsc Handler SyncGenesisHeader, %v", err)
+		}
+		headerWithSum.Justified, headerWithSum.Finalized = voteRefs(attestation, nil)
+	}
+
+	err = storeGenesis(native, params, &genesis, headerWithSum)
 	if err != nil {
 		return fmt.Errorf("bsc Handler SyncGenesisHeader, storeGenesis error: %v", err)
 	}
@@ -155,7 +182,7 @@ func getGenesis(native *native.NativeService, chainID uint64) (genesisHeader *Ge
 	return
 }
 
-func storeGenesis(native *native.NativeService, params *scom.SyncGenesisHeaderParam, genesisHeader *GenesisHeader) (err error) {
+func storeGenesis(native *native.NativeService, params *scom.SyncGenesisHeaderParam, genesisHeader *GenesisHeader, headerWithSum *HeaderWithDifficultySum) (err error) {
 
 	genesisBytes, err := json.Marshal(genesisHeader)
 	if err != nil {
@@ -166,8 +193,6 @@ func storeGenesis(native *native.NativeService, params *scom.SyncGenesisHeaderPa
 		utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.GENESIS_HEADER), utils.GetUint64Bytes(params.ChainID)),
 		cstates.GenRawStorageItem(genesisBytes))
 
-	headerWithSum := &HeaderWithDifficultySum{Header: &genesisHeader.Header, DifficultySum: genesisHeader.Header.Difficulty}
-
 	err = putHeaderWithSum(native, params.ChainID, headerWithSum)
 	if err != nil {
 		return
@@ -182,7 +207,13 @@ func storeGenesis(native *native.NativeService, params *scom.SyncGenesisHeaderPa
 
 // ExtraInfo ...
 type ExtraInfo struct {
-	ChainID *big.Int // for bsc
+	ChainID     *big.Int // for bsc
+	LubanHeight *big.Int // checkpoints list BLS vote addresses and blocks carry vote attestations from Luban on
+	Epoch       uint64   // blocks between checkpoints, needed from Luban on
+}
+
+func (this *ExtraInfo) isLuban(number *big.Int) bool {
+	return this.LubanHeight != nil && number.Cmp(this.LubanHeight) >= 0
 }
 
 // Context ...
@@ -202,38 +233,41 @@ type HeaderWithDifficultySum struct {
 	Header          *types.Header `json:"header"`
 	DifficultySum   *big.Int      `json:"difficultySum"`
 	EpochParentHash *ecommon.Hash `json:"epochParentHash"`
+	Justified       *BlockRef     `json:"justified,omitempty"`
+	Finalized       *BlockRef     `json:"finalized,omitempty"`
 }
 
 // SyncBlockHeader ...
-func (h *Handler) SyncBlockHeader(native *native.NativeService) error {
+func (h *Handler) SyncBlockHeader(native *native.NativeService) (uint64, error) {
 	headerParams := new(scom.SyncBlockHeaderParam)
 	if err := headerParams.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
-		return fmt.Errorf("bsc Handler SyncBlockHeader, contract params deserialize error: %v", err)
+		return 0, fmt.Errorf("bsc Handler SyncBlockHeader, contract params deserialize error: %v", err)
 	}
 
 	side, err := side_chain_manager.GetSideChain(native, headerParams.ChainID)
 	if err != nil {
-		return fmt.Errorf("bsc Handler SyncBlockHeader, GetSideChain error: %v", err)
+		return 0, fmt.Errorf("bsc Handler SyncBlockHeader, GetSideChain error: %v", err)
 	}
 	var extraInfo ExtraInfo
 	err = json.Unmarshal(side.ExtraInfo, &extraInfo)
 	if err != nil {
-		return fmt.Errorf("bsc Handler SyncBlockHeader, ExtraInfo Unmarshal error: %v", err)
+		return 0, fmt.Errorf("bsc Handler SyncBlockHeader, ExtraInfo Unmarshal error: %v", err)
 	}
 
 	ctx := &Context{ExtraInfo: extraInfo, ChainID: headerParams.ChainID}
 
+	var stored uint64
 	for _, v := range headerParams.Headers {
 		var header types.Header
 		err := json.Unmarshal(v, &header)
 		if err != nil {
-			return fmt.Errorf("bsc Handler SyncBlockHeader, deserialize header err: %v", err)
+			return 0, fmt.Errorf("bsc Handler SyncBlockHeader, deserialize header err: %v", err)
 		}
 		headerHash := header.Hash()
 
 		exist, err := isHeaderExist(native, headerHash, ctx)
 		if err != nil {
-			return fmt.Errorf("bsc Handler SyncBlockHeader, isHeaderExist headerHash err: %v", err)
+			return 0, fmt.Errorf("bsc Handler SyncBlockHeader, isHeaderExist headerHash err: %v", err)
 		}
 		if exist {
 			log.Warnf("bsc Handler SyncBlockHeader, header has exist. Header: %s", string(v))
@@ -242,7 +276,7 @@ func (h *Handler) SyncBlockHeader(native *native.NativeService) error {
 
 		parentExist, err := isHeaderExist(native, header.ParentHash, ctx)
 		if err != nil {
-			return fmt.Errorf("bsc Handler SyncBlockHeader, isHeaderExist ParentHash err: %v", err)
+			return 0, fmt.Errorf("bsc Handler SyncBlockHeader, isHeaderExist ParentHash err: %v", err)
 		}
 		if !parentExist {
 			log.Warnf("bsc Handler SyncBlockHeader, parent header not exist. Header: %s", string(v))
@@ -251,13 +285,13 @@ func (h *Handler) SyncBlockHeader(native *native.NativeService) error {
 
 		signer, err := verifySignature(native, &header, ctx)
 		if err != nil {
-			return fmt.Errorf("bsc Handler SyncBlockHeader, verifySignature err: %v", err)
+			return 0, fmt.Errorf("bsc Handler SyncBlockHeader, verifySignature err: %v", err)
 		}
 
 		// get prev epochs, also checking recent limit
 		phv, pphv, lastSeenHeight, err := getPrevHeightAndValidators(native, &header, ctx)
 		if err != nil {
-			return fmt.Errorf("bsc Handler SyncBlockHeader, getPrevHeightAndValidators err: %v", err)
+			return 0, fmt.Errorf("bsc Handler SyncBlockHeader, getPrevHeightAndValidators err: %v", err)
 		}
 
 		var (
@@ -276,13 +310,13 @@ func (h *Handler) SyncBlockHeader(native *native.NativeService) error {
 		if lastSeenHeight > 0 {
 			limit := int64(len(inTurnHV.Validators) / 2)
 			if header.Number.Int64() <= lastSeenHeight+limit {
-				return fmt.Errorf("bsc Handler SyncBlockHeader, RecentlySigned, lastSeenHeight:%d currentHeight:%d #V:%d", lastSeenHeight, header.Number.Int64(), len(inTurnHV.Validators))
+				return 0, fmt.Errorf("bsc Handler SyncBlockHeader, RecentlySigned, lastSeenHeight:%d currentHeight:%d #V:%d", lastSeenHeight, header.Number.Int64(), len(inTurnHV.Validators))
 			}
 		}
 
 		indexInTurn := int(header.Number.Uint64()) % len(inTurnHV.Validators)
 		if indexInTurn < 0 {
-			return fmt.Errorf("indexInTurn is negative:%d inTurnHV.Height:%d header.Number:%d", indexInTurn, inTurnHV.Height.Int64(), header.Number.Int64())
+			return 0, fmt.Errorf("indexInTurn is negative:%d inTurnHV.Height:%d header.Number:%d", indexInTurn, inTurnHV.Height.Int64(), header.Number.Int64())
 		}
 		valid := false
 		for idx, v := range inTurnHV.Validators {
@@ -290,27 +324,33 @@ func (h *Handler) SyncBlockHeader(native *native.NativeService) error {
 				valid = true
 				if indexInTurn == idx {
 					if header.Difficulty.Cmp(diffInTurn) != 0 {
-						return fmt.Errorf("invalid difficulty, got %v expect %v index:%v", header.Difficulty.Int64(), diffInTurn.Int64(), int(indexInTurn)%len(inTurnHV.Validators))
+						return 0, fmt.Errorf("invalid difficulty, got %v expect %v index:%v", header.Difficulty.Int64(), diffInTurn.Int64(), int(indexInTurn)%len(inTurnHV.Validators))
 					}
 				} else {
 					if header.Difficulty.Cmp(diffNoTurn) != 0 {
-						return fmt.Errorf("invalid difficulty, got %v expect %v index:%v", header.Difficulty.Int64(), diffNoTurn.Int64(), int(indexInTurn)%len(inTurnHV.Validators))
+						return 0, fmt.Errorf("invalid difficulty, got %v expect %v index:%v", header.Difficulty.Int64(), diffNoTurn.Int64(), int(indexInTurn)%len(inTurnHV.Validators))
 					}
 				}
 			}
 		}
 		if !valid {
-			return fmt.Errorf("bsc Handler SyncBlockHeader, invalid signer")
+			return 0, fmt.Errorf("bsc Handler SyncBlockHeader, invalid signer")
+		}
+
+		justified, finalized, err := verifyVoteAttestation(native, &header, phv, pphv, ctx)
+		if err != nil {
+			return 0, fmt.Errorf("bsc Handler SyncBlockHeader, verifyVoteAttestation err: %v", err)
 		}
 
-		err = addHeader(native, &header, phv, ctx)
+		err = addHeader(native, &header, phv, justified, finalized, ctx)
 		if err != nil {
-			return fmt.Errorf("bsc Handler SyncBlockHeader, addHeader err: %v", err)
+			return 0, fmt.Errorf("bsc Handler SyncBlockHeader, addHeader err: %v", err)
 		}
 
 		scom.NotifyPutHeader(native, headerParams.ChainID, header.Number.Uint64(), header.Hash().Hex())
+		stored++
 	}
-	return nil
+	return stored, nil
 }
 
 func isHeaderExist(native *native.NativeService, headerHash ecommon.Hash, ctx *Context) (bool, error) {
@@ -346,6 +386,20 @@ func GetCanonicalHeight(native *native.NativeService, chainID uint64) (height ui
 	return
 }
 
+// GetFinalizedHeight returns the height finalized by fast finality votes on the
+// canonical chain, ok is false if no vote has been seen
+func GetFinalizedHeight(native *native.NativeService, chainID uint64) (height uint64, ok bool, err error) {
+	cheight, err := GetCanonicalHeight(native, chainID)
+	if err != nil {
+		return
+	}
+	cheader, err := GetCanonicalHeader(native, chainID, cheight)
+	if err != nil || cheader == nil || cheader.Finalized == nil {
+		return
+	}
+	return cheader.Finalized.Number, true, nil
+}
+
 // GetCanonicalHeader ...
 func GetCanonicalHeader(native *native.NativeService, chainID uint64, height uint64) (headerWithSum *HeaderWithDifficultySum, err error) {
 	hash, err := getCanonicalHash(native, chainID, height)
@@ -409,7 +463,7 @@ func putCanonicalHeight(native *native.NativeService, chainID uint64, height uin
 		cstates.GenRawStorageItem(utils.GetUint64Bytes(uint64(height))))
 }
 
-func addHeader(native *native.NativeService, header *types.Header, phv *HeightAndValidators, ctx *Context) (err error) {
+func addHeader(native *native.NativeService, header *types.Header, phv *HeightAndValidators, justified, finalized *BlockRef, ctx *Context) (err error) {
 
 	parentHeader, err := getHeader(native, header.ParentHash, ctx.ChainID)
 	if err != nil {
@@ -432,13 +486,20 @@ func addHeader(native *native.NativeService, header *types.Header, phv *HeightAn
 	localTd := cheader.DifficultySum
 	externTd := new(big.Int).Add(header.Difficulty, parentHeader.DifficultySum)
 
-	headerWithSum := &HeaderWithDifficultySum{Header: header, DifficultySum: externTd, EpochParentHash: phv.Hash}
+	headerWithSum := &HeaderWithDifficultySum{Header: header, DifficultySum: externTd, EpochParentHash: phv.Hash,
+		Justified: justified, Finalized: finalized}
 	err = putHeaderWithSum(native, ctx.ChainID, headerWithSum)
 	if err != nil {
 		return
 	}
 
-	if externTd.Cmp(localTd) > 0 {
+	// fast finality prefers the chain with the higher justified block
+	reorg := externTd.Cmp(localTd) > 0
+	if localJustified, externJustified := cheader.Justified.number(), justified.number(); localJustified != externJustified {
+		reorg = externJustified > localJustified
+	}
+
+	if reorg {
 		// Delete any canonical number assignments above the new head
 		var headerWithSum *HeaderWithDifficultySum
 		for i := header.Number.Uint64() + 1; ; i++ {
@@ -469,6 +530,10 @@ func addHeader(native *native.NativeService, header *types.Header, phv *HeightAn
 			if hash == headHash {
 				break
 			}
+			if cheader.Finalized != nil && cheight <= cheader.Finalized.Number {
+				err = fmt.Errorf("reorg below finalized height %d", cheader.Finalized.Number)
+				return
+			}
 
 			putCanonicalHash(native, ctx.ChainID, cheight, headHash)
 			headHeader, err = getHeader(native, headHash, ctx.ChainID)
@@ -479,6 +544,19 @@ func addHeader(native *native.NativeService, header *types.Header, phv *HeightAn
 			cheight--
 		}
 
+		// Record the replaced canonical headers, cheight is the fork height
+		err = scom.RecordReorg(native, &scom.ReorgInfo{
+			ChainID:    ctx.ChainID,
+			OldTip:     cheader.Header.Hash().Bytes(),
+			OldHeight:  cheader.Header.Number.Uint64(),
+			NewTip:     header.Hash().Bytes(),
+			NewHeight:  header.Number.Uint64(),
+			ForkHeight: cheight,
+		})
+		if err != nil {
+			return
+		}
+
 		// Extend the canonical chain with the new header
 		putCanonicalHash(native, ctx.ChainID, header.Number.Uint64(), header.Hash())
 		putCanonicalHeight(native, ctx.ChainID, header.Number.Uint64())
@@ -489,9 +567,10 @@ func addHeader(native *native.NativeService, header *types.Header, phv *HeightAn
 
 // HeightAndValidators ...
 type HeightAndValidators struct {
-	Height     *big.Int
-	Validators []ecommon.Address
-	Hash       *ecommon.Hash
+	Height        *big.Int
+	Validators    []ecommon.Address
+	Hash          *ecommon.Hash
+	VoteAddresses []hexutil.Bytes `json:",omitempty"` // BLS public keys paired with Validators from Luban on
 }
 
 func getPrevHeightAndValidators(native *native.NativeService, header *types.Header, ctx *Context) (phv, pphv *HeightAndValidators, lastSeenHeight int64, err error) {
@@ -565,6 +644,7 @@ func getPrevHeightAndValidators(native *native.NativeService, header *types.Head
 
 	var (
 		validators     []ecommon.Address
+		voteAddrs      []hexutil.Bytes
 		nextParentHash ecommon.Hash
 	)
 
@@ -572,15 +652,16 @@ func getPrevHeightAndValidators(native *native.NativeService, header *types.Head
 
 	for {
 
-		if len(prevHeaderWithSum.Header.Extra) > extraVanity+extraSeal {
-			validators, err = ParseValidators(prevHeaderWithSum.Header.Extra[extraVanity : len(prevHeaderWithSum.Header.Extra)-extraSeal])
-			if err != nil {
-				err = fmt.Errorf("bsc Handler ParseValidators error: %v", err)
-				return
-			}
+		validators, voteAddrs, _, err = parseExtra(prevHeaderWithSum.Header, ctx)
+		if err != nil {
+			err = fmt.Errorf("bsc Handler ParseValidators error: %v", err)
+			return
+		}
+		if len(validators) > 0 {
 			*currentPV = &HeightAndValidators{
-				Height:     prevHeaderWithSum.Header.Number,
-				Validators: validators,
+				Height:        prevHeaderWithSum.Header.Number,
+				Validators:    validators,
+				VoteAddresses: voteAddrs,
 			}
 			switch *currentPV {
 			case phv:
@@ -655,6 +736,8 @@ var (
 	diffInTurn      = big.NewInt(2)            // Block difficulty for in-turn signatures
 	diffNoTurn      = big.NewInt(1)            // Block difficulty for out-of-turn signatures
 
+	validatorBytesLength = ecommon.AddressLength + bls.PubkeyCompressedLength // Validator address and BLS vote address of a Luban checkpoint
+
 	GasLimitBoundDivisor uint64 = 256 // The bound divisor of the gas limit, used in update calculations.
 )
 
@@ -677,10 +760,7 @@ func verifyHeader(native *native.NativeService, header *types.Header, ctx *Conte
 	}
 
 	// Ensure that the extra-data contains a signer list on checkpoint, but none otherwise
-	signersBytes := len(header.Extra) - extraVanity - extraSeal
-
-	if signersBytes%ecommon.AddressLength != 0 {
-		err = errors.New("invalid signer list")
+	if _, _, _, err = parseExtra(header, ctx); err != nil {
 		return
 	}
 
@@ -826,6 +906,47 @@ func encodeSigHeader(w io.Writer, header *types.Header, chainID *big.Int) {
 	}
 }
 
+// parseExtra returns the validators of a checkpoint with their vote addresses
+// from Luban on, and the rlp encoded vote attestation if any
+func parseExtra(header *types.Header, ctx *Context) (validators []ecommon.Address, voteAddrs []hexutil.Bytes, attestation []byte, err error) {
+	data := header.Extra[extraVanity : len(header.Extra)-extraSeal]
+	if !ctx.ExtraInfo.isLuban(header.Number) {
+		if len(data)%ecommon.AddressLength != 0 {
+			err = errors.New("invalid signer list")
+			return
+		}
+		if len(data) > 0 {
+			validators, err = ParseValidators(data)
+		}
+		return
+	}
+
+	if ctx.ExtraInfo.Epoch == 0 {
+		err = errors.New("epoch not set")
+		return
+	}
+	if header.Number.Uint64()%ctx.ExtraInfo.Epoch != 0 {
+		attestation = data
+		return
+	}
+	if len(data) == 0 {
+		err = errors.New("invalid validator list")
+		return
+	}
+	n := int(data[0])
+	if n == 0 || len(data) < 1+n*validatorBytesLength {
+		err = errors.New("invalid validator list")
+		return
+	}
+	for i := 0; i < n; i++ {
+		item := data[1+i*validatorBytesLength : 1+(i+1)*validatorBytesLength]
+		validators = append(validators, ecommon.BytesToAddress(item[:ecommon.AddressLength]))
+		voteAddrs = append(voteAddrs, append(hexutil.Bytes{}, item[ecommon.AddressLength:]...))
+	}
+	attestation = data[1+n*validatorBytesLength:]
+	return
+}
+
 // ParseValidators ...
 func ParseValidators(validatorsBytes []byte) ([]ecommon.Address, error) {
 	if len(validatorsBytes)%ecommon.AddressLength != 0 {
@@ -842,6 +963,30 @@ func ParseValidators(validatorsBytes []byte) ([]ecommon.Address, error) {
 }
 
 // SyncCrossChainMsg ...
-func (h *Handler) SyncCrossChainMsg(native *native.NativeService) error {
-	return nil
+func (h *Handler) SyncCrossChainMsg(native *native.NativeService) (uint64, error) {
+	return 0, nil
+}
+
+// GetHeaderRange ...
+func (h *Handler) GetHeaderRange(native *native.NativeService, chainID uint64) (genesisHeight, currentHeight uint64, err error) {
+	genesis, err := getGenesis(native, chainID)
+	if err != nil {
+		err = fmt.Errorf("bsc Handler GetHeaderRange, getGenesis error: %v", err)
+		return
+	}
+	if genesis == nil {
+		err = fmt.Errorf("bsc Handler GetHeaderRange, genesis not set")
+		return
+	}
+	currentHeight, err = GetCanonicalHeight(native, chainID)
+	if err != nil {
+		return
+	}
+	genesisHeight = genesis.Header.Number.Uint64()
+	return
+}
+
+// PruneHeader ...
+func (h *Handler) PruneHeader(native *native.NativeService, chainID uint64, height uint64) error {
+	return scom.PruneMainChainHeader(native, chainID, height)
 }
@@ -18,10 +18,12 @@ package bsc
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"math/big"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -165,20 +167,32 @@ func getHeaderByHash(native *native.NativeService, hash ethcommon.Hash) []byte {
 
 const BSCChainID = 2
 
-func getTool() *eth.ETHTools {
+var (
+	toolOnce sync.Once
+	toolErr  error
+)
+
+// getTool skips the test when the bsc test node can't be reached
+func getTool(t *testing.T) *eth.ETHTools {
 	tool := eth.NewEthTools("https://data-seed-prebsc-2-s3.binance.org:8545/")
+	toolOnce.Do(func() {
+		_, toolErr = tool.GetNodeHeight()
+	})
+	if toolErr != nil {
+		t.Skipf("bsc test node is unreachable: %v", toolErr)
+	}
 	return tool
 }
 
 func getBlockHeaderByHash(t *testing.T, hash ethcommon.Hash) *etypes.Header {
-	tool := getTool()
-	hdr, err := tool.GetBlockHeaderByHash(hash)
+	tool := getTool(t)
+	hdr, err := tool.GetEthClient().HeaderByHash(context.Background(), hash)
 	assert.NilError(t, err)
 	return hdr
 }
 
 func getBlockHeader(t *testing.T, height uint64) *etypes.Header {
-	tool := getTool()
+	tool := getTool(t)
 	hdr, err := tool.GetBlockHeader(height)
 	assert.NilError(t, err)
 	return hdr
@@ -187,7 +201,7 @@ func getBlockHeader(t *testing.T, height uint64) *etypes.Header {
 func getGenesisHeaderByHeight(t *testing.T, epochHeight uint64) *GenesisHeader {
 	pEpochHeight := epochHeight - 200
 
-	tool := getTool()
+	tool := getTool(t)
 	hdr, err := tool.GetBlockHeader(epochHeight)
 	assert.NilError(t, err)
 	phdr, err := tool.GetBlockHeader(pEpochHeight)
@@ -203,7 +217,7 @@ func getGenesisHeaderByHeight(t *testing.T, epochHeight uint64) *GenesisHeader {
 }
 
 func getGenesisHeader(t *testing.T) *GenesisHeader {
-	tool := getTool()
+	tool := getTool(t)
 	height, err := tool.GetNodeHeight()
 	if err != nil {
 		panic(err)
@@ -350,7 +364,7 @@ func TestSyncGenesisHeader_ParamError(t *testing.T) {
 }
 
 func untilGetBlockHeader(t *testing.T, height uint64) *etypes.Header {
-	tool := getTool()
+	tool := getTool(t)
 	for {
 		hdr, err := tool.GetBlockHeader(height)
 		if err == nil {
@@ -444,7 +458,7 @@ func TestSyncBlockHeader(t *testing.T) {
 		}
 
 		// fmt.Println("gHeight", height)
-		err := handler.SyncBlockHeader(native)
+		_, err := handler.SyncBlockHeader(native)
 		assert.Equal(t, SUCCESS, typeOfError(err), err)
 		latestHeight := getLatestHeight(native)
 		assert.Equal(t, latestHeight, height+4)
@@ -527,7 +541,7 @@ func TestSyncBlockHeaderForked(t *testing.T) {
 		}
 
 		// fmt.Println("gHeight", height)
-		err := handler.SyncBlockHeader(native)
+		_, err := handler.SyncBlockHeader(native)
 		assert.Equal(t, SUCCESS, typeOfError(err), err)
 		latestHeight := getLatestHeight(native)
 		assert.Equal(t, latestHeight, height+9)
@@ -558,7 +572,7 @@ func TestSyncBlockHeaderForked(t *testing.T) {
 		}
 		native, _ = NewNative(sink.Bytes(), tx, native.GetCacheDB())
 
-		err = handler.SyncBlockHeader(native)
+		_, err = handler.SyncBlockHeader(native)
 		assert.Equal(t, SUCCESS, typeOfError(err), err)
 		latestHeight := getLatestHeight(native)
 		assert.Equal(t, latestHeight, interestedHeight)
@@ -586,7 +600,7 @@ func TestSyncBlockHeaderForked(t *testing.T) {
 		}
 		native, _ = NewNative(sink.Bytes(), tx, native.GetCacheDB())
 
-		err = handler.SyncBlockHeader(native)
+		_, err = handler.SyncBlockHeader(native)
 		assert.Equal(t, SUCCESS, typeOfError(err), err)
 		latestHeight = getLatestHeight(native)
 		assert.Equal(t, latestHeight, interestedHeight)
@@ -613,7 +627,7 @@ func TestSyncBlockHeaderForked(t *testing.T) {
 		}
 		native, _ = NewNative(sink.Bytes(), tx, native.GetCacheDB())
 
-		err = handler.SyncBlockHeader(native)
+		_, err = handler.SyncBlockHeader(native)
 		assert.Equal(t, SUCCESS, typeOfError(err), err)
 		latestHeight = getLatestHeight(native)
 		assert.Equal(t, latestHeight, interestedHeight+1)
@@ -0,0 +1,190 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bsc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/bits"
+	"sort"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/header_sync/common/bls"
+)
+
+// VoteData is the source and target of a fast finality vote
+type VoteData struct {
+	SourceNumber uint64
+	SourceHash   ecommon.Hash
+	TargetNumber uint64
+	TargetHash   ecommon.Hash
+}
+
+// Hash is the message signed by the voters
+func (this *VoteData) Hash() ecommon.Hash {
+	raw, err := rlp.EncodeToBytes(this)
+	if err != nil {
+		panic("can't encode: " + err.Error())
+	}
+	return crypto.Keccak256Hash(raw)
+}
+
+// VoteAttestation aggregates the votes of a validator set, bit i of
+// VoteAddressSet is the i-th validator sorted by address
+type VoteAttestation struct {
+	VoteAddressSet uint64
+	AggSignature   [bls.SignatureCompressedLength]byte
+	Data           *VoteData
+	Extra          []byte
+}
+
+// BlockRef ...
+type BlockRef struct {
+	Number uint64       `json:"number"`
+	Hash   ecommon.Hash `json:"hash"`
+}
+
+func decodeVoteAttestation(raw []byte) (*VoteAttestation, error) {
+	attestation := new(VoteAttestation)
+	if err := rlp.DecodeBytes(raw, attestation); err != nil {
+		return nil, fmt.Errorf("decodeVoteAttestation, rlp decode error: %v", err)
+	}
+	if attestation.Data == nil {
+		return nil, errors.New("decodeVoteAttestation, no vote data")
+	}
+	return attestation, nil
+}
+
+// voteRefs returns the blocks justified and finalized by attestation, the
+// target is justified by the votes and the source is finalized once its
+// direct child is justified
+func voteRefs(attestation *VoteAttestation, finalized *BlockRef) (*BlockRef, *BlockRef) {
+	data := attestation.Data
+	justified := &BlockRef{Number: data.TargetNumber, Hash: data.TargetHash}
+	if data.TargetNumber == data.SourceNumber+1 {
+		finalized = &BlockRef{Number: data.SourceNumber, Hash: data.SourceHash}
+	}
+	return justified, finalized
+}
+
+// verifyVoteAttestation checks the votes carried by header, the target must be
+// the parent voted by 2/3 of the validators signing the parent, and the source
+// the latest justified block of the parent when it is known
+func verifyVoteAttestation(native *native.NativeService, header *types.Header, phv, pphv *HeightAndValidators, ctx *Context) (justified, finalized *BlockRef, err error) {
+	parent, err := getHeader(native, header.ParentHash, ctx.ChainID)
+	if err != nil {
+		return
+	}
+	justified, finalized = parent.Justified, parent.Finalized
+
+	_, _, raw, err := parseExtra(header, ctx)
+	if err != nil || len(raw) == 0 {
+		return
+	}
+	attestation, err := decodeVoteAttestation(raw)
+	if err != nil {
+		return
+	}
+	data := attestation.Data
+	if data.TargetNumber != parent.Header.Number.Uint64() || data.TargetHash != header.ParentHash {
+		err = fmt.Errorf("invalid attestation, target mismatch, expect %d %s got %d %s", parent.Header.Number.Uint64(), header.ParentHash.Hex(), data.TargetNumber, data.TargetHash.Hex())
+		return
+	}
+	if parent.Justified != nil {
+		if data.SourceNumber != parent.Justified.Number || data.SourceHash != parent.Justified.Hash {
+			err = fmt.Errorf("invalid attestation, source mismatch, expect %d %s got %d %s", parent.Justified.Number, parent.Justified.Hash.Hex(), data.SourceNumber, data.SourceHash.Hex())
+			return
+		}
+	} else if data.SourceNumber >= data.TargetNumber {
+		err = fmt.Errorf("invalid attestation, source %d is not below target %d", data.SourceNumber, data.TargetNumber)
+		return
+	}
+
+	// the validators signing the parent, a checkpoint is signed by the set before it
+	hv := phv
+	if phv.Height.Cmp(parent.Header.Number) == 0 || new(big.Int).Sub(parent.Header.Number, phv.Height).Int64() <= int64(len(pphv.Validators)/2) {
+		hv = pphv
+	}
+	voteAddrs, err := sortedVoteAddresses(hv)
+	if err != nil {
+		return
+	}
+	if attestation.VoteAddressSet>>uint(len(voteAddrs)) != 0 {
+		err = fmt.Errorf("invalid attestation, vote address set %x exceeds %d validators", attestation.VoteAddressSet, len(voteAddrs))
+		return
+	}
+	if count := bits.OnesCount64(attestation.VoteAddressSet); count < (len(voteAddrs)*2+2)/3 {
+		err = fmt.Errorf("invalid attestation, not enough votes %d of %d", count, len(voteAddrs))
+		return
+	}
+	var pubkeys [][]byte
+	for i, voteAddr := range voteAddrs {
+		if attestation.VoteAddressSet&(1<<uint(i)) == 0 {
+			continue
+		}
+		pubkey, e := bls.DecompressPubkey(voteAddr)
+		if e != nil {
+			err = fmt.Errorf("invalid vote address of validator %d: %v", i, e)
+			return
+		}
+		pubkeys = append(pubkeys, pubkey)
+	}
+	signature, err := bls.DecompressSignature(attestation.AggSignature[:])
+	if err != nil {
+		return
+	}
+	hash := data.Hash()
+	if err = bls.FastAggregateVerify(pubkeys, hash[:], signature); err != nil {
+		return
+	}
+
+	justified, finalized = voteRefs(attestation, parent.Finalized)
+	return
+}
+
+// sortedVoteAddresses returns the vote addresses in the order of the vote address set
+func sortedVoteAddresses(hv *HeightAndValidators) ([][]byte, error) {
+	if len(hv.VoteAddresses) != len(hv.Validators) {
+		return nil, fmt.Errorf("vote addresses of validators at %v not known", hv.Height)
+	}
+	index := make([]int, len(hv.Validators))
+	for i := range index {
+		index[i] = i
+	}
+	sort.Slice(index, func(i, j int) bool {
+		return bytes.Compare(hv.Validators[index[i]][:], hv.Validators[index[j]][:]) < 0
+	})
+	voteAddrs := make([][]byte, len(index))
+	for i, idx := range index {
+		voteAddrs[i] = hv.VoteAddresses[idx]
+	}
+	return voteAddrs, nil
+}
+
+func (this *BlockRef) number() uint64 {
+	if this == nil {
+		return 0
+	}
+	return this.Number
+}
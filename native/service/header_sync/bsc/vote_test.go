@@ -0,0 +1,255 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bsc
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"math/big"
+	"sort"
+	"testing"
+	"time"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	etypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/bls12381"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/core/types"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	scom "github.com/polynetwork/poly/native/service/header_sync/common"
+	"github.com/polynetwork/poly/native/service/header_sync/common/bls"
+	"github.com/polynetwork/poly/native/service/utils"
+	"github.com/polynetwork/poly/native/storage"
+	"gotest.tools/assert"
+)
+
+type voteValidator struct {
+	address  ethcommon.Address
+	key      *ecdsa.PrivateKey
+	blsKey   *big.Int
+	voteAddr []byte
+}
+
+type voteChain struct {
+	t          *testing.T
+	extraInfo  *ExtraInfo
+	validators []*voteValidator
+	db         *storage.CacheDB
+}
+
+func newVoteChain(t *testing.T) *voteChain {
+	chain := &voteChain{t: t, extraInfo: &ExtraInfo{ChainID: big.NewInt(56), LubanHeight: big.NewInt(0), Epoch: 10}}
+	g1 := bls12381.NewG1()
+	for i := int64(1); i <= 3; i++ {
+		key, _ := crypto.GenerateKey()
+		blsKey := big.NewInt(i * 104729)
+		voteAddr, err := bls.CompressPubkey(g1.ToBytes(g1.MulScalar(g1.New(), g1.One(), blsKey)))
+		assert.NilError(t, err)
+		chain.validators = append(chain.validators, &voteValidator{
+			address: crypto.PubkeyToAddress(key.PublicKey), key: key, blsKey: blsKey, voteAddr: voteAddr,
+		})
+	}
+	sort.Slice(chain.validators, func(i, j int) bool {
+		return bytes.Compare(chain.validators[i].address[:], chain.validators[j].address[:]) < 0
+	})
+
+	native, _ := NewNative(nil, &types.Transaction{}, nil)
+	extraInfo, _ := json.Marshal(chain.extraInfo)
+	assert.NilError(t, side_chain_manager.PutSideChain(native, &side_chain_manager.SideChain{
+		ChainId:   BSCChainID,
+		Router:    utils.BSC_ROUTER,
+		ExtraInfo: extraInfo,
+	}))
+	chain.db = native.GetCacheDB()
+	return chain
+}
+
+// attest aggregates the votes of the validators set in voters
+func (this *voteChain) attest(source, target *etypes.Header, voters uint64) []byte {
+	data := &VoteData{
+		SourceNumber: source.Number.Uint64(),
+		SourceHash:   source.Hash(),
+		TargetNumber: target.Number.Uint64(),
+		TargetHash:   target.Hash(),
+	}
+	hash := data.Hash()
+	h, err := bls.HashToG2(hash[:], bls.DST)
+	assert.NilError(this.t, err)
+	sum := new(big.Int)
+	for i, v := range this.validators {
+		if voters&(1<<uint(i)) != 0 {
+			sum.Add(sum, v.blsKey)
+		}
+	}
+	g2 := bls12381.NewG2()
+	sig, err := bls.CompressSignature(g2.ToBytes(g2.MulScalar(g2.New(), h, sum)))
+	assert.NilError(this.t, err)
+	attestation := &VoteAttestation{VoteAddressSet: voters, Data: data}
+	copy(attestation.AggSignature[:], sig)
+	raw, err := rlp.EncodeToBytes(attestation)
+	assert.NilError(this.t, err)
+	return raw
+}
+
+func (this *voteChain) makeHeader(parent *etypes.Header, signer *voteValidator, difficulty int64, attestation []byte) *etypes.Header {
+	header := &etypes.Header{
+		ParentHash: parent.Hash(),
+		UncleHash:  uncleHash,
+		Coinbase:   signer.address,
+		Number:     new(big.Int).Add(parent.Number, big.NewInt(1)),
+		Difficulty: big.NewInt(difficulty),
+		GasLimit:   parent.GasLimit,
+		Time:       parent.Time + 3,
+		Extra:      make([]byte, extraVanity),
+	}
+	if header.Number.Uint64()%this.extraInfo.Epoch == 0 {
+		header.Extra = append(header.Extra, byte(len(this.validators)))
+		for _, v := range this.validators {
+			header.Extra = append(append(header.Extra, v.address.Bytes()...), v.voteAddr...)
+		}
+	}
+	header.Extra = append(header.Extra, attestation...)
+	header.Extra = append(header.Extra, make([]byte, extraSeal)...)
+	sig, err := crypto.Sign(SealHash(header, this.extraInfo.ChainID).Bytes(), signer.key)
+	assert.NilError(this.t, err)
+	copy(header.Extra[len(header.Extra)-extraSeal:], sig)
+	return header
+}
+
+// next seals a child of parent by the validator in turn, or by the next one
+// if the validator in turn signed parent
+func (this *voteChain) next(parent *etypes.Header, attestation []byte) *etypes.Header {
+	n := uint64(len(this.validators))
+	number := parent.Number.Uint64() + 1
+	if signer := this.validators[number%n]; signer.address != parent.Coinbase {
+		return this.makeHeader(parent, signer, 2, attestation)
+	}
+	return this.makeHeader(parent, this.validators[(number+1)%n], 1, attestation)
+}
+
+func (this *voteChain) genesis() *etypes.Header {
+	header := &etypes.Header{
+		UncleHash:  uncleHash,
+		Number:     big.NewInt(10),
+		Difficulty: big.NewInt(2),
+		GasLimit:   30000000,
+		Time:       uint64(time.Now().Unix()) - 1000,
+		Extra:      append(make([]byte, extraVanity), byte(len(this.validators))),
+	}
+	prev := HeightAndValidators{Height: big.NewInt(0)}
+	for _, v := range this.validators {
+		header.Extra = append(append(header.Extra, v.address.Bytes()...), v.voteAddr...)
+		prev.Validators = append(prev.Validators, v.address)
+		prev.VoteAddresses = append(prev.VoteAddresses, v.voteAddr)
+	}
+	header.Extra = append(header.Extra, make([]byte, extraSeal)...)
+
+	raw, _ := json.Marshal(&GenesisHeader{Header: *header, PrevValidators: []HeightAndValidators{prev}})
+	param := &scom.SyncGenesisHeaderParam{ChainID: BSCChainID, GenesisHeader: raw}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	native, _ := NewNative(sink.Bytes(), &types.Transaction{SignedAddr: []common.Address{acct.Address}}, this.db)
+	assert.NilError(this.t, NewHandler().SyncGenesisHeader(native))
+	return header
+}
+
+func (this *voteChain) sync(headers ...*etypes.Header) error {
+	param := &scom.SyncBlockHeaderParam{ChainID: BSCChainID}
+	for _, header := range headers {
+		raw, _ := json.Marshal(header)
+		param.Headers = append(param.Headers, raw)
+	}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	native, _ := NewNative(sink.Bytes(), &types.Transaction{}, this.db)
+	_, err := NewHandler().SyncBlockHeader(native)
+	return err
+}
+
+func (this *voteChain) finalized() (uint64, bool) {
+	native, _ := NewNative(nil, &types.Transaction{}, this.db)
+	height, ok, err := GetFinalizedHeight(native, BSCChainID)
+	assert.NilError(this.t, err)
+	return height, ok
+}
+
+func (this *voteChain) head() ethcommon.Hash {
+	native, _ := NewNative(nil, &types.Transaction{}, this.db)
+	return getHeaderHashByHeight(native, getLatestHeight(native))
+}
+
+func TestSyncVoteAttestation(t *testing.T) {
+	chain := newVoteChain(t)
+	g := chain.genesis()
+	_, ok := chain.finalized()
+	assert.Assert(t, !ok)
+
+	h11 := chain.next(g, nil)
+	assert.NilError(t, chain.sync(h11))
+	// 2 of 3 votes justify 11 and finalize its parent
+	h12 := chain.next(h11, chain.attest(g, h11, 0x3))
+	assert.NilError(t, chain.sync(h12))
+	height, ok := chain.finalized()
+	assert.Assert(t, ok)
+	assert.Equal(t, uint64(10), height)
+
+	// the source must be the latest justified block
+	err := chain.sync(chain.next(h12, chain.attest(g, h12, 0x7)))
+	assert.ErrorContains(t, err, "source mismatch")
+	// the target must be the parent
+	err = chain.sync(chain.next(h12, chain.attest(h11, h11, 0x7)))
+	assert.ErrorContains(t, err, "target mismatch")
+	// a third of the votes is not enough
+	err = chain.sync(chain.next(h12, chain.attest(h11, h12, 0x4)))
+	assert.ErrorContains(t, err, "not enough votes")
+	err = chain.sync(chain.next(h12, chain.attest(h11, h12, 0x8|0x3)))
+	assert.ErrorContains(t, err, "exceeds")
+	// signatures must match the vote address set
+	attestation, err := decodeVoteAttestation(chain.attest(h11, h12, 0x3))
+	assert.NilError(t, err)
+	attestation.VoteAddressSet = 0x5
+	bad, _ := rlp.EncodeToBytes(attestation)
+	err = chain.sync(chain.next(h12, bad))
+	assert.ErrorContains(t, err, "invalid signature")
+
+	// a block without votes is canonical until a fork with less difficulty justifies more
+	h13 := chain.next(h12, nil)
+	assert.NilError(t, chain.sync(h13))
+	assert.Equal(t, h13.Hash(), chain.head())
+	h13b := chain.makeHeader(h12, chain.validators[2], 1, chain.attest(h11, h12, 0x6))
+	assert.NilError(t, chain.sync(h13b))
+	assert.Equal(t, h13b.Hash(), chain.head())
+	height, _ = chain.finalized()
+	assert.Equal(t, uint64(11), height)
+
+	// a checkpoint carrying votes, each block finalizes its grandparent
+	headers := []*etypes.Header{}
+	parent, source := h13b, h12
+	for parent.Number.Uint64() < 20 {
+		header := chain.next(parent, chain.attest(source, parent, 0x7))
+		headers = append(headers, header)
+		parent, source = header, parent
+	}
+	assert.NilError(t, chain.sync(headers...))
+	height, _ = chain.finalized()
+	assert.Equal(t, uint64(18), height)
+	assert.Equal(t, parent.Hash(), chain.head())
+}
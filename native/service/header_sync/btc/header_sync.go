@@ -70,16 +70,17 @@ func (this *BTCHandler) SyncGenesisHeader(native *native.NativeService) error {
 	return nil
 }
 
-func (this *BTCHandler) SyncBlockHeader(native *native.NativeService) error {
+func (this *BTCHandler) SyncBlockHeader(native *native.NativeService) (uint64, error) {
 	headerParams := new(scom.SyncBlockHeaderParam)
 	if err := headerParams.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
-		return fmt.Errorf("SyncBlockHeader, contract params deserialize error: %v", err)
+		return 0, fmt.Errorf("SyncBlockHeader, contract params deserialize error: %v", err)
 	}
+	var stored uint64
 	for _, v := range headerParams.Headers {
 		var blockHeader wire.BlockHeader
 		err := blockHeader.Deserialize(bytes.NewBuffer(v))
 		if err != nil {
-			return fmt.Errorf("SyncBlockHeader, deserialize header err: %v", err)
+			return 0, fmt.Errorf("SyncBlockHeader, deserialize header err: %v", err)
 		}
 
 		_, err = GetHeaderByHash(native, headerParams.ChainID, blockHeader.BlockHash())
@@ -90,15 +91,31 @@ func (this *BTCHandler) SyncBlockHeader(native *native.NativeService) error {
 		//isBestHeader, commonAncestor, heightOfHeader, err := commitHeader(native, headerParams.ChainID, blockHeader)
 		_, _, _, err = commitHeader(native, headerParams.ChainID, blockHeader)
 		if err != nil {
-			return fmt.Errorf("SyncBlockHeader, commit header err: %v", err)
+			return 0, fmt.Errorf("SyncBlockHeader, commit header err: %v", err)
 		}
+		stored++
+	}
+	return stored, nil
+}
+
+func (this *BTCHandler) SyncCrossChainMsg(native *native.NativeService) (uint64, error) {
+	return 0, nil
+}
 
+func (this *BTCHandler) GetHeaderRange(native *native.NativeService, chainID uint64) (uint64, uint64, error) {
+	genesis, err := getGenesisHeight(native, chainID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("BTCHandler GetHeaderRange, %v", err)
 	}
-	return nil
+	best, err := GetBestBlockHeader(native, chainID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("BTCHandler GetHeaderRange, %v", err)
+	}
+	return uint64(genesis), uint64(best.Height), nil
 }
 
-func (this *BTCHandler) SyncCrossChainMsg(native *native.NativeService) error {
-	return nil
+func (this *BTCHandler) PruneHeader(native *native.NativeService, chainID uint64, height uint64) error {
+	return pruneBlockHeader(native, chainID, uint32(height))
 }
 
 func getGenesisHeader(input []byte) (*wire.BlockHeader, uint32, error) {
@@ -146,6 +163,11 @@ func commitHeader(native *native.NativeService, chainID uint64, header wire.Bloc
 				headerHash, err)
 		}
 	}
+	if isHeaderCheckActive(native) {
+		if err := checkCheckpoints(native, chainID, header, parentHeader.Height+1); err != nil {
+			return false, nil, 0, err
+		}
+	}
 	valid, err := CheckHeader(native, chainID, header, parentHeader)
 	if err != nil {
 		return false, nil, 0, err
@@ -26,18 +26,22 @@ import (
 	"github.com/btcsuite/btcd/wire"
 	"github.com/polynetwork/poly/account"
 	"github.com/polynetwork/poly/common"
+	cstates "github.com/polynetwork/poly/core/states"
 	"github.com/polynetwork/poly/core/store/leveldbstore"
 	"github.com/polynetwork/poly/core/store/overlaydb"
 	"github.com/polynetwork/poly/core/types"
 	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
 	scom "github.com/polynetwork/poly/native/service/header_sync/common"
+	"github.com/polynetwork/poly/native/service/utils"
 	"github.com/polynetwork/poly/native/storage"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
 
 var (
-	acct *account.Account = account.NewAccount("")
+	acct     *account.Account = account.NewAccount("")
+	netParam                  = &chaincfg.RegressionNetParams
 
 	getNativeFunc = func(args []byte, db *storage.CacheDB) *native.NativeService {
 		if db == nil {
@@ -48,6 +52,28 @@ var (
 		return ns
 	}
 
+	setSideChain = func(db *storage.CacheDB, p *chaincfg.Params, extraInfo []byte) {
+		netType := utils.TyMainnet
+		switch p.Name {
+		case chaincfg.TestNet3Params.Name:
+			netType = utils.TyTestnet3
+		case chaincfg.RegressionNetParams.Name:
+			netType = utils.TyRegtest
+		case chaincfg.SimNetParams.Name:
+			netType = utils.TySimnet
+		}
+		side := &side_chain_manager.SideChain{
+			Name:        "btc",
+			ChainId:     0,
+			CCMCAddress: utils.GetUint64Bytes(uint64(netType)),
+			ExtraInfo:   extraInfo,
+		}
+		sink := common.NewZeroCopySink(nil)
+		_ = side.Serialization(sink)
+		db.Put(utils.ConcatKey(utils.SideChainManagerContractAddress, []byte(side_chain_manager.SIDE_CHAIN),
+			utils.GetUint64Bytes(0)), cstates.GenRawStorageItem(sink.Bytes()))
+	}
+
 	getHeaders = func() []*wire.BlockHeader {
 		res := make([]*wire.BlockHeader, 0)
 		for _, v := range chain {
@@ -96,6 +122,7 @@ var (
 		params.Serialization(sink)
 
 		ns := getNativeFunc(sink.Bytes(), nil)
+		setSideChain(ns.GetCacheDB(), netParam, nil)
 		handler := NewBTCHandler()
 		_ = handler.SyncGenesisHeader(ns)
 
@@ -112,7 +139,7 @@ var (
 		sink := common.NewZeroCopySink(nil)
 		param.Serialization(sink)
 		ns := getNativeFunc(sink.Bytes(), db)
-		_ = handler.SyncBlockHeader(ns)
+		_, _ = handler.SyncBlockHeader(ns)
 	}
 
 	getForkInBytes = func() [][]byte {
@@ -159,7 +186,7 @@ func TestBTCHandler_SyncBlockHeader(t *testing.T) {
 	sink := common.NewZeroCopySink(nil)
 	param.Serialization(sink)
 	ns = getNativeFunc(sink.Bytes(), ns.GetCacheDB())
-	err := handler.SyncBlockHeader(ns)
+	_, err := handler.SyncBlockHeader(ns)
 	assert.NoError(t, err)
 
 	normal := getHeaders()
@@ -175,7 +202,7 @@ func TestBTCHandler_SyncBlockHeader(t *testing.T) {
 	sink.Reset()
 	param.Serialization(sink)
 	ns = getNativeFunc(sink.Bytes(), ns.GetCacheDB())
-	err = handler.SyncBlockHeader(ns)
+	_, err = handler.SyncBlockHeader(ns)
 	assert.NoError(t, err)
 	best, _ := GetBestBlockHeader(ns, 0)
 	assert.Equal(t, normal[len(normal)-1].BlockHash().String(), best.Header.BlockHash().String(), "wrong best")
@@ -192,7 +219,7 @@ func TestBTCHandler_SyncBlockHeader(t *testing.T) {
 	sink.Reset()
 	param.Serialization(sink)
 	ns = getNativeFunc(sink.Bytes(), ns.GetCacheDB())
-	err = handler.SyncBlockHeader(ns)
+	_, err = handler.SyncBlockHeader(ns)
 	assert.NoError(t, err)
 	best, _ = GetBestBlockHeader(ns, 0)
 	assert.Equal(t, forks[5].BlockHash().String(), best.Header.BlockHash().String(), "wrong best")
@@ -202,7 +229,7 @@ func TestBTCHandler_SyncBlockHeader(t *testing.T) {
 	}
 
 	// add replicated header
-	err = handler.SyncBlockHeader(ns)
+	_, err = handler.SyncBlockHeader(ns)
 	assert.NoError(t, err)
 
 	// orphan
@@ -211,6 +238,6 @@ func TestBTCHandler_SyncBlockHeader(t *testing.T) {
 	sink.Reset()
 	param.Serialization(sink)
 	ns = getNativeFunc(sink.Bytes(), ns.GetCacheDB())
-	err = handler.SyncBlockHeader(ns)
+	_, err = handler.SyncBlockHeader(ns)
 	assert.Error(t, err, "should be error")
 }
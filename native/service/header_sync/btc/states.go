@@ -32,6 +32,24 @@ type StoredHeader struct {
 	totalWork *big.Int
 }
 
+// TotalWork returns the chain work accumulated on top of the genesis header
+func (this *StoredHeader) TotalWork() *big.Int {
+	return new(big.Int).Set(this.totalWork)
+}
+
+// Checkpoint pins the block hash at height, Hash is in the byte-reversed hex
+// form shown by bitcoin explorers
+type Checkpoint struct {
+	Height uint32
+	Hash   string
+}
+
+// ExtraInfo is the json configuration registered as ExtraInfo of a btc side chain.
+// Once the synced main chain contains a checkpoint, no header may fork off below it.
+type ExtraInfo struct {
+	Checkpoints []*Checkpoint
+}
+
 /*----- header serialization ------- */
 /* byteLength   desc          at offset
    80	       header	           0
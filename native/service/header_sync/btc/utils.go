@@ -20,12 +20,14 @@ package btc
 import (
 	"encoding/binary"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"github.com/btcsuite/btcd/blockchain"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/common/config"
 	"github.com/polynetwork/poly/common/log"
 	cstates "github.com/polynetwork/poly/core/states"
 	"github.com/polynetwork/poly/native"
@@ -33,18 +35,32 @@ import (
 	scom "github.com/polynetwork/poly/native/service/header_sync/common"
 	"github.com/polynetwork/poly/native/service/utils"
 	"math/big"
+	"sort"
 	"time"
 )
 
 const (
-	targetTimespan      = time.Hour * 24 * 14
-	targetSpacing       = time.Minute * 10
-	epochLength         = int32(targetTimespan / targetSpacing) // 2016
-	maxDiffAdjust       = 4
-	minRetargetTimespan = int64(targetTimespan / maxDiffAdjust)
-	maxRetargetTimespan = int64(targetTimespan * maxDiffAdjust)
+	medianTimeBlocks = 11
+	maxTimeOffset    = 2 * time.Hour
 )
 
+// blocksPerRetarget is 2016 for all bitcoin networks
+func blocksPerRetarget(p *chaincfg.Params) int32 {
+	return int32(p.TargetTimespan / p.TargetTimePerBlock)
+}
+
+// noRetargeting reports whether the difficulty never changes at retarget heights,
+// which is the case for bitcoind regtest but not described by btcd params
+func noRetargeting(p *chaincfg.Params) bool {
+	return p.Name == chaincfg.RegressionNetParams.Name
+}
+
+// isHeaderCheckActive reports whether the median time, future time and checkpoint rules
+// apply, and whether regtest and simnet headers have their difficulty checked
+func isHeaderCheckActive(native *native.NativeService) bool {
+	return native.GetHeight() >= config.GetBtcHeaderCheckHeight(config.DefConfig.P2PNode.NetworkId)
+}
+
 func getNetParam(service *native.NativeService, chainId uint64) (*chaincfg.Params, error) {
 	side, err := side_chain_manager.GetSideChain(service, chainId)
 	if err != nil {
@@ -68,6 +84,21 @@ func getNetParam(service *native.NativeService, chainId uint64) (*chaincfg.Param
 	}
 }
 
+// BlockHashKey is the storage key of the main chain hash at height, without the contract prefix
+func BlockHashKey(chainID uint64, height uint32) []byte {
+	return append(append([]byte(scom.HEADER_INDEX), utils.GetUint64Bytes(chainID)...), utils.GetUint32Bytes(height)...)
+}
+
+// BlockHeaderKey is the storage key of the header with hash, without the contract prefix
+func BlockHeaderKey(chainID uint64, hash chainhash.Hash) []byte {
+	return append(append([]byte(scom.BLOCK_HEADER), utils.GetUint64Bytes(chainID)...), hash.CloneBytes()...)
+}
+
+// BestHeaderKey is the storage key of the best header, without the contract prefix
+func BestHeaderKey(chainID uint64) []byte {
+	return append([]byte(scom.CURRENT_HEADER_HEIGHT), utils.GetUint64Bytes(chainID)...)
+}
+
 func putGenesisBlockHeader(native *native.NativeService, chainID uint64, blockHeader StoredHeader) {
 	contract := utils.HeaderSyncContractAddress
 	blockHash := blockHeader.Header.BlockHash()
@@ -87,15 +118,35 @@ func putGenesisBlockHeader(native *native.NativeService, chainID uint64, blockHe
 	scom.NotifyPutHeader(native, chainID, uint64(blockHeight), hex.EncodeToString(blockHash.CloneBytes()))
 }
 
+func getGenesisHeight(native *native.NativeService, chainID uint64) (uint32, error) {
+	genesisStore, err := native.GetCacheDB().Get(utils.ConcatKey(utils.HeaderSyncContractAddress,
+		[]byte(scom.GENESIS_HEADER), utils.GetUint64Bytes(chainID)))
+	if err != nil {
+		return 0, fmt.Errorf("getGenesisHeight, get genesisStore error: %v", err)
+	}
+	if genesisStore == nil {
+		return 0, fmt.Errorf("getGenesisHeight, genesis header is not synced")
+	}
+	genesisBs, err := cstates.GetValueFromRawStorageItem(genesisStore)
+	if err != nil {
+		return 0, fmt.Errorf("getGenesisHeight, deserialize genesisBytes from raw storage item err: %v", err)
+	}
+	genesis := new(StoredHeader)
+	if err := genesis.Deserialization(common.NewZeroCopySource(genesisBs)); err != nil {
+		return 0, fmt.Errorf("getGenesisHeight, deserialize storedHeader error: %v", err)
+	}
+	return genesis.Height, nil
+}
+
 func putBlockHash(native *native.NativeService, chainID uint64, height uint32, hash chainhash.Hash) {
-	native.GetCacheDB().Put(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.HEADER_INDEX), utils.GetUint64Bytes(chainID), utils.GetUint32Bytes(height)),
+	native.GetCacheDB().Put(utils.ConcatKey(utils.HeaderSyncContractAddress, BlockHashKey(chainID, height)),
 		cstates.GenRawStorageItem(hash.CloneBytes()))
 }
 
 func GetBlockHashByHeight(native *native.NativeService, chainID uint64, height uint32) (*chainhash.Hash, error) {
 	contract := utils.HeaderSyncContractAddress
 
-	hashStore, err := native.GetCacheDB().Get(utils.ConcatKey(contract, []byte(scom.HEADER_INDEX), utils.GetUint64Bytes(chainID), utils.GetUint32Bytes(height)))
+	hashStore, err := native.GetCacheDB().Get(utils.ConcatKey(contract, BlockHashKey(chainID, height)))
 	if err != nil {
 		return nil, fmt.Errorf("GetBlockHashByHeight, get heightBlockHashStore error: %v", err)
 	}
@@ -121,15 +172,39 @@ func putBlockHeader(native *native.NativeService, chainID uint64, sh StoredHeade
 	blockHash := sh.Header.BlockHash()
 	sink := new(common.ZeroCopySink)
 	sh.Serialization(sink)
-	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(scom.BLOCK_HEADER), utils.GetUint64Bytes(chainID), blockHash.CloneBytes()),
+	native.GetCacheDB().Put(utils.ConcatKey(contract, BlockHeaderKey(chainID, blockHash)),
 		cstates.GenRawStorageItem(sink.Bytes()))
 	scom.NotifyPutHeader(native, chainID, uint64(sh.Height), hex.EncodeToString(blockHash.CloneBytes()))
 }
 
+// pruneBlockHeader deletes the main chain header at height and its height index
+func pruneBlockHeader(native *native.NativeService, chainID uint64, height uint32) error {
+	contract := utils.HeaderSyncContractAddress
+
+	hashStore, err := native.GetCacheDB().Get(utils.ConcatKey(contract, BlockHashKey(chainID, height)))
+	if err != nil {
+		return fmt.Errorf("pruneBlockHeader, get heightBlockHashStore error: %v", err)
+	}
+	if hashStore == nil {
+		return nil
+	}
+	hashBs, err := cstates.GetValueFromRawStorageItem(hashStore)
+	if err != nil {
+		return fmt.Errorf("pruneBlockHeader, deserialize blockHashBytes from raw storage item err:%v", err)
+	}
+	hash, err := chainhash.NewHash(hashBs)
+	if err != nil {
+		return fmt.Errorf("pruneBlockHeader at height = %d, error:%v", height, err)
+	}
+	native.GetCacheDB().Delete(utils.ConcatKey(contract, BlockHeaderKey(chainID, *hash)))
+	native.GetCacheDB().Delete(utils.ConcatKey(contract, BlockHashKey(chainID, height)))
+	return nil
+}
+
 func GetHeaderByHash(native *native.NativeService, chainID uint64, hash chainhash.Hash) (*StoredHeader, error) {
 	contract := utils.HeaderSyncContractAddress
 
-	headerStore, err := native.GetCacheDB().Get(utils.ConcatKey(contract, []byte(scom.BLOCK_HEADER), utils.GetUint64Bytes(chainID), hash.CloneBytes()))
+	headerStore, err := native.GetCacheDB().Get(utils.ConcatKey(contract, BlockHeaderKey(chainID, hash)))
 	if err != nil {
 		return nil, fmt.Errorf("GetHeaderByHash, get hashBlockHeaderStore error: %v", err)
 	}
@@ -165,14 +240,14 @@ func putBestBlockHeader(native *native.NativeService, chainID uint64, bestHeader
 
 	sink := new(common.ZeroCopySink)
 	bestHeader.Serialization(sink)
-	native.GetCacheDB().Put(utils.ConcatKey(contract, []byte(scom.CURRENT_HEADER_HEIGHT), utils.GetUint64Bytes(chainID)),
+	native.GetCacheDB().Put(utils.ConcatKey(contract, BestHeaderKey(chainID)),
 		cstates.GenRawStorageItem(sink.Bytes()))
 }
 
 func GetBestBlockHeader(native *native.NativeService, chainID uint64) (*StoredHeader, error) {
 	contract := utils.HeaderSyncContractAddress
 
-	bestBlockHeaderStore, err := native.GetCacheDB().Get(utils.ConcatKey(contract, []byte(scom.CURRENT_HEADER_HEIGHT), utils.GetUint64Bytes(chainID)))
+	bestBlockHeaderStore, err := native.GetCacheDB().Get(utils.ConcatKey(contract, BestHeaderKey(chainID)))
 	if err != nil {
 		return nil, fmt.Errorf("GetBestBlockHeader, get BestBlockHeader error: %v", err)
 	}
@@ -210,7 +285,8 @@ func CheckHeader(native *native.NativeService, chainID uint64, header wire.Block
 		return false, fmt.Errorf("CheckHeader error: Headers %d and %d don't link.", height, height+1)
 	}
 
-	if netParam.Name != "regtest" && netParam.Name != "simnet" {
+	checkActive := isHeaderCheckActive(native)
+	if checkActive || (netParam.Name != "regtest" && netParam.Name != "simnet") {
 		// Check the header meets the difficulty requirement
 		diffTarget, err := calcRequiredWork(native, chainID, header, int32(height+1), prevHeader, netParam)
 		if err != nil {
@@ -222,6 +298,18 @@ func CheckHeader(native *native.NativeService, chainID uint64, header wire.Block
 		}
 	}
 
+	if checkActive {
+		// Check the timestamp is after the median time of the previous headers and not too far in the future
+		medianTime := calcPastMedianTime(native, chainID, prevHeader)
+		if !header.Timestamp.After(medianTime) {
+			return false, fmt.Errorf("CheckHeader, Block %d timestamp %v is not after median time %v",
+				height+1, header.Timestamp, medianTime)
+		}
+		if maxTime := time.Unix(int64(native.GetBlockTime()), 0).Add(maxTimeOffset); header.Timestamp.After(maxTime) {
+			return false, fmt.Errorf("CheckHeader, Block %d timestamp %v is too far in the future", height+1, header.Timestamp)
+		}
+	}
+
 	// Check if there's a valid proof of work.  That whole "Bitcoin" thing.
 	if !checkProofOfWork(header, netParam) {
 		log.Debugf("CheckHeader, Block %d bad proof of work.", height+1)
@@ -234,36 +322,35 @@ func CheckHeader(native *native.NativeService, chainID uint64, header wire.Block
 // Get the PoW target this block should meet. We may need to handle a difficulty adjustment
 // or testnet difficulty rules.
 func calcRequiredWork(native *native.NativeService, chainID uint64, header wire.BlockHeader, height int32, prevHeader *StoredHeader, netParam *chaincfg.Params) (uint32, error) {
+	interval := blocksPerRetarget(netParam)
 	// If this is not a difficulty adjustment period
-	if height%epochLength != 0 {
+	if height%interval != 0 {
 		// If we are on testnet
 		if netParam.ReduceMinDifficulty {
 			// If it's been more than 20 minutes since the last header return the minimum difficulty
-			if header.Timestamp.After(prevHeader.Header.Timestamp.Add(targetSpacing * 2)) {
+			if header.Timestamp.After(prevHeader.Header.Timestamp.Add(netParam.MinDiffReductionTime)) {
 				return netParam.PowLimitBits, nil
-			} else {
-				// Otherwise return the difficulty of the last block not using special difficulty rules
-				for {
-					var err error = nil
-					for err == nil && int32(prevHeader.Height)%epochLength != 0 && prevHeader.Header.Bits == netParam.PowLimitBits {
-						var sh *StoredHeader
-						sh, err = GetPreviousHeader(native, chainID, prevHeader.Header)
-						// Error should only be non-nil if prevHeader is the checkpoint.
-						// In that case we should just return checkpoint bits
-						if err == nil {
-							prevHeader = sh
-						}
-
-					}
-					return prevHeader.Header.Bits, nil
+			}
+			// Otherwise return the difficulty of the last block not using special difficulty rules
+			for int32(prevHeader.Height)%interval != 0 && prevHeader.Header.Bits == netParam.PowLimitBits {
+				sh, err := GetPreviousHeader(native, chainID, prevHeader.Header)
+				// Error should only be non-nil if prevHeader is the checkpoint.
+				// In that case we should just return checkpoint bits
+				if err != nil {
+					break
 				}
+				prevHeader = sh
 			}
+			return prevHeader.Header.Bits, nil
 		}
 		// Just return the bits from the last header
 		return prevHeader.Header.Bits, nil
 	}
+	if noRetargeting(netParam) {
+		return prevHeader.Header.Bits, nil
+	}
 	// We are on a difficulty adjustment period so we need to correctly calculate the new difficulty.
-	epoch, err := GetEpoch(native, chainID, prevHeader)
+	epoch, err := getAncestor(native, chainID, prevHeader, interval-1)
 	if err != nil {
 		return 0, err
 	}
@@ -271,17 +358,100 @@ func calcRequiredWork(native *native.NativeService, chainID uint64, header wire.
 }
 
 func GetEpoch(native *native.NativeService, chainID uint64, sh *StoredHeader) (*wire.BlockHeader, error) {
+	return getAncestor(native, chainID, sh, blocksPerRetarget(&chaincfg.MainNetParams)-1)
+}
+
+func getAncestor(native *native.NativeService, chainID uint64, sh *StoredHeader, distance int32) (*wire.BlockHeader, error) {
 	var err error
-	for i := 0; i < 2015; i++ {
+	for i := int32(0); i < distance; i++ {
 		sh, err = GetPreviousHeader(native, chainID, sh.Header)
 		if err != nil {
-			return &sh.Header, err
+			return nil, err
 		}
 	}
 	log.Debug("Epoch", sh.Header.BlockHash().String())
 	return &sh.Header, nil
 }
 
+// calcPastMedianTime returns the median timestamp of the last 11 headers, or of
+// all headers back to the genesis header if there are fewer
+func calcPastMedianTime(native *native.NativeService, chainID uint64, sh *StoredHeader) time.Time {
+	timestamps := make([]int64, 0, medianTimeBlocks)
+	for {
+		timestamps = append(timestamps, sh.Header.Timestamp.Unix())
+		if len(timestamps) == medianTimeBlocks {
+			break
+		}
+		prev, err := GetPreviousHeader(native, chainID, sh.Header)
+		if err != nil {
+			break
+		}
+		sh = prev
+	}
+	sort.Slice(timestamps, func(i, j int) bool {
+		return timestamps[i] < timestamps[j]
+	})
+	return time.Unix(timestamps[len(timestamps)/2], 0)
+}
+
+// GetExtraInfo returns the checkpoints of chainID sorted by height
+func GetExtraInfo(native *native.NativeService, chainID uint64) (*ExtraInfo, error) {
+	side, err := side_chain_manager.GetSideChain(native, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("GetExtraInfo, GetSideChain error: %v", err)
+	}
+	if side == nil {
+		return nil, fmt.Errorf("GetExtraInfo, side chain %d is not registered", chainID)
+	}
+	info := &ExtraInfo{Checkpoints: make([]*Checkpoint, 0)}
+	if len(side.ExtraInfo) == 0 {
+		return info, nil
+	}
+	if err := json.Unmarshal(side.ExtraInfo, info); err != nil {
+		return nil, fmt.Errorf("GetExtraInfo, ExtraInfo Unmarshal error: %v", err)
+	}
+	sort.Slice(info.Checkpoints, func(i, j int) bool {
+		return info.Checkpoints[i].Height < info.Checkpoints[j].Height
+	})
+	for i, cp := range info.Checkpoints {
+		if _, err := chainhash.NewHashFromStr(cp.Hash); err != nil {
+			return nil, fmt.Errorf("GetExtraInfo, wrong hash of checkpoint at height %d: %v", cp.Height, err)
+		}
+		if i > 0 && info.Checkpoints[i-1].Height == cp.Height {
+			return nil, fmt.Errorf("GetExtraInfo, duplicate checkpoints at height %d", cp.Height)
+		}
+	}
+	return info, nil
+}
+
+// checkCheckpoints rejects the header at height if it conflicts with the checkpoint at
+// the same height, or if it forks off below a checkpoint already on the synced main chain
+func checkCheckpoints(native *native.NativeService, chainID uint64, header wire.BlockHeader, height uint32) error {
+	info, err := GetExtraInfo(native, chainID)
+	if err != nil {
+		return err
+	}
+	hash := header.BlockHash()
+	for _, cp := range info.Checkpoints {
+		if cp.Height < height {
+			continue
+		}
+		cpHash, _ := chainhash.NewHashFromStr(cp.Hash)
+		if cp.Height == height {
+			if !cpHash.IsEqual(&hash) {
+				return fmt.Errorf("checkCheckpoints, header %s at height %d conflicts with checkpoint %s",
+					hash.String(), height, cp.Hash)
+			}
+			continue
+		}
+		if onChain, err := GetBlockHashByHeight(native, chainID, cp.Height); err == nil && onChain.IsEqual(cpHash) {
+			return fmt.Errorf("checkCheckpoints, header %s at height %d forks off below checkpoint at height %d",
+				hash.String(), height, cp.Height)
+		}
+	}
+	return nil
+}
+
 func GetCommonAncestor(native *native.NativeService, chainID uint64, bestHeader, prevBestHeader *StoredHeader) (*StoredHeader, []chainhash.Hash, error) {
 	var err error
 	bestHash := bestHeader.Header.BlockHash()
@@ -327,7 +497,7 @@ func ReIndexHeaderHeight(native *native.NativeService, chainID uint64, bestHeade
 	newBlock *StoredHeader) error {
 	contract := utils.HeaderSyncContractAddress
 	for i := bestHeaderHeight; i > newBlock.Height; i-- {
-		native.GetCacheDB().Delete(utils.ConcatKey(contract, []byte(scom.HEADER_INDEX), utils.GetUint64Bytes(chainID), utils.GetUint32Bytes(i)))
+		native.GetCacheDB().Delete(utils.ConcatKey(contract, BlockHashKey(chainID, i)))
 	}
 
 	for i, v := range hdrs {
@@ -367,7 +537,10 @@ func checkProofOfWork(header wire.BlockHeader, p *chaincfg.Params) bool {
 // to calculate how much of a difficulty adjustment is needed. It returns a new compact
 // difficulty target.
 func calcDiffAdjust(start, end wire.BlockHeader, p *chaincfg.Params) uint32 {
-	duration := end.Timestamp.UnixNano() - start.Timestamp.UnixNano()
+	targetTimespan := int64(p.TargetTimespan / time.Second)
+	minRetargetTimespan := targetTimespan / p.RetargetAdjustmentFactor
+	maxRetargetTimespan := targetTimespan * p.RetargetAdjustmentFactor
+	duration := end.Timestamp.Unix() - start.Timestamp.Unix()
 	if duration < minRetargetTimespan {
 		log.Debugf("Whoa there, block %s off-scale high 4X diff adjustment!",
 			end.BlockHash().String())
@@ -384,7 +557,7 @@ func calcDiffAdjust(start, end wire.BlockHeader, p *chaincfg.Params) uint32 {
 	// new target is old * duration...
 	newTarget := new(big.Int).Mul(prevTarget, big.NewInt(duration))
 	// divided by 2 weeks
-	newTarget.Div(newTarget, big.NewInt(int64(targetTimespan)))
+	newTarget.Div(newTarget, big.NewInt(targetTimespan))
 	// clip again if above minimum target (too easy)
 	if newTarget.Cmp(p.PowLimit) > 0 {
 		newTarget.Set(p.PowLimit)
@@ -22,12 +22,14 @@ import (
 	"crypto/rand"
 	"encoding/binary"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"github.com/btcsuite/btcd/blockchain"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/common/config"
 	"github.com/polynetwork/poly/core/types"
 	"github.com/polynetwork/poly/native"
 	scom "github.com/polynetwork/poly/native/service/header_sync/common"
@@ -91,7 +93,7 @@ func TestCalcRequiredWork(t *testing.T) {
 	// Test during difficulty adjust period
 	newHdr := wire.BlockHeader{}
 	newHdr.PrevBlock = bestHeader.Header.BlockHash()
-	work, err := calcRequiredWork(nativeService, 0, newHdr, 2016, bestHeader)
+	work, err := calcRequiredWork(nativeService, 0, newHdr, 2016, bestHeader, netParam)
 	if err != nil {
 		t.Error(err)
 	}
@@ -114,7 +116,7 @@ func TestCalcRequiredWork(t *testing.T) {
 	netParam.ReduceMinDifficulty = false
 	newHdr1 := wire.BlockHeader{}
 	newHdr1.PrevBlock = newHdr.BlockHash()
-	work1, err := calcRequiredWork(nativeService, 0, newHdr1, 2017, &sh)
+	work1, err := calcRequiredWork(nativeService, 0, newHdr1, 2017, &sh, netParam)
 	if err != nil {
 		t.Error(err)
 	}
@@ -137,7 +139,7 @@ func TestCalcRequiredWork(t *testing.T) {
 	netParam.ReduceMinDifficulty = true
 	newHdr2 := wire.BlockHeader{}
 	newHdr2.PrevBlock = newHdr1.BlockHash()
-	work2, err := calcRequiredWork(nativeService, 0, newHdr2, 2018, &sh)
+	work2, err := calcRequiredWork(nativeService, 0, newHdr2, 2018, &sh, netParam)
 	if err != nil {
 		t.Error(err)
 	}
@@ -160,7 +162,7 @@ func TestCalcRequiredWork(t *testing.T) {
 	newHdr3 := wire.BlockHeader{}
 	newHdr3.PrevBlock = newHdr2.BlockHash()
 	newHdr3.Timestamp = newHdr2.Timestamp.Add(time.Minute * 21)
-	work3, err := calcRequiredWork(nativeService, 0, newHdr3, 2019, &sh)
+	work3, err := calcRequiredWork(nativeService, 0, newHdr3, 2019, &sh, netParam)
 	if err != nil {
 		t.Error(err)
 	}
@@ -183,7 +185,7 @@ func TestCalcRequiredWork(t *testing.T) {
 	netParam.ReduceMinDifficulty = true
 	newHdr4 := wire.BlockHeader{}
 	newHdr4.PrevBlock = newHdr3.BlockHash()
-	work4, err := calcRequiredWork(nativeService, 0, newHdr4, 2020, &sh)
+	work4, err := calcRequiredWork(nativeService, 0, newHdr4, 2020, &sh, netParam)
 	if err != nil {
 		t.Error(err)
 	}
@@ -365,6 +367,7 @@ func syncGenesisHeader(genesisHeader *wire.BlockHeader) (*storage.CacheDB, error
 	sink = new(common.ZeroCopySink)
 	params.Serialization(sink)
 	ns := getNativeFunc(sink.Bytes(), nil)
+	setSideChain(ns.GetCacheDB(), netParam, nil)
 	_ = btcHander.SyncGenesisHeader(ns)
 
 	return ns.GetCacheDB(), nil
@@ -445,3 +448,72 @@ func TestReIndexHeaderHeight(t *testing.T) {
 		assert.Equal(t, true, hash.IsEqual(&prevs[11-i]))
 	}
 }
+
+func TestCheckHeaderActivation(t *testing.T) {
+	defer func(id uint32) {
+		config.DefConfig.P2PNode.NetworkId = id
+	}(config.DefConfig.P2PNode.NetworkId)
+	netParam = &chaincfg.RegressionNetParams
+
+	mine := func(prev wire.BlockHeader, ts time.Time, bits uint32) wire.BlockHeader {
+		hdr := wire.BlockHeader{
+			Version:   prev.Version,
+			PrevBlock: prev.BlockHash(),
+			Timestamp: ts,
+			Bits:      bits,
+		}
+		for !checkProofOfWork(hdr, netParam) {
+			hdr.Nonce++
+		}
+		return hdr
+	}
+	// genesis at 2004 and 11 headers after it, so the next header is at the retarget height 2016
+	genesis := StoredHeader{Header: netParam.GenesisBlock.Header, Height: 2004, totalWork: big.NewInt(0)}
+	hdrs := []wire.BlockHeader{genesis.Header}
+	for i := 1; i <= 11; i++ {
+		prev := hdrs[i-1]
+		hdrs = append(hdrs, mine(prev, prev.Timestamp.Add(10*time.Minute), prev.Bits))
+	}
+	tip := hdrs[len(hdrs)-1]
+	blockTime := tip.Timestamp.Add(10 * time.Minute)
+	checkpoint, _ := json.Marshal(&ExtraInfo{Checkpoints: []*Checkpoint{{Height: 2016, Hash: tip.BlockHash().String()}}})
+
+	cases := []struct {
+		name      string
+		header    wire.BlockHeader
+		extraInfo []byte
+		activeErr bool
+	}{
+		{"valid", mine(tip, blockTime, tip.Bits), nil, false},
+		{"median time past", mine(tip, hdrs[6].Timestamp, tip.Bits), nil, true},
+		{"future time", mine(tip, blockTime.Add(maxTimeOffset+time.Minute), tip.Bits), nil, true},
+		{"checkpoint", mine(tip, blockTime, tip.Bits), checkpoint, true},
+		{"retarget", mine(tip, blockTime, tip.Bits-1), nil, true},
+	}
+	for _, c := range cases {
+		for _, active := range []bool{false, true} {
+			config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_MAIN_NET
+			if active {
+				config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+			}
+			ns := getNativeFunc(nil, nil)
+			setSideChain(ns.GetCacheDB(), netParam, c.extraInfo)
+			putGenesisBlockHeader(ns, 0, genesis)
+			for i, hdr := range hdrs[1:] {
+				sh := StoredHeader{Header: hdr, Height: genesis.Height + uint32(i+1), totalWork: big.NewInt(int64(i + 1))}
+				putBlockHeader(ns, 0, sh)
+				putBestBlockHeader(ns, 0, sh)
+				putBlockHash(ns, 0, sh.Height, sh.Header.BlockHash())
+			}
+			ns, _ = native.NewNativeService(ns.GetCacheDB(), new(types.Transaction), uint32(blockTime.Unix()), 0,
+				common.Uint256{}, 0, nil, false)
+
+			_, _, _, err := commitHeader(ns, 0, c.header)
+			if active && c.activeErr {
+				assert.Error(t, err, c.name)
+			} else {
+				assert.NoError(t, err, fmt.Sprintf("%s, active: %v", c.name, active))
+			}
+		}
+	}
+}
@@ -0,0 +1,359 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package bls verifies BLS12-381 signatures of the proof of possession scheme
+// with public keys in G1 and signatures in G2, as used by the beacon chain and
+// by the fast finality votes of bsc.
+package bls
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto/bls12381"
+)
+
+// The bls12381 package only reads uncompressed points, compressed points are
+// converted with CompressPubkey, DecompressPubkey, CompressSignature and
+// DecompressSignature, see https://github.com/zkcrypto/pairing for the format.
+const (
+	PubkeyCompressedLength    = 48
+	PubkeyLength              = 96
+	SignatureCompressedLength = 96
+	SignatureLength           = 192
+)
+
+var (
+	// DST is the domain separation tag of the proof of possession scheme
+	DST = []byte("BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_")
+
+	fieldModulus, _ = new(big.Int).SetString("1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaaab", 16)
+	halfModulus     = new(big.Int).Rsh(fieldModulus, 1)
+	// (p+1)/4 and (p-3)/4
+	sqrtExponent  = new(big.Int).Rsh(new(big.Int).Add(fieldModulus, big.NewInt(1)), 2)
+	sqrtExponent2 = new(big.Int).Rsh(new(big.Int).Sub(fieldModulus, big.NewInt(3)), 2)
+)
+
+// expandMessageXMD implements expand_message_xmd of RFC 9380 with sha256
+func expandMessageXMD(msg, dst []byte, length int) ([]byte, error) {
+	ell := (length + sha256.Size - 1) / sha256.Size
+	if ell > 255 || len(dst) > 255 {
+		return nil, errors.New("expandMessageXMD, invalid length")
+	}
+	dstPrime := append(append([]byte{}, dst...), byte(len(dst)))
+
+	h := sha256.New()
+	h.Write(make([]byte, sha256.BlockSize))
+	h.Write(msg)
+	h.Write([]byte{byte(length >> 8), byte(length), 0})
+	h.Write(dstPrime)
+	b0 := h.Sum(nil)
+
+	h.Reset()
+	h.Write(b0)
+	h.Write([]byte{1})
+	h.Write(dstPrime)
+	bi := h.Sum(nil)
+
+	out := make([]byte, 0, ell*sha256.Size)
+	out = append(out, bi...)
+	for i := 2; i <= ell; i++ {
+		tmp := make([]byte, sha256.Size)
+		for j := range tmp {
+			tmp[j] = b0[j] ^ bi[j]
+		}
+		h.Reset()
+		h.Write(tmp)
+		h.Write([]byte{byte(i)})
+		h.Write(dstPrime)
+		bi = h.Sum(nil)
+		out = append(out, bi...)
+	}
+	return out[:length], nil
+}
+
+// HashToG2 implements hash_to_curve of the BLS12381G2_XMD:SHA-256_SSWU_RO_ suite
+func HashToG2(msg, dst []byte) (*bls12381.PointG2, error) {
+	uniform, err := expandMessageXMD(msg, dst, 256)
+	if err != nil {
+		return nil, err
+	}
+	g2 := bls12381.NewG2()
+	result := g2.Zero()
+	for i := 0; i < 2; i++ {
+		// MapToCurve expects c1 || c0, each reduced into the field
+		in := make([]byte, 96)
+		for j := 0; j < 2; j++ {
+			e := new(big.Int).SetBytes(uniform[64*(j+2*i) : 64*(j+2*i+1)])
+			e.Mod(e, fieldModulus)
+			b := e.Bytes()
+			copy(in[48*(2-j)-len(b):48*(2-j)], b)
+		}
+		// cofactor clearing is linear, so mapping and clearing each element
+		// before adding gives the same point as clearing the sum
+		p, err := g2.MapToCurve(in)
+		if err != nil {
+			return nil, fmt.Errorf("HashToG2, map to curve error: %v", err)
+		}
+		g2.Add(result, result, p)
+	}
+	return result, nil
+}
+
+// CompressPubkey returns the 48 byte compressed form of an uncompressed G1 point,
+// the point is checked to be on the curve and not at infinity
+func CompressPubkey(raw []byte) ([]byte, error) {
+	if len(raw) != PubkeyLength {
+		return nil, fmt.Errorf("CompressPubkey, invalid pubkey length: %d", len(raw))
+	}
+	g1 := bls12381.NewG1()
+	p, err := g1.FromBytes(raw)
+	if err != nil {
+		return nil, fmt.Errorf("CompressPubkey, %v", err)
+	}
+	if g1.IsZero(p) {
+		return nil, errors.New("CompressPubkey, pubkey is infinity")
+	}
+	out := make([]byte, PubkeyCompressedLength)
+	copy(out, raw[:PubkeyCompressedLength])
+	out[0] |= 0x80
+	if new(big.Int).SetBytes(raw[PubkeyCompressedLength:]).Cmp(halfModulus) > 0 {
+		out[0] |= 0x20
+	}
+	return out, nil
+}
+
+// DecompressPubkey returns the uncompressed form of a compressed G1 point,
+// the point is checked to be in the correct subgroup and not at infinity
+func DecompressPubkey(in []byte) ([]byte, error) {
+	if len(in) != PubkeyCompressedLength {
+		return nil, fmt.Errorf("DecompressPubkey, invalid pubkey length: %d", len(in))
+	}
+	x, largest, err := readCompressed(in)
+	if err != nil {
+		return nil, fmt.Errorf("DecompressPubkey, %v", err)
+	}
+	// y^2 = x^3 + 4
+	rhs := new(big.Int).Exp(x, big.NewInt(3), fieldModulus)
+	rhs.Add(rhs, big.NewInt(4)).Mod(rhs, fieldModulus)
+	y, ok := fpSqrt(rhs)
+	if !ok {
+		return nil, errors.New("DecompressPubkey, point is not on curve")
+	}
+	if (y.Cmp(halfModulus) > 0) != largest {
+		y.Sub(fieldModulus, y)
+	}
+	out := make([]byte, PubkeyLength)
+	putFp(out[:48], x)
+	putFp(out[48:], y)
+
+	g1 := bls12381.NewG1()
+	p, err := g1.FromBytes(out)
+	if err != nil {
+		return nil, fmt.Errorf("DecompressPubkey, %v", err)
+	}
+	if !g1.InCorrectSubgroup(p) {
+		return nil, errors.New("DecompressPubkey, pubkey is not in the correct subgroup")
+	}
+	return out, nil
+}
+
+// CompressSignature returns the 96 byte compressed form of an uncompressed G2 point
+func CompressSignature(raw []byte) ([]byte, error) {
+	if len(raw) != SignatureLength {
+		return nil, fmt.Errorf("CompressSignature, invalid signature length: %d", len(raw))
+	}
+	g2 := bls12381.NewG2()
+	p, err := g2.FromBytes(raw)
+	if err != nil {
+		return nil, fmt.Errorf("CompressSignature, %v", err)
+	}
+	if g2.IsZero(p) {
+		return nil, errors.New("CompressSignature, signature is infinity")
+	}
+	out := make([]byte, SignatureCompressedLength)
+	copy(out, raw[:SignatureCompressedLength])
+	out[0] |= 0x80
+	if fp2Largest(&fp2{c0: new(big.Int).SetBytes(raw[144:]), c1: new(big.Int).SetBytes(raw[96:144])}) {
+		out[0] |= 0x20
+	}
+	return out, nil
+}
+
+// DecompressSignature returns the uncompressed form of a compressed G2 point,
+// the point is checked to be on the curve and not at infinity
+func DecompressSignature(in []byte) ([]byte, error) {
+	if len(in) != SignatureCompressedLength {
+		return nil, fmt.Errorf("DecompressSignature, invalid signature length: %d", len(in))
+	}
+	x1, largest, err := readCompressed(in[:48])
+	if err != nil {
+		return nil, fmt.Errorf("DecompressSignature, %v", err)
+	}
+	x0 := new(big.Int).SetBytes(in[48:])
+	if x0.Cmp(fieldModulus) >= 0 {
+		return nil, errors.New("DecompressSignature, invalid x coordinate")
+	}
+	// y^2 = x^3 + 4(u + 1)
+	x := &fp2{c0: x0, c1: x1}
+	rhs := fp2Mul(fp2Mul(x, x), x)
+	rhs.c0.Add(rhs.c0, big.NewInt(4)).Mod(rhs.c0, fieldModulus)
+	rhs.c1.Add(rhs.c1, big.NewInt(4)).Mod(rhs.c1, fieldModulus)
+	y, ok := fp2Sqrt(rhs)
+	if !ok {
+		return nil, errors.New("DecompressSignature, point is not on curve")
+	}
+	if fp2Largest(y) != largest {
+		y.c0.Sub(fieldModulus, y.c0).Mod(y.c0, fieldModulus)
+		y.c1.Sub(fieldModulus, y.c1).Mod(y.c1, fieldModulus)
+	}
+	out := make([]byte, SignatureLength)
+	putFp(out[:48], x1)
+	putFp(out[48:96], x0)
+	putFp(out[96:144], y.c1)
+	putFp(out[144:], y.c0)
+	if _, err := bls12381.NewG2().FromBytes(out); err != nil {
+		return nil, fmt.Errorf("DecompressSignature, %v", err)
+	}
+	return out, nil
+}
+
+// readCompressed reads the flags and the first coordinate of a compressed point
+func readCompressed(in []byte) (x *big.Int, largest bool, err error) {
+	if in[0]&0x80 == 0 {
+		return nil, false, errors.New("point is not compressed")
+	}
+	if in[0]&0x40 != 0 {
+		return nil, false, errors.New("point is infinity")
+	}
+	largest = in[0]&0x20 != 0
+	raw := append([]byte{in[0] & 0x1f}, in[1:]...)
+	x = new(big.Int).SetBytes(raw)
+	if x.Cmp(fieldModulus) >= 0 {
+		return nil, false, errors.New("invalid x coordinate")
+	}
+	return x, largest, nil
+}
+
+// putFp writes e right aligned into the 48 bytes of out
+func putFp(out []byte, e *big.Int) {
+	b := e.Bytes()
+	copy(out[len(out)-len(b):], b)
+}
+
+// fpSqrt returns a square root of a, p = 3 mod 4 so it is a^((p+1)/4)
+func fpSqrt(a *big.Int) (*big.Int, bool) {
+	y := new(big.Int).Exp(a, sqrtExponent, fieldModulus)
+	return y, new(big.Int).Exp(y, big.NewInt(2), fieldModulus).Cmp(a) == 0
+}
+
+// fp2 is c0 + c1*u with u^2 = -1
+type fp2 struct {
+	c0, c1 *big.Int
+}
+
+func fp2Mul(a, b *fp2) *fp2 {
+	t0 := new(big.Int).Mul(a.c0, b.c0)
+	t1 := new(big.Int).Mul(a.c1, b.c1)
+	c1 := new(big.Int).Mul(a.c0, b.c1)
+	c1.Add(c1, new(big.Int).Mul(a.c1, b.c0))
+	return &fp2{c0: t0.Sub(t0, t1).Mod(t0, fieldModulus), c1: c1.Mod(c1, fieldModulus)}
+}
+
+func fp2Exp(a *fp2, e *big.Int) *fp2 {
+	r := &fp2{c0: big.NewInt(1), c1: big.NewInt(0)}
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		r = fp2Mul(r, r)
+		if e.Bit(i) == 1 {
+			r = fp2Mul(r, a)
+		}
+	}
+	return r
+}
+
+func fp2Equal(a, b *fp2) bool {
+	return a.c0.Cmp(b.c0) == 0 && a.c1.Cmp(b.c1) == 0
+}
+
+// fp2Largest compares c1 first and c0 if c1 is zero
+func fp2Largest(a *fp2) bool {
+	if a.c1.Sign() != 0 {
+		return a.c1.Cmp(halfModulus) > 0
+	}
+	return a.c0.Cmp(halfModulus) > 0
+}
+
+// fp2Sqrt is algorithm 9 of https://eprint.iacr.org/2012/685.pdf
+func fp2Sqrt(a *fp2) (*fp2, bool) {
+	a1 := fp2Exp(a, sqrtExponent2)
+	alpha := fp2Mul(fp2Mul(a1, a1), a)
+	x0 := fp2Mul(a1, a)
+	var x *fp2
+	if fp2Equal(alpha, &fp2{c0: new(big.Int).Sub(fieldModulus, big.NewInt(1)), c1: big.NewInt(0)}) {
+		x = &fp2{c0: new(big.Int).Sub(fieldModulus, x0.c1), c1: x0.c0}
+		x.c0.Mod(x.c0, fieldModulus)
+	} else {
+		b := &fp2{c0: new(big.Int).Add(alpha.c0, big.NewInt(1)), c1: alpha.c1}
+		b.c0.Mod(b.c0, fieldModulus)
+		x = fp2Mul(fp2Exp(b, halfModulus), x0)
+	}
+	return x, fp2Equal(fp2Mul(x, x), a)
+}
+
+// FastAggregateVerify checks signature against the aggregate of pubkeys, all
+// signing the same message
+func FastAggregateVerify(pubkeys [][]byte, msg, signature []byte) error {
+	if len(pubkeys) == 0 {
+		return errors.New("FastAggregateVerify, no pubkey")
+	}
+	if len(signature) != SignatureLength {
+		return fmt.Errorf("FastAggregateVerify, invalid signature length: %d", len(signature))
+	}
+	g1 := bls12381.NewG1()
+	aggregate := g1.Zero()
+	for _, raw := range pubkeys {
+		p, err := g1.FromBytes(raw)
+		if err != nil {
+			return fmt.Errorf("FastAggregateVerify, invalid pubkey: %v", err)
+		}
+		g1.Add(aggregate, aggregate, p)
+	}
+
+	g2 := bls12381.NewG2()
+	sig, err := g2.FromBytes(signature)
+	if err != nil {
+		return fmt.Errorf("FastAggregateVerify, invalid signature: %v", err)
+	}
+	if g2.IsZero(sig) || !g2.InCorrectSubgroup(sig) {
+		return errors.New("FastAggregateVerify, signature is not in the correct subgroup")
+	}
+	h, err := HashToG2(msg, DST)
+	if err != nil {
+		return fmt.Errorf("FastAggregateVerify, %v", err)
+	}
+
+	// e(pk, H(m)) == e(g1, sig)
+	engine := bls12381.NewPairingEngine()
+	engine.AddPair(aggregate, h)
+	engine.AddPairInv(g1.One(), sig)
+	if !engine.Check() {
+		return errors.New("FastAggregateVerify, invalid signature")
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bls
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto/bls12381"
+	"gotest.tools/assert"
+)
+
+func TestHashToG2(t *testing.T) {
+	// RFC 9380 J.10.1, msg = ""
+	p, err := HashToG2([]byte{}, []byte("QUUX-V01-CS02-with-BLS12381G2_XMD:SHA-256_SSWU_RO_"))
+	assert.NilError(t, err)
+	expected := "05cb8437535e20ecffaef7752baddf98034139c38452458baeefab379ba13dff5bf5dd71b72418717047f5b0f37da03d" +
+		"0141ebfbdca40eb85b87142e130ab689c673cf60f1a3e98d69335266f30d9b8d4ac44c1038e9dcdd5393faf5c41fb78a" +
+		"12424ac32561493f3fe3c260708a12b7c620e7be00099a974e259ddc7d1f6395c3c811cdd19f1e8dbf3e9ecfdcbab8d6" +
+		"0503921d7f6a12805e72940b963c0cf3471c7b2a524950ca195d11062ee75ec076daf2d4bc358c4b190c0c98064fdd92"
+	assert.Equal(t, expected, hex.EncodeToString(bls12381.NewG2().ToBytes(p)))
+}
+
+func TestSignAndVerify(t *testing.T) {
+	// consensus spec bls sign test vector
+	sk, _ := new(big.Int).SetString("263dbd792f5b1be47ed85f8938c0f29586af0d3ac7b977f21c278fe1462040e3", 16)
+	msg := make([]byte, 32)
+	g1, g2 := bls12381.NewG1(), bls12381.NewG2()
+
+	h, err := HashToG2(msg, DST)
+	assert.NilError(t, err)
+	sig := g2.ToBytes(g2.MulScalar(g2.New(), h, sk))
+	compressed, err := CompressSignature(sig)
+	assert.NilError(t, err)
+	assert.Equal(t, "b6ed936746e01f8ecf281f020953fbf1f01debd5657c4a383940b020b26507f6076334f91e2366c96e9ab279fb5158090352ea1c5b0c9274504f4f0e7053af24802e51e4568d164fe986834f41e55c8e850ce1f98458c0cfc9ab380b55285a55",
+		hex.EncodeToString(compressed))
+
+	pubkey := g1.ToBytes(g1.MulScalar(g1.New(), g1.One(), sk))
+	compressed, err = CompressPubkey(pubkey)
+	assert.NilError(t, err)
+	assert.Equal(t, "a491d1b0ecd9bb917989f0e74f0dea0422eac4a873e5e2644f368dffb9a6e20fd6e10c1b77654d067c0618f6e5a7f79a",
+		hex.EncodeToString(compressed))
+
+	assert.NilError(t, FastAggregateVerify([][]byte{pubkey}, msg, sig))
+	msg[0] = 1
+	assert.ErrorContains(t, FastAggregateVerify([][]byte{pubkey}, msg, sig), "invalid signature")
+}
+
+func TestFastAggregateVerify(t *testing.T) {
+	g1, g2 := bls12381.NewG1(), bls12381.NewG2()
+	msg := []byte("sync committee")
+	h, err := HashToG2(msg, DST)
+	assert.NilError(t, err)
+
+	var pubkeys [][]byte
+	sum := new(big.Int)
+	for i := int64(1); i <= 3; i++ {
+		sk := big.NewInt(i * 1000003)
+		pubkeys = append(pubkeys, g1.ToBytes(g1.MulScalar(g1.New(), g1.One(), sk)))
+		sum.Add(sum, sk)
+	}
+	sig := g2.ToBytes(g2.MulScalar(g2.New(), h, sum))
+	assert.NilError(t, FastAggregateVerify(pubkeys, msg, sig))
+	assert.ErrorContains(t, FastAggregateVerify(pubkeys[:2], msg, sig), "invalid signature")
+
+	// points off the curve are rejected
+	bad := append([]byte{}, pubkeys[0]...)
+	bad[95] ^= 1
+	assert.ErrorContains(t, FastAggregateVerify([][]byte{bad, pubkeys[1], pubkeys[2]}, msg, sig), "invalid pubkey")
+	_, err = CompressPubkey(bad)
+	assert.Assert(t, err != nil)
+}
+
+func TestDecompress(t *testing.T) {
+	g1, g2 := bls12381.NewG1(), bls12381.NewG2()
+	h, err := HashToG2([]byte("vote"), DST)
+	assert.NilError(t, err)
+	for i := int64(1); i <= 8; i++ {
+		sk := big.NewInt(i * 7919)
+		pubkey := g1.ToBytes(g1.MulScalar(g1.New(), g1.One(), sk))
+		compressed, err := CompressPubkey(pubkey)
+		assert.NilError(t, err)
+		raw, err := DecompressPubkey(compressed)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, pubkey, raw)
+
+		sig := g2.ToBytes(g2.MulScalar(g2.New(), h, sk))
+		compressed, err = CompressSignature(sig)
+		assert.NilError(t, err)
+		raw, err = DecompressSignature(compressed)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, sig, raw)
+	}
+
+	// consensus spec pubkey and signature of the sign test vector
+	pubkey, _ := hex.DecodeString("a491d1b0ecd9bb917989f0e74f0dea0422eac4a873e5e2644f368dffb9a6e20fd6e10c1b77654d067c0618f6e5a7f79a")
+	sig, _ := hex.DecodeString("b6ed936746e01f8ecf281f020953fbf1f01debd5657c4a383940b020b26507f6076334f91e2366c96e9ab279fb5158090352ea1c5b0c9274504f4f0e7053af24802e51e4568d164fe986834f41e55c8e850ce1f98458c0cfc9ab380b55285a55")
+	rawPubkey, err := DecompressPubkey(pubkey)
+	assert.NilError(t, err)
+	rawSig, err := DecompressSignature(sig)
+	assert.NilError(t, err)
+	assert.NilError(t, FastAggregateVerify([][]byte{rawPubkey}, make([]byte, 32), rawSig))
+
+	_, err = DecompressPubkey(pubkey[1:])
+	assert.ErrorContains(t, err, "invalid pubkey length")
+	infinity := make([]byte, PubkeyCompressedLength)
+	infinity[0] = 0xc0
+	_, err = DecompressPubkey(infinity)
+	assert.ErrorContains(t, err, "point is infinity")
+	uncompressed := append([]byte{}, pubkey...)
+	uncompressed[0] &= 0x7f
+	_, err = DecompressPubkey(uncompressed)
+	assert.ErrorContains(t, err, "point is not compressed")
+}
@@ -28,6 +28,13 @@ import (
 )
 
 const (
+	//function name
+	SYNC_GENESIS_HEADER  = "syncGenesisHeader"
+	SYNC_BLOCK_HEADER    = "syncBlockHeader"
+	SYNC_CROSS_CHAIN_MSG = "syncCrossChainMsg"
+	SET_HEADER_RETENTION = "setHeaderRetention"
+	GET_HEADER_RETENTION = "getHeaderRetention"
+
 	//key prefix
 	CROSS_CHAIN_MSG             = "crossChainMsg"
 	CURRENT_MSG_HEIGHT          = "currentMsgHeight"
@@ -44,12 +51,30 @@ const (
 	SYNC_HEADER_NAME            = "syncHeader"
 	SYNC_CROSSCHAIN_MSG         = "syncCrossChainMsg"
 	POLYGON_SPAN                = "polygonSpan"
+	FINALIZED_HEADER            = "finalizedHeader"
+	SYNC_COMMITTEE              = "syncCommittee"
+	CONSENSUS_STATE             = "consensusState"
+	STATE_ROOT                  = "stateRoot"
+	HEADER_RETENTION            = "headerRetention"
+	PRUNED_HEIGHT               = "prunedHeight"
+	HEADER_PIN                  = "headerPin"
+	REORG_INFO                  = "reorgInfo"
+	REORG_COUNT                 = "reorgCount"
+	NOTIFY_REORG                = "reorg"
 )
 
 type HeaderSyncHandler interface {
 	SyncGenesisHeader(service *native.NativeService) error
-	SyncBlockHeader(service *native.NativeService) error
-	SyncCrossChainMsg(service *native.NativeService) error
+	SyncBlockHeader(service *native.NativeService) (uint64, error)
+	SyncCrossChainMsg(service *native.NativeService) (uint64, error)
+}
+
+// HeaderPruner is implemented by the header sync handlers whose main chain headers can be pruned.
+type HeaderPruner interface {
+	// GetHeaderRange returns the heights of the genesis header and the current header of the main chain.
+	GetHeaderRange(service *native.NativeService, chainID uint64) (uint64, uint64, error)
+	// PruneHeader deletes the main chain header at height and its height index, the genesis header is never pruned.
+	PruneHeader(service *native.NativeService, chainID uint64, height uint64) error
 }
 
 type SyncGenesisHeaderParam struct {
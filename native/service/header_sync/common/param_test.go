@@ -56,3 +56,43 @@ func TestSyncBlockHeaderParam(t *testing.T) {
 
 	assert.Equal(t, p, param)
 }
+
+func TestHeaderRetention(t *testing.T) {
+	p := HeaderRetention{
+		ChainID: 2,
+		Retain:  MIN_HEADER_RETENTION,
+	}
+
+	sink := common.NewZeroCopySink(nil)
+	p.Serialization(sink)
+
+	var param HeaderRetention
+	err := param.Deserialization(common.NewZeroCopySource(sink.Bytes()))
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, p, param)
+}
+
+func TestReorgInfo(t *testing.T) {
+	p := ReorgInfo{
+		ChainID:    2,
+		OldTip:     []byte{1, 2, 3},
+		OldHeight:  107,
+		NewTip:     []byte{4, 5, 6},
+		NewHeight:  108,
+		ForkHeight: 104,
+		PolyHeight: 1000,
+	}
+
+	sink := common.NewZeroCopySink(nil)
+	p.Serialization(sink)
+
+	var param ReorgInfo
+	err := param.Deserialization(common.NewZeroCopySource(sink.Bytes()))
+
+	assert.NoError(t, err)
+
+	assert.Equal(t, p, param)
+	assert.Equal(t, uint64(3), param.Depth())
+}
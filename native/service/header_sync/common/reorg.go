@@ -0,0 +1,197 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/common/config"
+	cstates "github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/event"
+	ccmcom "github.com/polynetwork/poly/native/service/cross_chain_manager/common"
+	"github.com/polynetwork/poly/native/service/utils"
+)
+
+const (
+	// at most this many replaced heights are searched for orphaned requests by one reorg
+	MAX_ORPHAN_HEIGHTS uint64 = 1000
+)
+
+// ReorgInfo describes a reorg of the main chain of an evm side chain, the main chain headers
+// from ForkHeight+1 to OldHeight were replaced by the branch ending at NewTip.
+type ReorgInfo struct {
+	ChainID    uint64
+	OldTip     []byte
+	OldHeight  uint64
+	NewTip     []byte
+	NewHeight  uint64
+	ForkHeight uint64
+	PolyHeight uint32
+}
+
+// Depth returns the number of main chain headers which were replaced.
+func (this *ReorgInfo) Depth() uint64 {
+	if this.OldHeight <= this.ForkHeight {
+		return 0
+	}
+	return this.OldHeight - this.ForkHeight
+}
+
+func (this *ReorgInfo) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarUint(this.ChainID)
+	sink.WriteVarBytes(this.OldTip)
+	sink.WriteVarUint(this.OldHeight)
+	sink.WriteVarBytes(this.NewTip)
+	sink.WriteVarUint(this.NewHeight)
+	sink.WriteVarUint(this.ForkHeight)
+	sink.WriteUint32(this.PolyHeight)
+}
+
+func (this *ReorgInfo) Deserialization(source *common.ZeroCopySource) error {
+	chainID, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("ReorgInfo deserialize chainID error")
+	}
+	oldTip, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("ReorgInfo deserialize oldTip error")
+	}
+	oldHeight, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("ReorgInfo deserialize oldHeight error")
+	}
+	newTip, eof := source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("ReorgInfo deserialize newTip error")
+	}
+	newHeight, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("ReorgInfo deserialize newHeight error")
+	}
+	forkHeight, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("ReorgInfo deserialize forkHeight error")
+	}
+	polyHeight, eof := source.NextUint32()
+	if eof {
+		return fmt.Errorf("ReorgInfo deserialize polyHeight error")
+	}
+	this.ChainID = chainID
+	this.OldTip = oldTip
+	this.OldHeight = oldHeight
+	this.NewTip = newTip
+	this.NewHeight = newHeight
+	this.ForkHeight = forkHeight
+	this.PolyHeight = polyHeight
+	return nil
+}
+
+// keys of the reorgs under the header sync contract, without the contract address
+
+func ReorgInfoKey(chainID uint64) []byte {
+	return append([]byte(REORG_INFO), utils.GetUint64Bytes(chainID)...)
+}
+
+func ReorgCountKey(chainID uint64) []byte {
+	return append([]byte(REORG_COUNT), utils.GetUint64Bytes(chainID)...)
+}
+
+// RecordReorg keeps reorg as the last reorg of its chain, notifies it and flags the cross chain
+// requests proven with one of the first MAX_ORPHAN_HEIGHTS replaced headers as orphaned. A reorg
+// of depth 0 only extended the main chain and is ignored, as are the reorgs below the reorg
+// record height.
+func RecordReorg(native *native.NativeService, reorg *ReorgInfo) error {
+	if reorg.Depth() == 0 {
+		return nil
+	}
+	if native.GetHeight() < config.GetReorgRecordHeight(config.DefConfig.P2PNode.NetworkId) {
+		return nil
+	}
+	count, err := GetReorgCount(native, reorg.ChainID)
+	if err != nil {
+		return fmt.Errorf("RecordReorg, %v", err)
+	}
+	reorg.PolyHeight = native.GetHeight()
+	sink := common.NewZeroCopySink(nil)
+	reorg.Serialization(sink)
+	native.GetCacheDB().Put(utils.ConcatKey(utils.HeaderSyncContractAddress, ReorgInfoKey(reorg.ChainID)), cstates.GenRawStorageItem(sink.Bytes()))
+	native.GetCacheDB().Put(utils.ConcatKey(utils.HeaderSyncContractAddress, ReorgCountKey(reorg.ChainID)), cstates.GenRawStorageItem(utils.GetUint64Bytes(count+1)))
+	NotifyReorg(native, reorg)
+
+	end := reorg.OldHeight
+	if reorg.Depth() > MAX_ORPHAN_HEIGHTS {
+		end = reorg.ForkHeight + MAX_ORPHAN_HEIGHTS
+	}
+	if err := ccmcom.FlagOrphanedRequests(native, reorg.ChainID, reorg.ForkHeight+1, end); err != nil {
+		return fmt.Errorf("RecordReorg, %v", err)
+	}
+	return nil
+}
+
+// GetLastReorg returns the last reorg of the main chain of chainID, nil if it never reorganized.
+func GetLastReorg(native *native.NativeService, chainID uint64) (*ReorgInfo, error) {
+	reorgStore, err := native.GetCacheDB().Get(utils.ConcatKey(utils.HeaderSyncContractAddress, ReorgInfoKey(chainID)))
+	if err != nil {
+		return nil, fmt.Errorf("GetLastReorg, get reorgStore error: %v", err)
+	}
+	if reorgStore == nil {
+		return nil, nil
+	}
+	reorgBytes, err := cstates.GetValueFromRawStorageItem(reorgStore)
+	if err != nil {
+		return nil, fmt.Errorf("GetLastReorg, deserialize from raw storage item err:%v", err)
+	}
+	reorg := new(ReorgInfo)
+	if err := reorg.Deserialization(common.NewZeroCopySource(reorgBytes)); err != nil {
+		return nil, fmt.Errorf("GetLastReorg, deserialize ReorgInfo error: %v", err)
+	}
+	return reorg, nil
+}
+
+// GetReorgCount returns the number of reorgs of the main chain of chainID.
+func GetReorgCount(native *native.NativeService, chainID uint64) (uint64, error) {
+	countStore, err := native.GetCacheDB().Get(utils.ConcatKey(utils.HeaderSyncContractAddress, ReorgCountKey(chainID)))
+	if err != nil {
+		return 0, fmt.Errorf("GetReorgCount, get countStore error: %v", err)
+	}
+	if countStore == nil {
+		return 0, nil
+	}
+	countBytes, err := cstates.GetValueFromRawStorageItem(countStore)
+	if err != nil {
+		return 0, fmt.Errorf("GetReorgCount, deserialize from raw storage item err:%v", err)
+	}
+	return utils.GetBytesUint64(countBytes), nil
+}
+
+// NotifyReorg notifies the old and new tip, the depth and the range of replaced heights of a reorg.
+func NotifyReorg(native *native.NativeService, reorg *ReorgInfo) {
+	if !config.DefConfig.Common.EnableEventLog {
+		return
+	}
+	native.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.HeaderSyncContractAddress,
+			States: []interface{}{NOTIFY_REORG, reorg.ChainID, reorg.OldHeight, hex.EncodeToString(reorg.OldTip),
+				reorg.NewHeight, hex.EncodeToString(reorg.NewTip), reorg.Depth(), reorg.ForkHeight + 1, reorg.OldHeight,
+				native.GetHeight()},
+		})
+}
@@ -0,0 +1,193 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+package common
+
+import (
+	"fmt"
+
+	"github.com/polynetwork/poly/common"
+	cstates "github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/utils"
+)
+
+const (
+	// the lookback of every prunable router fits in this window, e.g. the btc retarget window
+	MIN_HEADER_RETENTION uint64 = 4096
+	// at most this many heights are visited by the pruning of one header sync
+	MAX_PRUNE_HEADERS uint64 = 1000
+)
+
+// HeaderRetention keeps the last Retain main chain headers of a side chain, and the headers
+// pinned by its pending cross chain requests.
+type HeaderRetention struct {
+	ChainID uint64
+	Retain  uint64
+}
+
+func (this *HeaderRetention) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarUint(this.ChainID)
+	sink.WriteVarUint(this.Retain)
+}
+
+func (this *HeaderRetention) Deserialization(source *common.ZeroCopySource) error {
+	chainID, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("HeaderRetention deserialize chainID error")
+	}
+	retain, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("HeaderRetention deserialize retain error")
+	}
+	this.ChainID = chainID
+	this.Retain = retain
+	return nil
+}
+
+func PutHeaderRetention(native *native.NativeService, retention *HeaderRetention) {
+	sink := common.NewZeroCopySink(nil)
+	retention.Serialization(sink)
+	native.GetCacheDB().Put(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(HEADER_RETENTION),
+		utils.GetUint64Bytes(retention.ChainID)), cstates.GenRawStorageItem(sink.Bytes()))
+}
+
+func GetHeaderRetention(native *native.NativeService, chainID uint64) (*HeaderRetention, error) {
+	retentionStore, err := native.GetCacheDB().Get(utils.ConcatKey(utils.HeaderSyncContractAddress,
+		[]byte(HEADER_RETENTION), utils.GetUint64Bytes(chainID)))
+	if err != nil {
+		return nil, fmt.Errorf("GetHeaderRetention, get retentionStore error: %v", err)
+	}
+	if retentionStore == nil {
+		return nil, nil
+	}
+	retentionBytes, err := cstates.GetValueFromRawStorageItem(retentionStore)
+	if err != nil {
+		return nil, fmt.Errorf("GetHeaderRetention, deserialize from raw storage item err:%v", err)
+	}
+	retention := new(HeaderRetention)
+	if err := retention.Deserialization(common.NewZeroCopySource(retentionBytes)); err != nil {
+		return nil, fmt.Errorf("GetHeaderRetention, deserialize HeaderRetention error: %v", err)
+	}
+	return retention, nil
+}
+
+// DeleteHeaderRetention stops the pruning of a side chain, the pruned height is kept so that
+// a later policy goes on from where this one stopped.
+func DeleteHeaderRetention(native *native.NativeService, chainID uint64) {
+	native.GetCacheDB().Delete(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(HEADER_RETENTION),
+		utils.GetUint64Bytes(chainID)))
+}
+
+// GetPrunedHeight returns the next height to be pruned, 0 if nothing has been pruned yet.
+func GetPrunedHeight(native *native.NativeService, chainID uint64) (uint64, error) {
+	heightStore, err := native.GetCacheDB().Get(utils.ConcatKey(utils.HeaderSyncContractAddress,
+		[]byte(PRUNED_HEIGHT), utils.GetUint64Bytes(chainID)))
+	if err != nil {
+		return 0, fmt.Errorf("GetPrunedHeight, get heightStore error: %v", err)
+	}
+	if heightStore == nil {
+		return 0, nil
+	}
+	heightBytes, err := cstates.GetValueFromRawStorageItem(heightStore)
+	if err != nil {
+		return 0, fmt.Errorf("GetPrunedHeight, deserialize from raw storage item err:%v", err)
+	}
+	return utils.GetBytesUint64(heightBytes), nil
+}
+
+func PutPrunedHeight(native *native.NativeService, chainID uint64, height uint64) {
+	native.GetCacheDB().Put(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(PRUNED_HEIGHT),
+		utils.GetUint64Bytes(chainID)), cstates.GenRawStorageItem(utils.GetUint64Bytes(height)))
+}
+
+func headerPinKey(chainID uint64, height uint64) []byte {
+	return utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(HEADER_PIN), utils.GetUint64Bytes(chainID),
+		utils.GetUint64Bytes(height))
+}
+
+// GetHeaderPins returns the number of pending cross chain requests proven with the header at height.
+func GetHeaderPins(native *native.NativeService, chainID uint64, height uint64) (uint64, error) {
+	pinStore, err := native.GetCacheDB().Get(headerPinKey(chainID, height))
+	if err != nil {
+		return 0, fmt.Errorf("GetHeaderPins, get pinStore error: %v", err)
+	}
+	if pinStore == nil {
+		return 0, nil
+	}
+	pinBytes, err := cstates.GetValueFromRawStorageItem(pinStore)
+	if err != nil {
+		return 0, fmt.Errorf("GetHeaderPins, deserialize from raw storage item err:%v", err)
+	}
+	return utils.GetBytesUint64(pinBytes), nil
+}
+
+// PinHeader keeps the header at height from being pruned until it is unpinned, it does nothing
+// for a side chain without a retention policy.
+func PinHeader(native *native.NativeService, chainID uint64, height uint64) error {
+	retention, err := GetHeaderRetention(native, chainID)
+	if err != nil {
+		return fmt.Errorf("PinHeader, %v", err)
+	}
+	if retention == nil {
+		return nil
+	}
+	pins, err := GetHeaderPins(native, chainID, height)
+	if err != nil {
+		return fmt.Errorf("PinHeader, %v", err)
+	}
+	native.GetCacheDB().Put(headerPinKey(chainID, height), cstates.GenRawStorageItem(utils.GetUint64Bytes(pins+1)))
+	return nil
+}
+
+// UnpinHeader drops a pin of the header at height, and returns true once the header is not pinned any more.
+func UnpinHeader(native *native.NativeService, chainID uint64, height uint64) (bool, error) {
+	pins, err := GetHeaderPins(native, chainID, height)
+	if err != nil {
+		return false, fmt.Errorf("UnpinHeader, %v", err)
+	}
+	if pins == 0 {
+		return false, nil
+	}
+	if pins > 1 {
+		native.GetCacheDB().Put(headerPinKey(chainID, height), cstates.GenRawStorageItem(utils.GetUint64Bytes(pins-1)))
+		return false, nil
+	}
+	native.GetCacheDB().Delete(headerPinKey(chainID, height))
+	return true, nil
+}
+
+// PruneMainChainHeader prunes the header at height for the routers which index the main chain by
+// MAIN_CHAIN and keep the headers under HEADER_INDEX by hash.
+func PruneMainChainHeader(native *native.NativeService, chainID uint64, height uint64) error {
+	mainChainKey := utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(MAIN_CHAIN), utils.GetUint64Bytes(chainID),
+		utils.GetUint64Bytes(height))
+	hashStore, err := native.GetCacheDB().Get(mainChainKey)
+	if err != nil {
+		return fmt.Errorf("PruneMainChainHeader, get hashStore error: %v", err)
+	}
+	if hashStore == nil {
+		return nil
+	}
+	hashBytes, err := cstates.GetValueFromRawStorageItem(hashStore)
+	if err != nil {
+		return fmt.Errorf("PruneMainChainHeader, deserialize from raw storage item err:%v", err)
+	}
+	native.GetCacheDB().Delete(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(HEADER_INDEX),
+		utils.GetUint64Bytes(chainID), hashBytes))
+	native.GetCacheDB().Delete(mainChainKey)
+	return nil
+}
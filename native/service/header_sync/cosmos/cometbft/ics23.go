@@ -0,0 +1,391 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cometbft
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ICS23 hash and length operations, only those of the IAVL and the
+// tendermint proof specs are supported
+const (
+	hashOpNoHash   = 0
+	hashOpSha256   = 1
+	lengthOpNone   = 0
+	lengthOpVarint = 1
+)
+
+// LeafOp hashes the key and value of a proof into a leaf
+type LeafOp struct {
+	Hash         int64
+	PrehashKey   int64
+	PrehashValue int64
+	Length       int64
+	Prefix       []byte
+}
+
+func (this *LeafOp) apply(key, value []byte) ([]byte, error) {
+	pkey, err := prepareLeafData(this.PrehashKey, this.Length, key)
+	if err != nil {
+		return nil, err
+	}
+	pvalue, err := prepareLeafData(this.PrehashValue, this.Length, value)
+	if err != nil {
+		return nil, err
+	}
+	return doHash(this.Hash, append(append(append([]byte{}, this.Prefix...), pkey...), pvalue...))
+}
+
+// InnerOp hashes a child with its siblings into the parent
+type InnerOp struct {
+	Hash   int64
+	Prefix []byte
+	Suffix []byte
+}
+
+func (this *InnerOp) apply(child []byte) ([]byte, error) {
+	return doHash(this.Hash, append(append(append([]byte{}, this.Prefix...), child...), this.Suffix...))
+}
+
+// ExistenceProof proves that key is set to value under a root
+type ExistenceProof struct {
+	Key   []byte
+	Value []byte
+	Leaf  LeafOp
+	Path  []*InnerOp
+}
+
+func (this *ExistenceProof) calculate() ([]byte, error) {
+	res, err := this.Leaf.apply(this.Key, this.Value)
+	if err != nil {
+		return nil, err
+	}
+	for _, inner := range this.Path {
+		if res, err = inner.apply(res); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// proofSpec describes the tree a proof is checked against
+type proofSpec struct {
+	leaf      LeafOp
+	childSize int
+	minPrefix int
+	maxPrefix int
+	iavl      bool
+}
+
+var (
+	iavlSpec = &proofSpec{
+		leaf:      LeafOp{Hash: hashOpSha256, PrehashKey: hashOpNoHash, PrehashValue: hashOpSha256, Length: lengthOpVarint, Prefix: []byte{0}},
+		childSize: 33,
+		minPrefix: 4,
+		maxPrefix: 12,
+		iavl:      true,
+	}
+	tendermintSpec = &proofSpec{
+		leaf:      LeafOp{Hash: hashOpSha256, PrehashKey: hashOpNoHash, PrehashValue: hashOpSha256, Length: lengthOpVarint, Prefix: []byte{0}},
+		childSize: 32,
+		minPrefix: 1,
+		maxPrefix: 1,
+	}
+)
+
+// checkAgainstSpec rejects proofs that could be crafted from other nodes of
+// the tree, the trees are binary so a prefix holds at most one sibling
+func (this *ExistenceProof) checkAgainstSpec(spec *proofSpec) error {
+	leaf := &this.Leaf
+	if leaf.Hash != spec.leaf.Hash || leaf.PrehashKey != spec.leaf.PrehashKey ||
+		leaf.PrehashValue != spec.leaf.PrehashValue || leaf.Length != spec.leaf.Length {
+		return errors.New("leaf op does not match the spec")
+	}
+	if !bytes.HasPrefix(leaf.Prefix, spec.leaf.Prefix) {
+		return fmt.Errorf("leaf prefix %x does not start with %x", leaf.Prefix, spec.leaf.Prefix)
+	}
+	if spec.iavl {
+		if err := validateIavlPrefix(leaf.Prefix, 0); err != nil {
+			return fmt.Errorf("leaf: %v", err)
+		}
+	}
+	for i, inner := range this.Path {
+		if inner.Hash != hashOpSha256 {
+			return fmt.Errorf("inner op %d has hash op %d", i, inner.Hash)
+		}
+		if bytes.HasPrefix(inner.Prefix, spec.leaf.Prefix) {
+			return fmt.Errorf("inner op %d has a leaf prefix", i)
+		}
+		if len(inner.Prefix) < spec.minPrefix || len(inner.Prefix) > spec.maxPrefix+spec.childSize {
+			return fmt.Errorf("inner op %d has invalid prefix length %d", i, len(inner.Prefix))
+		}
+		if len(inner.Suffix)%spec.childSize != 0 {
+			return fmt.Errorf("inner op %d has invalid suffix length %d", i, len(inner.Suffix))
+		}
+		if spec.iavl {
+			if err := validateIavlPrefix(inner.Prefix, i+1); err != nil {
+				return fmt.Errorf("inner op %d: %v", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateIavlPrefix checks the height, size and version of an IAVL node,
+// inner nodes then have the length of the left child and the left child
+// if the proven node is on the right
+func validateIavlPrefix(prefix []byte, layer int) error {
+	r := bytes.NewReader(prefix)
+	height, err := binary.ReadVarint(r)
+	if err != nil || height < int64(layer) {
+		return errors.New("invalid height")
+	}
+	size, err := binary.ReadVarint(r)
+	if err != nil || size < 0 {
+		return errors.New("invalid size")
+	}
+	version, err := binary.ReadVarint(r)
+	if err != nil || version < 0 {
+		return errors.New("invalid version")
+	}
+	if layer == 0 {
+		if height != 0 || size != 1 || r.Len() != 0 {
+			return errors.New("invalid leaf")
+		}
+	} else if r.Len() != 1 && r.Len() != 34 {
+		return errors.New("invalid inner node")
+	}
+	return nil
+}
+
+func (this *ExistenceProof) unmarshal(buf []byte) error {
+	return decodeMessage(buf, func(r *protoReader, field, wireType int) (ok bool, err error) {
+		if field < 1 || field > 4 {
+			return false, nil
+		}
+		if err = expect(wireType, wireBytes); err != nil {
+			return true, err
+		}
+		raw, err := r.bytes()
+		if err != nil {
+			return true, err
+		}
+		switch field {
+		case 1:
+			this.Key = raw
+		case 2:
+			this.Value = raw
+		case 3:
+			err = decodeMessage(raw, func(r *protoReader, field, wireType int) (ok bool, err error) {
+				if field == 5 {
+					if err = expect(wireType, wireBytes); err == nil {
+						this.Leaf.Prefix, err = r.bytes()
+					}
+					return true, err
+				}
+				if field < 1 || field > 4 {
+					return false, nil
+				}
+				if err = expect(wireType, wireVarint); err != nil {
+					return true, err
+				}
+				v, err := r.varint()
+				switch field {
+				case 1:
+					this.Leaf.Hash = v
+				case 2:
+					this.Leaf.PrehashKey = v
+				case 3:
+					this.Leaf.PrehashValue = v
+				case 4:
+					this.Leaf.Length = v
+				}
+				return true, err
+			})
+		case 4:
+			inner := new(InnerOp)
+			err = decodeMessage(raw, func(r *protoReader, field, wireType int) (ok bool, err error) {
+				switch field {
+				case 1:
+					if err = expect(wireType, wireVarint); err == nil {
+						inner.Hash, err = r.varint()
+					}
+				case 2:
+					if err = expect(wireType, wireBytes); err == nil {
+						inner.Prefix, err = r.bytes()
+					}
+				case 3:
+					if err = expect(wireType, wireBytes); err == nil {
+						inner.Suffix, err = r.bytes()
+					}
+				default:
+					return false, nil
+				}
+				return true, err
+			})
+			this.Path = append(this.Path, inner)
+		}
+		return true, err
+	})
+}
+
+// Marshal encodes the proof as an ics23.ExistenceProof
+func (this *ExistenceProof) Marshal() []byte {
+	leaf := &protoWriter{}
+	leaf.varint(1, this.Leaf.Hash)
+	leaf.varint(2, this.Leaf.PrehashKey)
+	leaf.varint(3, this.Leaf.PrehashValue)
+	leaf.varint(4, this.Leaf.Length)
+	leaf.bytes(5, this.Leaf.Prefix)
+	w := &protoWriter{}
+	w.bytes(1, this.Key)
+	w.bytes(2, this.Value)
+	w.message(3, leaf.buf)
+	for _, inner := range this.Path {
+		op := &protoWriter{}
+		op.varint(1, inner.Hash)
+		op.bytes(2, inner.Prefix)
+		op.bytes(3, inner.Suffix)
+		w.message(4, op.buf)
+	}
+	return w.buf
+}
+
+// MerkleProof is an ibc.core.commitment.v1.MerkleProof of a key in a store of
+// the multistore, the first proof is of the key in the IAVL store and the
+// second of the store root in the multistore
+type MerkleProof struct {
+	Proofs []*ExistenceProof
+}
+
+// DecodeMerkleProof only accepts existence proofs
+func DecodeMerkleProof(buf []byte) (*MerkleProof, error) {
+	proof := new(MerkleProof)
+	err := decodeMessage(buf, func(r *protoReader, field, wireType int) (ok bool, err error) {
+		if field != 1 {
+			return false, nil
+		}
+		var raw []byte
+		if err = expect(wireType, wireBytes); err == nil {
+			if raw, err = r.bytes(); err == nil {
+				exist := new(ExistenceProof)
+				found := false
+				err = decodeMessage(raw, func(r *protoReader, field, wireType int) (ok bool, err error) {
+					if field != 1 {
+						return false, nil
+					}
+					found = true
+					var raw []byte
+					if err = expect(wireType, wireBytes); err == nil {
+						if raw, err = r.bytes(); err == nil {
+							err = exist.unmarshal(raw)
+						}
+					}
+					return true, err
+				})
+				if err == nil && !found {
+					err = errors.New("not an existence proof")
+				}
+				proof.Proofs = append(proof.Proofs, exist)
+			}
+		}
+		return true, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("DecodeMerkleProof, %v", err)
+	}
+	if len(proof.Proofs) != 2 {
+		return nil, fmt.Errorf("DecodeMerkleProof, expect 2 proofs, got %d", len(proof.Proofs))
+	}
+	return proof, nil
+}
+
+// Marshal encodes the proof as an ibc MerkleProof of existence proofs
+func (this *MerkleProof) Marshal() []byte {
+	w := &protoWriter{}
+	for _, proof := range this.Proofs {
+		exist := &protoWriter{}
+		exist.message(1, proof.Marshal())
+		w.message(1, exist.buf)
+	}
+	return w.buf
+}
+
+// Key is the key proven in the store
+func (this *MerkleProof) Key() []byte {
+	return this.Proofs[0].Key
+}
+
+// VerifyMembership checks that key is set to value in the store storeName of
+// the multistore committed in root, the app hash of a header
+func (this *MerkleProof) VerifyMembership(root []byte, storeName string, key, value []byte) error {
+	store, multistore := this.Proofs[0], this.Proofs[1]
+	if !bytes.Equal(store.Key, key) || !bytes.Equal(store.Value, value) {
+		return errors.New("VerifyMembership, proof is not for the key and value")
+	}
+	if err := store.checkAgainstSpec(iavlSpec); err != nil {
+		return fmt.Errorf("VerifyMembership, store proof: %v", err)
+	}
+	storeRoot, err := store.calculate()
+	if err != nil {
+		return fmt.Errorf("VerifyMembership, %v", err)
+	}
+	if !bytes.Equal(multistore.Key, []byte(storeName)) || !bytes.Equal(multistore.Value, storeRoot) {
+		return fmt.Errorf("VerifyMembership, multistore proof is not for the root of store %s", storeName)
+	}
+	if err := multistore.checkAgainstSpec(tendermintSpec); err != nil {
+		return fmt.Errorf("VerifyMembership, multistore proof: %v", err)
+	}
+	calculated, err := multistore.calculate()
+	if err != nil {
+		return fmt.Errorf("VerifyMembership, %v", err)
+	}
+	if !bytes.Equal(calculated, root) {
+		return fmt.Errorf("VerifyMembership, calculated root %x does not match %x", calculated, root)
+	}
+	return nil
+}
+
+func doHash(op int64, data []byte) ([]byte, error) {
+	switch op {
+	case hashOpNoHash:
+		return data, nil
+	case hashOpSha256:
+		hash := sha256.Sum256(data)
+		return hash[:], nil
+	}
+	return nil, fmt.Errorf("unsupported hash op %d", op)
+}
+
+func prepareLeafData(hashOp, lengthOp int64, data []byte) ([]byte, error) {
+	hashed, err := doHash(hashOp, data)
+	if err != nil {
+		return nil, err
+	}
+	switch lengthOp {
+	case lengthOpNone:
+		return hashed, nil
+	case lengthOpVarint:
+		return append(appendUvarint(nil, uint64(len(hashed))), hashed...), nil
+	}
+	return nil, fmt.Errorf("unsupported length op %d", lengthOp)
+}
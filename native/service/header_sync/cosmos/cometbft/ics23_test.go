@@ -0,0 +1,96 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cometbft
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+// iavlProof proves key in a two leaf IAVL tree, on the left if left is set
+func iavlProof(key, value, sibling []byte, left bool) *ExistenceProof {
+	proof := &ExistenceProof{
+		Key:   key,
+		Value: value,
+		Leaf:  iavlSpec.leaf,
+	}
+	// height 0, size 1, version 1 zigzag encoded
+	proof.Leaf.Prefix = []byte{0, 2, 2}
+	inner := &InnerOp{Hash: hashOpSha256, Prefix: []byte{2, 4, 2}}
+	if left {
+		inner.Prefix = append(inner.Prefix, 32)
+		inner.Suffix = append([]byte{32}, sibling...)
+	} else {
+		inner.Prefix = append(append(append(inner.Prefix, 32), sibling...), 32)
+	}
+	proof.Path = []*InnerOp{inner}
+	return proof
+}
+
+// multistoreProof proves the root of store as the left of two stores
+func multistoreProof(store string, root, sibling []byte) *ExistenceProof {
+	return &ExistenceProof{
+		Key:   []byte(store),
+		Value: root,
+		Leaf:  tendermintSpec.leaf,
+		Path:  []*InnerOp{{Hash: hashOpSha256, Prefix: []byte{1}, Suffix: sibling}},
+	}
+}
+
+func TestVerifyMembership(t *testing.T) {
+	sibling := sha256.Sum256([]byte("sibling"))
+	key, value := []byte("ccm/1"), []byte("param")
+
+	for _, left := range []bool{true, false} {
+		store := iavlProof(key, value, sibling[:], left)
+		storeRoot, err := store.calculate()
+		assert.NilError(t, err)
+		multistore := multistoreProof("ccm", storeRoot, sibling[:])
+		root, err := multistore.calculate()
+		assert.NilError(t, err)
+
+		proof, err := DecodeMerkleProof((&MerkleProof{Proofs: []*ExistenceProof{store, multistore}}).Marshal())
+		assert.NilError(t, err)
+		assert.DeepEqual(t, proof.Key(), key)
+		assert.NilError(t, proof.VerifyMembership(root, "ccm", key, value))
+
+		assert.ErrorContains(t, proof.VerifyMembership(root, "ccm", key, []byte("other")), "not for the key and value")
+		assert.ErrorContains(t, proof.VerifyMembership(root, "bank", key, value), "not for the root of store")
+		assert.ErrorContains(t, proof.VerifyMembership(sibling[:], "ccm", key, value), "does not match")
+	}
+
+	// ops outside of the specs are rejected
+	store := iavlProof(key, value, sibling[:], true)
+	store.Path[0].Prefix = []byte{0, 4, 2, 32}
+	assert.ErrorContains(t, store.checkAgainstSpec(iavlSpec), "leaf prefix")
+	store = iavlProof(key, value, sibling[:], true)
+	store.Leaf.Prefix = []byte{0, 4, 2}
+	assert.ErrorContains(t, store.checkAgainstSpec(iavlSpec), "invalid leaf")
+	store = iavlProof(key, value, sibling[:], true)
+	store.Leaf.PrehashValue = hashOpNoHash
+	assert.ErrorContains(t, store.checkAgainstSpec(iavlSpec), "does not match the spec")
+	multistore := multistoreProof("ccm", sibling[:], append(sibling[:], 0))
+	assert.ErrorContains(t, multistore.checkAgainstSpec(tendermintSpec), "suffix length")
+
+	_, err := DecodeMerkleProof((&MerkleProof{Proofs: []*ExistenceProof{store}}).Marshal())
+	assert.ErrorContains(t, err, "expect 2 proofs")
+	_, err = DecodeMerkleProof([]byte{10, 2, 18, 0})
+	assert.ErrorContains(t, err, "not an existence proof")
+}
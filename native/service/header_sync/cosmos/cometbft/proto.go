@@ -0,0 +1,195 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cometbft
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// protobuf wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// protoWriter encodes the protobuf wire format, zero values are skipped as
+// proto3 does unless the field is a non nullable message
+type protoWriter struct {
+	buf []byte
+}
+
+func (this *protoWriter) tag(field, wireType int) {
+	this.buf = appendUvarint(this.buf, uint64(field<<3|wireType))
+}
+
+func (this *protoWriter) uvarint(field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	this.tag(field, wireVarint)
+	this.buf = appendUvarint(this.buf, v)
+}
+
+// varint writes int32 and int64 values, negative values take ten bytes
+func (this *protoWriter) varint(field int, v int64) {
+	this.uvarint(field, uint64(v))
+}
+
+func (this *protoWriter) sfixed64(field int, v int64) {
+	if v == 0 {
+		return
+	}
+	this.tag(field, wireFixed64)
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], uint64(v))
+	this.buf = append(this.buf, b[:]...)
+}
+
+func (this *protoWriter) bytes(field int, v []byte) {
+	if len(v) == 0 {
+		return
+	}
+	this.message(field, v)
+}
+
+func (this *protoWriter) string(field int, v string) {
+	this.bytes(field, []byte(v))
+}
+
+// message writes an embedded message even if it is empty
+func (this *protoWriter) message(field int, v []byte) {
+	this.tag(field, wireBytes)
+	this.buf = appendUvarint(this.buf, uint64(len(v)))
+	this.buf = append(this.buf, v...)
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	return append(buf, b[:n]...)
+}
+
+// protoReader decodes the protobuf wire format
+type protoReader struct {
+	buf []byte
+}
+
+func (this *protoReader) done() bool {
+	return len(this.buf) == 0
+}
+
+func (this *protoReader) next() (field, wireType int, err error) {
+	key, err := this.uvarint()
+	if err != nil {
+		return
+	}
+	field, wireType = int(key>>3), int(key&7)
+	if field == 0 {
+		err = errors.New("invalid field number")
+	}
+	return
+}
+
+func (this *protoReader) uvarint() (uint64, error) {
+	v, n := binary.Uvarint(this.buf)
+	if n <= 0 {
+		return 0, errors.New("invalid varint")
+	}
+	this.buf = this.buf[n:]
+	return v, nil
+}
+
+func (this *protoReader) varint() (int64, error) {
+	v, err := this.uvarint()
+	return int64(v), err
+}
+
+func (this *protoReader) fixed64() (uint64, error) {
+	if len(this.buf) < 8 {
+		return 0, errors.New("unexpected end of fixed64")
+	}
+	v := binary.LittleEndian.Uint64(this.buf)
+	this.buf = this.buf[8:]
+	return v, nil
+}
+
+func (this *protoReader) bytes() ([]byte, error) {
+	l, err := this.uvarint()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(this.buf)) < l {
+		return nil, errors.New("unexpected end of bytes")
+	}
+	v := this.buf[:l]
+	this.buf = this.buf[l:]
+	return v, nil
+}
+
+func (this *protoReader) skip(wireType int) (err error) {
+	switch wireType {
+	case wireVarint:
+		_, err = this.uvarint()
+	case wireFixed64:
+		_, err = this.fixed64()
+	case wireBytes:
+		_, err = this.bytes()
+	case wireFixed32:
+		if len(this.buf) < 4 {
+			return errors.New("unexpected end of fixed32")
+		}
+		this.buf = this.buf[4:]
+	default:
+		err = fmt.Errorf("unsupported wire type %d", wireType)
+	}
+	return
+}
+
+// decodeMessage calls read for each field of a message, read returns false
+// for fields it does not know which are then skipped
+func decodeMessage(buf []byte, read func(r *protoReader, field, wireType int) (bool, error)) error {
+	r := &protoReader{buf: buf}
+	for !r.done() {
+		field, wireType, err := r.next()
+		if err != nil {
+			return err
+		}
+		ok, err := read(r, field, wireType)
+		if err != nil {
+			return fmt.Errorf("field %d: %v", field, err)
+		}
+		if !ok {
+			if err := r.skip(wireType); err != nil {
+				return fmt.Errorf("field %d: %v", field, err)
+			}
+		}
+	}
+	return nil
+}
+
+// expect checks the wire type of a known field
+func expect(wireType, want int) error {
+	if wireType != want {
+		return fmt.Errorf("unexpected wire type %d", wireType)
+	}
+	return nil
+}
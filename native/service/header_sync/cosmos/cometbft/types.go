@@ -0,0 +1,784 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cometbft
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"time"
+
+	ecrypto "github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/ripemd160"
+)
+
+// block id flags of commit signatures
+const (
+	BlockIDFlagAbsent = 1
+	BlockIDFlagCommit = 2
+	BlockIDFlagNil    = 3
+)
+
+// public key types of validators
+const (
+	KeyTypeEd25519   = "ed25519"
+	KeyTypeSecp256k1 = "secp256k1"
+)
+
+const (
+	precommitType       = 2
+	addressLength       = 20
+	hashLength          = 32
+	maxChainIDLength    = 50
+	maxTotalVotingPower = int64(^uint64(0)>>1) / 8
+)
+
+// Timestamp is a google.protobuf.Timestamp
+type Timestamp struct {
+	Seconds int64
+	Nanos   int32
+}
+
+// Time converts the protobuf timestamp
+func (this Timestamp) Time() time.Time {
+	return time.Unix(this.Seconds, int64(this.Nanos)).UTC()
+}
+
+func (this Timestamp) marshal() []byte {
+	w := new(protoWriter)
+	w.varint(1, this.Seconds)
+	w.varint(2, int64(this.Nanos))
+	return w.buf
+}
+
+func (this *Timestamp) unmarshal(buf []byte) error {
+	return decodeMessage(buf, func(r *protoReader, field, wireType int) (ok bool, err error) {
+		switch field {
+		case 1:
+			if err = expect(wireType, wireVarint); err == nil {
+				this.Seconds, err = r.varint()
+			}
+		case 2:
+			var v int64
+			if err = expect(wireType, wireVarint); err == nil {
+				v, err = r.varint()
+				this.Nanos = int32(v)
+			}
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+// Consensus is the block and app protocol version of a header
+type Consensus struct {
+	Block uint64
+	App   uint64
+}
+
+func (this Consensus) marshal() []byte {
+	w := new(protoWriter)
+	w.uvarint(1, this.Block)
+	w.uvarint(2, this.App)
+	return w.buf
+}
+
+// PartSetHeader identifies the parts a block is gossiped in
+type PartSetHeader struct {
+	Total uint32
+	Hash  []byte
+}
+
+// BlockID is the hash of a header and of its parts
+type BlockID struct {
+	Hash          []byte
+	PartSetHeader PartSetHeader
+}
+
+// marshal encodes the block id, the canonical block id of votes has the same encoding
+func (this *BlockID) marshal() []byte {
+	psh := new(protoWriter)
+	psh.uvarint(1, uint64(this.PartSetHeader.Total))
+	psh.bytes(2, this.PartSetHeader.Hash)
+	w := new(protoWriter)
+	w.bytes(1, this.Hash)
+	w.message(2, psh.buf)
+	return w.buf
+}
+
+func (this *BlockID) unmarshal(buf []byte) error {
+	return decodeMessage(buf, func(r *protoReader, field, wireType int) (ok bool, err error) {
+		switch field {
+		case 1:
+			if err = expect(wireType, wireBytes); err == nil {
+				this.Hash, err = r.bytes()
+			}
+		case 2:
+			var raw []byte
+			if err = expect(wireType, wireBytes); err == nil {
+				if raw, err = r.bytes(); err == nil {
+					err = decodeMessage(raw, func(r *protoReader, field, wireType int) (ok bool, err error) {
+						switch field {
+						case 1:
+							var v uint64
+							if err = expect(wireType, wireVarint); err == nil {
+								v, err = r.uvarint()
+								this.PartSetHeader.Total = uint32(v)
+							}
+						case 2:
+							if err = expect(wireType, wireBytes); err == nil {
+								this.PartSetHeader.Hash, err = r.bytes()
+							}
+						default:
+							return false, nil
+						}
+						return true, err
+					})
+				}
+			}
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+// IsZero is true for the block id of nil votes
+func (this *BlockID) IsZero() bool {
+	return len(this.Hash) == 0 && this.PartSetHeader.Total == 0 && len(this.PartSetHeader.Hash) == 0
+}
+
+// Equal compares both hashes and the part count
+func (this *BlockID) Equal(other *BlockID) bool {
+	return bytes.Equal(this.Hash, other.Hash) && this.PartSetHeader.Total == other.PartSetHeader.Total &&
+		bytes.Equal(this.PartSetHeader.Hash, other.PartSetHeader.Hash)
+}
+
+// Header is a CometBFT block header
+type Header struct {
+	Version            Consensus
+	ChainID            string
+	Height             int64
+	Time               Timestamp
+	LastBlockID        BlockID
+	LastCommitHash     []byte
+	DataHash           []byte
+	ValidatorsHash     []byte
+	NextValidatorsHash []byte
+	ConsensusHash      []byte
+	AppHash            []byte
+	LastResultsHash    []byte
+	EvidenceHash       []byte
+	ProposerAddress    []byte
+}
+
+// Hash is the merkle root of the protobuf encoded fields
+func (this *Header) Hash() []byte {
+	bytesValue := func(v []byte) []byte {
+		w := new(protoWriter)
+		w.bytes(1, v)
+		return w.buf
+	}
+	chainID := new(protoWriter)
+	chainID.string(1, this.ChainID)
+	height := new(protoWriter)
+	height.varint(1, this.Height)
+	return hashFromByteSlices([][]byte{
+		this.Version.marshal(),
+		chainID.buf,
+		height.buf,
+		this.Time.marshal(),
+		this.LastBlockID.marshal(),
+		bytesValue(this.LastCommitHash),
+		bytesValue(this.DataHash),
+		bytesValue(this.ValidatorsHash),
+		bytesValue(this.NextValidatorsHash),
+		bytesValue(this.ConsensusHash),
+		bytesValue(this.AppHash),
+		bytesValue(this.LastResultsHash),
+		bytesValue(this.EvidenceHash),
+		bytesValue(this.ProposerAddress),
+	})
+}
+
+// ValidateBasic checks the fields that do not depend on other headers
+func (this *Header) ValidateBasic() error {
+	if len(this.ChainID) > maxChainIDLength {
+		return fmt.Errorf("chain id is too long")
+	}
+	if this.Height <= 0 {
+		return fmt.Errorf("non positive height %d", this.Height)
+	}
+	for _, hash := range [][]byte{this.LastBlockID.Hash, this.LastCommitHash, this.DataHash, this.EvidenceHash, this.LastResultsHash} {
+		if len(hash) != 0 && len(hash) != hashLength {
+			return fmt.Errorf("invalid hash length %d", len(hash))
+		}
+	}
+	for _, hash := range [][]byte{this.ValidatorsHash, this.NextValidatorsHash, this.ConsensusHash} {
+		if len(hash) != hashLength {
+			return fmt.Errorf("invalid hash length %d", len(hash))
+		}
+	}
+	if len(this.ProposerAddress) != addressLength {
+		return fmt.Errorf("invalid proposer address length %d", len(this.ProposerAddress))
+	}
+	return nil
+}
+
+func (this *Header) unmarshal(buf []byte) error {
+	return decodeMessage(buf, func(r *protoReader, field, wireType int) (ok bool, err error) {
+		if field == 3 {
+			if err = expect(wireType, wireVarint); err == nil {
+				this.Height, err = r.varint()
+			}
+			return true, err
+		}
+		if field < 1 || field > 14 {
+			return false, nil
+		}
+		if err = expect(wireType, wireBytes); err != nil {
+			return true, err
+		}
+		raw, err := r.bytes()
+		if err != nil {
+			return true, err
+		}
+		switch field {
+		case 1:
+			err = decodeMessage(raw, func(r *protoReader, field, wireType int) (ok bool, err error) {
+				if field != 1 && field != 2 {
+					return false, nil
+				}
+				var v uint64
+				if err = expect(wireType, wireVarint); err == nil {
+					v, err = r.uvarint()
+				}
+				if field == 1 {
+					this.Version.Block = v
+				} else {
+					this.Version.App = v
+				}
+				return true, err
+			})
+		case 2:
+			this.ChainID = string(raw)
+		case 4:
+			err = this.Time.unmarshal(raw)
+		case 5:
+			err = this.LastBlockID.unmarshal(raw)
+		case 6:
+			this.LastCommitHash = raw
+		case 7:
+			this.DataHash = raw
+		case 8:
+			this.ValidatorsHash = raw
+		case 9:
+			this.NextValidatorsHash = raw
+		case 10:
+			this.ConsensusHash = raw
+		case 11:
+			this.AppHash = raw
+		case 12:
+			this.LastResultsHash = raw
+		case 13:
+			this.EvidenceHash = raw
+		case 14:
+			this.ProposerAddress = raw
+		}
+		return true, err
+	})
+}
+
+// PubKey is a validator key of type KeyTypeEd25519 or KeyTypeSecp256k1
+type PubKey struct {
+	Type string
+	Key  []byte
+}
+
+// Address is the validator address derived from the key
+func (this *PubKey) Address() []byte {
+	hash := sha256.Sum256(this.Key)
+	if this.Type == KeyTypeSecp256k1 {
+		hasher := ripemd160.New()
+		hasher.Write(hash[:])
+		return hasher.Sum(nil)
+	}
+	return hash[:addressLength]
+}
+
+// VerifySignature checks sig over msg, false for unknown key types
+func (this *PubKey) VerifySignature(msg, sig []byte) bool {
+	switch this.Type {
+	case KeyTypeEd25519:
+		return len(this.Key) == ed25519.PublicKeySize && ed25519.Verify(this.Key, msg, sig)
+	case KeyTypeSecp256k1:
+		// signatures are r || s over the sha256 of the message
+		hash := sha256.Sum256(msg)
+		return len(sig) == 64 && ecrypto.VerifySignature(this.Key, hash[:], sig)
+	}
+	return false
+}
+
+func (this *PubKey) marshal() []byte {
+	w := new(protoWriter)
+	if this.Type == KeyTypeSecp256k1 {
+		w.bytes(2, this.Key)
+	} else {
+		w.bytes(1, this.Key)
+	}
+	return w.buf
+}
+
+func (this *PubKey) unmarshal(buf []byte) error {
+	return decodeMessage(buf, func(r *protoReader, field, wireType int) (ok bool, err error) {
+		switch field {
+		case 1:
+			this.Type = KeyTypeEd25519
+		case 2:
+			this.Type = KeyTypeSecp256k1
+		default:
+			return false, nil
+		}
+		if err = expect(wireType, wireBytes); err == nil {
+			this.Key, err = r.bytes()
+		}
+		return true, err
+	})
+}
+
+// Validator is a member of a validator set with its voting power
+type Validator struct {
+	Address          []byte
+	PubKey           PubKey
+	VotingPower      int64
+	ProposerPriority int64
+}
+
+// Bytes is the encoding of the validator hashed into the validator set hash
+func (this *Validator) Bytes() []byte {
+	w := new(protoWriter)
+	w.message(1, this.PubKey.marshal())
+	w.varint(2, this.VotingPower)
+	return w.buf
+}
+
+func (this *Validator) unmarshal(buf []byte) error {
+	return decodeMessage(buf, func(r *protoReader, field, wireType int) (ok bool, err error) {
+		switch field {
+		case 1:
+			if err = expect(wireType, wireBytes); err == nil {
+				this.Address, err = r.bytes()
+			}
+		case 2:
+			var raw []byte
+			if err = expect(wireType, wireBytes); err == nil {
+				if raw, err = r.bytes(); err == nil {
+					err = this.PubKey.unmarshal(raw)
+				}
+			}
+		case 3:
+			if err = expect(wireType, wireVarint); err == nil {
+				this.VotingPower, err = r.varint()
+			}
+		case 4:
+			if err = expect(wireType, wireVarint); err == nil {
+				this.ProposerPriority, err = r.varint()
+			}
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+// ValidatorSet lists validators in the order of the commit signatures
+type ValidatorSet struct {
+	Validators []*Validator
+}
+
+// Hash is the merkle root of the validators in set order
+func (this *ValidatorSet) Hash() []byte {
+	items := make([][]byte, len(this.Validators))
+	for i, v := range this.Validators {
+		items[i] = v.Bytes()
+	}
+	return hashFromByteSlices(items)
+}
+
+// TotalVotingPower sums the voting power of the set
+func (this *ValidatorSet) TotalVotingPower() int64 {
+	total := int64(0)
+	for _, v := range this.Validators {
+		total += v.VotingPower
+	}
+	return total
+}
+
+// ValidateBasic checks the addresses and the voting powers
+func (this *ValidatorSet) ValidateBasic() error {
+	if len(this.Validators) == 0 {
+		return errors.New("validator set is empty")
+	}
+	total := int64(0)
+	for i, v := range this.Validators {
+		if v.PubKey.Type != KeyTypeEd25519 && v.PubKey.Type != KeyTypeSecp256k1 {
+			return fmt.Errorf("validator %d has no public key", i)
+		}
+		if !bytes.Equal(v.Address, v.PubKey.Address()) {
+			return fmt.Errorf("validator %d address does not match its public key", i)
+		}
+		if v.VotingPower <= 0 || v.VotingPower > maxTotalVotingPower {
+			return fmt.Errorf("validator %d has invalid voting power %d", i, v.VotingPower)
+		}
+		total += v.VotingPower
+		if total > maxTotalVotingPower {
+			return errors.New("total voting power is too large")
+		}
+	}
+	return nil
+}
+
+func (this *ValidatorSet) getByAddress(address []byte) (int, *Validator) {
+	for i, v := range this.Validators {
+		if bytes.Equal(v.Address, address) {
+			return i, v
+		}
+	}
+	return -1, nil
+}
+
+func (this *ValidatorSet) unmarshal(buf []byte) error {
+	return decodeMessage(buf, func(r *protoReader, field, wireType int) (ok bool, err error) {
+		if field != 1 {
+			return false, nil
+		}
+		var raw []byte
+		if err = expect(wireType, wireBytes); err == nil {
+			if raw, err = r.bytes(); err == nil {
+				v := new(Validator)
+				err = v.unmarshal(raw)
+				this.Validators = append(this.Validators, v)
+			}
+		}
+		return true, err
+	})
+}
+
+// CommitSig is the precommit of a validator, in validator set order
+type CommitSig struct {
+	BlockIDFlag      int32
+	ValidatorAddress []byte
+	Timestamp        Timestamp
+	Signature        []byte
+}
+
+func (this *CommitSig) unmarshal(buf []byte) error {
+	return decodeMessage(buf, func(r *protoReader, field, wireType int) (ok bool, err error) {
+		switch field {
+		case 1:
+			var v int64
+			if err = expect(wireType, wireVarint); err == nil {
+				v, err = r.varint()
+				this.BlockIDFlag = int32(v)
+			}
+		case 2:
+			if err = expect(wireType, wireBytes); err == nil {
+				this.ValidatorAddress, err = r.bytes()
+			}
+		case 3:
+			var raw []byte
+			if err = expect(wireType, wireBytes); err == nil {
+				if raw, err = r.bytes(); err == nil {
+					err = this.Timestamp.unmarshal(raw)
+				}
+			}
+		case 4:
+			if err = expect(wireType, wireBytes); err == nil {
+				this.Signature, err = r.bytes()
+			}
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+// Commit holds the precommits for a block
+type Commit struct {
+	Height     int64
+	Round      int32
+	BlockID    BlockID
+	Signatures []*CommitSig
+}
+
+// VoteSignBytes returns the length delimited canonical vote signed by the validator at idx
+func (this *Commit) VoteSignBytes(chainID string, idx int) []byte {
+	sig := this.Signatures[idx]
+	w := new(protoWriter)
+	w.varint(1, precommitType)
+	w.sfixed64(2, this.Height)
+	w.sfixed64(3, int64(this.Round))
+	if sig.BlockIDFlag == BlockIDFlagCommit && !this.BlockID.IsZero() {
+		w.message(4, this.BlockID.marshal())
+	}
+	w.message(5, sig.Timestamp.marshal())
+	w.string(6, chainID)
+	return append(appendUvarint(nil, uint64(len(w.buf))), w.buf...)
+}
+
+func (this *Commit) unmarshal(buf []byte) error {
+	return decodeMessage(buf, func(r *protoReader, field, wireType int) (ok bool, err error) {
+		switch field {
+		case 1:
+			if err = expect(wireType, wireVarint); err == nil {
+				this.Height, err = r.varint()
+			}
+		case 2:
+			var v int64
+			if err = expect(wireType, wireVarint); err == nil {
+				v, err = r.varint()
+				this.Round = int32(v)
+			}
+		case 3:
+			var raw []byte
+			if err = expect(wireType, wireBytes); err == nil {
+				if raw, err = r.bytes(); err == nil {
+					err = this.BlockID.unmarshal(raw)
+				}
+			}
+		case 4:
+			var raw []byte
+			if err = expect(wireType, wireBytes); err == nil {
+				if raw, err = r.bytes(); err == nil {
+					sig := new(CommitSig)
+					err = sig.unmarshal(raw)
+					this.Signatures = append(this.Signatures, sig)
+				}
+			}
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+// SignedHeader is a header with the commit for it
+type SignedHeader struct {
+	Header *Header
+	Commit *Commit
+}
+
+// ValidateBasic checks the header against chainID and that the commit is for the header
+func (this *SignedHeader) ValidateBasic(chainID string) error {
+	if this.Header == nil || this.Commit == nil {
+		return errors.New("missing header or commit")
+	}
+	if this.Header.ChainID != chainID {
+		return fmt.Errorf("header belongs to another chain %q, not %q", this.Header.ChainID, chainID)
+	}
+	if err := this.Header.ValidateBasic(); err != nil {
+		return fmt.Errorf("invalid header: %v", err)
+	}
+	if this.Commit.Height != this.Header.Height {
+		return fmt.Errorf("commit height %d does not match header height %d", this.Commit.Height, this.Header.Height)
+	}
+	if hash := this.Header.Hash(); !bytes.Equal(this.Commit.BlockID.Hash, hash) {
+		return fmt.Errorf("commit signs block %X, header is block %X", this.Commit.BlockID.Hash, hash)
+	}
+	return nil
+}
+
+func (this *SignedHeader) unmarshal(buf []byte) error {
+	return decodeMessage(buf, func(r *protoReader, field, wireType int) (ok bool, err error) {
+		var raw []byte
+		switch field {
+		case 1:
+			if err = expect(wireType, wireBytes); err == nil {
+				if raw, err = r.bytes(); err == nil {
+					this.Header = new(Header)
+					err = this.Header.unmarshal(raw)
+				}
+			}
+		case 2:
+			if err = expect(wireType, wireBytes); err == nil {
+				if raw, err = r.bytes(); err == nil {
+					this.Commit = new(Commit)
+					err = this.Commit.unmarshal(raw)
+				}
+			}
+		default:
+			return false, nil
+		}
+		return true, err
+	})
+}
+
+// LightHeader is an ibc.lightclients.tendermint.v1.Header, TrustedValidators
+// are the next validators of the header at TrustedHeight
+type LightHeader struct {
+	SignedHeader      SignedHeader
+	ValidatorSet      ValidatorSet
+	TrustedHeight     uint64
+	TrustedValidators ValidatorSet
+}
+
+// DecodeLightHeader decodes the protobuf of an ibc tendermint header
+func DecodeLightHeader(buf []byte) (*LightHeader, error) {
+	header := new(LightHeader)
+	err := decodeMessage(buf, func(r *protoReader, field, wireType int) (ok bool, err error) {
+		if field < 1 || field > 4 {
+			return false, nil
+		}
+		if err = expect(wireType, wireBytes); err != nil {
+			return true, err
+		}
+		raw, err := r.bytes()
+		if err != nil {
+			return true, err
+		}
+		switch field {
+		case 1:
+			err = header.SignedHeader.unmarshal(raw)
+		case 2:
+			err = header.ValidatorSet.unmarshal(raw)
+		case 3:
+			// ibc.core.client.v1.Height, the revision number is not used
+			err = decodeMessage(raw, func(r *protoReader, field, wireType int) (ok bool, err error) {
+				if field != 2 {
+					return false, nil
+				}
+				if err = expect(wireType, wireVarint); err == nil {
+					header.TrustedHeight, err = r.uvarint()
+				}
+				return true, err
+			})
+		case 4:
+			err = header.TrustedValidators.unmarshal(raw)
+		}
+		return true, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("DecodeLightHeader, %v", err)
+	}
+	return header, nil
+}
+
+// hashFromByteSlices computes the RFC 6962 merkle root of items
+func hashFromByteSlices(items [][]byte) []byte {
+	switch len(items) {
+	case 0:
+		hash := sha256.Sum256(nil)
+		return hash[:]
+	case 1:
+		hash := sha256.Sum256(append([]byte{0}, items[0]...))
+		return hash[:]
+	}
+	k := 1
+	for k*2 < len(items) {
+		k *= 2
+	}
+	left, right := hashFromByteSlices(items[:k]), hashFromByteSlices(items[k:])
+	hash := sha256.Sum256(append(append([]byte{1}, left...), right...))
+	return hash[:]
+}
+
+// Marshal encodes the header as a tendermint.types.Header
+func (this *Header) Marshal() []byte {
+	w := new(protoWriter)
+	w.message(1, this.Version.marshal())
+	w.string(2, this.ChainID)
+	w.varint(3, this.Height)
+	w.message(4, this.Time.marshal())
+	w.message(5, this.LastBlockID.marshal())
+	for i, hash := range [][]byte{this.LastCommitHash, this.DataHash, this.ValidatorsHash, this.NextValidatorsHash,
+		this.ConsensusHash, this.AppHash, this.LastResultsHash, this.EvidenceHash, this.ProposerAddress} {
+		w.bytes(6+i, hash)
+	}
+	return w.buf
+}
+
+// Marshal encodes the validator as a tendermint.types.Validator
+func (this *Validator) Marshal() []byte {
+	w := new(protoWriter)
+	w.bytes(1, this.Address)
+	w.message(2, this.PubKey.marshal())
+	w.varint(3, this.VotingPower)
+	w.varint(4, this.ProposerPriority)
+	return w.buf
+}
+
+// Marshal encodes the set as a tendermint.types.ValidatorSet
+func (this *ValidatorSet) Marshal() []byte {
+	w := new(protoWriter)
+	for _, v := range this.Validators {
+		w.message(1, v.Marshal())
+	}
+	w.varint(3, this.TotalVotingPower())
+	return w.buf
+}
+
+// Marshal encodes the signature as a tendermint.types.CommitSig
+func (this *CommitSig) Marshal() []byte {
+	w := new(protoWriter)
+	w.varint(1, int64(this.BlockIDFlag))
+	w.bytes(2, this.ValidatorAddress)
+	w.message(3, this.Timestamp.marshal())
+	w.bytes(4, this.Signature)
+	return w.buf
+}
+
+// Marshal encodes the commit as a tendermint.types.Commit
+func (this *Commit) Marshal() []byte {
+	w := new(protoWriter)
+	w.varint(1, this.Height)
+	w.varint(2, int64(this.Round))
+	w.message(3, this.BlockID.marshal())
+	for _, sig := range this.Signatures {
+		w.message(4, sig.Marshal())
+	}
+	return w.buf
+}
+
+// Marshal encodes the header as a tendermint.types.SignedHeader
+func (this *SignedHeader) Marshal() []byte {
+	w := new(protoWriter)
+	if this.Header != nil {
+		w.message(1, this.Header.Marshal())
+	}
+	if this.Commit != nil {
+		w.message(2, this.Commit.Marshal())
+	}
+	return w.buf
+}
+
+// Marshal encodes the header as an ibc.lightclients.tendermint.v1.Header
+func (this *LightHeader) Marshal() []byte {
+	height := new(protoWriter)
+	height.uvarint(2, this.TrustedHeight)
+	w := new(protoWriter)
+	w.message(1, this.SignedHeader.Marshal())
+	w.message(2, this.ValidatorSet.Marshal())
+	w.message(3, height.buf)
+	if len(this.TrustedValidators.Validators) > 0 {
+		w.message(4, this.TrustedValidators.Marshal())
+	}
+	return w.buf
+}
@@ -0,0 +1,93 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cometbft
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	ecrypto "github.com/ethereum/go-ethereum/crypto"
+	"gotest.tools/assert"
+)
+
+func TestHeaderHash(t *testing.T) {
+	// CometBFT types.TestHeaderHash
+	sum := func(s string) []byte {
+		hash := sha256.Sum256([]byte(s))
+		return hash[:]
+	}
+	header := &Header{
+		Version:            Consensus{Block: 1, App: 2},
+		ChainID:            "chainId",
+		Height:             3,
+		Time:               Timestamp{Seconds: time.Date(2019, 10, 13, 16, 14, 44, 0, time.UTC).Unix()},
+		LastBlockID:        BlockID{Hash: make([]byte, 32), PartSetHeader: PartSetHeader{Total: 6, Hash: make([]byte, 32)}},
+		LastCommitHash:     sum("last_commit_hash"),
+		DataHash:           sum("data_hash"),
+		ValidatorsHash:     sum("validators_hash"),
+		NextValidatorsHash: sum("next_validators_hash"),
+		ConsensusHash:      sum("consensus_hash"),
+		AppHash:            sum("app_hash"),
+		LastResultsHash:    sum("last_results_hash"),
+		EvidenceHash:       sum("evidence_hash"),
+		ProposerAddress:    sum("proposer_address")[:20],
+	}
+	assert.Equal(t, "f740121f553b5418c3efbd343c2dbfe9e007bb67b0d020a0741374bab65242a4", hex.EncodeToString(header.Hash()))
+
+	decoded := new(Header)
+	assert.NilError(t, decoded.unmarshal(header.Marshal()))
+	assert.DeepEqual(t, header, decoded)
+}
+
+func TestVoteSignBytes(t *testing.T) {
+	// CometBFT types.TestVoteSignBytesTestVectors, the zero time stamp
+	zero := Timestamp{Seconds: time.Time{}.Unix()}
+	commit := &Commit{Height: 1, Round: 1, Signatures: []*CommitSig{{Timestamp: zero}}}
+	assert.Equal(t, "2108021101000000000000001901000000000000002a0b088092b8c398feffffff01",
+		hex.EncodeToString(commit.VoteSignBytes("", 0)))
+	assert.Equal(t, "3008021101000000000000001901000000000000002a0b088092b8c398feffffff01320d746573745f636861696e5f6964",
+		hex.EncodeToString(commit.VoteSignBytes("test_chain_id", 0)))
+
+	// the block id is only signed by commit votes
+	commit.BlockID = BlockID{Hash: make([]byte, 32), PartSetHeader: PartSetHeader{Total: 1, Hash: make([]byte, 32)}}
+	commit.Signatures[0].BlockIDFlag = BlockIDFlagNil
+	nilVote := commit.VoteSignBytes("", 0)
+	commit.Signatures[0].BlockIDFlag = BlockIDFlagCommit
+	assert.Equal(t, len(nilVote)+2+len(commit.BlockID.marshal()), len(commit.VoteSignBytes("", 0)))
+}
+
+func TestPubKey(t *testing.T) {
+	key, _ := ecrypto.GenerateKey()
+	pubKey := &PubKey{Type: KeyTypeSecp256k1, Key: ecrypto.CompressPubkey(&key.PublicKey)}
+	msg := []byte("vote")
+	hash := sha256.Sum256(msg)
+	sig, err := ecrypto.Sign(hash[:], key)
+	assert.NilError(t, err)
+	assert.Assert(t, pubKey.VerifySignature(msg, sig[:64]))
+	assert.Assert(t, !pubKey.VerifySignature([]byte("other"), sig[:64]))
+	assert.Equal(t, 20, len(pubKey.Address()))
+
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	pubKey = &PubKey{Type: KeyTypeEd25519, Key: pub}
+	assert.Assert(t, pubKey.VerifySignature(msg, ed25519.Sign(priv, msg)))
+	sha := sha256.Sum256(pub)
+	assert.DeepEqual(t, sha[:20], pubKey.Address())
+}
@@ -0,0 +1,170 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package cometbft implements the light client of tendermint 0.34+ and
+// CometBFT chains on the protobuf encodings, and the ICS23 proofs of the
+// Cosmos SDK multistore.
+package cometbft
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Options configures the light client, see the CometBFT light client
+// verification specification
+type Options struct {
+	TrustingPeriod        time.Duration
+	MaxClockDrift         time.Duration
+	TrustLevelNumerator   int64
+	TrustLevelDenominator int64
+}
+
+// Validate checks the trusting period and that the trust level is in [1/3, 1]
+func (this *Options) Validate() error {
+	if this.TrustingPeriod <= 0 {
+		return errors.New("trusting period must be positive")
+	}
+	if this.MaxClockDrift < 0 {
+		return errors.New("max clock drift must not be negative")
+	}
+	n, d := this.TrustLevelNumerator, this.TrustLevelDenominator
+	if n <= 0 || d <= 0 || n > d || n*3 < d {
+		return fmt.Errorf("trust level %d/%d is not in [1/3, 1]", n, d)
+	}
+	return nil
+}
+
+// TrustedState is what the light client keeps of a verified header
+type TrustedState struct {
+	ChainID            string
+	Height             int64
+	Time               time.Time
+	NextValidatorsHash []byte
+}
+
+// Verify checks untrusted against trusted. An adjacent header must be signed
+// by the next validators of trusted, any later header by validators holding
+// the trust level of the voting power of trustedVals, the next validators of
+// trusted, so that the relayer can skip headers by bisection.
+func Verify(trusted *TrustedState, trustedVals *ValidatorSet, untrusted *SignedHeader, untrustedVals *ValidatorSet, opts *Options, now time.Time) error {
+	if !trusted.Time.Add(opts.TrustingPeriod).After(now) {
+		return fmt.Errorf("trusted header at %d expired at %v", trusted.Height, trusted.Time.Add(opts.TrustingPeriod))
+	}
+	if err := untrusted.ValidateBasic(trusted.ChainID); err != nil {
+		return err
+	}
+	header := untrusted.Header
+	if header.Height <= trusted.Height {
+		return fmt.Errorf("header height %d is not above trusted height %d", header.Height, trusted.Height)
+	}
+	if !header.Time.Time().After(trusted.Time) {
+		return fmt.Errorf("header time %v is not after trusted time %v", header.Time.Time(), trusted.Time)
+	}
+	if !header.Time.Time().Before(now.Add(opts.MaxClockDrift)) {
+		return fmt.Errorf("header time %v is from the future, now %v", header.Time.Time(), now)
+	}
+	if err := untrustedVals.ValidateBasic(); err != nil {
+		return fmt.Errorf("invalid validator set: %v", err)
+	}
+	if !bytes.Equal(header.ValidatorsHash, untrustedVals.Hash()) {
+		return fmt.Errorf("validators hash %X does not match the validator set", header.ValidatorsHash)
+	}
+
+	if header.Height == trusted.Height+1 {
+		if !bytes.Equal(header.ValidatorsHash, trusted.NextValidatorsHash) {
+			return fmt.Errorf("validators hash %X of adjacent header is not the trusted next validators hash %X",
+				header.ValidatorsHash, trusted.NextValidatorsHash)
+		}
+	} else {
+		if trustedVals == nil {
+			return errors.New("trusted validators are needed for non adjacent headers")
+		}
+		if err := trustedVals.ValidateBasic(); err != nil {
+			return fmt.Errorf("invalid trusted validator set: %v", err)
+		}
+		if !bytes.Equal(trustedVals.Hash(), trusted.NextValidatorsHash) {
+			return fmt.Errorf("trusted validators do not match the trusted next validators hash %X", trusted.NextValidatorsHash)
+		}
+		if err := trustedVals.VerifyCommitLightTrusting(trusted.ChainID, untrusted.Commit, opts.TrustLevelNumerator, opts.TrustLevelDenominator); err != nil {
+			return err
+		}
+	}
+	return untrustedVals.VerifyCommitLight(trusted.ChainID, untrusted.Commit)
+}
+
+// VerifyCommitLight checks that more than 2/3 of the voting power signed the
+// commit, the signatures are in the order of the validators
+func (this *ValidatorSet) VerifyCommitLight(chainID string, commit *Commit) error {
+	if len(commit.Signatures) != len(this.Validators) {
+		return fmt.Errorf("commit has %d signatures for %d validators", len(commit.Signatures), len(this.Validators))
+	}
+	tallied, needed := int64(0), this.TotalVotingPower()*2/3
+	for idx, sig := range commit.Signatures {
+		if sig.BlockIDFlag != BlockIDFlagCommit {
+			continue
+		}
+		val := this.Validators[idx]
+		if !bytes.Equal(sig.ValidatorAddress, val.Address) {
+			return fmt.Errorf("signature %d is not from validator %X", idx, val.Address)
+		}
+		if !val.PubKey.VerifySignature(commit.VoteSignBytes(chainID, idx), sig.Signature) {
+			return fmt.Errorf("invalid signature %d", idx)
+		}
+		tallied += val.VotingPower
+		if tallied > needed {
+			return nil
+		}
+	}
+	return fmt.Errorf("not enough voting power signed, got %d, needed more than %d", tallied, needed)
+}
+
+// VerifyCommitLightTrusting checks that validators of this set holding more
+// than numerator/denominator of its voting power signed the commit
+func (this *ValidatorSet) VerifyCommitLightTrusting(chainID string, commit *Commit, numerator, denominator int64) error {
+	if denominator <= 0 {
+		return errors.New("invalid trust level")
+	}
+	needed := new(big.Int).Mul(big.NewInt(this.TotalVotingPower()), big.NewInt(numerator))
+	needed.Quo(needed, big.NewInt(denominator))
+	tallied := int64(0)
+	seen := make(map[int]int, len(commit.Signatures))
+	for idx, sig := range commit.Signatures {
+		if sig.BlockIDFlag != BlockIDFlagCommit {
+			continue
+		}
+		valIdx, val := this.getByAddress(sig.ValidatorAddress)
+		if val == nil {
+			continue
+		}
+		if first, ok := seen[valIdx]; ok {
+			return fmt.Errorf("double vote from validator %X, signatures %d and %d", val.Address, first, idx)
+		}
+		seen[valIdx] = idx
+		if !val.PubKey.VerifySignature(commit.VoteSignBytes(chainID, idx), sig.Signature) {
+			return fmt.Errorf("invalid signature %d", idx)
+		}
+		tallied += val.VotingPower
+		if big.NewInt(tallied).Cmp(needed) > 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("not enough trusted voting power signed, got %d, needed more than %v", tallied, needed)
+}
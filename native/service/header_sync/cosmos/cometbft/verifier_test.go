@@ -0,0 +1,142 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cometbft
+
+import (
+	"crypto/ed25519"
+	"testing"
+	"time"
+
+	"gotest.tools/assert"
+)
+
+const testChainID = "test-chain"
+
+type testValidator struct {
+	key       ed25519.PrivateKey
+	validator *Validator
+}
+
+func newTestValidators(n int, power int64) []*testValidator {
+	var vals []*testValidator
+	for i := 0; i < n; i++ {
+		pub, priv, _ := ed25519.GenerateKey(nil)
+		pubKey := PubKey{Type: KeyTypeEd25519, Key: pub}
+		vals = append(vals, &testValidator{key: priv, validator: &Validator{Address: pubKey.Address(), PubKey: pubKey, VotingPower: power}})
+	}
+	return vals
+}
+
+func validatorSet(vals []*testValidator) *ValidatorSet {
+	set := new(ValidatorSet)
+	for _, v := range vals {
+		set.Validators = append(set.Validators, v.validator)
+	}
+	return set
+}
+
+// signedHeader makes a header of vals committed by the validators whose index is in signers
+func signedHeader(height int64, t time.Time, vals, next []*testValidator, signers ...int) *SignedHeader {
+	header := &Header{
+		Version:            Consensus{Block: 11},
+		ChainID:            testChainID,
+		Height:             height,
+		Time:               Timestamp{Seconds: t.Unix()},
+		ValidatorsHash:     validatorSet(vals).Hash(),
+		NextValidatorsHash: validatorSet(next).Hash(),
+		ConsensusHash:      make([]byte, 32),
+		AppHash:            []byte{byte(height)},
+		ProposerAddress:    vals[0].validator.Address,
+	}
+	commit := &Commit{Height: height, BlockID: BlockID{Hash: header.Hash(), PartSetHeader: PartSetHeader{Total: 1, Hash: make([]byte, 32)}}}
+	for range vals {
+		commit.Signatures = append(commit.Signatures, &CommitSig{BlockIDFlag: BlockIDFlagAbsent})
+	}
+	for _, i := range signers {
+		commit.Signatures[i] = &CommitSig{BlockIDFlag: BlockIDFlagCommit, ValidatorAddress: vals[i].validator.Address, Timestamp: header.Time}
+		commit.Signatures[i].Signature = ed25519.Sign(vals[i].key, commit.VoteSignBytes(testChainID, i))
+	}
+	return &SignedHeader{Header: header, Commit: commit}
+}
+
+func TestVerify(t *testing.T) {
+	opts := &Options{TrustingPeriod: time.Hour, MaxClockDrift: 10 * time.Second, TrustLevelNumerator: 1, TrustLevelDenominator: 3}
+	assert.NilError(t, opts.Validate())
+	vals := newTestValidators(4, 10)
+	start := time.Unix(1600000000, 0)
+	trusted := &TrustedState{ChainID: testChainID, Height: 10, Time: start, NextValidatorsHash: validatorSet(vals).Hash()}
+	now := start.Add(time.Minute)
+
+	// adjacent
+	header := signedHeader(11, start.Add(time.Second), vals, vals, 0, 1, 2)
+	assert.NilError(t, Verify(trusted, nil, header, validatorSet(vals), opts, now))
+	header = signedHeader(11, start.Add(time.Second), vals, vals, 0, 1)
+	assert.ErrorContains(t, Verify(trusted, nil, header, validatorSet(vals), opts, now), "not enough voting power")
+
+	// skipping to a new set sharing two of four validators
+	next := append(newTestValidators(2, 10), vals[2], vals[3])
+	header = signedHeader(20, start.Add(10*time.Second), next, next, 0, 1, 2, 3)
+	assert.ErrorContains(t, Verify(trusted, nil, header, validatorSet(next), opts, now), "trusted validators are needed")
+	assert.NilError(t, Verify(trusted, validatorSet(vals), header, validatorSet(next), opts, now))
+	// one of the old validators holds only 1/4 of the trusted power, 1/3 is needed
+	header = signedHeader(20, start.Add(10*time.Second), next, next, 0, 1, 2)
+	assert.ErrorContains(t, Verify(trusted, validatorSet(vals), header, validatorSet(next), opts, now), "not enough trusted voting power")
+	assert.ErrorContains(t, Verify(trusted, validatorSet(next), header, validatorSet(next), opts, now), "trusted validators do not match")
+
+	// a header of another validator set
+	header = signedHeader(11, start.Add(time.Second), next, vals, 0, 1, 2)
+	assert.ErrorContains(t, Verify(trusted, nil, header, validatorSet(next), opts, now), "adjacent header")
+	header = signedHeader(11, start.Add(time.Second), vals, vals, 0, 1, 2)
+	assert.ErrorContains(t, Verify(trusted, nil, header, validatorSet(next), opts, now), "does not match the validator set")
+
+	// tampered signature
+	header = signedHeader(11, start.Add(time.Second), vals, vals, 0, 1, 2)
+	header.Commit.Signatures[1].Signature[0] ^= 1
+	assert.ErrorContains(t, Verify(trusted, nil, header, validatorSet(vals), opts, now), "invalid signature 1")
+	// the commit must be for the header
+	header = signedHeader(11, start.Add(time.Second), vals, vals, 0, 1, 2)
+	header.Header.AppHash = []byte{0}
+	assert.ErrorContains(t, Verify(trusted, nil, header, validatorSet(vals), opts, now), "commit signs block")
+
+	// time checks
+	header = signedHeader(11, start.Add(time.Hour), vals, vals, 0, 1, 2)
+	assert.ErrorContains(t, Verify(trusted, nil, header, validatorSet(vals), opts, now), "from the future")
+	header = signedHeader(11, start, vals, vals, 0, 1, 2)
+	assert.ErrorContains(t, Verify(trusted, nil, header, validatorSet(vals), opts, now), "is not after trusted time")
+	header = signedHeader(11, start.Add(time.Second), vals, vals, 0, 1, 2)
+	assert.ErrorContains(t, Verify(trusted, nil, header, validatorSet(vals), opts, start.Add(2*time.Hour)), "expired")
+
+	// round trip of the relayed message
+	light := &LightHeader{SignedHeader: *header, ValidatorSet: *validatorSet(vals), TrustedHeight: 10, TrustedValidators: *validatorSet(vals)}
+	decoded, err := DecodeLightHeader(light.Marshal())
+	assert.NilError(t, err)
+	assert.Equal(t, uint64(10), decoded.TrustedHeight)
+	assert.DeepEqual(t, header.Header.Hash(), decoded.SignedHeader.Header.Hash())
+	assert.NilError(t, Verify(trusted, &decoded.TrustedValidators, &decoded.SignedHeader, &decoded.ValidatorSet, opts, now))
+	_, err = DecodeLightHeader([]byte{0x0a, 0x05, 0x01})
+	assert.ErrorContains(t, err, "DecodeLightHeader")
+}
+
+func TestOptions(t *testing.T) {
+	opts := &Options{TrustingPeriod: time.Hour, TrustLevelNumerator: 1, TrustLevelDenominator: 4}
+	assert.ErrorContains(t, opts.Validate(), "trust level")
+	opts.TrustLevelNumerator, opts.TrustLevelDenominator = 2, 1
+	assert.ErrorContains(t, opts.Validate(), "trust level")
+	opts.TrustLevelNumerator, opts.TrustLevelDenominator, opts.TrustingPeriod = 2, 3, 0
+	assert.ErrorContains(t, opts.Validate(), "trusting period")
+}
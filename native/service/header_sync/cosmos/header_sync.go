@@ -68,6 +68,16 @@ func (this *CosmosHandler) SyncGenesisHeader(native *native.NativeService) error
 	if err != nil {
 		return fmt.Errorf("CosmosHandler SyncGenesisHeader, checkWitness error: %v", err)
 	}
+	extraInfo, err := GetExtraInfo(native, param.ChainID)
+	if err != nil {
+		return fmt.Errorf("CosmosHandler SyncGenesisHeader, %v", err)
+	}
+	if extraInfo != nil {
+		if err := syncLightGenesisHeader(native, param); err != nil {
+			return fmt.Errorf("CosmosHandler SyncGenesisHeader, %v", err)
+		}
+		return nil
+	}
 	// get genesis header from input parameters
 	cdc := newCDC()
 	var header CosmosHeader
@@ -89,22 +99,33 @@ func (this *CosmosHandler) SyncGenesisHeader(native *native.NativeService) error
 	return nil
 }
 
-func (this *CosmosHandler) SyncBlockHeader(native *native.NativeService) error {
+func (this *CosmosHandler) SyncBlockHeader(native *native.NativeService) (uint64, error) {
 	params := new(hscommon.SyncBlockHeaderParam)
 	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
-		return fmt.Errorf("SyncBlockHeader, contract params deserialize error: %v", err)
+		return 0, fmt.Errorf("SyncBlockHeader, contract params deserialize error: %v", err)
+	}
+	extraInfo, err := GetExtraInfo(native, params.ChainID)
+	if err != nil {
+		return 0, fmt.Errorf("SyncBlockHeader, %v", err)
+	}
+	if extraInfo != nil {
+		cnt, err := syncLightHeaders(native, params, extraInfo)
+		if err != nil {
+			return 0, fmt.Errorf("SyncBlockHeader, %v", err)
+		}
+		return cnt, nil
 	}
 	cdc := newCDC()
 	cnt := 0
 	info, err := GetEpochSwitchInfo(native, params.ChainID)
 	if err != nil {
-		return fmt.Errorf("SyncBlockHeader, get epoch switching height failed: %v", err)
+		return 0, fmt.Errorf("SyncBlockHeader, get epoch switching height failed: %v", err)
 	}
 	for _, v := range params.Headers {
 		var myHeader CosmosHeader
 		err := cdc.UnmarshalBinaryBare(v, &myHeader)
 		if err != nil {
-			return fmt.Errorf("SyncBlockHeader failed to unmarshal header: %v", err)
+			return 0, fmt.Errorf("SyncBlockHeader failed to unmarshal header: %v", err)
 		}
 		if bytes.Equal(myHeader.Header.NextValidatorsHash, myHeader.Header.ValidatorsHash) {
 			continue
@@ -115,7 +136,7 @@ func (this *CosmosHandler) SyncBlockHeader(native *native.NativeService) error {
 			continue
 		}
 		if err = VerifyCosmosHeader(&myHeader, info); err != nil {
-			return fmt.Errorf("SyncBlockHeader, failed to verify header: %v", err)
+			return 0, fmt.Errorf("SyncBlockHeader, failed to verify header: %v", err)
 		}
 		info.NextValidatorsHash = myHeader.Header.NextValidatorsHash
 		info.Height = myHeader.Header.Height
@@ -123,12 +144,12 @@ func (this *CosmosHandler) SyncBlockHeader(native *native.NativeService) error {
 		cnt++
 	}
 	if cnt == 0 {
-		return fmt.Errorf("no header you commited is useful")
+		return 0, fmt.Errorf("no header you commited is useful")
 	}
 	PutEpochSwitchInfo(native, params.ChainID, info)
-	return nil
+	return uint64(cnt), nil
 }
 
-func (this *CosmosHandler) SyncCrossChainMsg(native *native.NativeService) error {
-	return nil
+func (this *CosmosHandler) SyncCrossChainMsg(native *native.NativeService) (uint64, error) {
+	return 0, nil
 }
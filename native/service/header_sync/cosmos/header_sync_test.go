@@ -150,7 +150,7 @@ func TestSyncBlockHeader(t *testing.T) {
 		param.Serialization(sink)
 
 		native = NewNative(sink.Bytes(), new(types.Transaction), native.GetCacheDB())
-		err := cosmosHandler.SyncBlockHeader(native)
+		_, err := cosmosHandler.SyncBlockHeader(native)
 		assert.Error(t, err)
 	}
 }
@@ -196,7 +196,7 @@ func TestSyncBlockHeader2(t *testing.T) {
 		param.Serialization(sink)
 
 		native = NewNative(sink.Bytes(), new(types.Transaction), native.GetCacheDB())
-		err := cosmosHandler.SyncBlockHeader(native)
+		_, err := cosmosHandler.SyncBlockHeader(native)
 		assert.Error(t, err)
 		assert.Equal(t, SUCCESS, typeOfError(err))
 
@@ -215,7 +215,7 @@ func TestSyncBlockHeader2(t *testing.T) {
 		param.Serialization(sink)
 
 		native = NewNative(sink.Bytes(), new(types.Transaction), native.GetCacheDB())
-		err := cosmosHandler.SyncBlockHeader(native)
+		_, err := cosmosHandler.SyncBlockHeader(native)
 		if err != nil {
 			fmt.Printf("err: %s", err.Error())
 		}
@@ -267,7 +267,7 @@ func TestSyncBlockHeader3(t *testing.T) {
 		param.Serialization(sink)
 
 		native = NewNative(sink.Bytes(), new(types.Transaction), native.GetCacheDB())
-		err := cosmosHandler.SyncBlockHeader(native)
+		_, err := cosmosHandler.SyncBlockHeader(native)
 		assert.Error(t, err)
 	}
 }
@@ -312,7 +312,7 @@ func TestSyncBlockHeaderTwice(t *testing.T) {
 		param.Serialization(sink)
 
 		native = NewNative(sink.Bytes(), new(types.Transaction), native.GetCacheDB())
-		err := cosmosHandler.SyncBlockHeader(native)
+		_, err := cosmosHandler.SyncBlockHeader(native)
 		if err != nil {
 			fmt.Printf("err: %s", err.Error())
 		}
@@ -333,7 +333,7 @@ func TestSyncBlockHeaderTwice(t *testing.T) {
 		param.Serialization(sink)
 
 		native = NewNative(sink.Bytes(), new(types.Transaction), native.GetCacheDB())
-		err := cosmosHandler.SyncBlockHeader(native)
+		_, err := cosmosHandler.SyncBlockHeader(native)
 		if err != nil {
 			fmt.Printf("err: %s", err.Error())
 		}
@@ -384,7 +384,7 @@ func TestSyncBlockHeaderUnorder(t *testing.T) {
 		param.Serialization(sink)
 
 		native = NewNative(sink.Bytes(), new(types.Transaction), native.GetCacheDB())
-		err := cosmosHandler.SyncBlockHeader(native)
+		_, err := cosmosHandler.SyncBlockHeader(native)
 		if err != nil {
 			fmt.Printf("err: %s", err.Error())
 		}
@@ -407,7 +407,7 @@ func TestSyncBlockHeaderUnorder(t *testing.T) {
 		param.Serialization(sink)
 
 		native = NewNative(sink.Bytes(), nil, native.GetCacheDB())
-		err := cosmosHandler.SyncBlockHeader(native)
+		_, err := cosmosHandler.SyncBlockHeader(native)
 		if err != nil {
 			fmt.Printf("err: %s", err.Error())
 		}
@@ -0,0 +1,293 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cosmos
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/common/config"
+	cstates "github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/event"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	hscommon "github.com/polynetwork/poly/native/service/header_sync/common"
+	"github.com/polynetwork/poly/native/service/header_sync/cosmos/cometbft"
+	"github.com/polynetwork/poly/native/service/utils"
+)
+
+// ExtraInfo configures the CometBFT light client of a side chain, chains
+// registered without it keep the amino headers of tendermint 0.33
+type ExtraInfo struct {
+	TrustingPeriod        uint64 // seconds a consensus state is trusted, below the unbonding period
+	MaxClockDrift         uint64 // seconds a header may be ahead of poly
+	TrustLevelNumerator   int64  // voting power of the trusted validators needed to skip, 0 is read as 1/3
+	TrustLevelDenominator int64
+	StoreName             string        // store of the cross chain module in the multistore
+	KeyPrefix             hexutil.Bytes // prefix of the cross chain transaction keys in the store
+}
+
+func (this *ExtraInfo) options() *cometbft.Options {
+	opts := &cometbft.Options{
+		TrustingPeriod:        time.Duration(this.TrustingPeriod) * time.Second,
+		MaxClockDrift:         time.Duration(this.MaxClockDrift) * time.Second,
+		TrustLevelNumerator:   this.TrustLevelNumerator,
+		TrustLevelDenominator: this.TrustLevelDenominator,
+	}
+	if opts.TrustLevelNumerator == 0 && opts.TrustLevelDenominator == 0 {
+		opts.TrustLevelNumerator, opts.TrustLevelDenominator = 1, 3
+	}
+	return opts
+}
+
+// GetExtraInfo returns the light client parameters of chainID, nil if the
+// chain is not configured for CometBFT
+func GetExtraInfo(native *native.NativeService, chainID uint64) (*ExtraInfo, error) {
+	side, err := side_chain_manager.GetSideChain(native, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("GetSideChain error: %v", err)
+	}
+	if side == nil || len(side.ExtraInfo) == 0 {
+		return nil, nil
+	}
+	extraInfo := new(ExtraInfo)
+	if err := json.Unmarshal(side.ExtraInfo, extraInfo); err != nil {
+		return nil, fmt.Errorf("ExtraInfo Unmarshal error: %v", err)
+	}
+	if extraInfo.TrustingPeriod == 0 {
+		return nil, nil
+	}
+	if err := extraInfo.options().Validate(); err != nil {
+		return nil, fmt.Errorf("invalid ExtraInfo: %v", err)
+	}
+	if extraInfo.StoreName == "" {
+		return nil, fmt.Errorf("invalid ExtraInfo: no store name")
+	}
+	return extraInfo, nil
+}
+
+// ConsensusState is what poly keeps of a verified CometBFT header, AppHash
+// commits the state proofs are verified against
+type ConsensusState struct {
+	ChainID            string
+	Height             uint64
+	Time               int64 // unix nanoseconds
+	BlockHash          []byte
+	AppHash            []byte
+	NextValidatorsHash []byte
+}
+
+func newConsensusState(header *cometbft.Header) *ConsensusState {
+	return &ConsensusState{
+		ChainID:            header.ChainID,
+		Height:             uint64(header.Height),
+		Time:               header.Time.Time().UnixNano(),
+		BlockHash:          header.Hash(),
+		AppHash:            header.AppHash,
+		NextValidatorsHash: header.NextValidatorsHash,
+	}
+}
+
+func (this *ConsensusState) trusted() *cometbft.TrustedState {
+	return &cometbft.TrustedState{
+		ChainID:            this.ChainID,
+		Height:             int64(this.Height),
+		Time:               time.Unix(0, this.Time),
+		NextValidatorsHash: this.NextValidatorsHash,
+	}
+}
+
+func (this *ConsensusState) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteString(this.ChainID)
+	sink.WriteUint64(this.Height)
+	sink.WriteInt64(this.Time)
+	sink.WriteVarBytes(this.BlockHash)
+	sink.WriteVarBytes(this.AppHash)
+	sink.WriteVarBytes(this.NextValidatorsHash)
+}
+
+func (this *ConsensusState) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	this.ChainID, eof = source.NextString()
+	if eof {
+		return fmt.Errorf("deserialize ChainID of ConsensusState failed")
+	}
+	this.Height, eof = source.NextUint64()
+	if eof {
+		return fmt.Errorf("deserialize Height of ConsensusState failed")
+	}
+	this.Time, eof = source.NextInt64()
+	if eof {
+		return fmt.Errorf("deserialize Time of ConsensusState failed")
+	}
+	this.BlockHash, eof = source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("deserialize BlockHash of ConsensusState failed")
+	}
+	this.AppHash, eof = source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("deserialize AppHash of ConsensusState failed")
+	}
+	this.NextValidatorsHash, eof = source.NextVarBytes()
+	if eof {
+		return fmt.Errorf("deserialize NextValidatorsHash of ConsensusState failed")
+	}
+	return nil
+}
+
+func syncLightGenesisHeader(native *native.NativeService, param *hscommon.SyncGenesisHeaderParam) error {
+	height, err := GetLightHeight(native, param.ChainID)
+	if err != nil {
+		return err
+	}
+	if height != 0 {
+		return fmt.Errorf("genesis header had been initialized")
+	}
+	header, err := cometbft.DecodeLightHeader(param.GenesisHeader)
+	if err != nil {
+		return err
+	}
+	signed := &header.SignedHeader
+	if signed.Header == nil {
+		return fmt.Errorf("no header")
+	}
+	if err := signed.ValidateBasic(signed.Header.ChainID); err != nil {
+		return fmt.Errorf("invalid genesis header: %v", err)
+	}
+	if !bytes.Equal(header.ValidatorSet.Hash(), signed.Header.ValidatorsHash) {
+		return fmt.Errorf("validator set does not match the genesis header")
+	}
+	if err := header.ValidatorSet.VerifyCommitLight(signed.Header.ChainID, signed.Commit); err != nil {
+		return fmt.Errorf("invalid genesis commit: %v", err)
+	}
+	putConsensusState(native, param.ChainID, newConsensusState(signed.Header))
+	return nil
+}
+
+// syncLightHeaders verifies each header from the consensus state at its
+// trusted height, so relayers can skip to any height the trusted validators
+// still hold a trust level of
+func syncLightHeaders(native *native.NativeService, params *hscommon.SyncBlockHeaderParam, extraInfo *ExtraInfo) (uint64, error) {
+	opts := extraInfo.options()
+	now := time.Unix(int64(native.GetBlockTime()), 0)
+	cnt := 0
+	for _, v := range params.Headers {
+		header, err := cometbft.DecodeLightHeader(v)
+		if err != nil {
+			return 0, err
+		}
+		signed := &header.SignedHeader
+		if signed.Header == nil {
+			return 0, fmt.Errorf("no header")
+		}
+		state, err := GetConsensusState(native, params.ChainID, uint64(signed.Header.Height))
+		if err != nil {
+			return 0, err
+		}
+		if state != nil {
+			if !bytes.Equal(state.BlockHash, signed.Header.Hash()) {
+				return 0, fmt.Errorf("conflicting header at height %d", signed.Header.Height)
+			}
+			continue
+		}
+		trusted, err := GetConsensusState(native, params.ChainID, header.TrustedHeight)
+		if err != nil {
+			return 0, err
+		}
+		if trusted == nil {
+			return 0, fmt.Errorf("no consensus state at trusted height %d", header.TrustedHeight)
+		}
+		if err := cometbft.Verify(trusted.trusted(), &header.TrustedValidators, signed, &header.ValidatorSet, opts, now); err != nil {
+			return 0, fmt.Errorf("failed to verify header %d: %v", signed.Header.Height, err)
+		}
+		putConsensusState(native, params.ChainID, newConsensusState(signed.Header))
+		cnt++
+	}
+	if cnt == 0 {
+		return 0, fmt.Errorf("no header you commited is useful")
+	}
+	return uint64(cnt), nil
+}
+
+// GetConsensusState returns the consensus state of chainID at height, nil if
+// no header was synced at height
+func GetConsensusState(native *native.NativeService, chainID, height uint64) (*ConsensusState, error) {
+	store, err := native.GetCacheDB().Get(utils.ConcatKey(utils.HeaderSyncContractAddress,
+		[]byte(hscommon.CONSENSUS_STATE), utils.GetUint64Bytes(chainID), utils.GetUint64Bytes(height)))
+	if err != nil {
+		return nil, fmt.Errorf("GetConsensusState, get consensus state error: %v", err)
+	}
+	if store == nil {
+		return nil, nil
+	}
+	raw, err := cstates.GetValueFromRawStorageItem(store)
+	if err != nil {
+		return nil, fmt.Errorf("GetConsensusState, deserialize from raw storage item err: %v", err)
+	}
+	state := new(ConsensusState)
+	if err := state.Deserialization(common.NewZeroCopySource(raw)); err != nil {
+		return nil, fmt.Errorf("GetConsensusState, %v", err)
+	}
+	return state, nil
+}
+
+// GetLightHeight returns the highest synced height of chainID, 0 before genesis
+func GetLightHeight(native *native.NativeService, chainID uint64) (uint64, error) {
+	store, err := native.GetCacheDB().Get(utils.ConcatKey(utils.HeaderSyncContractAddress,
+		[]byte(hscommon.CURRENT_HEADER_HEIGHT), utils.GetUint64Bytes(chainID)))
+	if err != nil {
+		return 0, fmt.Errorf("GetLightHeight, get current height error: %v", err)
+	}
+	if store == nil {
+		return 0, nil
+	}
+	raw, err := cstates.GetValueFromRawStorageItem(store)
+	if err != nil {
+		return 0, fmt.Errorf("GetLightHeight, deserialize from raw storage item err: %v", err)
+	}
+	return utils.GetBytesUint64(raw), nil
+}
+
+func putConsensusState(native *native.NativeService, chainID uint64, state *ConsensusState) {
+	sink := common.NewZeroCopySink(nil)
+	state.Serialization(sink)
+	native.GetCacheDB().Put(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(hscommon.CONSENSUS_STATE),
+		utils.GetUint64Bytes(chainID), utils.GetUint64Bytes(state.Height)), cstates.GenRawStorageItem(sink.Bytes()))
+	if height, err := GetLightHeight(native, chainID); err == nil && state.Height > height {
+		native.GetCacheDB().Put(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(hscommon.CURRENT_HEADER_HEIGHT),
+			utils.GetUint64Bytes(chainID)), cstates.GenRawStorageItem(utils.GetUint64Bytes(state.Height)))
+	}
+	notifyConsensusState(native, chainID, state)
+}
+
+func notifyConsensusState(native *native.NativeService, chainID uint64, state *ConsensusState) {
+	if !config.DefConfig.Common.EnableEventLog {
+		return
+	}
+	native.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.HeaderSyncContractAddress,
+			States: []interface{}{chainID, hex.EncodeToString(state.BlockHash), state.Height,
+				hex.EncodeToString(state.AppHash), state.ChainID, native.GetHeight()},
+		})
+}
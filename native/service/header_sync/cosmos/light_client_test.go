@@ -0,0 +1,181 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package cosmos
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/polynetwork/poly/common"
+	vconfig "github.com/polynetwork/poly/consensus/vbft/config"
+	cstates "github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/core/store/leveldbstore"
+	"github.com/polynetwork/poly/core/store/overlaydb"
+	"github.com/polynetwork/poly/core/types"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/governance/node_manager"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	scom "github.com/polynetwork/poly/native/service/header_sync/common"
+	"github.com/polynetwork/poly/native/service/header_sync/cosmos/cometbft"
+	"github.com/polynetwork/poly/native/service/utils"
+	"github.com/polynetwork/poly/native/storage"
+	"gotest.tools/assert"
+)
+
+const (
+	lightChainID     = 7
+	lightCosmosChain = "light-chain"
+)
+
+var lightStart = time.Unix(1600000000, 0)
+
+func newLightNative(args []byte, db *storage.CacheDB, now time.Time) *native.NativeService {
+	tx := &types.Transaction{SignedAddr: []common.Address{acct.Address}}
+	if db == nil {
+		store, _ := leveldbstore.NewMemLevelDBStore()
+		db = storage.NewCacheDB(overlaydb.NewOverlayDB(store))
+		sink := common.NewZeroCopySink(nil)
+		view := &node_manager.GovernanceView{TxHash: common.UINT256_EMPTY}
+		view.Serialization(sink)
+		db.Put(utils.ConcatKey(utils.NodeManagerContractAddress, []byte(node_manager.GOVERNANCE_VIEW)), cstates.GenRawStorageItem(sink.Bytes()))
+
+		peerPoolMap := &node_manager.PeerPoolMap{
+			PeerPoolMap: map[string]*node_manager.PeerPoolItem{
+				vconfig.PubkeyID(acct.PublicKey): {
+					Address:    acct.Address,
+					Status:     node_manager.ConsensusStatus,
+					PeerPubkey: vconfig.PubkeyID(acct.PublicKey),
+				},
+			},
+		}
+		sink.Reset()
+		peerPoolMap.Serialization(sink)
+		db.Put(utils.ConcatKey(utils.NodeManagerContractAddress, []byte(node_manager.PEER_POOL), utils.GetUint32Bytes(0)),
+			cstates.GenRawStorageItem(sink.Bytes()))
+
+		extraInfo, _ := json.Marshal(&ExtraInfo{TrustingPeriod: 3600, MaxClockDrift: 10, StoreName: "ccm", KeyPrefix: []byte{1}})
+		service, _ := native.NewNativeService(db, tx, 0, 0, common.Uint256{0}, 0, nil, false)
+		_ = side_chain_manager.PutSideChain(service, &side_chain_manager.SideChain{
+			Name:      "cometbft",
+			ChainId:   lightChainID,
+			Router:    utils.COSMOS_ROUTER,
+			ExtraInfo: extraInfo,
+		})
+	}
+	service, _ := native.NewNativeService(db, tx, uint32(now.Unix()), 0, common.Uint256{0}, 0, args, false)
+	return service
+}
+
+type lightValidator struct {
+	key       ed25519.PrivateKey
+	validator *cometbft.Validator
+}
+
+func newLightValidators(n int) []*lightValidator {
+	var vals []*lightValidator
+	for i := 0; i < n; i++ {
+		pub, priv, _ := ed25519.GenerateKey(nil)
+		pubKey := cometbft.PubKey{Type: cometbft.KeyTypeEd25519, Key: pub}
+		vals = append(vals, &lightValidator{key: priv, validator: &cometbft.Validator{Address: pubKey.Address(), PubKey: pubKey, VotingPower: 10}})
+	}
+	return vals
+}
+
+func lightValidatorSet(vals []*lightValidator) *cometbft.ValidatorSet {
+	set := new(cometbft.ValidatorSet)
+	for _, v := range vals {
+		set.Validators = append(set.Validators, v.validator)
+	}
+	return set
+}
+
+// lightHeader makes a header of vals committed by all of them, to be verified
+// from the consensus state at trustedHeight whose next validators are trusted
+func lightHeader(height int64, t time.Time, appHash []byte, vals, next []*lightValidator, trustedHeight uint64, trusted []*lightValidator) []byte {
+	header := &cometbft.Header{
+		Version:            cometbft.Consensus{Block: 11},
+		ChainID:            lightCosmosChain,
+		Height:             height,
+		Time:               cometbft.Timestamp{Seconds: t.Unix()},
+		ValidatorsHash:     lightValidatorSet(vals).Hash(),
+		NextValidatorsHash: lightValidatorSet(next).Hash(),
+		ConsensusHash:      make([]byte, 32),
+		AppHash:            appHash,
+		ProposerAddress:    vals[0].validator.Address,
+	}
+	commit := &cometbft.Commit{Height: height, BlockID: cometbft.BlockID{Hash: header.Hash(), PartSetHeader: cometbft.PartSetHeader{Total: 1, Hash: make([]byte, 32)}}}
+	for i, v := range vals {
+		commit.Signatures = append(commit.Signatures, &cometbft.CommitSig{BlockIDFlag: cometbft.BlockIDFlagCommit, ValidatorAddress: v.validator.Address, Timestamp: header.Time})
+		commit.Signatures[i].Signature = ed25519.Sign(v.key, commit.VoteSignBytes(lightCosmosChain, i))
+	}
+	light := &cometbft.LightHeader{
+		SignedHeader:      cometbft.SignedHeader{Header: header, Commit: commit},
+		ValidatorSet:      *lightValidatorSet(vals),
+		TrustedHeight:     trustedHeight,
+		TrustedValidators: *lightValidatorSet(trusted),
+	}
+	return light.Marshal()
+}
+
+func syncLight(db *storage.CacheDB, now time.Time, headers ...[]byte) error {
+	param := &scom.SyncBlockHeaderParam{ChainID: lightChainID, Address: acct.Address, Headers: headers}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	_, err := NewCosmosHandler().SyncBlockHeader(newLightNative(sink.Bytes(), db, now))
+	return err
+}
+
+func TestLightClient(t *testing.T) {
+	vals := newLightValidators(4)
+	param := &scom.SyncGenesisHeaderParam{ChainID: lightChainID, GenesisHeader: lightHeader(10, lightStart, []byte{10}, vals, vals, 0, nil)}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	service := newLightNative(sink.Bytes(), nil, lightStart)
+	db := service.GetCacheDB()
+	assert.NilError(t, NewCosmosHandler().SyncGenesisHeader(service))
+	assert.ErrorContains(t, NewCosmosHandler().SyncGenesisHeader(newLightNative(sink.Bytes(), db, lightStart)), "had been initialized")
+
+	now := lightStart.Add(time.Minute)
+	assert.NilError(t, syncLight(db, now, lightHeader(11, lightStart.Add(time.Second), []byte{11}, vals, vals, 10, vals)))
+
+	// skip to a new set sharing half of the trusted validators
+	next := append(newLightValidators(2), vals[2], vals[3])
+	header20 := lightHeader(20, lightStart.Add(10*time.Second), []byte{20}, next, next, 11, vals)
+	assert.NilError(t, syncLight(db, now, header20))
+	state, err := GetConsensusState(newLightNative(nil, db, now), lightChainID, 20)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, state.AppHash, []byte{20})
+	height, err := GetLightHeight(newLightNative(nil, db, now), lightChainID)
+	assert.NilError(t, err)
+	assert.Equal(t, uint64(20), height)
+
+	// headers below the latest height can be synced from any trusted state
+	assert.NilError(t, syncLight(db, now, lightHeader(15, lightStart.Add(5*time.Second), []byte{15}, vals, vals, 11, vals)))
+	state, err = GetConsensusState(newLightNative(nil, db, now), lightChainID, 15)
+	assert.NilError(t, err)
+	assert.Assert(t, state != nil)
+
+	assert.ErrorContains(t, syncLight(db, now, header20), "no header you commited is useful")
+	conflict := lightHeader(20, lightStart.Add(10*time.Second), []byte{0}, next, next, 11, vals)
+	assert.ErrorContains(t, syncLight(db, now, conflict), "conflicting header")
+	assert.ErrorContains(t, syncLight(db, now, lightHeader(21, lightStart.Add(11*time.Second), []byte{21}, next, next, 12, next)), "no consensus state at trusted height 12")
+	assert.ErrorContains(t, syncLight(db, now, lightHeader(30, lightStart.Add(20*time.Second), []byte{30}, newLightValidators(4), vals, 20, next)), "failed to verify header 30")
+	assert.ErrorContains(t, syncLight(db, lightStart.Add(2*time.Hour), lightHeader(21, lightStart.Add(11*time.Second), []byte{21}, next, next, 20, next)), "expired")
+}
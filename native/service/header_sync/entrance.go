@@ -19,35 +19,25 @@ package header_sync
 
 import (
 	"fmt"
-	"github.com/polynetwork/poly/native/service/header_sync/zilliqa"
-	"github.com/polynetwork/poly/native/service/header_sync/zilliqalegacy"
-
-	"github.com/polynetwork/poly/native/service/header_sync/neo"
-	"github.com/polynetwork/poly/native/service/header_sync/neo3"
-	"github.com/polynetwork/poly/native/service/header_sync/neo3legacy"
-
-	"github.com/polynetwork/poly/native/service/header_sync/heco"
-	"github.com/polynetwork/poly/native/service/header_sync/msc"
-	"github.com/polynetwork/poly/native/service/header_sync/okex"
-	"github.com/polynetwork/poly/native/service/header_sync/polygon"
 
 	"github.com/polynetwork/poly/common"
 	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/event"
+	"github.com/polynetwork/poly/native/service/governance/node_manager"
+	"github.com/polynetwork/poly/native/service/governance/relayer_manager"
+	"github.com/polynetwork/poly/native/service/governance/relayer_reward"
 	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
-	"github.com/polynetwork/poly/native/service/header_sync/bsc"
-	"github.com/polynetwork/poly/native/service/header_sync/btc"
 	hscommon "github.com/polynetwork/poly/native/service/header_sync/common"
-	"github.com/polynetwork/poly/native/service/header_sync/cosmos"
-	"github.com/polynetwork/poly/native/service/header_sync/eth"
-	"github.com/polynetwork/poly/native/service/header_sync/ont"
-	"github.com/polynetwork/poly/native/service/header_sync/quorum"
+	"github.com/polynetwork/poly/native/service/router"
 	"github.com/polynetwork/poly/native/service/utils"
 )
 
 const (
-	SYNC_GENESIS_HEADER  = "syncGenesisHeader"
-	SYNC_BLOCK_HEADER    = "syncBlockHeader"
-	SYNC_CROSS_CHAIN_MSG = "syncCrossChainMsg"
+	SYNC_GENESIS_HEADER  = hscommon.SYNC_GENESIS_HEADER
+	SYNC_BLOCK_HEADER    = hscommon.SYNC_BLOCK_HEADER
+	SYNC_CROSS_CHAIN_MSG = hscommon.SYNC_CROSS_CHAIN_MSG
+	SET_HEADER_RETENTION = hscommon.SET_HEADER_RETENTION
+	GET_HEADER_RETENTION = hscommon.GET_HEADER_RETENTION
 )
 
 //Register methods of node_manager contract
@@ -55,45 +45,8 @@ func RegisterHeaderSyncContract(native *native.NativeService) {
 	native.Register(SYNC_GENESIS_HEADER, SyncGenesisHeader)
 	native.Register(SYNC_BLOCK_HEADER, SyncBlockHeader)
 	native.Register(SYNC_CROSS_CHAIN_MSG, SyncCrossChainMsg)
-}
-
-func GetChainHandler(router uint64) (hscommon.HeaderSyncHandler, error) {
-	switch router {
-	case utils.BTC_ROUTER:
-		return btc.NewBTCHandler(), nil
-	case utils.ETH_ROUTER:
-		return eth.NewETHHandler(), nil
-	case utils.ONT_ROUTER:
-		return ont.NewONTHandler(), nil
-	case utils.NEO_ROUTER:
-		return neo.NewNEOHandler(), nil
-	case utils.NEO3_ROUTER:
-		return neo3.NewNeo3Handler(), nil
-	case utils.NEO3_LEGACY_ROUTER:
-		return neo3legacy.NewNeo3Handler(), nil
-	case utils.COSMOS_ROUTER:
-		return cosmos.NewCosmosHandler(), nil
-	case utils.QUORUM_ROUTER:
-		return quorum.NewQuorumHandler(), nil
-	case utils.BSC_ROUTER:
-		return bsc.NewHandler(), nil
-	case utils.HECO_ROUTER:
-		return heco.NewHecoHandler(), nil
-	case utils.ZILLIQA_LEGACY_ROUTER:
-		return zilliqalegacy.NewHandler(), nil
-	case utils.ZILLIQA_ROUTER:
-		return zilliqa.NewHandler(), nil
-	case utils.MSC_ROUTER:
-		return msc.NewHandler(), nil
-	case utils.OKEX_ROUTER:
-		return okex.NewHandler(), nil
-	case utils.POLYGON_HEIMDALL_ROUTER:
-		return polygon.NewHeimdallHandler(), nil
-	case utils.POLYGON_BOR_ROUTER:
-		return polygon.NewBorHandler(), nil
-	default:
-		return nil, fmt.Errorf("not a supported router:%d", router)
-	}
+	native.Register(SET_HEADER_RETENTION, SetHeaderRetention)
+	native.Register(GET_HEADER_RETENTION, GetHeaderRetention)
 }
 
 func SyncGenesisHeader(native *native.NativeService) ([]byte, error) {
@@ -112,7 +65,7 @@ func SyncGenesisHeader(native *native.NativeService) ([]byte, error) {
 		return utils.BYTE_FALSE, fmt.Errorf("SyncGenesisHeader, side chain is not registered")
 	}
 
-	handler, err := GetChainHandler(sideChain.Router)
+	handler, err := router.GetHeaderSyncHandler(native, sideChain.Router)
 	if err != nil {
 		return utils.BYTE_FALSE, err
 	}
@@ -130,6 +83,9 @@ func SyncBlockHeader(native *native.NativeService) ([]byte, error) {
 		return utils.BYTE_FALSE, fmt.Errorf("SyncBlockHeader, contract params deserialize error: %v", err)
 	}
 	chainID := params.ChainID
+	if err := relayer_manager.CheckRelayerScope(native, SYNC_BLOCK_HEADER, chainID); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SyncBlockHeader, %v", err)
+	}
 
 	//check if chainid exist
 	sideChain, err := side_chain_manager.GetSideChain(native, chainID)
@@ -140,15 +96,21 @@ func SyncBlockHeader(native *native.NativeService) ([]byte, error) {
 		return utils.BYTE_FALSE, fmt.Errorf("SyncBlockHeader, side chain is not registered")
 	}
 
-	handler, err := GetChainHandler(sideChain.Router)
+	handler, err := router.GetHeaderSyncHandler(native, sideChain.Router)
 	if err != nil {
 		return utils.BYTE_FALSE, err
 	}
 
-	err = handler.SyncBlockHeader(native)
+	stored, err := handler.SyncBlockHeader(native)
 	if err != nil {
 		return utils.BYTE_FALSE, err
 	}
+	if err := pruneHeaders(native, chainID, handler); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SyncBlockHeader, %v", err)
+	}
+	if err := relayer_reward.RecordHeaderSync(native, chainID, params.Address[:], stored); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SyncBlockHeader, %v", err)
+	}
 	return utils.BYTE_TRUE, nil
 }
 
@@ -158,6 +120,9 @@ func SyncCrossChainMsg(native *native.NativeService) ([]byte, error) {
 		return utils.BYTE_FALSE, fmt.Errorf("SyncCrossChainMsg, contract params deserialize error: %v", err)
 	}
 	chainID := params.ChainID
+	if err := relayer_manager.CheckRelayerScope(native, SYNC_CROSS_CHAIN_MSG, chainID); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SyncCrossChainMsg, %v", err)
+	}
 
 	//check if chainid exist
 	sideChain, err := side_chain_manager.GetSideChain(native, chainID)
@@ -168,14 +133,169 @@ func SyncCrossChainMsg(native *native.NativeService) ([]byte, error) {
 		return utils.BYTE_FALSE, fmt.Errorf("SyncCrossChainMsg, side chain is not registered")
 	}
 
-	handler, err := GetChainHandler(sideChain.Router)
+	handler, err := router.GetHeaderSyncHandler(native, sideChain.Router)
 	if err != nil {
 		return utils.BYTE_FALSE, err
 	}
 
-	err = handler.SyncCrossChainMsg(native)
+	stored, err := handler.SyncCrossChainMsg(native)
+	if err != nil {
+		return utils.BYTE_FALSE, err
+	}
+	if err := relayer_reward.RecordHeaderSync(native, chainID, params.Address[:], stored); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SyncCrossChainMsg, %v", err)
+	}
+	return utils.BYTE_TRUE, nil
+}
+
+// SetHeaderRetention sets the retention policy of a side chain whose router can prune its headers,
+// a zero Retain removes the policy and stops the pruning.
+func SetHeaderRetention(native *native.NativeService) ([]byte, error) {
+	params := new(hscommon.HeaderRetention)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetHeaderRetention, contract params deserialize error: %v", err)
+	}
+	if params.Retain != 0 && params.Retain < hscommon.MIN_HEADER_RETENTION {
+		return utils.BYTE_FALSE, fmt.Errorf("SetHeaderRetention, retain should not be less than %d", hscommon.MIN_HEADER_RETENTION)
+	}
+
+	// Get current epoch operator
+	operatorAddress, err := node_manager.GetCurConOperator(native)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetHeaderRetention, get current consensus operator address error: %v", err)
+	}
+	//check witness
+	err = utils.ValidateOwner(native, operatorAddress)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetHeaderRetention, checkWitness error: %v", err)
+	}
+
+	sideChain, err := side_chain_manager.GetSideChain(native, params.ChainID)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetHeaderRetention, side_chain_manager.GetSideChain error: %v", err)
+	}
+	if sideChain == nil {
+		return utils.BYTE_FALSE, fmt.Errorf("SetHeaderRetention, side chain is not registered")
+	}
+	handler, err := router.GetHeaderSyncHandler(native, sideChain.Router)
 	if err != nil {
 		return utils.BYTE_FALSE, err
 	}
+	if _, ok := handler.(hscommon.HeaderPruner); !ok {
+		return utils.BYTE_FALSE, fmt.Errorf("SetHeaderRetention, router %d does not support header pruning", sideChain.Router)
+	}
+
+	if params.Retain == 0 {
+		hscommon.DeleteHeaderRetention(native, params.ChainID)
+	} else {
+		hscommon.PutHeaderRetention(native, params)
+	}
+	native.AddNotify(
+		&event.NotifyEventInfo{
+			ContractAddress: utils.HeaderSyncContractAddress,
+			States:          []interface{}{SET_HEADER_RETENTION, params.ChainID, params.Retain},
+		})
 	return utils.BYTE_TRUE, nil
 }
+
+func GetHeaderRetention(native *native.NativeService) ([]byte, error) {
+	chainID, eof := common.NewZeroCopySource(native.GetInput()).NextVarUint()
+	if eof {
+		return utils.BYTE_FALSE, fmt.Errorf("GetHeaderRetention, contract params deserialize chainID error")
+	}
+	retention, err := hscommon.GetHeaderRetention(native, chainID)
+	if err != nil {
+		return utils.BYTE_FALSE, fmt.Errorf("GetHeaderRetention, %v", err)
+	}
+	if retention == nil {
+		return utils.BYTE_FALSE, nil
+	}
+	sink := common.NewZeroCopySink(nil)
+	retention.Serialization(sink)
+	return sink.Bytes(), nil
+}
+
+// pruneHeaders visits at most MAX_PRUNE_HEADERS heights above the pruned height which fell out of the
+// retention window, and prunes the ones not pinned by a pending cross chain request. The pinned headers
+// are pruned once they are released. Fork headers are not indexed by height and are kept.
+func pruneHeaders(native *native.NativeService, chainID uint64, handler hscommon.HeaderSyncHandler) error {
+	pruner, ok := handler.(hscommon.HeaderPruner)
+	if !ok {
+		return nil
+	}
+	retention, err := hscommon.GetHeaderRetention(native, chainID)
+	if err != nil {
+		return fmt.Errorf("pruneHeaders, %v", err)
+	}
+	if retention == nil {
+		return nil
+	}
+	genesis, current, err := pruner.GetHeaderRange(native, chainID)
+	if err != nil {
+		return fmt.Errorf("pruneHeaders, GetHeaderRange error: %v", err)
+	}
+	if current < genesis+retention.Retain {
+		return nil
+	}
+	bound := current - retention.Retain
+	next, err := hscommon.GetPrunedHeight(native, chainID)
+	if err != nil {
+		return fmt.Errorf("pruneHeaders, %v", err)
+	}
+	if next <= genesis {
+		next = genesis + 1
+	}
+	for end := next + hscommon.MAX_PRUNE_HEADERS; next <= bound && next < end; next++ {
+		pins, err := hscommon.GetHeaderPins(native, chainID, next)
+		if err != nil {
+			return fmt.Errorf("pruneHeaders, %v", err)
+		}
+		if pins != 0 {
+			continue
+		}
+		if err := pruner.PruneHeader(native, chainID, next); err != nil {
+			return fmt.Errorf("pruneHeaders, PruneHeader error: %v", err)
+		}
+	}
+	hscommon.PutPrunedHeight(native, chainID, next)
+	return nil
+}
+
+// ReleaseHeader drops the pin a finished cross chain request holds on the header at height, and prunes
+// the header if it is not pinned any more and the pruning has already gone past it. A header the request
+// did not pin is left as it is, without looking up the side chain.
+func ReleaseHeader(native *native.NativeService, chainID uint64, height uint64) error {
+	released, err := hscommon.UnpinHeader(native, chainID, height)
+	if err != nil {
+		return fmt.Errorf("ReleaseHeader, %v", err)
+	}
+	if !released {
+		return nil
+	}
+	next, err := hscommon.GetPrunedHeight(native, chainID)
+	if err != nil {
+		return fmt.Errorf("ReleaseHeader, %v", err)
+	}
+	if height >= next {
+		return nil
+	}
+	sideChain, err := side_chain_manager.GetSideChain(native, chainID)
+	if err != nil {
+		return fmt.Errorf("ReleaseHeader, side_chain_manager.GetSideChain error: %v", err)
+	}
+	if sideChain == nil {
+		return nil
+	}
+	handler, err := router.GetHeaderSyncHandler(native, sideChain.Router)
+	if err != nil {
+		return fmt.Errorf("ReleaseHeader, %v", err)
+	}
+	pruner, ok := handler.(hscommon.HeaderPruner)
+	if !ok {
+		return nil
+	}
+	if err := pruner.PruneHeader(native, chainID, height); err != nil {
+		return fmt.Errorf("ReleaseHeader, PruneHeader error: %v", err)
+	}
+	return nil
+}
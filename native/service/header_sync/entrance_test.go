@@ -0,0 +1,330 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+package header_sync
+
+import (
+	"testing"
+
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/common/config"
+	cstates "github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/core/store/leveldbstore"
+	"github.com/polynetwork/poly/core/store/overlaydb"
+	"github.com/polynetwork/poly/core/types"
+	"github.com/polynetwork/poly/native"
+	ccmcom "github.com/polynetwork/poly/native/service/cross_chain_manager/common"
+	"github.com/polynetwork/poly/native/service/governance/relayer_manager"
+	"github.com/polynetwork/poly/native/service/governance/relayer_reward"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	hscommon "github.com/polynetwork/poly/native/service/header_sync/common"
+	"github.com/polynetwork/poly/native/service/router"
+	"github.com/polynetwork/poly/native/service/utils"
+	"github.com/polynetwork/poly/native/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+type testPruner struct {
+	genesis uint64
+	current uint64
+}
+
+func (this *testPruner) SyncGenesisHeader(native *native.NativeService) error { return nil }
+func (this *testPruner) SyncBlockHeader(native *native.NativeService) (uint64, error) {
+	return 0, nil
+}
+func (this *testPruner) SyncCrossChainMsg(native *native.NativeService) (uint64, error) {
+	return 0, nil
+}
+
+func (this *testPruner) GetHeaderRange(native *native.NativeService, chainID uint64) (uint64, uint64, error) {
+	return this.genesis, this.current, nil
+}
+
+func (this *testPruner) PruneHeader(native *native.NativeService, chainID uint64, height uint64) error {
+	return hscommon.PruneMainChainHeader(native, chainID, height)
+}
+
+func newTestNative() *native.NativeService {
+	store, _ := leveldbstore.NewMemLevelDBStore()
+	db := storage.NewCacheDB(overlaydb.NewOverlayDB(store))
+	ns, _ := native.NewNativeService(db, &types.Transaction{}, 0, 0, common.Uint256{0}, 0, nil, false)
+	return ns
+}
+
+func putTestHeader(ns *native.NativeService, chainID, height uint64) {
+	hash := utils.GetUint64Bytes(height)
+	ns.GetCacheDB().Put(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(hscommon.MAIN_CHAIN),
+		utils.GetUint64Bytes(chainID), utils.GetUint64Bytes(height)), cstates.GenRawStorageItem(hash))
+	ns.GetCacheDB().Put(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(hscommon.HEADER_INDEX),
+		utils.GetUint64Bytes(chainID), hash), cstates.GenRawStorageItem(hash))
+}
+
+func isTestHeaderKept(t *testing.T, ns *native.NativeService, chainID, height uint64) bool {
+	mainChain, err := ns.GetCacheDB().Get(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(hscommon.MAIN_CHAIN),
+		utils.GetUint64Bytes(chainID), utils.GetUint64Bytes(height)))
+	assert.Nil(t, err)
+	header, err := ns.GetCacheDB().Get(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(hscommon.HEADER_INDEX),
+		utils.GetUint64Bytes(chainID), utils.GetUint64Bytes(height)))
+	assert.Nil(t, err)
+	assert.Equal(t, mainChain != nil, header != nil)
+	return header != nil
+}
+
+func TestPruneHeaders(t *testing.T) {
+	ns := newTestNative()
+	pruner := &testPruner{genesis: 100, current: 130}
+	for h := pruner.genesis; h <= pruner.current; h++ {
+		putTestHeader(ns, 2, h)
+	}
+
+	// nothing is pruned without a retention policy, and nothing is pinned
+	assert.Nil(t, hscommon.PinHeader(ns, 2, 105))
+	assert.Nil(t, pruneHeaders(ns, 2, pruner))
+	for h := pruner.genesis; h <= pruner.current; h++ {
+		assert.True(t, isTestHeaderKept(t, ns, 2, h))
+	}
+	pins, err := hscommon.GetHeaderPins(ns, 2, 105)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), pins)
+
+	hscommon.PutHeaderRetention(ns, &hscommon.HeaderRetention{ChainID: 2, Retain: 10})
+	assert.Nil(t, hscommon.PinHeader(ns, 2, 105))
+	assert.Nil(t, hscommon.PinHeader(ns, 2, 105))
+	assert.Nil(t, pruneHeaders(ns, 2, pruner))
+	for h := pruner.genesis; h <= pruner.current; h++ {
+		kept := h == pruner.genesis || h == 105 || h > pruner.current-10
+		assert.Equal(t, kept, isTestHeaderKept(t, ns, 2, h), "height %d", h)
+	}
+	next, err := hscommon.GetPrunedHeight(ns, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(121), next)
+
+	// the pinned header is released by its last request only
+	released, err := hscommon.UnpinHeader(ns, 2, 105)
+	assert.Nil(t, err)
+	assert.False(t, released)
+	released, err = hscommon.UnpinHeader(ns, 2, 105)
+	assert.Nil(t, err)
+	assert.True(t, released)
+
+	// the pruning goes on from the pruned height as the chain grows
+	for h := pruner.current + 1; h <= 135; h++ {
+		putTestHeader(ns, 2, h)
+	}
+	pruner.current = 135
+	assert.Nil(t, pruneHeaders(ns, 2, pruner))
+	for h := uint64(121); h <= pruner.current; h++ {
+		assert.Equal(t, h > pruner.current-10, isTestHeaderKept(t, ns, 2, h), "height %d", h)
+	}
+	next, err = hscommon.GetPrunedHeight(ns, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(126), next)
+}
+
+func TestPruneHeadersLimit(t *testing.T) {
+	ns := newTestNative()
+	pruner := &testPruner{genesis: 0, current: hscommon.MAX_PRUNE_HEADERS + 20}
+	hscommon.PutHeaderRetention(ns, &hscommon.HeaderRetention{ChainID: 2, Retain: 10})
+
+	assert.Nil(t, pruneHeaders(ns, 2, pruner))
+	next, err := hscommon.GetPrunedHeight(ns, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, hscommon.MAX_PRUNE_HEADERS+1, next)
+
+	assert.Nil(t, pruneHeaders(ns, 2, pruner))
+	next, err = hscommon.GetPrunedHeight(ns, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, pruner.current-10+1, next)
+}
+
+const testPruneRouter = 1001
+
+func TestReleaseHeader(t *testing.T) {
+	ns := newTestNative()
+	pruner := &testPruner{genesis: 100, current: 130}
+	for h := pruner.genesis; h <= pruner.current; h++ {
+		putTestHeader(ns, 2, h)
+	}
+	hscommon.PutHeaderRetention(ns, &hscommon.HeaderRetention{ChainID: 2, Retain: 10})
+	assert.Nil(t, hscommon.PinHeader(ns, 2, 105))
+	assert.Nil(t, pruneHeaders(ns, 2, pruner))
+
+	// a header which is not pinned is left as it is, the side chain is not looked up
+	assert.Nil(t, side_chain_manager.PutSideChain(ns, &side_chain_manager.SideChain{ChainId: 2, Router: 9999}))
+	assert.Nil(t, ReleaseHeader(ns, 2, 125))
+	assert.True(t, isTestHeaderKept(t, ns, 2, 125))
+
+	// a released header below the pruned height is pruned
+	assert.Nil(t, side_chain_manager.PutSideChain(ns, &side_chain_manager.SideChain{ChainId: 2, Router: testPruneRouter}))
+	assert.True(t, isTestHeaderKept(t, ns, 2, 105))
+	assert.Nil(t, ReleaseHeader(ns, 2, 105))
+	assert.False(t, isTestHeaderKept(t, ns, 2, 105))
+}
+
+func TestRecordReorg(t *testing.T) {
+	ns := newTestNative()
+	var chainID uint64 = 2
+	requests := make([]*ccmcom.CrossChainRequest, 0)
+	for i, height := range []uint32{103, 105, 105, 108} {
+		request := &ccmcom.CrossChainRequest{
+			FromChainID:  chainID,
+			ToChainID:    3,
+			SourceTxHash: []byte{byte(i)},
+			PolyTxHash:   common.Uint256{byte(i + 1)},
+			SourceHeight: height,
+		}
+		assert.Nil(t, ccmcom.PutCrossChainRequest(ns, request))
+		requests = append(requests, request)
+	}
+
+	// an extension of the main chain is not a reorg
+	assert.Nil(t, hscommon.RecordReorg(ns, &hscommon.ReorgInfo{ChainID: chainID, OldHeight: 110, NewHeight: 111, ForkHeight: 110}))
+	reorg, err := hscommon.GetLastReorg(ns, chainID)
+	assert.Nil(t, err)
+	assert.Nil(t, reorg)
+
+	assert.Nil(t, hscommon.RecordReorg(ns, &hscommon.ReorgInfo{
+		ChainID:    chainID,
+		OldTip:     []byte{1},
+		OldHeight:  107,
+		NewTip:     []byte{2},
+		NewHeight:  108,
+		ForkHeight: 104,
+	}))
+	reorg, err = hscommon.GetLastReorg(ns, chainID)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(3), reorg.Depth())
+	assert.Equal(t, []byte{2}, reorg.NewTip)
+	count, err := hscommon.GetReorgCount(ns, chainID)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), count)
+
+	for _, request := range requests {
+		record, err := ccmcom.GetCrossChainRequest(ns, request.PolyTxHash)
+		assert.Nil(t, err)
+		assert.Equal(t, request.SourceHeight == 105, record.Orphaned)
+	}
+}
+
+func TestRecordReorgBounds(t *testing.T) {
+	ns := newTestNative()
+	var chainID uint64 = 2
+	heights := []uint32{101, 100 + uint32(hscommon.MAX_ORPHAN_HEIGHTS), 101 + uint32(hscommon.MAX_ORPHAN_HEIGHTS)}
+	for i, height := range heights {
+		assert.Nil(t, ccmcom.PutCrossChainRequest(ns, &ccmcom.CrossChainRequest{
+			FromChainID:  chainID,
+			ToChainID:    3,
+			SourceTxHash: []byte{byte(i)},
+			PolyTxHash:   common.Uint256{byte(i + 1)},
+			SourceHeight: height,
+		}))
+	}
+	reorg := &hscommon.ReorgInfo{
+		ChainID:    chainID,
+		OldHeight:  200 + hscommon.MAX_ORPHAN_HEIGHTS,
+		NewHeight:  201 + hscommon.MAX_ORPHAN_HEIGHTS,
+		ForkHeight: 100,
+	}
+
+	// nothing is recorded below the reorg record height
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_MAIN_NET
+	assert.Nil(t, hscommon.RecordReorg(ns, reorg))
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	count, err := hscommon.GetReorgCount(ns, chainID)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), count)
+
+	// only the first MAX_ORPHAN_HEIGHTS replaced heights are searched
+	assert.Nil(t, hscommon.RecordReorg(ns, reorg))
+	for i := range heights {
+		record, err := ccmcom.GetCrossChainRequest(ns, common.Uint256{byte(i + 1)})
+		assert.Nil(t, err)
+		assert.Equal(t, i < 2, record.Orphaned)
+	}
+}
+
+const testSyncRouter = 1000
+
+// testSyncer stores each header once, keyed by its bytes
+type testSyncer struct{}
+
+func (this *testSyncer) SyncGenesisHeader(native *native.NativeService) error { return nil }
+
+func (this *testSyncer) SyncBlockHeader(native *native.NativeService) (uint64, error) {
+	params := new(hscommon.SyncBlockHeaderParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return 0, err
+	}
+	var stored uint64
+	for _, v := range params.Headers {
+		key := utils.ConcatKey(utils.HeaderSyncContractAddress, utils.GetUint64Bytes(params.ChainID), v)
+		exist, err := native.GetCacheDB().Get(key)
+		if err != nil {
+			return 0, err
+		}
+		if exist != nil {
+			continue
+		}
+		native.GetCacheDB().Put(key, cstates.GenRawStorageItem(v))
+		stored++
+	}
+	return stored, nil
+}
+
+func (this *testSyncer) SyncCrossChainMsg(native *native.NativeService) (uint64, error) {
+	return 0, nil
+}
+
+func init() {
+	// features scheduled by height are active from genesis on solo net
+	config.DefConfig.P2PNode.NetworkId = config.NETWORK_ID_SOLO_NET
+	router.Register(&router.Router{Name: "test_sync", ID: testSyncRouter, HeaderSyncHandler: &testSyncer{}})
+	router.Register(&router.Router{Name: "test_prune", ID: testPruneRouter, HeaderSyncHandler: &testPruner{}})
+}
+
+func TestSyncBlockHeaderWeight(t *testing.T) {
+	relayer := common.Address{1}
+	ns := newTestNative()
+	db := ns.GetCacheDB()
+	db.Put(utils.ConcatKey(utils.RelayerManagerContractAddress, []byte(relayer_manager.RELAYER), relayer[:]),
+		cstates.GenRawStorageItem(relayer[:]))
+	assert.Nil(t, side_chain_manager.PutSideChain(ns, &side_chain_manager.SideChain{ChainId: 2, Router: testSyncRouter}))
+
+	weight := func(headers ...[]byte) uint64 {
+		params := &hscommon.SyncBlockHeaderParam{ChainID: 2, Address: relayer, Headers: headers}
+		sink := common.NewZeroCopySink(nil)
+		params.Serialization(sink)
+		ns, _ := native.NewNativeService(db, &types.Transaction{SignedAddr: []common.Address{relayer}}, 0, 0, common.Uint256{0}, 0, sink.Bytes(), false)
+		_, err := SyncBlockHeader(ns)
+		assert.Nil(t, err)
+
+		sink = common.NewZeroCopySink(nil)
+		(&relayer_reward.GetRelayerActivityParam{Relayer: relayer, ChainID: 2}).Serialization(sink)
+		ns, _ = native.NewNativeService(db, &types.Transaction{}, 0, 0, common.Uint256{0}, 0, sink.Bytes(), false)
+		res, err := relayer_reward.GetRelayerActivity(ns)
+		assert.Nil(t, err)
+		activity := new(relayer_reward.RelayerActivity)
+		assert.Nil(t, activity.Deserialization(common.NewZeroCopySource(res)))
+		return activity.HeaderSyncs
+	}
+	assert.Equal(t, uint64(2), weight([]byte{1}, []byte{2}))
+	// only the new headers of a sync are counted
+	assert.Equal(t, uint64(3), weight([]byte{2}, []byte{3}))
+	// a duplicate or empty sync adds no weight
+	assert.Equal(t, uint64(3), weight([]byte{1}))
+	assert.Equal(t, uint64(3), weight())
+}
@@ -315,7 +315,7 @@ func TestSyncBlockHeader(t *testing.T) {
 		param.Serialization(sink)
 
 		native = NewNative(sink.Bytes(), tx, native.GetCacheDB())
-		err := ethHandler.SyncBlockHeader(native)
+		_, err := ethHandler.SyncBlockHeader(native)
 		if err != nil {
 			t.Fatal("SyncBlockHeader", err)
 		}
@@ -380,7 +380,7 @@ func TestSyncBlockHeader1559(t *testing.T) {
 		param.Serialization(sink)
 
 		native = NewNative(sink.Bytes(), tx, native.GetCacheDB())
-		err := ethHandler.SyncBlockHeader(native)
+		_, err := ethHandler.SyncBlockHeader(native)
 		if err != nil {
 			t.Fatal("SyncBlockHeader", err)
 		}
@@ -432,7 +432,7 @@ func TestSyncBlockHeaderTwice(t *testing.T) {
 		sink := common.NewZeroCopySink(nil)
 		param.Serialization(sink)
 		native = NewNative(sink.Bytes(), nil, native.GetCacheDB())
-		err := ethHandler.SyncBlockHeader(native)
+		_, err := ethHandler.SyncBlockHeader(native)
 		assert.Equal(t, SUCCESS, typeOfError(err))
 		height := getLatestHeight(native)
 		assert.Equal(t, uint64(7152787), height)
@@ -453,7 +453,7 @@ func TestSyncBlockHeaderTwice(t *testing.T) {
 		sink := common.NewZeroCopySink(nil)
 		param.Serialization(sink)
 		native = NewNative(sink.Bytes(), nil, native.GetCacheDB())
-		err := ethHandler.SyncBlockHeader(native)
+		_, err := ethHandler.SyncBlockHeader(native)
 		assert.Equal(t, SUCCESS, typeOfError(err))
 		height := getLatestHeight(native)
 		assert.Equal(t, uint64(7152789), height)
@@ -522,7 +522,7 @@ func TestSyncBlockHeader_ParamError(t *testing.T) {
 	param.Serialization(sink)
 
 	native = NewNative(sink.Bytes(), nil, native.GetCacheDB())
-	err := ethHandler.SyncBlockHeader(native)
+	_, err := ethHandler.SyncBlockHeader(native)
 	assert.Equal(t, SYNCBLOCK_PARAM_ERROR, typeOfError(err))
 	height := getLatestHeight(native)
 	assert.Equal(t, uint64(7152787), height)
@@ -569,7 +569,7 @@ func TestSyncBlockHeader_OrphanBlock(t *testing.T) {
 	param.Serialization(sink)
 
 	native = NewNative(sink.Bytes(), nil, native.GetCacheDB())
-	err := ethHandler.SyncBlockHeader(native)
+	_, err := ethHandler.SyncBlockHeader(native)
 	assert.Equal(t, SYNCBLOCK_ORPHAN, typeOfError(err))
 	height := getLatestHeight(native)
 	assert.Equal(t, uint64(7152787), height)
@@ -620,7 +620,7 @@ func TestSyncBlockHeader_DifficultyWrong(t *testing.T) {
 	param.Serialization(sink)
 
 	native = NewNative(sink.Bytes(), nil, native.GetCacheDB())
-	err := ethHandler.SyncBlockHeader(native)
+	_, err := ethHandler.SyncBlockHeader(native)
 	assert.Equal(t, DIFFICULTY_ERROR, typeOfError(err))
 	height := getLatestHeight(native)
 	assert.Equal(t, uint64(7152787), height)
@@ -681,7 +681,7 @@ func TestSyncBlockHeader_NonceWrong(t *testing.T) {
 	param.Serialization(sink)
 
 	native = NewNative(sink.Bytes(), nil, native.GetCacheDB())
-	err := ethHandler.SyncBlockHeader(native)
+	_, err := ethHandler.SyncBlockHeader(native)
 	assert.Equal(t, NONCE_ERROR, typeOfError(err))
 	height := getLatestHeight(native)
 	assert.Equal(t, uint64(7152787), height)
@@ -741,7 +741,7 @@ func TestSyncBlockHeaderEpoch(t *testing.T) {
 	param.Serialization(sink)
 
 	native = NewNative(sink.Bytes(), nil, native.GetCacheDB())
-	err := ethHandler.SyncBlockHeader(native)
+	_, err := ethHandler.SyncBlockHeader(native)
 	assert.Equal(t, SUCCESS, typeOfError(err))
 	height := getLatestHeight(native)
 	assert.Equal(t, uint64(7140001), height)
@@ -802,7 +802,7 @@ func TestSyncBlockHeaderForked(t *testing.T) {
 		param.Serialization(sink)
 
 		native = NewNative(sink.Bytes(), nil, native.GetCacheDB())
-		err := ethHandler.SyncBlockHeader(native)
+		_, err := ethHandler.SyncBlockHeader(native)
 		assert.Equal(t, SUCCESS, typeOfError(err))
 		height := getLatestHeight(native)
 		assert.Equal(t, uint64(7152788), height)
@@ -820,7 +820,7 @@ func TestSyncBlockHeaderForked(t *testing.T) {
 		param.Serialization(sink)
 
 		native = NewNative(sink.Bytes(), nil, native.GetCacheDB())
-		err := ethHandler.SyncBlockHeader(native)
+		_, err := ethHandler.SyncBlockHeader(native)
 		assert.Equal(t, SUCCESS, typeOfError(err))
 		height := getLatestHeight(native)
 		assert.Equal(t, uint64(7152789), height)
@@ -862,7 +862,7 @@ func TestSyncBlockHeaderForked2(t *testing.T) {
 		param.Serialization(sink)
 
 		native = NewNative(sink.Bytes(), nil, native.GetCacheDB())
-		err := ethHandler.SyncBlockHeader(native)
+		_, err := ethHandler.SyncBlockHeader(native)
 		assert.Equal(t, SUCCESS, typeOfError(err))
 		height := getLatestHeight(native)
 		assert.Equal(t, uint64(7155390), height)
@@ -883,7 +883,7 @@ func TestSyncBlockHeaderForked2(t *testing.T) {
 		param.Serialization(sink)
 
 		native = NewNative(sink.Bytes(), nil, native.GetCacheDB())
-		err := ethHandler.SyncBlockHeader(native)
+		_, err := ethHandler.SyncBlockHeader(native)
 		assert.Equal(t, SUCCESS, typeOfError(err))
 		height := getLatestHeight(native)
 		assert.Equal(t, uint64(7155391), height)
@@ -73,6 +73,25 @@ func putGenesisBlockHeader(native *native.NativeService, blockHeader Header, cha
 	scom.NotifyPutHeader(native, chainID, blockHeader.Number.Uint64(), blockHeader.Hash().String())
 	return nil
 }
+func getGenesisHeight(native *native.NativeService, chainID uint64) (uint64, error) {
+	headerStore, err := native.GetCacheDB().Get(utils.ConcatKey(utils.HeaderSyncContractAddress,
+		[]byte(scom.GENESIS_HEADER), utils.GetUint64Bytes(chainID)))
+	if err != nil {
+		return 0, fmt.Errorf("getGenesisHeight, get headerStore error: %v", err)
+	}
+	if headerStore == nil {
+		return 0, fmt.Errorf("getGenesisHeight, genesis header is not synced")
+	}
+	storeBytes, err := cstates.GetValueFromRawStorageItem(headerStore)
+	if err != nil {
+		return 0, fmt.Errorf("getGenesisHeight, deserialize headerBytes from raw storage item err:%v", err)
+	}
+	var headerWithDifficultySum HeaderWithDifficultySum
+	if err := json.Unmarshal(storeBytes, &headerWithDifficultySum); err != nil {
+		return 0, fmt.Errorf("getGenesisHeight, deserialize header error: %v", err)
+	}
+	return headerWithDifficultySum.Header.Number.Uint64(), nil
+}
 func putBlockHeader(native *native.NativeService, blockHeader Header, difficultySum *big.Int, chainID uint64) error {
 	contract := utils.HeaderSyncContractAddress
 	headerWithDifficultySum := HeaderWithDifficultySum{
@@ -176,6 +195,13 @@ func IsHeaderExist(native *native.NativeService, hash []byte, chainID uint64) (b
 	}
 }
 func RestructChain(native *native.NativeService, current, new *Header, chainID uint64) error {
+	reorg := &scom.ReorgInfo{
+		ChainID:   chainID,
+		OldTip:    current.Hash().Bytes(),
+		OldHeight: current.Number.Uint64(),
+		NewTip:    new.Hash().Bytes(),
+		NewHeight: new.Number.Uint64(),
+	}
 	si, ti := current.Number.Uint64(), new.Number.Uint64()
 	var err error
 	if si > ti {
@@ -208,11 +234,15 @@ func RestructChain(native *native.NativeService, current, new *Header, chainID u
 		}
 	}
 	newHashs = append(newHashs, new.Hash())
+	reorg.ForkHeight = ti - 1
+	if new.Hash() == current.Hash() {
+		reorg.ForkHeight = ti
+	}
 	for i := len(newHashs) - 1; i >= 0; i-- {
 		appendHeader2Main(native, ti, newHashs[i], chainID)
 		ti++
 	}
-	return nil
+	return scom.RecordReorg(native, reorg)
 }
 
 var two256 = new(big.Int).Exp(big.NewInt(2), big.NewInt(256), big.NewInt(0))
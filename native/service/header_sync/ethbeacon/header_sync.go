@@ -0,0 +1,408 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package ethbeacon tracks the finalized Ethereum beacon chain with the sync
+// committee light client protocol and keeps the execution payload headers of
+// the finalized blocks, against whose state roots storage proofs are verified.
+package ethbeacon
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/polynetwork/poly/common"
+	cstates "github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/governance/node_manager"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	scom "github.com/polynetwork/poly/native/service/header_sync/common"
+	"github.com/polynetwork/poly/native/service/header_sync/common/bls"
+	"github.com/polynetwork/poly/native/service/utils"
+)
+
+// Handler ...
+type Handler struct {
+}
+
+// NewHandler ...
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// SyncGenesisHeader stores a trusted LightClientBootstrap
+func (h *Handler) SyncGenesisHeader(native *native.NativeService) error {
+	params := new(scom.SyncGenesisHeaderParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return fmt.Errorf("ethbeacon Handler SyncGenesisHeader, contract params deserialize error: %v", err)
+	}
+	// get current epoch operator
+	operatorAddress, err := node_manager.GetCurConOperator(native)
+	if err != nil {
+		return fmt.Errorf("ethbeacon Handler SyncGenesisHeader, get current consensus operator address error: %v", err)
+	}
+	// check witness
+	err = utils.ValidateOwner(native, operatorAddress)
+	if err != nil {
+		return fmt.Errorf("ethbeacon Handler SyncGenesisHeader, checkWitness error: %v", err)
+	}
+
+	// can only store once
+	finalized, err := getFinalizedHeader(native, params.ChainID)
+	if err != nil {
+		return fmt.Errorf("ethbeacon Handler SyncGenesisHeader, getFinalizedHeader error: %v", err)
+	}
+	if finalized != nil {
+		return fmt.Errorf("ethbeacon Handler SyncGenesisHeader, genesis had been initialized")
+	}
+
+	extraInfo, err := getExtraInfo(native, params.ChainID)
+	if err != nil {
+		return fmt.Errorf("ethbeacon Handler SyncGenesisHeader, %v", err)
+	}
+
+	var bootstrap LightClientBootstrap
+	if err := json.Unmarshal(params.GenesisHeader, &bootstrap); err != nil {
+		return fmt.Errorf("ethbeacon Handler SyncGenesisHeader, deserialize GenesisHeader err: %v", err)
+	}
+	fork, err := extraInfo.forkAt(bootstrap.Header.Slot)
+	if err != nil {
+		return fmt.Errorf("ethbeacon Handler SyncGenesisHeader, %v", err)
+	}
+	committeeRoot, err := bootstrap.CurrentSyncCommittee.HashTreeRoot(extraInfo.SyncCommitteeSize)
+	if err != nil {
+		return fmt.Errorf("ethbeacon Handler SyncGenesisHeader, %v", err)
+	}
+	if !isValidMerkleBranch(committeeRoot, bootstrap.CurrentSyncCommitteeBranch, fork.CurrentSyncCommitteeGindex, bootstrap.Header.StateRoot) {
+		return fmt.Errorf("ethbeacon Handler SyncGenesisHeader, invalid current sync committee branch")
+	}
+
+	putSyncCommittee(native, params.ChainID, extraInfo.periodAt(bootstrap.Header.Slot), &bootstrap.CurrentSyncCommittee)
+	if err := putFinalizedHeader(native, params.ChainID, &bootstrap.Header); err != nil {
+		return fmt.Errorf("ethbeacon Handler SyncGenesisHeader, putFinalizedHeader error: %v", err)
+	}
+	scom.NotifyPutHeader(native, params.ChainID, bootstrap.Header.Slot, bootstrap.Header.HashTreeRoot().Hex())
+	return nil
+}
+
+// SyncBlockHeader applies LightClientUpdates in order, each update must be
+// signed by the sync committee of its signature period
+func (h *Handler) SyncBlockHeader(native *native.NativeService) (uint64, error) {
+	params := new(scom.SyncBlockHeaderParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return 0, fmt.Errorf("ethbeacon Handler SyncBlockHeader, contract params deserialize error: %v", err)
+	}
+	extraInfo, err := getExtraInfo(native, params.ChainID)
+	if err != nil {
+		return 0, fmt.Errorf("ethbeacon Handler SyncBlockHeader, %v", err)
+	}
+	for _, v := range params.Headers {
+		update := new(LightClientUpdate)
+		if err := json.Unmarshal(v, update); err != nil {
+			return 0, fmt.Errorf("ethbeacon Handler SyncBlockHeader, deserialize update err: %v", err)
+		}
+		if err := processUpdate(native, params.ChainID, extraInfo, update); err != nil {
+			return 0, fmt.Errorf("ethbeacon Handler SyncBlockHeader, %v", err)
+		}
+	}
+	return uint64(len(params.Headers)), nil
+}
+
+// SyncCrossChainMsg ...
+func (h *Handler) SyncCrossChainMsg(native *native.NativeService) (uint64, error) {
+	return 0, nil
+}
+
+func processUpdate(native *native.NativeService, chainID uint64, extraInfo *ExtraInfo, update *LightClientUpdate) error {
+	finalized, err := getFinalizedHeader(native, chainID)
+	if err != nil {
+		return fmt.Errorf("processUpdate, getFinalizedHeader error: %v", err)
+	}
+	if finalized == nil {
+		return fmt.Errorf("processUpdate, genesis is not initialized")
+	}
+
+	// participation
+	size := extraInfo.SyncCommitteeSize
+	bits := update.SyncAggregate.SyncCommitteeBits
+	if uint64(len(bits)) != (size+7)/8 {
+		return fmt.Errorf("processUpdate, invalid sync committee bits length: %d", len(bits))
+	}
+	var participants []uint64
+	for i := uint64(0); i < size; i++ {
+		if bits[i/8]&(1<<(i%8)) != 0 {
+			participants = append(participants, i)
+		}
+	}
+	if uint64(len(participants))*3 < size*2 {
+		return fmt.Errorf("processUpdate, insufficient participants: %d", len(participants))
+	}
+
+	attested, newFinalized := &update.AttestedHeader, &update.FinalizedHeader
+	if update.SignatureSlot <= attested.Slot || attested.Slot < newFinalized.Slot {
+		return fmt.Errorf("processUpdate, invalid slots, signature: %d, attested: %d, finalized: %d",
+			update.SignatureSlot, attested.Slot, newFinalized.Slot)
+	}
+
+	storePeriod := extraInfo.periodAt(finalized.Slot)
+	signaturePeriod := extraInfo.periodAt(update.SignatureSlot)
+	attestedPeriod := extraInfo.periodAt(attested.Slot)
+	finalizedPeriod := extraInfo.periodAt(newFinalized.Slot)
+
+	current, err := getSyncCommittee(native, chainID, storePeriod)
+	if err != nil {
+		return fmt.Errorf("processUpdate, %v", err)
+	}
+	next, err := getSyncCommittee(native, chainID, storePeriod+1)
+	if err != nil {
+		return fmt.Errorf("processUpdate, %v", err)
+	}
+	var committee *storedCommittee
+	switch {
+	case signaturePeriod == storePeriod:
+		committee = current
+	case signaturePeriod == storePeriod+1 && next != nil:
+		committee = next
+	default:
+		return fmt.Errorf("processUpdate, no sync committee for period %d, store period: %d", signaturePeriod, storePeriod)
+	}
+	if committee == nil || uint64(len(committee.Pubkeys)) != size {
+		return fmt.Errorf("processUpdate, invalid sync committee of period %d", signaturePeriod)
+	}
+
+	learnsNext := update.NextSyncCommittee != nil && next == nil && attestedPeriod == storePeriod
+	if newFinalized.Slot <= finalized.Slot && !learnsNext {
+		return fmt.Errorf("processUpdate, update is not newer than finalized slot %d", finalized.Slot)
+	}
+
+	// finality
+	attestedFork, err := extraInfo.forkAt(attested.Slot)
+	if err != nil {
+		return fmt.Errorf("processUpdate, %v", err)
+	}
+	if !isValidMerkleBranch(newFinalized.HashTreeRoot(), update.FinalityBranch, attestedFork.FinalizedRootGindex, attested.StateRoot) {
+		return fmt.Errorf("processUpdate, invalid finality branch")
+	}
+
+	// the next sync committee is only taken from an attested state of the finalized period
+	if update.NextSyncCommittee != nil {
+		if attestedPeriod != finalizedPeriod {
+			return fmt.Errorf("processUpdate, next sync committee is not attested in the finalized period")
+		}
+		root, err := update.NextSyncCommittee.HashTreeRoot(size)
+		if err != nil {
+			return fmt.Errorf("processUpdate, next sync committee %v", err)
+		}
+		if !isValidMerkleBranch(root, update.NextSyncCommitteeBranch, attestedFork.NextSyncCommitteeGindex, attested.StateRoot) {
+			return fmt.Errorf("processUpdate, invalid next sync committee branch")
+		}
+	}
+
+	// execution payload of the finalized block
+	finalizedFork, err := extraInfo.forkAt(newFinalized.Slot)
+	if err != nil {
+		return fmt.Errorf("processUpdate, %v", err)
+	}
+	if len(update.ExecutionPayload) != finalizedFork.ExecutionPayloadFields {
+		return fmt.Errorf("processUpdate, invalid execution payload fields: %d", len(update.ExecutionPayload))
+	}
+	if !isValidMerkleBranch(merkleize(update.ExecutionPayload), update.ExecutionBranch, finalizedFork.ExecutionPayloadGindex, newFinalized.BodyRoot) {
+		return fmt.Errorf("processUpdate, invalid execution branch")
+	}
+
+	// signature, the fork version is the one of the slot before the signature slot
+	previousSlot := update.SignatureSlot - 1
+	signatureFork, err := extraInfo.forkAt(previousSlot)
+	if err != nil {
+		return fmt.Errorf("processUpdate, %v", err)
+	}
+	var version [4]byte
+	copy(version[:], signatureFork.Version)
+	domain := computeDomain(domainSyncCommittee, version, extraInfo.GenesisValidatorsRoot)
+	signingRoot := computeSigningRoot(attested.HashTreeRoot(), domain)
+	pubkeys := make([][]byte, 0, len(participants))
+	for _, i := range participants {
+		pubkeys = append(pubkeys, committee.Pubkeys[i])
+	}
+	if err := bls.FastAggregateVerify(pubkeys, signingRoot[:], update.SyncAggregate.SyncCommitteeSignature); err != nil {
+		return fmt.Errorf("processUpdate, %v", err)
+	}
+
+	// apply
+	if update.NextSyncCommittee != nil {
+		stored, err := getSyncCommittee(native, chainID, attestedPeriod+1)
+		if err != nil {
+			return fmt.Errorf("processUpdate, %v", err)
+		}
+		if stored == nil {
+			putSyncCommittee(native, chainID, attestedPeriod+1, update.NextSyncCommittee)
+		} else if !sameCommittee(stored, update.NextSyncCommittee) {
+			return fmt.Errorf("processUpdate, conflicting next sync committee of period %d", attestedPeriod+1)
+		}
+	}
+	if newFinalized.Slot > finalized.Slot {
+		if err := putFinalizedHeader(native, chainID, newFinalized); err != nil {
+			return fmt.Errorf("processUpdate, putFinalizedHeader error: %v", err)
+		}
+		header := &ExecutionHeader{
+			Number:    utils.GetBytesUint64(update.ExecutionPayload[payloadBlockNumberIndex][:8]),
+			BlockHash: update.ExecutionPayload[payloadBlockHashIndex],
+			StateRoot: update.ExecutionPayload[payloadStateRootIndex],
+			Slot:      newFinalized.Slot,
+		}
+		putExecutionHeader(native, chainID, header)
+		scom.NotifyPutHeader(native, chainID, header.Number, header.BlockHash.Hex())
+	}
+	return nil
+}
+
+func sameCommittee(stored *storedCommittee, committee *SyncCommittee) bool {
+	if len(stored.Pubkeys) != len(committee.Pubkeys) {
+		return false
+	}
+	for i := range stored.Pubkeys {
+		if !bytes.Equal(stored.Pubkeys[i], committee.Pubkeys[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func getExtraInfo(native *native.NativeService, chainID uint64) (*ExtraInfo, error) {
+	sideChain, err := side_chain_manager.GetSideChain(native, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("GetSideChain error: %v", err)
+	}
+	if sideChain == nil {
+		return nil, fmt.Errorf("side chain %d is not registered", chainID)
+	}
+	extraInfo := new(ExtraInfo)
+	if err := json.Unmarshal(sideChain.ExtraInfo, extraInfo); err != nil {
+		return nil, fmt.Errorf("ExtraInfo Unmarshal error: %v", err)
+	}
+	if err := extraInfo.validate(); err != nil {
+		return nil, fmt.Errorf("invalid ExtraInfo: %v", err)
+	}
+	return extraInfo, nil
+}
+
+func getFinalizedHeader(native *native.NativeService, chainID uint64) (*BeaconBlockHeader, error) {
+	store, err := native.GetCacheDB().Get(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.FINALIZED_HEADER), utils.GetUint64Bytes(chainID)))
+	if err != nil {
+		return nil, fmt.Errorf("getFinalizedHeader, GetCacheDB err:%v", err)
+	}
+	if store == nil {
+		return nil, nil
+	}
+	raw, err := cstates.GetValueFromRawStorageItem(store)
+	if err != nil {
+		return nil, fmt.Errorf("getFinalizedHeader, GetValueFromRawStorageItem err:%v", err)
+	}
+	header := new(BeaconBlockHeader)
+	if err := json.Unmarshal(raw, header); err != nil {
+		return nil, fmt.Errorf("getFinalizedHeader, json.Unmarshal err:%v", err)
+	}
+	return header, nil
+}
+
+func putFinalizedHeader(native *native.NativeService, chainID uint64, header *BeaconBlockHeader) error {
+	raw, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	native.GetCacheDB().Put(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.FINALIZED_HEADER), utils.GetUint64Bytes(chainID)),
+		cstates.GenRawStorageItem(raw))
+	return nil
+}
+
+func getSyncCommittee(native *native.NativeService, chainID, period uint64) (*storedCommittee, error) {
+	store, err := native.GetCacheDB().Get(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.SYNC_COMMITTEE),
+		utils.GetUint64Bytes(chainID), utils.GetUint64Bytes(period)))
+	if err != nil {
+		return nil, fmt.Errorf("getSyncCommittee, GetCacheDB err:%v", err)
+	}
+	if store == nil {
+		return nil, nil
+	}
+	raw, err := cstates.GetValueFromRawStorageItem(store)
+	if err != nil {
+		return nil, fmt.Errorf("getSyncCommittee, GetValueFromRawStorageItem err:%v", err)
+	}
+	committee := new(storedCommittee)
+	if err := committee.Deserialization(common.NewZeroCopySource(raw)); err != nil {
+		return nil, fmt.Errorf("getSyncCommittee, %v", err)
+	}
+	return committee, nil
+}
+
+func putSyncCommittee(native *native.NativeService, chainID, period uint64, committee *SyncCommittee) {
+	stored := &storedCommittee{Pubkeys: make([][]byte, len(committee.Pubkeys))}
+	for i, pubkey := range committee.Pubkeys {
+		stored.Pubkeys[i] = pubkey
+	}
+	sink := common.NewZeroCopySink(nil)
+	stored.Serialization(sink)
+	native.GetCacheDB().Put(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.SYNC_COMMITTEE),
+		utils.GetUint64Bytes(chainID), utils.GetUint64Bytes(period)), cstates.GenRawStorageItem(sink.Bytes()))
+}
+
+func putExecutionHeader(native *native.NativeService, chainID uint64, header *ExecutionHeader) {
+	sink := common.NewZeroCopySink(nil)
+	header.Serialization(sink)
+	native.GetCacheDB().Put(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.BLOCK_HEADER),
+		utils.GetUint64Bytes(chainID), utils.GetUint64Bytes(header.Number)), cstates.GenRawStorageItem(sink.Bytes()))
+	native.GetCacheDB().Put(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.CURRENT_HEADER_HEIGHT),
+		utils.GetUint64Bytes(chainID)), cstates.GenRawStorageItem(utils.GetUint64Bytes(header.Number)))
+}
+
+// GetCurrentExecutionHeight returns the block number of the latest finalized execution header
+func GetCurrentExecutionHeight(native *native.NativeService, chainID uint64) (uint64, error) {
+	store, err := native.GetCacheDB().Get(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.CURRENT_HEADER_HEIGHT),
+		utils.GetUint64Bytes(chainID)))
+	if err != nil {
+		return 0, fmt.Errorf("GetCurrentExecutionHeight, GetCacheDB err:%v", err)
+	}
+	if store == nil {
+		return 0, fmt.Errorf("GetCurrentExecutionHeight, no execution header of chain %d", chainID)
+	}
+	raw, err := cstates.GetValueFromRawStorageItem(store)
+	if err != nil {
+		return 0, fmt.Errorf("GetCurrentExecutionHeight, GetValueFromRawStorageItem err:%v", err)
+	}
+	return utils.GetBytesUint64(raw), nil
+}
+
+// GetExecutionHeader returns the finalized execution header with block number height
+func GetExecutionHeader(native *native.NativeService, chainID, height uint64) (*ExecutionHeader, error) {
+	store, err := native.GetCacheDB().Get(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.BLOCK_HEADER),
+		utils.GetUint64Bytes(chainID), utils.GetUint64Bytes(height)))
+	if err != nil {
+		return nil, fmt.Errorf("GetExecutionHeader, GetCacheDB err:%v", err)
+	}
+	if store == nil {
+		return nil, fmt.Errorf("GetExecutionHeader, no finalized execution header at height %d", height)
+	}
+	raw, err := cstates.GetValueFromRawStorageItem(store)
+	if err != nil {
+		return nil, fmt.Errorf("GetExecutionHeader, GetValueFromRawStorageItem err:%v", err)
+	}
+	header := new(ExecutionHeader)
+	if err := header.Deserialization(common.NewZeroCopySource(raw)); err != nil {
+		return nil, fmt.Errorf("GetExecutionHeader, %v", err)
+	}
+	return header, nil
+}
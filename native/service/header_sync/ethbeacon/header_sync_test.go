@@ -0,0 +1,281 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ethbeacon
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto/bls12381"
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/polynetwork/poly/account"
+	"github.com/polynetwork/poly/common"
+	vconfig "github.com/polynetwork/poly/consensus/vbft/config"
+	"github.com/polynetwork/poly/core/genesis"
+	"github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/core/store/leveldbstore"
+	"github.com/polynetwork/poly/core/store/overlaydb"
+	"github.com/polynetwork/poly/core/types"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/governance/node_manager"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	scom "github.com/polynetwork/poly/native/service/header_sync/common"
+	"github.com/polynetwork/poly/native/service/header_sync/common/bls"
+	"github.com/polynetwork/poly/native/service/utils"
+	"github.com/polynetwork/poly/native/storage"
+	"gotest.tools/assert"
+)
+
+const testChainID = 100
+
+var (
+	acct = account.NewAccount("")
+
+	testFork = &Fork{
+		Epoch:                      0,
+		Version:                    hexutil.Bytes{0x04, 0, 0, 0},
+		FinalizedRootGindex:        105,
+		CurrentSyncCommitteeGindex: 54,
+		NextSyncCommitteeGindex:    55,
+		ExecutionPayloadGindex:     25,
+		ExecutionPayloadFields:     17,
+	}
+	testExtraInfo = &ExtraInfo{
+		GenesisValidatorsRoot:        ecommon.HexToHash("0x4b363db94e286120d76eb905340fdd4e54bfe9f06bf33ff6cf5ad27f511bfe95"),
+		SlotsPerEpoch:                8,
+		EpochsPerSyncCommitteePeriod: 4,
+		SyncCommitteeSize:            4,
+		Forks:                        []*Fork{testFork},
+	}
+)
+
+func init() {
+	genesis.GenesisBookkeepers = []keypair.PublicKey{acct.PublicKey}
+}
+
+func newNative(args []byte, tx *types.Transaction, db *storage.CacheDB) *native.NativeService {
+	if db == nil {
+		store, _ := leveldbstore.NewMemLevelDBStore()
+		db = storage.NewCacheDB(overlaydb.NewOverlayDB(store))
+		sink := common.NewZeroCopySink(nil)
+		view := &node_manager.GovernanceView{TxHash: common.UINT256_EMPTY}
+		view.Serialization(sink)
+		db.Put(utils.ConcatKey(utils.NodeManagerContractAddress, []byte(node_manager.GOVERNANCE_VIEW)), states.GenRawStorageItem(sink.Bytes()))
+
+		peerPoolMap := &node_manager.PeerPoolMap{
+			PeerPoolMap: map[string]*node_manager.PeerPoolItem{
+				vconfig.PubkeyID(acct.PublicKey): {
+					Address:    acct.Address,
+					Status:     node_manager.ConsensusStatus,
+					PeerPubkey: vconfig.PubkeyID(acct.PublicKey),
+				},
+			},
+		}
+		sink.Reset()
+		peerPoolMap.Serialization(sink)
+		db.Put(utils.ConcatKey(utils.NodeManagerContractAddress, []byte(node_manager.PEER_POOL), utils.GetUint32Bytes(0)),
+			states.GenRawStorageItem(sink.Bytes()))
+	}
+	service, _ := native.NewNativeService(db, tx, 0, 0, common.Uint256{0}, 0, args, false)
+	return service
+}
+
+// testCommittee is a sync committee with known secret keys
+type testCommittee struct {
+	keys      []*big.Int
+	committee *SyncCommittee
+}
+
+func newTestCommittee(seed int64) *testCommittee {
+	g1 := bls12381.NewG1()
+	c := &testCommittee{committee: &SyncCommittee{AggregatePubkey: make(hexutil.Bytes, bls.PubkeyCompressedLength)}}
+	for i := int64(0); i < int64(testExtraInfo.SyncCommitteeSize); i++ {
+		sk := big.NewInt(seed*1000 + i + 1)
+		c.keys = append(c.keys, sk)
+		c.committee.Pubkeys = append(c.committee.Pubkeys, g1.ToBytes(g1.MulScalar(g1.New(), g1.One(), sk)))
+	}
+	return c
+}
+
+func (this *testCommittee) root(t *testing.T) ecommon.Hash {
+	root, err := this.committee.HashTreeRoot(testExtraInfo.SyncCommitteeSize)
+	assert.NilError(t, err)
+	return root
+}
+
+// sign signs header by the members set in bits
+func (this *testCommittee) sign(t *testing.T, header *BeaconBlockHeader, signatureSlot uint64, bits byte) SyncAggregate {
+	fork, err := testExtraInfo.forkAt(signatureSlot - 1)
+	assert.NilError(t, err)
+	var version [4]byte
+	copy(version[:], fork.Version)
+	signingRoot := computeSigningRoot(header.HashTreeRoot(), computeDomain(domainSyncCommittee, version, testExtraInfo.GenesisValidatorsRoot))
+	h, err := bls.HashToG2(signingRoot[:], bls.DST)
+	assert.NilError(t, err)
+	sum := new(big.Int)
+	for i, sk := range this.keys {
+		if bits&(1<<uint(i)) != 0 {
+			sum.Add(sum, sk)
+		}
+	}
+	g2 := bls12381.NewG2()
+	return SyncAggregate{
+		SyncCommitteeBits:      hexutil.Bytes{bits},
+		SyncCommitteeSignature: g2.ToBytes(g2.MulScalar(g2.New(), h, sum)),
+	}
+}
+
+// sparseTree is a merkle tree of the given depth where only some nodes are set
+type sparseTree struct {
+	depth int
+	nodes map[uint64]ecommon.Hash
+}
+
+func (this *sparseTree) node(gindex uint64) ecommon.Hash {
+	if v, ok := this.nodes[gindex]; ok {
+		return v
+	}
+	if gindex >= 1<<uint(this.depth) {
+		return ecommon.Hash{}
+	}
+	return hashPair(this.node(2*gindex), this.node(2*gindex+1))
+}
+
+func (this *sparseTree) branch(gindex uint64) []ecommon.Hash {
+	var branch []ecommon.Hash
+	for ; gindex > 1; gindex >>= 1 {
+		branch = append(branch, this.node(gindex^1))
+	}
+	return branch
+}
+
+func executionPayload(number uint64) []ecommon.Hash {
+	fields := make([]ecommon.Hash, testFork.ExecutionPayloadFields)
+	for i := range fields {
+		fields[i] = ecommon.BigToHash(big.NewInt(int64(number*100) + int64(i)))
+	}
+	fields[payloadBlockNumberIndex] = uint64Root(number)
+	return fields
+}
+
+func makeUpdate(t *testing.T, signer *testCommittee, next *testCommittee, finalizedSlot, attestedSlot, signatureSlot, number uint64, bits byte) *LightClientUpdate {
+	update := &LightClientUpdate{ExecutionPayload: executionPayload(number), SignatureSlot: signatureSlot}
+	body := &sparseTree{depth: 4, nodes: map[uint64]ecommon.Hash{testFork.ExecutionPayloadGindex: merkleize(update.ExecutionPayload)}}
+	update.ExecutionBranch = body.branch(testFork.ExecutionPayloadGindex)
+	update.FinalizedHeader = BeaconBlockHeader{Slot: finalizedSlot, ProposerIndex: 7, BodyRoot: body.node(1)}
+
+	state := &sparseTree{depth: 6, nodes: map[uint64]ecommon.Hash{testFork.FinalizedRootGindex: update.FinalizedHeader.HashTreeRoot()}}
+	if next != nil {
+		state.nodes[testFork.NextSyncCommitteeGindex] = next.root(t)
+		update.NextSyncCommittee = next.committee
+	}
+	update.FinalityBranch = state.branch(testFork.FinalizedRootGindex)
+	if next != nil {
+		update.NextSyncCommitteeBranch = state.branch(testFork.NextSyncCommitteeGindex)
+	}
+	update.AttestedHeader = BeaconBlockHeader{Slot: attestedSlot, ProposerIndex: 9, StateRoot: state.node(1)}
+	update.SyncAggregate = signer.sign(t, &update.AttestedHeader, signatureSlot, bits)
+	return update
+}
+
+func syncUpdates(db *storage.CacheDB, updates ...*LightClientUpdate) error {
+	param := &scom.SyncBlockHeaderParam{ChainID: testChainID}
+	for _, update := range updates {
+		raw, _ := json.Marshal(update)
+		param.Headers = append(param.Headers, raw)
+	}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	_, err := NewHandler().SyncBlockHeader(newNative(sink.Bytes(), &types.Transaction{}, db))
+	return err
+}
+
+func TestSyncBeaconHeaders(t *testing.T) {
+	committee0, committee1 := newTestCommittee(1), newTestCommittee(2)
+
+	service := newNative(nil, &types.Transaction{}, nil)
+	extra, _ := json.Marshal(testExtraInfo)
+	assert.NilError(t, side_chain_manager.PutSideChain(service, &side_chain_manager.SideChain{
+		ChainId:   testChainID,
+		Router:    utils.ETH_BEACON_ROUTER,
+		ExtraInfo: extra,
+	}))
+	db := service.GetCacheDB()
+
+	// genesis, slot 8 of period 0
+	state := &sparseTree{depth: 6, nodes: map[uint64]ecommon.Hash{testFork.CurrentSyncCommitteeGindex: committee0.root(t)}}
+	bootstrap := &LightClientBootstrap{
+		Header:                     BeaconBlockHeader{Slot: 8, StateRoot: state.node(1)},
+		CurrentSyncCommittee:       *committee0.committee,
+		CurrentSyncCommitteeBranch: state.branch(testFork.CurrentSyncCommitteeGindex),
+	}
+	raw, _ := json.Marshal(bootstrap)
+	param := &scom.SyncGenesisHeaderParam{ChainID: testChainID, GenesisHeader: raw}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	err := NewHandler().SyncGenesisHeader(newNative(sink.Bytes(), &types.Transaction{}, db))
+	assert.ErrorContains(t, err, "checkWitness error")
+	tx := &types.Transaction{SignedAddr: []common.Address{acct.Address}}
+	assert.NilError(t, NewHandler().SyncGenesisHeader(newNative(sink.Bytes(), tx, db)))
+	err = NewHandler().SyncGenesisHeader(newNative(sink.Bytes(), tx, db))
+	assert.ErrorContains(t, err, "genesis had been initialized")
+
+	// period 0 update carrying the committee of period 1
+	err = syncUpdates(db, makeUpdate(t, committee0, committee1, 16, 20, 21, 100, 0x07))
+	assert.NilError(t, err)
+	header, err := GetExecutionHeader(newNative(nil, &types.Transaction{}, db), testChainID, 100)
+	assert.NilError(t, err)
+	assert.Equal(t, executionPayload(100)[payloadStateRootIndex], header.StateRoot)
+	assert.Equal(t, executionPayload(100)[payloadBlockHashIndex], header.BlockHash)
+	assert.Equal(t, uint64(16), header.Slot)
+	height, err := GetCurrentExecutionHeight(newNative(nil, &types.Transaction{}, db), testChainID)
+	assert.NilError(t, err)
+	assert.Equal(t, uint64(100), height)
+
+	err = syncUpdates(db, makeUpdate(t, committee0, nil, 16, 20, 21, 100, 0x07))
+	assert.ErrorContains(t, err, "update is not newer")
+	err = syncUpdates(db, makeUpdate(t, committee0, nil, 24, 26, 27, 110, 0x03))
+	assert.ErrorContains(t, err, "insufficient participants")
+	err = syncUpdates(db, makeUpdate(t, committee1, nil, 24, 26, 27, 110, 0x0f))
+	assert.ErrorContains(t, err, "invalid signature")
+
+	bad := makeUpdate(t, committee0, nil, 24, 26, 27, 110, 0x0f)
+	bad.ExecutionPayload[payloadStateRootIndex] = ecommon.Hash{1}
+	err = syncUpdates(db, bad)
+	assert.ErrorContains(t, err, "invalid execution branch")
+	bad = makeUpdate(t, committee0, nil, 24, 26, 27, 110, 0x0f)
+	bad.FinalizedHeader.Slot = 25
+	err = syncUpdates(db, bad)
+	assert.ErrorContains(t, err, "invalid finality branch")
+
+	// rotation, updates signed in period 1 need the committee of period 1
+	err = syncUpdates(db, makeUpdate(t, committee0, nil, 40, 44, 45, 200, 0x0f))
+	assert.ErrorContains(t, err, "invalid signature")
+	err = syncUpdates(db, makeUpdate(t, committee1, nil, 40, 44, 45, 200, 0x0e))
+	assert.NilError(t, err)
+	height, err = GetCurrentExecutionHeight(newNative(nil, &types.Transaction{}, db), testChainID)
+	assert.NilError(t, err)
+	assert.Equal(t, uint64(200), height)
+
+	// period 2 is unknown yet
+	err = syncUpdates(db, makeUpdate(t, committee1, nil, 64, 66, 67, 300, 0x0f))
+	assert.ErrorContains(t, err, "no sync committee for period 2")
+}
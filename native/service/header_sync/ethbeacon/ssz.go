@@ -0,0 +1,98 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ethbeacon
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+)
+
+// the subset of SSZ merkleization needed by the light client
+
+func hashPair(a, b ecommon.Hash) ecommon.Hash {
+	return sha256.Sum256(append(a[:], b[:]...))
+}
+
+func uint64Root(v uint64) (root ecommon.Hash) {
+	binary.LittleEndian.PutUint64(root[:], v)
+	return
+}
+
+// merkleize computes the root of chunks padded with zero chunks to the next power of two
+func merkleize(chunks []ecommon.Hash) ecommon.Hash {
+	size := 1
+	for size < len(chunks) {
+		size <<= 1
+	}
+	layer := make([]ecommon.Hash, size)
+	copy(layer, chunks)
+	for len(layer) > 1 {
+		next := make([]ecommon.Hash, len(layer)/2)
+		for i := range next {
+			next[i] = hashPair(layer[2*i], layer[2*i+1])
+		}
+		layer = next
+	}
+	return layer[0]
+}
+
+// isValidMerkleBranch checks that leaf is the node at generalized index gindex
+// of the tree with the given root
+func isValidMerkleBranch(leaf ecommon.Hash, branch []ecommon.Hash, gindex uint64, root ecommon.Hash) bool {
+	depth := 0
+	for g := gindex; g > 1; g >>= 1 {
+		depth++
+	}
+	if gindex == 0 || len(branch) != depth {
+		return false
+	}
+	value := leaf
+	for i := 0; i < depth; i++ {
+		if (gindex>>uint(i))&1 == 1 {
+			value = hashPair(branch[i], value)
+		} else {
+			value = hashPair(value, branch[i])
+		}
+	}
+	return value == root
+}
+
+// pubkeyRoot is the hash tree root of a 48 byte compressed pubkey
+func pubkeyRoot(pubkey []byte) ecommon.Hash {
+	var chunks [2]ecommon.Hash
+	copy(chunks[0][:], pubkey[:32])
+	copy(chunks[1][:], pubkey[32:])
+	return hashPair(chunks[0], chunks[1])
+}
+
+// computeDomain returns the signing domain of domainType for forkVersion
+func computeDomain(domainType [4]byte, forkVersion [4]byte, genesisValidatorsRoot ecommon.Hash) (domain ecommon.Hash) {
+	var version ecommon.Hash
+	copy(version[:], forkVersion[:])
+	forkDataRoot := hashPair(version, genesisValidatorsRoot)
+	copy(domain[:], domainType[:])
+	copy(domain[4:], forkDataRoot[:28])
+	return
+}
+
+// computeSigningRoot is the hash tree root of the SigningData container
+func computeSigningRoot(objectRoot, domain ecommon.Hash) ecommon.Hash {
+	return hashPair(objectRoot, domain)
+}
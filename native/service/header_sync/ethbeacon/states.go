@@ -0,0 +1,237 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ethbeacon
+
+import (
+	"fmt"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/native/service/header_sync/common/bls"
+)
+
+// indexes of the execution payload header fields used by poly
+const (
+	payloadStateRootIndex   = 2
+	payloadBlockNumberIndex = 6
+	payloadBlockHashIndex   = 12
+)
+
+var domainSyncCommittee = [4]byte{0x07, 0x00, 0x00, 0x00}
+
+// ExtraInfo is the beacon chain specification of a side chain, it is kept in
+// SideChain.ExtraInfo so that new forks can be scheduled with UpdateSideChain
+type ExtraInfo struct {
+	GenesisValidatorsRoot        ecommon.Hash
+	SlotsPerEpoch                uint64
+	EpochsPerSyncCommitteePeriod uint64
+	SyncCommitteeSize            uint64
+	Forks                        []*Fork
+}
+
+// Fork describes the signing version and the state layout from Epoch on,
+// gindexes are the generalized indexes of the light client proofs
+type Fork struct {
+	Epoch                      uint64
+	Version                    hexutil.Bytes
+	FinalizedRootGindex        uint64
+	CurrentSyncCommitteeGindex uint64
+	NextSyncCommitteeGindex    uint64
+	ExecutionPayloadGindex     uint64
+	ExecutionPayloadFields     int
+}
+
+func (this *ExtraInfo) validate() error {
+	if this.SlotsPerEpoch == 0 || this.EpochsPerSyncCommitteePeriod == 0 || this.SyncCommitteeSize == 0 {
+		return fmt.Errorf("invalid chain spec")
+	}
+	if len(this.Forks) == 0 {
+		return fmt.Errorf("no fork")
+	}
+	for i, fork := range this.Forks {
+		if len(fork.Version) != 4 {
+			return fmt.Errorf("invalid version of fork %d", i)
+		}
+		if fork.ExecutionPayloadFields <= payloadBlockHashIndex {
+			return fmt.Errorf("invalid execution payload fields of fork %d", i)
+		}
+		if i > 0 && fork.Epoch <= this.Forks[i-1].Epoch {
+			return fmt.Errorf("forks are not sorted by epoch")
+		}
+	}
+	return nil
+}
+
+func (this *ExtraInfo) epochAt(slot uint64) uint64 {
+	return slot / this.SlotsPerEpoch
+}
+
+func (this *ExtraInfo) periodAt(slot uint64) uint64 {
+	return slot / this.SlotsPerEpoch / this.EpochsPerSyncCommitteePeriod
+}
+
+func (this *ExtraInfo) forkAt(slot uint64) (*Fork, error) {
+	epoch := this.epochAt(slot)
+	for i := len(this.Forks) - 1; i >= 0; i-- {
+		if this.Forks[i].Epoch <= epoch {
+			return this.Forks[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no fork at epoch %d", epoch)
+}
+
+// BeaconBlockHeader ...
+type BeaconBlockHeader struct {
+	Slot          uint64       `json:"slot,string"`
+	ProposerIndex uint64       `json:"proposer_index,string"`
+	ParentRoot    ecommon.Hash `json:"parent_root"`
+	StateRoot     ecommon.Hash `json:"state_root"`
+	BodyRoot      ecommon.Hash `json:"body_root"`
+}
+
+// HashTreeRoot ...
+func (this *BeaconBlockHeader) HashTreeRoot() ecommon.Hash {
+	return merkleize([]ecommon.Hash{
+		uint64Root(this.Slot),
+		uint64Root(this.ProposerIndex),
+		this.ParentRoot,
+		this.StateRoot,
+		this.BodyRoot,
+	})
+}
+
+// SyncCommittee carries uncompressed pubkeys, AggregatePubkey is only hashed
+// and is kept compressed
+type SyncCommittee struct {
+	Pubkeys         []hexutil.Bytes `json:"pubkeys"`
+	AggregatePubkey hexutil.Bytes   `json:"aggregate_pubkey"`
+}
+
+// HashTreeRoot checks the pubkeys and computes the root of the committee
+func (this *SyncCommittee) HashTreeRoot(size uint64) (ecommon.Hash, error) {
+	if uint64(len(this.Pubkeys)) != size {
+		return ecommon.Hash{}, fmt.Errorf("invalid sync committee size: %d", len(this.Pubkeys))
+	}
+	if len(this.AggregatePubkey) != bls.PubkeyCompressedLength {
+		return ecommon.Hash{}, fmt.Errorf("invalid aggregate pubkey length: %d", len(this.AggregatePubkey))
+	}
+	roots := make([]ecommon.Hash, len(this.Pubkeys))
+	for i, pubkey := range this.Pubkeys {
+		compressed, err := bls.CompressPubkey(pubkey)
+		if err != nil {
+			return ecommon.Hash{}, fmt.Errorf("invalid pubkey %d: %v", i, err)
+		}
+		roots[i] = pubkeyRoot(compressed)
+	}
+	return hashPair(merkleize(roots), pubkeyRoot(this.AggregatePubkey)), nil
+}
+
+// SyncAggregate ...
+type SyncAggregate struct {
+	SyncCommitteeBits      hexutil.Bytes `json:"sync_committee_bits"`
+	SyncCommitteeSignature hexutil.Bytes `json:"sync_committee_signature"`
+}
+
+// LightClientBootstrap is the genesis of the light client, a trusted block
+// header and its current sync committee
+type LightClientBootstrap struct {
+	Header                     BeaconBlockHeader `json:"header"`
+	CurrentSyncCommittee       SyncCommittee     `json:"current_sync_committee"`
+	CurrentSyncCommitteeBranch []ecommon.Hash    `json:"current_sync_committee_branch"`
+}
+
+// LightClientUpdate advances the finalized header, ExecutionPayload holds the
+// hash tree roots of the fields of the execution payload header of the
+// finalized block
+type LightClientUpdate struct {
+	AttestedHeader          BeaconBlockHeader `json:"attested_header"`
+	NextSyncCommittee       *SyncCommittee    `json:"next_sync_committee,omitempty"`
+	NextSyncCommitteeBranch []ecommon.Hash    `json:"next_sync_committee_branch,omitempty"`
+	FinalizedHeader         BeaconBlockHeader `json:"finalized_header"`
+	FinalityBranch          []ecommon.Hash    `json:"finality_branch"`
+	ExecutionPayload        []ecommon.Hash    `json:"execution_payload"`
+	ExecutionBranch         []ecommon.Hash    `json:"execution_branch"`
+	SyncAggregate           SyncAggregate     `json:"sync_aggregate"`
+	SignatureSlot           uint64            `json:"signature_slot,string"`
+}
+
+// ExecutionHeader is the part of a verified execution payload header kept by poly
+type ExecutionHeader struct {
+	Number    uint64
+	BlockHash ecommon.Hash
+	StateRoot ecommon.Hash
+	Slot      uint64
+}
+
+func (this *ExecutionHeader) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint64(this.Number)
+	sink.WriteHash(common.Uint256(this.BlockHash))
+	sink.WriteHash(common.Uint256(this.StateRoot))
+	sink.WriteUint64(this.Slot)
+}
+
+func (this *ExecutionHeader) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	this.Number, eof = source.NextUint64()
+	if eof {
+		return fmt.Errorf("ExecutionHeader deserialize number error")
+	}
+	blockHash, eof := source.NextHash()
+	if eof {
+		return fmt.Errorf("ExecutionHeader deserialize block hash error")
+	}
+	stateRoot, eof := source.NextHash()
+	if eof {
+		return fmt.Errorf("ExecutionHeader deserialize state root error")
+	}
+	this.Slot, eof = source.NextUint64()
+	if eof {
+		return fmt.Errorf("ExecutionHeader deserialize slot error")
+	}
+	this.BlockHash, this.StateRoot = ecommon.Hash(blockHash), ecommon.Hash(stateRoot)
+	return nil
+}
+
+// storedCommittee holds the uncompressed pubkeys of a verified sync committee
+type storedCommittee struct {
+	Pubkeys [][]byte
+}
+
+func (this *storedCommittee) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteVarUint(uint64(len(this.Pubkeys)))
+	for _, pubkey := range this.Pubkeys {
+		sink.WriteVarBytes(pubkey)
+	}
+}
+
+func (this *storedCommittee) Deserialization(source *common.ZeroCopySource) error {
+	n, eof := source.NextVarUint()
+	if eof {
+		return fmt.Errorf("storedCommittee deserialize length error")
+	}
+	this.Pubkeys = make([][]byte, 0, n)
+	for i := uint64(0); i < n; i++ {
+		pubkey, eof := source.NextVarBytes()
+		if eof {
+			return fmt.Errorf("storedCommittee deserialize pubkey error")
+		}
+		this.Pubkeys = append(this.Pubkeys, pubkey)
+	}
+	return nil
+}
@@ -211,38 +211,39 @@ type HeaderWithDifficultySum struct {
 // SyncBlockHeader ...
 // Will verify header coming from congress consensus
 // https://github.com/HuobiGroup/huobi-eco-chain/tree/master/consensus/congress
-func (h *Handler) SyncBlockHeader(native *native.NativeService) error {
+func (h *Handler) SyncBlockHeader(native *native.NativeService) (uint64, error) {
 	headerParams := new(scom.SyncBlockHeaderParam)
 	if err := headerParams.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
-		return fmt.Errorf("heco Handler SyncBlockHeader, contract params deserialize error: %v", err)
+		return 0, fmt.Errorf("heco Handler SyncBlockHeader, contract params deserialize error: %v", err)
 	}
 
 	side, err := side_chain_manager.GetSideChain(native, headerParams.ChainID)
 	if err != nil {
-		return fmt.Errorf("heco Handler SyncBlockHeader, GetSideChain error: %v", err)
+		return 0, fmt.Errorf("heco Handler SyncBlockHeader, GetSideChain error: %v", err)
 	}
 	if side == nil {
-		return fmt.Errorf("heco Hander SyncBlockHeader, GetSideChain info nil")
+		return 0, fmt.Errorf("heco Hander SyncBlockHeader, GetSideChain info nil")
 	}
 	var extraInfo ExtraInfo
 	err = json.Unmarshal(side.ExtraInfo, &extraInfo)
 	if err != nil {
-		return fmt.Errorf("heco Handler SyncBlockHeader, ExtraInfo Unmarshal error: %v", err)
+		return 0, fmt.Errorf("heco Handler SyncBlockHeader, ExtraInfo Unmarshal error: %v", err)
 	}
 
 	ctx := &Context{ExtraInfo: extraInfo, ChainID: headerParams.ChainID}
 
+	var stored uint64
 	for _, v := range headerParams.Headers {
 		var header eth.Header
 		err := json.Unmarshal(v, &header)
 		if err != nil {
-			return fmt.Errorf("heco Handler SyncBlockHeader, deserialize header err: %v", err)
+			return 0, fmt.Errorf("heco Handler SyncBlockHeader, deserialize header err: %v", err)
 		}
 		headerHash := header.Hash()
 
 		exist, err := isHeaderExist(native, headerHash, ctx)
 		if err != nil {
-			return fmt.Errorf("heco Handler SyncBlockHeader, isHeaderExist headerHash err: %v", err)
+			return 0, fmt.Errorf("heco Handler SyncBlockHeader, isHeaderExist headerHash err: %v", err)
 		}
 		if exist {
 			log.Warnf("heco Handler SyncBlockHeader, header has exist. Header: %s", string(v))
@@ -251,7 +252,7 @@ func (h *Handler) SyncBlockHeader(native *native.NativeService) error {
 
 		parentExist, err := isHeaderExist(native, header.ParentHash, ctx)
 		if err != nil {
-			return fmt.Errorf("heco Handler SyncBlockHeader, isHeaderExist ParentHash err: %v", err)
+			return 0, fmt.Errorf("heco Handler SyncBlockHeader, isHeaderExist ParentHash err: %v", err)
 		}
 		if !parentExist {
 			log.Warnf("heco Handler SyncBlockHeader, parent header not exist. Header: %s", string(v))
@@ -260,13 +261,13 @@ func (h *Handler) SyncBlockHeader(native *native.NativeService) error {
 
 		signer, err := verifySignature(native, &header, ctx)
 		if err != nil {
-			return fmt.Errorf("heco Handler SyncBlockHeader, verifySignature err: %v", err)
+			return 0, fmt.Errorf("heco Handler SyncBlockHeader, verifySignature err: %v", err)
 		}
 
 		// get prev epochs, also checking recent limit
 		phv, _, lastSeenHeight, err := getPrevHeightAndValidators(native, &header, ctx)
 		if err != nil {
-			return fmt.Errorf("heco Handler SyncBlockHeader, getPrevHeightAndValidators err: %v", err)
+			return 0, fmt.Errorf("heco Handler SyncBlockHeader, getPrevHeightAndValidators err: %v", err)
 		}
 
 		inTurnHV := phv
@@ -274,13 +275,13 @@ func (h *Handler) SyncBlockHeader(native *native.NativeService) error {
 		if lastSeenHeight > 0 {
 			limit := int64(len(inTurnHV.Validators) / 2)
 			if header.Number.Int64() <= lastSeenHeight+limit {
-				return fmt.Errorf("heco Handler SyncBlockHeader, RecentlySigned, lastSeenHeight:%d currentHeight:%d #V:%d", lastSeenHeight, header.Number.Int64(), len(inTurnHV.Validators))
+				return 0, fmt.Errorf("heco Handler SyncBlockHeader, RecentlySigned, lastSeenHeight:%d currentHeight:%d #V:%d", lastSeenHeight, header.Number.Int64(), len(inTurnHV.Validators))
 			}
 		}
 
 		indexInTurn := int(header.Number.Uint64()) % len(inTurnHV.Validators)
 		if indexInTurn < 0 {
-			return fmt.Errorf("indexInTurn is negative:%d inTurnHV.Height:%d header.Number:%d", indexInTurn, inTurnHV.Height.Int64(), header.Number.Int64())
+			return 0, fmt.Errorf("indexInTurn is negative:%d inTurnHV.Height:%d header.Number:%d", indexInTurn, inTurnHV.Height.Int64(), header.Number.Int64())
 		}
 		valid := false
 		// fmt.Println("signer", signer)
@@ -289,27 +290,28 @@ func (h *Handler) SyncBlockHeader(native *native.NativeService) error {
 				valid = true
 				if indexInTurn == idx {
 					if header.Difficulty.Cmp(diffInTurn) != 0 {
-						return fmt.Errorf("invalid difficulty, got %v expect %v index:%v", header.Difficulty.Int64(), diffInTurn.Int64(), int(indexInTurn)%len(inTurnHV.Validators))
+						return 0, fmt.Errorf("invalid difficulty, got %v expect %v index:%v", header.Difficulty.Int64(), diffInTurn.Int64(), int(indexInTurn)%len(inTurnHV.Validators))
 					}
 				} else {
 					if header.Difficulty.Cmp(diffNoTurn) != 0 {
-						return fmt.Errorf("invalid difficulty, got %v expect %v index:%v", header.Difficulty.Int64(), diffNoTurn.Int64(), int(indexInTurn)%len(inTurnHV.Validators))
+						return 0, fmt.Errorf("invalid difficulty, got %v expect %v index:%v", header.Difficulty.Int64(), diffNoTurn.Int64(), int(indexInTurn)%len(inTurnHV.Validators))
 					}
 				}
 			}
 		}
 		if !valid {
-			return fmt.Errorf("heco Handler SyncBlockHeader, invalid signer")
+			return 0, fmt.Errorf("heco Handler SyncBlockHeader, invalid signer")
 		}
 
 		err = addHeader(native, &header, phv, ctx)
 		if err != nil {
-			return fmt.Errorf("heco Handler SyncBlockHeader, addHeader err: %v", err)
+			return 0, fmt.Errorf("heco Handler SyncBlockHeader, addHeader err: %v", err)
 		}
 
 		scom.NotifyPutHeader(native, headerParams.ChainID, header.Number.Uint64(), header.Hash().Hex())
+		stored++
 	}
-	return nil
+	return stored, nil
 }
 
 func isHeaderExist(native *native.NativeService, headerHash ecommon.Hash, ctx *Context) (bool, error) {
@@ -478,6 +480,19 @@ func addHeader(native *native.NativeService, header *eth.Header, phv *HeightAndV
 			cheight--
 		}
 
+		// Record the replaced canonical headers, cheight is the fork height
+		err = scom.RecordReorg(native, &scom.ReorgInfo{
+			ChainID:    ctx.ChainID,
+			OldTip:     cheader.Header.Hash().Bytes(),
+			OldHeight:  cheader.Header.Number.Uint64(),
+			NewTip:     header.Hash().Bytes(),
+			NewHeight:  header.Number.Uint64(),
+			ForkHeight: cheight,
+		})
+		if err != nil {
+			return
+		}
+
 		// Extend the canonical chain with the new header
 		putCanonicalHash(native, ctx.ChainID, header.Number.Uint64(), header.Hash())
 		putCanonicalHeight(native, ctx.ChainID, header.Number.Uint64())
@@ -845,6 +860,30 @@ func ParseValidators(validatorsBytes []byte) ([]ecommon.Address, error) {
 }
 
 // SyncCrossChainMsg ...
-func (h *Handler) SyncCrossChainMsg(native *native.NativeService) error {
-	return nil
+func (h *Handler) SyncCrossChainMsg(native *native.NativeService) (uint64, error) {
+	return 0, nil
+}
+
+// GetHeaderRange ...
+func (h *Handler) GetHeaderRange(native *native.NativeService, chainID uint64) (genesisHeight, currentHeight uint64, err error) {
+	genesis, err := getGenesis(native, chainID)
+	if err != nil {
+		err = fmt.Errorf("heco Handler GetHeaderRange, getGenesis error: %v", err)
+		return
+	}
+	if genesis == nil {
+		err = fmt.Errorf("heco Handler GetHeaderRange, genesis not set")
+		return
+	}
+	currentHeight, err = GetCanonicalHeight(native, chainID)
+	if err != nil {
+		return
+	}
+	genesisHeight = genesis.Header.Number.Uint64()
+	return
+}
+
+// PruneHeader ...
+func (h *Handler) PruneHeader(native *native.NativeService, chainID uint64, height uint64) error {
+	return scom.PruneMainChainHeader(native, chainID, height)
 }
@@ -486,7 +486,7 @@ func TestSyncBlockHeader(t *testing.T) {
 		native, _ = NewNative(sink.Bytes(), tx, native.GetCacheDB())
 
 		// fmt.Println("gHeight", height)
-		err := handler.SyncBlockHeader(native)
+		_, err := handler.SyncBlockHeader(native)
 		assert.Equal(t, SUCCESS, typeOfError(err), err)
 		latestHeight := getLatestHeight(native)
 		assert.Equal(t, latestHeight, height+4)
@@ -571,7 +571,7 @@ func TestSyncBlockHeaderForked(t *testing.T) {
 		native, _ = NewNative(sink.Bytes(), tx, native.GetCacheDB())
 
 		// fmt.Println("gHeight", height)
-		err := handler.SyncBlockHeader(native)
+		_, err := handler.SyncBlockHeader(native)
 		assert.Equal(t, SUCCESS, typeOfError(err), err)
 		latestHeight := getLatestHeight(native)
 		assert.Equal(t, latestHeight, height+9)
@@ -602,7 +602,7 @@ func TestSyncBlockHeaderForked(t *testing.T) {
 		}
 		native, _ = NewNative(sink.Bytes(), tx, native.GetCacheDB())
 
-		err = handler.SyncBlockHeader(native)
+		_, err = handler.SyncBlockHeader(native)
 		assert.Equal(t, SUCCESS, typeOfError(err), err)
 		latestHeight := getLatestHeight(native)
 		assert.Equal(t, latestHeight, interestedHeight)
@@ -630,7 +630,7 @@ func TestSyncBlockHeaderForked(t *testing.T) {
 		}
 		native, _ = NewNative(sink.Bytes(), tx, native.GetCacheDB())
 
-		err = handler.SyncBlockHeader(native)
+		_, err = handler.SyncBlockHeader(native)
 		assert.Equal(t, SUCCESS, typeOfError(err), err)
 		latestHeight = getLatestHeight(native)
 		assert.Equal(t, latestHeight, interestedHeight)
@@ -657,7 +657,7 @@ func TestSyncBlockHeaderForked(t *testing.T) {
 		}
 		native, _ = NewNative(sink.Bytes(), tx, native.GetCacheDB())
 
-		err = handler.SyncBlockHeader(native)
+		_, err = handler.SyncBlockHeader(native)
 		assert.Equal(t, SUCCESS, typeOfError(err), err)
 		latestHeight = getLatestHeight(native)
 		assert.Equal(t, latestHeight, interestedHeight+1)
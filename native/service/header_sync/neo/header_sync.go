@@ -67,40 +67,42 @@ func (this *NEOHandler) SyncGenesisHeader(native *native.NativeService) error {
 	return nil
 }
 
-func (this *NEOHandler) SyncBlockHeader(native *native.NativeService) error {
+func (this *NEOHandler) SyncBlockHeader(native *native.NativeService) (uint64, error) {
 	params := new(hscommon.SyncBlockHeaderParam)
 	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
-		return fmt.Errorf("SyncBlockHeader, contract params deserialize error: %v", err)
+		return 0, fmt.Errorf("SyncBlockHeader, contract params deserialize error: %v", err)
 	}
 	neoConsensus, err := getConsensusValByChainId(native, params.ChainID)
 	if err != nil {
-		return fmt.Errorf("SyncBlockHeader, the consensus validator has not been initialized, chainId: %d", params.ChainID)
+		return 0, fmt.Errorf("SyncBlockHeader, the consensus validator has not been initialized, chainId: %d", params.ChainID)
 	}
 	var newNeoConsensus *NeoConsensus
+	var stored uint64
 	for _, v := range params.Headers {
 		header := new(NeoBlockHeader)
 		if err := header.Deserialization(common.NewZeroCopySource(v)); err != nil {
-			return fmt.Errorf("SyncBlockHeader, NeoBlockHeaderFromBytes error: %v", err)
+			return 0, fmt.Errorf("SyncBlockHeader, NeoBlockHeaderFromBytes error: %v", err)
 		}
 		if !header.NextConsensus.Equals(neoConsensus.NextConsensus) && header.Index > neoConsensus.Height {
 			if err = verifyHeader(native, params.ChainID, header); err != nil {
-				return fmt.Errorf("SyncBlockHeader, verifyHeader error: %v", err)
+				return 0, fmt.Errorf("SyncBlockHeader, verifyHeader error: %v", err)
 			}
 			newNeoConsensus = &NeoConsensus{
 				ChainID:       neoConsensus.ChainID,
 				Height:        header.Index,
 				NextConsensus: header.NextConsensus,
 			}
+			stored++
 		}
 	}
 	if newNeoConsensus != nil {
 		if err = putConsensusValByChainId(native, newNeoConsensus); err != nil {
-			return fmt.Errorf("SyncBlockHeader, update ConsensusPeer error: %v", err)
+			return 0, fmt.Errorf("SyncBlockHeader, update ConsensusPeer error: %v", err)
 		}
 	}
-	return nil
+	return stored, nil
 }
 
-func (this *NEOHandler) SyncCrossChainMsg(native *native.NativeService) error {
-	return nil
+func (this *NEOHandler) SyncCrossChainMsg(native *native.NativeService) (uint64, error) {
+	return 0, nil
 }
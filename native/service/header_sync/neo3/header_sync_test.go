@@ -215,7 +215,7 @@ func TestSyncBlockHeader(t *testing.T) {
 		}
 
 		native = NewNative(sink.Bytes(), tx, native.GetCacheDB())
-		err := neoHandler.SyncBlockHeader(native)
+		_, err := neoHandler.SyncBlockHeader(native)
 		assert.NoError(t, err)
 	}
 }
@@ -70,44 +70,46 @@ func (this *Neo3Handler) SyncGenesisHeader(native *native.NativeService) error {
 	return nil
 }
 
-func (this *Neo3Handler) SyncBlockHeader(native *native.NativeService) error {
+func (this *Neo3Handler) SyncBlockHeader(native *native.NativeService) (uint64, error) {
 	params := new(hscommon.SyncBlockHeaderParam)
 	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
-		return fmt.Errorf("Neo3Handler SyncBlockHeader, contract params deserialize error: %v", err)
+		return 0, fmt.Errorf("Neo3Handler SyncBlockHeader, contract params deserialize error: %v", err)
 	}
 	neoConsensus, err := getConsensusValByChainId(native, params.ChainID)
 	if err != nil {
-		return fmt.Errorf("Neo3Handler SyncBlockHeader, the consensus validator has not been initialized, chainId: %d", params.ChainID)
+		return 0, fmt.Errorf("Neo3Handler SyncBlockHeader, the consensus validator has not been initialized, chainId: %d", params.ChainID)
 	}
 	sideChain, err := side_chain_manager.GetSideChain(native, params.ChainID)
 	if err != nil {
-		return fmt.Errorf("neo3 MakeDepositProposal, side_chain_manager.GetSideChain error: %v", err)
+		return 0, fmt.Errorf("neo3 MakeDepositProposal, side_chain_manager.GetSideChain error: %v", err)
 	}
 	var newNeoConsensus *NeoConsensus
+	var stored uint64
 	for _, v := range params.Headers {
 		header := new(NeoBlockHeader)
 		if err := header.Deserialization(common.NewZeroCopySource(v)); err != nil {
-			return fmt.Errorf("Neo3Handler SyncBlockHeader, NeoBlockHeaderFromBytes error: %v", err)
+			return 0, fmt.Errorf("Neo3Handler SyncBlockHeader, NeoBlockHeaderFromBytes error: %v", err)
 		}
 		if !header.GetNextConsensus().Equals(neoConsensus.NextConsensus) && header.GetIndex() > neoConsensus.Height {
 			if err = verifyHeader(native, params.ChainID, header, helper.BytesToUInt32(sideChain.ExtraInfo)); err != nil {
-				return fmt.Errorf("Neo3Handler SyncBlockHeader, verifyHeader error: %v", err)
+				return 0, fmt.Errorf("Neo3Handler SyncBlockHeader, verifyHeader error: %v", err)
 			}
 			newNeoConsensus = &NeoConsensus{
 				ChainID:       neoConsensus.ChainID,
 				Height:        header.GetIndex(),
 				NextConsensus: header.GetNextConsensus(),
 			}
+			stored++
 		}
 	}
 	if newNeoConsensus != nil {
 		if err = putConsensusValByChainId(native, newNeoConsensus); err != nil {
-			return fmt.Errorf("Neo3Handler SyncBlockHeader, update ConsensusPeer error: %v", err)
+			return 0, fmt.Errorf("Neo3Handler SyncBlockHeader, update ConsensusPeer error: %v", err)
 		}
 	}
-	return nil
+	return stored, nil
 }
 
-func (this *Neo3Handler) SyncCrossChainMsg(native *native.NativeService) error {
-	return nil
+func (this *Neo3Handler) SyncCrossChainMsg(native *native.NativeService) (uint64, error) {
+	return 0, nil
 }
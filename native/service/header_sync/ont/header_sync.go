@@ -73,15 +73,16 @@ func (this *ONTHandler) SyncGenesisHeader(native *native.NativeService) error {
 	return nil
 }
 
-func (this *ONTHandler) SyncBlockHeader(native *native.NativeService) error {
+func (this *ONTHandler) SyncBlockHeader(native *native.NativeService) (uint64, error) {
 	params := new(hscommon.SyncBlockHeaderParam)
 	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
-		return fmt.Errorf("SyncBlockHeader, contract params deserialize error: %v", err)
+		return 0, fmt.Errorf("SyncBlockHeader, contract params deserialize error: %v", err)
 	}
+	var stored uint64
 	for _, v := range params.Headers {
 		header, err := otypes.HeaderFromRawBytes(v)
 		if err != nil {
-			return fmt.Errorf("SyncBlockHeader, otypes.HeaderFromRawBytes error: %v", err)
+			return 0, fmt.Errorf("SyncBlockHeader, otypes.HeaderFromRawBytes error: %v", err)
 		}
 		_, err = GetHeaderByHeight(native, params.ChainID, header.Height)
 		if err == nil {
@@ -89,45 +90,47 @@ func (this *ONTHandler) SyncBlockHeader(native *native.NativeService) error {
 		}
 		err = verifyHeader(native, params.ChainID, header)
 		if err != nil {
-			return fmt.Errorf("SyncBlockHeader, verifyHeader error: %v", err)
+			return 0, fmt.Errorf("SyncBlockHeader, verifyHeader error: %v", err)
 		}
 		err = PutBlockHeader(native, params.ChainID, header)
 		if err != nil {
-			return fmt.Errorf("SyncBlockHeader, put BlockHeader error: %v", err)
+			return 0, fmt.Errorf("SyncBlockHeader, put BlockHeader error: %v", err)
 		}
 		err = UpdateConsensusPeer(native, params.ChainID, header)
 		if err != nil {
-			return fmt.Errorf("SyncBlockHeader, update ConsensusPeer error: %v", err)
+			return 0, fmt.Errorf("SyncBlockHeader, update ConsensusPeer error: %v", err)
 		}
+		stored++
 	}
-	return nil
+	return stored, nil
 }
 
-func (this *ONTHandler) SyncCrossChainMsg(native *native.NativeService) error {
+func (this *ONTHandler) SyncCrossChainMsg(native *native.NativeService) (uint64, error) {
 	params := new(hscommon.SyncCrossChainMsgParam)
 	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
-		return fmt.Errorf("SyncCrossChainMsg, contract params deserialize error: %v", err)
+		return 0, fmt.Errorf("SyncCrossChainMsg, contract params deserialize error: %v", err)
 	}
+	var stored uint64
 	for _, v := range params.CrossChainMsgs {
 		source := ocommon.NewZeroCopySource(v)
 		crossChainMsg := new(otypes.CrossChainMsg)
 		err := crossChainMsg.Deserialization(source)
 		if err != nil {
-			return fmt.Errorf("SyncCrossChainMsg, deserialize crossChainMsg error: %v", err)
+			return 0, fmt.Errorf("SyncCrossChainMsg, deserialize crossChainMsg error: %v", err)
 		}
 		n, _, irr, eof := source.NextVarUint()
 		if irr || eof {
-			return fmt.Errorf("SyncCrossChainMsg, deserialization bookkeeper length error")
+			return 0, fmt.Errorf("SyncCrossChainMsg, deserialization bookkeeper length error")
 		}
 		var bookkeepers []keypair.PublicKey
 		for i := 0; uint64(i) < n; i++ {
 			v, _, irr, eof := source.NextVarBytes()
 			if irr || eof {
-				return fmt.Errorf("SyncCrossChainMsg, deserialization bookkeeper error")
+				return 0, fmt.Errorf("SyncCrossChainMsg, deserialization bookkeeper error")
 			}
 			bookkeeper, err := keypair.DeserializePublicKey(v)
 			if err != nil {
-				return fmt.Errorf("SyncCrossChainMsg, keypair.DeserializePublicKey error: %v", err)
+				return 0, fmt.Errorf("SyncCrossChainMsg, keypair.DeserializePublicKey error: %v", err)
 			}
 			bookkeepers = append(bookkeepers, bookkeeper)
 		}
@@ -137,12 +140,13 @@ func (this *ONTHandler) SyncCrossChainMsg(native *native.NativeService) error {
 		}
 		err = VerifyCrossChainMsg(native, params.ChainID, crossChainMsg, bookkeepers)
 		if err != nil {
-			return fmt.Errorf("SyncCrossChainMsg, VerifyCrossChainMsg error: %v", err)
+			return 0, fmt.Errorf("SyncCrossChainMsg, VerifyCrossChainMsg error: %v", err)
 		}
 		err = PutCrossChainMsg(native, params.ChainID, crossChainMsg)
 		if err != nil {
-			return fmt.Errorf("SyncCrossChainMsg, put PutCrossChainMsg error: %v", err)
+			return 0, fmt.Errorf("SyncCrossChainMsg, put PutCrossChainMsg error: %v", err)
 		}
+		stored++
 	}
-	return nil
+	return stored, nil
 }
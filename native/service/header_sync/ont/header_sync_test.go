@@ -119,7 +119,7 @@ func TestSyncBlockHeader(t *testing.T) {
 	}
 
 	native = NewNative(sink.Bytes(), tx, native.GetCacheDB())
-	err := ontHandler.SyncBlockHeader(native)
+	_, err := ontHandler.SyncBlockHeader(native)
 	assert.NoError(t, err)
 }
 
@@ -158,7 +158,7 @@ func TestSyncBlockHeaderTwice(t *testing.T) {
 		}
 
 		native = NewNative(sink.Bytes(), tx, native.GetCacheDB())
-		err := ontHandler.SyncBlockHeader(native)
+		_, err := ontHandler.SyncBlockHeader(native)
 		assert.NoError(t, err)
 	}
 	{
@@ -178,7 +178,7 @@ func TestSyncBlockHeaderTwice(t *testing.T) {
 		}
 
 		native = NewNative(sink.Bytes(), tx, native.GetCacheDB())
-		err := ontHandler.SyncBlockHeader(native)
+		_, err := ontHandler.SyncBlockHeader(native)
 		assert.Nil(t, err)
 	}
 }
@@ -0,0 +1,429 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package poa syncs the headers of Clique and Parlia style proof of authority
+// chains, the engine parameters are read from SideChain.ExtraInfo.
+package poa
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/common/log"
+	cstates "github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/governance/node_manager"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	scom "github.com/polynetwork/poly/native/service/header_sync/common"
+	"github.com/polynetwork/poly/native/service/header_sync/eth"
+	"github.com/polynetwork/poly/native/service/utils"
+)
+
+// Handler ...
+type Handler struct {
+}
+
+// NewHandler ...
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// SyncGenesisHeader ...
+func (h *Handler) SyncGenesisHeader(native *native.NativeService) error {
+	params := new(scom.SyncGenesisHeaderParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return fmt.Errorf("poa Handler SyncGenesisHeader, contract params deserialize error: %v", err)
+	}
+	// Get current epoch operator
+	operatorAddress, err := node_manager.GetCurConOperator(native)
+	if err != nil {
+		return fmt.Errorf("poa Handler SyncGenesisHeader, get current consensus operator address error: %v", err)
+	}
+	//check witness
+	err = utils.ValidateOwner(native, operatorAddress)
+	if err != nil {
+		return fmt.Errorf("poa Handler SyncGenesisHeader, checkWitness error: %v", err)
+	}
+
+	// can only store once
+	height, err := GetCanonicalHeight(native, params.ChainID)
+	if err != nil {
+		return fmt.Errorf("poa Handler SyncGenesisHeader, GetCanonicalHeight error: %v", err)
+	}
+	if height != nil {
+		return fmt.Errorf("poa Handler SyncGenesisHeader, genesis had been initialized")
+	}
+
+	extraInfo, err := GetExtraInfo(native, params.ChainID)
+	if err != nil {
+		return fmt.Errorf("poa Handler SyncGenesisHeader, %v", err)
+	}
+
+	var genesis GenesisHeader
+	if err := json.Unmarshal(params.GenesisHeader, &genesis); err != nil {
+		return fmt.Errorf("poa Handler SyncGenesisHeader, deserialize GenesisHeader err: %v", err)
+	}
+	header := &genesis.Header
+	if header.Number.Uint64()%extraInfo.Epoch != 0 {
+		return fmt.Errorf("poa Handler SyncGenesisHeader, genesis height %d is not a checkpoint", header.Number.Uint64())
+	}
+	validators, turnLength, err := parseValidators(header, extraInfo)
+	if err != nil {
+		return fmt.Errorf("poa Handler SyncGenesisHeader, %v", err)
+	}
+	if extraInfo.DelayedSwitch && len(genesis.PrevValidators) == 0 {
+		return fmt.Errorf("poa Handler SyncGenesisHeader, previous validators are needed by delayed switch")
+	}
+	prevTurnLength := genesis.PrevTurnLength
+	if prevTurnLength == 0 {
+		prevTurnLength = 1
+	}
+
+	hash := header.Hash()
+	if err := putSnapshot(native, params.ChainID, hash, &Snapshot{
+		Number:         header.Number.Uint64(),
+		Validators:     validators,
+		TurnLength:     turnLength,
+		PrevValidators: genesis.PrevValidators,
+		PrevTurnLength: prevTurnLength,
+	}); err != nil {
+		return fmt.Errorf("poa Handler SyncGenesisHeader, putSnapshot error: %v", err)
+	}
+	if err := putHeader(native, params.ChainID, &HeaderWithSum{Header: header, DifficultySum: header.Difficulty, Checkpoint: hash}); err != nil {
+		return fmt.Errorf("poa Handler SyncGenesisHeader, putHeader error: %v", err)
+	}
+	putCanonicalHeight(native, params.ChainID, header.Number.Uint64())
+	putCanonicalHash(native, params.ChainID, header.Number.Uint64(), hash)
+
+	scom.NotifyPutHeader(native, params.ChainID, header.Number.Uint64(), hash.Hex())
+	return nil
+}
+
+// SyncBlockHeader ...
+func (h *Handler) SyncBlockHeader(native *native.NativeService) (uint64, error) {
+	headerParams := new(scom.SyncBlockHeaderParam)
+	if err := headerParams.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return 0, fmt.Errorf("poa Handler SyncBlockHeader, contract params deserialize error: %v", err)
+	}
+	extraInfo, err := GetExtraInfo(native, headerParams.ChainID)
+	if err != nil {
+		return 0, fmt.Errorf("poa Handler SyncBlockHeader, %v", err)
+	}
+
+	var stored uint64
+	for _, v := range headerParams.Headers {
+		var header eth.Header
+		if err := json.Unmarshal(v, &header); err != nil {
+			return 0, fmt.Errorf("poa Handler SyncBlockHeader, deserialize header err: %v", err)
+		}
+		hash := header.Hash()
+		exist, err := getHeader(native, headerParams.ChainID, hash)
+		if err != nil {
+			return 0, fmt.Errorf("poa Handler SyncBlockHeader, getHeader error: %v", err)
+		}
+		if exist != nil {
+			log.Warnf("poa Handler SyncBlockHeader, header has exist. Header: %s", string(v))
+			continue
+		}
+		parent, err := getHeader(native, headerParams.ChainID, header.ParentHash)
+		if err != nil {
+			return 0, fmt.Errorf("poa Handler SyncBlockHeader, getHeader error: %v", err)
+		}
+		if parent == nil {
+			log.Warnf("poa Handler SyncBlockHeader, parent header not exist. Header: %s", string(v))
+			continue
+		}
+
+		record, err := verifyHeader(native, headerParams.ChainID, &header, parent, extraInfo)
+		if err != nil {
+			return 0, fmt.Errorf("poa Handler SyncBlockHeader, verifyHeader err: %v", err)
+		}
+		if err := addHeader(native, headerParams.ChainID, record); err != nil {
+			return 0, fmt.Errorf("poa Handler SyncBlockHeader, addHeader err: %v", err)
+		}
+		scom.NotifyPutHeader(native, headerParams.ChainID, header.Number.Uint64(), hash.Hex())
+		stored++
+	}
+	return stored, nil
+}
+
+// SyncCrossChainMsg ...
+func (h *Handler) SyncCrossChainMsg(native *native.NativeService) (uint64, error) {
+	return 0, nil
+}
+
+// verifyHeader checks header against its parent and returns the record to store
+func verifyHeader(native *native.NativeService, chainID uint64, header *eth.Header, parent *HeaderWithSum, extraInfo *ExtraInfo) (*HeaderWithSum, error) {
+	if err := checkBasic(header, parent.Header, extraInfo); err != nil {
+		return nil, err
+	}
+	number := header.Number.Uint64()
+	snapshot, err := getSnapshot(native, chainID, parent.Checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	validators, turnLength := snapshot.validatorsAt(number, extraInfo.DelayedSwitch)
+
+	signer, err := recoverSigner(header, extraInfo)
+	if err != nil {
+		return nil, err
+	}
+	if extraInfo.SignerRecovery == RECOVERY_PARLIA && signer != header.Coinbase {
+		return nil, fmt.Errorf("coinbase do not match with signature")
+	}
+	index := -1
+	for i, v := range validators {
+		if v == signer {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("unauthorized signer %s", signer.Hex())
+	}
+	if recentlySigned(parent.Recents, signer, len(validators), turnLength) {
+		return nil, fmt.Errorf("signer %s signed recently", signer.Hex())
+	}
+	inTurn := validators[(number/turnLength)%uint64(len(validators))] == signer
+	if inTurn && header.Difficulty.Cmp(diffInTurn) != 0 || !inTurn && header.Difficulty.Cmp(diffNoTurn) != 0 {
+		return nil, fmt.Errorf("invalid difficulty %d, in turn: %v", header.Difficulty, inTurn)
+	}
+
+	hash := header.Hash()
+	record := &HeaderWithSum{
+		Header:        header,
+		DifficultySum: new(big.Int).Add(parent.DifficultySum, header.Difficulty),
+		Checkpoint:    parent.Checkpoint,
+	}
+	window := (len(validators)/2+1)*int(turnLength) - 1
+	record.Recents = append(append([]ecommon.Address{}, parent.Recents...), signer)
+	if len(record.Recents) > window {
+		record.Recents = record.Recents[len(record.Recents)-window:]
+	}
+
+	if number%extraInfo.Epoch == 0 {
+		next, nextTurnLength, err := parseValidators(header, extraInfo)
+		if err != nil {
+			return nil, err
+		}
+		if err := putSnapshot(native, chainID, hash, &Snapshot{
+			Number:         number,
+			Validators:     next,
+			TurnLength:     nextTurnLength,
+			PrevValidators: snapshot.Validators,
+			PrevTurnLength: snapshot.TurnLength,
+		}); err != nil {
+			return nil, err
+		}
+		record.Checkpoint = hash
+	}
+	return record, nil
+}
+
+// addHeader stores record and moves the canonical chain to it if its total difficulty is higher
+func addHeader(native *native.NativeService, chainID uint64, record *HeaderWithSum) error {
+	if err := putHeader(native, chainID, record); err != nil {
+		return err
+	}
+	height, err := GetCanonicalHeight(native, chainID)
+	if err != nil {
+		return err
+	}
+	current, err := GetCanonicalHeader(native, chainID, *height)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return fmt.Errorf("canonical header at %d not found", *height)
+	}
+	if record.DifficultySum.Cmp(current.DifficultySum) <= 0 {
+		return nil
+	}
+
+	number := record.Header.Number.Uint64()
+	// delete any canonical number assignments above the new head
+	for i := number + 1; i <= *height; i++ {
+		deleteCanonicalHash(native, chainID, i)
+	}
+	// overwrite any stale canonical number assignments
+	headHash := record.Header.ParentHash
+	for i := number - 1; ; i-- {
+		hash, err := getCanonicalHash(native, chainID, i)
+		if err != nil {
+			return err
+		}
+		if hash == headHash {
+			break
+		}
+		putCanonicalHash(native, chainID, i, headHash)
+		head, err := getHeader(native, chainID, headHash)
+		if err != nil {
+			return err
+		}
+		if head == nil {
+			return fmt.Errorf("header %s not found", headHash.Hex())
+		}
+		headHash = head.Header.ParentHash
+	}
+	putCanonicalHash(native, chainID, number, record.Header.Hash())
+	putCanonicalHeight(native, chainID, number)
+	return nil
+}
+
+// GetExtraInfo returns the validated engine parameters of chainID
+func GetExtraInfo(native *native.NativeService, chainID uint64) (*ExtraInfo, error) {
+	side, err := side_chain_manager.GetSideChain(native, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("GetSideChain error: %v", err)
+	}
+	if side == nil {
+		return nil, fmt.Errorf("side chain %d is not registered", chainID)
+	}
+	extraInfo := new(ExtraInfo)
+	if err := json.Unmarshal(side.ExtraInfo, extraInfo); err != nil {
+		return nil, fmt.Errorf("ExtraInfo Unmarshal error: %v", err)
+	}
+	if err := extraInfo.validate(); err != nil {
+		return nil, fmt.Errorf("invalid ExtraInfo: %v", err)
+	}
+	return extraInfo, nil
+}
+
+// GetCanonicalHeight returns the height of the canonical head, nil before genesis
+func GetCanonicalHeight(native *native.NativeService, chainID uint64) (*uint64, error) {
+	store, err := native.GetCacheDB().Get(
+		utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.CURRENT_HEADER_HEIGHT), utils.GetUint64Bytes(chainID)))
+	if err != nil {
+		return nil, fmt.Errorf("GetCanonicalHeight, GetCacheDB err:%v", err)
+	}
+	if store == nil {
+		return nil, nil
+	}
+	raw, err := cstates.GetValueFromRawStorageItem(store)
+	if err != nil {
+		return nil, fmt.Errorf("GetCanonicalHeight, GetValueFromRawStorageItem err:%v", err)
+	}
+	height := utils.GetBytesUint64(raw)
+	return &height, nil
+}
+
+// GetCanonicalHeader returns the canonical header at height, nil if there is none
+func GetCanonicalHeader(native *native.NativeService, chainID uint64, height uint64) (*HeaderWithSum, error) {
+	hash, err := getCanonicalHash(native, chainID, height)
+	if err != nil {
+		return nil, err
+	}
+	if hash == (ecommon.Hash{}) {
+		return nil, nil
+	}
+	return getHeader(native, chainID, hash)
+}
+
+func putCanonicalHeight(native *native.NativeService, chainID uint64, height uint64) {
+	native.GetCacheDB().Put(
+		utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.CURRENT_HEADER_HEIGHT), utils.GetUint64Bytes(chainID)),
+		cstates.GenRawStorageItem(utils.GetUint64Bytes(height)))
+}
+
+func getCanonicalHash(native *native.NativeService, chainID uint64, height uint64) (ecommon.Hash, error) {
+	store, err := native.GetCacheDB().Get(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.MAIN_CHAIN),
+		utils.GetUint64Bytes(chainID), utils.GetUint64Bytes(height)))
+	if err != nil {
+		return ecommon.Hash{}, fmt.Errorf("getCanonicalHash, GetCacheDB err:%v", err)
+	}
+	if store == nil {
+		return ecommon.Hash{}, nil
+	}
+	raw, err := cstates.GetValueFromRawStorageItem(store)
+	if err != nil {
+		return ecommon.Hash{}, fmt.Errorf("getCanonicalHash, GetValueFromRawStorageItem err:%v", err)
+	}
+	return ecommon.BytesToHash(raw), nil
+}
+
+func putCanonicalHash(native *native.NativeService, chainID uint64, height uint64, hash ecommon.Hash) {
+	native.GetCacheDB().Put(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.MAIN_CHAIN),
+		utils.GetUint64Bytes(chainID), utils.GetUint64Bytes(height)), cstates.GenRawStorageItem(hash.Bytes()))
+}
+
+func deleteCanonicalHash(native *native.NativeService, chainID uint64, height uint64) {
+	native.GetCacheDB().Delete(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.MAIN_CHAIN),
+		utils.GetUint64Bytes(chainID), utils.GetUint64Bytes(height)))
+}
+
+func getHeader(native *native.NativeService, chainID uint64, hash ecommon.Hash) (*HeaderWithSum, error) {
+	store, err := native.GetCacheDB().Get(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.HEADER_INDEX),
+		utils.GetUint64Bytes(chainID), hash.Bytes()))
+	if err != nil {
+		return nil, fmt.Errorf("getHeader, GetCacheDB err:%v", err)
+	}
+	if store == nil {
+		return nil, nil
+	}
+	raw, err := cstates.GetValueFromRawStorageItem(store)
+	if err != nil {
+		return nil, fmt.Errorf("getHeader, GetValueFromRawStorageItem err:%v", err)
+	}
+	record := new(HeaderWithSum)
+	if err := json.Unmarshal(raw, record); err != nil {
+		return nil, fmt.Errorf("getHeader, json.Unmarshal err:%v", err)
+	}
+	return record, nil
+}
+
+func putHeader(native *native.NativeService, chainID uint64, record *HeaderWithSum) error {
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	native.GetCacheDB().Put(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.HEADER_INDEX),
+		utils.GetUint64Bytes(chainID), record.Header.Hash().Bytes()), cstates.GenRawStorageItem(raw))
+	return nil
+}
+
+func getSnapshot(native *native.NativeService, chainID uint64, checkpoint ecommon.Hash) (*Snapshot, error) {
+	store, err := native.GetCacheDB().Get(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.EPOCH_SWITCH),
+		utils.GetUint64Bytes(chainID), checkpoint.Bytes()))
+	if err != nil {
+		return nil, fmt.Errorf("getSnapshot, GetCacheDB err:%v", err)
+	}
+	if store == nil {
+		return nil, fmt.Errorf("getSnapshot, no snapshot of checkpoint %s", checkpoint.Hex())
+	}
+	raw, err := cstates.GetValueFromRawStorageItem(store)
+	if err != nil {
+		return nil, fmt.Errorf("getSnapshot, GetValueFromRawStorageItem err:%v", err)
+	}
+	snapshot := new(Snapshot)
+	if err := json.Unmarshal(raw, snapshot); err != nil {
+		return nil, fmt.Errorf("getSnapshot, json.Unmarshal err:%v", err)
+	}
+	return snapshot, nil
+}
+
+func putSnapshot(native *native.NativeService, chainID uint64, checkpoint ecommon.Hash, snapshot *Snapshot) error {
+	raw, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	native.GetCacheDB().Put(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.EPOCH_SWITCH),
+		utils.GetUint64Bytes(chainID), checkpoint.Bytes()), cstates.GenRawStorageItem(raw))
+	return nil
+}
@@ -0,0 +1,324 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package poa
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"math/big"
+	"sort"
+	"testing"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/polynetwork/poly/account"
+	"github.com/polynetwork/poly/common"
+	vconfig "github.com/polynetwork/poly/consensus/vbft/config"
+	"github.com/polynetwork/poly/core/genesis"
+	"github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/core/store/leveldbstore"
+	"github.com/polynetwork/poly/core/store/overlaydb"
+	"github.com/polynetwork/poly/core/types"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/governance/node_manager"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	scom "github.com/polynetwork/poly/native/service/header_sync/common"
+	"github.com/polynetwork/poly/native/service/header_sync/eth"
+	"github.com/polynetwork/poly/native/service/utils"
+	"github.com/polynetwork/poly/native/storage"
+	"gotest.tools/assert"
+)
+
+const testChainID = 101
+
+var acct = account.NewAccount("")
+
+func init() {
+	genesis.GenesisBookkeepers = []keypair.PublicKey{acct.PublicKey}
+}
+
+func newNative(args []byte, tx *types.Transaction, db *storage.CacheDB) *native.NativeService {
+	if db == nil {
+		store, _ := leveldbstore.NewMemLevelDBStore()
+		db = storage.NewCacheDB(overlaydb.NewOverlayDB(store))
+		sink := common.NewZeroCopySink(nil)
+		view := &node_manager.GovernanceView{TxHash: common.UINT256_EMPTY}
+		view.Serialization(sink)
+		db.Put(utils.ConcatKey(utils.NodeManagerContractAddress, []byte(node_manager.GOVERNANCE_VIEW)), states.GenRawStorageItem(sink.Bytes()))
+
+		peerPoolMap := &node_manager.PeerPoolMap{
+			PeerPoolMap: map[string]*node_manager.PeerPoolItem{
+				vconfig.PubkeyID(acct.PublicKey): {
+					Address:    acct.Address,
+					Status:     node_manager.ConsensusStatus,
+					PeerPubkey: vconfig.PubkeyID(acct.PublicKey),
+				},
+			},
+		}
+		sink.Reset()
+		peerPoolMap.Serialization(sink)
+		db.Put(utils.ConcatKey(utils.NodeManagerContractAddress, []byte(node_manager.PEER_POOL), utils.GetUint32Bytes(0)),
+			states.GenRawStorageItem(sink.Bytes()))
+	}
+	service, _ := native.NewNativeService(db, tx, 0, 0, common.Uint256{0}, 0, args, false)
+	return service
+}
+
+type testChain struct {
+	t         *testing.T
+	extraInfo *ExtraInfo
+	keys      map[ecommon.Address]*ecdsa.PrivateKey
+	db        *storage.CacheDB
+}
+
+func newTestChain(t *testing.T, extraInfo *ExtraInfo, n int) (*testChain, []ecommon.Address) {
+	chain := &testChain{t: t, extraInfo: extraInfo, keys: make(map[ecommon.Address]*ecdsa.PrivateKey)}
+	var validators []ecommon.Address
+	for i := 0; i < n; i++ {
+		validators = append(validators, chain.newKey())
+	}
+	sort.Slice(validators, func(i, j int) bool {
+		return validators[i].Hex() < validators[j].Hex()
+	})
+
+	service := newNative(nil, &types.Transaction{}, nil)
+	raw, _ := json.Marshal(extraInfo)
+	assert.NilError(t, side_chain_manager.PutSideChain(service, &side_chain_manager.SideChain{
+		ChainId:   testChainID,
+		Router:    utils.POA_ROUTER,
+		ExtraInfo: raw,
+	}))
+	chain.db = service.GetCacheDB()
+	return chain, validators
+}
+
+func (this *testChain) newKey() ecommon.Address {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	this.keys[addr] = key
+	return addr
+}
+
+// makeHeader seals a child of parent by signer, checkpoints list validators
+func (this *testChain) makeHeader(parent *eth.Header, signer ecommon.Address, difficulty int64, validators []ecommon.Address) *eth.Header {
+	header := &eth.Header{
+		ParentHash: parent.Hash(),
+		UncleHash:  uncleHash,
+		Number:     new(big.Int).Add(parent.Number, big.NewInt(1)),
+		Difficulty: big.NewInt(difficulty),
+		GasLimit:   8000000,
+		Time:       parent.Time + 3,
+		Extra:      make([]byte, extraVanity),
+	}
+	if this.extraInfo.SignerRecovery == RECOVERY_PARLIA {
+		header.Coinbase = signer
+	}
+	for _, v := range validators {
+		header.Extra = append(header.Extra, v.Bytes()...)
+	}
+	header.Extra = append(header.Extra, make([]byte, extraSeal)...)
+	if key, ok := this.keys[signer]; ok {
+		sig, err := crypto.Sign(SealHash(header, this.extraInfo).Bytes(), key)
+		assert.NilError(this.t, err)
+		copy(header.Extra[len(header.Extra)-extraSeal:], sig)
+	}
+	return header
+}
+
+func (this *testChain) sync(headers ...*eth.Header) error {
+	param := &scom.SyncBlockHeaderParam{ChainID: testChainID}
+	for _, header := range headers {
+		raw, _ := json.Marshal(header)
+		param.Headers = append(param.Headers, raw)
+	}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	_, err := NewHandler().SyncBlockHeader(newNative(sink.Bytes(), &types.Transaction{}, this.db))
+	return err
+}
+
+func (this *testChain) canonical() (uint64, ecommon.Hash) {
+	service := newNative(nil, &types.Transaction{}, this.db)
+	height, err := GetCanonicalHeight(service, testChainID)
+	assert.NilError(this.t, err)
+	header, err := GetCanonicalHeader(service, testChainID, *height)
+	assert.NilError(this.t, err)
+	return *height, header.Header.Hash()
+}
+
+func (this *testChain) genesis(header *eth.Header, prev []ecommon.Address) {
+	raw, _ := json.Marshal(&GenesisHeader{Header: *header, PrevValidators: prev})
+	param := &scom.SyncGenesisHeaderParam{ChainID: testChainID, GenesisHeader: raw}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	err := NewHandler().SyncGenesisHeader(newNative(sink.Bytes(), &types.Transaction{}, this.db))
+	assert.ErrorContains(this.t, err, "checkWitness error")
+	tx := &types.Transaction{SignedAddr: []common.Address{acct.Address}}
+	assert.NilError(this.t, NewHandler().SyncGenesisHeader(newNative(sink.Bytes(), tx, this.db)))
+	err = NewHandler().SyncGenesisHeader(newNative(sink.Bytes(), tx, this.db))
+	assert.ErrorContains(this.t, err, "genesis had been initialized")
+}
+
+func genesisHeader(number int64, validators []ecommon.Address) *eth.Header {
+	header := &eth.Header{
+		UncleHash:  uncleHash,
+		Number:     big.NewInt(number),
+		Difficulty: big.NewInt(2),
+		GasLimit:   8000000,
+		Time:       1600000000,
+		Extra:      make([]byte, extraVanity),
+	}
+	for _, v := range validators {
+		header.Extra = append(header.Extra, v.Bytes()...)
+	}
+	header.Extra = append(header.Extra, make([]byte, extraSeal)...)
+	return header
+}
+
+func TestParliaSync(t *testing.T) {
+	chain, validators := newTestChain(t, &ExtraInfo{
+		ChainID:           big.NewInt(56),
+		Epoch:             4,
+		Period:            3,
+		ValidatorEncoding: ENCODING_ADDRESS,
+		SignerRecovery:    RECOVERY_PARLIA,
+		DelayedSwitch:     true,
+	}, 3)
+	inTurn := func(number uint64) ecommon.Address {
+		return validators[number%uint64(len(validators))]
+	}
+	g := genesisHeader(4, validators)
+	chain.genesis(g, validators)
+
+	h5 := chain.makeHeader(g, inTurn(5), 2, nil)
+	h6 := chain.makeHeader(h5, inTurn(6), 2, nil)
+	assert.NilError(t, chain.sync(h5, h6))
+	height, hash := chain.canonical()
+	assert.Equal(t, uint64(6), height)
+	assert.Equal(t, h6.Hash(), hash)
+
+	// out of turn block must have difficulty 1
+	err := chain.sync(chain.makeHeader(h6, inTurn(8), 2, nil))
+	assert.ErrorContains(t, err, "invalid difficulty")
+	// signer of h6 can not sign the next block
+	err = chain.sync(chain.makeHeader(h6, inTurn(6), 1, nil))
+	assert.ErrorContains(t, err, "signed recently")
+	// unknown signer
+	err = chain.sync(chain.makeHeader(h6, chain.newKey(), 1, nil))
+	assert.ErrorContains(t, err, "unauthorized signer")
+	// coinbase must be the signer
+	bad := chain.makeHeader(h6, inTurn(7), 2, nil)
+	bad.Coinbase = inTurn(5)
+	err = chain.sync(bad)
+	assert.ErrorContains(t, err, "coinbase do not match")
+	// too early
+	bad = chain.makeHeader(h6, inTurn(7), 2, nil)
+	bad.Time = h6.Time + 1
+	err = chain.sync(bad)
+	assert.ErrorContains(t, err, "invalid timestamp")
+
+	// an out of turn fork has less difficulty and stays off the canonical chain
+	fork6 := chain.makeHeader(h5, inTurn(7), 1, nil)
+	assert.NilError(t, chain.sync(fork6))
+	_, hash = chain.canonical()
+	assert.Equal(t, h6.Hash(), hash)
+
+	// checkpoint 8 replaces the validator of turn 8
+	h7 := chain.makeHeader(h6, inTurn(7), 2, nil)
+	next := append([]ecommon.Address{}, validators...)
+	next[8%3] = chain.newKey()
+	h8 := chain.makeHeader(h7, inTurn(8), 2, next)
+	assert.NilError(t, chain.sync(h7, h8))
+
+	// the previous set still signs block 9, the delay is len(prev)/2 = 1 block
+	h9 := chain.makeHeader(h8, inTurn(9), 2, nil)
+	assert.NilError(t, chain.sync(h9))
+	// from block 10 on the new set signs, the turn of 11 is taken by the new validator
+	h10 := chain.makeHeader(h9, next[10%3], 2, nil)
+	assert.NilError(t, chain.sync(h10))
+	err = chain.sync(chain.makeHeader(h10, validators[11%3], 2, nil))
+	assert.ErrorContains(t, err, "unauthorized signer")
+	h11 := chain.makeHeader(h10, next[11%3], 2, nil)
+	assert.NilError(t, chain.sync(h11))
+	height, hash = chain.canonical()
+	assert.Equal(t, uint64(11), height)
+	assert.Equal(t, h11.Hash(), hash)
+}
+
+func TestCliqueSync(t *testing.T) {
+	chain, validators := newTestChain(t, &ExtraInfo{
+		Epoch:             8,
+		ValidatorEncoding: ENCODING_ADDRESS,
+		SignerRecovery:    RECOVERY_CLIQUE,
+		TurnLength:        2,
+	}, 4)
+	inTurn := func(number uint64) ecommon.Address {
+		return validators[number/2%uint64(len(validators))]
+	}
+	g := genesisHeader(0, validators)
+	chain.genesis(g, nil)
+
+	// each validator signs two blocks in a row
+	h1 := chain.makeHeader(g, inTurn(1), 2, nil)
+	h2 := chain.makeHeader(h1, inTurn(2), 2, nil)
+	h3 := chain.makeHeader(h2, inTurn(3), 2, nil)
+	assert.NilError(t, chain.sync(h1, h2, h3))
+	// inTurn(2) signed blocks 2 and 3 within the window of (4/2+1)*2-1 = 5 blocks
+	err := chain.sync(chain.makeHeader(h3, inTurn(2), 1, nil))
+	assert.ErrorContains(t, err, "signed recently")
+	// an out of turn block must have difficulty 1
+	err = chain.sync(chain.makeHeader(h3, inTurn(6), 2, nil))
+	assert.ErrorContains(t, err, "invalid difficulty")
+	// signers are only listed at checkpoints
+	err = chain.sync(chain.makeHeader(h3, inTurn(4), 2, validators))
+	assert.ErrorContains(t, err, "contains signers")
+	h4 := chain.makeHeader(h3, inTurn(4), 2, nil)
+	assert.NilError(t, chain.sync(h4))
+	height, hash := chain.canonical()
+	assert.Equal(t, uint64(4), height)
+	assert.Equal(t, h4.Hash(), hash)
+}
+
+func TestParseValidators(t *testing.T) {
+	a, b := ecommon.HexToAddress("0x01"), ecommon.HexToAddress("0x02")
+	extraInfo := &ExtraInfo{ValidatorEncoding: ENCODING_PARLIA_LUBAN, TurnLengthInExtra: true}
+	extra := append(make([]byte, extraVanity), 2)
+	for _, v := range []ecommon.Address{a, b} {
+		extra = append(extra, v.Bytes()...)
+		extra = append(extra, make([]byte, blsPublicKeyLength)...)
+	}
+	// turn length, then a vote attestation
+	extra = append(extra, 4, 0xf8, 0x01)
+	extra = append(extra, make([]byte, extraSeal)...)
+	validators, turnLength, err := parseValidators(&eth.Header{Extra: extra}, extraInfo)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []ecommon.Address{a, b}, validators)
+	assert.Equal(t, uint64(4), turnLength)
+
+	extraInfo = &ExtraInfo{ValidatorEncoding: ENCODING_ADDRESS, TurnLengthInExtra: true}
+	extra = append(append(append(make([]byte, extraVanity), a.Bytes()...), 3), make([]byte, extraSeal)...)
+	validators, turnLength, err = parseValidators(&eth.Header{Extra: extra}, extraInfo)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, []ecommon.Address{a}, validators)
+	assert.Equal(t, uint64(3), turnLength)
+
+	extraInfo.TurnLengthInExtra = false
+	_, _, err = parseValidators(&eth.Header{Extra: extra}, extraInfo)
+	assert.ErrorContains(t, err, "invalid signer list")
+}
@@ -0,0 +1,112 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package poa
+
+import (
+	"fmt"
+	"math/big"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/polynetwork/poly/native/service/header_sync/eth"
+)
+
+// validator encodings of checkpoint extra data
+const (
+	// ENCODING_ADDRESS lists 20 byte addresses, used by clique and by parlia before luban
+	ENCODING_ADDRESS = "address"
+	// ENCODING_PARLIA_LUBAN is a count byte followed by address and 48 byte BLS key pairs
+	ENCODING_PARLIA_LUBAN = "parlia-luban"
+)
+
+// signer recoveries
+const (
+	// RECOVERY_CLIQUE hashes the header without chain id, coinbase is free for votes
+	RECOVERY_CLIQUE = "clique"
+	// RECOVERY_PARLIA prefixes the chain id to the sealed fields and requires coinbase to be the signer
+	RECOVERY_PARLIA = "parlia"
+)
+
+// ExtraInfo configures the PoA engine of a side chain, it is set with the side
+// chain registration so that new chains can be onboarded through governance.
+//
+// Clique votes are not tracked, the signer set only changes at checkpoints.
+type ExtraInfo struct {
+	ChainID           *big.Int // mixed into the seal hash by parlia
+	Epoch             uint64   // blocks between checkpoints carrying the validator set
+	Period            uint64   // minimum seconds between blocks, 0 skips the check
+	ValidatorEncoding string
+	SignerRecovery    string
+	TurnLength        uint64 // blocks signed in a row by a validator, 0 is read as 1
+	TurnLengthInExtra bool   // checkpoints carry the turn length after the validators
+	DelayedSwitch     bool   // a new set takes effect len(previous set)/2 blocks after its checkpoint
+}
+
+func (this *ExtraInfo) validate() error {
+	if this.Epoch == 0 {
+		return fmt.Errorf("invalid epoch")
+	}
+	switch this.ValidatorEncoding {
+	case ENCODING_ADDRESS, ENCODING_PARLIA_LUBAN:
+	default:
+		return fmt.Errorf("unknown validator encoding: %s", this.ValidatorEncoding)
+	}
+	switch this.SignerRecovery {
+	case RECOVERY_CLIQUE:
+	case RECOVERY_PARLIA:
+		if this.ChainID == nil {
+			return fmt.Errorf("parlia needs a chain id")
+		}
+	default:
+		return fmt.Errorf("unknown signer recovery: %s", this.SignerRecovery)
+	}
+	return nil
+}
+
+// GenesisHeader is a checkpoint header, PrevValidators is the set of the
+// checkpoint before it and is only needed with DelayedSwitch
+type GenesisHeader struct {
+	Header         eth.Header
+	PrevValidators []ecommon.Address
+	PrevTurnLength uint64
+}
+
+// Snapshot is the validator set announced at a checkpoint along with the set it replaces
+type Snapshot struct {
+	Number         uint64
+	Validators     []ecommon.Address
+	TurnLength     uint64
+	PrevValidators []ecommon.Address
+	PrevTurnLength uint64
+}
+
+// validatorsAt returns the validator set and turn length signing block number
+func (this *Snapshot) validatorsAt(number uint64, delayed bool) ([]ecommon.Address, uint64) {
+	if delayed && number-this.Number <= uint64(len(this.PrevValidators)/2) {
+		return this.PrevValidators, this.PrevTurnLength
+	}
+	return this.Validators, this.TurnLength
+}
+
+// HeaderWithSum is a stored header, Checkpoint is the hash of the latest
+// checkpoint at or below it and Recents are the signers of the latest blocks
+type HeaderWithSum struct {
+	Header        *eth.Header       `json:"header"`
+	DifficultySum *big.Int          `json:"difficultySum"`
+	Checkpoint    ecommon.Hash      `json:"checkpoint"`
+	Recents       []ecommon.Address `json:"recents"`
+}
@@ -0,0 +1,183 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package poa
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/polynetwork/poly/native/service/header_sync/eth"
+	"github.com/polynetwork/poly/native/service/header_sync/eth/rlp"
+	"golang.org/x/crypto/sha3"
+)
+
+var (
+	extraVanity = 32                       // Fixed number of extra-data prefix bytes reserved for signer vanity
+	extraSeal   = crypto.SignatureLength   // Fixed number of extra-data suffix bytes reserved for signer seal
+	uncleHash   = types.CalcUncleHash(nil) // Always Keccak256(RLP([])) as uncles are meaningless outside of PoW.
+	diffInTurn  = big.NewInt(2)            // Block difficulty for in-turn signatures
+	diffNoTurn  = big.NewInt(1)            // Block difficulty for out-of-turn signatures
+
+	blsPublicKeyLength = 48
+)
+
+// parseValidators reads the validator set and turn length from the extra data of a checkpoint
+func parseValidators(header *eth.Header, extraInfo *ExtraInfo) (validators []ecommon.Address, turnLength uint64, err error) {
+	if len(header.Extra) < extraVanity+extraSeal {
+		return nil, 0, errors.New("extra-data too short")
+	}
+	data := header.Extra[extraVanity : len(header.Extra)-extraSeal]
+	var rest []byte
+	switch extraInfo.ValidatorEncoding {
+	case ENCODING_ADDRESS:
+		list := data
+		if extraInfo.TurnLengthInExtra && len(data) > 0 {
+			list, rest = data[:len(data)-1], data[len(data)-1:]
+		}
+		if len(list) == 0 || len(list)%ecommon.AddressLength != 0 {
+			return nil, 0, errors.New("invalid signer list")
+		}
+		for i := 0; i < len(list)/ecommon.AddressLength; i++ {
+			validators = append(validators, ecommon.BytesToAddress(list[i*ecommon.AddressLength:(i+1)*ecommon.AddressLength]))
+		}
+	case ENCODING_PARLIA_LUBAN:
+		if len(data) == 0 {
+			return nil, 0, errors.New("invalid validator list")
+		}
+		n := int(data[0])
+		size := ecommon.AddressLength + blsPublicKeyLength
+		if n == 0 || len(data) < 1+n*size {
+			return nil, 0, errors.New("invalid validator list")
+		}
+		for i := 0; i < n; i++ {
+			validators = append(validators, ecommon.BytesToAddress(data[1+i*size:1+i*size+ecommon.AddressLength]))
+		}
+		// a vote attestation may follow, it is not part of the validator set
+		rest = data[1+n*size:]
+	}
+
+	turnLength = extraInfo.TurnLength
+	if extraInfo.TurnLengthInExtra {
+		if len(rest) == 0 || rest[0] == 0 {
+			return nil, 0, errors.New("missing turn length")
+		}
+		turnLength = uint64(rest[0])
+	}
+	if turnLength == 0 {
+		turnLength = 1
+	}
+	return validators, turnLength, nil
+}
+
+// recoverSigner extracts the signer address from a sealed header
+func recoverSigner(header *eth.Header, extraInfo *ExtraInfo) (ecommon.Address, error) {
+	if len(header.Extra) < extraSeal {
+		return ecommon.Address{}, errors.New("extra-data 65 byte signature suffix missing")
+	}
+	signature := header.Extra[len(header.Extra)-extraSeal:]
+	pubkey, err := crypto.Ecrecover(SealHash(header, extraInfo).Bytes(), signature)
+	if err != nil {
+		return ecommon.Address{}, err
+	}
+	var signer ecommon.Address
+	copy(signer[:], crypto.Keccak256(pubkey[1:])[12:])
+	return signer, nil
+}
+
+// SealHash returns the hash of a block prior to it being sealed.
+func SealHash(header *eth.Header, extraInfo *ExtraInfo) (hash ecommon.Hash) {
+	var fields []interface{}
+	if extraInfo.SignerRecovery == RECOVERY_PARLIA {
+		fields = append(fields, extraInfo.ChainID)
+	}
+	fields = append(fields,
+		header.ParentHash,
+		header.UncleHash,
+		header.Coinbase,
+		header.Root,
+		header.TxHash,
+		header.ReceiptHash,
+		header.Bloom,
+		header.Difficulty,
+		header.Number,
+		header.GasLimit,
+		header.GasUsed,
+		header.Time,
+		header.Extra[:len(header.Extra)-extraSeal],
+		header.MixDigest,
+		header.Nonce,
+	)
+	if header.BaseFee != nil {
+		fields = append(fields, header.BaseFee)
+	}
+	hasher := sha3.NewLegacyKeccak256()
+	if err := rlp.Encode(hasher, fields); err != nil {
+		panic("can't encode: " + err.Error())
+	}
+	hasher.Sum(hash[:0])
+	return hash
+}
+
+// recentlySigned reports whether signer has used up its turns in the history window
+// of recents, the window is (len(validators)/2+1)*turnLength-1 blocks
+func recentlySigned(recents []ecommon.Address, signer ecommon.Address, validators int, turnLength uint64) bool {
+	window := (validators/2+1)*int(turnLength) - 1
+	if window > len(recents) {
+		window = len(recents)
+	}
+	count := uint64(0)
+	for _, recent := range recents[len(recents)-window:] {
+		if recent == signer {
+			count++
+		}
+	}
+	return count >= turnLength
+}
+
+func checkBasic(header *eth.Header, parent *eth.Header, extraInfo *ExtraInfo) error {
+	number := header.Number.Uint64()
+	if number == 0 {
+		return errors.New("unknown block")
+	}
+	if parent.Number.Uint64() != number-1 {
+		return errors.New("unknown ancestor")
+	}
+	if len(header.Extra) < extraVanity+extraSeal {
+		return errors.New("extra-data vanity or signature missing")
+	}
+	if extraInfo.ValidatorEncoding == ENCODING_ADDRESS && number%extraInfo.Epoch != 0 && len(header.Extra) != extraVanity+extraSeal {
+		return errors.New("extra-data of non-checkpoint block contains signers")
+	}
+	if header.UncleHash != uncleHash {
+		return errors.New("non empty uncle hash")
+	}
+	if header.Difficulty == nil || (header.Difficulty.Cmp(diffInTurn) != 0 && header.Difficulty.Cmp(diffNoTurn) != 0) {
+		return errors.New("invalid difficulty")
+	}
+	if header.GasUsed > header.GasLimit {
+		return fmt.Errorf("invalid gasUsed: have %d, gasLimit %d", header.GasUsed, header.GasLimit)
+	}
+	if parent.Time+extraInfo.Period > header.Time {
+		return errors.New("invalid timestamp")
+	}
+	return nil
+}
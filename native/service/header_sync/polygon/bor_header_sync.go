@@ -207,35 +207,36 @@ type HeaderWithOptionalProof struct {
 }
 
 // SyncBlockHeader ...
-func (h *BorHandler) SyncBlockHeader(native *native.NativeService) error {
+func (h *BorHandler) SyncBlockHeader(native *native.NativeService) (uint64, error) {
 	headerParams := new(scom.SyncBlockHeaderParam)
 	if err := headerParams.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
-		return fmt.Errorf("bor Handler SyncBlockHeader, contract params deserialize error: %v", err)
+		return 0, fmt.Errorf("bor Handler SyncBlockHeader, contract params deserialize error: %v", err)
 	}
 
 	side, err := side_chain_manager.GetSideChain(native, headerParams.ChainID)
 	if err != nil {
-		return fmt.Errorf("bor Handler SyncBlockHeader, GetSideChain error: %v", err)
+		return 0, fmt.Errorf("bor Handler SyncBlockHeader, GetSideChain error: %v", err)
 	}
 	var extraInfo ExtraInfo
 	err = json.Unmarshal(side.ExtraInfo, &extraInfo)
 	if err != nil {
-		return fmt.Errorf("bor Handler SyncBlockHeader, ExtraInfo Unmarshal error: %v", err)
+		return 0, fmt.Errorf("bor Handler SyncBlockHeader, ExtraInfo Unmarshal error: %v", err)
 	}
 
 	ctx := &Context{ExtraInfo: extraInfo, ChainID: headerParams.ChainID, Cdc: polygonTypes.NewCDC()}
 
+	var stored uint64
 	for _, v := range headerParams.Headers {
 		var headerWOP HeaderWithOptionalProof
 		err := json.Unmarshal(v, &headerWOP)
 		if err != nil {
-			return fmt.Errorf("bor Handler SyncBlockHeader, deserialize header err: %v", err)
+			return 0, fmt.Errorf("bor Handler SyncBlockHeader, deserialize header err: %v", err)
 		}
 		headerHash := headerWOP.Header.Hash()
 
 		exist, err := isHeaderExist(native, headerHash, ctx)
 		if err != nil {
-			return fmt.Errorf("bor Handler SyncBlockHeader, isHeaderExist headerHash err: %v", err)
+			return 0, fmt.Errorf("bor Handler SyncBlockHeader, isHeaderExist headerHash err: %v", err)
 		}
 		if exist {
 			log.Warnf("bor Handler SyncBlockHeader, header has exist. Header: %s", string(v))
@@ -244,7 +245,7 @@ func (h *BorHandler) SyncBlockHeader(native *native.NativeService) error {
 
 		parentExist, err := isHeaderExist(native, headerWOP.Header.ParentHash, ctx)
 		if err != nil {
-			return fmt.Errorf("bor Handler SyncBlockHeader, isHeaderExist ParentHash err: %v", err)
+			return 0, fmt.Errorf("bor Handler SyncBlockHeader, isHeaderExist ParentHash err: %v", err)
 		}
 		if !parentExist {
 			log.Warnf("bor Handler SyncBlockHeader, parent header not exist. Header: %s", string(v))
@@ -254,17 +255,18 @@ func (h *BorHandler) SyncBlockHeader(native *native.NativeService) error {
 		var snap *Snapshot
 		snap, err = verifyHeader(native, &headerWOP, ctx)
 		if err != nil {
-			return fmt.Errorf("bor Handler SyncBlockHeader, verifyHeader err: %v", err)
+			return 0, fmt.Errorf("bor Handler SyncBlockHeader, verifyHeader err: %v", err)
 		}
 
 		err = addHeader(native, &headerWOP.Header, snap, ctx)
 		if err != nil {
-			return fmt.Errorf("bor Handler SyncBlockHeader, addHeader err: %v", err)
+			return 0, fmt.Errorf("bor Handler SyncBlockHeader, addHeader err: %v", err)
 		}
 
 		scom.NotifyPutHeader(native, headerParams.ChainID, headerWOP.Header.Number.Uint64(), headerWOP.Header.Hash().Hex())
+		stored++
 	}
-	return nil
+	return stored, nil
 }
 
 func isHeaderExist(native *native.NativeService, headerHash ecommon.Hash, ctx *Context) (bool, error) {
@@ -434,6 +436,19 @@ func addHeader(native *native.NativeService, header *types.Header, snap *Snapsho
 			cheight--
 		}
 
+		// Record the replaced canonical headers, cheight is the fork height
+		err = scom.RecordReorg(native, &scom.ReorgInfo{
+			ChainID:    ctx.ChainID,
+			OldTip:     cheader.HeaderWithOptionalSnap.Header.Hash().Bytes(),
+			OldHeight:  cheader.HeaderWithOptionalSnap.Header.Number.Uint64(),
+			NewTip:     header.Hash().Bytes(),
+			NewHeight:  header.Number.Uint64(),
+			ForkHeight: cheight,
+		})
+		if err != nil {
+			return
+		}
+
 		// Extend the canonical chain with the new header
 		putCanonicalHash(native, ctx.ChainID, header.Number.Uint64(), header.Hash())
 		putCanonicalHeight(native, ctx.ChainID, header.Number.Uint64())
@@ -867,6 +882,30 @@ func encodeSigHeader(w io.Writer, header *types.Header) {
 }
 
 // SyncCrossChainMsg ...
-func (h *BorHandler) SyncCrossChainMsg(native *native.NativeService) error {
-	return nil
+func (h *BorHandler) SyncCrossChainMsg(native *native.NativeService) (uint64, error) {
+	return 0, nil
+}
+
+// GetHeaderRange ...
+func (h *BorHandler) GetHeaderRange(native *native.NativeService, chainID uint64) (genesisHeight, currentHeight uint64, err error) {
+	genesis, err := getGenesis(native, chainID)
+	if err != nil {
+		err = fmt.Errorf("bor Handler GetHeaderRange, getGenesis error: %v", err)
+		return
+	}
+	if genesis == nil {
+		err = fmt.Errorf("bor Handler GetHeaderRange, genesis not set")
+		return
+	}
+	currentHeight, err = GetCanonicalHeight(native, chainID)
+	if err != nil {
+		return
+	}
+	genesisHeight = genesis.Header.Number.Uint64()
+	return
+}
+
+// PruneHeader ...
+func (h *BorHandler) PruneHeader(native *native.NativeService, chainID uint64, height uint64) error {
+	return scom.PruneMainChainHeader(native, chainID, height)
 }
@@ -143,7 +143,7 @@ func TestHeimdall(t *testing.T) {
 		if err != nil {
 			t.Fatal("NewNative fail", err)
 		}
-		err = handler.SyncBlockHeader(native)
+		_, err = handler.SyncBlockHeader(native)
 		if err != nil {
 			t.Fatal("SyncBlockHeader fail", err)
 		}
@@ -273,7 +273,7 @@ func TestBor(t *testing.T) {
 		if err != nil {
 			t.Fatal("NewNative fail", err)
 		}
-		err = borHandler.SyncBlockHeader(native)
+		_, err = borHandler.SyncBlockHeader(native)
 		if err != nil {
 			t.Fatal("SyncBlockHeader fail", err)
 		}
@@ -304,7 +304,7 @@ func TestBor(t *testing.T) {
 		if err != nil {
 			t.Fatal("NewNative fail", err)
 		}
-		err = borHandler.SyncBlockHeader(native)
+		_, err = borHandler.SyncBlockHeader(native)
 		if err != nil {
 			t.Fatal("SyncBlockHeader fail", err)
 		}
@@ -456,7 +456,7 @@ func TestBorSnap(t *testing.T) {
 			t.Fatal("NewNative fail", err)
 		}
 		skipVerifySpan = true
-		err = borHandler.SyncBlockHeader(native)
+		_, err = borHandler.SyncBlockHeader(native)
 		skipVerifySpan = false
 		if err != nil {
 			t.Fatal("SyncBlockHeader fail", err)
@@ -54,55 +54,81 @@ func (h *QuorumHandler) SyncGenesisHeader(ns *native.NativeService) error {
 	if err = json.Unmarshal(params.GenesisHeader, header); err != nil {
 		return fmt.Errorf("QuorumHandler SyncGenesisHeader, deserialize header err: %v", err)
 	}
-	extra, err := ExtractIstanbulExtra(header)
+	info, err := GetExtraInfo(ns, params.ChainID)
 	if err != nil {
-		return fmt.Errorf("QuorumHandler SyncGenesisHeader, failed to ExtractIstanbulExtra: %v", err)
+		return fmt.Errorf("QuorumHandler SyncGenesisHeader, %v", err)
+	}
+	var vs QuorumValSet
+	if info.Consensus == CONSENSUS_QBFT {
+		extra, err := ExtractQBFTExtra(header)
+		if err != nil {
+			return fmt.Errorf("QuorumHandler SyncGenesisHeader, failed to ExtractQBFTExtra: %v", err)
+		}
+		vs = extra.Validators
+	} else {
+		extra, err := ExtractIstanbulExtra(header)
+		if err != nil {
+			return fmt.Errorf("QuorumHandler SyncGenesisHeader, failed to ExtractIstanbulExtra: %v", err)
+		}
+		vs = extra.Validators
 	}
 
-	putValSet(ns, params.ChainID, header.Number.Uint64(), extra.Validators)
+	putValSet(ns, params.ChainID, header.Number.Uint64(), vs)
 	return nil
 }
 
-func (h *QuorumHandler) SyncBlockHeader(ns *native.NativeService) error {
+func (h *QuorumHandler) SyncBlockHeader(ns *native.NativeService) (uint64, error) {
 	params := new(common.SyncBlockHeaderParam)
 	err := params.Deserialization(pcom.NewZeroCopySource(ns.GetInput()))
 	if err != nil {
-		return fmt.Errorf("QuorumHandler SyncBlockHeader, contract params deserialize error: %v", err)
+		return 0, fmt.Errorf("QuorumHandler SyncBlockHeader, contract params deserialize error: %v", err)
 	}
 	if len(params.Headers) == 0 {
-		return errors.New("QuorumHandler SyncBlockHeader, none headers in input")
+		return 0, errors.New("QuorumHandler SyncBlockHeader, none headers in input")
 	}
 
 	currh, err := GetCurrentValHeight(ns, params.ChainID)
 	if err != nil {
-		return fmt.Errorf("QuorumHandler SyncBlockHeader, failed to get current validator height: %v", err)
+		return 0, fmt.Errorf("QuorumHandler SyncBlockHeader, failed to get current validator height: %v", err)
 	}
 	vs, err := GetValSet(ns, params.ChainID)
 	if err != nil {
-		return fmt.Errorf("QuorumHandler SyncBlockHeader, failed to get validators: %v", err)
+		return 0, fmt.Errorf("QuorumHandler SyncBlockHeader, failed to get validators: %v", err)
+	}
+	info, err := GetExtraInfo(ns, params.ChainID)
+	if err != nil {
+		return 0, fmt.Errorf("QuorumHandler SyncBlockHeader, %v", err)
+	}
+	if info.Consensus == CONSENSUS_QBFT {
+		var changes uint64
+		if currh, vs, changes, err = syncQBFTHeaders(params.Headers, currh, vs); err != nil {
+			return 0, fmt.Errorf("QuorumHandler SyncBlockHeader, %v", err)
+		}
+		putValSet(ns, params.ChainID, currh, vs)
+		return changes, nil
 	}
 	header := &types.Header{}
 	for i, v := range params.Headers {
 		if err := json.Unmarshal(v, header); err != nil {
-			return fmt.Errorf("QuorumHandler SyncBlockHeader, deserialize No.%d header err: %v", i, err)
+			return 0, fmt.Errorf("QuorumHandler SyncBlockHeader, deserialize No.%d header err: %v", i, err)
 		}
 		h := header.Number.Uint64()
 		if currh >= h {
-			return fmt.Errorf("QuorumHandler SyncBlockHeader, wrong height of No.%d header: (curr: %d, commit: %d)", i, currh, h)
+			return 0, fmt.Errorf("QuorumHandler SyncBlockHeader, wrong height of No.%d header: (curr: %d, commit: %d)", i, currh, h)
 		}
 
 		extra, err := VerifyQuorumHeader(vs, header, true)
 		if err != nil {
-			return fmt.Errorf("QuorumHandler SyncBlockHeader, failed to verify No.%d quorum header %s: %v", i, GetQuorumHeaderHash(header).String(), err)
+			return 0, fmt.Errorf("QuorumHandler SyncBlockHeader, failed to verify No.%d quorum header %s: %v", i, GetQuorumHeaderHash(header).String(), err)
 		}
 
 		currh, vs = h, extra.Validators
 	}
 
 	putValSet(ns, params.ChainID, currh, vs)
-	return nil
+	return uint64(len(params.Headers)), nil
 }
 
-func (h *QuorumHandler) SyncCrossChainMsg(ns *native.NativeService) error {
-	return nil
+func (h *QuorumHandler) SyncCrossChainMsg(ns *native.NativeService) (uint64, error) {
+	return 0, nil
 }
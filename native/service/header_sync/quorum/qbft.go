@@ -0,0 +1,244 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+package quorum
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+)
+
+// consensus variants of a quorum side chain
+const (
+	CONSENSUS_IBFT = "ibft"
+	CONSENSUS_QBFT = "qbft"
+)
+
+// vote types of a QBFT validator vote
+const (
+	QBFTAuthVote byte = 0xFF
+	QBFTDropVote byte = 0x00
+)
+
+// ExtraInfo selects the consensus of a quorum side chain, chains registered
+// without it run the legacy istanbul BFT
+type ExtraInfo struct {
+	Consensus string
+}
+
+// GetExtraInfo returns the consensus configuration of chainID
+func GetExtraInfo(ns *native.NativeService, chainID uint64) (*ExtraInfo, error) {
+	sideChain, err := side_chain_manager.GetSideChain(ns, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("GetExtraInfo, GetSideChain error: %v", err)
+	}
+	if sideChain == nil {
+		return nil, fmt.Errorf("GetExtraInfo, side chain %d is not registered", chainID)
+	}
+	info := &ExtraInfo{Consensus: CONSENSUS_IBFT}
+	if len(sideChain.ExtraInfo) == 0 {
+		return info, nil
+	}
+	if err := json.Unmarshal(sideChain.ExtraInfo, info); err != nil {
+		return nil, fmt.Errorf("GetExtraInfo, ExtraInfo Unmarshal error: %v", err)
+	}
+	switch info.Consensus {
+	case "":
+		info.Consensus = CONSENSUS_IBFT
+	case CONSENSUS_IBFT, CONSENSUS_QBFT:
+	default:
+		return nil, fmt.Errorf("GetExtraInfo, unknown consensus: %s", info.Consensus)
+	}
+	return info, nil
+}
+
+type ValidatorVote struct {
+	RecipientAddress common.Address
+	VoteType         byte
+}
+
+// QBFTExtra is the extra-data of a QBFT header, unlike istanbul the vanity is
+// part of the rlp list and there is no proposer seal
+type QBFTExtra struct {
+	VanityData    []byte
+	Validators    []common.Address
+	Vote          *ValidatorVote `rlp:"nil"`
+	Round         uint32
+	CommittedSeal [][]byte
+}
+
+// copy from quorum
+func ExtractQBFTExtra(h *types.Header) (*QBFTExtra, error) {
+	extra := new(QBFTExtra)
+	if err := rlp.DecodeBytes(h.Extra, extra); err != nil {
+		return nil, err
+	}
+	return extra, nil
+}
+
+// copy from quorum
+func QBFTFilteredHeaderWithRound(h *types.Header, round uint32) *types.Header {
+	newHeader := CopyHeader(h)
+	extra, err := ExtractQBFTExtra(newHeader)
+	if err != nil {
+		return nil
+	}
+	extra.CommittedSeal = [][]byte{}
+	extra.Round = round
+
+	payload, err := rlp.EncodeToBytes(extra)
+	if err != nil {
+		return nil
+	}
+	newHeader.Extra = payload
+	return newHeader
+}
+
+// GetQBFTHeaderHash returns the block hash, which is independent of the round
+// and the committed seals
+func GetQBFTHeaderHash(h *types.Header) common.Hash {
+	if h.MixDigest == IstanbulDigest {
+		if qbftHeader := QBFTFilteredHeaderWithRound(h, 0); qbftHeader != nil {
+			return qbftHeader.Hash()
+		}
+	}
+	return h.Hash()
+}
+
+// VerifyQBFTHeader checks that the committed seals of hdr, signed over the
+// header at the round it was committed in, come from a quorum of vs
+func VerifyQBFTHeader(vs QuorumValSet, hdr *types.Header) (*QBFTExtra, error) {
+	extra, err := ExtractQBFTExtra(hdr)
+	if err != nil {
+		return nil, fmt.Errorf("extract qbft extra from header %s error: %v", GetQBFTHeaderHash(hdr).String(), err)
+	}
+	sealed := QBFTFilteredHeaderWithRound(hdr, extra.Round)
+	if sealed == nil {
+		return nil, fmt.Errorf("failed to filter header %s", GetQBFTHeaderHash(hdr).String())
+	}
+	hash := sealed.Hash()
+	signed := make(map[common.Address]bool)
+	for i, seal := range extra.CommittedSeal {
+		pub, err := crypto.SigToPub(hash[:], seal)
+		if err != nil {
+			return nil, fmt.Errorf("failed to recover No.%d committed seal: %v", i, err)
+		}
+		addr := crypto.PubkeyToAddress(*pub)
+		if !vs.Exist(addr) {
+			return nil, fmt.Errorf("addess %s is not in validators", addr.String())
+		}
+		if signed[addr] {
+			return nil, fmt.Errorf("duplicated committed seal of %s", addr.String())
+		}
+		signed[addr] = true
+	}
+	if len(signed) < vs.QBFTQuorum() {
+		return nil, fmt.Errorf("valid seal not enough: (%d found, %d required)", len(signed), vs.QBFTQuorum())
+	}
+	return extra, nil
+}
+
+// QBFTQuorum is ceil(2n/3)
+func (vs QuorumValSet) QBFTQuorum() int { return (2*len(vs) + 2) / 3 }
+
+// ApplyQBFTVote returns the sorted validators after vote
+func (vs QuorumValSet) ApplyQBFTVote(vote *ValidatorVote) (QuorumValSet, error) {
+	next := make(QuorumValSet, 0, len(vs)+1)
+	switch vote.VoteType {
+	case QBFTAuthVote:
+		if vs.Exist(vote.RecipientAddress) {
+			return nil, fmt.Errorf("validator %s is already authorized", vote.RecipientAddress.Hex())
+		}
+		next = append(append(next, vs...), vote.RecipientAddress)
+	case QBFTDropVote:
+		if !vs.Exist(vote.RecipientAddress) {
+			return nil, fmt.Errorf("validator %s is not authorized", vote.RecipientAddress.Hex())
+		}
+		for _, v := range vs {
+			if v != vote.RecipientAddress {
+				next = append(next, v)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("invalid vote type %x", vote.VoteType)
+	}
+	sort.Slice(next, func(i, j int) bool {
+		return bytes.Compare(next[i][:], next[j][:]) < 0
+	})
+	return next, nil
+}
+
+// syncQBFTHeaders verifies the headers changing the validators. Such a header is
+// committed by the new validators and must follow its parent, committed by the
+// current validators, whose vote it applies. Vote tallies are not tracked, the
+// parent carries the vote that completes the tally. It returns the number of
+// validator changes along with the last one.
+func syncQBFTHeaders(headers [][]byte, currh uint64, vs QuorumValSet) (uint64, QuorumValSet, uint64, error) {
+	var changes uint64
+	var parent *types.Header
+	var parentExtra *QBFTExtra
+	for i, v := range headers {
+		header := &types.Header{}
+		if err := json.Unmarshal(v, header); err != nil {
+			return 0, nil, 0, fmt.Errorf("deserialize No.%d header err: %v", i, err)
+		}
+		h := header.Number.Uint64()
+		if currh > h {
+			return 0, nil, 0, fmt.Errorf("wrong height of No.%d header: (curr: %d, commit: %d)", i, currh, h)
+		}
+		extra, err := ExtractQBFTExtra(header)
+		if err != nil {
+			return 0, nil, 0, fmt.Errorf("extract qbft extra from No.%d header error: %v", i, err)
+		}
+		if !vs.IfChanged(extra.Validators) {
+			if _, err := VerifyQBFTHeader(vs, header); err != nil {
+				return 0, nil, 0, fmt.Errorf("failed to verify No.%d qbft header %s: %v", i, GetQBFTHeaderHash(header).String(), err)
+			}
+			parent, parentExtra = header, extra
+			continue
+		}
+
+		if parent == nil || parent.Number.Uint64()+1 != h || header.ParentHash != GetQBFTHeaderHash(parent) {
+			return 0, nil, 0, fmt.Errorf("No.%d header changes validators without its parent", i)
+		}
+		if parentExtra.Vote == nil {
+			return 0, nil, 0, fmt.Errorf("parent of No.%d header has no vote", i)
+		}
+		next, err := vs.ApplyQBFTVote(parentExtra.Vote)
+		if err != nil {
+			return 0, nil, 0, fmt.Errorf("failed to apply vote of No.%d header: %v", i-1, err)
+		}
+		if next.IfChanged(extra.Validators) {
+			return 0, nil, 0, fmt.Errorf("validators of No.%d header do not match the vote", i)
+		}
+		if _, err := VerifyQBFTHeader(next, header); err != nil {
+			return 0, nil, 0, fmt.Errorf("failed to verify No.%d qbft header %s: %v", i, GetQBFTHeaderHash(header).String(), err)
+		}
+		currh, vs = h, next
+		changes++
+		parent, parentExtra = header, extra
+	}
+	return currh, vs, changes, nil
+}
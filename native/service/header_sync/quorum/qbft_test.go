@@ -0,0 +1,166 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+package quorum
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"math/big"
+	"sort"
+	"strings"
+	"testing"
+
+	common2 "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	common4 "github.com/polynetwork/poly/native/service/header_sync/common"
+	"github.com/polynetwork/poly/native/service/utils"
+	"github.com/polynetwork/poly/native/storage"
+)
+
+const qbftChainID = 9
+
+func newQBFTKeys(n int) []*ecdsa.PrivateKey {
+	keys := make([]*ecdsa.PrivateKey, n)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(crypto.PubkeyToAddress(keys[i].PublicKey).Bytes(), crypto.PubkeyToAddress(keys[j].PublicKey).Bytes()) < 0
+	})
+	return keys
+}
+
+func newQBFTHeader(number int64, parent common2.Hash, keys []*ecdsa.PrivateKey, vote *ValidatorVote) *types.Header {
+	extra := &QBFTExtra{VanityData: make([]byte, 32), Vote: vote}
+	for _, key := range keys {
+		extra.Validators = append(extra.Validators, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	raw, _ := rlp.EncodeToBytes(extra)
+	return &types.Header{
+		ParentHash: parent,
+		Number:     big.NewInt(number),
+		Difficulty: big.NewInt(1),
+		MixDigest:  IstanbulDigest,
+		Extra:      raw,
+	}
+}
+
+// commit seals hdr at round by keys
+func commit(hdr *types.Header, round uint32, keys ...*ecdsa.PrivateKey) *types.Header {
+	extra, _ := ExtractQBFTExtra(hdr)
+	extra.Round = round
+	extra.CommittedSeal = nil
+	sealed := CopyHeader(hdr)
+	sealed.Extra, _ = rlp.EncodeToBytes(extra)
+	hash := QBFTFilteredHeaderWithRound(sealed, round).Hash()
+	for _, key := range keys {
+		seal, _ := crypto.Sign(hash[:], key)
+		extra.CommittedSeal = append(extra.CommittedSeal, seal)
+	}
+	sealed.Extra, _ = rlp.EncodeToBytes(extra)
+	return sealed
+}
+
+func syncQBFT(db *storage.CacheDB, headers ...*types.Header) error {
+	p := &common4.SyncBlockHeaderParam{ChainID: qbftChainID, Address: acct.Address}
+	for _, hdr := range headers {
+		raw, _ := json.Marshal(hdr)
+		p.Headers = append(p.Headers, raw)
+	}
+	sink := common.NewZeroCopySink(nil)
+	p.Serialization(sink)
+	_, err := NewQuorumHandler().SyncBlockHeader(getNativeFunc(sink.Bytes(), db))
+	return err
+}
+
+func TestQuorumHandler_SyncQBFTHeader(t *testing.T) {
+	keys := newQBFTKeys(5)
+	validators, joining := keys[:4], keys[4]
+	genesis := newQBFTHeader(0, common2.Hash{}, validators, nil)
+
+	raw, _ := json.Marshal(genesis)
+	p := &common4.SyncGenesisHeaderParam{ChainID: qbftChainID, GenesisHeader: raw}
+	sink := common.NewZeroCopySink(nil)
+	p.Serialization(sink)
+	ns := getNativeFunc(sink.Bytes(), nil)
+	db := ns.GetCacheDB()
+	sc := &side_chain_manager.SideChain{ChainId: qbftChainID, Router: utils.QUORUM_ROUTER, ExtraInfo: []byte(`{"Consensus":"qbft"}`)}
+	scSink := common.NewZeroCopySink(nil)
+	_ = sc.Serialization(scSink)
+	db.Put(utils.ConcatKey(utils.SideChainManagerContractAddress, []byte(side_chain_manager.SIDE_CHAIN), utils.GetUint64Bytes(qbftChainID)),
+		states.GenRawStorageItem(scSink.Bytes()))
+	if err := NewQuorumHandler().SyncGenesisHeader(ns); err != nil {
+		t.Fatal(err)
+	}
+
+	expectErr := func(err error, msg string) {
+		t.Helper()
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Fatalf("expect error %q, got %v", msg, err)
+		}
+	}
+
+	// the vote completing the tally is committed at round 1 after a round change
+	vote := &ValidatorVote{RecipientAddress: crypto.PubkeyToAddress(joining.PublicKey), VoteType: QBFTAuthVote}
+	h1 := newQBFTHeader(1, GetQBFTHeaderHash(genesis), validators, vote)
+	h2 := newQBFTHeader(2, GetQBFTHeaderHash(h1), keys, nil)
+	if GetQBFTHeaderHash(h1) != GetQBFTHeaderHash(commit(h1, 1, validators...)) {
+		t.Fatal("qbft hash depends on the committed seals")
+	}
+
+	expectErr(syncQBFT(db, commit(h1, 1, validators[:2]...)), "valid seal not enough")
+	expectErr(syncQBFT(db, commit(h1, 1, validators[0], validators[1], validators[0])), "duplicated committed seal")
+	wrongRound := commit(h1, 1, validators[:3]...)
+	extra, _ := ExtractQBFTExtra(wrongRound)
+	extra.Round = 0
+	wrongRound.Extra, _ = rlp.EncodeToBytes(extra)
+	expectErr(syncQBFT(db, wrongRound), "is not in validators")
+	expectErr(syncQBFT(db, commit(h2, 0, keys[:4]...)), "changes validators without its parent")
+	expectErr(syncQBFT(db, commit(newQBFTHeader(1, GetQBFTHeaderHash(genesis), validators, nil), 0, validators...),
+		commit(h2, 0, keys[:4]...)), "changes validators without its parent")
+	expectErr(syncQBFT(db, commit(newQBFTHeader(1, GetQBFTHeaderHash(genesis), validators, nil), 0, validators...),
+		commit(newQBFTHeader(2, GetQBFTHeaderHash(newQBFTHeader(1, GetQBFTHeaderHash(genesis), validators, nil)), keys, nil), 0, keys...)),
+		"has no vote")
+	dropped := newQBFTHeader(2, GetQBFTHeaderHash(h1), keys[1:], nil)
+	expectErr(syncQBFT(db, commit(h1, 1, validators...), commit(dropped, 0, keys[1:]...)), "do not match the vote")
+	expectErr(syncQBFT(db, commit(h1, 1, validators...), commit(h2, 0, keys[:3]...)), "valid seal not enough")
+
+	if err := syncQBFT(db, commit(h1, 1, validators[:3]...), commit(h2, 0, keys[1:]...)); err != nil {
+		t.Fatal(err)
+	}
+	vs, err := GetValSet(ns, qbftChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vs) != 5 || !vs.Exist(vote.RecipientAddress) {
+		t.Fatalf("wrong validators: %s", vs)
+	}
+	height, err := GetCurrentValHeight(ns, qbftChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if height != 2 {
+		t.Fatalf("wrong validator height: %d", height)
+	}
+	expectErr(syncQBFT(db, commit(h1, 1, validators...)), "wrong height")
+}
@@ -0,0 +1,301 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+// Package rollup keeps the L2 state roots of optimistic rollups. An output is
+// proven by a storage proof of the L1 contract the rollup commits to, against a
+// header synced by header_sync/eth, and is only imported once it can no longer
+// be challenged.
+package rollup
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/polynetwork/poly/common"
+	cstates "github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/native"
+	ccmeth "github.com/polynetwork/poly/native/service/cross_chain_manager/eth"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	scom "github.com/polynetwork/poly/native/service/header_sync/common"
+	"github.com/polynetwork/poly/native/service/header_sync/eth"
+	"github.com/polynetwork/poly/native/service/utils"
+)
+
+// Handler ...
+type Handler struct {
+}
+
+// NewHandler ...
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// SyncGenesisHeader is not supported, the outputs are proven by the L1 headers
+func (h *Handler) SyncGenesisHeader(native *native.NativeService) error {
+	return fmt.Errorf("rollup Handler SyncGenesisHeader, outputs are proven on L1, no genesis header is needed")
+}
+
+// SyncBlockHeader imports OutputProofs, outputs that are already imported are skipped
+func (h *Handler) SyncBlockHeader(native *native.NativeService) (uint64, error) {
+	params := new(scom.SyncBlockHeaderParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return 0, fmt.Errorf("rollup Handler SyncBlockHeader, contract params deserialize error: %v", err)
+	}
+	extraInfo, err := GetExtraInfo(native, params.ChainID)
+	if err != nil {
+		return 0, fmt.Errorf("rollup Handler SyncBlockHeader, %v", err)
+	}
+	var stored uint64
+	for i, v := range params.Headers {
+		proof := new(OutputProof)
+		if err := json.Unmarshal(v, proof); err != nil {
+			return 0, fmt.Errorf("rollup Handler SyncBlockHeader, deserialize OutputProof %d err: %v", i, err)
+		}
+		output, err := verifyOutput(native, extraInfo, proof)
+		if err != nil {
+			return 0, fmt.Errorf("rollup Handler SyncBlockHeader, OutputProof %d: %v", i, err)
+		}
+		exist, err := GetOutput(native, params.ChainID, output.L2Height)
+		if err != nil {
+			return 0, fmt.Errorf("rollup Handler SyncBlockHeader, %v", err)
+		}
+		if exist != nil {
+			continue
+		}
+		if err := putOutput(native, params.ChainID, output); err != nil {
+			return 0, fmt.Errorf("rollup Handler SyncBlockHeader, %v", err)
+		}
+		stored++
+	}
+	return stored, nil
+}
+
+// SyncCrossChainMsg ...
+func (h *Handler) SyncCrossChainMsg(native *native.NativeService) (uint64, error) {
+	return 0, nil
+}
+
+// verifyOutput checks the oracle account against the L1 header at proof.L1Height
+// once it is L1BlocksToWait deep, then the output committed in its storage
+func verifyOutput(native *native.NativeService, extraInfo *ExtraInfo, proof *OutputProof) (*Output, error) {
+	if proof.Proof == nil {
+		return nil, fmt.Errorf("no L1 proof")
+	}
+	current, err := eth.GetCurrentHeaderHeight(native, extraInfo.L1ChainID)
+	if err != nil {
+		return nil, fmt.Errorf("get L1 height error: %v", err)
+	}
+	if current < proof.L1Height || current-proof.L1Height+1 < extraInfo.L1BlocksToWait {
+		return nil, fmt.Errorf("L1 block is not confirmed, current height: %d, input height: %d", current, proof.L1Height)
+	}
+	l1Header, _, err := eth.GetHeaderByHeight(native, proof.L1Height, extraInfo.L1ChainID)
+	if err != nil {
+		return nil, fmt.Errorf("get L1 header error: %v", err)
+	}
+	storageHash, err := ccmeth.VerifyAccountProof(proof.Proof, l1Header.Root, extraInfo.Oracle.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	var output *Output
+	switch extraInfo.Kind {
+	case KIND_OP_STACK:
+		output, err = verifyOPStackOutput(extraInfo, l1Header, storageHash, proof)
+	case KIND_ARBITRUM:
+		output, err = verifyArbitrumNode(extraInfo, storageHash, proof)
+	default:
+		err = fmt.Errorf("unknown rollup kind: %s", extraInfo.Kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+	output.L1Height = proof.L1Height
+	return output, nil
+}
+
+// verifyOPStackOutput checks the version 0 output root at proof.Index, which is
+// final once the L1 block is later than its timestamp plus the challenge window
+func verifyOPStackOutput(extraInfo *ExtraInfo, l1Header *eth.Header, storageHash ecommon.Hash, proof *OutputProof) (*Output, error) {
+	slot := new(big.Int).SetBytes(crypto.Keccak256(ecommon.BigToHash(new(big.Int).SetUint64(extraInfo.OutputsSlot)).Bytes()))
+	slot.Add(slot, new(big.Int).Mul(new(big.Int).SetUint64(proof.Index), big.NewInt(2)))
+	outputRoot, err := provenSlot(storageHash, proof.Proof.StorageProofs, slot)
+	if err != nil {
+		return nil, err
+	}
+	if outputRoot == (ecommon.Hash{}) {
+		return nil, fmt.Errorf("no output at index %d", proof.Index)
+	}
+	word, err := provenSlot(storageHash, proof.Proof.StorageProofs, slot.Add(slot, big.NewInt(1)))
+	if err != nil {
+		return nil, err
+	}
+	if proof.Version != (ecommon.Hash{}) {
+		return nil, fmt.Errorf("unsupported output version %x", proof.Version)
+	}
+	root := crypto.Keccak256Hash(proof.Version[:], proof.StateRoot[:], proof.MessagePasserStorageRoot[:], proof.LatestBlockHash[:])
+	if root != outputRoot {
+		return nil, fmt.Errorf("output root mismatch, proven: %x, preimage: %x", outputRoot, root)
+	}
+	// timestamp and l2BlockNumber are packed as uint128, timestamp in the lower half
+	timestamp := new(big.Int).SetBytes(word[16:])
+	number := new(big.Int).SetBytes(word[:16])
+	if !timestamp.IsUint64() || !number.IsUint64() {
+		return nil, fmt.Errorf("invalid output %d, timestamp: %s, number: %s", proof.Index, timestamp, number)
+	}
+	if l1Header.Time <= timestamp.Uint64()+extraInfo.ChallengeWindow {
+		return nil, fmt.Errorf("output %d is in the challenge window until %d, L1 time: %d", proof.Index,
+			timestamp.Uint64()+extraInfo.ChallengeWindow, l1Header.Time)
+	}
+	return &Output{L2Height: number.Uint64(), StateRoot: proof.StateRoot}, nil
+}
+
+// verifyArbitrumNode checks that node proof.Index is the latest confirmed node
+// and that its confirmData commits to proof.Header
+func verifyArbitrumNode(extraInfo *ExtraInfo, storageHash ecommon.Hash, proof *OutputProof) (*Output, error) {
+	if proof.Header == nil {
+		return nil, fmt.Errorf("no L2 header")
+	}
+	word, err := provenSlot(storageHash, proof.Proof.StorageProofs, new(big.Int).SetUint64(extraInfo.LatestConfirmedSlot))
+	if err != nil {
+		return nil, err
+	}
+	// _latestConfirmed is the lowest uint64 of its slot
+	if latest := binary.BigEndian.Uint64(word[24:]); latest != proof.Index {
+		return nil, fmt.Errorf("node %d is not the latest confirmed node %d", proof.Index, latest)
+	}
+	slot := new(big.Int).SetBytes(crypto.Keccak256(ecommon.BigToHash(new(big.Int).SetUint64(proof.Index)).Bytes(),
+		ecommon.BigToHash(new(big.Int).SetUint64(extraInfo.OutputsSlot)).Bytes()))
+	confirmData, err := provenSlot(storageHash, proof.Proof.StorageProofs, slot.Add(slot, big.NewInt(arbitrumConfirmDataOffset)))
+	if err != nil {
+		return nil, err
+	}
+	hash := proof.Header.Hash()
+	if data := crypto.Keccak256Hash(hash[:], proof.SendRoot[:]); data != confirmData {
+		return nil, fmt.Errorf("confirm data mismatch, proven: %x, header: %x", confirmData, data)
+	}
+	return &Output{L2Height: proof.Header.Number.Uint64(), StateRoot: proof.Header.Root}, nil
+}
+
+// provenSlot returns the word at slot verified with the matching one of proofs
+func provenSlot(storageHash ecommon.Hash, proofs []ccmeth.StorageProof, slot *big.Int) (ecommon.Hash, error) {
+	key := ecommon.BigToHash(slot)
+	for i := range proofs {
+		if ecommon.HexToHash(proofs[i].Key) != key {
+			continue
+		}
+		raw, err := ccmeth.VerifyStorageProof(storageHash, &proofs[i])
+		if err != nil {
+			return ecommon.Hash{}, err
+		}
+		if len(raw) == 0 {
+			return ecommon.Hash{}, nil
+		}
+		var value []byte
+		if err := rlp.DecodeBytes(raw, &value); err != nil {
+			return ecommon.Hash{}, fmt.Errorf("decode slot %x error: %v", key, err)
+		}
+		if len(value) > ecommon.HashLength {
+			return ecommon.Hash{}, fmt.Errorf("invalid value of slot %x", key)
+		}
+		return ecommon.BytesToHash(value), nil
+	}
+	return ecommon.Hash{}, fmt.Errorf("no proof of slot %x", key)
+}
+
+// GetExtraInfo returns the L1 commitment contract of chainID
+func GetExtraInfo(native *native.NativeService, chainID uint64) (*ExtraInfo, error) {
+	sideChain, err := side_chain_manager.GetSideChain(native, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("GetSideChain error: %v", err)
+	}
+	if sideChain == nil {
+		return nil, fmt.Errorf("side chain %d is not registered", chainID)
+	}
+	extraInfo := new(ExtraInfo)
+	if err := json.Unmarshal(sideChain.ExtraInfo, extraInfo); err != nil {
+		return nil, fmt.Errorf("ExtraInfo Unmarshal error: %v", err)
+	}
+	if err := extraInfo.validate(); err != nil {
+		return nil, fmt.Errorf("invalid ExtraInfo: %v", err)
+	}
+	l1, err := side_chain_manager.GetSideChain(native, extraInfo.L1ChainID)
+	if err != nil {
+		return nil, fmt.Errorf("GetSideChain error: %v", err)
+	}
+	if l1 == nil || l1.Router != utils.ETH_ROUTER {
+		return nil, fmt.Errorf("L1 chain %d is not an eth chain", extraInfo.L1ChainID)
+	}
+	return extraInfo, nil
+}
+
+// GetOutput returns the final output at L2 height, nil if it is not imported
+func GetOutput(native *native.NativeService, chainID, height uint64) (*Output, error) {
+	store, err := native.GetCacheDB().Get(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.STATE_ROOT),
+		utils.GetUint64Bytes(chainID), utils.GetUint64Bytes(height)))
+	if err != nil {
+		return nil, fmt.Errorf("GetOutput, GetCacheDB err:%v", err)
+	}
+	if store == nil {
+		return nil, nil
+	}
+	raw, err := cstates.GetValueFromRawStorageItem(store)
+	if err != nil {
+		return nil, fmt.Errorf("GetOutput, GetValueFromRawStorageItem err:%v", err)
+	}
+	output := new(Output)
+	if err := output.Deserialization(common.NewZeroCopySource(raw)); err != nil {
+		return nil, fmt.Errorf("GetOutput, %v", err)
+	}
+	return output, nil
+}
+
+// GetCurrentHeight returns the highest imported L2 height
+func GetCurrentHeight(native *native.NativeService, chainID uint64) (uint64, error) {
+	store, err := native.GetCacheDB().Get(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.CURRENT_HEADER_HEIGHT),
+		utils.GetUint64Bytes(chainID)))
+	if err != nil {
+		return 0, fmt.Errorf("GetCurrentHeight, GetCacheDB err:%v", err)
+	}
+	if store == nil {
+		return 0, nil
+	}
+	raw, err := cstates.GetValueFromRawStorageItem(store)
+	if err != nil {
+		return 0, fmt.Errorf("GetCurrentHeight, GetValueFromRawStorageItem err:%v", err)
+	}
+	return utils.GetBytesUint64(raw), nil
+}
+
+func putOutput(native *native.NativeService, chainID uint64, output *Output) error {
+	sink := common.NewZeroCopySink(nil)
+	output.Serialization(sink)
+	native.GetCacheDB().Put(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.STATE_ROOT),
+		utils.GetUint64Bytes(chainID), utils.GetUint64Bytes(output.L2Height)), cstates.GenRawStorageItem(sink.Bytes()))
+	current, err := GetCurrentHeight(native, chainID)
+	if err != nil {
+		return err
+	}
+	if output.L2Height > current {
+		native.GetCacheDB().Put(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.CURRENT_HEADER_HEIGHT),
+			utils.GetUint64Bytes(chainID)), cstates.GenRawStorageItem(utils.GetUint64Bytes(output.L2Height)))
+	}
+	scom.NotifyPutHeader(native, chainID, output.L2Height, output.StateRoot.Hex())
+	return nil
+}
@@ -0,0 +1,245 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+package rollup
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/polynetwork/poly/common"
+	cstates "github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/core/store/leveldbstore"
+	"github.com/polynetwork/poly/core/store/overlaydb"
+	"github.com/polynetwork/poly/core/types"
+	"github.com/polynetwork/poly/native"
+	ccmeth "github.com/polynetwork/poly/native/service/cross_chain_manager/eth"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	scom "github.com/polynetwork/poly/native/service/header_sync/common"
+	"github.com/polynetwork/poly/native/service/header_sync/eth"
+	"github.com/polynetwork/poly/native/service/utils"
+	"github.com/polynetwork/poly/native/storage"
+	"gotest.tools/assert"
+)
+
+const (
+	l1ChainID       = 2
+	opChainID       = 300
+	arbitrumChainID = 301
+)
+
+var (
+	opOracle       = ecommon.HexToAddress("0x0000000000000000000000000000000000000a01")
+	arbitrumRollup = ecommon.HexToAddress("0x0000000000000000000000000000000000000a02")
+)
+
+func newNative(args []byte, db *storage.CacheDB) *native.NativeService {
+	if db == nil {
+		store, _ := leveldbstore.NewMemLevelDBStore()
+		db = storage.NewCacheDB(overlaydb.NewOverlayDB(store))
+		service, _ := native.NewNativeService(db, new(types.Transaction), 0, 0, common.Uint256{0}, 0, nil, false)
+		_ = side_chain_manager.PutSideChain(service, &side_chain_manager.SideChain{Name: "eth", ChainId: l1ChainID, Router: utils.ETH_ROUTER})
+		opInfo, _ := json.Marshal(&ExtraInfo{
+			Kind:            KIND_OP_STACK,
+			L1ChainID:       l1ChainID,
+			L1BlocksToWait:  2,
+			Oracle:          opOracle,
+			OutputsSlot:     3,
+			ChallengeWindow: 100,
+		})
+		_ = side_chain_manager.PutSideChain(service, &side_chain_manager.SideChain{Name: "op", ChainId: opChainID,
+			Router: utils.ROLLUP_ROUTER, ExtraInfo: opInfo})
+		arbitrumInfo, _ := json.Marshal(&ExtraInfo{
+			Kind:                KIND_ARBITRUM,
+			L1ChainID:           l1ChainID,
+			Oracle:              arbitrumRollup,
+			OutputsSlot:         6,
+			LatestConfirmedSlot: 5,
+		})
+		_ = side_chain_manager.PutSideChain(service, &side_chain_manager.SideChain{Name: "arbitrum", ChainId: arbitrumChainID,
+			Router: utils.ROLLUP_ROUTER, ExtraInfo: arbitrumInfo})
+	}
+	service, _ := native.NewNativeService(db, new(types.Transaction), 0, 0, common.Uint256{0}, 0, args, false)
+	return service
+}
+
+// putL1Header appends header to the main chain the way header_sync/eth stores it
+func putL1Header(db *storage.CacheDB, header *eth.Header) {
+	raw, _ := json.Marshal(&eth.HeaderWithDifficultySum{Header: *header, DifficultySum: header.Difficulty})
+	hash := header.Hash()
+	db.Put(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.HEADER_INDEX), utils.GetUint64Bytes(l1ChainID), hash[:]),
+		cstates.GenRawStorageItem(raw))
+	db.Put(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.MAIN_CHAIN), utils.GetUint64Bytes(l1ChainID),
+		utils.GetUint64Bytes(header.Number.Uint64())), cstates.GenRawStorageItem(hash[:]))
+	db.Put(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.CURRENT_HEADER_HEIGHT), utils.GetUint64Bytes(l1ChainID)),
+		cstates.GenRawStorageItem(utils.GetUint64Bytes(header.Number.Uint64())))
+}
+
+// slotAt returns keccak(base) + offset, the offset-th word of a dynamic array at base
+func slotAt(base []byte, offset int64) ecommon.Hash {
+	slot := new(big.Int).SetBytes(crypto.Keccak256(base))
+	return ecommon.BigToHash(slot.Add(slot, big.NewInt(offset)))
+}
+
+func makeState(t *testing.T, slots map[ecommon.Address]map[ecommon.Hash]ecommon.Hash) *state.StateDB {
+	st, err := state.New(ecommon.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	assert.NilError(t, err)
+	for addr, words := range slots {
+		st.SetNonce(addr, 1)
+		for k, v := range words {
+			st.SetState(addr, k, v)
+		}
+	}
+	_, err = st.Commit(false)
+	assert.NilError(t, err)
+	return st
+}
+
+func makeProof(t *testing.T, st *state.StateDB, addr ecommon.Address, keys ...ecommon.Hash) *ccmeth.ETHProof {
+	encode := func(nodes [][]byte) []string {
+		list := make([]string, len(nodes))
+		for i, node := range nodes {
+			list[i] = hexutil.Encode(node)
+		}
+		return list
+	}
+	accountProof, err := st.GetProof(addr)
+	assert.NilError(t, err)
+	proof := &ccmeth.ETHProof{
+		Address:      addr.Hex(),
+		Balance:      hexutil.EncodeBig(st.GetBalance(addr)),
+		CodeHash:     st.GetCodeHash(addr).Hex(),
+		Nonce:        hexutil.EncodeUint64(st.GetNonce(addr)),
+		StorageHash:  st.StorageTrie(addr).Hash().Hex(),
+		AccountProof: encode(accountProof),
+	}
+	for _, key := range keys {
+		storageProof, err := st.GetStorageProof(addr, key)
+		assert.NilError(t, err)
+		proof.StorageProofs = append(proof.StorageProofs, ccmeth.StorageProof{Key: key.Hex(), Proof: encode(storageProof)})
+	}
+	return proof
+}
+
+func syncOutput(db *storage.CacheDB, chainID uint64, proof *OutputProof) error {
+	raw, _ := json.Marshal(proof)
+	param := &scom.SyncBlockHeaderParam{ChainID: chainID, Headers: [][]byte{raw}}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	_, err := NewHandler().SyncBlockHeader(newNative(sink.Bytes(), db))
+	return err
+}
+
+func TestSyncOPStackOutput(t *testing.T) {
+	l2StateRoot := ecommon.HexToHash("0x5a")
+	messagePasserRoot := ecommon.HexToHash("0x5b")
+	blockHash := ecommon.HexToHash("0x5c")
+	outputRoot := crypto.Keccak256Hash(make([]byte, 32), l2StateRoot[:], messagePasserRoot[:], blockHash[:])
+	// output 1 of l2Outputs at slot 3, proposed at 1000 for L2 block 1800
+	base := ecommon.BigToHash(big.NewInt(3)).Bytes()
+	word := ecommon.BigToHash(new(big.Int).Or(new(big.Int).Lsh(big.NewInt(1800), 128), big.NewInt(1000)))
+	st := makeState(t, map[ecommon.Address]map[ecommon.Hash]ecommon.Hash{
+		opOracle: {slotAt(base, 2): outputRoot, slotAt(base, 3): word},
+	})
+	root := st.IntermediateRoot(false)
+
+	service := newNative(nil, nil)
+	db := service.GetCacheDB()
+	for i, time := range []uint64{1050, 1101, 1102} {
+		putL1Header(db, &eth.Header{Number: big.NewInt(int64(10 + i)), Difficulty: big.NewInt(1), Time: time, Root: root})
+	}
+
+	newProof := func(l1Height, index uint64) *OutputProof {
+		return &OutputProof{
+			L1Height:                 l1Height,
+			Index:                    index,
+			Proof:                    makeProof(t, st, opOracle, slotAt(base, int64(2*index)), slotAt(base, int64(2*index+1))),
+			StateRoot:                l2StateRoot,
+			MessagePasserStorageRoot: messagePasserRoot,
+			LatestBlockHash:          blockHash,
+		}
+	}
+	assert.ErrorContains(t, syncOutput(db, opChainID, newProof(12, 1)), "L1 block is not confirmed")
+	assert.ErrorContains(t, syncOutput(db, opChainID, newProof(10, 1)), "is in the challenge window until 1100")
+	assert.ErrorContains(t, syncOutput(db, opChainID, newProof(11, 0)), "no output at index 0")
+	proof := newProof(11, 1)
+	proof.StateRoot = ecommon.HexToHash("0x5d")
+	assert.ErrorContains(t, syncOutput(db, opChainID, proof), "output root mismatch")
+	proof = newProof(11, 1)
+	proof.Proof.StorageProofs = proof.Proof.StorageProofs[:1]
+	assert.ErrorContains(t, syncOutput(db, opChainID, proof), "no proof of slot")
+	proof = newProof(11, 1)
+	proof.Proof.Address = arbitrumRollup.Hex()
+	assert.ErrorContains(t, syncOutput(db, opChainID, proof), "contract address is error")
+
+	assert.NilError(t, syncOutput(db, opChainID, newProof(11, 1)))
+	output, err := GetOutput(service, opChainID, 1800)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, output, &Output{L2Height: 1800, StateRoot: l2StateRoot, L1Height: 11})
+	height, err := GetCurrentHeight(service, opChainID)
+	assert.NilError(t, err)
+	assert.Equal(t, height, uint64(1800))
+	// imported outputs are skipped
+	assert.NilError(t, syncOutput(db, opChainID, newProof(11, 1)))
+}
+
+func TestSyncArbitrumNode(t *testing.T) {
+	l2Header := &eth.Header{Number: big.NewInt(5000), Difficulty: big.NewInt(1), Root: ecommon.HexToHash("0x6a"), BaseFee: big.NewInt(1)}
+	sendRoot := ecommon.HexToHash("0x6b")
+	hash := l2Header.Hash()
+	confirmData := crypto.Keccak256Hash(hash[:], sendRoot[:])
+	// _latestConfirmed 7 is packed with _firstUnresolvedNode 8
+	latest := ecommon.BigToHash(new(big.Int).Or(new(big.Int).Lsh(big.NewInt(8), 64), big.NewInt(7)))
+	node := func(num int64) ecommon.Hash {
+		return slotAt(append(ecommon.BigToHash(big.NewInt(num)).Bytes(), ecommon.BigToHash(big.NewInt(6)).Bytes()...), arbitrumConfirmDataOffset)
+	}
+	latestSlot := ecommon.BigToHash(big.NewInt(5))
+	st := makeState(t, map[ecommon.Address]map[ecommon.Hash]ecommon.Hash{
+		arbitrumRollup: {latestSlot: latest, node(6): ecommon.HexToHash("0x01"), node(7): confirmData},
+	})
+
+	service := newNative(nil, nil)
+	db := service.GetCacheDB()
+	putL1Header(db, &eth.Header{Number: big.NewInt(10), Difficulty: big.NewInt(1), Root: st.IntermediateRoot(false)})
+
+	newProof := func(index int64) *OutputProof {
+		return &OutputProof{
+			L1Height: 10,
+			Index:    uint64(index),
+			Proof:    makeProof(t, st, arbitrumRollup, latestSlot, node(index)),
+			Header:   l2Header,
+			SendRoot: sendRoot,
+		}
+	}
+	assert.ErrorContains(t, syncOutput(db, arbitrumChainID, newProof(6)), "node 6 is not the latest confirmed node 7")
+	proof := newProof(7)
+	proof.SendRoot = ecommon.HexToHash("0x6c")
+	assert.ErrorContains(t, syncOutput(db, arbitrumChainID, proof), "confirm data mismatch")
+	proof = newProof(7)
+	proof.Header = nil
+	assert.ErrorContains(t, syncOutput(db, arbitrumChainID, proof), "no L2 header")
+
+	assert.NilError(t, syncOutput(db, arbitrumChainID, newProof(7)))
+	output, err := GetOutput(service, arbitrumChainID, 5000)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, output, &Output{L2Height: 5000, StateRoot: l2Header.Root, L1Height: 10})
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+package rollup
+
+import (
+	"fmt"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/polynetwork/poly/common"
+	ccmeth "github.com/polynetwork/poly/native/service/cross_chain_manager/eth"
+	"github.com/polynetwork/poly/native/service/header_sync/eth"
+)
+
+// rollup kinds
+const (
+	// KIND_OP_STACK reads the l2Outputs array of an L2OutputOracle, an output is
+	// final once ChallengeWindow seconds have passed since it was proposed
+	KIND_OP_STACK = "opstack"
+	// KIND_ARBITRUM reads the _nodes mapping of a nitro rollup contract, a node is
+	// final once it is the latest confirmed node
+	KIND_ARBITRUM = "arbitrum"
+)
+
+// the confirmData field is the third word of an arbitrum node
+const arbitrumConfirmDataOffset = 2
+
+// ExtraInfo configures the L1 contract that commits to the L2 states, it is set
+// with the side chain registration.
+//
+// The storage slots depend on the deployed contract version and are given as
+// read from its storage layout.
+type ExtraInfo struct {
+	Kind                string
+	L1ChainID           uint64          // an ETH_ROUTER side chain synced by header_sync/eth
+	L1BlocksToWait      uint64          // confirmations of the L1 block an output is proven against
+	Oracle              ecommon.Address // the L2OutputOracle or the rollup contract
+	OutputsSlot         uint64          // slot of l2Outputs or _nodes
+	LatestConfirmedSlot uint64          // slot of _latestConfirmed, arbitrum only
+	ChallengeWindow     uint64          // seconds, opstack only
+}
+
+func (this *ExtraInfo) validate() error {
+	if this.L1ChainID == 0 {
+		return fmt.Errorf("no L1 chain id")
+	}
+	if this.Oracle == (ecommon.Address{}) {
+		return fmt.Errorf("no oracle address")
+	}
+	switch this.Kind {
+	case KIND_OP_STACK:
+		if this.ChallengeWindow == 0 {
+			return fmt.Errorf("no challenge window")
+		}
+	case KIND_ARBITRUM:
+	default:
+		return fmt.Errorf("unknown rollup kind: %s", this.Kind)
+	}
+	return nil
+}
+
+// OutputProof proves an L2 state commitment with the L1 state at L1Height.
+//
+// For opstack chains Index is the index in l2Outputs, Proof carries the two
+// words of the output and the preimage of the version 0 output root is given.
+// For arbitrum chains Index is the node number, Proof carries _latestConfirmed
+// and the confirmData of the node, and Header is the L2 block it confirms.
+type OutputProof struct {
+	L1Height uint64           `json:"l1Height"`
+	Index    uint64           `json:"index"`
+	Proof    *ccmeth.ETHProof `json:"proof"`
+
+	Version                  ecommon.Hash `json:"version"`
+	StateRoot                ecommon.Hash `json:"stateRoot"`
+	MessagePasserStorageRoot ecommon.Hash `json:"messagePasserStorageRoot"`
+	LatestBlockHash          ecommon.Hash `json:"latestBlockHash"`
+
+	Header   *eth.Header  `json:"header"`
+	SendRoot ecommon.Hash `json:"sendRoot"`
+}
+
+// Output is a final L2 state root
+type Output struct {
+	L2Height  uint64
+	StateRoot ecommon.Hash
+	L1Height  uint64
+}
+
+func (this *Output) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint64(this.L2Height)
+	sink.WriteHash(common.Uint256(this.StateRoot))
+	sink.WriteUint64(this.L1Height)
+}
+
+func (this *Output) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	this.L2Height, eof = source.NextUint64()
+	if eof {
+		return fmt.Errorf("deserialize L2Height of Output failed")
+	}
+	root, eof := source.NextHash()
+	if eof {
+		return fmt.Errorf("deserialize StateRoot of Output failed")
+	}
+	this.StateRoot = ecommon.Hash(root)
+	this.L1Height, eof = source.NextUint64()
+	if eof {
+		return fmt.Errorf("deserialize L1Height of Output failed")
+	}
+	return nil
+}
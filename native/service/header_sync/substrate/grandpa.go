@@ -0,0 +1,88 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package substrate
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"fmt"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+)
+
+// verify checks that the justification finalizes the block hash at number
+// with the votes of more than 2/3 of the weight of the authority set
+func (this *Justification) verify(hash ecommon.Hash, number uint32, setID uint64, authorities []Authority) error {
+	if this.TargetHash != hash || this.TargetNumber != number {
+		return fmt.Errorf("justification is for block %d(%s) not %d(%s)", this.TargetNumber, this.TargetHash.Hex(), number, hash.Hex())
+	}
+	ancestries := make(map[ecommon.Hash]*Header, len(this.VotesAncestries))
+	for _, header := range this.VotesAncestries {
+		ancestries[header.Hash()] = header
+	}
+	used := make(map[ecommon.Hash]bool)
+	voted := make(map[[32]byte]bool)
+	var total, weight uint64
+	for _, a := range authorities {
+		total += a.Weight
+	}
+	for i := range this.Precommits {
+		vote := &this.Precommits[i]
+		authority := findAuthority(authorities, vote.ID[:])
+		if authority == nil {
+			return fmt.Errorf("precommit %d is not from an authority", i)
+		}
+		if voted[vote.ID] {
+			return fmt.Errorf("authority %x voted twice", vote.ID)
+		}
+		voted[vote.ID] = true
+		if !ed25519.Verify(vote.ID[:], precommitSignBytes(&vote.Precommit, this.Round, setID), vote.Signature[:]) {
+			return fmt.Errorf("invalid signature of precommit %d", i)
+		}
+		// the vote must be for the target or one of its descendants
+		cur, curNumber := vote.Precommit.TargetHash, vote.Precommit.TargetNumber
+		for cur != hash {
+			header, ok := ancestries[cur]
+			if !ok || header.Number != curNumber || curNumber <= number {
+				return fmt.Errorf("precommit %d is not for a descendant of the target", i)
+			}
+			used[cur] = true
+			cur, curNumber = header.ParentHash, curNumber-1
+		}
+		if curNumber != number {
+			return fmt.Errorf("precommit %d has an invalid target number", i)
+		}
+		weight += authority.Weight
+	}
+	if len(used) != len(ancestries) {
+		return fmt.Errorf("justification has unused ancestries")
+	}
+	if weight < total-(total-1)/3 {
+		return fmt.Errorf("not enough votes, %d of %d", weight, total)
+	}
+	return nil
+}
+
+func findAuthority(authorities []Authority, key []byte) *Authority {
+	for i := range authorities {
+		if bytes.Equal(authorities[i].Key, key) {
+			return &authorities[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package substrate tracks the GRANDPA finality of substrate chains and keeps
+// the state roots of the finalized blocks, against which storage trie proofs
+// are verified.
+package substrate
+
+import (
+	"encoding/json"
+	"fmt"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/polynetwork/poly/common"
+	cstates "github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/governance/node_manager"
+	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
+	scom "github.com/polynetwork/poly/native/service/header_sync/common"
+	"github.com/polynetwork/poly/native/service/utils"
+)
+
+// Handler ...
+type Handler struct {
+}
+
+// NewHandler ...
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// SyncGenesisHeader stores a trusted finalized header and its authority set
+func (h *Handler) SyncGenesisHeader(native *native.NativeService) error {
+	params := new(scom.SyncGenesisHeaderParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return fmt.Errorf("substrate Handler SyncGenesisHeader, contract params deserialize error: %v", err)
+	}
+	if err := checkOperator(native); err != nil {
+		return fmt.Errorf("substrate Handler SyncGenesisHeader, %v", err)
+	}
+	state, err := GetFinalizedState(native, params.ChainID)
+	if err != nil {
+		return fmt.Errorf("substrate Handler SyncGenesisHeader, %v", err)
+	}
+	if state != nil {
+		return fmt.Errorf("substrate Handler SyncGenesisHeader, genesis had been initialized")
+	}
+
+	var genesis GenesisHeader
+	if err := json.Unmarshal(params.GenesisHeader, &genesis); err != nil {
+		return fmt.Errorf("substrate Handler SyncGenesisHeader, deserialize GenesisHeader err: %v", err)
+	}
+	header, err := DecodeHeader(genesis.Header)
+	if err != nil {
+		return fmt.Errorf("substrate Handler SyncGenesisHeader, %v", err)
+	}
+	if err := validateAuthorities(genesis.Authorities); err != nil {
+		return fmt.Errorf("substrate Handler SyncGenesisHeader, %v", err)
+	}
+	state = &FinalizedState{
+		Height:      header.Number,
+		Hash:        header.Hash(),
+		StateRoot:   header.StateRoot,
+		SetID:       genesis.SetID,
+		Authorities: genesis.Authorities,
+	}
+	putFinalizedState(native, params.ChainID, state)
+	putStateRoot(native, params.ChainID, header.Number, header.StateRoot)
+	scom.NotifyPutHeader(native, params.ChainID, uint64(header.Number), state.Hash.Hex())
+	return nil
+}
+
+// SyncBlockHeader imports FinalityProofs, proofs of blocks that are already
+// finalized are skipped
+func (h *Handler) SyncBlockHeader(native *native.NativeService) (uint64, error) {
+	params := new(scom.SyncBlockHeaderParam)
+	if err := params.Deserialization(common.NewZeroCopySource(native.GetInput())); err != nil {
+		return 0, fmt.Errorf("substrate Handler SyncBlockHeader, contract params deserialize error: %v", err)
+	}
+	state, err := GetFinalizedState(native, params.ChainID)
+	if err != nil {
+		return 0, fmt.Errorf("substrate Handler SyncBlockHeader, %v", err)
+	}
+	if state == nil {
+		return 0, fmt.Errorf("substrate Handler SyncBlockHeader, genesis is not initialized")
+	}
+	var stored uint64
+	for i, v := range params.Headers {
+		proof := new(FinalityProof)
+		if err := json.Unmarshal(v, proof); err != nil {
+			return 0, fmt.Errorf("substrate Handler SyncBlockHeader, deserialize FinalityProof %d err: %v", i, err)
+		}
+		cnt, err := processFinalityProof(native, params.ChainID, state, proof)
+		if err != nil {
+			return 0, fmt.Errorf("substrate Handler SyncBlockHeader, FinalityProof %d: %v", i, err)
+		}
+		stored += cnt
+	}
+	putFinalizedState(native, params.ChainID, state)
+	return stored, nil
+}
+
+// SyncCrossChainMsg ...
+func (h *Handler) SyncCrossChainMsg(native *native.NativeService) (uint64, error) {
+	return 0, nil
+}
+
+// processFinalityProof advances state to the target of the proof and returns
+// the number of headers finalized by it.
+//
+// A scheduled change is enacted once the block Delay after its announcement is
+// finalized by the current set, so proofs may not go past that block. A forced
+// change is enacted when the block Delay after it is imported, usually when
+// finality is stalled, and can not be proven by the current set; it is only
+// accepted from the consensus operators and the target is then finalized by
+// the new set.
+func processFinalityProof(native *native.NativeService, chainID uint64, state *FinalizedState, proof *FinalityProof) (uint64, error) {
+	if len(proof.Headers) == 0 {
+		return 0, fmt.Errorf("no headers")
+	}
+	headers := make([]*Header, len(proof.Headers))
+	for i, raw := range proof.Headers {
+		header, err := DecodeHeader(raw)
+		if err != nil {
+			return 0, err
+		}
+		headers[i] = header
+	}
+	target := headers[len(headers)-1]
+	if target.Number <= state.Height {
+		return 0, nil
+	}
+	hashes := make([]ecommon.Hash, len(headers))
+	parent, number := state.Hash, state.Height
+	for i, header := range headers {
+		if header.ParentHash != parent || header.Number != number+1 {
+			return 0, fmt.Errorf("header %d does not extend block %d(%s)", header.Number, number, parent.Hex())
+		}
+		hashes[i] = header.Hash()
+		parent, number = hashes[i], header.Number
+
+		changes, err := header.grandpaChanges()
+		if err != nil {
+			return 0, fmt.Errorf("header %d has an invalid GRANDPA log: %v", header.Number, err)
+		}
+		for _, change := range changes {
+			if state.Pending != nil {
+				return 0, fmt.Errorf("header %d announces a change while the change at %d is pending", header.Number, state.Pending.EnactHeight)
+			}
+			if change.Forced {
+				if err := checkOperator(native); err != nil {
+					return 0, fmt.Errorf("forced change of header %d, %v", header.Number, err)
+				}
+			}
+			state.Pending = &PendingChange{
+				Authorities: change.Authorities,
+				EnactHeight: header.Number + change.Delay,
+				Forced:      change.Forced,
+			}
+		}
+	}
+
+	pending := state.Pending
+	if pending != nil && pending.Forced && pending.EnactHeight <= target.Number {
+		enact(state)
+	}
+	if pending != nil && !pending.Forced && pending.EnactHeight < target.Number {
+		return 0, fmt.Errorf("the authority set change at %d must be finalized first", pending.EnactHeight)
+	}
+	justification, err := DecodeJustification(proof.Justification)
+	if err != nil {
+		return 0, err
+	}
+	if err := justification.verify(hashes[len(hashes)-1], target.Number, state.SetID, state.Authorities); err != nil {
+		return 0, fmt.Errorf("invalid justification of set %d: %v", state.SetID, err)
+	}
+	if state.Pending != nil && state.Pending.EnactHeight == target.Number {
+		enact(state)
+	}
+
+	for _, header := range headers {
+		putStateRoot(native, chainID, header.Number, header.StateRoot)
+	}
+	state.Height, state.Hash, state.StateRoot = target.Number, hashes[len(hashes)-1], target.StateRoot
+	scom.NotifyPutHeader(native, chainID, uint64(target.Number), state.Hash.Hex())
+	return uint64(len(headers)), nil
+}
+
+func enact(state *FinalizedState) {
+	state.SetID++
+	state.Authorities = state.Pending.Authorities
+	state.Pending = nil
+}
+
+func checkOperator(native *native.NativeService) error {
+	operatorAddress, err := node_manager.GetCurConOperator(native)
+	if err != nil {
+		return fmt.Errorf("get current consensus operator address error: %v", err)
+	}
+	if err := utils.ValidateOwner(native, operatorAddress); err != nil {
+		return fmt.Errorf("checkWitness error: %v", err)
+	}
+	return nil
+}
+
+// GetExtraInfo returns the storage layout of the cross chain transactions of chainID
+func GetExtraInfo(native *native.NativeService, chainID uint64) (*ExtraInfo, error) {
+	sideChain, err := side_chain_manager.GetSideChain(native, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("GetSideChain error: %v", err)
+	}
+	if sideChain == nil {
+		return nil, fmt.Errorf("side chain %d is not registered", chainID)
+	}
+	extraInfo := new(ExtraInfo)
+	if err := json.Unmarshal(sideChain.ExtraInfo, extraInfo); err != nil {
+		return nil, fmt.Errorf("ExtraInfo Unmarshal error: %v", err)
+	}
+	if len(extraInfo.KeyPrefix) == 0 {
+		return nil, fmt.Errorf("invalid ExtraInfo: no key prefix")
+	}
+	return extraInfo, nil
+}
+
+// GetFinalizedState returns the latest finalized header of chainID, nil before genesis
+func GetFinalizedState(native *native.NativeService, chainID uint64) (*FinalizedState, error) {
+	store, err := native.GetCacheDB().Get(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.FINALIZED_HEADER), utils.GetUint64Bytes(chainID)))
+	if err != nil {
+		return nil, fmt.Errorf("GetFinalizedState, GetCacheDB err:%v", err)
+	}
+	if store == nil {
+		return nil, nil
+	}
+	raw, err := cstates.GetValueFromRawStorageItem(store)
+	if err != nil {
+		return nil, fmt.Errorf("GetFinalizedState, GetValueFromRawStorageItem err:%v", err)
+	}
+	state := new(FinalizedState)
+	if err := state.Deserialization(common.NewZeroCopySource(raw)); err != nil {
+		return nil, fmt.Errorf("GetFinalizedState, %v", err)
+	}
+	return state, nil
+}
+
+func putFinalizedState(native *native.NativeService, chainID uint64, state *FinalizedState) {
+	sink := common.NewZeroCopySink(nil)
+	state.Serialization(sink)
+	native.GetCacheDB().Put(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.FINALIZED_HEADER), utils.GetUint64Bytes(chainID)),
+		cstates.GenRawStorageItem(sink.Bytes()))
+}
+
+// GetStateRoot returns the state root of the finalized block at height
+func GetStateRoot(native *native.NativeService, chainID uint64, height uint32) (ecommon.Hash, error) {
+	store, err := native.GetCacheDB().Get(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.STATE_ROOT),
+		utils.GetUint64Bytes(chainID), utils.GetUint32Bytes(height)))
+	if err != nil {
+		return ecommon.Hash{}, fmt.Errorf("GetStateRoot, GetCacheDB err:%v", err)
+	}
+	if store == nil {
+		return ecommon.Hash{}, fmt.Errorf("GetStateRoot, no finalized block at height %d", height)
+	}
+	raw, err := cstates.GetValueFromRawStorageItem(store)
+	if err != nil {
+		return ecommon.Hash{}, fmt.Errorf("GetStateRoot, GetValueFromRawStorageItem err:%v", err)
+	}
+	return ecommon.BytesToHash(raw), nil
+}
+
+func putStateRoot(native *native.NativeService, chainID uint64, height uint32, root ecommon.Hash) {
+	native.GetCacheDB().Put(utils.ConcatKey(utils.HeaderSyncContractAddress, []byte(scom.STATE_ROOT),
+		utils.GetUint64Bytes(chainID), utils.GetUint32Bytes(height)), cstates.GenRawStorageItem(root[:]))
+}
@@ -0,0 +1,274 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package substrate
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"testing"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ontio/ontology-crypto/keypair"
+	"github.com/polynetwork/poly/account"
+	"github.com/polynetwork/poly/common"
+	vconfig "github.com/polynetwork/poly/consensus/vbft/config"
+	"github.com/polynetwork/poly/core/genesis"
+	"github.com/polynetwork/poly/core/states"
+	"github.com/polynetwork/poly/core/store/leveldbstore"
+	"github.com/polynetwork/poly/core/store/overlaydb"
+	"github.com/polynetwork/poly/core/types"
+	"github.com/polynetwork/poly/native"
+	"github.com/polynetwork/poly/native/service/governance/node_manager"
+	scom "github.com/polynetwork/poly/native/service/header_sync/common"
+	"github.com/polynetwork/poly/native/service/utils"
+	"github.com/polynetwork/poly/native/storage"
+	"gotest.tools/assert"
+)
+
+const testChainID = 102
+
+var acct = account.NewAccount("")
+
+func init() {
+	genesis.GenesisBookkeepers = []keypair.PublicKey{acct.PublicKey}
+}
+
+func newNative(args []byte, tx *types.Transaction, db *storage.CacheDB) *native.NativeService {
+	if db == nil {
+		store, _ := leveldbstore.NewMemLevelDBStore()
+		db = storage.NewCacheDB(overlaydb.NewOverlayDB(store))
+		sink := common.NewZeroCopySink(nil)
+		view := &node_manager.GovernanceView{TxHash: common.UINT256_EMPTY}
+		view.Serialization(sink)
+		db.Put(utils.ConcatKey(utils.NodeManagerContractAddress, []byte(node_manager.GOVERNANCE_VIEW)), states.GenRawStorageItem(sink.Bytes()))
+
+		peerPoolMap := &node_manager.PeerPoolMap{
+			PeerPoolMap: map[string]*node_manager.PeerPoolItem{
+				vconfig.PubkeyID(acct.PublicKey): {
+					Address:    acct.Address,
+					Status:     node_manager.ConsensusStatus,
+					PeerPubkey: vconfig.PubkeyID(acct.PublicKey),
+				},
+			},
+		}
+		sink.Reset()
+		peerPoolMap.Serialization(sink)
+		db.Put(utils.ConcatKey(utils.NodeManagerContractAddress, []byte(node_manager.PEER_POOL), utils.GetUint32Bytes(0)),
+			states.GenRawStorageItem(sink.Bytes()))
+	}
+	service, _ := native.NewNativeService(db, tx, 0, 0, common.Uint256{0}, 0, args, false)
+	return service
+}
+
+func TestHeaderHash(t *testing.T) {
+	// the genesis header of polkadot
+	header := &Header{
+		StateRoot:      ecommon.HexToHash("0x29d0d972cd27cbc511e9589fcb7a4506d5eb6a9e8df205f00472e5ab354a4e17"),
+		ExtrinsicsRoot: ecommon.HexToHash("0x03170a2e7597b7b7e3d84c05391d139a62b157e78786d8c082f29dcf4c111314"),
+	}
+	assert.Equal(t, header.Hash().Hex(), "0x91b171bb158e2d3848fa23a9f1c25182fb8e20313b2c1eb49219da7a70ce90c3")
+
+	header = &Header{
+		ParentHash: header.Hash(),
+		Number:     1 << 20,
+		Digest: []DigestItem{
+			{Kind: DIGEST_PRE_RUNTIME, Engine: [4]byte{'B', 'A', 'B', 'E'}, Data: []byte{1, 2}},
+			{Kind: DIGEST_CONSENSUS, Engine: GrandpaEngineID, Data: []byte{GRANDPA_PAUSE, 1, 0, 0, 0}},
+			{Kind: DIGEST_OTHER, Data: []byte{3}},
+			{Kind: DIGEST_RUNTIME_ENVIRONMENT_UPDATED},
+			{Kind: DIGEST_SEAL, Engine: [4]byte{'B', 'A', 'B', 'E'}, Data: make([]byte, 64)},
+		},
+	}
+	decoded, err := DecodeHeader(header.Encode())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, decoded, header)
+	_, err = DecodeHeader(append(header.Encode(), 0))
+	assert.ErrorContains(t, err, "trailing")
+	_, err = DecodeHeader(header.Encode()[:100])
+	assert.ErrorContains(t, err, "unexpected end")
+}
+
+type testChain struct {
+	t       *testing.T
+	db      *storage.CacheDB
+	keys    []ed25519.PrivateKey
+	setID   uint64
+	headers []*Header
+}
+
+func newAuthorities(n int) ([]ed25519.PrivateKey, []Authority) {
+	var keys []ed25519.PrivateKey
+	var authorities []Authority
+	for i := 0; i < n; i++ {
+		pub, priv, _ := ed25519.GenerateKey(nil)
+		keys = append(keys, priv)
+		authorities = append(authorities, Authority{Key: hexutil.Bytes(pub), Weight: 1})
+	}
+	return keys, authorities
+}
+
+func newTestChain(t *testing.T, n int) *testChain {
+	chain := &testChain{t: t, setID: 3}
+	var authorities []Authority
+	chain.keys, authorities = newAuthorities(n)
+	genesisHeader := &Header{Number: 100, StateRoot: ecommon.Hash{100}}
+	raw, _ := json.Marshal(&GenesisHeader{Header: genesisHeader.Encode(), SetID: chain.setID, Authorities: authorities})
+	param := &scom.SyncGenesisHeaderParam{ChainID: testChainID, GenesisHeader: raw}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	service := newNative(sink.Bytes(), &types.Transaction{SignedAddr: []common.Address{acct.Address}}, nil)
+	assert.NilError(t, NewHandler().SyncGenesisHeader(service))
+	chain.db = service.GetCacheDB()
+	chain.headers = []*Header{genesisHeader}
+	return chain
+}
+
+// extend adds a header to the chain, logs are GRANDPA consensus logs
+func (this *testChain) extend(logs ...[]byte) *Header {
+	parent := this.headers[len(this.headers)-1]
+	header := &Header{ParentHash: parent.Hash(), Number: parent.Number + 1, StateRoot: ecommon.Hash{byte(parent.Number + 1)}}
+	for _, log := range logs {
+		header.Digest = append(header.Digest, DigestItem{Kind: DIGEST_CONSENSUS, Engine: GrandpaEngineID, Data: log})
+	}
+	this.headers = append(this.headers, header)
+	return header
+}
+
+func (this *testChain) header(number uint32) *Header {
+	return this.headers[number-this.headers[0].Number]
+}
+
+func changeLog(forced bool, authorities []Authority, delay uint32) []byte {
+	log := []byte{GRANDPA_SCHEDULED_CHANGE}
+	if forced {
+		log = appendUint32([]byte{GRANDPA_FORCED_CHANGE}, 0)
+	}
+	log = appendCompact(log, uint64(len(authorities)))
+	for _, a := range authorities {
+		log = appendUint64(append(log, a.Key...), a.Weight)
+	}
+	return appendUint32(log, delay)
+}
+
+// justify signs a commit for target with the keys at signers
+func justify(target *Header, setID uint64, keys []ed25519.PrivateKey, signers ...int) *Justification {
+	j := &Justification{Round: 7, TargetHash: target.Hash(), TargetNumber: target.Number}
+	for _, i := range signers {
+		vote := SignedPrecommit{Precommit: Precommit{TargetHash: j.TargetHash, TargetNumber: j.TargetNumber}}
+		copy(vote.ID[:], keys[i].Public().(ed25519.PublicKey))
+		copy(vote.Signature[:], ed25519.Sign(keys[i], precommitSignBytes(&vote.Precommit, j.Round, setID)))
+		j.Precommits = append(j.Precommits, vote)
+	}
+	return j
+}
+
+// finalize submits the headers from from to to finalized by j
+func (this *testChain) finalize(signer common.Address, from, to uint32, j *Justification) error {
+	proof := &FinalityProof{Justification: j.Encode()}
+	for n := from; n <= to; n++ {
+		proof.Headers = append(proof.Headers, this.header(n).Encode())
+	}
+	raw, _ := json.Marshal(proof)
+	param := &scom.SyncBlockHeaderParam{ChainID: testChainID, Address: signer, Headers: [][]byte{raw}}
+	sink := common.NewZeroCopySink(nil)
+	param.Serialization(sink)
+	_, err := NewHandler().SyncBlockHeader(newNative(sink.Bytes(), &types.Transaction{SignedAddr: []common.Address{signer}}, this.db))
+	return err
+}
+
+func (this *testChain) state() *FinalizedState {
+	state, err := GetFinalizedState(newNative(nil, &types.Transaction{}, this.db), testChainID)
+	assert.NilError(this.t, err)
+	return state
+}
+
+func TestSyncFinalityProof(t *testing.T) {
+	chain := newTestChain(t, 4)
+	other := account.NewAccount("").Address
+	for i := 0; i < 3; i++ {
+		chain.extend()
+	}
+
+	// 3 of 4 are needed
+	assert.ErrorContains(t, chain.finalize(other, 101, 103, justify(chain.header(103), 3, chain.keys, 0, 1)), "not enough votes")
+	assert.ErrorContains(t, chain.finalize(other, 101, 103, justify(chain.header(103), 3, chain.keys, 0, 1, 1)), "voted twice")
+	assert.ErrorContains(t, chain.finalize(other, 101, 103, justify(chain.header(103), 4, chain.keys, 0, 1, 2)), "invalid signature")
+	assert.ErrorContains(t, chain.finalize(other, 101, 103, justify(chain.header(102), 3, chain.keys, 0, 1, 2)), "justification is for block 102")
+	assert.ErrorContains(t, chain.finalize(other, 102, 103, justify(chain.header(103), 3, chain.keys, 0, 1, 2)), "does not extend block 100")
+	strangers, _ := newAuthorities(1)
+	assert.ErrorContains(t, chain.finalize(other, 101, 103, justify(chain.header(103), 3, strangers, 0)), "not from an authority")
+
+	// precommits for descendants are linked by the ancestries
+	j := justify(chain.header(103), 3, chain.keys, 0, 1)
+	descendant := justify(chain.header(103), 3, chain.keys, 2)
+	chain.extend()
+	descendant.Precommits[0].Precommit = Precommit{TargetHash: chain.header(104).Hash(), TargetNumber: 104}
+	copy(descendant.Precommits[0].Signature[:], ed25519.Sign(chain.keys[2], precommitSignBytes(&descendant.Precommits[0].Precommit, 7, 3)))
+	j.Precommits = append(j.Precommits, descendant.Precommits[0])
+	assert.ErrorContains(t, chain.finalize(other, 101, 103, j), "not for a descendant")
+	j.VotesAncestries = []*Header{chain.header(104), chain.header(102)}
+	assert.ErrorContains(t, chain.finalize(other, 101, 103, j), "unused ancestries")
+	j.VotesAncestries = j.VotesAncestries[:1]
+	assert.NilError(t, chain.finalize(other, 101, 103, j))
+	state := chain.state()
+	assert.Equal(t, state.Height, uint32(103))
+	assert.Equal(t, state.Hash, chain.header(103).Hash())
+	root, err := GetStateRoot(newNative(nil, &types.Transaction{}, chain.db), testChainID, 102)
+	assert.NilError(t, err)
+	assert.Equal(t, root, ecommon.Hash{102})
+
+	// finalized proofs are skipped
+	assert.NilError(t, chain.finalize(other, 101, 102, justify(chain.header(102), 3, chain.keys, 0, 1, 2)))
+	assert.Equal(t, chain.state().Height, uint32(103))
+
+	// a scheduled change announced at 105 is enacted when 106 is finalized by the current set
+	newKeys, nextAuthorities := newAuthorities(3)
+	chain.extend(changeLog(false, nextAuthorities, 1))
+	chain.extend()
+	chain.extend()
+	assert.ErrorContains(t, chain.finalize(other, 104, 107, justify(chain.header(107), 3, chain.keys, 0, 1, 2)), "must be finalized first")
+	assert.NilError(t, chain.finalize(other, 104, 105, justify(chain.header(105), 3, chain.keys, 0, 1, 2)))
+	state = chain.state()
+	assert.Equal(t, state.SetID, uint64(3))
+	assert.Equal(t, state.Pending.EnactHeight, uint32(106))
+	assert.NilError(t, chain.finalize(other, 106, 106, justify(chain.header(106), 3, chain.keys, 0, 1, 2)))
+	state = chain.state()
+	assert.Equal(t, state.SetID, uint64(4))
+	assert.Assert(t, state.Pending == nil)
+	assert.DeepEqual(t, state.Authorities, nextAuthorities)
+	assert.ErrorContains(t, chain.finalize(other, 107, 107, justify(chain.header(107), 4, chain.keys, 0, 1, 2)), "not from an authority")
+	assert.NilError(t, chain.finalize(other, 107, 107, justify(chain.header(107), 4, newKeys, 0, 1, 2)))
+
+	// a forced change is only accepted from the operators and its target is
+	// finalized by the new set
+	forcedKeys, forcedAuthorities := newAuthorities(1)
+	chain.extend(changeLog(true, forcedAuthorities, 2))
+	chain.extend()
+	chain.extend()
+	assert.ErrorContains(t, chain.finalize(other, 108, 110, justify(chain.header(110), 5, forcedKeys, 0)), "checkWitness error")
+	assert.ErrorContains(t, chain.finalize(acct.Address, 108, 110, justify(chain.header(110), 4, newKeys, 0, 1, 2)), "invalid justification of set 5")
+	assert.NilError(t, chain.finalize(acct.Address, 108, 110, justify(chain.header(110), 5, forcedKeys, 0)))
+	state = chain.state()
+	assert.Equal(t, state.Height, uint32(110))
+	assert.Equal(t, state.SetID, uint64(5))
+
+	// one change at a time
+	chain.extend(changeLog(false, nextAuthorities, 5), changeLog(false, forcedAuthorities, 5))
+	assert.ErrorContains(t, chain.finalize(other, 111, 111, justify(chain.header(111), 5, forcedKeys, 0)), "is pending")
+}
@@ -0,0 +1,210 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package substrate
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"golang.org/x/crypto/blake2b"
+)
+
+var errUnexpectedEOF = errors.New("unexpected end of SCALE data")
+
+// scaleReader decodes the SCALE codec of substrate
+type scaleReader struct {
+	buf []byte
+}
+
+func (this *scaleReader) done() bool {
+	return len(this.buf) == 0
+}
+
+func (this *scaleReader) byte() (byte, error) {
+	if len(this.buf) == 0 {
+		return 0, errUnexpectedEOF
+	}
+	b := this.buf[0]
+	this.buf = this.buf[1:]
+	return b, nil
+}
+
+func (this *scaleReader) bytes(n uint64) ([]byte, error) {
+	if uint64(len(this.buf)) < n {
+		return nil, errUnexpectedEOF
+	}
+	b := this.buf[:n]
+	this.buf = this.buf[n:]
+	return b, nil
+}
+
+func (this *scaleReader) hash() (hash ecommon.Hash, err error) {
+	b, err := this.bytes(ecommon.HashLength)
+	if err == nil {
+		copy(hash[:], b)
+	}
+	return
+}
+
+func (this *scaleReader) uint16() (uint16, error) {
+	b, err := this.bytes(2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint16(b), nil
+}
+
+func (this *scaleReader) uint32() (uint32, error) {
+	b, err := this.bytes(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b), nil
+}
+
+func (this *scaleReader) uint64() (uint64, error) {
+	b, err := this.bytes(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(b), nil
+}
+
+// compact decodes a compact integer, only the canonical encoding is accepted
+// so that hashes of re-encoded data match
+func (this *scaleReader) compact() (uint64, error) {
+	b, err := this.byte()
+	if err != nil {
+		return 0, err
+	}
+	var v uint64
+	switch b & 3 {
+	case 0:
+		return uint64(b >> 2), nil
+	case 1:
+		next, err := this.byte()
+		if err != nil {
+			return 0, err
+		}
+		v = uint64(b)>>2 | uint64(next)<<6
+		if v < 1<<6 {
+			return 0, fmt.Errorf("non canonical compact %d", v)
+		}
+	case 2:
+		rest, err := this.bytes(3)
+		if err != nil {
+			return 0, err
+		}
+		v = uint64(binary.LittleEndian.Uint32(append([]byte{b}, rest...)) >> 2)
+		if v < 1<<14 {
+			return 0, fmt.Errorf("non canonical compact %d", v)
+		}
+	case 3:
+		n := uint64(b>>2) + 4
+		if n > 8 {
+			return 0, fmt.Errorf("compact of %d bytes overflows uint64", n)
+		}
+		raw, err := this.bytes(n)
+		if err != nil {
+			return 0, err
+		}
+		for i := len(raw) - 1; i >= 0; i-- {
+			v = v<<8 | uint64(raw[i])
+		}
+		if v < 1<<30 || (n > 4 && v>>((n-1)*8) == 0) {
+			return 0, fmt.Errorf("non canonical compact %d", v)
+		}
+	}
+	return v, nil
+}
+
+// varBytes decodes a Vec<u8>
+func (this *scaleReader) varBytes() ([]byte, error) {
+	n, err := this.compact()
+	if err != nil {
+		return nil, err
+	}
+	return this.bytes(n)
+}
+
+// length decodes the length of a vector whose items take at least min bytes
+func (this *scaleReader) length(min int) (int, error) {
+	n, err := this.compact()
+	if err != nil {
+		return 0, err
+	}
+	if n > uint64(len(this.buf)/min) {
+		return 0, errUnexpectedEOF
+	}
+	return int(n), nil
+}
+
+func appendCompact(buf []byte, v uint64) []byte {
+	switch {
+	case v < 1<<6:
+		return append(buf, byte(v<<2))
+	case v < 1<<14:
+		return append(buf, byte(v<<2|1), byte(v>>6))
+	case v < 1<<30:
+		var b [4]byte
+		binary.LittleEndian.PutUint32(b[:], uint32(v<<2|2))
+		return append(buf, b[:]...)
+	}
+	n := (bits.Len64(v) + 7) / 8
+	buf = append(buf, byte((n-4)<<2|3))
+	for i := 0; i < n; i++ {
+		buf = append(buf, byte(v>>(8*i)))
+	}
+	return buf
+}
+
+func appendVarBytes(buf, v []byte) []byte {
+	return append(appendCompact(buf, uint64(len(v))), v...)
+}
+
+func appendUint32(buf []byte, v uint32) []byte {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	return append(buf, b[:]...)
+}
+
+func appendUint64(buf []byte, v uint64) []byte {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	return append(buf, b[:]...)
+}
+
+func blake2b256(data []byte) ecommon.Hash {
+	return blake2b.Sum256(data)
+}
+
+// DecodeScaleBytes decodes a SCALE encoded Vec<u8> that makes up all of buf
+func DecodeScaleBytes(buf []byte) ([]byte, error) {
+	r := &scaleReader{buf}
+	v, err := r.varBytes()
+	if err != nil {
+		return nil, err
+	}
+	if !r.done() {
+		return nil, fmt.Errorf("%d trailing bytes", len(r.buf))
+	}
+	return v, nil
+}
@@ -0,0 +1,453 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package substrate
+
+import (
+	"bytes"
+	"fmt"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/polynetwork/poly/common"
+)
+
+// digest item kinds
+const (
+	DIGEST_OTHER                       = 0
+	DIGEST_CONSENSUS                   = 4
+	DIGEST_SEAL                        = 5
+	DIGEST_PRE_RUNTIME                 = 6
+	DIGEST_RUNTIME_ENVIRONMENT_UPDATED = 8
+)
+
+// GRANDPA consensus logs
+const (
+	GRANDPA_SCHEDULED_CHANGE = 1
+	GRANDPA_FORCED_CHANGE    = 2
+	GRANDPA_ON_DISABLED      = 3
+	GRANDPA_PAUSE            = 4
+	GRANDPA_RESUME           = 5
+)
+
+// GrandpaEngineID tags the consensus digests of GRANDPA
+var GrandpaEngineID = [4]byte{'F', 'R', 'N', 'K'}
+
+// DigestItem is a log of a header, Engine is empty for DIGEST_OTHER and
+// DIGEST_RUNTIME_ENVIRONMENT_UPDATED
+type DigestItem struct {
+	Kind   byte
+	Engine [4]byte
+	Data   []byte
+}
+
+// Header is a substrate header with a u32 block number
+type Header struct {
+	ParentHash     ecommon.Hash
+	Number         uint32
+	StateRoot      ecommon.Hash
+	ExtrinsicsRoot ecommon.Hash
+	Digest         []DigestItem
+}
+
+// DecodeHeader decodes a SCALE encoded header that makes up all of buf
+func DecodeHeader(buf []byte) (*Header, error) {
+	r := &scaleReader{buf}
+	header, err := decodeHeader(r)
+	if err != nil {
+		return nil, fmt.Errorf("DecodeHeader, %v", err)
+	}
+	if !r.done() {
+		return nil, fmt.Errorf("DecodeHeader, %d trailing bytes", len(r.buf))
+	}
+	return header, nil
+}
+
+func decodeHeader(r *scaleReader) (header *Header, err error) {
+	header = new(Header)
+	if header.ParentHash, err = r.hash(); err != nil {
+		return nil, err
+	}
+	number, err := r.compact()
+	if err != nil {
+		return nil, err
+	}
+	if number > 1<<32-1 {
+		return nil, fmt.Errorf("block number %d overflows u32", number)
+	}
+	header.Number = uint32(number)
+	if header.StateRoot, err = r.hash(); err != nil {
+		return nil, err
+	}
+	if header.ExtrinsicsRoot, err = r.hash(); err != nil {
+		return nil, err
+	}
+	n, err := r.length(1)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < n; i++ {
+		var item DigestItem
+		if item.Kind, err = r.byte(); err != nil {
+			return nil, err
+		}
+		switch item.Kind {
+		case DIGEST_OTHER:
+			item.Data, err = r.varBytes()
+		case DIGEST_CONSENSUS, DIGEST_SEAL, DIGEST_PRE_RUNTIME:
+			var engine []byte
+			if engine, err = r.bytes(4); err == nil {
+				copy(item.Engine[:], engine)
+				item.Data, err = r.varBytes()
+			}
+		case DIGEST_RUNTIME_ENVIRONMENT_UPDATED:
+		default:
+			err = fmt.Errorf("unknown digest item %d", item.Kind)
+		}
+		if err != nil {
+			return nil, err
+		}
+		header.Digest = append(header.Digest, item)
+	}
+	return header, nil
+}
+
+// Encode returns the SCALE encoding of the header
+func (this *Header) Encode() []byte {
+	buf := append([]byte{}, this.ParentHash[:]...)
+	buf = appendCompact(buf, uint64(this.Number))
+	buf = append(buf, this.StateRoot[:]...)
+	buf = append(buf, this.ExtrinsicsRoot[:]...)
+	buf = appendCompact(buf, uint64(len(this.Digest)))
+	for _, item := range this.Digest {
+		buf = append(buf, item.Kind)
+		switch item.Kind {
+		case DIGEST_OTHER:
+			buf = appendVarBytes(buf, item.Data)
+		case DIGEST_CONSENSUS, DIGEST_SEAL, DIGEST_PRE_RUNTIME:
+			buf = appendVarBytes(append(buf, item.Engine[:]...), item.Data)
+		}
+	}
+	return buf
+}
+
+// Hash is the blake2b hash of the encoded header
+func (this *Header) Hash() ecommon.Hash {
+	return blake2b256(this.Encode())
+}
+
+// Authority is a GRANDPA voter with its ed25519 key
+type Authority struct {
+	Key    hexutil.Bytes `json:"key"`
+	Weight uint64        `json:"weight"`
+}
+
+// AuthorityChange is a change of the authority set announced in a header,
+// it is enacted Delay blocks after the header
+type AuthorityChange struct {
+	Authorities []Authority
+	Delay       uint32
+	Forced      bool
+	// MedianLastFinalized of a forced change is where finality was stalled
+	MedianLastFinalized uint32
+}
+
+// grandpaChanges returns the authority set changes announced in the header
+func (this *Header) grandpaChanges() ([]*AuthorityChange, error) {
+	var changes []*AuthorityChange
+	for _, item := range this.Digest {
+		if item.Kind != DIGEST_CONSENSUS || item.Engine != GrandpaEngineID {
+			continue
+		}
+		r := &scaleReader{item.Data}
+		kind, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		change := new(AuthorityChange)
+		switch kind {
+		case GRANDPA_FORCED_CHANGE:
+			change.Forced = true
+			if change.MedianLastFinalized, err = r.uint32(); err != nil {
+				return nil, err
+			}
+			fallthrough
+		case GRANDPA_SCHEDULED_CHANGE:
+			n, err := r.length(40)
+			if err != nil {
+				return nil, err
+			}
+			// length checked that the fixed size items are there
+			for i := 0; i < n; i++ {
+				key, _ := r.bytes(32)
+				weight, _ := r.uint64()
+				change.Authorities = append(change.Authorities, Authority{Key: key, Weight: weight})
+			}
+			if change.Delay, err = r.uint32(); err != nil {
+				return nil, err
+			}
+			if err := validateAuthorities(change.Authorities); err != nil {
+				return nil, err
+			}
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+func validateAuthorities(authorities []Authority) error {
+	if len(authorities) == 0 {
+		return fmt.Errorf("empty authority set")
+	}
+	for i, a := range authorities {
+		if len(a.Key) != 32 {
+			return fmt.Errorf("invalid authority key %x", a.Key)
+		}
+		if a.Weight == 0 {
+			return fmt.Errorf("authority %x has no weight", a.Key)
+		}
+		for _, b := range authorities[:i] {
+			if bytes.Equal(a.Key, b.Key) {
+				return fmt.Errorf("duplicated authority %x", a.Key)
+			}
+		}
+	}
+	return nil
+}
+
+// Precommit is a GRANDPA vote for a block and its ancestors
+type Precommit struct {
+	TargetHash   ecommon.Hash
+	TargetNumber uint32
+}
+
+// SignedPrecommit is a precommit with the signature of authority ID
+type SignedPrecommit struct {
+	Precommit Precommit
+	Signature [64]byte
+	ID        [32]byte
+}
+
+// Justification is the commit of a GRANDPA round finalizing its target,
+// VotesAncestries link the precommits for descendants to the target
+type Justification struct {
+	Round           uint64
+	TargetHash      ecommon.Hash
+	TargetNumber    uint32
+	Precommits      []SignedPrecommit
+	VotesAncestries []*Header
+}
+
+// DecodeJustification decodes a SCALE encoded justification that makes up all of buf
+func DecodeJustification(buf []byte) (*Justification, error) {
+	r := &scaleReader{buf}
+	justification, err := decodeJustification(r)
+	if err != nil {
+		return nil, fmt.Errorf("DecodeJustification, %v", err)
+	}
+	if !r.done() {
+		return nil, fmt.Errorf("DecodeJustification, %d trailing bytes", len(r.buf))
+	}
+	return justification, nil
+}
+
+func decodeJustification(r *scaleReader) (j *Justification, err error) {
+	j = new(Justification)
+	if j.Round, err = r.uint64(); err != nil {
+		return nil, err
+	}
+	if j.TargetHash, err = r.hash(); err != nil {
+		return nil, err
+	}
+	if j.TargetNumber, err = r.uint32(); err != nil {
+		return nil, err
+	}
+	n, err := r.length(32 + 4 + 64 + 32)
+	if err != nil {
+		return nil, err
+	}
+	// length checked that the fixed size items are there
+	for i := 0; i < n; i++ {
+		var vote SignedPrecommit
+		vote.Precommit.TargetHash, _ = r.hash()
+		vote.Precommit.TargetNumber, _ = r.uint32()
+		signature, _ := r.bytes(64)
+		copy(vote.Signature[:], signature)
+		id, _ := r.bytes(32)
+		copy(vote.ID[:], id)
+		j.Precommits = append(j.Precommits, vote)
+	}
+	if n, err = r.length(32 + 1 + 32 + 32 + 1); err != nil {
+		return nil, err
+	}
+	for i := 0; i < n; i++ {
+		header, err := decodeHeader(r)
+		if err != nil {
+			return nil, err
+		}
+		j.VotesAncestries = append(j.VotesAncestries, header)
+	}
+	return j, nil
+}
+
+// Encode returns the SCALE encoding of the justification
+func (this *Justification) Encode() []byte {
+	buf := appendUint64(nil, this.Round)
+	buf = appendUint32(append(buf, this.TargetHash[:]...), this.TargetNumber)
+	buf = appendCompact(buf, uint64(len(this.Precommits)))
+	for _, vote := range this.Precommits {
+		buf = appendUint32(append(buf, vote.Precommit.TargetHash[:]...), vote.Precommit.TargetNumber)
+		buf = append(append(buf, vote.Signature[:]...), vote.ID[:]...)
+	}
+	buf = appendCompact(buf, uint64(len(this.VotesAncestries)))
+	for _, header := range this.VotesAncestries {
+		buf = append(buf, header.Encode()...)
+	}
+	return buf
+}
+
+// precommitSignBytes is the SCALE encoding of (Message::Precommit, round, set id)
+func precommitSignBytes(precommit *Precommit, round, setID uint64) []byte {
+	buf := append([]byte{1}, precommit.TargetHash[:]...)
+	buf = appendUint32(buf, precommit.TargetNumber)
+	return appendUint64(appendUint64(buf, round), setID)
+}
+
+// GenesisHeader is a finalized header with the authority set finalizing its descendants
+type GenesisHeader struct {
+	Header      hexutil.Bytes `json:"header"`
+	SetID       uint64        `json:"set_id"`
+	Authorities []Authority   `json:"authorities"`
+}
+
+// FinalityProof finalizes the last of Headers with Justification, the
+// headers descend from the latest finalized header without gaps so that
+// the authority set changes they announce are seen
+type FinalityProof struct {
+	Headers       []hexutil.Bytes `json:"headers"`
+	Justification hexutil.Bytes   `json:"justification"`
+}
+
+// PendingChange is an announced authority set change not enacted yet
+type PendingChange struct {
+	Authorities []Authority
+	EnactHeight uint32
+	Forced      bool
+}
+
+// FinalizedState is the latest finalized header with the authority set
+// finalizing its descendants
+type FinalizedState struct {
+	Height      uint32
+	Hash        ecommon.Hash
+	StateRoot   ecommon.Hash
+	SetID       uint64
+	Authorities []Authority
+	Pending     *PendingChange
+}
+
+func serializeAuthorities(sink *common.ZeroCopySink, authorities []Authority) {
+	sink.WriteVarUint(uint64(len(authorities)))
+	for _, a := range authorities {
+		sink.WriteVarBytes(a.Key)
+		sink.WriteUint64(a.Weight)
+	}
+}
+
+func deserializeAuthorities(source *common.ZeroCopySource) ([]Authority, error) {
+	n, eof := source.NextVarUint()
+	if eof {
+		return nil, fmt.Errorf("deserialize authority count failed")
+	}
+	var authorities []Authority
+	for i := uint64(0); i < n; i++ {
+		key, eof := source.NextVarBytes()
+		if eof {
+			return nil, fmt.Errorf("deserialize authority key failed")
+		}
+		weight, eof := source.NextUint64()
+		if eof {
+			return nil, fmt.Errorf("deserialize authority weight failed")
+		}
+		authorities = append(authorities, Authority{Key: key, Weight: weight})
+	}
+	return authorities, nil
+}
+
+func (this *FinalizedState) Serialization(sink *common.ZeroCopySink) {
+	sink.WriteUint32(this.Height)
+	sink.WriteHash(common.Uint256(this.Hash))
+	sink.WriteHash(common.Uint256(this.StateRoot))
+	sink.WriteUint64(this.SetID)
+	serializeAuthorities(sink, this.Authorities)
+	sink.WriteBool(this.Pending != nil)
+	if this.Pending != nil {
+		serializeAuthorities(sink, this.Pending.Authorities)
+		sink.WriteUint32(this.Pending.EnactHeight)
+		sink.WriteBool(this.Pending.Forced)
+	}
+}
+
+func (this *FinalizedState) Deserialization(source *common.ZeroCopySource) error {
+	var eof bool
+	this.Height, eof = source.NextUint32()
+	if eof {
+		return fmt.Errorf("deserialize Height of FinalizedState failed")
+	}
+	hash, eof := source.NextHash()
+	if eof {
+		return fmt.Errorf("deserialize Hash of FinalizedState failed")
+	}
+	this.Hash = ecommon.Hash(hash)
+	root, eof := source.NextHash()
+	if eof {
+		return fmt.Errorf("deserialize StateRoot of FinalizedState failed")
+	}
+	this.StateRoot = ecommon.Hash(root)
+	this.SetID, eof = source.NextUint64()
+	if eof {
+		return fmt.Errorf("deserialize SetID of FinalizedState failed")
+	}
+	var err error
+	if this.Authorities, err = deserializeAuthorities(source); err != nil {
+		return err
+	}
+	pending, eof := source.NextBool()
+	if eof {
+		return fmt.Errorf("deserialize Pending of FinalizedState failed")
+	}
+	if pending {
+		this.Pending = new(PendingChange)
+		if this.Pending.Authorities, err = deserializeAuthorities(source); err != nil {
+			return err
+		}
+		this.Pending.EnactHeight, eof = source.NextUint32()
+		if eof {
+			return fmt.Errorf("deserialize EnactHeight of FinalizedState failed")
+		}
+		this.Pending.Forced, eof = source.NextBool()
+		if eof {
+			return fmt.Errorf("deserialize Forced of FinalizedState failed")
+		}
+	}
+	return nil
+}
+
+// ExtraInfo configures where the cross chain transactions of a substrate chain are stored
+type ExtraInfo struct {
+	KeyPrefix hexutil.Bytes // storage key prefix of the cross chain transactions, twox128(pallet)++twox128(item)
+}
@@ -0,0 +1,194 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package substrate
+
+import (
+	"fmt"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+)
+
+// trie node kinds of the substrate node header, the hashed value kinds are
+// those of state version 1
+const (
+	nodeEmpty = iota
+	nodeLeaf
+	nodeBranch
+	nodeBranchWithValue
+	nodeHashedLeaf
+	nodeHashedBranch
+)
+
+// trieNode is a decoded node of the base 16 patricia merkle trie of substrate
+type trieNode struct {
+	kind     int
+	partial  []byte // nibbles
+	value    []byte
+	hashed   bool // value is the hash of the value
+	children [16][]byte
+}
+
+func (this *trieNode) hasValue() bool {
+	return this.kind == nodeLeaf || this.kind == nodeBranchWithValue || this.kind == nodeHashedLeaf || this.kind == nodeHashedBranch
+}
+
+func (this *trieNode) isBranch() bool {
+	return this.kind == nodeBranch || this.kind == nodeBranchWithValue || this.kind == nodeHashedBranch
+}
+
+func decodeTrieNode(buf []byte) (*trieNode, error) {
+	r := &scaleReader{buf}
+	b, err := r.byte()
+	if err != nil {
+		return nil, err
+	}
+	node := new(trieNode)
+	var mask byte
+	switch {
+	case b == 0:
+		if !r.done() {
+			return nil, fmt.Errorf("empty node with data")
+		}
+		return node, nil
+	case b>>6 == 1:
+		node.kind, mask = nodeLeaf, 0x3f
+	case b>>6 == 2:
+		node.kind, mask = nodeBranch, 0x3f
+	case b>>6 == 3:
+		node.kind, mask = nodeBranchWithValue, 0x3f
+	case b>>5 == 1:
+		node.kind, mask = nodeHashedLeaf, 0x1f
+	case b>>4 == 1:
+		node.kind, mask = nodeHashedBranch, 0x0f
+	default:
+		return nil, fmt.Errorf("invalid node header %x", b)
+	}
+	// the nibble count continues in the next bytes while they are all ones
+	count := uint64(b & mask)
+	if count == uint64(mask) {
+		for {
+			next, err := r.byte()
+			if err != nil {
+				return nil, err
+			}
+			count += uint64(next)
+			if next != 255 {
+				break
+			}
+		}
+	}
+	packed, err := r.bytes((count + 1) / 2)
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < count; i++ {
+		// an odd count pads the first byte
+		j := i + count%2
+		node.partial = append(node.partial, packed[j/2]>>(4*(1-j%2))&0x0f)
+	}
+	var bitmap uint16
+	if node.isBranch() {
+		if bitmap, err = r.uint16(); err != nil {
+			return nil, err
+		}
+	}
+	if node.hasValue() {
+		if node.kind == nodeHashedLeaf || node.kind == nodeHashedBranch {
+			node.hashed = true
+			node.value, err = r.bytes(ecommon.HashLength)
+		} else {
+			node.value, err = r.varBytes()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	for i := 0; i < 16; i++ {
+		if bitmap&(1<<i) != 0 {
+			if node.children[i], err = r.varBytes(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if !r.done() {
+		return nil, fmt.Errorf("%d trailing bytes of node", len(r.buf))
+	}
+	return node, nil
+}
+
+// VerifyStorageProof returns the value of key in the state committed by root,
+// proof is the list of encoded nodes returned by state_getReadProof. A nil
+// value means that the key is proven to be absent.
+func VerifyStorageProof(root ecommon.Hash, key []byte, proof [][]byte) ([]byte, error) {
+	db := make(map[ecommon.Hash][]byte, len(proof))
+	for _, node := range proof {
+		db[blake2b256(node)] = node
+	}
+	var nibbles []byte
+	for _, b := range key {
+		nibbles = append(nibbles, b>>4, b&0x0f)
+	}
+	encoded, ok := db[root]
+	if !ok {
+		return nil, fmt.Errorf("VerifyStorageProof, root node %s is not in the proof", root.Hex())
+	}
+	for {
+		node, err := decodeTrieNode(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("VerifyStorageProof, %v", err)
+		}
+		if node.kind == nodeEmpty {
+			return nil, nil
+		}
+		if len(nibbles) < len(node.partial) {
+			return nil, nil
+		}
+		for i, n := range node.partial {
+			if nibbles[i] != n {
+				return nil, nil
+			}
+		}
+		nibbles = nibbles[len(node.partial):]
+		if len(nibbles) == 0 || !node.isBranch() {
+			if len(nibbles) != 0 || !node.hasValue() {
+				return nil, nil
+			}
+			if !node.hashed {
+				return node.value, nil
+			}
+			value, ok := db[ecommon.BytesToHash(node.value)]
+			if !ok {
+				return nil, fmt.Errorf("VerifyStorageProof, hashed value %x is not in the proof", node.value)
+			}
+			return value, nil
+		}
+		child := node.children[nibbles[0]]
+		nibbles = nibbles[1:]
+		switch {
+		case child == nil:
+			return nil, nil
+		case len(child) == ecommon.HashLength:
+			if encoded, ok = db[ecommon.BytesToHash(child)]; !ok {
+				return nil, fmt.Errorf("VerifyStorageProof, node %x is not in the proof", child)
+			}
+		default:
+			// nodes shorter than a hash are inlined
+			encoded = child
+		}
+	}
+}
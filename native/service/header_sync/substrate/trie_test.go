@@ -0,0 +1,174 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package substrate
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	ecommon "github.com/ethereum/go-ethereum/common"
+	"gotest.tools/assert"
+)
+
+func TestCompact(t *testing.T) {
+	for _, c := range []struct {
+		value   uint64
+		encoded string
+	}{
+		{0, "00"},
+		{1, "04"},
+		{42, "a8"},
+		{69, "1501"},
+		{65535, "feff0300"},
+		{1<<30 - 1, "feffffff"},
+		{1 << 30, "0300000040"},
+		{1<<64 - 1, "13ffffffffffffffff"},
+	} {
+		assert.Equal(t, hex.EncodeToString(appendCompact(nil, c.value)), c.encoded)
+		raw, _ := hex.DecodeString(c.encoded)
+		r := &scaleReader{raw}
+		v, err := r.compact()
+		assert.NilError(t, err)
+		assert.Equal(t, v, c.value)
+		assert.Assert(t, r.done())
+	}
+	for _, encoded := range []string{"0100", "02000000", "0300000000", "07ffffff3f00"} {
+		raw, _ := hex.DecodeString(encoded)
+		_, err := (&scaleReader{raw}).compact()
+		assert.ErrorContains(t, err, "non canonical")
+	}
+	_, err := DecodeScaleBytes([]byte{8, 1})
+	assert.Equal(t, err, errUnexpectedEOF)
+	_, err = DecodeScaleBytes([]byte{4, 1, 2})
+	assert.ErrorContains(t, err, "trailing")
+}
+
+func packNibbles(nibbles []byte) []byte {
+	var packed []byte
+	if len(nibbles)%2 == 1 {
+		packed = append(packed, nibbles[0])
+		nibbles = nibbles[1:]
+	}
+	for i := 0; i < len(nibbles); i += 2 {
+		packed = append(packed, nibbles[i]<<4|nibbles[i+1])
+	}
+	return packed
+}
+
+func nodeHeader(prefix, mask byte, count int) []byte {
+	if count < int(mask) {
+		return []byte{prefix | byte(count)}
+	}
+	header := []byte{prefix | mask}
+	for count -= int(mask); count >= 255; count -= 255 {
+		header = append(header, 255)
+	}
+	return append(header, byte(count))
+}
+
+func leafNode(partial, value []byte) []byte {
+	node := append(nodeHeader(0x40, 0x3f, len(partial)), packNibbles(partial)...)
+	return appendVarBytes(node, value)
+}
+
+// branchNode makes a branch whose children are the encoded nodes in children
+func branchNode(partial, value []byte, children map[byte][]byte) []byte {
+	prefix := byte(0x80)
+	if value != nil {
+		prefix = 0xc0
+	}
+	node := append(nodeHeader(prefix, 0x3f, len(partial)), packNibbles(partial)...)
+	var bitmap uint16
+	for i := range children {
+		bitmap |= 1 << i
+	}
+	node = append(node, byte(bitmap), byte(bitmap>>8))
+	if value != nil {
+		node = appendVarBytes(node, value)
+	}
+	for i := byte(0); i < 16; i++ {
+		if child, ok := children[i]; ok {
+			if len(child) >= ecommon.HashLength {
+				hash := blake2b256(child)
+				child = hash[:]
+			}
+			node = appendVarBytes(node, child)
+		}
+	}
+	return node
+}
+
+func TestVerifyStorageProof(t *testing.T) {
+	long := bytes.Repeat([]byte{0xbb}, 40)
+	leafA := leafNode([]byte{4}, []byte("a"))
+	leafB := leafNode([]byte{6}, long)
+	root := branchNode([]byte{1, 2}, []byte("c"), map[byte][]byte{3: leafA, 5: leafB})
+	rootHash := blake2b256(root)
+	proof := [][]byte{root, leafB}
+
+	for _, c := range []struct {
+		key   string
+		value []byte
+	}{
+		{"1234", []byte("a")},
+		{"1256", long},
+		{"12", []byte("c")},
+		{"1235", nil},
+		{"1277", nil},
+		{"13", nil},
+		{"123400", nil},
+		{"", nil},
+	} {
+		key, _ := hex.DecodeString(c.key)
+		value, err := VerifyStorageProof(rootHash, key, proof)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, value, c.value)
+	}
+	_, err := VerifyStorageProof(rootHash, []byte{0x12, 0x56}, [][]byte{root})
+	assert.ErrorContains(t, err, "is not in the proof")
+	_, err = VerifyStorageProof(blake2b256(leafA), []byte{0x12, 0x56}, proof)
+	assert.ErrorContains(t, err, "root node")
+	_, err = VerifyStorageProof(blake2b256(append(root, 0)), []byte{0x12}, [][]byte{append(root, 0)})
+	assert.ErrorContains(t, err, "trailing")
+
+	// keys of more than 62 nibbles extend the nibble count
+	key := bytes.Repeat([]byte{0x5a}, 40)
+	var nibbles []byte
+	for _, b := range key {
+		nibbles = append(nibbles, b>>4, b&0x0f)
+	}
+	leaf := leafNode(nibbles, []byte("v"))
+	assert.DeepEqual(t, leaf[:2], []byte{0x7f, 80 - 63})
+	value, err := VerifyStorageProof(blake2b256(leaf), key, [][]byte{leaf})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, value, []byte("v"))
+
+	// state version 1 stores the hash of long values
+	valueHash := blake2b256(long)
+	hashed := append(append(nodeHeader(0x20, 0x1f, 3), packNibbles([]byte{1, 2, 3})...), valueHash[:]...)
+	value, err = VerifyStorageProof(blake2b256(hashed), []byte{0x01, 0x23}, [][]byte{hashed, long})
+	assert.NilError(t, err)
+	assert.Assert(t, value == nil)
+	hashed = append(append(nodeHeader(0x20, 0x1f, 4), packNibbles([]byte{0, 1, 2, 3})...), valueHash[:]...)
+	value, err = VerifyStorageProof(blake2b256(hashed), []byte{0x01, 0x23}, [][]byte{hashed, long})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, value, long)
+	_, err = VerifyStorageProof(blake2b256(hashed), []byte{0x01, 0x23}, [][]byte{hashed})
+	assert.ErrorContains(t, err, "hashed value")
+}
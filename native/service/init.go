@@ -23,10 +23,34 @@ import (
 	"github.com/polynetwork/poly/native/service/cross_chain_manager"
 	"github.com/polynetwork/poly/native/service/governance/neo3_state_manager"
 	"github.com/polynetwork/poly/native/service/governance/node_manager"
+	"github.com/polynetwork/poly/native/service/governance/proposal_manager"
 	"github.com/polynetwork/poly/native/service/governance/relayer_manager"
+	"github.com/polynetwork/poly/native/service/governance/relayer_reward"
 	"github.com/polynetwork/poly/native/service/governance/side_chain_manager"
 	"github.com/polynetwork/poly/native/service/header_sync"
 	"github.com/polynetwork/poly/native/service/utils"
+
+	// side chain routers, registered in init
+	_ "github.com/polynetwork/poly/native/service/cross_chain_manager/bsc"
+	_ "github.com/polynetwork/poly/native/service/cross_chain_manager/btc"
+	_ "github.com/polynetwork/poly/native/service/cross_chain_manager/consensus_vote"
+	_ "github.com/polynetwork/poly/native/service/cross_chain_manager/cosmos"
+	_ "github.com/polynetwork/poly/native/service/cross_chain_manager/eth"
+	_ "github.com/polynetwork/poly/native/service/cross_chain_manager/ethbeacon"
+	_ "github.com/polynetwork/poly/native/service/cross_chain_manager/heco"
+	_ "github.com/polynetwork/poly/native/service/cross_chain_manager/msc"
+	_ "github.com/polynetwork/poly/native/service/cross_chain_manager/neo"
+	_ "github.com/polynetwork/poly/native/service/cross_chain_manager/neo3"
+	_ "github.com/polynetwork/poly/native/service/cross_chain_manager/neo3legacy"
+	_ "github.com/polynetwork/poly/native/service/cross_chain_manager/okex"
+	_ "github.com/polynetwork/poly/native/service/cross_chain_manager/ont"
+	_ "github.com/polynetwork/poly/native/service/cross_chain_manager/poa"
+	_ "github.com/polynetwork/poly/native/service/cross_chain_manager/polygon"
+	_ "github.com/polynetwork/poly/native/service/cross_chain_manager/quorum"
+	_ "github.com/polynetwork/poly/native/service/cross_chain_manager/rollup"
+	_ "github.com/polynetwork/poly/native/service/cross_chain_manager/substrate"
+	_ "github.com/polynetwork/poly/native/service/cross_chain_manager/zilliqa"
+	_ "github.com/polynetwork/poly/native/service/cross_chain_manager/zilliqalegacy"
 )
 
 func init() {
@@ -36,6 +60,8 @@ func init() {
 	native.Contracts[utils.NodeManagerContractAddress] = node_manager.RegisterNodeManagerContract
 	native.Contracts[utils.RelayerManagerContractAddress] = relayer_manager.RegisterRelayerManagerContract
 	native.Contracts[utils.Neo3StateManagerContractAddress] = neo3_state_manager.RegisterStateValidatorManagerContract
+	native.Contracts[utils.RelayerRewardContractAddress] = relayer_reward.RegisterRelayerRewardContract
+	native.Contracts[utils.ProposalManagerContractAddress] = proposal_manager.RegisterProposalManagerContract
 
 	config.EXTRA_INFO_HEIGHT_FORK_CHECK = true
 }
@@ -0,0 +1,112 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package router
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/polynetwork/poly/native"
+	scom "github.com/polynetwork/poly/native/service/cross_chain_manager/common"
+	hscommon "github.com/polynetwork/poly/native/service/header_sync/common"
+)
+
+// Router describes a side chain router: the handlers serving its header sync
+// and cross chain methods together with the metadata exposed to tooling.
+type Router struct {
+	Name             string
+	ID               uint64
+	Methods          []string
+	ActivationHeight uint32
+
+	HeaderSyncHandler hscommon.HeaderSyncHandler
+	ChainHandler      scom.ChainHandler
+}
+
+var (
+	routers = make(map[uint64]*Router)
+	lock    sync.RWMutex
+)
+
+// Register adds a router to the registry, it is expected to be called from the
+// init function of the router package and panics on a duplicated router id.
+func Register(r *Router) {
+	lock.Lock()
+	defer lock.Unlock()
+	if _, ok := routers[r.ID]; ok {
+		panic(fmt.Sprintf("router %d(%s) already registered", r.ID, r.Name))
+	}
+	routers[r.ID] = r
+}
+
+// GetRouter returns the registered router for id, or nil if there is none.
+func GetRouter(id uint64) *Router {
+	lock.RLock()
+	defer lock.RUnlock()
+	return routers[id]
+}
+
+// GetRouters returns all registered routers sorted by router id.
+func GetRouters() []*Router {
+	lock.RLock()
+	defer lock.RUnlock()
+	list := make([]*Router, 0, len(routers))
+	for _, r := range routers {
+		list = append(list, r)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+func getActiveRouter(native *native.NativeService, id uint64) (*Router, error) {
+	r := GetRouter(id)
+	if r == nil {
+		return nil, fmt.Errorf("not a supported router:%d", id)
+	}
+	if native.GetHeight() < r.ActivationHeight {
+		return nil, fmt.Errorf("router %d(%s) is not activated until height %d", id, r.Name, r.ActivationHeight)
+	}
+	return r, nil
+}
+
+// GetHeaderSyncHandler returns the header sync handler of router id at the current height.
+func GetHeaderSyncHandler(native *native.NativeService, id uint64) (hscommon.HeaderSyncHandler, error) {
+	r, err := getActiveRouter(native, id)
+	if err != nil {
+		return nil, err
+	}
+	if r.HeaderSyncHandler == nil {
+		return nil, fmt.Errorf("router %d(%s) does not support header sync", id, r.Name)
+	}
+	return r.HeaderSyncHandler, nil
+}
+
+// GetChainHandler returns the cross chain handler of router id at the current height.
+func GetChainHandler(native *native.NativeService, id uint64) (scom.ChainHandler, error) {
+	r, err := getActiveRouter(native, id)
+	if err != nil {
+		return nil, err
+	}
+	if r.ChainHandler == nil {
+		return nil, fmt.Errorf("router %d(%s) does not support cross chain transfer", id, r.Name)
+	}
+	return r.ChainHandler, nil
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package router
+
+import (
+	"testing"
+
+	"github.com/polynetwork/poly/common"
+	"github.com/polynetwork/poly/core/store/leveldbstore"
+	"github.com/polynetwork/poly/core/store/overlaydb"
+	"github.com/polynetwork/poly/core/types"
+	"github.com/polynetwork/poly/native"
+	scom "github.com/polynetwork/poly/native/service/cross_chain_manager/common"
+	"github.com/polynetwork/poly/native/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+type testChainHandler struct{}
+
+func (this *testChainHandler) MakeDepositProposal(service *native.NativeService) (*scom.MakeTxParam, error) {
+	return nil, nil
+}
+
+func newNativeAt(height uint32) *native.NativeService {
+	store, _ := leveldbstore.NewMemLevelDBStore()
+	cacheDB := storage.NewCacheDB(overlaydb.NewOverlayDB(store))
+	ns, _ := native.NewNativeService(cacheDB, new(types.Transaction), 0, height, common.Uint256{}, 0, nil, false)
+	return ns
+}
+
+func TestRegister(t *testing.T) {
+	Register(&Router{Name: "b", ID: 1001, ChainHandler: new(testChainHandler)})
+	Register(&Router{Name: "a", ID: 1000, ChainHandler: new(testChainHandler), ActivationHeight: 100})
+
+	assert.Equal(t, "a", GetRouter(1000).Name)
+	assert.Nil(t, GetRouter(1002))
+	assert.Panics(t, func() { Register(&Router{Name: "c", ID: 1000}) })
+
+	list := GetRouters()
+	for i := 1; i < len(list); i++ {
+		assert.True(t, list[i-1].ID < list[i].ID)
+	}
+}
+
+func TestGetHandler(t *testing.T) {
+	Register(&Router{Name: "d", ID: 2000, ChainHandler: new(testChainHandler), ActivationHeight: 100})
+
+	_, err := GetChainHandler(newNativeAt(99), 2000)
+	assert.NotNil(t, err)
+	handler, err := GetChainHandler(newNativeAt(100), 2000)
+	assert.Nil(t, err)
+	assert.NotNil(t, handler)
+
+	_, err = GetHeaderSyncHandler(newNativeAt(100), 2000)
+	assert.NotNil(t, err)
+	_, err = GetChainHandler(newNativeAt(100), 2001)
+	assert.NotNil(t, err)
+}
@@ -38,6 +38,8 @@ var (
 	NodeManagerContractAddress, _       = common.AddressParseFromBytes([]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05})
 	RelayerManagerContractAddress, _    = common.AddressParseFromBytes([]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x06})
 	Neo3StateManagerContractAddress, _  = common.AddressParseFromBytes([]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x07})
+	RelayerRewardContractAddress, _     = common.AddressParseFromBytes([]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x08})
+	ProposalManagerContractAddress, _   = common.AddressParseFromBytes([]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x09})
 
 	VOTE_ROUTER             = uint64(0)
 	BTC_ROUTER              = uint64(1)
@@ -56,4 +58,8 @@ var (
 	POLYGON_HEIMDALL_ROUTER = uint64(15)
 	POLYGON_BOR_ROUTER      = uint64(16)
 	ZILLIQA_ROUTER          = uint64(17)
+	ETH_BEACON_ROUTER       = uint64(18)
+	POA_ROUTER              = uint64(19)
+	SUBSTRATE_ROUTER        = uint64(20)
+	ROLLUP_ROUTER           = uint64(21)
 )
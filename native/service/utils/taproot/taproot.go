@@ -0,0 +1,344 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package taproot implements the parts of BIP340, BIP341 and BIP342 needed to lock
+// bitcoin in a script-path only taproot output and to verify the signatures spending it.
+package taproot
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+const (
+	LEAF_VERSION_TAPSCRIPT = byte(0xc0)
+	SIGHASH_DEFAULT        = byte(0x00)
+	SIGHASH_ALL            = byte(0x01)
+
+	KEY_SIZE     = 32
+	SIG_SIZE     = 64
+	MAX_SIGNERS  = 999
+	CONTROL_SIZE = 33
+
+	// OP_CHECKSIGADD is the BIP342 opcode taking the place of OP_UNKNOWN186
+	OP_CHECKSIGADD = byte(0xba)
+)
+
+var (
+	curve = btcec.S256()
+
+	// NUMSKey is the BIP341 internal key with no known discrete logarithm, so that
+	// outputs built on it can only be spent through the script path.
+	NUMSKey, _ = hex.DecodeString("50929b74c1a04954b78b4b6035e97a5e078a5a0f28ec96d547bfee9ace803ac0")
+)
+
+// TaggedHash returns sha256(sha256(tag) || sha256(tag) || msgs...)
+func TaggedHash(tag string, msgs ...[]byte) []byte {
+	th := sha256.Sum256([]byte(tag))
+	hasher := sha256.New()
+	hasher.Write(th[:])
+	hasher.Write(th[:])
+	for _, m := range msgs {
+		hasher.Write(m)
+	}
+	return hasher.Sum(nil)
+}
+
+func liftX(key []byte) (*big.Int, *big.Int, error) {
+	if len(key) != KEY_SIZE {
+		return nil, nil, fmt.Errorf("wrong length of x-only key: %d", len(key))
+	}
+	p := curve.Params().P
+	x := new(big.Int).SetBytes(key)
+	if x.Cmp(p) >= 0 {
+		return nil, nil, errors.New("x-only key is not a field element")
+	}
+	c := new(big.Int).Exp(x, big.NewInt(3), p)
+	c.Add(c, curve.Params().B).Mod(c, p)
+	e := new(big.Int).Add(p, big.NewInt(1))
+	y := new(big.Int).Exp(c, e.Rsh(e, 2), p)
+	if new(big.Int).Exp(y, big.NewInt(2), p).Cmp(c) != 0 {
+		return nil, nil, errors.New("x-only key is not on the curve")
+	}
+	if y.Bit(0) == 1 {
+		y.Sub(p, y)
+	}
+	return x, y, nil
+}
+
+func bytes32(n *big.Int) []byte {
+	res := make([]byte, 32)
+	b := n.Bytes()
+	copy(res[32-len(b):], b)
+	return res
+}
+
+// XOnlyPubKey returns the 32 bytes x coordinate of the public key.
+func XOnlyPubKey(pub *btcec.PublicKey) []byte {
+	return bytes32(pub.X)
+}
+
+// VerifySchnorr verifies a BIP340 signature of msg under the x-only public key.
+func VerifySchnorr(pubKey, msg, sig []byte) bool {
+	if len(sig) != SIG_SIZE {
+		return false
+	}
+	px, py, err := liftX(pubKey)
+	if err != nil {
+		return false
+	}
+	params := curve.Params()
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	if r.Cmp(params.P) >= 0 || s.Cmp(params.N) >= 0 {
+		return false
+	}
+	e := new(big.Int).SetBytes(TaggedHash("BIP0340/challenge", sig[:32], pubKey, msg))
+	e.Mod(e, params.N)
+	e.Sub(params.N, e)
+
+	sx, sy := curve.ScalarBaseMult(bytes32(s))
+	ex, ey := curve.ScalarMult(px, py, bytes32(e))
+	rx, ry := curve.Add(sx, sy, ex, ey)
+	if rx.Sign() == 0 && ry.Sign() == 0 {
+		return false
+	}
+	return ry.Bit(0) == 0 && rx.Cmp(r) == 0
+}
+
+// SignSchnorr signs msg with BIP340 using the default nonce derivation and all-zero
+// auxiliary randomness, so the signature is deterministic.
+func SignSchnorr(priv *btcec.PrivateKey, msg []byte) ([]byte, error) {
+	n := curve.Params().N
+	d := new(big.Int).Set(priv.D)
+	if d.Sign() == 0 || d.Cmp(n) >= 0 {
+		return nil, errors.New("invalid private key")
+	}
+	px, py := curve.ScalarBaseMult(bytes32(d))
+	if py.Bit(0) == 1 {
+		d.Sub(n, d)
+	}
+	pk := bytes32(px)
+	t := bytes32(d)
+	aux := TaggedHash("BIP0340/aux", make([]byte, 32))
+	for i := range t {
+		t[i] ^= aux[i]
+	}
+	k := new(big.Int).SetBytes(TaggedHash("BIP0340/nonce", t, pk, msg))
+	k.Mod(k, n)
+	if k.Sign() == 0 {
+		return nil, errors.New("nonce is zero")
+	}
+	rx, ry := curve.ScalarBaseMult(bytes32(k))
+	if ry.Bit(0) == 1 {
+		k.Sub(n, k)
+	}
+	r := bytes32(rx)
+	e := new(big.Int).SetBytes(TaggedHash("BIP0340/challenge", r, pk, msg))
+	e.Mul(e, d).Add(e, k).Mod(e, n)
+	return append(r, bytes32(e)...), nil
+}
+
+// MultisigLeaf builds the tapscript `<k1> OP_CHECKSIG <k2> OP_CHECKSIGADD ... <m> OP_NUMEQUAL`
+// which is satisfied by exactly m signatures of the x-only keys.
+func MultisigLeaf(keys [][]byte, m int) ([]byte, error) {
+	if len(keys) == 0 || len(keys) > MAX_SIGNERS {
+		return nil, fmt.Errorf("number of keys %d is out of range", len(keys))
+	}
+	if m < 1 || m > len(keys) {
+		return nil, fmt.Errorf("required signatures %d is out of range", m)
+	}
+	builder := txscript.NewScriptBuilder()
+	for i, k := range keys {
+		if _, _, err := liftX(k); err != nil {
+			return nil, fmt.Errorf("no.%d key: %v", i, err)
+		}
+		for _, prev := range keys[:i] {
+			if bytes.Equal(prev, k) {
+				return nil, fmt.Errorf("duplicate key %x", k)
+			}
+		}
+		builder.AddData(k)
+		if i == 0 {
+			builder.AddOp(txscript.OP_CHECKSIG)
+		} else {
+			builder.AddOp(OP_CHECKSIGADD)
+		}
+	}
+	builder.AddInt64(int64(m)).AddOp(txscript.OP_NUMEQUAL)
+	return builder.Script()
+}
+
+// ParseMultisigLeaf returns the keys and the number of required signatures of a
+// script built by MultisigLeaf. Any other encoding is rejected.
+func ParseMultisigLeaf(script []byte) ([][]byte, int, error) {
+	keys := make([][]byte, 0)
+	i := 0
+	for i+KEY_SIZE+2 <= len(script) && script[i] == txscript.OP_DATA_32 {
+		keys = append(keys, script[i+1:i+1+KEY_SIZE])
+		i += KEY_SIZE + 2
+	}
+	if len(keys) == 0 {
+		return nil, 0, errors.New("not a taproot multisig leaf")
+	}
+	var m int
+	switch rest := script[i:]; {
+	case len(rest) == 2 && rest[0] >= txscript.OP_1 && rest[0] <= txscript.OP_16:
+		m = int(rest[0] - txscript.OP_1 + 1)
+	case len(rest) == 3 && rest[0] == txscript.OP_DATA_1:
+		m = int(rest[1])
+	case len(rest) == 4 && rest[0] == txscript.OP_DATA_2:
+		m = int(binary.LittleEndian.Uint16(rest[1:3]))
+	default:
+		return nil, 0, errors.New("not a taproot multisig leaf")
+	}
+	expected, err := MultisigLeaf(keys, m)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !bytes.Equal(expected, script) {
+		return nil, 0, errors.New("not a canonical taproot multisig leaf")
+	}
+	return keys, m, nil
+}
+
+// IsMultisigLeaf returns true if the script is a taproot multisig leaf.
+func IsMultisigLeaf(script []byte) bool {
+	_, _, err := ParseMultisigLeaf(script)
+	return err == nil
+}
+
+// LeafHash returns the BIP341 tapleaf hash of a tapscript.
+func LeafHash(script []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(LEAF_VERSION_TAPSCRIPT)
+	_ = wire.WriteVarBytes(&buf, 0, script)
+	return TaggedHash("TapLeaf", buf.Bytes())
+}
+
+// TweakPubKey returns the x-only output key committing to the merkle root, which
+// is empty for key-path only outputs, and the parity of its y coordinate.
+func TweakPubKey(internalKey, merkleRoot []byte) ([]byte, byte, error) {
+	px, py, err := liftX(internalKey)
+	if err != nil {
+		return nil, 0, err
+	}
+	t := new(big.Int).SetBytes(TaggedHash("TapTweak", internalKey, merkleRoot))
+	if t.Cmp(curve.Params().N) >= 0 {
+		return nil, 0, errors.New("tweak exceeds the curve order")
+	}
+	tx, ty := curve.ScalarBaseMult(bytes32(t))
+	qx, qy := curve.Add(px, py, tx, ty)
+	if qx.Sign() == 0 && qy.Sign() == 0 {
+		return nil, 0, errors.New("tweaked key is infinity")
+	}
+	return bytes32(qx), byte(qy.Bit(0)), nil
+}
+
+// OutputKey returns the output key of the taproot output whose only leaf is the
+// script, with NUMSKey as internal key.
+func OutputKey(script []byte) ([]byte, byte, error) {
+	return TweakPubKey(NUMSKey, LeafHash(script))
+}
+
+// ControlBlock returns the control block to spend the only leaf of OutputKey(script).
+func ControlBlock(script []byte) ([]byte, error) {
+	_, parity, err := OutputKey(script)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{LEAF_VERSION_TAPSCRIPT | parity}, NUMSKey...), nil
+}
+
+// PayToTaprootScript returns the segwit v1 script paying to the output key.
+func PayToTaprootScript(outputKey []byte) []byte {
+	return append([]byte{txscript.OP_1, txscript.OP_DATA_32}, outputKey...)
+}
+
+// IsPayToTaproot returns true if the script is a segwit v1 output.
+func IsPayToTaproot(script []byte) bool {
+	return len(script) == KEY_SIZE+2 && script[0] == txscript.OP_1 && script[1] == txscript.OP_DATA_32
+}
+
+func sha256Of(write func(buf *bytes.Buffer)) []byte {
+	var buf bytes.Buffer
+	write(&buf)
+	h := sha256.Sum256(buf.Bytes())
+	return h[:]
+}
+
+// SigHash computes the BIP341 signature hash for the script path spending of input
+// idx with the leaf of leafHash. amts and pkScripts are the values and scripts of
+// all previous outputs spent by tx. Only SIGHASH_DEFAULT and SIGHASH_ALL are supported.
+func SigHash(tx *wire.MsgTx, idx int, hashType byte, amts []uint64, pkScripts [][]byte, leafHash []byte) ([]byte, error) {
+	if hashType != SIGHASH_DEFAULT && hashType != SIGHASH_ALL {
+		return nil, fmt.Errorf("sighash type %x is not supported", hashType)
+	}
+	if idx < 0 || idx >= len(tx.TxIn) {
+		return nil, fmt.Errorf("input index %d out of range", idx)
+	}
+	if len(amts) != len(tx.TxIn) || len(pkScripts) != len(tx.TxIn) {
+		return nil, errors.New("amounts and scripts of all previous outputs are required")
+	}
+	var msg bytes.Buffer
+	msg.WriteByte(0x00) // epoch
+	msg.WriteByte(hashType)
+	_ = binary.Write(&msg, binary.LittleEndian, tx.Version)
+	_ = binary.Write(&msg, binary.LittleEndian, tx.LockTime)
+	msg.Write(sha256Of(func(buf *bytes.Buffer) {
+		for _, in := range tx.TxIn {
+			buf.Write(in.PreviousOutPoint.Hash[:])
+			_ = binary.Write(buf, binary.LittleEndian, in.PreviousOutPoint.Index)
+		}
+	}))
+	msg.Write(sha256Of(func(buf *bytes.Buffer) {
+		for _, a := range amts {
+			_ = binary.Write(buf, binary.LittleEndian, a)
+		}
+	}))
+	msg.Write(sha256Of(func(buf *bytes.Buffer) {
+		for _, s := range pkScripts {
+			_ = wire.WriteVarBytes(buf, 0, s)
+		}
+	}))
+	msg.Write(sha256Of(func(buf *bytes.Buffer) {
+		for _, in := range tx.TxIn {
+			_ = binary.Write(buf, binary.LittleEndian, in.Sequence)
+		}
+	}))
+	msg.Write(sha256Of(func(buf *bytes.Buffer) {
+		for _, out := range tx.TxOut {
+			_ = binary.Write(buf, binary.LittleEndian, out.Value)
+			_ = wire.WriteVarBytes(buf, 0, out.PkScript)
+		}
+	}))
+	msg.WriteByte(0x02) // spend type: script path without annex
+	_ = binary.Write(&msg, binary.LittleEndian, uint32(idx))
+	msg.Write(leafHash)
+	msg.WriteByte(0x00) // key version
+	_ = binary.Write(&msg, binary.LittleEndian, uint32(0xffffffff))
+	return TaggedHash("TapSighash", msg.Bytes()), nil
+}
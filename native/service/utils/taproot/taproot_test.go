@@ -0,0 +1,86 @@
+/*
+ * Copyright (C) 2021 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package taproot
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchnorr(t *testing.T) {
+	d, _ := hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000003")
+	priv, pub := btcec.PrivKeyFromBytes(btcec.S256(), d)
+	msg := make([]byte, 32)
+	sig, err := SignSchnorr(priv, msg)
+	assert.NoError(t, err)
+	assert.Equal(t, "f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9", hex.EncodeToString(XOnlyPubKey(pub)))
+	assert.Equal(t, "e907831f80848d1069a5371b402410364bdf1c5f8307b0084c55f1ce2dca821525f66a4a85ea8b71e482a74f382d2ce5ebeee8fdb2172f477df4900d310536c0", hex.EncodeToString(sig))
+	assert.True(t, VerifySchnorr(XOnlyPubKey(pub), msg, sig))
+
+	pk, _ := hex.DecodeString("d69c3509bb99e412e68b0fe8544e72837dfa30746d8be2aa65975f29d22dc7b9")
+	msg, _ = hex.DecodeString("4df3c3f68fcc83b27e9d42c90431a72499f17875c81a599b566c9889b9696703")
+	sig, _ = hex.DecodeString("00000000000000000000003b78ce563f89a0ed9414f5aa28ad0d96d6795f9c6376afb1548af603b3eb45c9f8207dee1060cb71c04e80f593060b07d28308d7f4")
+	assert.True(t, VerifySchnorr(pk, msg, sig))
+	sig[63] ^= 1
+	assert.False(t, VerifySchnorr(pk, msg, sig))
+}
+
+func TestTweakPubKey(t *testing.T) {
+	internal, _ := hex.DecodeString("d6889cb081036e0faefa3a35157ad71086b123b2b144b649798b494c300a961d")
+	q, _, err := TweakPubKey(internal, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "53a1f6e454df1aa2776a2814a721372d6258050de330b3c6d10ee8f4e0dda343", hex.EncodeToString(q))
+}
+
+func TestMultisigLeaf(t *testing.T) {
+	for _, n := range []int{1, 3, 20, 200} {
+		keys := make([][]byte, n)
+		for i := range keys {
+			_, pub := btcec.PrivKeyFromBytes(btcec.S256(), big.NewInt(int64(i+1)).Bytes())
+			keys[i] = XOnlyPubKey(pub)
+		}
+		m := n*2/3 + 1
+		if m > n {
+			m = n
+		}
+		script, err := MultisigLeaf(keys, m)
+		assert.NoError(t, err)
+		pks, pm, err := ParseMultisigLeaf(script)
+		assert.NoError(t, err)
+		assert.Equal(t, keys, pks)
+		assert.Equal(t, m, pm)
+
+		cb, err := ControlBlock(script)
+		assert.NoError(t, err)
+		assert.Equal(t, CONTROL_SIZE, len(cb))
+		q, parity, err := OutputKey(script)
+		assert.NoError(t, err)
+		assert.Equal(t, LEAF_VERSION_TAPSCRIPT|parity, cb[0])
+		assert.True(t, IsPayToTaproot(PayToTaprootScript(q)))
+	}
+
+	_, err := MultisigLeaf([][]byte{NUMSKey, NUMSKey}, 1)
+	assert.Error(t, err)
+	script, _ := MultisigLeaf([][]byte{NUMSKey}, 1)
+	script[len(script)-1] = 0x87
+	assert.False(t, IsMultisigLeaf(script))
+}
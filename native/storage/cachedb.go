@@ -26,8 +26,11 @@ import (
 
 // CacheDB is smart contract execute cache, it contain transaction cache and block cache
 // When smart contract execute finish, need to commit transaction cache to block cache
+// When a snapshot is taken the transaction cache gets a new layer, which is merged into the one
+// below or dropped once the snapshot is done with
 type CacheDB struct {
 	memdb      *overlaydb.MemDB
+	layers     []*overlaydb.MemDB // the layers below memdb, from the bottom up
 	backend    *overlaydb.OverlayDB
 	keyScratch []byte
 }
@@ -44,6 +47,10 @@ func NewCacheDB(store *overlaydb.OverlayDB) *CacheDB {
 }
 
 func (self *CacheDB) Reset() {
+	if len(self.layers) != 0 {
+		self.memdb = self.layers[0]
+		self.layers = nil
+	}
 	self.memdb.Reset()
 }
 
@@ -63,13 +70,42 @@ func makePrefixedKey(dst []byte, prefix byte, key []byte) []byte {
 
 // Commit current transaction cache to block cache
 func (self *CacheDB) Commit() {
-	self.memdb.ForEach(func(key, val []byte) {
+	commit := func(key, val []byte) {
 		if len(val) == 0 {
 			self.backend.Delete(key)
 		} else {
 			self.backend.Put(key, val)
 		}
-	})
+	}
+	for _, layer := range self.layers {
+		layer.ForEach(commit)
+	}
+	self.memdb.ForEach(commit)
+}
+
+// Snapshot puts a new layer on top of the transaction cache and returns its id
+func (self *CacheDB) Snapshot() int {
+	self.layers = append(self.layers, self.memdb)
+	self.memdb = overlaydb.NewMemDB(initCap, initKvNum)
+	return len(self.layers)
+}
+
+// RevertToSnapshot drops the changes made to the transaction cache since snapshot was taken
+func (self *CacheDB) RevertToSnapshot(snapshot int) {
+	self.memdb = self.layers[snapshot-1]
+	self.layers = self.layers[:snapshot-1]
+}
+
+// CommitSnapshot keeps the changes made to the transaction cache since snapshot was taken,
+// they are merged into the layer below the snapshot
+func (self *CacheDB) CommitSnapshot(snapshot int) {
+	below := self.layers[snapshot-1]
+	for _, layer := range self.layers[snapshot:] {
+		layer.ForEach(below.Put)
+	}
+	self.memdb.ForEach(below.Put)
+	self.memdb = below
+	self.layers = self.layers[:snapshot-1]
 }
 
 func (self *CacheDB) Put(key []byte, value []byte) {
@@ -88,6 +124,9 @@ func (self *CacheDB) Get(key []byte) ([]byte, error) {
 func (self *CacheDB) get(prefix common.DataEntryPrefix, key []byte) ([]byte, error) {
 	self.keyScratch = makePrefixedKey(self.keyScratch, byte(prefix), key)
 	value, unknown := self.memdb.Get(self.keyScratch)
+	for i := len(self.layers) - 1; unknown && i >= 0; i-- {
+		value, unknown = self.layers[i].Get(self.keyScratch)
+	}
 	if unknown {
 		v, err := self.backend.Get(self.keyScratch)
 		if err != nil {
@@ -114,10 +153,13 @@ func (self *CacheDB) NewIterator(key []byte) common.StoreIterator {
 	pkey[0] = byte(common.ST_STORAGE)
 	copy(pkey[1:], key)
 	prefixRange := util.BytesPrefix(pkey)
-	backIter := self.backend.NewIterator(pkey)
+	var iter common.StoreIterator = self.backend.NewIterator(pkey)
+	for _, layer := range self.layers {
+		iter = overlaydb.NewJoinIter(layer.NewIterator(prefixRange), iter)
+	}
 	memIter := self.memdb.NewIterator(prefixRange)
 
-	return &Iter{overlaydb.NewJoinIter(memIter, backIter)}
+	return &Iter{overlaydb.NewJoinIter(memIter, iter)}
 }
 
 type Iter struct {
@@ -82,3 +82,70 @@ func TestCacheDB(t *testing.T) {
 	}
 
 }
+
+func TestCacheDBSnapshot(t *testing.T) {
+	memback, _ := leveldbstore.NewMemLevelDBStore()
+	cache := NewCacheDB(overlaydb.NewOverlayDB(memback))
+	cache.Put([]byte("a"), []byte("1"))
+	cache.Put([]byte("b"), []byte("2"))
+	cache.Delete([]byte("b"))
+
+	snapshot := cache.Snapshot()
+	cache.Put([]byte("a"), []byte("3"))
+	cache.Put([]byte("b"), []byte("4"))
+	cache.Put([]byte("c"), []byte("5"))
+	cache.RevertToSnapshot(snapshot)
+
+	value, err := cache.Get([]byte("a"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("1"), value)
+	value, err = cache.Get([]byte("b"))
+	assert.Nil(t, err)
+	assert.Nil(t, value)
+	value, err = cache.Get([]byte("c"))
+	assert.Nil(t, err)
+	assert.Nil(t, value)
+}
+
+func TestCacheDBNestedSnapshot(t *testing.T) {
+	memback, _ := leveldbstore.NewMemLevelDBStore()
+	overlay := overlaydb.NewOverlayDB(memback)
+	cache := NewCacheDB(overlay)
+	cache.Put([]byte("a"), []byte("1"))
+
+	outer := cache.Snapshot()
+	cache.Put([]byte("b"), []byte("2"))
+	inner := cache.Snapshot()
+	cache.Put([]byte("c"), []byte("3"))
+	cache.Delete([]byte("a"))
+	cache.RevertToSnapshot(inner)
+
+	inner = cache.Snapshot()
+	cache.Put([]byte("d"), []byte("4"))
+	cache.Delete([]byte("b"))
+	value, err := cache.Get([]byte("a"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("1"), value)
+
+	// the iterator joins every layer
+	iter := cache.NewIterator(nil)
+	kvs := make(map[string]string)
+	for has := iter.First(); has; has = iter.Next() {
+		kvs[string(iter.Key())] = string(iter.Value())
+	}
+	iter.Release()
+	assert.Equal(t, map[string]string{"a": "1", "d": "4"}, kvs)
+
+	cache.CommitSnapshot(inner)
+	cache.CommitSnapshot(outer)
+
+	cache.Commit()
+	for key, val := range kvs {
+		raw, err := overlay.Get(append([]byte{byte(common.ST_STORAGE)}, key...))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte(val), raw)
+	}
+	raw, err := overlay.Get(append([]byte{byte(common.ST_STORAGE)}, 'c'))
+	assert.Nil(t, err)
+	assert.Nil(t, raw)
+}